@@ -0,0 +1,112 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// Error codes, so clients can tell failures apart without parsing messages.
+const (
+	CodeBadRequest   = "bad_request"
+	CodeUnauthorized = "unauthorized"
+	CodeForbidden    = "forbidden"
+	CodeNotFound     = "not_found"
+	CodeConflict     = "conflict"
+	CodeNotAllowed   = "method_not_allowed"
+	CodeTooLarge     = "request_too_large"
+	CodeInvalid      = "validation_failed"
+	CodeInternal     = "internal_error"
+)
+
+const internalMessage = "something went wrong, please try again"
+
+// FieldError is what is wrong with one field of a request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is a failure a client may see. Status is the HTTP status it is sent
+// with; RequestId is set when it is sent, to find the request in the logs.
+type Error struct {
+	Status    int          `json:"-"`
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Fields    []FieldError `json:"fields,omitempty"`
+	RequestId string       `json:"request_id,omitempty"`
+}
+
+// ErrorResponse is the body of every failed request.
+type ErrorResponse struct {
+	Error Error `json:"error"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func NewError(status int, code string, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func BadRequest(message string) *Error {
+	return NewError(http.StatusBadRequest, CodeBadRequest, message)
+}
+
+func Unauthorized(message string) *Error {
+	return NewError(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return NewError(http.StatusForbidden, CodeForbidden, message)
+}
+
+func NotFound(message string) *Error {
+	return NewError(http.StatusNotFound, CodeNotFound, message)
+}
+
+func Conflict(message string) *Error {
+	return NewError(http.StatusConflict, CodeConflict, message)
+}
+
+func TooLarge(message string) *Error {
+	return NewError(http.StatusRequestEntityTooLarge, CodeTooLarge, message)
+}
+
+// Unprocessable is a well formed request that breaks a rule. The fields at
+// fault are set on it when they are known.
+func Unprocessable(message string) *Error {
+	return NewError(http.StatusUnprocessableEntity, CodeInvalid, message)
+}
+
+// Invalid is a request with one field at fault.
+func Invalid(field string, message string) *Error {
+	e := Unprocessable(message)
+	e.Fields = []FieldError{{Field: field, Message: message}}
+	return e
+}
+
+// Internal is the error a client sees for any failure that is not its own.
+func Internal() *Error {
+	return NewError(http.StatusInternalServerError, CodeInternal, internalMessage)
+}
+
+// Fail answers the request with err if it is an *Error. Any other error is
+// logged and answered as an internal error, so that database and driver
+// messages never reach the client.
+func Fail(w http.ResponseWriter, r *http.Request, err error) {
+
+	requestId := RequestIDFrom(r.Context())
+
+	var clientErr *Error
+	if !errors.As(err, &clientErr) {
+		logger.WithField("request_id", requestId).WithField("err", err.Error()).Error("Error handling request")
+		clientErr = Internal()
+	}
+
+	response := ErrorResponse{Error: *clientErr}
+	response.Error.RequestId = requestId
+	Response(w, clientErr.Status, response)
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFail(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		body   string
+	}{
+		{"when the error is a client error", NotFound("booking not found"), http.StatusNotFound,
+			`{"error":{"code":"not_found","message":"booking not found","request_id":"req-1"}}`},
+		{"when the client error is wrapped", fmt.Errorf("cancelling: %w", Conflict("slot not empty")), http.StatusConflict,
+			`{"error":{"code":"conflict","message":"slot not empty","request_id":"req-1"}}`},
+		{"when fields are at fault", Invalid("date", "invalid date"), http.StatusUnprocessableEntity,
+			`{"error":{"code":"validation_failed","message":"invalid date","fields":[{"field":"date","message":"invalid date"}],"request_id":"req-1"}}`},
+		{"when the error is internal", errors.New(`pq: relation "bookings" does not exist`), http.StatusInternalServerError,
+			`{"error":{"code":"internal_error","message":"something went wrong, please try again","request_id":"req-1"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/bookings", nil)
+			r.Header.Set(RequestIDHeader, "req-1")
+			w := httptest.NewRecorder()
+
+			RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Fail(w, r, tt.err)
+			})).ServeHTTP(w, r)
+
+			assert.Equal(t, tt.status, w.Result().StatusCode)
+			assert.Equal(t, tt.body, w.Body.String())
+			assert.Equal(t, "req-1", w.Header().Get(RequestIDHeader))
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var got string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = RequestIDFrom(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil)
+	r.Header.Set(RequestIDHeader, "not a usable id\n")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	assert.Len(t, got, 32)
+	assert.Equal(t, got, w.Header().Get(RequestIDHeader))
+}
@@ -4,18 +4,35 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 var (
-	appName string
-	appPort int
+	appName              string
+	appPort              int
+	waitlistOfferMinutes int
 )
 
 func Load() {
 	viper.SetDefault("APP_NAME", "app")
 	viper.SetDefault("APP_PORT", "8002")
+	viper.SetDefault("APP_ENV", "production")
+	viper.SetDefault("WAITLIST_OFFER_MINUTES", "15")
+	viper.SetDefault("PLATFORM_COMMISSION_BP", "1000")
+	viper.SetDefault("SETTLEMENT_INTERVAL_HOURS", "24")
+	viper.SetDefault("ADMIN_FARMER_IDS", "")
+	viper.SetDefault("CLAIM_FILING_DAYS", "7")
+	viper.SetDefault("CLAIM_RESPONSE_HOURS", "72")
+	viper.SetDefault("EMAIL_PROVIDER", "fake")
+	viper.SetDefault("SMS_PROVIDER", "fake")
+	viper.SetDefault("NOTIFICATION_DEFAULT_CHANNELS", "in_app,email")
+	viper.SetDefault("BOOKING_REMINDER_HOURS", "24")
+	viper.SetDefault("NO_SHOW_GRACE_MINUTES", "60")
+	viper.SetDefault("NO_SHOW_LOOKBACK_HOURS", "24")
+	viper.SetDefault("REDOC_INTEGRITY", "")
 
 	viper.SetConfigName("application")
 	viper.SetConfigType("yaml")
@@ -40,6 +57,122 @@ func AppPort() int {
 	return appPort
 }
 
+// Development reports whether the server runs on a developer's machine,
+// where webhooks may be posted over plain http.
+func Development() bool {
+	return ReadEnvString("APP_ENV") == "development"
+}
+
+// WaitlistOfferWindow is how long a waitlisted farmer has to confirm a booking
+// that was made for them automatically.
+func WaitlistOfferWindow() time.Duration {
+	if waitlistOfferMinutes == 0 {
+		waitlistOfferMinutes = ReadEnvInt("WAITLIST_OFFER_MINUTES")
+	}
+	return time.Duration(waitlistOfferMinutes) * time.Minute
+}
+
+// PaymentProvider names the payment gateway invoices are paid through. It has
+// no default, so a server is never left taking payments on the fake by
+// accident.
+func PaymentProvider() string {
+	return ReadEnvString("PAYMENT_PROVIDER")
+}
+
+// PaymentWebhookSecret is the key payment provider webhooks are signed with. It
+// has no default, as a known key would let anyone forge a payment.
+func PaymentWebhookSecret() string {
+	return ReadEnvString("PAYMENT_WEBHOOK_SECRET")
+}
+
+// PlatformCommission is the platform's cut of the taxable value of every paid
+// invoice, in basis points.
+func PlatformCommission() uint {
+	return uint(ReadEnvInt("PLATFORM_COMMISSION_BP"))
+}
+
+// SettlementInterval is how often owners are paid what they earned.
+func SettlementInterval() time.Duration {
+	return time.Duration(ReadEnvInt("SETTLEMENT_INTERVAL_HOURS")) * time.Hour
+}
+
+// AdminFarmerIds are the farmers who may manage platform settings such as
+// promo codes, read from a comma separated list.
+func AdminFarmerIds() (ids []uint) {
+	for _, field := range strings.Split(ReadEnvString("ADMIN_FARMER_IDS"), ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			panic(fmt.Sprintf("key ADMIN_FARMER_IDS has an invalid farmer id %q", field))
+		}
+		ids = append(ids, uint(id))
+	}
+	return
+}
+
+// ClaimFilingWindow is how many days after a booking's last slot its owner may
+// file a damage claim.
+func ClaimFilingWindow() int {
+	return ReadEnvInt("CLAIM_FILING_DAYS")
+}
+
+// ClaimResponseWindow is how long a renter has to accept or dispute a claim
+// before it goes to the admins.
+func ClaimResponseWindow() time.Duration {
+	return time.Duration(ReadEnvInt("CLAIM_RESPONSE_HOURS")) * time.Hour
+}
+
+// EmailProvider names the service emails are sent through, "fake" to log
+// them, or is empty to send none.
+func EmailProvider() string {
+	return ReadEnvString("EMAIL_PROVIDER")
+}
+
+// SMSProvider names the service text messages are sent through, "fake" to log
+// them, or is empty to send none.
+func SMSProvider() string {
+	return ReadEnvString("SMS_PROVIDER")
+}
+
+// NotificationDefaultChannels are the channels a farmer is notified on until
+// they choose otherwise, read from a comma separated list.
+func NotificationDefaultChannels() (channels []string) {
+	for _, field := range strings.Split(ReadEnvString("NOTIFICATION_DEFAULT_CHANNELS"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			channels = append(channels, field)
+		}
+	}
+	return
+}
+
+// BookingReminderHours is how long before a booking starts its renter is
+// reminded of it.
+func BookingReminderHours() int {
+	return ReadEnvInt("BOOKING_REMINDER_HOURS")
+}
+
+// NoShowGraceMinutes is how long after a booking's first slot starts without
+// a check-in it is taken as a no-show.
+func NoShowGraceMinutes() int {
+	return ReadEnvInt("NO_SHOW_GRACE_MINUTES")
+}
+
+// NoShowLookbackHours is how far back bookings are looked at for no-shows, so
+// that bookings from before check-ins were recorded are never marked.
+func NoShowLookbackHours() int {
+	return ReadEnvInt("NO_SHOW_LOOKBACK_HOURS")
+}
+
+// RedocIntegrity is the Subresource Integrity digest, e.g. "sha384-...", of
+// the pinned Redoc bundle the API docs page loads. The bundle is loaded
+// unchecked when it is empty.
+func RedocIntegrity() string {
+	return ReadEnvString("REDOC_INTEGRITY")
+}
+
 func ReadEnvInt(key string) int {
 	checkIfSet(key)
 	v, err := strconv.Atoi(viper.GetString(key))
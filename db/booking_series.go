@@ -0,0 +1,149 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+
+	"github.com/lib/pq"
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	addBookingSeriesQuery     = "INSERT INTO booking_series (farmer_id, machine_id, start_date, frequency, interval, until, count, slots) VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::date, NULLIF($7, 0), $8) RETURNING id"
+	cancelSeriesQuery         = "UPDATE booking_series SET status = 'cancelled' WHERE id = $1 and farmer_id = $2 RETURNING id"
+	cancelSeriesBookingsQuery = "UPDATE bookings SET status = 'cancelled' WHERE series_id = $1 and status NOT IN ('cancelled', 'no_show') and NOT EXISTS (SELECT 1 FROM slots_booked WHERE booking_id = bookings.id and date < CURRENT_DATE) and NOT EXISTS (SELECT 1 FROM handovers WHERE booking_id = bookings.id) RETURNING id, machine_id, (SELECT owner_id FROM machines WHERE id = bookings.machine_id)"
+)
+
+// BookSeries adds the series and books each of its dates in one transaction,
+// so that a failure leaves neither the series nor any of its occurrences.
+// Dates whose slots are taken are reported as conflicts. When every date
+// conflicts nothing is kept, and ErrSlotNotEmpty is returned with the
+// conflicts.
+func (s *pgStore) BookSeries(ctx context.Context, series *domain.BookingSeries, dates []string) (rsp domain.RecurringBookingResponse, err error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, addBookingSeriesQuery, series.FarmerId, series.MachineId, series.StartDate, series.Frequency, series.Interval, series.Until, series.Count, pq.Array(series.Slots)).Scan(&series.Id)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error inserting booking series")
+		return
+	}
+
+	for _, date := range dates {
+		bookedSlots, err := getBookedSlots(ctx, tx, series.MachineId, date)
+		if err != nil {
+			return rsp, err
+		}
+
+		var conflicting []uint
+		for _, slot := range series.Slots {
+			if _, ok := bookedSlots[slot]; ok {
+				conflicting = append(conflicting, slot)
+			}
+		}
+		if len(conflicting) > 0 {
+			rsp.Conflicts = append(rsp.Conflicts, domain.OccurrenceConflict{Date: date, ConflictingSlots: conflicting})
+			continue
+		}
+
+		// a clash is found before anything is written, so the transaction
+		// can go on to the next date
+		booking, err := book(ctx, tx, domain.NewBookingRequest{
+			MachineId: series.MachineId,
+			Date:      date,
+			Slots:     series.Slots,
+			FarmerId:  series.FarmerId,
+			SeriesId:  &series.Id,
+		})
+		if err == ErrSlotNotEmpty {
+			rsp.Conflicts = append(rsp.Conflicts, domain.OccurrenceConflict{Date: date, ConflictingSlots: series.Slots})
+			continue
+		}
+		if err != nil {
+			return rsp, err
+		}
+		rsp.Booked = append(rsp.Booked, booking)
+	}
+
+	if len(rsp.Booked) == 0 {
+		return rsp, ErrSlotNotEmpty
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return
+	}
+	rsp.SeriesId = series.Id
+	return
+}
+
+// CancelBookingSeries cancels every occurrence of the series that has not
+// started yet, refunds and releases the deposit of each like CancelBooking
+// and tells the owner of each. Occurrences in the past are left untouched.
+func (s *pgStore) CancelBookingSeries(ctx context.Context, seriesId uint, farmerId uint) (cancelled []domain.CancelledBooking, err error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, cancelSeriesQuery, seriesId, farmerId).Scan(&seriesId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error cancelling booking series")
+		return
+	}
+
+	rows, err := tx.QueryContext(ctx, cancelSeriesBookingsQuery, seriesId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error cancelling series bookings")
+		return
+	}
+
+	for rows.Next() {
+		var booking domain.CancelledBooking
+		err = rows.Scan(&booking.BookingId, &booking.MachineId, &booking.OwnerId)
+		if err != nil {
+			logger.WithField("err", err.Error()).Error("Error scanning cancelled bookings")
+			rows.Close()
+			return
+		}
+		cancelled = append(cancelled, booking)
+	}
+	rows.Close()
+
+	for i, booking := range cancelled {
+		err = addOutboxEvent(ctx, tx, domain.NewBookingEvent(domain.EventBookingCancelled, booking.OwnerId, booking.BookingId, booking.MachineId))
+		if err != nil {
+			return
+		}
+
+		cancelled[i].Refund, err = refundCancelledBooking(ctx, tx, booking.BookingId)
+		if err != nil {
+			return
+		}
+
+		cancelled[i].Deposit, err = settleBookingDeposit(ctx, tx, booking.BookingId, false, cancelledNote)
+		if err != nil {
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return
+	}
+
+	for i := range cancelled {
+		err = s.getBookingSlots(ctx, &cancelled[i])
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func (s *DbTestSuite) Test_pgStore_BookSeries() {
+	t := s.T()
+
+	series := domain.BookingSeries{FarmerId: 9, MachineId: 1, StartDate: "2026-11-07", Frequency: domain.RecurrenceWeekly, Interval: 1, Count: 2, Slots: []uint{7}}
+	dates := []string{"2026-11-07", "2026-11-14"}
+	expectBookedSlots := func(date string, slots ...uint) {
+		rows := sqlxmock.NewRows([]string{"slot_id"})
+		for _, slot := range slots {
+			rows.AddRow(slot)
+		}
+		s.mock.ExpectQuery("select s.slot_id from slots_booked").WithArgs(1, date).WillReturnRows(rows)
+	}
+
+	s.Run("books the free occurrences with the series", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("INSERT INTO booking_series").WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(5))
+		expectBookedSlots("2026-11-07")
+		s.expectBookLine(1, 20, 300, 11, "2026-11-07", 7)
+		s.expectCreateInvoice(31, 7, 20, "", 30000, 1)
+		expectBookedSlots("2026-11-14", 7)
+		s.mock.ExpectCommit()
+
+		got, err := s.repo.BookSeries(context.TODO(), &series, dates)
+		require.NoError(t, err)
+		assert.Equal(t, uint(5), got.SeriesId)
+		require.Len(t, got.Booked, 1)
+		assert.Equal(t, uint(11), got.Booked[0].BookingId)
+		assert.Equal(t, []domain.OccurrenceConflict{{Date: "2026-11-14", ConflictingSlots: []uint{7}}}, got.Conflicts)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("keeps nothing when every occurrence clashes", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("INSERT INTO booking_series").WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(5))
+		expectBookedSlots("2026-11-07", 7)
+		expectBookedSlots("2026-11-14", 7)
+		s.mock.ExpectRollback()
+
+		got, err := s.repo.BookSeries(context.TODO(), &series, dates)
+		assert.Equal(t, ErrSlotNotEmpty, err)
+		assert.Zero(t, got.SeriesId)
+		assert.Len(t, got.Conflicts, 2)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+}
+
+func (s *DbTestSuite) Test_pgStore_CancelBookingSeries() {
+	t := s.T()
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("UPDATE booking_series SET status = 'cancelled'").WithArgs(5, 1).WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(5))
+	s.mock.ExpectQuery("UPDATE bookings SET status = 'cancelled' WHERE series_id = \\$1").WithArgs(5).WillReturnRows(sqlxmock.NewRows([]string{"id", "machine_id", "owner_id"}).AddRow(10, 2, 20))
+	s.expectOutboxEvent(domain.EventBookingCancelled, 20)
+	s.expectNoWalletRefund(10)
+	s.expectReleaseHeldDeposit(10, "booking cancelled")
+	s.mock.ExpectCommit()
+	s.mock.ExpectQuery("SELECT (.+) FROM slots_booked WHERE booking_id = \\$1").WithArgs(10).WillReturnRows(sqlxmock.NewRows([]string{"date", "slot_id"}).AddRow("2026-11-14", 7))
+
+	got, err := s.repo.CancelBookingSeries(context.TODO(), 5, 1)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.NotNil(t, got[0].Deposit)
+	assert.Equal(t, domain.DepositReleased, got[0].Deposit.Status)
+	got[0].Deposit = nil
+	assert.Equal(t, []domain.CancelledBooking{{BookingId: 10, MachineId: 2, OwnerId: 20, Date: "2026-11-14", Slots: []uint{7}}}, got)
+	assert.NoError(t, s.mock.ExpectationsWereMet())
+}
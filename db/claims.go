@@ -0,0 +1,288 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	claimColumns = "id, booking_id, owner_id, farmer_id, amount AS \"amount.minor\", currency AS \"amount.currency\", description, evidence, status, response, response_evidence, respond_by, " +
+		"approved AS \"approved.minor\", currency AS \"approved.currency\", from_deposit AS \"from_deposit.minor\", currency AS \"from_deposit.currency\", invoice_id, decision_note, decided_by, created_at, updated_at"
+	addClaimQuery              = "INSERT INTO claims (booking_id, owner_id, farmer_id, amount, currency, description, evidence, respond_by) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT (booking_id) DO NOTHING RETURNING id, status, created_at, updated_at"
+	addClaimEventQuery         = "INSERT INTO claim_events (claim_id, status, actor_id, note) VALUES ($1, $2, $3, $4)"
+	getClaimsQuery             = "SELECT " + claimColumns + " FROM claims WHERE owner_id = $1 or farmer_id = $1 ORDER BY id DESC"
+	getClaimsByStatusQuery     = "SELECT " + claimColumns + " FROM claims WHERE status = ANY($1) ORDER BY respond_by, id"
+	getClaimQuery              = "SELECT " + claimColumns + " FROM claims WHERE id = $1"
+	getBookingClaimQuery       = "SELECT " + claimColumns + " FROM claims WHERE booking_id = $1"
+	getClaimEventsQuery        = "SELECT id, claim_id, status, actor_id, note, created_at FROM claim_events WHERE claim_id = $1 ORDER BY id"
+	disputeClaimQuery          = "UPDATE claims SET status = 'disputed', response = $2, response_evidence = $3, updated_at = NOW() WHERE id = $1 and status = 'open' and respond_by > NOW() RETURNING id"
+	lockClaimQuery             = "SELECT " + claimColumns + " FROM claims WHERE id = $1 and status = ANY($2) FOR UPDATE"
+	lockBookingDepositQuery    = "SELECT " + depositColumns + " FROM deposits WHERE booking_id = $1 and status = 'held' FOR UPDATE"
+	decideClaimQuery           = "UPDATE claims SET status = $2, approved = $3, from_deposit = $4, invoice_id = $5, decision_note = $6, decided_by = $7, updated_at = NOW() WHERE id = $1 RETURNING updated_at"
+	escalateOverdueClaimsQuery = "WITH escalated AS (UPDATE claims SET status = 'escalated', updated_at = NOW() WHERE status = 'open' and respond_by <= NOW() RETURNING *), " +
+		"events AS (INSERT INTO claim_events (claim_id, status, note) SELECT id, 'escalated', 'renter did not respond in time' FROM escalated) " +
+		"SELECT " + claimColumns + " FROM escalated ORDER BY id"
+)
+
+// claimRow reads the evidence columns of a claim, which the domain type keeps
+// as plain slices.
+type claimRow struct {
+	domain.Claim
+	Evidence         pq.StringArray `db:"evidence"`
+	ResponseEvidence pq.StringArray `db:"response_evidence"`
+}
+
+func (row claimRow) claim() (claim domain.Claim) {
+	claim = row.Claim
+	claim.Evidence = []string(row.Evidence)
+	claim.ResponseEvidence = []string(row.ResponseEvidence)
+	return
+}
+
+func claims(rows []claimRow) (claims []domain.Claim) {
+	for _, row := range rows {
+		claims = append(claims, row.claim())
+	}
+	return
+}
+
+// AddClaim files the claim. It returns sql.ErrNoRows if the booking already
+// has one.
+func (s *pgStore) AddClaim(ctx context.Context, claim *domain.Claim) (err error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, addClaimQuery, claim.BookingId, claim.OwnerId, claim.FarmerId, claim.Amount.Minor, claim.Amount.Currency, claim.Description, pq.StringArray(claim.Evidence), claim.RespondBy).Scan(&claim.Id, &claim.Status, &claim.CreatedAt, &claim.UpdatedAt)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error inserting claim")
+		return
+	}
+	claim.Approved = domain.NewMoney(0, claim.Amount.Currency)
+	claim.FromDeposit = domain.NewMoney(0, claim.Amount.Currency)
+
+	err = addClaimEvent(ctx, tx, claim.Id, claim.Status, &claim.OwnerId, claim.Description)
+	if err != nil {
+		return
+	}
+
+	return tx.Commit()
+}
+
+func addClaimEvent(ctx context.Context, tx *sqlx.Tx, claimId uint, status string, actorId *uint, note string) (err error) {
+
+	_, err = tx.ExecContext(ctx, addClaimEventQuery, claimId, status, actorId, note)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error adding claim event")
+		return
+	}
+
+	return
+}
+
+// GetClaims returns the claims the farmer filed as an owner or faces as a
+// renter, newest first.
+func (s *pgStore) GetClaims(ctx context.Context, farmerId uint) (found []domain.Claim, err error) {
+
+	var rows []claimRow
+	err = s.db.SelectContext(ctx, &rows, getClaimsQuery, farmerId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting claims")
+		return
+	}
+
+	return claims(rows), nil
+}
+
+// GetClaimsByStatus returns the claims in any of the statuses, the ones whose
+// response deadline passed first.
+func (s *pgStore) GetClaimsByStatus(ctx context.Context, statuses []string) (found []domain.Claim, err error) {
+
+	var rows []claimRow
+	err = s.db.SelectContext(ctx, &rows, getClaimsByStatusQuery, pq.StringArray(statuses))
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting claims by status")
+		return
+	}
+
+	return claims(rows), nil
+}
+
+// GetClaim returns the claim with its history.
+func (s *pgStore) GetClaim(ctx context.Context, claimId uint) (claim domain.Claim, err error) {
+
+	var row claimRow
+	err = s.db.GetContext(ctx, &row, getClaimQuery, claimId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting claim")
+		return
+	}
+	claim = row.claim()
+
+	err = s.db.SelectContext(ctx, &claim.Events, getClaimEventsQuery, claimId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting claim events")
+		return
+	}
+
+	return
+}
+
+func (s *pgStore) GetBookingClaim(ctx context.Context, bookingId uint) (claim domain.Claim, err error) {
+
+	var row claimRow
+	err = s.db.GetContext(ctx, &row, getBookingClaimQuery, bookingId)
+	if err != nil {
+		return
+	}
+
+	return row.claim(), nil
+}
+
+// DisputeClaim records the renter's side and hands the claim to the admins.
+// It returns sql.ErrNoRows if the claim is not open or its response deadline
+// passed.
+func (s *pgStore) DisputeClaim(ctx context.Context, claimId uint, farmerId uint, response string, evidence []string) (err error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, disputeClaimQuery, claimId, response, pq.StringArray(evidence)).Scan(&claimId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error disputing claim")
+		return
+	}
+
+	err = addClaimEvent(ctx, tx, claimId, domain.ClaimDisputed, &farmerId, response)
+	if err != nil {
+		return
+	}
+
+	return tx.Commit()
+}
+
+// DecideClaim closes a claim that is in one of the statuses from. An approved
+// amount is captured from the booking's held deposit as far as it goes, and
+// the rest is billed to the renter on a new invoice. A rejected claim releases
+// the deposit. It returns sql.ErrNoRows if the claim is not in any of the
+// statuses.
+func (s *pgStore) DecideClaim(ctx context.Context, claimId uint, from []string, decision domain.ClaimDecision) (claim domain.Claim, err error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	var row claimRow
+	err = tx.GetContext(ctx, &row, lockClaimQuery, claimId, pq.StringArray(from))
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error locking claim")
+		return
+	}
+	claim = row.claim()
+
+	var deposit domain.Deposit
+	err = tx.GetContext(ctx, &deposit, lockBookingDepositQuery, claim.BookingId)
+	if err != nil && err != sql.ErrNoRows {
+		logger.WithField("err", err.Error()).Error("Error locking booking deposit")
+		return
+	}
+	held := err == nil
+	note := fmt.Sprintf("claim %d", claim.Id)
+
+	claim.Status = decision.Status
+	claim.DecisionNote = decision.Note
+	claim.DecidedBy = &decision.ActorId
+	claim.Approved = domain.NewMoney(0, claim.Amount.Currency)
+	claim.FromDeposit = domain.NewMoney(0, claim.Amount.Currency)
+
+	switch {
+	case decision.Status == domain.ClaimApproved:
+		claim.Approved = decision.Amount
+		if held {
+			claim.FromDeposit = deposit.Amount
+			if claim.FromDeposit.Minor > claim.Approved.Minor {
+				claim.FromDeposit = claim.Approved
+			}
+			err = captureDeposit(ctx, tx, deposit.Id, claim.FromDeposit, note)
+			if err != nil {
+				return
+			}
+		}
+
+		if rest := claim.Approved.Sub(claim.FromDeposit); rest.Minor > 0 {
+			invoice := domain.Invoice{
+				BookingId:    claim.BookingId,
+				FarmerId:     claim.FarmerId,
+				OwnerId:      claim.OwnerId,
+				DateGenrated: time.Now().Format("2006-01-02"),
+				LineItems:    []domain.InvoiceLineItem{damageItem(claim, rest)},
+			}
+			err = createInvoice(ctx, tx, &invoice)
+			if err != nil {
+				return
+			}
+			claim.InvoiceId = &invoice.Id
+		}
+
+	case held:
+		err = releaseDeposit(ctx, tx, deposit.Id, note+" rejected")
+		if err != nil {
+			return
+		}
+	}
+
+	err = tx.QueryRowContext(ctx, decideClaimQuery, claim.Id, claim.Status, claim.Approved.Minor, claim.FromDeposit.Minor, claim.InvoiceId, claim.DecisionNote, claim.DecidedBy).Scan(&claim.UpdatedAt)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error deciding claim")
+		return
+	}
+
+	err = addClaimEvent(ctx, tx, claim.Id, claim.Status, claim.DecidedBy, claim.DecisionNote)
+	if err != nil {
+		return
+	}
+
+	err = tx.Commit()
+	return
+}
+
+func damageItem(claim domain.Claim, amount domain.Money) domain.InvoiceLineItem {
+	bookingId := claim.BookingId
+	return domain.InvoiceLineItem{
+		BookingId:   &bookingId,
+		Kind:        domain.LineItemDamage,
+		Description: fmt.Sprintf("Damage claim %d, beyond the deposit", claim.Id),
+		Quantity:    1,
+		UnitAmount:  amount,
+		Amount:      amount,
+	}
+}
+
+// EscalateOverdueClaims hands the open claims whose renter did not respond in
+// time to the admins, and returns them.
+func (s *pgStore) EscalateOverdueClaims(ctx context.Context) (escalated []domain.Claim, err error) {
+
+	var rows []claimRow
+	err = s.db.SelectContext(ctx, &rows, escalateOverdueClaimsQuery)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error escalating overdue claims")
+		return
+	}
+
+	return claims(rows), nil
+}
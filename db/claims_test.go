@@ -0,0 +1,148 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+var claimColumnNames = []string{"id", "booking_id", "owner_id", "farmer_id", "amount.minor", "amount.currency", "description", "evidence", "status", "response", "response_evidence", "respond_by", "approved.minor", "approved.currency", "from_deposit.minor", "from_deposit.currency", "invoice_id", "decision_note", "decided_by", "created_at", "updated_at"}
+
+// expectLockClaim returns a claim of 8000 rupees for booking 11.
+func (s *DbTestSuite) expectLockClaim(status string) {
+	now := time.Now()
+	s.mock.ExpectQuery("SELECT (.+) FROM claims WHERE id = \\$1 and status = ANY\\(\\$2\\) FOR UPDATE").WithArgs(3, sqlxmock.AnyArg()).WillReturnRows(
+		sqlxmock.NewRows(claimColumnNames).AddRow(3, 11, 20, 9, 800000, "INR", "cracked bonnet", "{https://photos.example/1.jpg}", status, "not me", "{}", now, 0, "INR", 0, "INR", nil, "", nil, now, now))
+}
+
+func (s *DbTestSuite) expectLockBookingDeposit(amount int64) {
+	s.mock.ExpectQuery("SELECT (.+) FROM deposits WHERE booking_id = \\$1 and status = 'held' FOR UPDATE").WithArgs(11).WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "booking_id", "farmer_id", "owner_id", "amount.minor", "amount.currency", "captured.minor", "captured.currency", "status", "created_at"}).
+			AddRow(5, 11, 9, 20, amount, "INR", 0, "INR", "held", time.Now()))
+}
+
+func (s *DbTestSuite) Test_pgStore_AddClaim() {
+	t := s.T()
+	now := time.Now()
+	claim := domain.Claim{BookingId: 11, OwnerId: 20, FarmerId: 9, Amount: domain.Rupees(8000), Description: "cracked bonnet", Evidence: []string{"https://photos.example/1.jpg"}, RespondBy: now}
+
+	s.Run("files the claim", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("INSERT INTO claims").WithArgs(11, 20, 9, 800000, "INR", "cracked bonnet", sqlxmock.AnyArg(), now).WillReturnRows(
+			sqlxmock.NewRows([]string{"id", "status", "created_at", "updated_at"}).AddRow(3, "open", now, now))
+		s.mock.ExpectExec("INSERT INTO claim_events").WithArgs(3, "open", 20, "cracked bonnet").WillReturnResult(sqlxmock.NewResult(1, 1))
+		s.mock.ExpectCommit()
+
+		got := claim
+		require.NoError(t, s.repo.AddClaim(context.TODO(), &got))
+		assert.Equal(t, uint(3), got.Id)
+		assert.Equal(t, domain.ClaimOpen, got.Status)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("when the booking already has one", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("INSERT INTO claims").WillReturnError(sql.ErrNoRows)
+		s.mock.ExpectRollback()
+
+		got := claim
+		assert.Equal(t, sql.ErrNoRows, s.repo.AddClaim(context.TODO(), &got))
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+}
+
+func (s *DbTestSuite) Test_pgStore_DecideClaim() {
+	t := s.T()
+	from := []string{domain.ClaimDisputed, domain.ClaimEscalated}
+
+	s.Run("charges the deposit and invoices the rest", func() {
+		s.mock.ExpectBegin()
+		s.expectLockClaim(domain.ClaimDisputed)
+		s.expectLockBookingDeposit(500000)
+		s.mock.ExpectQuery("UPDATE deposits SET status = 'captured'").WithArgs(5, 500000, "INR").WillReturnRows(
+			sqlxmock.NewRows([]string{"booking_id", "farmer_id", "owner_id", "amount", "currency"}).AddRow(11, 9, 20, 500000, "INR"))
+		s.mock.ExpectExec("INSERT INTO deposit_ledger_entries").WithArgs(5, domain.DepositEntryCapture, 500000, "claim 3").WillReturnResult(sqlxmock.NewResult(1, 1))
+		s.expectPostTransaction("deposit_capture", "deposit:5", 9, 20)
+		s.expectCreateInvoice(7, 12, 20, "", 100000, 1)
+		s.mock.ExpectQuery("UPDATE claims SET status = \\$2").WithArgs(3, "approved", 600000, 500000, 7, "photos show the crack", 1).WillReturnRows(sqlxmock.NewRows([]string{"updated_at"}).AddRow(time.Now()))
+		s.mock.ExpectExec("INSERT INTO claim_events").WithArgs(3, "approved", 1, "photos show the crack").WillReturnResult(sqlxmock.NewResult(1, 1))
+		s.mock.ExpectCommit()
+
+		got, err := s.repo.DecideClaim(context.TODO(), 3, from, domain.ClaimDecision{Status: domain.ClaimApproved, Amount: domain.Rupees(6000), Note: "photos show the crack", ActorId: 1})
+		require.NoError(t, err)
+		assert.Equal(t, domain.Rupees(5000), got.FromDeposit)
+		require.NotNil(t, got.InvoiceId)
+		assert.Equal(t, uint(7), *got.InvoiceId)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("takes what the deposit covers from it alone", func() {
+		s.mock.ExpectBegin()
+		s.expectLockClaim(domain.ClaimEscalated)
+		s.expectLockBookingDeposit(2500000)
+		s.mock.ExpectQuery("UPDATE deposits SET status = 'captured'").WithArgs(5, 300000, "INR").WillReturnRows(
+			sqlxmock.NewRows([]string{"booking_id", "farmer_id", "owner_id", "amount", "currency"}).AddRow(11, 9, 20, 2500000, "INR"))
+		s.mock.ExpectExec("INSERT INTO deposit_ledger_entries").WithArgs(5, domain.DepositEntryCapture, 300000, "claim 3").WillReturnResult(sqlxmock.NewResult(1, 1))
+		s.expectPostTransaction("deposit_capture", "deposit:5", 9, 20)
+		s.expectChangeWallet(9, domain.WalletEntryDepositRelease, 2200000, 0, "deposit:5")
+		s.mock.ExpectExec("INSERT INTO deposit_ledger_entries").WithArgs(5, domain.DepositEntryRelease, 2200000, "remainder after capture").WillReturnResult(sqlxmock.NewResult(1, 1))
+		s.expectPostTransaction("deposit_release", "deposit:5", 9, 9)
+		s.mock.ExpectQuery("UPDATE claims SET status = \\$2").WithArgs(3, "approved", 300000, 300000, nil, "partly", 1).WillReturnRows(sqlxmock.NewRows([]string{"updated_at"}).AddRow(time.Now()))
+		s.mock.ExpectExec("INSERT INTO claim_events").WillReturnResult(sqlxmock.NewResult(1, 1))
+		s.mock.ExpectCommit()
+
+		got, err := s.repo.DecideClaim(context.TODO(), 3, from, domain.ClaimDecision{Status: domain.ClaimApproved, Amount: domain.Rupees(3000), Note: "partly", ActorId: 1})
+		require.NoError(t, err)
+		assert.Nil(t, got.InvoiceId)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("releases the deposit when rejected", func() {
+		s.mock.ExpectBegin()
+		s.expectLockClaim(domain.ClaimDisputed)
+		s.expectLockBookingDeposit(500000)
+		s.mock.ExpectQuery("UPDATE deposits SET status = 'released'").WithArgs(5).WillReturnRows(
+			sqlxmock.NewRows([]string{"booking_id", "farmer_id", "owner_id", "amount", "currency"}).AddRow(11, 9, 20, 500000, "INR"))
+		s.expectChangeWallet(9, domain.WalletEntryDepositRelease, 500000, 0, "deposit:5")
+		s.mock.ExpectExec("INSERT INTO deposit_ledger_entries").WithArgs(5, domain.DepositEntryRelease, 500000, "claim 3 rejected").WillReturnResult(sqlxmock.NewResult(1, 1))
+		s.expectPostTransaction("deposit_release", "deposit:5", 9, 9)
+		s.mock.ExpectQuery("UPDATE claims SET status = \\$2").WithArgs(3, "rejected", 0, 0, nil, "wear and tear", 1).WillReturnRows(sqlxmock.NewRows([]string{"updated_at"}).AddRow(time.Now()))
+		s.mock.ExpectExec("INSERT INTO claim_events").WillReturnResult(sqlxmock.NewResult(1, 1))
+		s.mock.ExpectCommit()
+
+		got, err := s.repo.DecideClaim(context.TODO(), 3, from, domain.ClaimDecision{Status: domain.ClaimRejected, Note: "wear and tear", ActorId: 1})
+		require.NoError(t, err)
+		assert.Equal(t, domain.ClaimRejected, got.Status)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("when the claim was already decided", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("SELECT (.+) FROM claims WHERE id = \\$1 and status = ANY\\(\\$2\\) FOR UPDATE").WillReturnError(sql.ErrNoRows)
+		s.mock.ExpectRollback()
+
+		_, err := s.repo.DecideClaim(context.TODO(), 3, from, domain.ClaimDecision{Status: domain.ClaimRejected, ActorId: 1})
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+}
+
+func (s *DbTestSuite) Test_pgStore_EscalateOverdueClaims() {
+	t := s.T()
+	now := time.Now()
+
+	s.mock.ExpectQuery("WITH escalated AS \\(UPDATE claims SET status = 'escalated'").WillReturnRows(
+		sqlxmock.NewRows(claimColumnNames).AddRow(3, 11, 20, 9, 800000, "INR", "cracked bonnet", "{https://photos.example/1.jpg}", "escalated", "", "{}", now, 0, "INR", 0, "INR", nil, "", nil, now, now))
+
+	got, err := s.repo.EscalateOverdueClaims(context.TODO())
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, []string{"https://photos.example/1.jpg"}, got[0].Evidence)
+	assert.Equal(t, []string{}, got[0].ResponseEvidence)
+	assert.NoError(t, s.mock.ExpectationsWereMet())
+}
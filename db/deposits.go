@@ -0,0 +1,258 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"FarmEasy/ledger"
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	depositColumns         = "id, booking_id, farmer_id, owner_id, amount AS \"amount.minor\", currency AS \"amount.currency\", captured AS \"captured.minor\", currency AS \"captured.currency\", status, created_at"
+	depositEntryColumns    = "e.id, e.deposit_id, e.kind, e.amount AS \"amount.minor\", d.currency AS \"amount.currency\", e.note, e.created_at"
+	holdDepositQuery       = "INSERT INTO deposits (booking_id, farmer_id, owner_id, amount, currency) VALUES ($1, $2, $3, $4, $5) RETURNING id, status, created_at"
+	addDepositEntryQuery   = "INSERT INTO deposit_ledger_entries (deposit_id, kind, amount, note) VALUES ($1, $2, $3, $4)"
+	getDepositsQuery       = "SELECT " + depositColumns + " FROM deposits WHERE farmer_id = $1 or owner_id = $1 ORDER BY id DESC"
+	getDepositQuery        = "SELECT " + depositColumns + " FROM deposits WHERE id = $1"
+	getBookingDepositQuery = "SELECT " + depositColumns + " FROM deposits WHERE booking_id = $1"
+	getDepositEntriesQuery = "SELECT " + depositEntryColumns + " FROM deposit_ledger_entries e JOIN deposits d ON d.id = e.deposit_id WHERE e.deposit_id = $1 ORDER BY e.id"
+	releaseDepositQuery    = "UPDATE deposits SET status = 'released', updated_at = NOW() WHERE id = $1 and status = 'held' RETURNING booking_id, farmer_id, owner_id, amount, currency"
+	captureDepositQuery    = "UPDATE deposits SET status = 'captured', captured = $2, updated_at = NOW() WHERE id = $1 and status = 'held' and amount >= $2 and currency = $3 RETURNING booking_id, farmer_id, owner_id, amount, currency"
+	// getUnclaimedDepositsQuery locks the held deposits of confirmed bookings
+	// whose last slot was more than $1 days ago, past the claim filing window,
+	// and that no claim was filed against.
+	getUnclaimedDepositsQuery = "SELECT " + depositColumns + " FROM deposits WHERE status = 'held' and booking_id IN (SELECT b.id FROM bookings b WHERE b.status = 'confirmed' " +
+		"and NOT EXISTS (SELECT 1 FROM claims c WHERE c.booking_id = b.id) and (SELECT MAX(s.date) FROM slots_booked s WHERE s.booking_id = b.id) + $1 < CURRENT_DATE) " +
+		"ORDER BY id LIMIT $2 FOR UPDATE SKIP LOCKED"
+)
+
+// holdDeposit records the deposit of a booking, takes it from the renter's
+// wallet and records its hold in the ledger, inside the booking's
+// transaction. It returns ErrInsufficientFunds when the wallet cannot cover
+// the deposit, so the booking is not made.
+func holdDeposit(ctx context.Context, tx *sqlx.Tx, deposit *domain.Deposit) (err error) {
+
+	err = tx.QueryRowContext(ctx, holdDepositQuery, deposit.BookingId, deposit.FarmerId, deposit.OwnerId, deposit.Amount.Minor, deposit.Amount.Currency).Scan(&deposit.Id, &deposit.Status, &deposit.CreatedAt)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error holding deposit")
+		return
+	}
+	deposit.Captured = domain.NewMoney(0, deposit.Amount.Currency)
+
+	_, err = changeWallet(ctx, tx, deposit.FarmerId, domain.WalletEntryDepositHold, deposit.Amount.Neg(), depositReference(deposit.Id))
+	if err != nil {
+		return
+	}
+
+	err = addDepositEntry(ctx, tx, deposit.Id, domain.DepositEntryHold, deposit.Amount.Minor, "")
+	if err != nil {
+		return
+	}
+
+	transaction := ledger.DepositHeld(*deposit)
+	return postTransaction(ctx, tx, &transaction)
+}
+
+func depositReference(depositId uint) string {
+	return fmt.Sprintf("deposit:%d", depositId)
+}
+
+func addDepositEntry(ctx context.Context, tx *sqlx.Tx, depositId uint, kind string, amount int64, note string) (err error) {
+
+	_, err = tx.ExecContext(ctx, addDepositEntryQuery, depositId, kind, amount, note)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error adding deposit ledger entry")
+		return
+	}
+
+	return
+}
+
+func (s *pgStore) GetDeposits(ctx context.Context, farmerId uint) (deposits []domain.Deposit, err error) {
+
+	err = s.db.SelectContext(ctx, &deposits, getDepositsQuery, farmerId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting deposits")
+		return
+	}
+
+	return
+}
+
+// GetDeposit returns the deposit with its ledger.
+func (s *pgStore) GetDeposit(ctx context.Context, depositId uint) (deposit domain.Deposit, err error) {
+
+	err = s.db.GetContext(ctx, &deposit, getDepositQuery, depositId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting deposit")
+		return
+	}
+
+	err = s.db.SelectContext(ctx, &deposit.Ledger, getDepositEntriesQuery, depositId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting deposit ledger")
+		return
+	}
+
+	return
+}
+
+func (s *pgStore) GetBookingDeposit(ctx context.Context, bookingId uint) (deposit domain.Deposit, err error) {
+
+	err = s.db.GetContext(ctx, &deposit, getBookingDepositQuery, bookingId)
+	return
+}
+
+// ReleaseDeposit gives a held deposit back in full. It returns sql.ErrNoRows
+// if the deposit is not held any more.
+func (s *pgStore) ReleaseDeposit(ctx context.Context, depositId uint, note string) (err error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	err = releaseDeposit(ctx, tx, depositId, note)
+	if err != nil {
+		return
+	}
+
+	return tx.Commit()
+}
+
+// CaptureDeposit keeps amount of a held deposit and releases the rest. It
+// returns sql.ErrNoRows if the deposit is not held any more or is smaller
+// than amount.
+func (s *pgStore) CaptureDeposit(ctx context.Context, depositId uint, amount domain.Money, note string) (err error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	err = captureDeposit(ctx, tx, depositId, amount, note)
+	if err != nil {
+		return
+	}
+
+	return tx.Commit()
+}
+
+// ReleaseUnclaimedDeposits gives back up to limit deposits that are still held
+// filingDays after their booking ended without a claim against it, such as
+// those of machines returned with damage the owner never claimed for. It
+// returns the deposits released.
+func (s *pgStore) ReleaseUnclaimedDeposits(ctx context.Context, filingDays int, limit uint) (released []domain.Deposit, err error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	err = tx.SelectContext(ctx, &released, getUnclaimedDepositsQuery, filingDays, limit)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting unclaimed deposits")
+		return
+	}
+
+	for i, deposit := range released {
+		err = releaseDeposit(ctx, tx, deposit.Id, "no claim filed in time")
+		if err != nil {
+			return nil, err
+		}
+		released[i].Status = domain.DepositReleased
+	}
+
+	err = tx.Commit()
+	return
+}
+
+// settleBookingDeposit releases the deposit held for a booking, or keeps all
+// of it for the owner when forfeit is set, inside the transaction that ended
+// the booking. It returns the deposit as settled, or nil if none was held.
+func settleBookingDeposit(ctx context.Context, tx *sqlx.Tx, bookingId uint, forfeit bool, note string) (settled *domain.Deposit, err error) {
+
+	var deposit domain.Deposit
+	err = tx.GetContext(ctx, &deposit, lockBookingDepositQuery, bookingId)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting held booking deposit")
+		return
+	}
+
+	if forfeit {
+		err = captureDeposit(ctx, tx, deposit.Id, deposit.Amount, note)
+		deposit.Status, deposit.Captured = domain.DepositCaptured, deposit.Amount
+	} else {
+		err = releaseDeposit(ctx, tx, deposit.Id, note)
+		deposit.Status = domain.DepositReleased
+	}
+	if err != nil {
+		return
+	}
+	return &deposit, nil
+}
+
+func releaseDeposit(ctx context.Context, tx *sqlx.Tx, depositId uint, note string) (err error) {
+
+	deposit := domain.Deposit{Id: depositId}
+	err = tx.QueryRowContext(ctx, releaseDepositQuery, depositId).Scan(&deposit.BookingId, &deposit.FarmerId, &deposit.OwnerId, &deposit.Amount.Minor, &deposit.Amount.Currency)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error releasing deposit")
+		return
+	}
+
+	return giveBackDeposit(ctx, tx, deposit, deposit.Amount, note)
+}
+
+// giveBackDeposit returns amount of the deposit to the renter's wallet.
+func giveBackDeposit(ctx context.Context, tx *sqlx.Tx, deposit domain.Deposit, amount domain.Money, note string) (err error) {
+
+	_, err = changeWallet(ctx, tx, deposit.FarmerId, domain.WalletEntryDepositRelease, amount, depositReference(deposit.Id))
+	if err != nil {
+		return
+	}
+
+	err = addDepositEntry(ctx, tx, deposit.Id, domain.DepositEntryRelease, amount.Minor, note)
+	if err != nil {
+		return
+	}
+
+	transaction := ledger.DepositReleased(deposit, amount)
+	return postTransaction(ctx, tx, &transaction)
+}
+
+func captureDeposit(ctx context.Context, tx *sqlx.Tx, depositId uint, amount domain.Money, note string) (err error) {
+
+	deposit := domain.Deposit{Id: depositId}
+	err = tx.QueryRowContext(ctx, captureDepositQuery, depositId, amount.Minor, amount.Currency).Scan(&deposit.BookingId, &deposit.FarmerId, &deposit.OwnerId, &deposit.Amount.Minor, &deposit.Amount.Currency)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error capturing deposit")
+		return
+	}
+
+	err = addDepositEntry(ctx, tx, depositId, domain.DepositEntryCapture, amount.Minor, note)
+	if err != nil {
+		return
+	}
+
+	transaction := ledger.DepositCaptured(deposit, amount)
+	err = postTransaction(ctx, tx, &transaction)
+	if err != nil {
+		return
+	}
+
+	if rest := deposit.Amount.Sub(amount); rest.Minor > 0 {
+		err = giveBackDeposit(ctx, tx, deposit, rest, "remainder after capture")
+	}
+	return
+}
@@ -0,0 +1,199 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+// expectChangeWallet expects amount, negative when spent, to move the
+// farmer's wallet from balance.
+func (s *DbTestSuite) expectChangeWallet(farmerId uint, kind string, amount int64, balance int64, reference string) {
+	s.expectLockWallet(farmerId, balance)
+	s.mock.ExpectExec("UPDATE wallets SET balance = \\$2").WithArgs(farmerId, balance+amount).WillReturnResult(sqlxmock.NewResult(0, 1))
+	s.mock.ExpectQuery("INSERT INTO wallet_transactions").WithArgs(farmerId, kind, amount, balance+amount, "INR", reference).WillReturnRows(sqlxmock.NewRows([]string{"id", "created_at"}).AddRow(4, time.Now()))
+}
+
+func (s *DbTestSuite) expectNoHeldDeposit(bookingId uint) {
+	s.mock.ExpectQuery("SELECT (.+) FROM deposits WHERE booking_id = \\$1 and status = 'held' FOR UPDATE").WithArgs(bookingId).WillReturnError(sql.ErrNoRows)
+}
+
+// expectHeldDeposit expects the deposit 5 of 25000 INR, held for the
+// booking by farmer 9 on a machine of owner 20, to be looked up for settling.
+func (s *DbTestSuite) expectHeldDeposit(bookingId uint) {
+	s.mock.ExpectQuery("SELECT (.+) FROM deposits WHERE booking_id = \\$1 and status = 'held' FOR UPDATE").WithArgs(bookingId).WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "booking_id", "farmer_id", "owner_id", "amount.minor", "amount.currency", "captured.minor", "captured.currency", "status", "created_at"}).
+			AddRow(5, bookingId, 9, 20, 2500000, "INR", 0, "INR", domain.DepositHeld, time.Now()))
+}
+
+// expectReleaseHeldDeposit expects the deposit of expectHeldDeposit to be
+// given back to the renter's wallet.
+func (s *DbTestSuite) expectReleaseHeldDeposit(bookingId uint, note string) {
+	s.expectHeldDeposit(bookingId)
+	s.mock.ExpectQuery("UPDATE deposits SET status = 'released'").WithArgs(5).WillReturnRows(
+		sqlxmock.NewRows([]string{"booking_id", "farmer_id", "owner_id", "amount", "currency"}).AddRow(bookingId, 9, 20, 2500000, "INR"))
+	s.expectChangeWallet(9, domain.WalletEntryDepositRelease, 2500000, 0, "deposit:5")
+	s.mock.ExpectExec("INSERT INTO deposit_ledger_entries").WithArgs(5, domain.DepositEntryRelease, 2500000, note).WillReturnResult(sqlxmock.NewResult(1, 1))
+	s.expectPostTransaction("deposit_release", "deposit:5", 9, 9)
+}
+
+func (s *DbTestSuite) expectBookHarvester() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM machines m (.+) FOR UPDATE OF m").WithArgs(1).WillReturnRows(
+		sqlxmock.NewRows([]string{"name", "owner_id", "base_hourly_charge", "currency", "security_deposit", "hsn_sac", "rate_bp", "category"}).AddRow("Harvester", 20, 300000, "INR", 2500000, "997314", 1800, "harvester"))
+	s.mock.ExpectQuery("SELECT slots_booked.id FROM slots_booked").WithArgs(1, 7, "2026-11-07").WillReturnError(sql.ErrNoRows)
+	s.mock.ExpectQuery("INSERT INTO bookings").WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(11))
+	s.mock.ExpectExec("INSERT INTO slots_booked").WillReturnResult(sqlxmock.NewResult(1, 1))
+	s.expectOutboxEvent(domain.EventBookingRequested, 20)
+	s.expectOutboxEvent(domain.EventBookingAccepted, 9)
+	s.mock.ExpectQuery("INSERT INTO deposits").WithArgs(11, 9, 20, 2500000, "INR").WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "status", "created_at"}).AddRow(5, "held", time.Now()))
+}
+
+func (s *DbTestSuite) Test_pgStore_Book_holdsDeposit() {
+	t := s.T()
+	booking := domain.NewBookingRequest{MachineId: 1, Date: "2026-11-07", Slots: []uint{7}, FarmerId: 9}
+
+	s.Run("takes it from the wallet", func() {
+		s.expectBookHarvester()
+		s.expectChangeWallet(9, domain.WalletEntryDepositHold, -2500000, 3000000, "deposit:5")
+		s.mock.ExpectExec("INSERT INTO deposit_ledger_entries").WithArgs(5, domain.DepositEntryHold, 2500000, "").WillReturnResult(sqlxmock.NewResult(1, 1))
+		s.expectPostTransaction("deposit_hold", "deposit:5", 9, 9)
+		s.expectCreateInvoice(1, 1, 20, "", 300000, 1)
+		s.mock.ExpectCommit()
+
+		got, err := s.repo.Book(context.TODO(), booking)
+		require.NoError(t, err)
+		require.NotNil(t, got.Deposit)
+		assert.Equal(t, uint(5), got.Deposit.Id)
+		assert.Equal(t, domain.Rupees(25000), got.Deposit.Amount)
+		assert.Equal(t, domain.DepositHeld, got.Deposit.Status)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("when the wallet cannot cover it", func() {
+		s.expectBookHarvester()
+		s.expectLockWallet(9, 2000000)
+		s.mock.ExpectRollback()
+
+		_, err := s.repo.Book(context.TODO(), booking)
+		assert.Equal(t, ErrInsufficientFunds, err)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+}
+
+func (s *DbTestSuite) Test_pgStore_CaptureDeposit() {
+	t := s.T()
+
+	s.Run("releases the rest", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("UPDATE deposits SET status = 'captured'").WithArgs(5, 400000, "INR").WillReturnRows(
+			sqlxmock.NewRows([]string{"booking_id", "farmer_id", "owner_id", "amount", "currency"}).AddRow(11, 9, 20, 2500000, "INR"))
+		s.mock.ExpectExec("INSERT INTO deposit_ledger_entries").WithArgs(5, domain.DepositEntryCapture, 400000, "broken blade").WillReturnResult(sqlxmock.NewResult(1, 1))
+		s.expectPostTransaction("deposit_capture", "deposit:5", 9, 20)
+		s.expectChangeWallet(9, domain.WalletEntryDepositRelease, 2100000, 0, "deposit:5")
+		s.mock.ExpectExec("INSERT INTO deposit_ledger_entries").WithArgs(5, domain.DepositEntryRelease, 2100000, "remainder after capture").WillReturnResult(sqlxmock.NewResult(1, 1))
+		s.expectPostTransaction("deposit_release", "deposit:5", 9, 9)
+		s.mock.ExpectCommit()
+
+		err := s.repo.CaptureDeposit(context.TODO(), 5, domain.Rupees(4000), "broken blade")
+		require.NoError(t, err)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("when it is no longer held", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("UPDATE deposits SET status = 'captured'").WithArgs(5, 400000, "INR").WillReturnError(sql.ErrNoRows)
+		s.mock.ExpectRollback()
+
+		err := s.repo.CaptureDeposit(context.TODO(), 5, domain.Rupees(4000), "broken blade")
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+}
+
+func (s *DbTestSuite) Test_pgStore_CaptureDeposit_ledgerParties() {
+	t := s.T()
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("UPDATE deposits SET status = 'captured'").WithArgs(5, 2500000, "INR").WillReturnRows(
+		sqlxmock.NewRows([]string{"booking_id", "farmer_id", "owner_id", "amount", "currency"}).AddRow(11, 9, 20, 2500000, "INR"))
+	s.mock.ExpectExec("INSERT INTO deposit_ledger_entries").WithArgs(5, domain.DepositEntryCapture, 2500000, "").WillReturnResult(sqlxmock.NewResult(1, 1))
+	s.mock.ExpectQuery("INSERT INTO ledger_transactions").WithArgs("deposit_capture", "deposit:5", sqlxmock.AnyArg()).WillReturnRows(sqlxmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+	// the renter's deposit goes to the owner, not the other way round
+	s.mock.ExpectQuery("INSERT INTO ledger_entries").WithArgs(1, "deposits_held", 9, 2500000, "INR").WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectQuery("INSERT INTO ledger_entries").WithArgs(1, "owner_payable", 20, -2500000, "INR").WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(2))
+	s.mock.ExpectCommit()
+
+	err := s.repo.CaptureDeposit(context.TODO(), 5, domain.Rupees(25000), "")
+	require.NoError(t, err)
+	assert.NoError(t, s.mock.ExpectationsWereMet())
+}
+
+func (s *DbTestSuite) Test_pgStore_GetDeposit() {
+	t := s.T()
+
+	now := time.Now()
+	s.mock.ExpectQuery("SELECT (.+) FROM deposits WHERE id = \\$1").WithArgs(5).WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "booking_id", "farmer_id", "owner_id", "amount.minor", "amount.currency", "captured.minor", "captured.currency", "status", "created_at"}).
+			AddRow(5, 11, 9, 20, 2500000, "INR", 0, "INR", "released", now))
+	s.mock.ExpectQuery("SELECT (.+) FROM deposit_ledger_entries e JOIN deposits d").WithArgs(5).WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "deposit_id", "kind", "amount.minor", "amount.currency", "note", "created_at"}).
+			AddRow(1, 5, "hold", 2500000, "INR", "", now).
+			AddRow(2, 5, "release", 2500000, "INR", "booking cancelled", now))
+
+	got, err := s.repo.GetDeposit(context.TODO(), 5)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DepositReleased, got.Status)
+	require.Len(t, got.Ledger, 2)
+	assert.Equal(t, domain.DepositEntryRelease, got.Ledger[1].Kind)
+	assert.Equal(t, domain.Rupees(25000), got.Ledger[1].Amount)
+	assert.NoError(t, s.mock.ExpectationsWereMet())
+}
+
+func (s *DbTestSuite) Test_pgStore_ReleaseUnclaimedDeposits() {
+	t := s.T()
+
+	// only held deposits of confirmed bookings that ended before the filing
+	// window and have no claim are picked, skipping those another run holds
+	selectUnclaimed := "SELECT (.+) FROM deposits WHERE status = 'held' and booking_id IN \\(SELECT b.id FROM bookings b WHERE b.status = 'confirmed' " +
+		"and NOT EXISTS \\(SELECT 1 FROM claims c WHERE c.booking_id = b.id\\) and \\(SELECT MAX\\(s.date\\) FROM slots_booked s WHERE s.booking_id = b.id\\) \\+ \\$1 < CURRENT_DATE\\) " +
+		"ORDER BY id LIMIT \\$2 FOR UPDATE SKIP LOCKED"
+
+	s.Run("releases them to the wallet", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery(selectUnclaimed).WithArgs(7, 100).WillReturnRows(
+			sqlxmock.NewRows([]string{"id", "booking_id", "farmer_id", "owner_id", "amount.minor", "amount.currency", "captured.minor", "captured.currency", "status", "created_at"}).
+				AddRow(5, 11, 9, 20, 2500000, "INR", 0, "INR", domain.DepositHeld, time.Now()))
+		s.mock.ExpectQuery("UPDATE deposits SET status = 'released'").WithArgs(5).WillReturnRows(
+			sqlxmock.NewRows([]string{"booking_id", "farmer_id", "owner_id", "amount", "currency"}).AddRow(11, 9, 20, 2500000, "INR"))
+		s.expectChangeWallet(9, domain.WalletEntryDepositRelease, 2500000, 0, "deposit:5")
+		s.mock.ExpectExec("INSERT INTO deposit_ledger_entries").WithArgs(5, domain.DepositEntryRelease, 2500000, "no claim filed in time").WillReturnResult(sqlxmock.NewResult(1, 1))
+		s.expectPostTransaction("deposit_release", "deposit:5", 9, 9)
+		s.mock.ExpectCommit()
+
+		got, err := s.repo.ReleaseUnclaimedDeposits(context.TODO(), 7, 100)
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, uint(11), got[0].BookingId)
+		assert.Equal(t, domain.DepositReleased, got[0].Status)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("when there are none", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery(selectUnclaimed).WithArgs(7, 100).WillReturnRows(
+			sqlxmock.NewRows([]string{"id", "booking_id", "farmer_id", "owner_id", "amount.minor", "amount.currency", "captured.minor", "captured.currency", "status", "created_at"}))
+		s.mock.ExpectCommit()
+
+		got, err := s.repo.ReleaseUnclaimedDeposits(context.TODO(), 7, 100)
+		require.NoError(t, err)
+		assert.Empty(t, got)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+}
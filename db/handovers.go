@@ -0,0 +1,270 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	handoverColumns        = "id, booking_id, kind, recorded_by, meter_reading, fuel_level, checklist, photos, notes, renter_confirmed_at, owner_confirmed_at, created_at"
+	getBookingPartiesQuery = "SELECT b.id AS booking_id, b.machine_id, b.farmer_id, m.owner_id, b.status, m.usage_billing, m.currency, " +
+		"COALESCE((SELECT to_char(MAX(date), 'YYYY-MM-DD') FROM slots_booked WHERE booking_id = b.id), '') AS end_date, " +
+		"EXISTS (SELECT 1 FROM handovers WHERE booking_id = b.id and kind = 'check_out') AS checked_out " +
+		"FROM bookings b JOIN machines m ON m.id = b.machine_id WHERE b.id = $1"
+	addHandoverQuery            = "INSERT INTO handovers (booking_id, kind, recorded_by, meter_reading, fuel_level, checklist, photos, notes, renter_confirmed_at, owner_confirmed_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) ON CONFLICT (booking_id, kind) DO NOTHING RETURNING id, created_at"
+	getHandoversQuery           = "SELECT " + handoverColumns + " FROM handovers WHERE booking_id = $1 ORDER BY id"
+	confirmRenterHandoverQuery  = "UPDATE handovers SET renter_confirmed_at = COALESCE(renter_confirmed_at, NOW()) WHERE booking_id = $1 and kind = $2 RETURNING " + handoverColumns
+	confirmOwnerHandoverQuery   = "UPDATE handovers SET owner_confirmed_at = COALESCE(owner_confirmed_at, NOW()) WHERE booking_id = $1 and kind = $2 RETURNING " + handoverColumns
+	settleHandoverQuery         = "UPDATE handovers SET settled_at = NOW() WHERE booking_id = $1 and kind = 'check_out' and settled_at IS NULL and renter_confirmed_at IS NOT NULL and owner_confirmed_at IS NOT NULL RETURNING meter_reading"
+	getCheckInUsageQuery        = "SELECT h.meter_reading, m.usage_billing FROM handovers h JOIN bookings b ON b.id = h.booking_id JOIN machines m ON m.id = b.machine_id WHERE h.booking_id = $1 and h.kind = 'check_in'"
+	lockBookingInvoiceQuery     = "SELECT " + invoiceColumns + " FROM invoices WHERE id = (SELECT invoice_id FROM invoice_line_items WHERE booking_id = $1 and kind = 'rental') FOR UPDATE"
+	deleteTaxLineItemsQuery     = "DELETE FROM invoice_line_items WHERE invoice_id = $1 and kind = 'tax'"
+	updateInvoiceTotalsQuery    = "UPDATE invoices SET supply_type = $2, taxable_value = $3, cgst = $4, sgst = $5, igst = $6, total_amount = $7 WHERE id = $1"
+	failOpenInvoicePaymentQuery = "UPDATE payments SET status = 'failed', updated_at = NOW() WHERE invoice_id = $1 and status = 'created'"
+)
+
+// handoverRow reads the checklist and photo columns of a handover, which the
+// domain type keeps as plain slices.
+type handoverRow struct {
+	domain.Handover
+	Checklist []byte         `db:"checklist"`
+	Photos    pq.StringArray `db:"photos"`
+}
+
+func (row handoverRow) handover() (handover domain.Handover, err error) {
+	handover = row.Handover
+	handover.Photos = []string(row.Photos)
+	err = json.Unmarshal(row.Checklist, &handover.Checklist)
+	return
+}
+
+// GetBookingParties returns the renter and the owner of a booking.
+func (s *pgStore) GetBookingParties(ctx context.Context, bookingId uint) (parties domain.BookingParties, err error) {
+
+	err = s.db.GetContext(ctx, &parties, getBookingPartiesQuery, bookingId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting booking parties")
+		return
+	}
+
+	return
+}
+
+// AddHandover records a check-in or check-out. It returns sql.ErrNoRows if the
+// booking already has one of that kind.
+func (s *pgStore) AddHandover(ctx context.Context, handover *domain.Handover) (err error) {
+
+	checklist, err := json.Marshal(handover.Checklist)
+	if err != nil {
+		return
+	}
+
+	err = s.db.QueryRowContext(ctx, addHandoverQuery, handover.BookingId, handover.Kind, handover.RecordedBy, handover.MeterReading, handover.FuelLevel, checklist, pq.StringArray(handover.Photos), handover.Notes, handover.RenterConfirmedAt, handover.OwnerConfirmedAt).Scan(&handover.Id, &handover.CreatedAt)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error inserting handover")
+		return
+	}
+
+	return
+}
+
+func (s *pgStore) GetHandovers(ctx context.Context, bookingId uint) (handovers []domain.Handover, err error) {
+
+	var rows []handoverRow
+	err = s.db.SelectContext(ctx, &rows, getHandoversQuery, bookingId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting handovers")
+		return
+	}
+
+	for _, row := range rows {
+		handover, err := row.handover()
+		if err != nil {
+			return nil, err
+		}
+		handovers = append(handovers, handover)
+	}
+	return
+}
+
+// ConfirmHandover records that the renter or the owner agrees with the
+// handover. Confirming twice keeps the first time. It returns sql.ErrNoRows if
+// the booking has no handover of that kind.
+func (s *pgStore) ConfirmHandover(ctx context.Context, bookingId uint, kind string, role string) (handover domain.Handover, err error) {
+
+	query := confirmRenterHandoverQuery
+	if role == "owner" {
+		query = confirmOwnerHandoverQuery
+	}
+
+	var row handoverRow
+	err = s.db.GetContext(ctx, &row, query, bookingId, kind)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error confirming handover")
+		return
+	}
+
+	return row.handover()
+}
+
+// SettleHandover bills a confirmed check-out, once. When the machine bills by
+// usage, the difference between the hours on the meter and the hours booked
+// is added to the booking's invoice while it is unpaid, or billed on a
+// supplementary invoice once it is paid, and the invoice is returned. Less use
+// than booked on a paid invoice is left to the owner to refund. It returns
+// sql.ErrNoRows if the check-out is not confirmed by both parties or was
+// already settled.
+func (s *pgStore) SettleHandover(ctx context.Context, bookingId uint) (invoice *domain.Invoice, err error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	var checkIn, checkOut domain.Handover
+	err = tx.QueryRowContext(ctx, settleHandoverQuery, bookingId).Scan(&checkOut.MeterReading)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error settling handover")
+		return
+	}
+
+	var usageBilling bool
+	err = tx.QueryRowContext(ctx, getCheckInUsageQuery, bookingId).Scan(&checkIn.MeterReading, &usageBilling)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting check-in meter reading")
+		return
+	}
+
+	if usageBilling {
+		invoice, err = billUsage(ctx, tx, bookingId, domain.UsageTenths(checkIn, checkOut))
+		if err != nil {
+			return
+		}
+	}
+
+	err = tx.Commit()
+	return
+}
+
+// billUsage adjusts the rental of the booking to the hours used and returns
+// the invoice that bills the adjustment, or nil when there is nothing to bill.
+func billUsage(ctx context.Context, tx *sqlx.Tx, bookingId uint, usedTenths int64) (invoice *domain.Invoice, err error) {
+
+	var locked domain.Invoice
+	err = tx.GetContext(ctx, &locked, lockBookingInvoiceQuery, bookingId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error locking booking invoice")
+		return
+	}
+
+	err = tx.SelectContext(ctx, &locked.LineItems, getInvoiceLineItemsQuery, pq.Array([]int64{int64(locked.Id)}))
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting invoice line items")
+		return
+	}
+
+	var rental domain.InvoiceLineItem
+	for _, item := range locked.LineItems {
+		if item.Kind == domain.LineItemRental && item.BookingId != nil && *item.BookingId == bookingId {
+			rental = item
+		}
+	}
+
+	adjustment := domain.UsageAdjustment(rental, usedTenths)
+	if adjustment.IsZero() {
+		return
+	}
+	item := domain.InvoiceLineItem{
+		BookingId:   &bookingId,
+		Kind:        domain.LineItemAdjustment,
+		Description: fmt.Sprintf("Metered usage, %.1f hours against %d booked", float64(usedTenths)/10, rental.Quantity),
+		HsnSac:      rental.HsnSac,
+		Quantity:    1,
+		UnitAmount:  adjustment,
+		Amount:      adjustment,
+		TaxRate:     rental.TaxRate,
+	}
+
+	switch {
+	case locked.Status == domain.InvoiceUnpaid:
+		err = reviseInvoice(ctx, tx, &locked, item)
+		invoice = &locked
+
+	case adjustment.Minor > 0:
+		invoice = &domain.Invoice{
+			BookingId:    bookingId,
+			FarmerId:     locked.FarmerId,
+			OwnerId:      locked.OwnerId,
+			DateGenrated: time.Now().Format("2006-01-02"),
+			LineItems:    []domain.InvoiceLineItem{item},
+		}
+		err = createInvoice(ctx, tx, invoice)
+		invoice.Status = domain.InvoiceUnpaid
+	}
+	return
+}
+
+// reviseInvoice adds a line to an unpaid invoice and taxes it afresh. The
+// invoice keeps its number; payments started for the old amount are failed so
+// that the renter pays the new one.
+func reviseInvoice(ctx context.Context, tx *sqlx.Tx, invoice *domain.Invoice, item domain.InvoiceLineItem) (err error) {
+
+	var ownerState, ownerGSTIN, renterState string
+	err = tx.QueryRowxContext(ctx, getInvoicePartiesQuery, invoice.OwnerId, invoice.FarmerId).Scan(&ownerState, &ownerGSTIN, &renterState)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting invoice parties")
+		return
+	}
+
+	var items []domain.InvoiceLineItem
+	for _, existing := range invoice.LineItems {
+		if existing.Kind != domain.LineItemTax {
+			items = append(items, existing)
+		}
+	}
+	kept := len(items)
+	invoice.LineItems = append(items, item)
+
+	err = applyTax(invoice, ownerState, renterState)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, deleteTaxLineItemsQuery, invoice.Id)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error deleting invoice tax lines")
+		return
+	}
+
+	for i := kept; i < len(invoice.LineItems); i++ {
+		added := &invoice.LineItems[i]
+		added.InvoiceId = invoice.Id
+		err = tx.QueryRowxContext(ctx, insertLineItemQuery, added.InvoiceId, added.BookingId, added.Kind, added.Description, added.HsnSac, added.Quantity, added.UnitAmount.Minor, added.Amount.Minor, added.TaxRate).Scan(&added.Id)
+		if err != nil {
+			logger.WithField("err", err.Error()).Error("Error inserting invoice line item")
+			return
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, updateInvoiceTotalsQuery, invoice.Id, invoice.SupplyType, invoice.TaxableValue.Minor, invoice.CGST.Minor, invoice.SGST.Minor, invoice.IGST.Minor, invoice.Amount.Minor)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error updating invoice totals")
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, failOpenInvoicePaymentQuery, invoice.Id)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error failing open invoice payments")
+		return
+	}
+
+	return
+}
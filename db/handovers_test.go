@@ -0,0 +1,146 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func (s *DbTestSuite) expectSettleHandover(bookingId uint, checkIn, checkOut string, usageBilling bool) {
+	s.mock.ExpectQuery("UPDATE handovers SET settled_at = NOW\\(\\)").WithArgs(bookingId).WillReturnRows(sqlxmock.NewRows([]string{"meter_reading"}).AddRow(checkOut))
+	s.mock.ExpectQuery("SELECT h.meter_reading, m.usage_billing FROM handovers h").WithArgs(bookingId).WillReturnRows(sqlxmock.NewRows([]string{"meter_reading", "usage_billing"}).AddRow(checkIn, usageBilling))
+}
+
+// expectLockBookingInvoice returns an intra-state invoice for three hourly
+// slots at 300 rupees, taxed at 18%.
+func (s *DbTestSuite) expectLockBookingInvoice(bookingId uint, status string) {
+	s.mock.ExpectQuery("SELECT (.+) FROM invoices WHERE id = \\(SELECT invoice_id FROM invoice_line_items (.+)\\) FOR UPDATE").WithArgs(bookingId).WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "number", "financial_year", "booking_id", "order_id", "farmer_id", "owner_id", "owner_gstin", "place_of_supply", "supply_type", "date_generated", "taxable_value.minor", "taxable_value.currency", "cgst.minor", "cgst.currency", "sgst.minor", "sgst.currency", "igst.minor", "igst.currency", "amount.minor", "amount.currency", "status"}).
+			AddRow(1, "FE/2026-27/000001", "2026-27", bookingId, nil, 9, 20, "27AAAPL1234C1Z5", "27", "intra_state", "2026-11-07", 90000, "INR", 8100, "INR", 8100, "INR", 0, "INR", 106200, "INR", status))
+	s.mock.ExpectQuery("SELECT (.+) FROM invoice_line_items l JOIN invoices i").WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "invoice_id", "booking_id", "kind", "description", "hsn_sac", "quantity", "unit_amount.minor", "unit_amount.currency", "amount.minor", "amount.currency", "tax_rate_bp"}).
+			AddRow(1, 1, bookingId, "rental", "Tractor, 3 hourly slots", "997314", 3, 30000, "INR", 90000, "INR", 1800).
+			AddRow(2, 1, nil, "tax", "CGST @ 9%", "", 1, 8100, "INR", 8100, "INR", 0).
+			AddRow(3, 1, nil, "tax", "SGST @ 9%", "", 1, 8100, "INR", 8100, "INR", 0))
+}
+
+func (s *DbTestSuite) Test_pgStore_AddHandover() {
+	t := s.T()
+	now := time.Now()
+	handover := domain.Handover{BookingId: 5, Kind: domain.HandoverCheckIn, RecordedBy: 9, MeterReading: 1200, FuelLevel: 80, Checklist: []domain.ChecklistItem{{Item: "tyres", Ok: true}}, Photos: []string{"https://photos.example/1.jpg"}, RenterConfirmedAt: &now}
+
+	s.Run("records the handover", func() {
+		s.mock.ExpectQuery("INSERT INTO handovers").WithArgs(5, "check_in", 9, 1200.0, 80, []byte(`[{"item":"tyres","ok":true}]`), sqlxmock.AnyArg(), "", &now, nil).WillReturnRows(sqlxmock.NewRows([]string{"id", "created_at"}).AddRow(3, now))
+
+		got := handover
+		require.NoError(t, s.repo.AddHandover(context.TODO(), &got))
+		assert.Equal(t, uint(3), got.Id)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("when one was already recorded", func() {
+		s.mock.ExpectQuery("INSERT INTO handovers").WillReturnError(sql.ErrNoRows)
+
+		got := handover
+		assert.Equal(t, sql.ErrNoRows, s.repo.AddHandover(context.TODO(), &got))
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+}
+
+func (s *DbTestSuite) Test_pgStore_GetHandovers() {
+	t := s.T()
+	now := time.Now()
+
+	s.mock.ExpectQuery("SELECT (.+) FROM handovers WHERE booking_id = \\$1").WithArgs(5).WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "booking_id", "kind", "recorded_by", "meter_reading", "fuel_level", "checklist", "photos", "notes", "renter_confirmed_at", "owner_confirmed_at", "created_at"}).
+			AddRow(3, 5, "check_in", 9, "1200.0", 80, []byte(`[{"item":"hitch","ok":false,"note":"bent pin"}]`), "{https://photos.example/1.jpg}", "", now, now, now))
+
+	got, err := s.repo.GetHandovers(context.TODO(), 5)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, 1200.0, got[0].MeterReading)
+	assert.Equal(t, []domain.ChecklistItem{{Item: "hitch", Note: "bent pin"}}, got[0].Checklist)
+	assert.Equal(t, []string{"https://photos.example/1.jpg"}, got[0].Photos)
+	assert.True(t, got[0].Confirmed())
+	assert.NoError(t, s.mock.ExpectationsWereMet())
+}
+
+func (s *DbTestSuite) Test_pgStore_SettleHandover() {
+	t := s.T()
+
+	s.Run("revises an unpaid invoice", func() {
+		s.mock.ExpectBegin()
+		s.expectSettleHandover(5, "1200.0", "1204.5", true)
+		s.expectLockBookingInvoice(5, domain.InvoiceUnpaid)
+		s.mock.ExpectQuery("SELECT (.+) FROM farmers o, farmers r").WithArgs(20, 9).WillReturnRows(sqlxmock.NewRows([]string{"state", "gstin", "state"}).AddRow("27", "27AAAPL1234C1Z5", "27"))
+		s.mock.ExpectExec("DELETE FROM invoice_line_items WHERE invoice_id = \\$1 and kind = 'tax'").WithArgs(1).WillReturnResult(sqlxmock.NewResult(0, 2))
+		s.mock.ExpectQuery("INSERT INTO invoice_line_items").WithArgs(1, sqlxmock.AnyArg(), "adjustment", "Metered usage, 4.5 hours against 3 booked", "997314", 1, 45000, 45000, 1800).WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(4))
+		s.mock.ExpectQuery("INSERT INTO invoice_line_items").WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(5))
+		s.mock.ExpectQuery("INSERT INTO invoice_line_items").WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(6))
+		s.mock.ExpectExec("UPDATE invoices SET supply_type").WithArgs(1, "intra_state", 135000, 12150, 12150, 0, 159300).WillReturnResult(sqlxmock.NewResult(0, 1))
+		s.mock.ExpectExec("UPDATE payments SET status = 'failed'").WithArgs(1).WillReturnResult(sqlxmock.NewResult(0, 0))
+		s.mock.ExpectCommit()
+
+		got, err := s.repo.SettleHandover(context.TODO(), 5)
+		require.NoError(t, err)
+		require.NotNil(t, got)
+		assert.Equal(t, "FE/2026-27/000001", got.Number)
+		assert.Equal(t, domain.Rupees(1593), got.Amount)
+		assert.Len(t, got.LineItems, 4)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("bills more use on a paid invoice separately", func() {
+		s.mock.ExpectBegin()
+		s.expectSettleHandover(5, "1200.0", "1204.0", true)
+		s.expectLockBookingInvoice(5, domain.InvoicePaid)
+		s.expectCreateInvoice(2, 2, 20, "27AAAPL1234C1Z5", 35400, 3)
+		s.mock.ExpectCommit()
+
+		got, err := s.repo.SettleHandover(context.TODO(), 5)
+		require.NoError(t, err)
+		require.NotNil(t, got)
+		assert.Equal(t, uint(2), got.Id)
+		assert.Equal(t, domain.LineItemAdjustment, got.LineItems[0].Kind)
+		assert.Equal(t, domain.Rupees(300), got.TaxableValue)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("leaves less use on a paid invoice to the owner", func() {
+		s.mock.ExpectBegin()
+		s.expectSettleHandover(5, "1200.0", "1202.0", true)
+		s.expectLockBookingInvoice(5, domain.InvoicePaid)
+		s.mock.ExpectCommit()
+
+		got, err := s.repo.SettleHandover(context.TODO(), 5)
+		require.NoError(t, err)
+		assert.Nil(t, got)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("when the machine bills by the slot", func() {
+		s.mock.ExpectBegin()
+		s.expectSettleHandover(5, "1200.0", "1204.5", false)
+		s.mock.ExpectCommit()
+
+		got, err := s.repo.SettleHandover(context.TODO(), 5)
+		require.NoError(t, err)
+		assert.Nil(t, got)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("when the check-out is not confirmed or already settled", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("UPDATE handovers SET settled_at = NOW\\(\\)").WithArgs(5).WillReturnError(sql.ErrNoRows)
+		s.mock.ExpectRollback()
+
+		_, err := s.repo.SettleHandover(context.TODO(), 5)
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+}
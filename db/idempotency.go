@@ -0,0 +1,68 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	claimIdempotencyKeyQuery     = "INSERT INTO idempotency_keys (farmer_id, key, request_hash) VALUES ($1, $2, $3) ON CONFLICT (farmer_id, key) DO NOTHING RETURNING id"
+	getIdempotencyRecordQuery    = "SELECT farmer_id, key, request_hash, status_code, response_body FROM idempotency_keys WHERE farmer_id = $1 and key = $2"
+	saveIdempotencyResponseQuery = "UPDATE idempotency_keys SET status_code = $3, response_body = $4 WHERE farmer_id = $1 and key = $2"
+	deleteIdempotencyKeyQuery    = "DELETE FROM idempotency_keys WHERE farmer_id = $1 and key = $2"
+)
+
+// ClaimIdempotencyKey reserves the key for the farmer. claimed is false when the
+// key was already used by an earlier (possibly still running) request.
+func (s *pgStore) ClaimIdempotencyKey(ctx context.Context, farmerId uint, key string, requestHash string) (claimed bool, err error) {
+
+	var id uint
+	err = s.db.QueryRowContext(ctx, claimIdempotencyKeyQuery, farmerId, key, requestHash).Scan(&id)
+	if err == sql.ErrNoRows {
+		err = nil
+		return
+	}
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error claiming idempotency key")
+		return
+	}
+
+	claimed = true
+	return
+}
+
+func (s *pgStore) GetIdempotencyRecord(ctx context.Context, farmerId uint, key string) (record domain.IdempotencyRecord, err error) {
+
+	err = s.db.GetContext(ctx, &record, getIdempotencyRecordQuery, farmerId, key)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting idempotency record")
+		return
+	}
+
+	return
+}
+
+func (s *pgStore) SaveIdempotencyResponse(ctx context.Context, record domain.IdempotencyRecord) (err error) {
+
+	_, err = s.db.ExecContext(ctx, saveIdempotencyResponseQuery, record.FarmerId, record.Key, record.StatusCode, record.ResponseBody)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error saving idempotent response")
+		return
+	}
+
+	return
+}
+
+func (s *pgStore) DeleteIdempotencyKey(ctx context.Context, farmerId uint, key string) (err error) {
+
+	_, err = s.db.ExecContext(ctx, deleteIdempotencyKeyQuery, farmerId, key)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error deleting idempotency key")
+		return
+	}
+
+	return
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func (s *DbTestSuite) Test_pgStore_ClaimIdempotencyKey() {
+	t := s.T()
+
+	tests := []struct {
+		name        string
+		wantClaimed bool
+		wantErr     bool
+		prepare     func(sqlxmock.Sqlmock)
+	}{
+		{
+			name:        "when the key is new",
+			wantClaimed: true,
+			prepare: func(mock sqlxmock.Sqlmock) {
+				rows := sqlxmock.NewRows([]string{"id"}).AddRow(uint(1))
+				mock.ExpectQuery("INSERT INTO idempotency_keys").WithArgs(1, "key-1", "hash").WillReturnRows(rows)
+			},
+		},
+		{
+			name:        "when the key was already claimed",
+			wantClaimed: false,
+			prepare: func(mock sqlxmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO idempotency_keys").WithArgs(1, "key-1", "hash").WillReturnError(sql.ErrNoRows)
+			},
+		},
+		{
+			name:    "when the insert fails",
+			wantErr: true,
+			prepare: func(mock sqlxmock.Sqlmock) {
+				mock.ExpectQuery("INSERT INTO idempotency_keys").WithArgs(1, "key-1", "hash").WillReturnError(errors.New("mocked error"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.prepare(s.mock)
+			claimed, err := s.repo.ClaimIdempotencyKey(context.TODO(), 1, "key-1", "hash")
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantClaimed, claimed)
+		})
+	}
+}
+
+func (s *DbTestSuite) Test_pgStore_GetIdempotencyRecord() {
+	t := s.T()
+
+	rows := sqlxmock.NewRows([]string{"farmer_id", "key", "request_hash", "status_code", "response_body"}).AddRow(1, "key-1", "hash", 201, []byte(`{}`))
+	s.mock.ExpectQuery("SELECT farmer_id, key, request_hash, status_code, response_body FROM idempotency_keys").WithArgs(1, "key-1").WillReturnRows(rows)
+
+	record, err := s.repo.GetIdempotencyRecord(context.TODO(), 1, "key-1")
+	require.NoError(t, err)
+	assert.Equal(t, 201, record.StatusCode)
+	assert.Equal(t, []byte(`{}`), record.ResponseBody)
+}
@@ -0,0 +1,261 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"FarmEasy/tax"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	invoiceColumns = "id, number, financial_year, COALESCE(booking_id, 0) AS booking_id, order_id, farmer_id, owner_id, COALESCE(owner_gstin, '') AS owner_gstin, COALESCE(place_of_supply, '') AS place_of_supply, supply_type, to_char(date_generated, 'YYYY-MM-DD') AS date_generated, " +
+		"taxable_value AS \"taxable_value.minor\", currency AS \"taxable_value.currency\", cgst AS \"cgst.minor\", currency AS \"cgst.currency\", sgst AS \"sgst.minor\", currency AS \"sgst.currency\", igst AS \"igst.minor\", currency AS \"igst.currency\", total_amount AS \"amount.minor\", currency AS \"amount.currency\", status"
+	lineItemColumns          = "l.id, l.invoice_id, l.booking_id, l.kind, l.description, COALESCE(l.hsn_sac, '') AS hsn_sac, l.quantity, l.unit_amount AS \"unit_amount.minor\", i.currency AS \"unit_amount.currency\", l.amount AS \"amount.minor\", i.currency AS \"amount.currency\", l.tax_rate_bp"
+	nextInvoiceNumberQuery   = "INSERT INTO invoice_sequences (financial_year, last_number) VALUES ($1, 1) ON CONFLICT (financial_year) DO UPDATE SET last_number = invoice_sequences.last_number + 1 RETURNING last_number"
+	getInvoicePartiesQuery   = "SELECT COALESCE(o.state, ''), COALESCE(o.gstin, ''), COALESCE(r.state, '') FROM farmers o, farmers r WHERE o.id = $1 and r.id = $2"
+	insertInvoiceQuery       = "INSERT INTO invoices (number, financial_year, booking_id, order_id, farmer_id, owner_id, owner_gstin, place_of_supply, supply_type, date_generated, taxable_value, cgst, sgst, igst, total_amount, currency) VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), $9, $10, $11, $12, $13, $14, $15, $16) RETURNING id"
+	insertLineItemQuery      = "INSERT INTO invoice_line_items (invoice_id, booking_id, kind, description, hsn_sac, quantity, unit_amount, amount, tax_rate_bp) VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9) RETURNING id"
+	getInvoicesQuery         = "SELECT " + invoiceColumns + " FROM invoices WHERE farmer_id = $1 or owner_id = $1 ORDER BY date_generated DESC, id DESC"
+	getInvoiceQuery          = "SELECT " + invoiceColumns + " FROM invoices WHERE id = $1"
+	getInvoicePartyQuery     = "SELECT id, fname || ' ' || lname AS name, address, COALESCE(state, '') AS state, COALESCE(gstin, '') AS gstin FROM farmers WHERE id = $1"
+	getInvoiceBookingsQuery  = "SELECT b.id, b.machine_id, m.name, to_char(s.date, 'YYYY-MM-DD'), s.slot_id FROM bookings b JOIN machines m ON m.id = b.machine_id JOIN slots_booked s ON s.booking_id = b.id WHERE b.id IN (SELECT booking_id FROM invoice_line_items WHERE invoice_id = $1) ORDER BY b.id, s.date, s.slot_id"
+	getInvoiceLineItemsQuery = "SELECT " + lineItemColumns + " FROM invoice_line_items l JOIN invoices i ON i.id = l.invoice_id WHERE l.invoice_id = ANY($1) ORDER BY l.invoice_id, l.id"
+)
+
+// financialYear returns the Indian financial year, April to March, that the
+// date falls in, e.g. "2026-27".
+func financialYear(date time.Time) string {
+	start := date.Year()
+	if date.Month() < time.April {
+		start--
+	}
+	return fmt.Sprintf("%d-%02d", start, (start+1)%100)
+}
+
+// createInvoice numbers the invoice, stores it with its line items and tells
+// the renter it was issued. It must run inside the transaction that creates
+// what is being invoiced: the sequence row stays locked until that transaction
+// ends, and a rollback gives the number back, so numbers have no gaps.
+func createInvoice(ctx context.Context, tx *sqlx.Tx, invoice *domain.Invoice) (err error) {
+
+	date, err := time.Parse("2006-01-02", invoice.DateGenrated)
+	if err != nil {
+		return
+	}
+	invoice.FinancialYear = financialYear(date)
+
+	var number uint
+	err = tx.QueryRowxContext(ctx, nextInvoiceNumberQuery, invoice.FinancialYear).Scan(&number)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error allocating invoice number")
+		return
+	}
+	invoice.Number = fmt.Sprintf("FE/%s/%06d", invoice.FinancialYear, number)
+
+	var ownerState, renterState string
+	err = tx.QueryRowxContext(ctx, getInvoicePartiesQuery, invoice.OwnerId, invoice.FarmerId).Scan(&ownerState, &invoice.OwnerGSTIN, &renterState)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting invoice parties")
+		return
+	}
+	invoice.PlaceOfSupply = renterState
+	err = applyTax(invoice, ownerState, renterState)
+	if err != nil {
+		return
+	}
+
+	err = tx.QueryRowxContext(ctx, insertInvoiceQuery, invoice.Number, invoice.FinancialYear, invoice.BookingId, invoice.OrderId, invoice.FarmerId, invoice.OwnerId, invoice.OwnerGSTIN, invoice.PlaceOfSupply, invoice.SupplyType, invoice.DateGenrated, invoice.TaxableValue.Minor, invoice.CGST.Minor, invoice.SGST.Minor, invoice.IGST.Minor, invoice.Amount.Minor, invoice.Amount.Currency).Scan(&invoice.Id)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error generating invoice")
+		return
+	}
+
+	for i := range invoice.LineItems {
+		item := &invoice.LineItems[i]
+		item.InvoiceId = invoice.Id
+		err = tx.QueryRowxContext(ctx, insertLineItemQuery, item.InvoiceId, item.BookingId, item.Kind, item.Description, item.HsnSac, item.Quantity, item.UnitAmount.Minor, item.Amount.Minor, item.TaxRate).Scan(&item.Id)
+		if err != nil {
+			logger.WithField("err", err.Error()).Error("Error inserting invoice line item")
+			return
+		}
+	}
+
+	for _, event := range domain.NewInvoiceEvents(*invoice) {
+		err = addOutboxEvent(ctx, tx, event)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// applyTax totals the invoice and, when the owner is registered for GST, adds
+// a tax line per tax and rate. Taxable lines at the same rate are taxed
+// together so that rounding happens once per rate. All lines must be in the
+// same currency.
+func applyTax(invoice *domain.Invoice, ownerState string, renterState string) (err error) {
+
+	invoice.SupplyType = tax.SupplyType(ownerState, renterState)
+
+	if len(invoice.LineItems) == 0 {
+		invoice.TaxableValue = invoice.Amount
+		invoice.CGST, invoice.SGST, invoice.IGST = domain.NewMoney(0, invoice.Amount.Currency), domain.NewMoney(0, invoice.Amount.Currency), domain.NewMoney(0, invoice.Amount.Currency)
+		return
+	}
+
+	var rates []uint
+	taxableByRate := map[uint]domain.Money{}
+	invoice.TaxableValue = domain.Money{}
+	for _, item := range invoice.LineItems {
+		if !invoice.TaxableValue.SameCurrency(item.Amount) {
+			return domain.ErrCurrencyMismatch
+		}
+		invoice.TaxableValue = invoice.TaxableValue.Add(item.Amount)
+		if item.TaxRate == 0 {
+			continue
+		}
+		if _, ok := taxableByRate[item.TaxRate]; !ok {
+			rates = append(rates, item.TaxRate)
+		}
+		taxableByRate[item.TaxRate] = taxableByRate[item.TaxRate].Add(item.Amount)
+	}
+
+	total := tax.Compute(domain.NewMoney(0, invoice.TaxableValue.Currency), 0, invoice.SupplyType)
+	if invoice.OwnerGSTIN != "" {
+		for _, rate := range rates {
+			breakdown := tax.Compute(taxableByRate[rate], rate, invoice.SupplyType)
+			invoice.LineItems = append(invoice.LineItems, taxItems(breakdown, rate)...)
+			total.Add(breakdown)
+		}
+	}
+
+	invoice.CGST, invoice.SGST, invoice.IGST = total.CGST, total.SGST, total.IGST
+	invoice.Amount = invoice.TaxableValue.Add(total.Total())
+	return
+}
+
+func taxItems(breakdown tax.Breakdown, rate uint) (items []domain.InvoiceLineItem) {
+	item := func(name string, rate uint, amount domain.Money) domain.InvoiceLineItem {
+		return domain.InvoiceLineItem{
+			Kind:        domain.LineItemTax,
+			Description: name + " @ " + tax.FormatRate(rate),
+			Quantity:    1,
+			UnitAmount:  amount,
+			Amount:      amount,
+		}
+	}
+	if breakdown.SupplyType == tax.InterState {
+		return append(items, item("IGST", rate, breakdown.IGST))
+	}
+	return append(items, item("CGST", rate/2, breakdown.CGST), item("SGST", rate/2, breakdown.SGST))
+}
+
+// GetInvoices returns the invoices the farmer was billed as a renter or issued
+// as a machine owner, newest first.
+func (s *pgStore) GetInvoices(ctx context.Context, farmerId uint) (invoices []domain.Invoice, err error) {
+
+	err = s.db.SelectContext(ctx, &invoices, getInvoicesQuery, farmerId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting invoices")
+		return
+	}
+
+	err = s.getLineItems(ctx, invoices)
+	return
+}
+
+func (s *pgStore) GetInvoice(ctx context.Context, invoiceId uint) (invoice domain.Invoice, err error) {
+
+	err = s.db.GetContext(ctx, &invoice, getInvoiceQuery, invoiceId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting invoice")
+		return
+	}
+
+	invoices := []domain.Invoice{invoice}
+	err = s.getLineItems(ctx, invoices)
+	invoice = invoices[0]
+	return
+}
+
+// GetInvoiceDocument returns the invoice with its parties and the bookings it
+// bills, for printing.
+func (s *pgStore) GetInvoiceDocument(ctx context.Context, invoiceId uint) (doc domain.InvoiceDocument, err error) {
+
+	doc.Invoice, err = s.GetInvoice(ctx, invoiceId)
+	if err != nil {
+		return
+	}
+
+	err = s.db.GetContext(ctx, &doc.Renter, getInvoicePartyQuery, doc.FarmerId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting invoice renter")
+		return
+	}
+
+	err = s.db.GetContext(ctx, &doc.Owner, getInvoicePartyQuery, doc.OwnerId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting invoice owner")
+		return
+	}
+
+	rows, err := s.db.QueryContext(ctx, getInvoiceBookingsQuery, invoiceId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting invoice bookings")
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var booking domain.InvoiceBooking
+		var slotId uint
+		err = rows.Scan(&booking.BookingId, &booking.MachineId, &booking.MachineName, &booking.Date, &slotId)
+		if err != nil {
+			logger.WithField("err", err.Error()).Error("Error scanning invoice bookings")
+			return
+		}
+
+		last := len(doc.Bookings) - 1
+		if last >= 0 && doc.Bookings[last].BookingId == booking.BookingId && doc.Bookings[last].Date == booking.Date {
+			doc.Bookings[last].Slots = append(doc.Bookings[last].Slots, slotId)
+			continue
+		}
+		booking.Slots = []uint{slotId}
+		doc.Bookings = append(doc.Bookings, booking)
+	}
+
+	return doc, rows.Err()
+}
+
+func (s *pgStore) getLineItems(ctx context.Context, invoices []domain.Invoice) (err error) {
+
+	if len(invoices) == 0 {
+		return
+	}
+
+	index := map[uint]int{}
+	ids := make([]int64, len(invoices))
+	for i, invoice := range invoices {
+		index[invoice.Id] = i
+		ids[i] = int64(invoice.Id)
+		invoices[i].LineItems = []domain.InvoiceLineItem{}
+	}
+
+	var items []domain.InvoiceLineItem
+	err = s.db.SelectContext(ctx, &items, getInvoiceLineItemsQuery, pq.Array(ids))
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting invoice line items")
+		return
+	}
+
+	for _, item := range items {
+		i := index[item.InvoiceId]
+		invoices[i].LineItems = append(invoices[i].LineItems, item)
+	}
+	return
+}
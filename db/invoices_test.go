@@ -0,0 +1,160 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+// expectCreateInvoice expects the number allocation, the lookup of the
+// parties, who are both in state 27, the invoice row, its line items and the
+// events telling the renter and the owner's partners.
+func (s *DbTestSuite) expectCreateInvoice(invoiceId, number, ownerId uint, ownerGSTIN string, amount uint, lineItems int) {
+	s.mock.ExpectQuery("INSERT INTO invoice_sequences").WillReturnRows(sqlxmock.NewRows([]string{"last_number"}).AddRow(number))
+	s.mock.ExpectQuery("SELECT (.+) FROM farmers o, farmers r").WithArgs(ownerId, sqlxmock.AnyArg()).WillReturnRows(sqlxmock.NewRows([]string{"state", "gstin", "state"}).AddRow("27", ownerGSTIN, "27"))
+	any := sqlxmock.AnyArg()
+	s.mock.ExpectQuery("INSERT INTO invoices").WithArgs(any, any, any, any, any, ownerId, ownerGSTIN, "27", "intra_state", any, any, any, any, any, amount, "INR").WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(invoiceId))
+	for i := 0; i < lineItems; i++ {
+		s.mock.ExpectQuery("INSERT INTO invoice_line_items").WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(i + 1))
+	}
+	s.expectOutboxEvent(domain.EventInvoiceIssued, any)
+	s.expectOutboxEvent(domain.EventInvoiceIssued, ownerId)
+}
+
+func Test_financialYear(t *testing.T) {
+	tests := []struct {
+		date string
+		want string
+	}{
+		{"2026-03-31", "2025-26"},
+		{"2026-04-01", "2026-27"},
+		{"2026-12-31", "2026-27"},
+		{"2099-06-15", "2099-00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			date, err := time.Parse("2006-01-02", tt.date)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, financialYear(date))
+		})
+	}
+}
+
+func (s *DbTestSuite) Test_pgStore_GenrateInvoice_numbersPerFinancialYear() {
+	t := s.T()
+
+	bookingId := uint(5)
+	invoice := domain.Invoice{
+		BookingId:    5,
+		FarmerId:     9,
+		OwnerId:      20,
+		DateGenrated: "2027-02-10",
+		LineItems: []domain.InvoiceLineItem{
+			{BookingId: &bookingId, Kind: domain.LineItemRental, Description: "Tractor, 2 hourly slots", HsnSac: "997314", Quantity: 2, UnitAmount: domain.Rupees(300), Amount: domain.Rupees(600), TaxRate: 1800},
+			{Kind: domain.LineItemDiscount, Description: "Early bird", Quantity: 1, UnitAmount: domain.Rupees(-100), Amount: domain.Rupees(-100), TaxRate: 1800},
+		},
+	}
+
+	// the owner is registered in Maharashtra and the renter is in Karnataka,
+	// so the rental is inter-state and pays IGST
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("INSERT INTO invoice_sequences").WithArgs("2026-27").WillReturnRows(sqlxmock.NewRows([]string{"last_number"}).AddRow(42))
+	s.mock.ExpectQuery("SELECT (.+) FROM farmers o, farmers r").WithArgs(20, 9).WillReturnRows(sqlxmock.NewRows([]string{"state", "gstin", "state"}).AddRow("27", "27AAPFU0939F1ZV", "29"))
+	s.mock.ExpectQuery("INSERT INTO invoices").WithArgs("FE/2026-27/000042", "2026-27", 5, nil, 9, 20, "27AAPFU0939F1ZV", "29", "inter_state", "2027-02-10", 50000, 0, 0, 9000, 59000, "INR").WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(7))
+	s.mock.ExpectQuery("INSERT INTO invoice_line_items").WithArgs(7, &bookingId, domain.LineItemRental, "Tractor, 2 hourly slots", "997314", 2, 30000, 60000, 1800).WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectQuery("INSERT INTO invoice_line_items").WithArgs(7, nil, domain.LineItemDiscount, "Early bird", "", 1, -10000, -10000, 1800).WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(2))
+	s.mock.ExpectQuery("INSERT INTO invoice_line_items").WithArgs(7, nil, domain.LineItemTax, "IGST @ 18%", "", 1, 9000, 9000, 0).WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(3))
+	s.mock.ExpectExec("INSERT INTO outbox_events").WithArgs(domain.EventInvoiceIssued, 9, 7, "Invoice FE/2026-27/000042 for 590.00 INR was issued to you", sqlxmock.AnyArg()).WillReturnResult(sqlxmock.NewResult(1, 1))
+	s.mock.ExpectExec("INSERT INTO outbox_events").WithArgs(domain.EventInvoiceIssued, 20, 7, "", `{"invoice_id":7,"number":"FE/2026-27/000042","booking_id":5,"renter_id":9,"owner_id":20,"amount":{"amount":"590.00","currency":"INR"}}`).WillReturnResult(sqlxmock.NewResult(1, 1))
+	s.mock.ExpectCommit()
+
+	got, err := s.repo.GenrateInvoice(context.TODO(), invoice)
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), got)
+	assert.NoError(t, s.mock.ExpectationsWereMet())
+}
+
+func (s *DbTestSuite) Test_pgStore_GetInvoice() {
+	t := s.T()
+
+	s.Run("with line items", func() {
+		s.mock.ExpectQuery("SELECT (.+) FROM invoices WHERE id = \\$1").WithArgs(7).WillReturnRows(
+			sqlxmock.NewRows([]string{"id", "number", "financial_year", "booking_id", "order_id", "farmer_id", "owner_id", "date_generated", "amount.minor", "amount.currency"}).
+				AddRow(7, "FE/2026-27/000042", "2026-27", 5, nil, 9, 20, "2027-02-10", 60000, "INR"))
+		s.mock.ExpectQuery("SELECT (.+) FROM invoice_line_items l JOIN invoices i (.+) ANY").WillReturnRows(
+			sqlxmock.NewRows([]string{"id", "invoice_id", "booking_id", "kind", "description", "quantity", "unit_amount.minor", "unit_amount.currency", "amount.minor", "amount.currency"}).
+				AddRow(1, 7, 5, "rental", "Tractor, 2 hourly slots", 2, 30000, "INR", 60000, "INR"))
+
+		got, err := s.repo.GetInvoice(context.TODO(), 7)
+		require.NoError(t, err)
+		assert.Equal(t, "FE/2026-27/000042", got.Number)
+		require.Len(t, got.LineItems, 1)
+		assert.Equal(t, domain.Rupees(600), got.LineItems[0].Amount)
+		assert.Equal(t, uint(5), *got.LineItems[0].BookingId)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("when missing", func() {
+		s.mock.ExpectQuery("SELECT (.+) FROM invoices WHERE id = \\$1").WithArgs(8).WillReturnError(sql.ErrNoRows)
+
+		_, err := s.repo.GetInvoice(context.TODO(), 8)
+		assert.Equal(t, sql.ErrNoRows, err)
+	})
+}
+
+func (s *DbTestSuite) Test_pgStore_GetInvoices() {
+	t := s.T()
+
+	s.mock.ExpectQuery("SELECT (.+) FROM invoices WHERE farmer_id = \\$1 or owner_id = \\$1").WithArgs(9).WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "number", "financial_year", "booking_id", "order_id", "farmer_id", "owner_id", "date_generated", "amount.minor", "amount.currency"}).
+			AddRow(8, "FE/2026-27/000043", "2026-27", 0, 4, 9, 21, "2027-02-11", 40000, "INR").
+			AddRow(7, "FE/2026-27/000042", "2026-27", 5, nil, 9, 20, "2027-02-10", 60000, "INR"))
+	s.mock.ExpectQuery("SELECT (.+) FROM invoice_line_items l JOIN invoices i (.+) ANY").WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "invoice_id", "booking_id", "kind", "description", "quantity", "unit_amount.minor", "unit_amount.currency", "amount.minor", "amount.currency"}).
+			AddRow(1, 7, 5, "rental", "Tractor, 2 hourly slots", 2, 30000, "INR", 60000, "INR").
+			AddRow(2, 8, 6, "rental", "Harvester, 1 hourly slots", 1, 40000, "INR", 40000, "INR"))
+
+	got, err := s.repo.GetInvoices(context.TODO(), 9)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, uint(4), *got[0].OrderId)
+	assert.Equal(t, uint(8), got[0].LineItems[0].InvoiceId)
+	assert.Equal(t, uint(7), got[1].LineItems[0].InvoiceId)
+	assert.NoError(t, s.mock.ExpectationsWereMet())
+}
+
+func (s *DbTestSuite) Test_pgStore_GetInvoiceDocument() {
+	t := s.T()
+
+	s.mock.ExpectQuery("SELECT (.+) FROM invoices WHERE id = \\$1").WithArgs(7).WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "number", "financial_year", "booking_id", "order_id", "farmer_id", "owner_id", "date_generated", "amount.minor", "amount.currency"}).
+			AddRow(7, "FE/2026-27/000042", "2026-27", 5, nil, 9, 20, "2027-02-10", 60000, "INR"))
+	s.mock.ExpectQuery("SELECT (.+) FROM invoice_line_items l JOIN invoices i (.+) ANY").WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "invoice_id", "booking_id", "kind", "description", "quantity", "unit_amount.minor", "unit_amount.currency", "amount.minor", "amount.currency"}).
+			AddRow(1, 7, 5, "rental", "Tractor, 3 hourly slots", 3, 20000, "INR", 60000, "INR"))
+	s.mock.ExpectQuery("SELECT (.+) FROM farmers WHERE id = \\$1").WithArgs(9).WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "name", "address", "state", "gstin"}).AddRow(9, "Ramesh Patil", "Baramati", "27", ""))
+	s.mock.ExpectQuery("SELECT (.+) FROM farmers WHERE id = \\$1").WithArgs(20).WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "name", "address", "state", "gstin"}).AddRow(20, "Suresh Jadhav", "Indapur", "27", "27AAPFU0939F1ZV"))
+	s.mock.ExpectQuery("SELECT (.+) FROM bookings b (.+) WHERE b.id IN").WithArgs(7).WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "machine_id", "name", "date", "slot_id"}).
+			AddRow(5, 4, "Tractor", "2027-02-12", 7).
+			AddRow(5, 4, "Tractor", "2027-02-12", 8).
+			AddRow(5, 4, "Tractor", "2027-02-13", 7))
+
+	got, err := s.repo.GetInvoiceDocument(context.TODO(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, "Ramesh Patil", got.Renter.Name)
+	assert.Equal(t, "27AAPFU0939F1ZV", got.Owner.GSTIN)
+	assert.Equal(t, []domain.InvoiceBooking{
+		{BookingId: 5, MachineId: 4, MachineName: "Tractor", Date: "2027-02-12", Slots: []uint{7, 8}},
+		{BookingId: 5, MachineId: 4, MachineName: "Tractor", Date: "2027-02-13", Slots: []uint{7}},
+	}, got.Bookings)
+	assert.NoError(t, s.mock.ExpectationsWereMet())
+}
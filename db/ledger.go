@@ -0,0 +1,168 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"FarmEasy/ledger"
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	addLedgerTransactionQuery  = "INSERT INTO ledger_transactions (kind, reference, description) VALUES ($1, $2, $3) RETURNING id, created_at"
+	addLedgerEntryQuery        = "INSERT INTO ledger_entries (transaction_id, account, farmer_id, amount, currency) VALUES ($1, $2, NULLIF($3, 0), $4, $5) RETURNING id"
+	getLedgerTransactionsQuery = "SELECT id, kind, reference, description, created_at FROM ledger_transactions WHERE reference = $1 ORDER BY id"
+	getLedgerEntriesQuery      = "SELECT id, transaction_id, account, COALESCE(farmer_id, 0) AS farmer_id, amount AS \"amount.minor\", currency AS \"amount.currency\" FROM ledger_entries WHERE transaction_id = ANY($1) ORDER BY id"
+	getOwnerStatementQuery     = "SELECT t.id AS transaction_id, t.kind, t.reference, t.description, -e.amount AS \"amount.minor\", e.currency AS \"amount.currency\", t.created_at FROM ledger_entries e JOIN ledger_transactions t ON t.id = e.transaction_id WHERE e.account = 'owner_payable' and e.farmer_id = $1 ORDER BY t.id, e.id"
+	getOwnerBalancesQuery      = "SELECT farmer_id, -SUM(amount) AS balance, currency FROM ledger_entries WHERE account = 'owner_payable' GROUP BY farmer_id, currency HAVING -SUM(amount) > 0 ORDER BY farmer_id, currency"
+	lockPayoutsQuery           = "LOCK TABLE payouts IN SHARE ROW EXCLUSIVE MODE"
+	addPayoutBatchQuery        = "INSERT INTO payout_batches DEFAULT VALUES RETURNING id, created_at"
+	addPayoutQuery             = "INSERT INTO payouts (batch_id, owner_id, amount, currency) VALUES ($1, $2, $3, $4) RETURNING id, status, created_at"
+	getPayoutsQuery            = "SELECT id, batch_id, owner_id, amount AS \"amount.minor\", currency AS \"amount.currency\", status, created_at FROM payouts WHERE owner_id = $1 ORDER BY id DESC"
+)
+
+// postTransaction writes a balanced transaction inside tx. The database
+// checks the balance again when tx commits.
+func postTransaction(ctx context.Context, tx *sqlx.Tx, t *domain.LedgerTransaction) (err error) {
+
+	if err = ledger.Validate(*t); err != nil {
+		logger.WithField("err", err.Error()).WithField("reference", t.Reference).Error("Refusing ledger transaction")
+		return
+	}
+
+	err = tx.QueryRowContext(ctx, addLedgerTransactionQuery, t.Kind, t.Reference, t.Description).Scan(&t.Id, &t.CreatedAt)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error inserting ledger transaction")
+		return
+	}
+
+	for i := range t.Entries {
+		entry := &t.Entries[i]
+		entry.TransactionId = t.Id
+		err = tx.QueryRowContext(ctx, addLedgerEntryQuery, t.Id, entry.Account, entry.FarmerId, entry.Amount.Minor, entry.Amount.Currency).Scan(&entry.Id)
+		if err != nil {
+			logger.WithField("err", err.Error()).Error("Error inserting ledger entry")
+			return
+		}
+	}
+
+	return
+}
+
+// getTransactions returns the transactions posted for the reference, with
+// their entries.
+func getTransactions(ctx context.Context, tx *sqlx.Tx, reference string) (transactions []domain.LedgerTransaction, err error) {
+
+	err = tx.SelectContext(ctx, &transactions, getLedgerTransactionsQuery, reference)
+	if err != nil || len(transactions) == 0 {
+		return
+	}
+
+	index := map[uint]int{}
+	ids := make([]int64, len(transactions))
+	for i, t := range transactions {
+		index[t.Id] = i
+		ids[i] = int64(t.Id)
+	}
+
+	var entries []domain.LedgerEntry
+	err = tx.SelectContext(ctx, &entries, getLedgerEntriesQuery, pq.Array(ids))
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting ledger entries")
+		return
+	}
+	for _, entry := range entries {
+		i := index[entry.TransactionId]
+		transactions[i].Entries = append(transactions[i].Entries, entry)
+	}
+
+	return
+}
+
+func (s *pgStore) GetOwnerStatement(ctx context.Context, ownerId uint) (statement []domain.StatementLine, err error) {
+
+	err = s.db.SelectContext(ctx, &statement, getOwnerStatementQuery, ownerId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting owner statement")
+		return
+	}
+
+	return
+}
+
+func (s *pgStore) GetPayouts(ctx context.Context, ownerId uint) (payouts []domain.Payout, err error) {
+
+	err = s.db.SelectContext(ctx, &payouts, getPayoutsQuery, ownerId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting payouts")
+		return
+	}
+
+	return
+}
+
+// SettleOwnerBalances pays every owner what the platform owes them, in one
+// batch. Settlements are serialized on the payouts table so that a balance is
+// never paid out twice. An empty batch is not kept.
+func (s *pgStore) SettleOwnerBalances(ctx context.Context) (batch domain.PayoutBatch, err error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, lockPayoutsQuery)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error locking payouts")
+		return
+	}
+
+	rows, err := tx.QueryContext(ctx, getOwnerBalancesQuery)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting owner balances")
+		return
+	}
+	for rows.Next() {
+		var payout domain.Payout
+		err = rows.Scan(&payout.OwnerId, &payout.Amount.Minor, &payout.Amount.Currency)
+		if err != nil {
+			logger.WithField("err", err.Error()).Error("Error scanning owner balances")
+			rows.Close()
+			return
+		}
+		batch.Payouts = append(batch.Payouts, payout)
+	}
+	rows.Close()
+
+	if len(batch.Payouts) == 0 {
+		return
+	}
+
+	err = tx.QueryRowContext(ctx, addPayoutBatchQuery).Scan(&batch.Id, &batch.CreatedAt)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error inserting payout batch")
+		return
+	}
+
+	for i := range batch.Payouts {
+		payout := &batch.Payouts[i]
+		payout.BatchId = batch.Id
+		err = tx.QueryRowContext(ctx, addPayoutQuery, batch.Id, payout.OwnerId, payout.Amount.Minor, payout.Amount.Currency).Scan(&payout.Id, &payout.Status, &payout.CreatedAt)
+		if err != nil {
+			logger.WithField("err", err.Error()).Error("Error inserting payout")
+			return
+		}
+
+		transaction := ledger.Payout(*payout)
+		err = postTransaction(ctx, tx, &transaction)
+		if err != nil {
+			return
+		}
+	}
+
+	err = tx.Commit()
+	return
+}
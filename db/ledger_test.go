@@ -0,0 +1,58 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+// expectPostTransaction expects a ledger transaction with an entry for each
+// of the farmers, zero standing for a platform account.
+func (s *DbTestSuite) expectPostTransaction(kind string, reference string, farmerIds ...uint) {
+	s.mock.ExpectQuery("INSERT INTO ledger_transactions").WithArgs(kind, reference, sqlxmock.AnyArg()).WillReturnRows(sqlxmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+	for i, farmerId := range farmerIds {
+		s.mock.ExpectQuery("INSERT INTO ledger_entries").WithArgs(1, sqlxmock.AnyArg(), farmerId, sqlxmock.AnyArg(), sqlxmock.AnyArg()).WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(i + 1))
+	}
+}
+
+func (s *DbTestSuite) Test_pgStore_SettleOwnerBalances() {
+	t := s.T()
+
+	s.Run("pays every owner", func() {
+		now := time.Now()
+		s.mock.ExpectBegin()
+		s.mock.ExpectExec("LOCK TABLE payouts").WillReturnResult(sqlxmock.NewResult(0, 0))
+		s.mock.ExpectQuery("SELECT farmer_id, -SUM\\(amount\\) AS balance, currency FROM ledger_entries").WillReturnRows(
+			sqlxmock.NewRows([]string{"farmer_id", "balance", "currency"}).AddRow(20, 64800, "INR").AddRow(21, 1000, "USD"))
+		s.mock.ExpectQuery("INSERT INTO payout_batches").WillReturnRows(sqlxmock.NewRows([]string{"id", "created_at"}).AddRow(2, now))
+		s.mock.ExpectQuery("INSERT INTO payouts").WithArgs(2, 20, 64800, "INR").WillReturnRows(sqlxmock.NewRows([]string{"id", "status", "created_at"}).AddRow(5, "pending", now))
+		s.expectPostTransaction("payout", "payout:5", 20, 0)
+		s.mock.ExpectQuery("INSERT INTO payouts").WithArgs(2, 21, 1000, "USD").WillReturnRows(sqlxmock.NewRows([]string{"id", "status", "created_at"}).AddRow(6, "pending", now))
+		s.expectPostTransaction("payout", "payout:6", 21, 0)
+		s.mock.ExpectCommit()
+
+		batch, err := s.repo.SettleOwnerBalances(context.TODO())
+		require.NoError(t, err)
+		assert.Equal(t, uint(2), batch.Id)
+		require.Len(t, batch.Payouts, 2)
+		assert.Equal(t, domain.Rupees(648), batch.Payouts[0].Amount)
+		assert.Equal(t, domain.NewMoney(1000, "USD"), batch.Payouts[1].Amount)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("when nothing is owed", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectExec("LOCK TABLE payouts").WillReturnResult(sqlxmock.NewResult(0, 0))
+		s.mock.ExpectQuery("SELECT farmer_id, -SUM\\(amount\\) AS balance, currency FROM ledger_entries").WillReturnRows(sqlxmock.NewRows([]string{"farmer_id", "balance", "currency"}))
+		s.mock.ExpectRollback()
+
+		batch, err := s.repo.SettleOwnerBalances(context.TODO())
+		require.NoError(t, err)
+		assert.Empty(t, batch.Payouts)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	tryAdvisoryLockQuery = "SELECT pg_try_advisory_lock($1)"
+	advisoryUnlockQuery  = "SELECT pg_advisory_unlock($1)"
+)
+
+// TryAdvisoryLock takes the session advisory lock on key if no other session
+// holds it. The lock belongs to the connection it was taken on, so that
+// connection is kept out of the pool until unlock releases the lock.
+func (s *pgStore) TryAdvisoryLock(ctx context.Context, key int64) (unlock func(), locked bool, err error) {
+
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting connection for advisory lock")
+		return
+	}
+
+	err = conn.QueryRowxContext(ctx, tryAdvisoryLockQuery, key).Scan(&locked)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error taking advisory lock")
+		conn.Close()
+		return
+	}
+	if !locked {
+		conn.Close()
+		return
+	}
+
+	unlock = func() {
+		// the lock is released even when the job's context was cancelled
+		if _, err := conn.ExecContext(context.Background(), advisoryUnlockQuery, key); err != nil {
+			logger.WithField("err", err.Error()).Error("Error releasing advisory lock")
+		}
+		conn.Close()
+	}
+	return
+}
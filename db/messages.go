@@ -0,0 +1,127 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	// threadColumns are read for the farmer in $1: the unread count is of the
+	// other party's messages the farmer has not seen.
+	threadColumns = "t.id, t.machine_id, t.booking_id, t.renter_id, t.owner_id, t.created_at, t.updated_at, " +
+		"(SELECT COUNT(*) FROM messages m WHERE m.thread_id = t.id and m.sender_id <> $1 and m.id > CASE WHEN t.renter_id = $1 THEN t.renter_read_id ELSE t.owner_read_id END) AS unread, " +
+		"COALESCE((SELECT body FROM messages m WHERE m.thread_id = t.id ORDER BY m.id DESC LIMIT 1), '') AS last_message, " +
+		"EXISTS (SELECT 1 FROM bookings b WHERE b.status = 'confirmed' and (b.id = t.booking_id or (t.booking_id IS NULL and b.machine_id = t.machine_id and b.farmer_id = t.renter_id))) AS revealed"
+	openEnquiryThreadQuery = "INSERT INTO threads (machine_id, renter_id, owner_id) SELECT id, $2, owner_id FROM machines WHERE id = $1 and owner_id <> $2 " +
+		"ON CONFLICT (machine_id, renter_id) WHERE booking_id IS NULL DO UPDATE SET machine_id = EXCLUDED.machine_id RETURNING id"
+	openBookingThreadQuery = "INSERT INTO threads (machine_id, booking_id, renter_id, owner_id) VALUES ($1, $2, $3, $4) " +
+		"ON CONFLICT (booking_id) DO UPDATE SET booking_id = EXCLUDED.booking_id RETURNING id"
+	getThreadsQuery     = "SELECT " + threadColumns + " FROM threads t WHERE t.renter_id = $1 or t.owner_id = $1 ORDER BY t.updated_at DESC, t.id DESC"
+	getThreadQuery      = "SELECT " + threadColumns + " FROM threads t WHERE t.id = $2"
+	getMessagesQuery    = "SELECT id, thread_id, sender_id, body, created_at FROM messages WHERE thread_id = $1 and ($2 = 0 or id < $2) ORDER BY id DESC LIMIT $3"
+	addMessageQuery     = "INSERT INTO messages (thread_id, sender_id, body) VALUES ($1, $2, $3) RETURNING id, created_at"
+	touchThreadQuery    = "UPDATE threads SET updated_at = NOW() WHERE id = $1"
+	markThreadReadQuery = "UPDATE threads SET renter_read_id = CASE WHEN renter_id = $2 THEN GREATEST(renter_read_id, $3) ELSE renter_read_id END, " +
+		"owner_read_id = CASE WHEN owner_id = $2 THEN GREATEST(owner_read_id, $3) ELSE owner_read_id END WHERE id = $1"
+)
+
+// OpenThread returns the id of the thread of the booking when it has one, or
+// else of the renter's enquiry about the machine, and creates the thread if it
+// does not exist yet. An enquiry returns sql.ErrNoRows if the machine does not
+// exist or the renter owns it.
+func (s *pgStore) OpenThread(ctx context.Context, thread domain.Thread) (threadId uint, err error) {
+
+	if thread.BookingId != nil {
+		err = s.db.QueryRowContext(ctx, openBookingThreadQuery, thread.MachineId, thread.BookingId, thread.RenterId, thread.OwnerId).Scan(&threadId)
+	} else {
+		err = s.db.QueryRowContext(ctx, openEnquiryThreadQuery, thread.MachineId, thread.RenterId).Scan(&threadId)
+	}
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error opening thread")
+		return
+	}
+
+	return
+}
+
+// GetThreads returns the farmer's threads, the most recently active first.
+func (s *pgStore) GetThreads(ctx context.Context, farmerId uint) (threads []domain.Thread, err error) {
+
+	err = s.db.SelectContext(ctx, &threads, getThreadsQuery, farmerId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting threads")
+		return
+	}
+
+	return
+}
+
+// GetThread returns the thread with its unread count for the farmer.
+func (s *pgStore) GetThread(ctx context.Context, threadId uint, farmerId uint) (thread domain.Thread, err error) {
+
+	err = s.db.GetContext(ctx, &thread, getThreadQuery, farmerId, threadId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting thread")
+		return
+	}
+
+	return
+}
+
+// GetMessages returns up to limit messages of the thread older than the
+// message before, or the newest when before is 0, newest first.
+func (s *pgStore) GetMessages(ctx context.Context, threadId uint, before uint, limit uint) (messages []domain.Message, err error) {
+
+	err = s.db.SelectContext(ctx, &messages, getMessagesQuery, threadId, before, limit)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting messages")
+		return
+	}
+
+	return
+}
+
+// AddMessage posts the message, which the sender has read by sending it.
+func (s *pgStore) AddMessage(ctx context.Context, message *domain.Message) (err error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, addMessageQuery, message.ThreadId, message.SenderId, message.Body).Scan(&message.Id, &message.CreatedAt)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error inserting message")
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, touchThreadQuery, message.ThreadId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error touching thread")
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, markThreadReadQuery, message.ThreadId, message.SenderId, message.Id)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error marking thread read")
+		return
+	}
+
+	return tx.Commit()
+}
+
+// MarkThreadRead records that the farmer has seen the thread up to the
+// message. It never moves the farmer's read position back.
+func (s *pgStore) MarkThreadRead(ctx context.Context, threadId uint, farmerId uint, messageId uint) (err error) {
+
+	_, err = s.db.ExecContext(ctx, markThreadReadQuery, threadId, farmerId, messageId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error marking thread read")
+		return
+	}
+
+	return
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func (s *DbTestSuite) Test_pgStore_OpenThread() {
+	t := s.T()
+
+	s.Run("opens the renter's enquiry", func() {
+		s.mock.ExpectQuery("INSERT INTO threads \\(machine_id, renter_id, owner_id\\) SELECT").WithArgs(2, 9).WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(6))
+
+		got, err := s.repo.OpenThread(context.TODO(), domain.Thread{MachineId: 2, RenterId: 9})
+		require.NoError(t, err)
+		assert.Equal(t, uint(6), got)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("when the owner enquires about their own machine", func() {
+		s.mock.ExpectQuery("INSERT INTO threads \\(machine_id, renter_id, owner_id\\) SELECT").WithArgs(2, 20).WillReturnError(sql.ErrNoRows)
+
+		_, err := s.repo.OpenThread(context.TODO(), domain.Thread{MachineId: 2, RenterId: 20})
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("opens the booking's thread", func() {
+		bookingId := uint(5)
+		s.mock.ExpectQuery("INSERT INTO threads \\(machine_id, booking_id, renter_id, owner_id\\) VALUES").WithArgs(2, 5, 9, 20).WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(7))
+
+		got, err := s.repo.OpenThread(context.TODO(), domain.Thread{MachineId: 2, BookingId: &bookingId, RenterId: 9, OwnerId: 20})
+		require.NoError(t, err)
+		assert.Equal(t, uint(7), got)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+}
+
+func (s *DbTestSuite) Test_pgStore_GetThread() {
+	t := s.T()
+	now := time.Now()
+
+	s.mock.ExpectQuery("SELECT (.+) FROM threads t WHERE t.id = \\$2").WithArgs(9, 6).WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "machine_id", "booking_id", "renter_id", "owner_id", "created_at", "updated_at", "unread", "last_message", "revealed"}).
+			AddRow(6, 2, nil, 9, 20, now, now, 3, "is it free on Friday?", false))
+
+	got, err := s.repo.GetThread(context.TODO(), 6, 9)
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), got.Unread)
+	assert.Nil(t, got.BookingId)
+	assert.False(t, got.Revealed)
+	assert.NoError(t, s.mock.ExpectationsWereMet())
+}
+
+func (s *DbTestSuite) Test_pgStore_AddMessage() {
+	t := s.T()
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("INSERT INTO messages").WithArgs(6, 9, "is it free on Friday?").WillReturnRows(sqlxmock.NewRows([]string{"id", "created_at"}).AddRow(41, time.Now()))
+	s.mock.ExpectExec("UPDATE threads SET updated_at = NOW\\(\\)").WithArgs(6).WillReturnResult(sqlxmock.NewResult(0, 1))
+	s.mock.ExpectExec("UPDATE threads SET renter_read_id").WithArgs(6, 9, 41).WillReturnResult(sqlxmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	message := domain.Message{ThreadId: 6, SenderId: 9, Body: "is it free on Friday?"}
+	require.NoError(t, s.repo.AddMessage(context.TODO(), &message))
+	assert.Equal(t, uint(41), message.Id)
+	assert.NoError(t, s.mock.ExpectationsWereMet())
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// noShowNote is the deposit ledger note of a no-show.
+const noShowNote = "renter did not show up"
+
+const (
+	// markNoShowsQuery marks the confirmed bookings that started more than $1
+	// minutes, but less than $2 hours, ago without a check-in. Under the refund
+	// policy of their machine they are cancelled, otherwise they are no-shows.
+	// Older bookings are left alone: they were made before check-ins were
+	// recorded, or the job has long since passed them.
+	markNoShowsQuery = "WITH due AS (SELECT b.id, to_char(MIN(s.date), 'YYYY-MM-DD') AS date FROM bookings b JOIN slots_booked s ON s.booking_id = b.id " +
+		"WHERE b.status = 'confirmed' and NOT EXISTS (SELECT 1 FROM handovers h WHERE h.booking_id = b.id and h.kind = 'check_in') " +
+		"GROUP BY b.id HAVING " + bookingStartColumn + " + make_interval(mins => $1) <= LOCALTIMESTAMP and " + bookingStartColumn + " > LOCALTIMESTAMP - make_interval(hours => $2)) " +
+		"UPDATE bookings b SET status = CASE WHEN m.no_show_policy = 'refund' THEN 'cancelled' ELSE 'no_show' END, no_show_at = NOW() FROM due, machines m " +
+		"WHERE b.id = due.id and m.id = b.machine_id and b.status = 'confirmed' RETURNING b.id AS booking_id, b.farmer_id, b.machine_id, m.owner_id, m.no_show_policy, due.date"
+)
+
+// MarkNoShows marks the bookings whose renter has not checked in graceMinutes
+// after they started, which releases their slots, and tells both parties.
+// Only bookings that started in the last lookbackHours are marked.
+// Bookings cancelled under the refund policy are refunded to the wallet, and
+// deposits released or forfeited, in the same transaction.
+func (s *pgStore) MarkNoShows(ctx context.Context, graceMinutes int, lookbackHours int) (noShows []domain.NoShow, err error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	err = tx.SelectContext(ctx, &noShows, markNoShowsQuery, graceMinutes, lookbackHours)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error marking no-shows")
+		return
+	}
+
+	for i, noShow := range noShows {
+		for _, event := range domain.NewNoShowEvents(noShow) {
+			err = addOutboxEvent(ctx, tx, event)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if noShow.Policy == domain.NoShowRefund {
+			noShows[i].Refund, err = refundCancelledBooking(ctx, tx, noShow.BookingId)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		forfeit := noShow.Policy == domain.NoShowForfeitDeposit
+		noShows[i].Deposit, err = settleBookingDeposit(ctx, tx, noShow.BookingId, forfeit, noShowNote)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	return
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func (s *DbTestSuite) Test_pgStore_MarkNoShows() {
+	t := s.T()
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("WITH due AS (.+) NOT EXISTS \\(SELECT 1 FROM handovers h (.+)\\) GROUP BY (.+) > LOCALTIMESTAMP - make_interval\\(hours => \\$2\\)\\) UPDATE bookings b SET status = CASE WHEN m.no_show_policy = 'refund' THEN 'cancelled' ELSE 'no_show' END").WithArgs(60, 24).WillReturnRows(
+		sqlxmock.NewRows([]string{"booking_id", "farmer_id", "machine_id", "owner_id", "no_show_policy", "date"}).
+			AddRow(11, 9, 2, 20, domain.NoShowForfeitDeposit, "2026-11-07").
+			AddRow(12, 9, 3, 21, domain.NoShowRefund, "2026-11-07"))
+	s.expectOutboxEvent(domain.EventBookingNoShow, 9)
+	s.expectOutboxEvent(domain.EventBookingNoShow, 20)
+	// the forfeit_deposit policy gives all of the deposit to the owner
+	s.expectHeldDeposit(11)
+	s.mock.ExpectQuery("UPDATE deposits SET status = 'captured'").WithArgs(5, 2500000, "INR").WillReturnRows(
+		sqlxmock.NewRows([]string{"booking_id", "farmer_id", "owner_id", "amount", "currency"}).AddRow(11, 9, 20, 2500000, "INR"))
+	s.mock.ExpectExec("INSERT INTO deposit_ledger_entries").WithArgs(5, domain.DepositEntryCapture, 2500000, "renter did not show up").WillReturnResult(sqlxmock.NewResult(1, 1))
+	s.expectPostTransaction("deposit_capture", "deposit:5", 9, 20)
+	s.expectOutboxEvent(domain.EventBookingNoShow, 9)
+	s.expectOutboxEvent(domain.EventBookingNoShow, 21)
+	// the refund policy gives the wallet payment back with the cancellation
+	s.expectWalletRefund(12, 8, 9, 90000)
+	s.expectNoHeldDeposit(12)
+	s.mock.ExpectCommit()
+
+	got, err := s.repo.MarkNoShows(context.TODO(), 60, 24)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.NotNil(t, got[0].Deposit)
+	assert.Equal(t, domain.DepositCaptured, got[0].Deposit.Status)
+	assert.Equal(t, got[0].Deposit.Amount, got[0].Deposit.Captured)
+	got[0].Deposit = nil
+	assert.Equal(t, domain.NoShow{BookingId: 11, FarmerId: 9, MachineId: 2, OwnerId: 20, Policy: domain.NoShowForfeitDeposit, Date: "2026-11-07"}, got[0])
+	require.NotNil(t, got[1].Refund)
+	assert.Equal(t, uint(8), got[1].Refund.PaymentId)
+	assert.NoError(t, s.mock.ExpectationsWereMet())
+}
+
+func (s *DbTestSuite) Test_pgStore_TryAdvisoryLock() {
+	t := s.T()
+
+	s.Run("when the lock is free", func() {
+		s.mock.ExpectQuery("SELECT pg_try_advisory_lock\\(\\$1\\)").WithArgs(42).WillReturnRows(sqlxmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+		s.mock.ExpectExec("SELECT pg_advisory_unlock\\(\\$1\\)").WithArgs(42).WillReturnResult(sqlxmock.NewResult(0, 1))
+
+		unlock, locked, err := s.repo.TryAdvisoryLock(context.TODO(), 42)
+		require.NoError(t, err)
+		require.True(t, locked)
+		unlock()
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("when another server holds it", func() {
+		s.mock.ExpectQuery("SELECT pg_try_advisory_lock\\(\\$1\\)").WithArgs(42).WillReturnRows(sqlxmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(false))
+
+		unlock, locked, err := s.repo.TryAdvisoryLock(context.TODO(), 42)
+		require.NoError(t, err)
+		assert.False(t, locked)
+		assert.Nil(t, unlock)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,250 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	notificationColumns = "n.id, n.event_id, n.farmer_id, n.channel, n.kind, n.message, n.status, n.attempts, n.next_attempt_at, n.last_error, n.sent_at, n.read_at, n.created_at"
+	// bookingStartColumn is when the first slot of the booking starts; slot n
+	// is the hour from n-1 to n.
+	bookingStartColumn    = "MIN(s.date + (s.slot_id - 1) * INTERVAL '1 hour')"
+	addOutboxEventQuery   = "INSERT INTO outbox_events (kind, farmer_id, reference, message, data) VALUES ($1, $2, $3, $4, COALESCE(NULLIF($5, '')::jsonb, '{}'))"
+	lockOutboxEventsQuery = "SELECT id FROM outbox_events WHERE dispatched_at IS NULL ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED"
+	// fanOutEventsQuery makes a delivery of each event on every channel in $2
+	// the farmer has enabled, or that is in the defaults in $3 when the farmer
+	// has not chosen. Events without a message are only for webhooks.
+	fanOutEventsQuery = "INSERT INTO notifications (event_id, farmer_id, channel, kind, message) SELECT e.id, e.farmer_id, c.channel, e.kind, e.message FROM outbox_events e CROSS JOIN unnest($2::text[]) AS c(channel) " +
+		"LEFT JOIN notification_preferences p ON p.farmer_id = e.farmer_id and p.channel = c.channel WHERE e.id = ANY($1) and e.message <> '' and COALESCE(p.enabled, c.channel = ANY($3)) ON CONFLICT (event_id, channel) DO NOTHING"
+	// fanOutWebhooksQuery makes a delivery of each event to every active
+	// webhook subscribed to its kind, of the api clients of the farmer it is for.
+	fanOutWebhooksQuery = "INSERT INTO webhook_deliveries (subscription_id, event_id, kind, payload) SELECT w.id, e.id, e.kind, " +
+		"json_build_object('id', e.id, 'type', e.kind, 'created_at', e.created_at, 'data', e.data) FROM outbox_events e JOIN api_clients c ON c.farmer_id = e.farmer_id " +
+		"JOIN webhook_subscriptions w ON w.client_id = c.id WHERE e.id = ANY($1) and w.active and e.kind = ANY(w.events)"
+	markEventsDispatchedQuery = "UPDATE outbox_events SET dispatched_at = NOW() WHERE id = ANY($1)"
+	getDueNotificationsQuery  = "SELECT " + notificationColumns + ", f.email, f.phone FROM notifications n JOIN farmers f ON f.id = n.farmer_id WHERE n.status = 'pending' and n.next_attempt_at <= NOW() ORDER BY n.next_attempt_at, n.id LIMIT $1"
+	updateNotificationQuery   = "UPDATE notifications SET status = $2, attempts = $3, next_attempt_at = $4, last_error = $5, sent_at = CASE WHEN $2 = 'sent' THEN NOW() END WHERE id = $1"
+	getInboxQuery             = "SELECT " + notificationColumns + " FROM notifications n WHERE n.farmer_id = $1 and n.channel = 'in_app' and n.status = 'sent' ORDER BY n.id DESC LIMIT $2"
+	countUnreadQuery          = "SELECT COUNT(*) FROM notifications WHERE farmer_id = $1 and channel = 'in_app' and status = 'sent' and read_at IS NULL"
+	markNotificationReadQuery = "UPDATE notifications SET read_at = COALESCE(read_at, NOW()) WHERE id = $1 and farmer_id = $2 and channel = 'in_app' RETURNING id"
+	getNotificationPrefsQuery = "SELECT channel, enabled FROM notification_preferences WHERE farmer_id = $1 ORDER BY channel"
+	setNotificationPrefQuery  = "INSERT INTO notification_preferences (farmer_id, channel, enabled) VALUES ($1, $2, $3) ON CONFLICT (farmer_id, channel) DO UPDATE SET enabled = EXCLUDED.enabled"
+	getDueRemindersQuery      = "SELECT b.id, b.farmer_id, b.machine_id, " + bookingStartColumn + " FROM bookings b JOIN slots_booked s ON s.booking_id = b.id WHERE b.status = 'confirmed' and b.reminded_at IS NULL " +
+		"GROUP BY b.id HAVING " + bookingStartColumn + " BETWEEN LOCALTIMESTAMP AND LOCALTIMESTAMP + make_interval(hours => $1)"
+	markRemindedQuery = "UPDATE bookings SET reminded_at = NOW() WHERE id = $1 and reminded_at IS NULL RETURNING id"
+)
+
+// addOutboxEvent stores the event. It must run in the transaction of the
+// change the event describes, so that the farmer is told exactly when the
+// change commits.
+func addOutboxEvent(ctx context.Context, q queryer, event domain.OutboxEvent) (err error) {
+
+	_, err = q.ExecContext(ctx, addOutboxEventQuery, event.Kind, event.FarmerId, event.Reference, event.Message, string(event.Data))
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error inserting outbox event")
+		return
+	}
+
+	return
+}
+
+func (s *pgStore) AddOutboxEvent(ctx context.Context, event domain.OutboxEvent) (err error) {
+	return addOutboxEvent(ctx, s.db, event)
+}
+
+// DispatchOutboxEvents turns up to limit undispatched events into deliveries
+// on the channels each farmer gets them on and to the webhooks subscribed to
+// them. Dispatchers running at the same
+// time take different events.
+func (s *pgStore) DispatchOutboxEvents(ctx context.Context, channels []string, defaults []string, limit uint) (dispatched int, err error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	var ids []int64
+	err = tx.SelectContext(ctx, &ids, lockOutboxEventsQuery, limit)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error locking outbox events")
+		return
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, fanOutEventsQuery, pq.Array(ids), pq.Array(channels), pq.Array(defaults))
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error fanning out outbox events")
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, fanOutWebhooksQuery, pq.Array(ids))
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error fanning out outbox events to webhooks")
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, markEventsDispatchedQuery, pq.Array(ids))
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error marking outbox events dispatched")
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return
+	}
+
+	dispatched = len(ids)
+	return
+}
+
+// GetDueNotifications returns up to limit pending deliveries whose next
+// attempt is due, the longest waiting first.
+func (s *pgStore) GetDueNotifications(ctx context.Context, limit uint) (deliveries []domain.NotificationDelivery, err error) {
+
+	err = s.db.SelectContext(ctx, &deliveries, getDueNotificationsQuery, limit)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting due notifications")
+		return
+	}
+
+	return
+}
+
+// UpdateNotification records the outcome of a delivery attempt.
+func (s *pgStore) UpdateNotification(ctx context.Context, notification domain.Notification) (err error) {
+
+	_, err = s.db.ExecContext(ctx, updateNotificationQuery, notification.Id, notification.Status, notification.Attempts, notification.NextAttemptAt, notification.LastError)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error updating notification")
+		return
+	}
+
+	return
+}
+
+// GetNotificationInbox returns the farmer's latest in-app notifications, up
+// to limit, and how many of all of them are unread.
+func (s *pgStore) GetNotificationInbox(ctx context.Context, farmerId uint, limit uint) (inbox domain.NotificationInbox, err error) {
+
+	err = s.db.SelectContext(ctx, &inbox.Notifications, getInboxQuery, farmerId, limit)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting notifications")
+		return
+	}
+
+	err = s.db.GetContext(ctx, &inbox.Unread, countUnreadQuery, farmerId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error counting unread notifications")
+		return
+	}
+
+	return
+}
+
+// MarkNotificationRead marks the farmer's in-app notification read. It returns
+// sql.ErrNoRows if the farmer has no such notification.
+func (s *pgStore) MarkNotificationRead(ctx context.Context, notificationId uint, farmerId uint) (err error) {
+
+	err = s.db.QueryRowContext(ctx, markNotificationReadQuery, notificationId, farmerId).Scan(&notificationId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error marking notification read")
+		return
+	}
+
+	return
+}
+
+// GetNotificationPreferences returns the channels the farmer has turned on or
+// off. Channels the farmer has not chosen for are not returned.
+func (s *pgStore) GetNotificationPreferences(ctx context.Context, farmerId uint) (preferences []domain.NotificationPreference, err error) {
+
+	err = s.db.SelectContext(ctx, &preferences, getNotificationPrefsQuery, farmerId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting notification preferences")
+		return
+	}
+
+	return
+}
+
+func (s *pgStore) SetNotificationPreferences(ctx context.Context, farmerId uint, preferences []domain.NotificationPreference) (err error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	for _, preference := range preferences {
+		_, err = tx.ExecContext(ctx, setNotificationPrefQuery, farmerId, preference.Channel, preference.Enabled)
+		if err != nil {
+			logger.WithField("err", err.Error()).Error("Error setting notification preference")
+			return
+		}
+	}
+
+	return tx.Commit()
+}
+
+// QueueBookingReminders reminds the renters of confirmed bookings that start
+// within the hours and have not been reminded of yet.
+func (s *pgStore) QueueBookingReminders(ctx context.Context, hours int) (queued int, err error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, getDueRemindersQuery, hours)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting due booking reminders")
+		return
+	}
+
+	var events []domain.OutboxEvent
+	for rows.Next() {
+		var bookingId, farmerId, machineId uint
+		var startsAt time.Time
+		err = rows.Scan(&bookingId, &farmerId, &machineId, &startsAt)
+		if err != nil {
+			logger.WithField("err", err.Error()).Error("Error scanning due booking reminders")
+			rows.Close()
+			return
+		}
+		events = append(events, domain.NewReminderEvent(farmerId, bookingId, machineId, startsAt))
+	}
+	rows.Close()
+
+	for _, event := range events {
+		// another run may have reminded the booking since it was read
+		var bookingId uint
+		err = tx.QueryRowContext(ctx, markRemindedQuery, event.Reference).Scan(&bookingId)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			logger.WithField("err", err.Error()).Error("Error marking booking reminded")
+			return
+		}
+
+		err = addOutboxEvent(ctx, tx, event)
+		if err != nil {
+			return
+		}
+		queued++
+	}
+
+	err = tx.Commit()
+	return
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func (s *DbTestSuite) expectOutboxEvent(kind string, farmerId driver.Value) {
+	s.mock.ExpectExec("INSERT INTO outbox_events").WithArgs(kind, farmerId, sqlxmock.AnyArg(), sqlxmock.AnyArg(), sqlxmock.AnyArg()).WillReturnResult(sqlxmock.NewResult(1, 1))
+}
+
+func (s *DbTestSuite) Test_pgStore_DispatchOutboxEvents() {
+	t := s.T()
+
+	s.Run("when there are new events", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("SELECT id FROM outbox_events (.+) FOR UPDATE SKIP LOCKED").WithArgs(100).WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(4).AddRow(5))
+		s.mock.ExpectExec("INSERT INTO notifications").WithArgs(pq.Array([]int64{4, 5}), pq.Array([]string{"email", "in_app"}), pq.Array([]string{"in_app"})).WillReturnResult(sqlxmock.NewResult(0, 3))
+		s.mock.ExpectExec("INSERT INTO webhook_deliveries").WithArgs(pq.Array([]int64{4, 5})).WillReturnResult(sqlxmock.NewResult(0, 1))
+		s.mock.ExpectExec("UPDATE outbox_events SET dispatched_at").WithArgs(pq.Array([]int64{4, 5})).WillReturnResult(sqlxmock.NewResult(0, 2))
+		s.mock.ExpectCommit()
+
+		got, err := s.repo.DispatchOutboxEvents(context.TODO(), []string{"email", "in_app"}, []string{"in_app"}, 100)
+		require.NoError(t, err)
+		assert.Equal(t, 2, got)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("when every event was dispatched", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("SELECT id FROM outbox_events").WillReturnRows(sqlxmock.NewRows([]string{"id"}))
+		s.mock.ExpectRollback()
+
+		got, err := s.repo.DispatchOutboxEvents(context.TODO(), []string{"in_app"}, []string{"in_app"}, 100)
+		require.NoError(t, err)
+		assert.Equal(t, 0, got)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+}
+
+func (s *DbTestSuite) Test_pgStore_QueueBookingReminders() {
+	t := s.T()
+
+	startsAt := time.Date(2026, 11, 7, 6, 0, 0, 0, time.UTC)
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT b.id, b.farmer_id, b.machine_id, (.+) FROM bookings b").WithArgs(24).WillReturnRows(sqlxmock.NewRows([]string{"id", "farmer_id", "machine_id", "min"}).AddRow(11, 9, 2, startsAt).AddRow(12, 10, 3, startsAt))
+	s.mock.ExpectQuery("UPDATE bookings SET reminded_at").WithArgs(11).WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(11))
+	s.mock.ExpectExec("INSERT INTO outbox_events").WithArgs(domain.EventBookingReminder, 9, 11, "Your booking 11 of machine 2 starts at 2026-11-07 06:00", `{"booking_id":11,"machine_id":2}`).WillReturnResult(sqlxmock.NewResult(1, 1))
+	// booking 12 was reminded by another run in the meantime
+	s.mock.ExpectQuery("UPDATE bookings SET reminded_at").WithArgs(12).WillReturnError(sql.ErrNoRows)
+	s.mock.ExpectCommit()
+
+	got, err := s.repo.QueueBookingReminders(context.TODO(), 24)
+	require.NoError(t, err)
+	assert.Equal(t, 1, got)
+	assert.NoError(t, s.mock.ExpectationsWereMet())
+}
+
+func (s *DbTestSuite) Test_pgStore_GetNotificationInbox() {
+	t := s.T()
+
+	sentAt := time.Date(2026, 11, 6, 6, 0, 0, 0, time.UTC)
+	columns := []string{"id", "event_id", "farmer_id", "channel", "kind", "message", "status", "attempts", "next_attempt_at", "last_error", "sent_at", "read_at", "created_at"}
+	s.mock.ExpectQuery("SELECT (.+) FROM notifications n WHERE n.farmer_id = \\$1 and n.channel = 'in_app'").WithArgs(9, 50).WillReturnRows(sqlxmock.NewRows(columns).
+		AddRow(7, 4, 9, "in_app", domain.EventBookingAccepted, "Your booking 11 of machine 2 is confirmed", "sent", 1, sentAt, "", sentAt, nil, sentAt))
+	s.mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM notifications").WithArgs(9).WillReturnRows(sqlxmock.NewRows([]string{"count"}).AddRow(1))
+
+	got, err := s.repo.GetNotificationInbox(context.TODO(), 9, 50)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), got.Unread)
+	require.Len(t, got.Notifications, 1)
+	assert.Equal(t, "Your booking 11 of machine 2 is confirmed", got.Notifications[0].Message)
+	assert.Nil(t, got.Notifications[0].ReadAt)
+	assert.NoError(t, s.mock.ExpectationsWereMet())
+}
@@ -0,0 +1,158 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	addOrderQuery         = "INSERT INTO orders (farmer_id, total_amount) VALUES ($1, 0) RETURNING id"
+	updateOrderTotalQuery = "UPDATE orders SET total_amount = $2, currency = $3 WHERE id = $1"
+)
+
+// Checkout books every line of the order in a single transaction, so either
+// all machines are reserved or none is. Each machine owner gets one invoice
+// for their share of the order, paid from the renter's wallet if they ask.
+func (s *pgStore) Checkout(ctx context.Context, order domain.NewOrderRequest) (rsp domain.OrderResponse, err error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	var promo domain.PromoCode
+	if order.PromoCode != "" {
+		promo, err = lockPromoCode(ctx, tx, order.PromoCode, order.FarmerId)
+		if err != nil {
+			return
+		}
+	}
+
+	err = tx.QueryRowxContext(ctx, addOrderQuery, order.FarmerId).Scan(&rsp.OrderId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error inserting order")
+		return
+	}
+
+	// machines are locked in id order so that two carts sharing machines
+	// cannot deadlock each other
+	indexes := make([]int, len(order.Lines))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return order.Lines[indexes[i]].MachineId < order.Lines[indexes[j]].MachineId
+	})
+
+	lines := make([]bookedLine, len(order.Lines))
+	for _, i := range indexes {
+		cartLine := order.Lines[i]
+		lines[i], err = bookLine(ctx, tx, domain.NewBookingRequest{
+			MachineId: cartLine.MachineId,
+			Date:      cartLine.Date,
+			Slots:     cartLine.Slots,
+			FarmerId:  order.FarmerId,
+			OrderId:   &rsp.OrderId,
+		})
+		if err != nil {
+			err = fmt.Errorf("line %d: %w", i+1, err)
+			return
+		}
+	}
+
+	invoices := map[uint]*domain.OrderInvoice{}
+	items := map[uint][]domain.InvoiceLineItem{}
+	var owners []uint
+	for i, line := range lines {
+		if !rsp.TotalCost.SameCurrency(line.amount) {
+			err = fmt.Errorf("line %d: %w", i+1, domain.ErrCurrencyMismatch)
+			return
+		}
+		invoice, ok := invoices[line.ownerId]
+		if !ok {
+			invoice = &domain.OrderInvoice{OwnerId: line.ownerId}
+			invoices[line.ownerId] = invoice
+			owners = append(owners, line.ownerId)
+		}
+		invoice.BookingIds = append(invoice.BookingIds, line.Id)
+		items[line.ownerId] = append(items[line.ownerId], line.rentalItem())
+		rsp.TotalCost = rsp.TotalCost.Add(line.amount)
+	}
+
+	if order.PromoCode != "" {
+		discounts, err := applyPromoCode(ctx, tx, promo, domain.PromoRedemption{FarmerId: order.FarmerId, OrderId: &rsp.OrderId}, lines)
+		if err != nil {
+			return rsp, err
+		}
+		total := domain.NewMoney(0, rsp.TotalCost.Currency)
+		for i, discount := range discounts {
+			if discount.IsZero() {
+				continue
+			}
+			items[lines[i].ownerId] = append(items[lines[i].ownerId], lines[i].discountItem(promo.Code, discount))
+			lines[i].amount = lines[i].amount.Sub(discount)
+			total = total.Add(discount)
+		}
+		rsp.TotalCost = rsp.TotalCost.Sub(total)
+		rsp.Discount = &total
+	}
+
+	today := time.Now().Format("2006-01-02")
+	for _, ownerId := range owners {
+		invoice := invoices[ownerId]
+		newInvoice := domain.Invoice{
+			OrderId:      &rsp.OrderId,
+			FarmerId:     order.FarmerId,
+			OwnerId:      ownerId,
+			DateGenrated: today,
+			LineItems:    items[ownerId],
+		}
+		err = createInvoice(ctx, tx, &newInvoice)
+		if err != nil {
+			return
+		}
+		invoice.InvoiceId = newInvoice.Id
+		invoice.Number = newInvoice.Number
+		invoice.Amount = newInvoice.Amount
+		if order.PayFromWallet {
+			paid, err := payFromWallet(ctx, tx, &newInvoice, order.CommissionBp)
+			if err != nil {
+				return rsp, err
+			}
+			invoice.Payment = &paid
+		}
+		rsp.Invoices = append(rsp.Invoices, *invoice)
+	}
+
+	_, err = tx.ExecContext(ctx, updateOrderTotalQuery, rsp.OrderId, rsp.TotalCost.Minor, rsp.TotalCost.Currency)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error updating order total")
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return
+	}
+
+	for i, line := range lines {
+		rsp.Bookings = append(rsp.Bookings, domain.NewBookingResponse{
+			BookingId:     line.Id,
+			InvoiceId:     invoices[line.ownerId].InvoiceId,
+			InvoiceNumber: invoices[line.ownerId].Number,
+			MachineId:     line.MachineId,
+			Date:          order.Lines[i].Date,
+			SlotsBooked:   order.Lines[i].Slots,
+			TotalCost:     line.amount,
+			Deposit:       line.deposit,
+		})
+	}
+
+	return
+}
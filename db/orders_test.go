@@ -0,0 +1,96 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func (s *DbTestSuite) expectBookLine(machineId, ownerId, charge, bookingId uint, date string, slots ...uint) {
+	s.mock.ExpectQuery("SELECT (.+) FROM machines m (.+) FOR UPDATE OF m").WithArgs(machineId).WillReturnRows(sqlxmock.NewRows([]string{"name", "owner_id", "base_hourly_charge", "currency", "security_deposit", "hsn_sac", "rate_bp", "category"}).AddRow("Tractor", ownerId, charge*100, "INR", 0, "997314", 1800, "tractor"))
+	for _, slot := range slots {
+		s.mock.ExpectQuery("SELECT slots_booked.id FROM slots_booked").WithArgs(machineId, slot, date).WillReturnError(sql.ErrNoRows)
+	}
+	s.mock.ExpectQuery("INSERT INTO bookings").WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(bookingId))
+	for range slots {
+		s.mock.ExpectExec("INSERT INTO slots_booked").WillReturnResult(sqlxmock.NewResult(1, 1))
+	}
+	s.expectOutboxEvent(domain.EventBookingRequested, ownerId)
+	s.expectOutboxEvent(domain.EventBookingAccepted, sqlxmock.AnyArg())
+}
+
+func (s *DbTestSuite) Test_pgStore_Checkout() {
+	t := s.T()
+
+	order := domain.NewOrderRequest{
+		FarmerId: 9,
+		Lines: []domain.CartLine{
+			{MachineId: 3, Date: "2026-11-07", Slots: []uint{7, 8}},
+			{MachineId: 1, Date: "2026-11-07", Slots: []uint{7}},
+			{MachineId: 2, Date: "2026-11-07", Slots: []uint{7}},
+		},
+	}
+
+	s.Run("when every line is free", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("INSERT INTO orders").WithArgs(9).WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(4))
+		s.expectBookLine(1, 20, 300, 11, "2026-11-07", 7)
+		s.expectBookLine(2, 21, 400, 12, "2026-11-07", 7)
+		s.expectBookLine(3, 20, 500, 13, "2026-11-07", 7, 8)
+		// owner 20 is registered for GST in the renter's state, owner 21 is not
+		s.expectCreateInvoice(31, 7, 20, "27AAPFU0939F1ZV", 153400, 4)
+		s.expectCreateInvoice(32, 8, 21, "", 40000, 1)
+		s.mock.ExpectExec("UPDATE orders SET total_amount").WithArgs(4, 170000, "INR").WillReturnResult(sqlxmock.NewResult(0, 1))
+		s.mock.ExpectCommit()
+
+		got, err := s.repo.Checkout(context.TODO(), order)
+		require.NoError(t, err)
+		assert.Equal(t, uint(4), got.OrderId)
+		assert.Equal(t, domain.Rupees(1700), got.TotalCost)
+		assert.Equal(t, []domain.OrderInvoice{
+			{InvoiceId: 31, Number: got.Invoices[0].Number, OwnerId: 20, BookingIds: []uint{13, 11}, Amount: domain.Rupees(1534)},
+			{InvoiceId: 32, Number: got.Invoices[1].Number, OwnerId: 21, BookingIds: []uint{12}, Amount: domain.Rupees(400)},
+		}, got.Invoices)
+		assert.Equal(t, uint(13), got.Bookings[0].BookingId)
+		assert.Equal(t, uint(31), got.Bookings[0].InvoiceId)
+		assert.Regexp(t, `^FE/\d{4}-\d{2}/000007$`, got.Invoices[0].Number)
+		assert.Equal(t, got.Invoices[0].Number, got.Bookings[0].InvoiceNumber)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("when a line is already booked", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("INSERT INTO orders").WithArgs(9).WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(4))
+		s.expectBookLine(1, 20, 300, 11, "2026-11-07", 7)
+		s.mock.ExpectQuery("SELECT (.+) FROM machines m").WithArgs(2).WillReturnRows(sqlxmock.NewRows([]string{"name", "owner_id", "base_hourly_charge", "currency", "security_deposit", "hsn_sac", "rate_bp", "category"}).AddRow("Harvester", 21, 40000, "INR", 0, "997314", 1800, "harvester"))
+		s.mock.ExpectQuery("SELECT slots_booked.id FROM slots_booked").WithArgs(2, 7, "2026-11-07").WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(1))
+		s.mock.ExpectRollback()
+
+		_, err := s.repo.Checkout(context.TODO(), order)
+		assert.True(t, errors.Is(err, ErrSlotNotEmpty))
+		assert.EqualError(t, err, "line 3: slot not empty")
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+	s.Run("when the lines are in different currencies", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("INSERT INTO orders").WithArgs(9).WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(4))
+		s.expectBookLine(1, 20, 300, 11, "2026-11-07", 7)
+		s.mock.ExpectQuery("SELECT (.+) FROM machines m").WithArgs(2).WillReturnRows(sqlxmock.NewRows([]string{"name", "owner_id", "base_hourly_charge", "currency", "security_deposit", "hsn_sac", "rate_bp", "category"}).AddRow("Harvester", 21, 500, "USD", 0, "997314", 1800, "harvester"))
+		s.mock.ExpectQuery("SELECT slots_booked.id FROM slots_booked").WithArgs(2, 7, "2026-11-07").WillReturnError(sql.ErrNoRows)
+		s.mock.ExpectQuery("INSERT INTO bookings").WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(12))
+		s.mock.ExpectExec("INSERT INTO slots_booked").WillReturnResult(sqlxmock.NewResult(1, 1))
+		s.expectOutboxEvent(domain.EventBookingRequested, 21)
+		s.expectOutboxEvent(domain.EventBookingAccepted, 9)
+		s.expectBookLine(3, 20, 500, 13, "2026-11-07", 7, 8)
+		s.mock.ExpectRollback()
+
+		_, err := s.repo.Checkout(context.TODO(), order)
+		assert.True(t, errors.Is(err, domain.ErrCurrencyMismatch))
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,180 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"FarmEasy/ledger"
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	paymentColumns           = "id, invoice_id, farmer_id, provider, provider_order_id, COALESCE(provider_payment_id, '') AS provider_payment_id, COALESCE(provider_refund_id, '') AS provider_refund_id, amount AS \"amount.minor\", currency AS \"amount.currency\", refunded AS \"refunded.minor\", currency AS \"refunded.currency\", status, created_at"
+	addPaymentQuery          = "INSERT INTO payments (invoice_id, farmer_id, provider, provider_order_id, amount, currency) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, status, created_at"
+	getPaymentQuery          = "SELECT " + paymentColumns + " FROM payments WHERE id = $1"
+	getPaymentByOrderQuery   = "SELECT " + paymentColumns + " FROM payments WHERE provider = $1 and provider_order_id = $2"
+	getOpenPaymentQuery      = "SELECT " + paymentColumns + " FROM payments WHERE invoice_id = $1 and status = 'created' ORDER BY id DESC LIMIT 1"
+	capturePaymentQuery      = "UPDATE payments SET status = 'captured', provider_payment_id = $2, updated_at = NOW() WHERE id = $1 and status = 'created' RETURNING invoice_id"
+	failPaymentQuery         = "UPDATE payments SET status = 'failed', provider_payment_id = NULLIF($2, ''), updated_at = NOW() WHERE id = $1 and status = 'created' RETURNING id"
+	lockCapturedPaymentQuery = "SELECT " + paymentColumns + " FROM payments WHERE id = $1 and status = 'captured' FOR UPDATE"
+	refundPaymentQuery       = "UPDATE payments SET status = 'refunded', refunded = amount, provider_refund_id = NULLIF($2, ''), updated_at = NOW() WHERE id = $1 and status = 'captured' RETURNING " + paymentColumns
+	updateInvoiceStatusQuery = "UPDATE invoices SET status = $2 WHERE id = $1 and status = $3 RETURNING id"
+)
+
+func (s *pgStore) AddPayment(ctx context.Context, payment *domain.Payment) (err error) {
+
+	err = s.db.QueryRowContext(ctx, addPaymentQuery, payment.InvoiceId, payment.FarmerId, payment.Provider, payment.ProviderOrderId, payment.Amount.Minor, payment.Amount.Currency).Scan(&payment.Id, &payment.Status, &payment.CreatedAt)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error inserting payment")
+		return
+	}
+
+	return
+}
+
+func (s *pgStore) GetPayment(ctx context.Context, paymentId uint) (payment domain.Payment, err error) {
+
+	err = s.db.GetContext(ctx, &payment, getPaymentQuery, paymentId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting payment")
+		return
+	}
+
+	return
+}
+
+func (s *pgStore) GetPaymentByOrder(ctx context.Context, provider string, orderId string) (payment domain.Payment, err error) {
+
+	err = s.db.GetContext(ctx, &payment, getPaymentByOrderQuery, provider, orderId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting payment by provider order")
+		return
+	}
+
+	return
+}
+
+// GetOpenPayment returns the latest payment of the invoice that is still
+// waiting for the renter to pay.
+func (s *pgStore) GetOpenPayment(ctx context.Context, invoiceId uint) (payment domain.Payment, err error) {
+
+	err = s.db.GetContext(ctx, &payment, getOpenPaymentQuery, invoiceId)
+	return
+}
+
+// CapturePayment marks the payment captured and its invoice paid, and posts
+// the ledger transactions recording it. It returns sql.ErrNoRows, and changes
+// nothing, unless the payment was waiting to be paid and the invoice unpaid.
+func (s *pgStore) CapturePayment(ctx context.Context, paymentId uint, providerPaymentId string, transactions []domain.LedgerTransaction) (err error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	var invoiceId uint
+	err = tx.QueryRowContext(ctx, capturePaymentQuery, paymentId, providerPaymentId).Scan(&invoiceId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error capturing payment")
+		return
+	}
+
+	err = tx.QueryRowContext(ctx, updateInvoiceStatusQuery, invoiceId, domain.InvoicePaid, domain.InvoiceUnpaid).Scan(&invoiceId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error marking invoice paid")
+		return
+	}
+
+	for i := range transactions {
+		err = postTransaction(ctx, tx, &transactions[i])
+		if err != nil {
+			return
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *pgStore) FailPayment(ctx context.Context, paymentId uint, providerPaymentId string) (err error) {
+
+	err = s.db.QueryRowContext(ctx, failPaymentQuery, paymentId, providerPaymentId).Scan(&paymentId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error marking payment failed")
+		return
+	}
+
+	return
+}
+
+// RefundPayment marks a captured payment refunded and its invoice with it, and
+// reverses what was posted to the ledger when it was captured. Wallet payments
+// are refunded to the wallet.
+func (s *pgStore) RefundPayment(ctx context.Context, paymentId uint, providerRefundId string) (err error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = refundPayment(ctx, tx, paymentId, providerRefundId)
+	if err != nil {
+		return
+	}
+
+	return tx.Commit()
+}
+
+// refundPayment marks the captured payment and its invoice refunded inside the
+// transaction, reverses what is left posted for it and gives what is left of
+// a wallet payment back to the wallet.
+func refundPayment(ctx context.Context, tx *sqlx.Tx, paymentId uint, providerRefundId string) (refunded domain.Payment, err error) {
+
+	var captured domain.Payment
+	err = tx.GetContext(ctx, &captured, lockCapturedPaymentQuery, paymentId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error locking payment")
+		return
+	}
+
+	err = tx.GetContext(ctx, &refunded, refundPaymentQuery, paymentId, providerRefundId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error refunding payment")
+		return
+	}
+
+	var invoiceId uint
+	err = tx.QueryRowContext(ctx, updateInvoiceStatusQuery, refunded.InvoiceId, domain.InvoiceRefunded, domain.InvoicePaid).Scan(&invoiceId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error marking invoice refunded")
+		return
+	}
+
+	reference := fmt.Sprintf("payment:%d", paymentId)
+	posted, err := getTransactions(ctx, tx, reference)
+	if err != nil {
+		return
+	}
+	// payments captured before the ledger existed have nothing to reverse
+	if len(posted) > 0 {
+		description := "Refund " + providerRefundId
+		if refunded.Provider == domain.WalletProvider {
+			description = "Refund to wallet"
+		}
+		reversal, err := ledger.Reverse(ledger.KindRefund, reference, description, posted)
+		if err != nil {
+			return refunded, err
+		}
+		if err = postTransaction(ctx, tx, &reversal); err != nil {
+			return refunded, err
+		}
+	}
+
+	if refunded.Provider == domain.WalletProvider {
+		_, err = changeWallet(ctx, tx, refunded.FarmerId, domain.WalletEntryRefund, captured.Refundable(), reference)
+	}
+	return
+}
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"FarmEasy/ledger"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func paidTransactions() []domain.LedgerTransaction {
+	invoice := domain.Invoice{Number: "FE/2026-27/000042", FarmerId: 9, OwnerId: 20, TaxableValue: domain.Rupees(600), Amount: domain.Rupees(708)}
+	return ledger.InvoicePaid(invoice, domain.Payment{Id: 3, Amount: domain.Rupees(708)}, 1000)
+}
+
+func (s *DbTestSuite) Test_pgStore_AddPayment() {
+	t := s.T()
+
+	created := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	s.mock.ExpectQuery("INSERT INTO payments").WithArgs(7, 9, "fake", "order_fake_1", 70800, "INR").
+		WillReturnRows(sqlxmock.NewRows([]string{"id", "status", "created_at"}).AddRow(3, "created", created))
+
+	payment := domain.Payment{InvoiceId: 7, FarmerId: 9, Provider: "fake", ProviderOrderId: "order_fake_1", Amount: domain.Rupees(708)}
+	err := s.repo.AddPayment(context.TODO(), &payment)
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), payment.Id)
+	assert.Equal(t, domain.PaymentCreated, payment.Status)
+	assert.NoError(t, s.mock.ExpectationsWereMet())
+}
+
+func (s *DbTestSuite) Test_pgStore_GetPaymentByOrder() {
+	t := s.T()
+
+	s.mock.ExpectQuery("SELECT (.+) FROM payments WHERE provider = \\$1 and provider_order_id = \\$2").WithArgs("fake", "order_fake_1").WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "invoice_id", "farmer_id", "provider", "provider_order_id", "provider_payment_id", "provider_refund_id", "amount.minor", "amount.currency", "status", "created_at"}).
+			AddRow(3, 7, 9, "fake", "order_fake_1", "", "", 70800, "INR", "created", time.Now()))
+
+	got, err := s.repo.GetPaymentByOrder(context.TODO(), "fake", "order_fake_1")
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), got.InvoiceId)
+	assert.Equal(t, domain.Rupees(708), got.Amount)
+	assert.NoError(t, s.mock.ExpectationsWereMet())
+}
+
+func (s *DbTestSuite) Test_pgStore_CapturePayment() {
+	t := s.T()
+
+	s.Run("marks the invoice paid", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("UPDATE payments SET status = 'captured'").WithArgs(3, "pay_fake_2").WillReturnRows(sqlxmock.NewRows([]string{"invoice_id"}).AddRow(7))
+		s.mock.ExpectQuery("UPDATE invoices SET status = \\$2").WithArgs(7, domain.InvoicePaid, domain.InvoiceUnpaid).WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(7))
+		s.expectPostTransaction(ledger.KindCharge, "payment:3", 9, 20, 0)
+		s.expectPostTransaction(ledger.KindPayment, "payment:3", 0, 9)
+		s.mock.ExpectCommit()
+
+		err := s.repo.CapturePayment(context.TODO(), 3, "pay_fake_2", paidTransactions())
+		require.NoError(t, err)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("when the invoice was already paid", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("UPDATE payments SET status = 'captured'").WithArgs(4, "pay_fake_3").WillReturnRows(sqlxmock.NewRows([]string{"invoice_id"}).AddRow(7))
+		s.mock.ExpectQuery("UPDATE invoices SET status = \\$2").WithArgs(7, domain.InvoicePaid, domain.InvoiceUnpaid).WillReturnError(sql.ErrNoRows)
+		s.mock.ExpectRollback()
+
+		err := s.repo.CapturePayment(context.TODO(), 4, "pay_fake_3", paidTransactions())
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+}
+
+func (s *DbTestSuite) Test_pgStore_RefundPayment() {
+	t := s.T()
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM payments WHERE id = \\$1 and status = 'captured' FOR UPDATE").WithArgs(3).WillReturnRows(sqlxmock.NewRows([]string{"id", "invoice_id"}).AddRow(3, 7))
+	s.mock.ExpectQuery("UPDATE payments SET status = 'refunded'").WithArgs(3, "rfnd_fake_4").WillReturnRows(sqlxmock.NewRows([]string{"invoice_id"}).AddRow(7))
+	s.mock.ExpectQuery("UPDATE invoices SET status = \\$2").WithArgs(7, domain.InvoiceRefunded, domain.InvoicePaid).WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(7))
+	s.mock.ExpectQuery("SELECT (.+) FROM ledger_transactions WHERE reference = \\$1").WithArgs("payment:3").WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "kind", "reference", "description", "created_at"}).
+			AddRow(1, "charge", "payment:3", "Invoice FE/2026-27/000042", time.Now()).
+			AddRow(2, "payment", "payment:3", "Payment for invoice FE/2026-27/000042", time.Now()))
+	s.mock.ExpectQuery("SELECT (.+) FROM ledger_entries WHERE transaction_id = ANY").WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "transaction_id", "account", "farmer_id", "amount.minor", "amount.currency"}).
+			AddRow(1, 1, "renter", 9, 70800, "INR").
+			AddRow(2, 1, "owner_payable", 20, -64800, "INR").
+			AddRow(3, 1, "commission", 0, -6000, "INR").
+			AddRow(4, 2, "cash", 0, 70800, "INR").
+			AddRow(5, 2, "renter", 9, -70800, "INR"))
+	// the renter's charge and payment cancel out, what is left is undone
+	s.expectPostTransaction(ledger.KindRefund, "payment:3", 20, 0, 0)
+	s.mock.ExpectCommit()
+
+	err := s.repo.RefundPayment(context.TODO(), 3, "rfnd_fake_4")
+	require.NoError(t, err)
+	assert.NoError(t, s.mock.ExpectationsWereMet())
+}
@@ -0,0 +1,182 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	promoColumns = "id, code, description, kind, percent_bp, amount AS \"amount.minor\", currency AS \"amount.currency\", valid_from, valid_until, max_redemptions, max_per_farmer, categories, owner_ids, active, created_at, " +
+		"(SELECT COUNT(*) FROM promo_redemptions r WHERE r.promo_code_id = promo_codes.id) AS redemptions"
+	addPromoCodeQuery          = "INSERT INTO promo_codes (code, description, kind, percent_bp, amount, currency, valid_from, valid_until, max_redemptions, max_per_farmer, categories, owner_ids) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id, active, created_at"
+	getPromoCodesQuery         = "SELECT " + promoColumns + " FROM promo_codes ORDER BY id DESC"
+	getPromoCodeQuery          = "SELECT " + promoColumns + " FROM promo_codes WHERE id = $1"
+	lockPromoCodeQuery         = "SELECT " + promoColumns + " FROM promo_codes WHERE code = $1 FOR UPDATE OF promo_codes"
+	deactivatePromoCodeQuery   = "UPDATE promo_codes SET active = FALSE WHERE id = $1 RETURNING id"
+	countFarmerRedemptionQuery = "SELECT COUNT(*) FROM promo_redemptions WHERE promo_code_id = $1 and farmer_id = $2"
+	addPromoRedemptionQuery    = "INSERT INTO promo_redemptions (promo_code_id, farmer_id, booking_id, order_id, discount, currency) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at"
+	getPromoRedemptionsQuery   = "SELECT id, promo_code_id, farmer_id, booking_id, order_id, discount AS \"discount.minor\", currency AS \"discount.currency\", created_at FROM promo_redemptions WHERE promo_code_id = $1 ORDER BY id DESC"
+)
+
+// promoRow reads the array columns of a promo code, which the domain type
+// keeps as plain slices.
+type promoRow struct {
+	domain.PromoCode
+	Categories pq.StringArray `db:"categories"`
+	OwnerIds   pq.Int64Array  `db:"owner_ids"`
+}
+
+func (row promoRow) promoCode() (promo domain.PromoCode) {
+	promo = row.PromoCode
+	promo.Categories = []string(row.Categories)
+	promo.OwnerIds = []uint{}
+	for _, id := range row.OwnerIds {
+		promo.OwnerIds = append(promo.OwnerIds, uint(id))
+	}
+	return
+}
+
+func (s *pgStore) AddPromoCode(ctx context.Context, promo *domain.PromoCode) (err error) {
+
+	ownerIds := make([]int64, len(promo.OwnerIds))
+	for i, id := range promo.OwnerIds {
+		ownerIds[i] = int64(id)
+	}
+
+	err = s.db.QueryRowContext(ctx, addPromoCodeQuery, promo.Code, promo.Description, promo.Kind, promo.PercentBp, promo.Amount.Minor, promo.Amount.Currency, promo.ValidFrom, promo.ValidUntil, promo.MaxRedemptions, promo.MaxPerFarmer, pq.StringArray(promo.Categories), pq.Int64Array(ownerIds)).Scan(&promo.Id, &promo.Active, &promo.CreatedAt)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error inserting promo code")
+		return
+	}
+
+	return
+}
+
+// GetPromoCodes returns every promo code with how often it was redeemed,
+// newest first.
+func (s *pgStore) GetPromoCodes(ctx context.Context) (promos []domain.PromoCode, err error) {
+
+	var rows []promoRow
+	err = s.db.SelectContext(ctx, &rows, getPromoCodesQuery)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting promo codes")
+		return
+	}
+
+	for _, row := range rows {
+		promos = append(promos, row.promoCode())
+	}
+	return
+}
+
+func (s *pgStore) GetPromoCode(ctx context.Context, promoId uint) (promo domain.PromoCode, err error) {
+
+	var row promoRow
+	err = s.db.GetContext(ctx, &row, getPromoCodeQuery, promoId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting promo code")
+		return
+	}
+
+	return row.promoCode(), nil
+}
+
+// DeactivatePromoCode stops the code from being redeemed. Redemptions already
+// made stand.
+func (s *pgStore) DeactivatePromoCode(ctx context.Context, promoId uint) (err error) {
+
+	err = s.db.QueryRowContext(ctx, deactivatePromoCodeQuery, promoId).Scan(&promoId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error deactivating promo code")
+		return
+	}
+
+	return
+}
+
+func (s *pgStore) GetPromoRedemptions(ctx context.Context, promoId uint) (redemptions []domain.PromoRedemption, err error) {
+
+	err = s.db.SelectContext(ctx, &redemptions, getPromoRedemptionsQuery, promoId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting promo redemptions")
+		return
+	}
+
+	return
+}
+
+// lockPromoCode locks the code for the rest of the transaction and checks that
+// the farmer may still redeem it. Holding the lock until the redemption is
+// recorded keeps concurrent bookings from going over the usage caps.
+func lockPromoCode(ctx context.Context, tx *sqlx.Tx, code string, farmerId uint) (promo domain.PromoCode, err error) {
+
+	var row promoRow
+	err = tx.GetContext(ctx, &row, lockPromoCodeQuery, domain.NormalizePromoCode(code))
+	if err == sql.ErrNoRows {
+		err = domain.ErrPromoInvalid
+		return
+	}
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error locking promo code")
+		return
+	}
+	promo = row.promoCode()
+
+	if err = promo.Usable(time.Now()); err != nil {
+		return
+	}
+	if promo.MaxRedemptions > 0 && promo.Redemptions >= promo.MaxRedemptions {
+		err = domain.ErrPromoExhausted
+		return
+	}
+
+	if promo.MaxPerFarmer > 0 {
+		var used uint
+		err = tx.QueryRowContext(ctx, countFarmerRedemptionQuery, promo.Id, farmerId).Scan(&used)
+		if err != nil {
+			logger.WithField("err", err.Error()).Error("Error counting promo redemptions")
+			return
+		}
+		if used >= promo.MaxPerFarmer {
+			err = domain.ErrPromoExhausted
+			return
+		}
+	}
+
+	return
+}
+
+// applyPromoCode works out the discount on each booked line and records the
+// redemption. Lines the code does not apply to get a zero discount.
+func applyPromoCode(ctx context.Context, tx *sqlx.Tx, promo domain.PromoCode, redemption domain.PromoRedemption, lines []bookedLine) (discounts []domain.Money, err error) {
+
+	promoLines := make([]domain.PromoLine, len(lines))
+	for i, line := range lines {
+		promoLines[i] = domain.PromoLine{Category: line.category, OwnerId: line.ownerId, Amount: line.amount}
+	}
+
+	discounts, err = promo.Discounts(promoLines)
+	if err != nil {
+		return
+	}
+
+	redemption.PromoCodeId = promo.Id
+	redemption.Discount = domain.Money{}
+	for _, discount := range discounts {
+		redemption.Discount = redemption.Discount.Add(discount)
+	}
+
+	err = tx.QueryRowContext(ctx, addPromoRedemptionQuery, redemption.PromoCodeId, redemption.FarmerId, redemption.BookingId, redemption.OrderId, redemption.Discount.Minor, redemption.Discount.Currency).Scan(&redemption.Id, &redemption.CreatedAt)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error inserting promo redemption")
+		return
+	}
+
+	return
+}
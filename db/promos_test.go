@@ -0,0 +1,58 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func (s *DbTestSuite) expectLockPromoCode(code string, maxPerFarmer uint, redemptions uint) {
+	now := time.Now()
+	s.mock.ExpectQuery("SELECT (.+) FROM promo_codes WHERE code = \\$1 FOR UPDATE").WithArgs(code).WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "code", "description", "kind", "percent_bp", "amount.minor", "amount.currency", "valid_from", "valid_until", "max_redemptions", "max_per_farmer", "categories", "owner_ids", "active", "created_at", "redemptions"}).
+			AddRow(4, code, "Kharif season", "percent", 1000, 0, "INR", now.Add(-time.Hour), now.Add(time.Hour), 100, maxPerFarmer, "{tractor}", "{}", true, now, redemptions))
+}
+
+func (s *DbTestSuite) Test_pgStore_Book_withPromoCode() {
+	t := s.T()
+	booking := domain.NewBookingRequest{MachineId: 1, Date: "2026-11-07", Slots: []uint{7}, FarmerId: 9, PromoCode: "monsoon10"}
+
+	s.Run("discounts the rental", func() {
+		s.mock.ExpectBegin()
+		s.expectLockPromoCode("MONSOON10", 1, 0)
+		s.mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM promo_redemptions").WithArgs(4, 9).WillReturnRows(sqlxmock.NewRows([]string{"count"}).AddRow(0))
+		s.mock.ExpectQuery("SELECT (.+) FROM machines m (.+) FOR UPDATE OF m").WithArgs(1).WillReturnRows(
+			sqlxmock.NewRows([]string{"name", "owner_id", "base_hourly_charge", "currency", "security_deposit", "hsn_sac", "rate_bp", "category"}).AddRow("Tractor", 20, 300000, "INR", 0, "997314", 1800, "tractor"))
+		s.mock.ExpectQuery("SELECT slots_booked.id FROM slots_booked").WithArgs(1, 7, "2026-11-07").WillReturnError(sql.ErrNoRows)
+		s.mock.ExpectQuery("INSERT INTO bookings").WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(11))
+		s.mock.ExpectExec("INSERT INTO slots_booked").WillReturnResult(sqlxmock.NewResult(1, 1))
+		s.expectOutboxEvent(domain.EventBookingRequested, 20)
+		s.expectOutboxEvent(domain.EventBookingAccepted, 9)
+		s.mock.ExpectQuery("INSERT INTO promo_redemptions").WithArgs(4, 9, sqlxmock.AnyArg(), nil, 30000, "INR").WillReturnRows(sqlxmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+		s.expectCreateInvoice(1, 1, 20, "", 270000, 2)
+		s.mock.ExpectCommit()
+
+		got, err := s.repo.Book(context.TODO(), booking)
+		require.NoError(t, err)
+		assert.Equal(t, domain.Rupees(2700), got.TotalCost)
+		require.NotNil(t, got.Discount)
+		assert.Equal(t, domain.Rupees(300), *got.Discount)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("when the farmer already used it", func() {
+		s.mock.ExpectBegin()
+		s.expectLockPromoCode("MONSOON10", 1, 1)
+		s.mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM promo_redemptions").WithArgs(4, 9).WillReturnRows(sqlxmock.NewRows([]string{"count"}).AddRow(1))
+		s.mock.ExpectRollback()
+
+		_, err := s.repo.Book(context.TODO(), booking)
+		assert.Equal(t, domain.ErrPromoExhausted, err)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+}
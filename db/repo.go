@@ -3,44 +3,159 @@ package db
 import (
 	"FarmEasy/domain"
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 
+	"github.com/jmoiron/sqlx"
 	logger "github.com/sirupsen/logrus"
 )
 
+var ErrSlotNotEmpty = errors.New("slot not empty")
+
+// queryer is satisfied by both *sqlx.DB and *sqlx.Tx, so that the same query
+// helpers can run on their own or as part of a transaction.
+type queryer interface {
+	sqlx.QueryerContext
+	sqlx.ExecerContext
+}
+
 type Storer interface {
 	RegisterFarmer(context.Context, *domain.FarmerResponse) (err error)
 	LoginFarmer(context.Context, string, string) (farmerId uint, err error)
 	AddMachine(context.Context, *domain.MachineResponse) (err error)
 	GetMachines(context.Context) (machines []domain.MachineResponse, err error)
+	GetMachine(context.Context, uint) (machine domain.MachineResponse, err error)
 	IsEmptySlot(context.Context, uint, uint, string) (isEmpty bool)
 	AddBooking(context.Context, domain.Booking) (bookingId uint, err error)
 	BookSlot(context.Context, domain.Slot) (err error)
-	GetBaseCharge(context.Context, uint) (baseCharge uint, err error)
+	GetBaseCharge(context.Context, uint) (baseCharge domain.Money, err error)
 	GenrateInvoice(context.Context, domain.Invoice) (invoiceId uint, err error)
 	GetBookedSlot(context.Context, uint, string) (map[uint]struct{}, error)
+	GetBookedSlots(context.Context, []uint, string, string) (map[uint]map[string]map[uint]struct{}, error)
+	FindMachines(context.Context, domain.MachineFilter) (machines []domain.MachineResponse, err error)
 	GetAllBookings(context.Context, uint) (bookings []domain.BookingResponse, err error)
 	Book(context.Context, domain.NewBookingRequest) (invoice domain.NewBookingResponse, err error)
+	Checkout(context.Context, domain.NewOrderRequest) (order domain.OrderResponse, err error)
+	CancelBooking(context.Context, uint, uint) (cancelled domain.CancelledBooking, err error)
+	BookSeries(context.Context, *domain.BookingSeries, []string) (rsp domain.RecurringBookingResponse, err error)
+	AddWaitlistEntry(context.Context, *domain.WaitlistEntry) (err error)
+	GetWaitlistEntries(context.Context, uint) (entries []domain.WaitlistEntry, err error)
+	CancelWaitlistEntry(context.Context, uint, uint) (err error)
+	GetWaitingEntries(context.Context, uint, string) (entries []domain.WaitlistEntry, err error)
+	UpdateWaitlistEntry(context.Context, domain.WaitlistEntry) (err error)
+	BookWaitlistOffer(context.Context, *domain.WaitlistEntry) (booking domain.NewBookingResponse, err error)
+	ExpireWaitlistOffer(context.Context, domain.WaitlistEntry) (cancelled domain.CancelledBooking, err error)
+	ConfirmWaitlistOffer(context.Context, uint, uint) (entry domain.WaitlistEntry, err error)
+	GetExpiredWaitlistOffers(context.Context) (entries []domain.WaitlistEntry, err error)
+	CancelBookingSeries(context.Context, uint, uint) (cancelled []domain.CancelledBooking, err error)
+	ClaimIdempotencyKey(context.Context, uint, string, string) (claimed bool, err error)
+	GetIdempotencyRecord(context.Context, uint, string) (record domain.IdempotencyRecord, err error)
+	SaveIdempotencyResponse(context.Context, domain.IdempotencyRecord) (err error)
+	DeleteIdempotencyKey(context.Context, uint, string) (err error)
+	GetInvoices(context.Context, uint) (invoices []domain.Invoice, err error)
+	GetInvoice(context.Context, uint) (invoice domain.Invoice, err error)
+	GetInvoiceDocument(context.Context, uint) (doc domain.InvoiceDocument, err error)
+	AddPayment(context.Context, *domain.Payment) (err error)
+	GetPayment(context.Context, uint) (payment domain.Payment, err error)
+	GetPaymentByOrder(context.Context, string, string) (payment domain.Payment, err error)
+	GetOpenPayment(context.Context, uint) (payment domain.Payment, err error)
+	CapturePayment(context.Context, uint, string, []domain.LedgerTransaction) (err error)
+	FailPayment(context.Context, uint, string) (err error)
+	RefundPayment(context.Context, uint, string) (err error)
+	GetDeposits(context.Context, uint) (deposits []domain.Deposit, err error)
+	GetDeposit(context.Context, uint) (deposit domain.Deposit, err error)
+	GetBookingDeposit(context.Context, uint) (deposit domain.Deposit, err error)
+	ReleaseDeposit(context.Context, uint, string) (err error)
+	CaptureDeposit(context.Context, uint, domain.Money, string) (err error)
+	ReleaseUnclaimedDeposits(context.Context, int, uint) (released []domain.Deposit, err error)
+	GetOwnerStatement(context.Context, uint) (statement []domain.StatementLine, err error)
+	GetPayouts(context.Context, uint) (payouts []domain.Payout, err error)
+	SettleOwnerBalances(context.Context) (batch domain.PayoutBatch, err error)
+	GetWallet(context.Context, uint) (wallet domain.Wallet, err error)
+	GetWalletTransactions(context.Context, uint) (transactions []domain.WalletTransaction, err error)
+	AddWalletTopup(context.Context, *domain.WalletTopup) (err error)
+	GetWalletTopupByOrder(context.Context, string, string) (topup domain.WalletTopup, err error)
+	CreditWalletTopup(context.Context, uint, string) (topup domain.WalletTopup, err error)
+	FailWalletTopup(context.Context, uint, string) (err error)
+	AddPromoCode(context.Context, *domain.PromoCode) (err error)
+	GetPromoCodes(context.Context) (promos []domain.PromoCode, err error)
+	GetPromoCode(context.Context, uint) (promo domain.PromoCode, err error)
+	DeactivatePromoCode(context.Context, uint) (err error)
+	GetPromoRedemptions(context.Context, uint) (redemptions []domain.PromoRedemption, err error)
+	GetBookingParties(context.Context, uint) (parties domain.BookingParties, err error)
+	AddHandover(context.Context, *domain.Handover) (err error)
+	GetHandovers(context.Context, uint) (handovers []domain.Handover, err error)
+	ConfirmHandover(context.Context, uint, string, string) (handover domain.Handover, err error)
+	SettleHandover(context.Context, uint) (invoice *domain.Invoice, err error)
+	AddClaim(context.Context, *domain.Claim) (err error)
+	GetClaims(context.Context, uint) (found []domain.Claim, err error)
+	GetClaimsByStatus(context.Context, []string) (found []domain.Claim, err error)
+	GetClaim(context.Context, uint) (claim domain.Claim, err error)
+	GetBookingClaim(context.Context, uint) (claim domain.Claim, err error)
+	DisputeClaim(context.Context, uint, uint, string, []string) (err error)
+	DecideClaim(context.Context, uint, []string, domain.ClaimDecision) (claim domain.Claim, err error)
+	EscalateOverdueClaims(context.Context) (escalated []domain.Claim, err error)
+	GetFarmer(context.Context, uint) (farmer domain.FarmerResponse, err error)
+	AddReview(context.Context, *domain.Review) (err error)
+	GetReview(context.Context, uint) (review domain.Review, err error)
+	GetMachineReviews(context.Context, uint) (reviews []domain.Review, err error)
+	GetFarmerReviews(context.Context, uint) (reviews []domain.Review, err error)
+	AddReviewReport(context.Context, *domain.ReviewReport) (err error)
+	GetReviewReports(context.Context, string) (reports []domain.ReviewReport, err error)
+	ResolveReviewReport(context.Context, uint, string, string, uint) (report domain.ReviewReport, err error)
+	OpenThread(context.Context, domain.Thread) (threadId uint, err error)
+	GetThreads(context.Context, uint) (threads []domain.Thread, err error)
+	GetThread(context.Context, uint, uint) (thread domain.Thread, err error)
+	GetMessages(context.Context, uint, uint, uint) (messages []domain.Message, err error)
+	AddMessage(context.Context, *domain.Message) (err error)
+	MarkThreadRead(context.Context, uint, uint, uint) (err error)
+	AddOutboxEvent(context.Context, domain.OutboxEvent) (err error)
+	DispatchOutboxEvents(context.Context, []string, []string, uint) (dispatched int, err error)
+	GetDueNotifications(context.Context, uint) (deliveries []domain.NotificationDelivery, err error)
+	UpdateNotification(context.Context, domain.Notification) (err error)
+	GetNotificationInbox(context.Context, uint, uint) (inbox domain.NotificationInbox, err error)
+	MarkNotificationRead(context.Context, uint, uint) (err error)
+	GetNotificationPreferences(context.Context, uint) (preferences []domain.NotificationPreference, err error)
+	SetNotificationPreferences(context.Context, uint, []domain.NotificationPreference) (err error)
+	QueueBookingReminders(context.Context, int) (queued int, err error)
+	AddAPIClient(context.Context, *domain.APIClient) (err error)
+	GetAPIClients(context.Context, uint) (clients []domain.APIClient, err error)
+	GetAPIClient(context.Context, uint) (client domain.APIClient, err error)
+	AddWebhookSubscription(context.Context, *domain.WebhookSubscription) (err error)
+	GetWebhookSubscriptions(context.Context, uint) (webhooks []domain.WebhookSubscription, err error)
+	GetWebhookSubscription(context.Context, uint) (webhook domain.WebhookSubscription, err error)
+	DeactivateWebhookSubscription(context.Context, uint, uint) (err error)
+	GetWebhookDeliveries(context.Context, uint, uint) (deliveries []domain.WebhookDelivery, err error)
+	ReplayWebhookDelivery(context.Context, uint, uint) (replay domain.WebhookDelivery, err error)
+	GetDueWebhookDeliveries(context.Context, uint) (deliveries []domain.DueWebhookDelivery, err error)
+	UpdateWebhookDelivery(context.Context, domain.WebhookDelivery) (err error)
+	MarkNoShows(context.Context, int, int) (noShows []domain.NoShow, err error)
+	TryAdvisoryLock(context.Context, int64) (unlock func(), locked bool, err error)
 }
 
 const (
-	registerFarmerQuery      = "INSERT INTO farmers (fname, lname, email, phone, address, password) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id"
+	registerFarmerQuery      = "INSERT INTO farmers (fname, lname, email, phone, address, password, state, gstin) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, '')) RETURNING id"
 	loginQuery               = "SELECT id FROM farmers WHERE email = $1 and password = $2"
-	insertMachineQuery       = "INSERT INTO machines (name, description, base_hourly_charge, owner_id) VALUES ($1, $2, $3, $4) RETURNING id"
-	getMachinesQuery         = "SELECT * FROM machines"
-	checkSlotQuery           = "SELECT slots_booked.id FROM slots_booked, bookings WHERE bookings.id = slots_booked.booking_id and  bookings.machine_id = $1 and slot_id = $2 and date = $3"
-	addBookingQuery          = "INSERT INTO bookings (machine_id, farmer_id) VALUES ($1, $2) RETURNING id"
+	machineColumns           = "id, name, description, base_hourly_charge AS \"base_hourly_charge.minor\", currency AS \"base_hourly_charge.currency\", security_deposit AS \"security_deposit.minor\", currency AS \"security_deposit.currency\", usage_billing, owner_id, category, latitude, longitude, no_show_policy, " + machineRatingColumns
+	insertMachineQuery       = "INSERT INTO machines (name, description, base_hourly_charge, currency, owner_id, category, latitude, longitude, security_deposit, usage_billing, no_show_policy) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id"
+	getMachinesQuery         = "SELECT " + machineColumns + " FROM machines"
+	getMachineQuery          = "SELECT " + machineColumns + " FROM machines WHERE id = $1"
+	checkSlotQuery           = "SELECT slots_booked.id FROM slots_booked, bookings WHERE bookings.id = slots_booked.booking_id and  bookings.machine_id = $1 and slot_id = $2 and date = $3 and bookings.status NOT IN ('cancelled', 'no_show')"
+	addBookingQuery          = "INSERT INTO bookings (machine_id, farmer_id, series_id, order_id, status) VALUES ($1, $2, $3, $4, COALESCE(NULLIF($5, ''), 'confirmed')) RETURNING id"
 	bookSlotQuery            = "INSERT INTO slots_booked (booking_id, slot_id, date) VALUES ($1, $2, $3)"
-	getChargeQuery           = "SELECT base_hourly_charge FROM machines WHERE id = $1"
-	generateInvoiceQuery     = "INSERT INTO invoices (booking_id, date_generated, total_amount) VALUES ($1, $2, $3) RETURNING id"
-	getBookedSlotQuery       = "select s.slot_id from slots_booked s , bookings b where s.booking_id = b.id and b.machine_id = $1 and s.date = $2"
-	getBookingsQuery         = "SELECT id,machine_id FROM bookings WHERE farmer_id = $1"
+	getChargeQuery           = "SELECT base_hourly_charge, currency FROM machines WHERE id = $1"
+	lockMachineQuery         = "SELECT m.name, m.owner_id, m.base_hourly_charge, m.currency, m.security_deposit, COALESCE(r.hsn_sac, d.hsn_sac), COALESCE(r.rate_bp, d.rate_bp), m.category FROM machines m LEFT JOIN gst_rates r ON r.category = m.category, gst_rates d WHERE m.id = $1 and d.category = 'other' FOR UPDATE OF m"
+	getBookedSlotQuery       = "select s.slot_id from slots_booked s , bookings b where s.booking_id = b.id and b.machine_id = $1 and s.date = $2 and b.status NOT IN ('cancelled', 'no_show')"
+	getBookingsQuery         = "SELECT id,machine_id,status,series_id FROM bookings WHERE farmer_id = $1"
 	getSlotsByBookingIdQuery = "SELECT slot_id FROM slots_booked WHERE booking_id = $1"
+	cancelBookingQuery       = "UPDATE bookings SET status = 'cancelled' WHERE id = $1 and farmer_id = $2 and status NOT IN ('cancelled', 'no_show') and NOT EXISTS (SELECT 1 FROM slots_booked WHERE booking_id = bookings.id and date < CURRENT_DATE) and NOT EXISTS (SELECT 1 FROM handovers WHERE booking_id = bookings.id) RETURNING machine_id, (SELECT owner_id FROM machines WHERE id = bookings.machine_id)"
+	getBookingSlotsQuery     = "SELECT to_char(date, 'YYYY-MM-DD'), slot_id FROM slots_booked WHERE booking_id = $1 ORDER BY date, slot_id"
 )
 
 func (s *pgStore) RegisterFarmer(ctx context.Context, farmer *domain.FarmerResponse) (err error) {
-	err = s.db.QueryRowContext(ctx, registerFarmerQuery, farmer.FirstName, farmer.LastName, farmer.Email, farmer.Phone, farmer.Address, farmer.Password).Scan(&farmer.Id)
+	err = s.db.QueryRowContext(ctx, registerFarmerQuery, farmer.FirstName, farmer.LastName, farmer.Email, farmer.Phone, farmer.Address, farmer.Password, farmer.State, farmer.GSTIN).Scan(&farmer.Id)
 	if err != nil {
 		logger.WithField("err", err.Error()).Error("Error inserting farmer")
 		return
@@ -62,41 +177,55 @@ func (s *pgStore) LoginFarmer(ctx context.Context, email string, password string
 
 func (s *pgStore) AddMachine(ctx context.Context, newMachine *domain.MachineResponse) (err error) {
 
-	err = s.db.QueryRowContext(ctx, insertMachineQuery, newMachine.Name, newMachine.Description, newMachine.BaseHourlyCharge, newMachine.OwnerId).Scan(&newMachine.Id)
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, insertMachineQuery, newMachine.Name, newMachine.Description, newMachine.BaseHourlyCharge.Minor, newMachine.BaseHourlyCharge.Currency, newMachine.OwnerId, newMachine.Category, newMachine.Latitude, newMachine.Longitude, newMachine.SecurityDeposit.Minor, newMachine.UsageBilling, newMachine.NoShowPolicy).Scan(&newMachine.Id)
 	if err != nil {
 		logger.WithField("err", err.Error()).Error("Error inserting machine")
 		return
 	}
 
-	return
+	err = addOutboxEvent(ctx, tx, domain.NewMachineEvent(*newMachine))
+	if err != nil {
+		return
+	}
+
+	return tx.Commit()
 
 }
 
 func (s *pgStore) GetMachines(ctx context.Context) (machines []domain.MachineResponse, err error) {
 
-	rows, err := s.db.QueryContext(ctx, getMachinesQuery)
+	err = s.db.SelectContext(ctx, &machines, getMachinesQuery)
 	if err != nil {
 		logger.WithField("err", err.Error()).Error("Error getting machines")
 		return
 	}
 
-	for rows.Next() {
-		var machine domain.MachineResponse
-		err = rows.Scan(&machine.Id, &machine.Name, &machine.Description, &machine.BaseHourlyCharge, &machine.OwnerId)
-		if err != nil {
-			logger.WithField("err", err.Error()).Error("Error scanning machines")
-			return
-		}
+	return
+}
 
-		machines = append(machines, machine)
-	}
+// GetMachine returns the machine, or sql.ErrNoRows when there is none.
+func (s *pgStore) GetMachine(ctx context.Context, machineId uint) (machine domain.MachineResponse, err error) {
 
+	err = s.db.GetContext(ctx, &machine, getMachineQuery, machineId)
+	if err != nil && err != sql.ErrNoRows {
+		logger.WithField("err", err.Error()).Error("Error getting machine")
+	}
 	return
 }
 
 func (s *pgStore) IsEmptySlot(ctx context.Context, machineId uint, slotId uint, date string) (isEmpty bool) {
+	return isEmptySlot(ctx, s.db, machineId, slotId, date)
+}
 
-	err := s.db.QueryRowContext(ctx, checkSlotQuery, machineId, slotId, date).Scan(&slotId)
+func isEmptySlot(ctx context.Context, q queryer, machineId uint, slotId uint, date string) (isEmpty bool) {
+
+	err := q.QueryRowxContext(ctx, checkSlotQuery, machineId, slotId, date).Scan(&slotId)
 	if err != nil {
 		isEmpty = true
 		return
@@ -107,8 +236,12 @@ func (s *pgStore) IsEmptySlot(ctx context.Context, machineId uint, slotId uint,
 }
 
 func (s *pgStore) AddBooking(ctx context.Context, booking domain.Booking) (bookingId uint, err error) {
+	return addBooking(ctx, s.db, booking)
+}
 
-	err = s.db.QueryRowContext(ctx, addBookingQuery, booking.MachineId, booking.FarmerId).Scan(&bookingId)
+func addBooking(ctx context.Context, q queryer, booking domain.Booking) (bookingId uint, err error) {
+
+	err = q.QueryRowxContext(ctx, addBookingQuery, booking.MachineId, booking.FarmerId, booking.SeriesId, booking.OrderId, booking.Status).Scan(&bookingId)
 	if err != nil {
 		logger.WithField("err", err.Error()).Error("Error inserting booking")
 		return
@@ -119,8 +252,12 @@ func (s *pgStore) AddBooking(ctx context.Context, booking domain.Booking) (booki
 }
 
 func (s *pgStore) BookSlot(ctx context.Context, slot domain.Slot) (err error) {
+	return bookSlot(ctx, s.db, slot)
+}
 
-	_, err = s.db.ExecContext(ctx, bookSlotQuery, slot.BookingId, slot.SlotId, slot.Date)
+func bookSlot(ctx context.Context, q queryer, slot domain.Slot) (err error) {
+
+	_, err = q.ExecContext(ctx, bookSlotQuery, slot.BookingId, slot.SlotId, slot.Date)
 	if err != nil {
 		logger.WithField("err", err.Error()).Error("Error booking slot")
 		return
@@ -130,9 +267,9 @@ func (s *pgStore) BookSlot(ctx context.Context, slot domain.Slot) (err error) {
 
 }
 
-func (s *pgStore) GetBaseCharge(ctx context.Context, machineId uint) (baseCharge uint, err error) {
+func (s *pgStore) GetBaseCharge(ctx context.Context, machineId uint) (baseCharge domain.Money, err error) {
 
-	err = s.db.QueryRowContext(ctx, getChargeQuery, machineId).Scan(&baseCharge)
+	err = s.db.QueryRowContext(ctx, getChargeQuery, machineId).Scan(&baseCharge.Minor, &baseCharge.Currency)
 	if err != nil {
 		err = errors.New("error getting base charge")
 		return
@@ -144,23 +281,39 @@ func (s *pgStore) GetBaseCharge(ctx context.Context, machineId uint) (baseCharge
 
 func (s *pgStore) GenrateInvoice(ctx context.Context, newInvoice domain.Invoice) (invoiceId uint, err error) {
 
-	err = s.db.QueryRowContext(ctx, generateInvoiceQuery, newInvoice.BookingId, newInvoice.DateGenrated, newInvoice.Amount).Scan(&invoiceId)
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	err = createInvoice(ctx, tx, &newInvoice)
 	if err != nil {
-		logger.WithField("err", err.Error()).Error("Error generating invoice")
 		return
 	}
 
+	err = tx.Commit()
+	if err != nil {
+		return
+	}
+
+	invoiceId = newInvoice.Id
 	return
 
 }
 
 func (s *pgStore) GetBookedSlot(ctx context.Context, machineId uint, date string) (map[uint]struct{}, error) {
+	return getBookedSlots(ctx, s.db, machineId, date)
+}
+
+func getBookedSlots(ctx context.Context, q queryer, machineId uint, date string) (map[uint]struct{}, error) {
 
-	rows, err := s.db.QueryContext(ctx, getBookedSlotQuery, machineId, date)
+	rows, err := q.QueryContext(ctx, getBookedSlotQuery, machineId, date)
 	if err != nil {
 		logger.WithField("err", err.Error()).Error("error getting booked slots")
 		return nil, err
 	}
+	defer rows.Close()
 
 	bookedSlots := map[uint]struct{}{}
 	for rows.Next() {
@@ -189,7 +342,9 @@ func (s *pgStore) GetAllBookings(ctx context.Context, farmerId uint) (bookings [
 	for rows.Next() {
 		var bookingId uint
 		var machineId uint
-		err = rows.Scan(&bookingId, &machineId)
+		var status string
+		var seriesId *uint
+		err = rows.Scan(&bookingId, &machineId, &status, &seriesId)
 		if err != nil {
 			logger.WithField("err", err.Error()).Error("Error scanning bookings")
 			return
@@ -214,6 +369,8 @@ func (s *pgStore) GetAllBookings(ctx context.Context, farmerId uint) (bookings [
 		subBooking := domain.BookingResponse{
 			BookingId:   bookingId,
 			MachineId:   machineId,
+			Status:      status,
+			SeriesId:    seriesId,
 			SlotsBooked: slots,
 		}
 		bookings = append(bookings, subBooking)
@@ -223,60 +380,261 @@ func (s *pgStore) GetAllBookings(ctx context.Context, farmerId uint) (bookings [
 }
 
 func (s *pgStore) Book(ctx context.Context, booking domain.NewBookingRequest) (invoice domain.NewBookingResponse, err error) {
-	tx, err := s.db.Beginx()
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	invoice, err = book(ctx, tx, booking)
+	if err != nil {
+		return
+	}
+
+	err = tx.Commit()
+	return
+}
+
+// book books the slots of one machine in tx, with the booking's invoice,
+// promo discount, deposit hold and wallet payment.
+func book(ctx context.Context, tx *sqlx.Tx, booking domain.NewBookingRequest) (invoice domain.NewBookingResponse, err error) {
+
+	// the promo code is locked before the machine, here and at checkout, so
+	// that bookings sharing both cannot deadlock
+	var promo domain.PromoCode
+	if booking.PromoCode != "" {
+		promo, err = lockPromoCode(ctx, tx, booking.PromoCode, booking.FarmerId)
+		if err != nil {
+			return
+		}
+	}
+
+	line, err := bookLine(ctx, tx, booking)
+	if err != nil {
+		return
+	}
+
+	items := []domain.InvoiceLineItem{line.rentalItem()}
+	var discount *domain.Money
+	if booking.PromoCode != "" {
+		discounts, err := applyPromoCode(ctx, tx, promo, domain.PromoRedemption{FarmerId: booking.FarmerId, BookingId: &line.Id}, []bookedLine{line})
+		if err != nil {
+			return invoice, err
+		}
+		items = append(items, line.discountItem(promo.Code, discounts[0]))
+		discount = &discounts[0]
+		line.amount = line.amount.Sub(discounts[0])
+	}
+
+	newInvoice := domain.Invoice{
+		BookingId:    line.Id,
+		FarmerId:     booking.FarmerId,
+		OwnerId:      line.ownerId,
+		DateGenrated: time.Now().Format("2006-01-02"),
+		LineItems:    items,
+	}
+	err = createInvoice(ctx, tx, &newInvoice)
+	if err != nil {
+		return
+	}
+
+	var payment *domain.Payment
+	if booking.PayFromWallet {
+		paid, err := payFromWallet(ctx, tx, &newInvoice, booking.CommissionBp)
+		if err != nil {
+			return invoice, err
+		}
+		payment = &paid
+	}
+
+	rsp := domain.NewBookingResponse{BookingId: line.Id, InvoiceId: newInvoice.Id, InvoiceNumber: newInvoice.Number, MachineId: line.MachineId, Date: booking.Date, SlotsBooked: booking.Slots, TotalCost: line.amount, Discount: discount, Deposit: line.deposit, Payment: payment}
+
+	invoice = rsp
+
+	return
+}
+
+type bookedLine struct {
+	domain.Booking
+	machineName string
+	category    string
+	ownerId     uint
+	baseCharge  domain.Money
+	hsnSac      string
+	taxRate     uint
+	slots       uint
+	amount      domain.Money
+	deposit     *domain.Deposit
+}
+
+func (line bookedLine) rentalItem() domain.InvoiceLineItem {
+	bookingId := line.Id
+	return domain.InvoiceLineItem{
+		BookingId:   &bookingId,
+		Kind:        domain.LineItemRental,
+		Description: fmt.Sprintf("%s, %d hourly slots", line.machineName, line.slots),
+		HsnSac:      line.hsnSac,
+		Quantity:    line.slots,
+		UnitAmount:  line.baseCharge,
+		Amount:      line.amount,
+		TaxRate:     line.taxRate,
+	}
+}
+
+func (line bookedLine) discountItem(code string, discount domain.Money) domain.InvoiceLineItem {
+	bookingId := line.Id
+	return domain.InvoiceLineItem{
+		BookingId:   &bookingId,
+		Kind:        domain.LineItemDiscount,
+		Description: "Promo " + code,
+		HsnSac:      line.hsnSac,
+		Quantity:    1,
+		UnitAmount:  discount.Neg(),
+		Amount:      discount.Neg(),
+		TaxRate:     line.taxRate,
+	}
+}
+
+// bookLine books the slots of one machine inside the transaction. The machine
+// row is locked first so that concurrent bookings of the same machine cannot
+// both see the slots as empty.
+func bookLine(ctx context.Context, tx *sqlx.Tx, booking domain.NewBookingRequest) (line bookedLine, err error) {
+
+	var depositAmount int64
+
+	err = tx.QueryRowxContext(ctx, lockMachineQuery, booking.MachineId).Scan(&line.machineName, &line.ownerId, &line.baseCharge.Minor, &line.baseCharge.Currency, &depositAmount, &line.hsnSac, &line.taxRate, &line.category)
 	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error locking machine")
 		return
 	}
+
 	for _, slot := range booking.Slots {
-		empty := s.IsEmptySlot(ctx, booking.MachineId, slot, booking.Date)
-		if !empty {
-			err = errors.New("slot not empty")
-			tx.Rollback()
+		if !isEmptySlot(ctx, tx, booking.MachineId, slot, booking.Date) {
+			err = ErrSlotNotEmpty
 			return
 		}
 	}
 
-	newBooking := domain.Booking{
+	line.Booking = domain.Booking{
 		MachineId: booking.MachineId,
 		FarmerId:  booking.FarmerId,
+		SeriesId:  booking.SeriesId,
+		OrderId:   booking.OrderId,
+		Status:    booking.Status,
 	}
-	newBooking.Id, err = s.AddBooking(ctx, newBooking)
+	line.Id, err = addBooking(ctx, tx, line.Booking)
 	if err != nil {
-		tx.Rollback()
 		return
 	}
+
 	for _, slot := range booking.Slots {
 		newSlot := domain.Slot{
-			BookingId: newBooking.Id,
+			BookingId: line.Id,
 			SlotId:    slot,
 			Date:      booking.Date,
 		}
-		err = s.BookSlot(ctx, newSlot)
+		err = bookSlot(ctx, tx, newSlot)
 		if err != nil {
-			tx.Rollback()
 			return
 		}
 	}
-	baseCharge, err := s.GetBaseCharge(ctx, booking.MachineId)
+
+	err = addOutboxEvent(ctx, tx, domain.NewBookingEvent(domain.EventBookingRequested, line.ownerId, line.Id, booking.MachineId))
 	if err != nil {
-		tx.Rollback()
 		return
 	}
-	totalAmount := uint(len(booking.Slots)) * baseCharge
-	newInvoice := domain.Invoice{
-		BookingId:    newBooking.Id,
-		DateGenrated: time.Now().Format("2006-01-02"),
-		Amount:       totalAmount,
+	if booking.Status == "" || booking.Status == domain.BookingConfirmed {
+		err = addOutboxEvent(ctx, tx, domain.NewBookingEvent(domain.EventBookingAccepted, booking.FarmerId, line.Id, booking.MachineId))
+		if err != nil {
+			return
+		}
+	}
+
+	line.slots = uint(len(booking.Slots))
+	line.amount = line.baseCharge.Mul(int64(line.slots))
+
+	if depositAmount > 0 {
+		line.deposit = &domain.Deposit{
+			BookingId: line.Id,
+			FarmerId:  booking.FarmerId,
+			OwnerId:   line.ownerId,
+			Amount:    domain.NewMoney(depositAmount, line.baseCharge.Currency),
+		}
+		err = holdDeposit(ctx, tx, line.deposit)
 	}
-	newInvoice.Id, err = s.GenrateInvoice(ctx, newInvoice)
+	return
+}
+
+// CancelBooking cancels a booking of the farmer that has not started yet,
+// refunds its invoice to the wallet if it was the last booking left on a
+// wallet-paid one, releases its deposit and returns the slots it frees. The
+// machine's owner is told.
+func (s *pgStore) CancelBooking(ctx context.Context, bookingId uint, farmerId uint) (cancelled domain.CancelledBooking, err error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
-		tx.Rollback()
 		return
 	}
+	defer tx.Rollback()
 
-	rsp := domain.NewBookingResponse{InvoiceId: newInvoice.Id, MachineId: newBooking.MachineId, SlotsBooked: booking.Slots, TotalCost: totalAmount}
+	cancelled, err = cancelBooking(ctx, tx, bookingId, farmerId)
+	if err != nil {
+		return
+	}
 
-	invoice = rsp
+	cancelled.Refund, err = refundCancelledBooking(ctx, tx, bookingId)
+	if err != nil {
+		return
+	}
+
+	cancelled.Deposit, err = settleBookingDeposit(ctx, tx, bookingId, false, cancelledNote)
+	if err != nil {
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return
+	}
 
+	err = s.getBookingSlots(ctx, &cancelled)
 	return
 }
+
+// cancelledNote is the deposit ledger note of a cancellation.
+const cancelledNote = "booking cancelled"
+
+func cancelBooking(ctx context.Context, tx *sqlx.Tx, bookingId uint, farmerId uint) (cancelled domain.CancelledBooking, err error) {
+
+	err = tx.QueryRowContext(ctx, cancelBookingQuery, bookingId, farmerId).Scan(&cancelled.MachineId, &cancelled.OwnerId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error cancelling booking")
+		return
+	}
+	cancelled.BookingId = bookingId
+
+	err = addOutboxEvent(ctx, tx, domain.NewBookingEvent(domain.EventBookingCancelled, cancelled.OwnerId, bookingId, cancelled.MachineId))
+	return
+}
+
+func (s *pgStore) getBookingSlots(ctx context.Context, booking *domain.CancelledBooking) (err error) {
+
+	rows, err := s.db.QueryContext(ctx, getBookingSlotsQuery, booking.BookingId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting slots of booking")
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var slotId uint
+		err = rows.Scan(&booking.Date, &slotId)
+		if err != nil {
+			logger.WithField("err", err.Error()).Error("Error scanning slots")
+			return
+		}
+		booking.Slots = append(booking.Slots, slotId)
+	}
+
+	return rows.Err()
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"FarmEasy/domain"
 	"context"
+	"database/sql"
 	"errors"
 	"testing"
 
@@ -61,6 +62,8 @@ func (s *DbTestSuite) Test_pgStore_RegisterFarmer() {
 					Phone:     "1234567890",
 					Address:   "1234, abc street, xyz city",
 					Password:  "password",
+					State:     "27",
+					GSTIN:     "27AAPFU0939F1ZV",
 				},
 			},
 			wantErr: false,
@@ -94,7 +97,7 @@ func (s *DbTestSuite) Test_pgStore_RegisterFarmer() {
 			}
 			rows := sqlxmock.NewRows([]string{"id"}).AddRow(1)
 
-			s.mock.ExpectQuery("INSERT INTO farmers").WithArgs(tt.args.farmer.FirstName, tt.args.farmer.LastName, tt.args.farmer.Email, tt.args.farmer.Phone, tt.args.farmer.Address, tt.args.farmer.Password).WillReturnError(err).WillReturnRows(rows)
+			s.mock.ExpectQuery("INSERT INTO farmers").WithArgs(tt.args.farmer.FirstName, tt.args.farmer.LastName, tt.args.farmer.Email, tt.args.farmer.Phone, tt.args.farmer.Address, tt.args.farmer.Password, tt.args.farmer.State, tt.args.farmer.GSTIN).WillReturnError(err).WillReturnRows(rows)
 
 			if err := s.repo.RegisterFarmer(tt.args.ctx, tt.args.farmer); tt.wantErr {
 				require.Error(t, err)
@@ -186,14 +189,17 @@ func (s *DbTestSuite) Test_pgStore_AddMachine() {
 					Id:               1,
 					Name:             "Machine1",
 					Description:      "Machine1 Description",
-					BaseHourlyCharge: 1000,
+					BaseHourlyCharge: domain.Rupees(1000),
 					OwnerId:          1,
 				},
 			},
 			wantErr: false,
 			prepare: func(args args, mock sqlxmock.Sqlmock) {
 				rows := sqlxmock.NewRows([]string{"id"}).AddRow(uint(1))
-				mock.ExpectQuery("INSERT INTO machines").WithArgs(args.newMachine.Name, args.newMachine.Description, args.newMachine.BaseHourlyCharge, args.newMachine.OwnerId).WillReturnRows(rows)
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO machines").WithArgs(args.newMachine.Name, args.newMachine.Description, args.newMachine.BaseHourlyCharge.Minor, args.newMachine.BaseHourlyCharge.Currency, args.newMachine.OwnerId, args.newMachine.Category, args.newMachine.Latitude, args.newMachine.Longitude, args.newMachine.SecurityDeposit.Minor, args.newMachine.UsageBilling, args.newMachine.NoShowPolicy).WillReturnRows(rows)
+				mock.ExpectExec("INSERT INTO outbox_events").WithArgs(domain.EventMachineCreated, args.newMachine.OwnerId, 1, "", `{"machine_id":1,"name":"Machine1","category":"","base_hourly_charge":{"amount":"1000.00","currency":"INR"}}`).WillReturnResult(sqlxmock.NewResult(1, 1))
+				mock.ExpectCommit()
 			},
 		},
 		{
@@ -203,16 +209,17 @@ func (s *DbTestSuite) Test_pgStore_AddMachine() {
 				newMachine: &domain.MachineResponse{
 					Name:             "Machine1",
 					Description:      "Machine1 Description",
-					BaseHourlyCharge: 1000,
+					BaseHourlyCharge: domain.Rupees(1000),
 					OwnerId:          1,
 				},
 			},
 			wantErr: true,
 			prepare: func(args args, mock sqlxmock.Sqlmock) {
-
-				mock.ExpectQuery("INSERT INTO machines").WithArgs(args.newMachine.Name, args.newMachine.Description, args.newMachine.BaseHourlyCharge, args.newMachine.OwnerId).WillReturnError(
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO machines").WithArgs(args.newMachine.Name, args.newMachine.Description, args.newMachine.BaseHourlyCharge.Minor, args.newMachine.BaseHourlyCharge.Currency, args.newMachine.OwnerId, args.newMachine.Category, args.newMachine.Latitude, args.newMachine.Longitude, args.newMachine.SecurityDeposit.Minor, args.newMachine.UsageBilling, args.newMachine.NoShowPolicy).WillReturnError(
 					errors.New("mocked error"),
 				)
+				mock.ExpectRollback()
 			},
 		},
 	}
@@ -253,22 +260,25 @@ func (s *DbTestSuite) Test_pgStore_GetMachines() {
 					Id:               1,
 					Name:             "Machine1",
 					Description:      "Machine1 Description",
-					BaseHourlyCharge: 1000,
+					BaseHourlyCharge: domain.Rupees(1000),
 					OwnerId:          1,
+					Category:         "other",
+					Rating:           domain.Rating{Average: 4.5, Count: 2},
 				},
 				{
 					Id:               2,
 					Name:             "Machine2",
 					Description:      "Machine2 Description",
-					BaseHourlyCharge: 2000,
+					BaseHourlyCharge: domain.Rupees(2000),
 					OwnerId:          3,
+					Category:         "other",
 				},
 			},
 			wantErr: false,
 			prepare: func(args args, mock sqlxmock.Sqlmock) {
-				rows := sqlxmock.NewRows([]string{"id", "name", "description", "base_hourly_charge", "owner_id"}).
-					AddRow(uint(1), "Machine1", "Machine1 Description", 1000, uint(1)).
-					AddRow(uint(2), "Machine2", "Machine2 Description", 2000, uint(3))
+				rows := sqlxmock.NewRows([]string{"id", "name", "description", "base_hourly_charge.minor", "base_hourly_charge.currency", "owner_id", "category", "latitude", "longitude", "rating.average", "rating.count"}).
+					AddRow(uint(1), "Machine1", "Machine1 Description", 100000, "INR", uint(1), "other", nil, nil, "4.50", 2).
+					AddRow(uint(2), "Machine2", "Machine2 Description", 200000, "INR", uint(3), "other", nil, nil, "0", 0)
 				mock.ExpectQuery("SELECT (.+) FROM machines").WillReturnRows(rows)
 
 			},
@@ -290,18 +300,42 @@ func (s *DbTestSuite) Test_pgStore_GetMachines() {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.prepare(tt.args, s.mock)
-			_, err := s.repo.GetMachines(tt.args.ctx)
+			gotMachines, err := s.repo.GetMachines(tt.args.ctx)
 			if tt.wantErr {
 				require.Error(t, err)
 			} else {
 
 				require.NoError(t, err)
+				assert.Equal(t, tt.wantMachines, gotMachines)
 
 			}
 		})
 	}
 }
 
+func (s *DbTestSuite) Test_pgStore_GetMachine() {
+	t := s.T()
+
+	s.Run("when the machine exists", func() {
+		s.mock.ExpectQuery("SELECT (.+) FROM machines WHERE id = \\$1").WithArgs(3).WillReturnRows(
+			sqlxmock.NewRows([]string{"id", "name", "base_hourly_charge.minor", "base_hourly_charge.currency", "owner_id", "category"}).
+				AddRow(3, "Tractor", 100000, "INR", 20, "tractor"))
+
+		got, err := s.repo.GetMachine(context.TODO(), 3)
+		require.NoError(t, err)
+		assert.Equal(t, domain.MachineResponse{Id: 3, Name: "Tractor", BaseHourlyCharge: domain.Rupees(1000), OwnerId: 20, Category: "tractor"}, got)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("when there is no such machine", func() {
+		s.mock.ExpectQuery("SELECT (.+) FROM machines WHERE id = \\$1").WithArgs(5).WillReturnError(sql.ErrNoRows)
+
+		_, err := s.repo.GetMachine(context.TODO(), 5)
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+}
+
 func (s *DbTestSuite) Test_pgStore_IsEmptySlot() {
 	t := s.T()
 	type args struct {
@@ -385,7 +419,7 @@ func (s *DbTestSuite) Test_pgStore_AddBooking() {
 			wantErr:       false,
 			prepare: func(args args, mock sqlxmock.Sqlmock) {
 				rows := sqlxmock.NewRows([]string{"id"}).AddRow(uint(1))
-				mock.ExpectQuery("INSERT INTO bookings").WithArgs(args.booking.MachineId, args.booking.FarmerId).WillReturnRows(rows)
+				mock.ExpectQuery("INSERT INTO bookings").WithArgs(args.booking.MachineId, args.booking.FarmerId, args.booking.SeriesId, args.booking.OrderId, args.booking.Status).WillReturnRows(rows)
 			},
 		},
 		{
@@ -401,7 +435,7 @@ func (s *DbTestSuite) Test_pgStore_AddBooking() {
 			wantErr:       true,
 			prepare: func(args args, mock sqlxmock.Sqlmock) {
 
-				mock.ExpectQuery("INSERT INTO bookings").WithArgs(args.booking.MachineId, args.booking.FarmerId).WillReturnError(errors.New("mocked error"))
+				mock.ExpectQuery("INSERT INTO bookings").WithArgs(args.booking.MachineId, args.booking.FarmerId, args.booking.SeriesId, args.booking.OrderId, args.booking.Status).WillReturnError(errors.New("mocked error"))
 
 			},
 		},
@@ -485,7 +519,7 @@ func (s *DbTestSuite) Test_pgStore_GetBaseCharge() {
 	tests := []struct {
 		name           string
 		args           args
-		wantBaseCharge uint
+		wantBaseCharge domain.Money
 		wantErr        bool
 		prepare        func(args, sqlxmock.Sqlmock)
 	}{
@@ -496,10 +530,10 @@ func (s *DbTestSuite) Test_pgStore_GetBaseCharge() {
 				ctx:       context.TODO(),
 				machineId: 1,
 			},
-			wantBaseCharge: 100,
+			wantBaseCharge: domain.Rupees(100),
 			wantErr:        false,
 			prepare: func(args args, mock sqlxmock.Sqlmock) {
-				rows := sqlxmock.NewRows([]string{"base_hourly_charge"}).AddRow(uint(100))
+				rows := sqlxmock.NewRows([]string{"base_hourly_charge", "currency"}).AddRow(10000, "INR")
 				mock.ExpectQuery("SELECT base_hourly_charge").WithArgs(args.machineId).WillReturnRows(rows)
 			},
 		},
@@ -510,7 +544,7 @@ func (s *DbTestSuite) Test_pgStore_GetBaseCharge() {
 				ctx:       context.TODO(),
 				machineId: 1,
 			},
-			wantBaseCharge: 0,
+			wantBaseCharge: domain.Money{},
 			wantErr:        true,
 			prepare: func(args args, mock sqlxmock.Sqlmock) {
 				mock.ExpectQuery("SELECT base_hourly_charge").WithArgs(args.machineId).WillReturnError(errors.New("mocked error"))
@@ -550,7 +584,9 @@ func (s *DbTestSuite) Test_pgStore_GenrateInvoice() {
 				ctx: context.TODO(),
 				newInvoice: domain.Invoice{
 					BookingId:    1,
-					Amount:       100,
+					FarmerId:     2,
+					OwnerId:      3,
+					Amount:       domain.Rupees(1),
 					DateGenrated: "2021-01-01",
 				},
 			},
@@ -558,7 +594,13 @@ func (s *DbTestSuite) Test_pgStore_GenrateInvoice() {
 			wantErr:       false,
 			prepare: func(args args, mock sqlxmock.Sqlmock) {
 				rows := sqlxmock.NewRows([]string{"id"}).AddRow(1)
-				mock.ExpectQuery("INSERT INTO invoice").WithArgs(args.newInvoice.BookingId, args.newInvoice.DateGenrated, args.newInvoice.Amount).WillReturnRows(rows)
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO invoice_sequences").WithArgs("2020-21").WillReturnRows(sqlxmock.NewRows([]string{"last_number"}).AddRow(1))
+				mock.ExpectQuery("SELECT (.+) FROM farmers o, farmers r").WithArgs(args.newInvoice.OwnerId, args.newInvoice.FarmerId).WillReturnRows(sqlxmock.NewRows([]string{"state", "gstin", "state"}).AddRow("", "", ""))
+				mock.ExpectQuery("INSERT INTO invoices").WithArgs("FE/2020-21/000001", "2020-21", args.newInvoice.BookingId, nil, args.newInvoice.FarmerId, args.newInvoice.OwnerId, "", "", "intra_state", args.newInvoice.DateGenrated, 100, 0, 0, 0, 100, "INR").WillReturnRows(rows)
+				mock.ExpectExec("INSERT INTO outbox_events").WithArgs(domain.EventInvoiceIssued, args.newInvoice.FarmerId, 1, sqlxmock.AnyArg(), sqlxmock.AnyArg()).WillReturnResult(sqlxmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO outbox_events").WithArgs(domain.EventInvoiceIssued, args.newInvoice.OwnerId, 1, "", sqlxmock.AnyArg()).WillReturnResult(sqlxmock.NewResult(1, 1))
+				mock.ExpectCommit()
 			},
 		},
 		{
@@ -567,14 +609,20 @@ func (s *DbTestSuite) Test_pgStore_GenrateInvoice() {
 				ctx: context.TODO(),
 				newInvoice: domain.Invoice{
 					BookingId:    1,
-					Amount:       100,
+					FarmerId:     2,
+					OwnerId:      3,
+					Amount:       domain.Rupees(1),
 					DateGenrated: "2021-01-01",
 				},
 			},
 			wantInvoiceId: 0,
 			wantErr:       true,
 			prepare: func(args args, mock sqlxmock.Sqlmock) {
-				mock.ExpectQuery("INSERT INTO invoice").WithArgs(args.newInvoice.BookingId, args.newInvoice.Amount, args.newInvoice.DateGenrated).WillReturnError(errors.New("mocked error"))
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO invoice_sequences").WithArgs("2020-21").WillReturnRows(sqlxmock.NewRows([]string{"last_number"}).AddRow(1))
+				mock.ExpectQuery("SELECT (.+) FROM farmers o, farmers r").WillReturnRows(sqlxmock.NewRows([]string{"state", "gstin", "state"}).AddRow("", "", ""))
+				mock.ExpectQuery("INSERT INTO invoices").WillReturnError(errors.New("mocked error"))
+				mock.ExpectRollback()
 			},
 		},
 	}
@@ -674,13 +722,14 @@ func (s *DbTestSuite) Test_pgStore_GetAllBookings() {
 				{
 					BookingId:   1,
 					MachineId:   1,
+					Status:      "confirmed",
 					SlotsBooked: []uint{1, 2, 3},
 				},
 			},
 			wantErr: false,
 			prepare: func(args args, mock sqlxmock.Sqlmock) {
-				rows := sqlxmock.NewRows([]string{"id", "machine_id"}).AddRow(1, 1)
-				mock.ExpectQuery("SELECT id,machine_id FROM bookings WHERE farmer_id = \\$1").WithArgs(args.farmerId).WillReturnRows(rows)
+				rows := sqlxmock.NewRows([]string{"id", "machine_id", "status", "series_id"}).AddRow(1, 1, "confirmed", nil)
+				mock.ExpectQuery("SELECT id,machine_id,status,series_id FROM bookings WHERE farmer_id = \\$1").WithArgs(args.farmerId).WillReturnRows(rows)
 				rows = sqlxmock.NewRows([]string{"slot_id"}).AddRow(1).AddRow(2).AddRow(3)
 				mock.ExpectQuery("SELECT slot_id FROM slots_booked WHERE booking_id = \\$1").WithArgs(1).WillReturnRows(rows)
 
@@ -696,7 +745,7 @@ func (s *DbTestSuite) Test_pgStore_GetAllBookings() {
 			wantErr:      true,
 			prepare: func(args args, mock sqlxmock.Sqlmock) {
 
-				mock.ExpectQuery("SELECT id,machine_id FROM bookings WHERE farmer_id = \\$1").WithArgs(args.farmerId).WillReturnError(errors.New("mocked error"))
+				mock.ExpectQuery("SELECT id,machine_id,status,series_id FROM bookings WHERE farmer_id = \\$1").WithArgs(args.farmerId).WillReturnError(errors.New("mocked error"))
 			},
 		},
 		{
@@ -708,8 +757,8 @@ func (s *DbTestSuite) Test_pgStore_GetAllBookings() {
 			wantBookings: nil,
 			wantErr:      true,
 			prepare: func(args args, mock sqlxmock.Sqlmock) {
-				rows := sqlxmock.NewRows([]string{"id", "machine_id"}).AddRow(1, 1)
-				mock.ExpectQuery("SELECT id,machine_id FROM bookings WHERE farmer_id = \\$1").WithArgs(args.farmerId).WillReturnRows(rows)
+				rows := sqlxmock.NewRows([]string{"id", "machine_id", "status", "series_id"}).AddRow(1, 1, "confirmed", nil)
+				mock.ExpectQuery("SELECT id,machine_id,status,series_id FROM bookings WHERE farmer_id = \\$1").WithArgs(args.farmerId).WillReturnRows(rows)
 				// rows = sqlxmock.NewRows([]string{"slot_id"}).AddRow(1).AddRow(2).AddRow(3)
 				mock.ExpectQuery("SELECT slot_id FROM slots_booked WHERE booking_id = \\$1").WithArgs(1).WillReturnError(errors.New("mocked error"))
 
@@ -728,3 +777,62 @@ func (s *DbTestSuite) Test_pgStore_GetAllBookings() {
 		})
 	}
 }
+
+func (s *DbTestSuite) Test_pgStore_CancelBooking() {
+	t := s.T()
+
+	t.Run("when the booking is cancelled", func(t *testing.T) {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("UPDATE bookings SET status = 'cancelled'").WithArgs(3, 1).WillReturnRows(sqlxmock.NewRows([]string{"machine_id", "owner_id"}).AddRow(2, 20))
+		s.expectOutboxEvent(domain.EventBookingCancelled, 20)
+		s.expectNoWalletRefund(3)
+		s.expectNoHeldDeposit(3)
+		s.mock.ExpectCommit()
+		rows := sqlxmock.NewRows([]string{"date", "slot_id"}).AddRow("2026-11-07", 7).AddRow("2026-11-07", 8)
+		s.mock.ExpectQuery("SELECT (.+) FROM slots_booked WHERE booking_id = \\$1").WithArgs(3).WillReturnRows(rows)
+
+		got, err := s.repo.CancelBooking(context.TODO(), 3, 1)
+		require.NoError(t, err)
+		assert.Equal(t, domain.CancelledBooking{BookingId: 3, MachineId: 2, OwnerId: 20, Date: "2026-11-07", Slots: []uint{7, 8}}, got)
+	})
+
+	t.Run("releases the deposit with the cancellation", func(t *testing.T) {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("UPDATE bookings SET status = 'cancelled'").WithArgs(3, 1).WillReturnRows(sqlxmock.NewRows([]string{"machine_id", "owner_id"}).AddRow(2, 20))
+		s.expectOutboxEvent(domain.EventBookingCancelled, 20)
+		s.expectNoWalletRefund(3)
+		s.expectReleaseHeldDeposit(3, "booking cancelled")
+		s.mock.ExpectCommit()
+		s.mock.ExpectQuery("SELECT (.+) FROM slots_booked WHERE booking_id = \\$1").WithArgs(3).WillReturnRows(sqlxmock.NewRows([]string{"date", "slot_id"}).AddRow("2026-11-07", 7))
+
+		got, err := s.repo.CancelBooking(context.TODO(), 3, 1)
+		require.NoError(t, err)
+		require.NotNil(t, got.Deposit)
+		assert.Equal(t, uint(5), got.Deposit.Id)
+		assert.Equal(t, domain.DepositReleased, got.Deposit.Status)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	t.Run("keeps the booking when the deposit cannot be released", func(t *testing.T) {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("UPDATE bookings SET status = 'cancelled'").WithArgs(3, 1).WillReturnRows(sqlxmock.NewRows([]string{"machine_id", "owner_id"}).AddRow(2, 20))
+		s.expectOutboxEvent(domain.EventBookingCancelled, 20)
+		s.expectNoWalletRefund(3)
+		s.expectHeldDeposit(3)
+		s.mock.ExpectQuery("UPDATE deposits SET status = 'released'").WithArgs(5).WillReturnError(errors.New("connection reset"))
+		s.mock.ExpectRollback()
+
+		_, err := s.repo.CancelBooking(context.TODO(), 3, 1)
+		assert.Error(t, err)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	t.Run("when the booking has already started", func(t *testing.T) {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("UPDATE bookings SET status = 'cancelled'").WithArgs(3, 1).WillReturnError(sql.ErrNoRows)
+		s.mock.ExpectRollback()
+
+		_, err := s.repo.CancelBooking(context.TODO(), 3, 1)
+		assert.Equal(t, sql.ErrNoRows, err)
+	})
+}
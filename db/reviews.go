@@ -0,0 +1,164 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	// machineRatingColumns rate a machine by its renters' visible reviews; they
+	// are selected alongside the columns of machines.
+	machineRatingColumns = "COALESCE((SELECT ROUND(AVG(rating), 2) FROM reviews WHERE machine_id = machines.id and role = 'renter' and not hidden), 0) AS \"rating.average\", " +
+		"(SELECT COUNT(*) FROM reviews WHERE machine_id = machines.id and role = 'renter' and not hidden) AS \"rating.count\""
+	farmerRatingColumns = "COALESCE((SELECT ROUND(AVG(rating), 2) FROM reviews WHERE reviewee_id = farmers.id and role = 'renter' and not hidden), 0) AS \"owner_rating.average\", " +
+		"(SELECT COUNT(*) FROM reviews WHERE reviewee_id = farmers.id and role = 'renter' and not hidden) AS \"owner_rating.count\", " +
+		"COALESCE((SELECT ROUND(AVG(rating), 2) FROM reviews WHERE reviewee_id = farmers.id and role = 'owner' and not hidden), 0) AS \"renter_rating.average\", " +
+		"(SELECT COUNT(*) FROM reviews WHERE reviewee_id = farmers.id and role = 'owner' and not hidden) AS \"renter_rating.count\""
+	reviewColumns       = "id, booking_id, machine_id, reviewer_id, reviewee_id, role, rating, comment, hidden, created_at"
+	reviewReportColumns = "rr.id, rr.review_id, rr.reporter_id, rr.reason, rr.status, rr.resolution_note, rr.resolved_by, rr.created_at, rr.resolved_at, " +
+		"r.id AS \"review.id\", r.booking_id AS \"review.booking_id\", r.machine_id AS \"review.machine_id\", r.reviewer_id AS \"review.reviewer_id\", r.reviewee_id AS \"review.reviewee_id\", " +
+		"r.role AS \"review.role\", r.rating AS \"review.rating\", r.comment AS \"review.comment\", r.hidden AS \"review.hidden\", r.created_at AS \"review.created_at\""
+	getFarmerQuery           = "SELECT id, fname, lname, email, phone, address, COALESCE(state, '') AS state, COALESCE(gstin, '') AS gstin, " + farmerRatingColumns + " FROM farmers WHERE id = $1"
+	addReviewQuery           = "INSERT INTO reviews (booking_id, machine_id, reviewer_id, reviewee_id, role, rating, comment) VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (booking_id, reviewer_id) DO NOTHING RETURNING id, created_at"
+	getReviewQuery           = "SELECT " + reviewColumns + " FROM reviews WHERE id = $1"
+	getMachineReviewsQuery   = "SELECT " + reviewColumns + " FROM reviews WHERE machine_id = $1 and role = 'renter' and not hidden ORDER BY id DESC"
+	getFarmerReviewsQuery    = "SELECT " + reviewColumns + " FROM reviews WHERE reviewee_id = $1 and not hidden ORDER BY id DESC"
+	addReviewReportQuery     = "INSERT INTO review_reports (review_id, reporter_id, reason) VALUES ($1, $2, $3) ON CONFLICT (review_id, reporter_id) DO NOTHING RETURNING id, status, created_at"
+	getReviewReportsQuery    = "SELECT " + reviewReportColumns + " FROM review_reports rr JOIN reviews r ON r.id = rr.review_id WHERE rr.status = $1 ORDER BY rr.id"
+	getReviewReportQuery     = "SELECT " + reviewReportColumns + " FROM review_reports rr JOIN reviews r ON r.id = rr.review_id WHERE rr.id = $1"
+	resolveReviewReportQuery = "UPDATE review_reports SET status = $2, resolution_note = $3, resolved_by = $4, resolved_at = NOW() WHERE id = $1 and status = 'open' RETURNING review_id"
+	hideReviewQuery          = "UPDATE reviews SET hidden = TRUE WHERE id = $1"
+	upholdOtherReportsQuery  = "UPDATE review_reports SET status = 'upheld', resolution_note = $2, resolved_by = $3, resolved_at = NOW() WHERE review_id = $1 and status = 'open'"
+)
+
+// GetFarmer returns the farmer with their ratings as an owner and as a renter.
+func (s *pgStore) GetFarmer(ctx context.Context, farmerId uint) (farmer domain.FarmerResponse, err error) {
+
+	err = s.db.GetContext(ctx, &farmer, getFarmerQuery, farmerId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting farmer")
+		return
+	}
+
+	return
+}
+
+// AddReview records the review. It returns sql.ErrNoRows if the reviewer
+// already reviewed the booking.
+func (s *pgStore) AddReview(ctx context.Context, review *domain.Review) (err error) {
+
+	err = s.db.QueryRowContext(ctx, addReviewQuery, review.BookingId, review.MachineId, review.ReviewerId, review.RevieweeId, review.Role, review.Rating, review.Comment).Scan(&review.Id, &review.CreatedAt)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error inserting review")
+		return
+	}
+
+	return
+}
+
+func (s *pgStore) GetReview(ctx context.Context, reviewId uint) (review domain.Review, err error) {
+
+	err = s.db.GetContext(ctx, &review, getReviewQuery, reviewId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting review")
+		return
+	}
+
+	return
+}
+
+// GetMachineReviews returns the visible reviews renters left on the machine,
+// newest first.
+func (s *pgStore) GetMachineReviews(ctx context.Context, machineId uint) (reviews []domain.Review, err error) {
+
+	err = s.db.SelectContext(ctx, &reviews, getMachineReviewsQuery, machineId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting machine reviews")
+		return
+	}
+
+	return
+}
+
+// GetFarmerReviews returns the visible reviews about the farmer, as an owner
+// or as a renter, newest first.
+func (s *pgStore) GetFarmerReviews(ctx context.Context, farmerId uint) (reviews []domain.Review, err error) {
+
+	err = s.db.SelectContext(ctx, &reviews, getFarmerReviewsQuery, farmerId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting farmer reviews")
+		return
+	}
+
+	return
+}
+
+// AddReviewReport records the report. It returns sql.ErrNoRows if the farmer
+// already reported the review.
+func (s *pgStore) AddReviewReport(ctx context.Context, report *domain.ReviewReport) (err error) {
+
+	err = s.db.QueryRowContext(ctx, addReviewReportQuery, report.ReviewId, report.ReporterId, report.Reason).Scan(&report.Id, &report.Status, &report.CreatedAt)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error inserting review report")
+		return
+	}
+
+	return
+}
+
+// GetReviewReports returns the reports in the status with the reviews they
+// report, oldest first.
+func (s *pgStore) GetReviewReports(ctx context.Context, status string) (reports []domain.ReviewReport, err error) {
+
+	err = s.db.SelectContext(ctx, &reports, getReviewReportsQuery, status)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting review reports")
+		return
+	}
+
+	return
+}
+
+// ResolveReviewReport dismisses or upholds an open report. Upholding it hides
+// the review and upholds the other open reports of it too. It returns
+// sql.ErrNoRows if the report is not open.
+func (s *pgStore) ResolveReviewReport(ctx context.Context, reportId uint, status string, note string, adminId uint) (report domain.ReviewReport, err error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	var reviewId uint
+	err = tx.QueryRowContext(ctx, resolveReviewReportQuery, reportId, status, note, adminId).Scan(&reviewId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error resolving review report")
+		return
+	}
+
+	if status == domain.ReviewReportUpheld {
+		_, err = tx.ExecContext(ctx, hideReviewQuery, reviewId)
+		if err != nil {
+			logger.WithField("err", err.Error()).Error("Error hiding review")
+			return
+		}
+
+		_, err = tx.ExecContext(ctx, upholdOtherReportsQuery, reviewId, note, adminId)
+		if err != nil {
+			logger.WithField("err", err.Error()).Error("Error upholding other review reports")
+			return
+		}
+	}
+
+	err = tx.GetContext(ctx, &report, getReviewReportQuery, reportId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting review report")
+		return
+	}
+
+	err = tx.Commit()
+	return
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func (s *DbTestSuite) Test_pgStore_AddReview() {
+	t := s.T()
+	review := domain.Review{BookingId: 5, MachineId: 2, ReviewerId: 9, RevieweeId: 20, Role: "renter", Rating: 4, Comment: "ran well"}
+
+	s.Run("records the review", func() {
+		s.mock.ExpectQuery("INSERT INTO reviews").WithArgs(5, 2, 9, 20, "renter", 4, "ran well").WillReturnRows(
+			sqlxmock.NewRows([]string{"id", "created_at"}).AddRow(7, time.Now()))
+
+		got := review
+		require.NoError(t, s.repo.AddReview(context.TODO(), &got))
+		assert.Equal(t, uint(7), got.Id)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("when the booking was already reviewed", func() {
+		s.mock.ExpectQuery("INSERT INTO reviews").WillReturnError(sql.ErrNoRows)
+
+		got := review
+		assert.Equal(t, sql.ErrNoRows, s.repo.AddReview(context.TODO(), &got))
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+}
+
+func (s *DbTestSuite) Test_pgStore_GetFarmer() {
+	t := s.T()
+
+	s.mock.ExpectQuery("SELECT (.+) FROM farmers WHERE id = \\$1").WithArgs(20).WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "fname", "lname", "email", "phone", "address", "state", "gstin", "owner_rating.average", "owner_rating.count", "renter_rating.average", "renter_rating.count"}).
+			AddRow(20, "Asha", "Patil", "asha@example.com", "9876543210", "Baramati", "27", "", "4.33", 3, "0", 0))
+
+	got, err := s.repo.GetFarmer(context.TODO(), 20)
+	require.NoError(t, err)
+	assert.Equal(t, domain.Rating{Average: 4.33, Count: 3}, got.OwnerRating)
+	assert.Equal(t, domain.Rating{}, got.RenterRating)
+	assert.NoError(t, s.mock.ExpectationsWereMet())
+}
+
+func (s *DbTestSuite) Test_pgStore_ResolveReviewReport() {
+	t := s.T()
+	now := time.Now()
+	reportRow := func(status string) *sqlxmock.Rows {
+		return sqlxmock.NewRows([]string{"id", "review_id", "reporter_id", "reason", "status", "resolution_note", "resolved_by", "created_at", "resolved_at",
+			"review.id", "review.booking_id", "review.machine_id", "review.reviewer_id", "review.reviewee_id", "review.role", "review.rating", "review.comment", "review.hidden", "review.created_at"}).
+			AddRow(4, 7, 20, "slurs", status, "abusive", 1, now, now, 7, 5, 2, 9, 20, "renter", 1, "...", status == domain.ReviewReportUpheld, now)
+	}
+
+	s.Run("upholding hides the review", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("UPDATE review_reports SET status = \\$2").WithArgs(4, "upheld", "abusive", 1).WillReturnRows(sqlxmock.NewRows([]string{"review_id"}).AddRow(7))
+		s.mock.ExpectExec("UPDATE reviews SET hidden = TRUE").WithArgs(7).WillReturnResult(sqlxmock.NewResult(0, 1))
+		s.mock.ExpectExec("UPDATE review_reports SET status = 'upheld'").WithArgs(7, "abusive", 1).WillReturnResult(sqlxmock.NewResult(0, 2))
+		s.mock.ExpectQuery("SELECT (.+) FROM review_reports rr JOIN reviews r").WithArgs(4).WillReturnRows(reportRow(domain.ReviewReportUpheld))
+		s.mock.ExpectCommit()
+
+		got, err := s.repo.ResolveReviewReport(context.TODO(), 4, domain.ReviewReportUpheld, "abusive", 1)
+		require.NoError(t, err)
+		assert.Equal(t, domain.ReviewReportUpheld, got.Status)
+		assert.True(t, got.Review.Hidden)
+		assert.Equal(t, uint(9), got.Review.ReviewerId)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("dismissing leaves the review", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("UPDATE review_reports SET status = \\$2").WithArgs(4, "dismissed", "abusive", 1).WillReturnRows(sqlxmock.NewRows([]string{"review_id"}).AddRow(7))
+		s.mock.ExpectQuery("SELECT (.+) FROM review_reports rr JOIN reviews r").WithArgs(4).WillReturnRows(reportRow(domain.ReviewReportDismissed))
+		s.mock.ExpectCommit()
+
+		got, err := s.repo.ResolveReviewReport(context.TODO(), 4, domain.ReviewReportDismissed, "abusive", 1)
+		require.NoError(t, err)
+		assert.False(t, got.Review.Hidden)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("when the report was already resolved", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("UPDATE review_reports SET status = \\$2").WillReturnError(sql.ErrNoRows)
+		s.mock.ExpectRollback()
+
+		_, err := s.repo.ResolveReviewReport(context.TODO(), 4, domain.ReviewReportUpheld, "abusive", 1)
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+
+	"github.com/lib/pq"
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	getBookedSlotsQuery = "SELECT b.machine_id, to_char(s.date, 'YYYY-MM-DD'), s.slot_id FROM slots_booked s, bookings b WHERE s.booking_id = b.id and b.machine_id = ANY($1) and s.date BETWEEN $2 and $3 and b.status NOT IN ('cancelled', 'no_show')"
+	findMachinesQuery   = "SELECT " + machineColumns + " FROM machines WHERE ($1 = '' or category = $1) and (cardinality($2::bigint[]) = 0 or id = ANY($2)) ORDER BY id"
+)
+
+// GetBookedSlots is the bulk form of GetBookedSlot. It returns the booked slots
+// of every machine for every date between fromDate and toDate (inclusive),
+// keyed by machine id and then date.
+func (s *pgStore) GetBookedSlots(ctx context.Context, machineIds []uint, fromDate string, toDate string) (map[uint]map[string]map[uint]struct{}, error) {
+
+	rows, err := s.db.QueryContext(ctx, getBookedSlotsQuery, pq.Array(machineIds), fromDate, toDate)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("error getting booked slots")
+		return nil, err
+	}
+	defer rows.Close()
+
+	bookedSlots := map[uint]map[string]map[uint]struct{}{}
+	for rows.Next() {
+		var machineId, slotId uint
+		var date string
+
+		err = rows.Scan(&machineId, &date, &slotId)
+		if err != nil {
+			logger.WithField("err", err.Error()).Error("Error scanning slots")
+			return nil, err
+		}
+
+		if bookedSlots[machineId] == nil {
+			bookedSlots[machineId] = map[string]map[uint]struct{}{}
+		}
+		if bookedSlots[machineId][date] == nil {
+			bookedSlots[machineId][date] = map[uint]struct{}{}
+		}
+		bookedSlots[machineId][date][slotId] = struct{}{}
+	}
+
+	return bookedSlots, rows.Err()
+}
+
+func (s *pgStore) FindMachines(ctx context.Context, filter domain.MachineFilter) (machines []domain.MachineResponse, err error) {
+
+	err = s.db.SelectContext(ctx, &machines, findMachinesQuery, filter.Category, pq.Array(filter.MachineIds))
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error finding machines")
+		return
+	}
+
+	return
+}
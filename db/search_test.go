@@ -0,0 +1,27 @@
+package db
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func (s *DbTestSuite) Test_pgStore_GetBookedSlots() {
+	t := s.T()
+
+	rows := sqlxmock.NewRows([]string{"machine_id", "date", "slot_id"}).
+		AddRow(1, "2026-11-07", 8).
+		AddRow(1, "2026-11-08", 9).
+		AddRow(2, "2026-11-07", 8)
+	s.mock.ExpectQuery("SELECT b.machine_id, (.+) FROM slots_booked s, bookings b").WithArgs(pq.Array([]uint{1, 2}), "2026-11-07", "2026-11-08").WillReturnRows(rows)
+
+	got, err := s.repo.GetBookedSlots(context.TODO(), []uint{1, 2}, "2026-11-07", "2026-11-08")
+	require.NoError(t, err)
+	assert.Equal(t, map[uint]map[string]map[uint]struct{}{
+		1: {"2026-11-07": {8: {}}, "2026-11-08": {9: {}}},
+		2: {"2026-11-07": {8: {}}},
+	}, got)
+}
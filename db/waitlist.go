@@ -0,0 +1,190 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	waitlistColumns               = "id, farmer_id, machine_id, to_char(date, 'YYYY-MM-DD') AS date, start_slot, end_slot, auto_book, status, booking_id, offer_expires_at, created_at"
+	addWaitlistEntryQuery         = "INSERT INTO waitlist_entries (farmer_id, machine_id, date, start_slot, end_slot, auto_book) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, status, created_at"
+	getWaitlistEntriesQuery       = "SELECT " + waitlistColumns + " FROM waitlist_entries WHERE farmer_id = $1 ORDER BY created_at DESC"
+	cancelWaitlistEntryQuery      = "UPDATE waitlist_entries SET status = 'cancelled' WHERE id = $1 and farmer_id = $2 and status IN ('waiting', 'notified') RETURNING id"
+	getWaitingEntriesQuery        = "SELECT " + waitlistColumns + " FROM waitlist_entries WHERE machine_id = $1 and date = $2 and status = 'waiting' ORDER BY created_at, id"
+	updateWaitlistEntryQuery      = "UPDATE waitlist_entries SET status = $2, booking_id = $3, offer_expires_at = $4 WHERE id = $1"
+	confirmWaitlistOfferQuery     = "UPDATE waitlist_entries SET status = 'booked' WHERE id = $1 and farmer_id = $2 and status = 'offered' and offer_expires_at > NOW() RETURNING " + waitlistColumns
+	confirmPendingBookingQuery    = "UPDATE bookings SET status = 'confirmed' WHERE id = $1 and status = 'pending'"
+	voidBookingInvoiceQuery       = "UPDATE invoices SET status = 'void' WHERE id = (SELECT invoice_id FROM invoice_line_items WHERE booking_id = $1 and kind = 'rental') and status = 'unpaid'"
+	getExpiredWaitlistOffersQuery = "SELECT " + waitlistColumns + " FROM waitlist_entries WHERE status = 'offered' and offer_expires_at <= NOW() ORDER BY offer_expires_at"
+)
+
+func (s *pgStore) AddWaitlistEntry(ctx context.Context, entry *domain.WaitlistEntry) (err error) {
+
+	err = s.db.QueryRowContext(ctx, addWaitlistEntryQuery, entry.FarmerId, entry.MachineId, entry.Date, entry.StartSlot, entry.EndSlot, entry.AutoBook).Scan(&entry.Id, &entry.Status, &entry.CreatedAt)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error inserting waitlist entry")
+		return
+	}
+
+	return
+}
+
+func (s *pgStore) GetWaitlistEntries(ctx context.Context, farmerId uint) (entries []domain.WaitlistEntry, err error) {
+
+	err = s.db.SelectContext(ctx, &entries, getWaitlistEntriesQuery, farmerId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting waitlist entries")
+		return
+	}
+
+	return
+}
+
+func (s *pgStore) CancelWaitlistEntry(ctx context.Context, entryId uint, farmerId uint) (err error) {
+
+	err = s.db.QueryRowContext(ctx, cancelWaitlistEntryQuery, entryId, farmerId).Scan(&entryId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error cancelling waitlist entry")
+		return
+	}
+
+	return
+}
+
+// GetWaitingEntries returns the entries still waiting for the machine on the
+// date, first come first.
+func (s *pgStore) GetWaitingEntries(ctx context.Context, machineId uint, date string) (entries []domain.WaitlistEntry, err error) {
+
+	err = s.db.SelectContext(ctx, &entries, getWaitingEntriesQuery, machineId, date)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting waiting entries")
+		return
+	}
+
+	return
+}
+
+func (s *pgStore) UpdateWaitlistEntry(ctx context.Context, entry domain.WaitlistEntry) (err error) {
+
+	_, err = s.db.ExecContext(ctx, updateWaitlistEntryQuery, entry.Id, entry.Status, entry.BookingId, entry.OfferExpiresAt)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error updating waitlist entry")
+		return
+	}
+
+	return
+}
+
+// BookWaitlistOffer books the slots of the entry, pending the farmer's
+// confirmation, and records the offer on the entry in the same transaction,
+// so that no held booking is left without an offer that expires it.
+func (s *pgStore) BookWaitlistOffer(ctx context.Context, entry *domain.WaitlistEntry) (booking domain.NewBookingResponse, err error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	booking, err = book(ctx, tx, domain.NewBookingRequest{
+		MachineId: entry.MachineId,
+		Date:      entry.Date,
+		Slots:     entry.Slots(),
+		FarmerId:  entry.FarmerId,
+		Status:    domain.BookingPending,
+	})
+	if err != nil {
+		return
+	}
+
+	entry.Status = domain.WaitlistOffered
+	entry.BookingId = &booking.BookingId
+	_, err = tx.ExecContext(ctx, updateWaitlistEntryQuery, entry.Id, entry.Status, entry.BookingId, entry.OfferExpiresAt)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error recording waitlist offer")
+		return
+	}
+
+	err = tx.Commit()
+	return
+}
+
+// ExpireWaitlistOffer cancels the held booking of an offer that was not
+// confirmed in time, releases its deposit, voids its unpaid invoice and marks
+// the entry expired. A booking the farmer already cancelled is left as it is.
+func (s *pgStore) ExpireWaitlistOffer(ctx context.Context, entry domain.WaitlistEntry) (cancelled domain.CancelledBooking, err error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	cancelled, err = cancelBooking(ctx, tx, *entry.BookingId, entry.FarmerId)
+	if err == nil {
+		cancelled.Deposit, err = settleBookingDeposit(ctx, tx, *entry.BookingId, false, "offer expired")
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, voidBookingInvoiceQuery, *entry.BookingId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error voiding invoice of expired offer")
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, updateWaitlistEntryQuery, entry.Id, domain.WaitlistExpired, entry.BookingId, entry.OfferExpiresAt)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error expiring waitlist entry")
+		return
+	}
+
+	err = tx.Commit()
+	return
+}
+
+// ConfirmWaitlistOffer turns the pending booking of an unexpired offer into a
+// confirmed booking.
+func (s *pgStore) ConfirmWaitlistOffer(ctx context.Context, entryId uint, farmerId uint) (entry domain.WaitlistEntry, err error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	err = tx.GetContext(ctx, &entry, confirmWaitlistOfferQuery, entryId, farmerId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error confirming waitlist offer")
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, confirmPendingBookingQuery, entry.BookingId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error confirming pending booking")
+		return
+	}
+
+	err = addOutboxEvent(ctx, tx, domain.NewBookingEvent(domain.EventBookingAccepted, entry.FarmerId, *entry.BookingId, entry.MachineId))
+	if err != nil {
+		return
+	}
+
+	err = tx.Commit()
+	return
+}
+
+func (s *pgStore) GetExpiredWaitlistOffers(ctx context.Context) (entries []domain.WaitlistEntry, err error) {
+
+	err = s.db.SelectContext(ctx, &entries, getExpiredWaitlistOffersQuery)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting expired waitlist offers")
+		return
+	}
+
+	return
+}
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func (s *DbTestSuite) Test_pgStore_AddWaitlistEntry() {
+	t := s.T()
+
+	entry := domain.WaitlistEntry{FarmerId: 10, MachineId: 1, Date: "2026-11-07", StartSlot: 7, EndSlot: 8, AutoBook: true}
+	s.mock.ExpectQuery("INSERT INTO waitlist_entries").WithArgs(10, 1, "2026-11-07", 7, 8, true).WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "status", "created_at"}).AddRow(1, domain.WaitlistWaiting, time.Now()))
+
+	err := s.repo.AddWaitlistEntry(context.TODO(), &entry)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), entry.Id)
+	assert.Equal(t, domain.WaitlistWaiting, entry.Status)
+	assert.NoError(t, s.mock.ExpectationsWereMet())
+}
+
+func (s *DbTestSuite) Test_pgStore_BookWaitlistOffer() {
+	t := s.T()
+
+	expiresAt := time.Now().Add(time.Hour)
+	newEntry := func() domain.WaitlistEntry {
+		return domain.WaitlistEntry{Id: 1, FarmerId: 10, MachineId: 1, Date: "2026-11-07", StartSlot: 7, EndSlot: 7, AutoBook: true, Status: domain.WaitlistWaiting, OfferExpiresAt: &expiresAt}
+	}
+	expectPendingBooking := func() {
+		s.mock.ExpectQuery("SELECT (.+) FROM machines m (.+) FOR UPDATE OF m").WithArgs(1).WillReturnRows(
+			sqlxmock.NewRows([]string{"name", "owner_id", "base_hourly_charge", "currency", "security_deposit", "hsn_sac", "rate_bp", "category"}).AddRow("Tractor", 20, 30000, "INR", 0, "997314", 1800, "tractor"))
+		s.mock.ExpectQuery("SELECT slots_booked.id FROM slots_booked").WithArgs(1, 7, "2026-11-07").WillReturnError(sql.ErrNoRows)
+		s.mock.ExpectQuery("INSERT INTO bookings").WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(20))
+		s.mock.ExpectExec("INSERT INTO slots_booked").WillReturnResult(sqlxmock.NewResult(1, 1))
+		s.expectOutboxEvent(domain.EventBookingRequested, 20)
+		s.expectCreateInvoice(31, 7, 20, "", 30000, 1)
+	}
+
+	s.Run("records the offer with the booking", func() {
+		entry := newEntry()
+		s.mock.ExpectBegin()
+		expectPendingBooking()
+		s.mock.ExpectExec("UPDATE waitlist_entries SET status = \\$2").WithArgs(1, domain.WaitlistOffered, 20, expiresAt).WillReturnResult(sqlxmock.NewResult(0, 1))
+		s.mock.ExpectCommit()
+
+		got, err := s.repo.BookWaitlistOffer(context.TODO(), &entry)
+		require.NoError(t, err)
+		assert.Equal(t, uint(20), got.BookingId)
+		assert.Equal(t, domain.WaitlistOffered, entry.Status)
+		assert.Equal(t, uint(20), *entry.BookingId)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("keeps no booking when the offer cannot be recorded", func() {
+		entry := newEntry()
+		s.mock.ExpectBegin()
+		expectPendingBooking()
+		s.mock.ExpectExec("UPDATE waitlist_entries SET status = \\$2").WillReturnError(errors.New("connection reset"))
+		s.mock.ExpectRollback()
+
+		_, err := s.repo.BookWaitlistOffer(context.TODO(), &entry)
+		assert.Error(t, err)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+}
+
+func (s *DbTestSuite) Test_pgStore_ExpireWaitlistOffer() {
+	t := s.T()
+
+	bookingId := uint(20)
+	entry := domain.WaitlistEntry{Id: 1, FarmerId: 10, MachineId: 1, Date: "2026-11-07", StartSlot: 7, EndSlot: 8, Status: domain.WaitlistOffered, BookingId: &bookingId}
+
+	s.Run("cancels the booking, releases its deposit and voids its invoice", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("UPDATE bookings SET status = 'cancelled'").WithArgs(20, 10).WillReturnRows(sqlxmock.NewRows([]string{"machine_id", "owner_id"}).AddRow(1, 20))
+		s.expectOutboxEvent(domain.EventBookingCancelled, 20)
+		s.expectReleaseHeldDeposit(20, "offer expired")
+		s.mock.ExpectExec("UPDATE invoices SET status = 'void'").WithArgs(20).WillReturnResult(sqlxmock.NewResult(0, 1))
+		s.mock.ExpectExec("UPDATE waitlist_entries SET status = \\$2").WithArgs(1, domain.WaitlistExpired, 20, nil).WillReturnResult(sqlxmock.NewResult(0, 1))
+		s.mock.ExpectCommit()
+
+		got, err := s.repo.ExpireWaitlistOffer(context.TODO(), entry)
+		require.NoError(t, err)
+		require.NotNil(t, got.Deposit)
+		assert.Equal(t, domain.DepositReleased, got.Deposit.Status)
+		got.Deposit = nil
+		assert.Equal(t, domain.CancelledBooking{BookingId: 20, MachineId: 1, OwnerId: 20}, got)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("when the farmer already cancelled the booking", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("UPDATE bookings SET status = 'cancelled'").WithArgs(20, 10).WillReturnError(sql.ErrNoRows)
+		s.mock.ExpectExec("UPDATE invoices SET status = 'void'").WithArgs(20).WillReturnResult(sqlxmock.NewResult(0, 0))
+		s.mock.ExpectExec("UPDATE waitlist_entries SET status = \\$2").WithArgs(1, domain.WaitlistExpired, 20, nil).WillReturnResult(sqlxmock.NewResult(0, 1))
+		s.mock.ExpectCommit()
+
+		_, err := s.repo.ExpireWaitlistOffer(context.TODO(), entry)
+		require.NoError(t, err)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+}
+
+func (s *DbTestSuite) Test_pgStore_ConfirmWaitlistOffer() {
+	t := s.T()
+
+	s.Run("confirms the held booking", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("UPDATE waitlist_entries SET status = 'booked'").WithArgs(1, 10).WillReturnRows(
+			sqlxmock.NewRows([]string{"id", "farmer_id", "machine_id", "date", "start_slot", "end_slot", "auto_book", "status", "booking_id", "offer_expires_at", "created_at"}).
+				AddRow(1, 10, 1, "2026-11-07", 7, 8, true, domain.WaitlistBooked, 20, time.Now(), time.Now()))
+		s.mock.ExpectExec("UPDATE bookings SET status = 'confirmed'").WithArgs(20).WillReturnResult(sqlxmock.NewResult(0, 1))
+		s.expectOutboxEvent(domain.EventBookingAccepted, 10)
+		s.mock.ExpectCommit()
+
+		got, err := s.repo.ConfirmWaitlistOffer(context.TODO(), 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, domain.WaitlistBooked, got.Status)
+		assert.Equal(t, uint(20), *got.BookingId)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("when the offer has expired", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("UPDATE waitlist_entries SET status = 'booked'").WithArgs(1, 10).WillReturnError(sql.ErrNoRows)
+		s.mock.ExpectRollback()
+
+		_, err := s.repo.ConfirmWaitlistOffer(context.TODO(), 1, 10)
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,321 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"FarmEasy/ledger"
+	"FarmEasy/tax"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	logger "github.com/sirupsen/logrus"
+)
+
+var ErrInsufficientFunds = errors.New("wallet balance is too low")
+
+const (
+	walletTransactionColumns     = "id, kind, amount AS \"amount.minor\", currency AS \"amount.currency\", balance AS \"balance.minor\", currency AS \"balance.currency\", reference, created_at"
+	topupColumns                 = "id, farmer_id, provider, provider_order_id, COALESCE(provider_payment_id, '') AS provider_payment_id, amount AS \"amount.minor\", currency AS \"amount.currency\", status, created_at"
+	getWalletQuery               = "SELECT farmer_id, balance AS \"balance.minor\", currency AS \"balance.currency\", updated_at FROM wallets WHERE farmer_id = $1"
+	addWalletQuery               = "INSERT INTO wallets (farmer_id, currency) VALUES ($1, $2) ON CONFLICT (farmer_id) DO NOTHING"
+	lockWalletQuery              = "SELECT balance, currency FROM wallets WHERE farmer_id = $1 FOR UPDATE"
+	updateWalletBalanceQuery     = "UPDATE wallets SET balance = $2, updated_at = NOW() WHERE farmer_id = $1"
+	addWalletTransactionQuery    = "INSERT INTO wallet_transactions (farmer_id, kind, amount, balance, currency, reference) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at"
+	getWalletTransactionsQuery   = "SELECT " + walletTransactionColumns + " FROM wallet_transactions WHERE farmer_id = $1 ORDER BY id DESC"
+	addWalletPaymentQuery        = "INSERT INTO payments (invoice_id, farmer_id, provider, provider_order_id, provider_payment_id, amount, currency, status) VALUES ($1, $2, $3, $4, $4, $5, $6, 'captured') RETURNING id, status, created_at"
+	getBookingWalletPaymentQuery = "SELECT " + paymentColumns + " FROM payments WHERE provider = 'wallet' and status = 'captured' and invoice_id IN (SELECT invoice_id FROM invoice_line_items WHERE booking_id = $1) FOR UPDATE"
+	countKeptBookingsQuery       = "SELECT COUNT(DISTINCT b.id) FROM invoice_line_items l JOIN bookings b ON b.id = l.booking_id WHERE l.invoice_id = $1 and b.id <> $2 and b.status <> 'cancelled'"
+	getBookingLinesQuery         = "SELECT l.amount AS \"amount.minor\", i.currency AS \"amount.currency\", l.tax_rate_bp, i.supply_type, COALESCE(i.owner_gstin, '') AS owner_gstin FROM invoice_line_items l JOIN invoices i ON i.id = l.invoice_id WHERE l.invoice_id = $1 and l.booking_id = $2"
+	refundPaymentShareQuery      = "UPDATE payments SET refunded = refunded + $2, updated_at = NOW() WHERE id = $1 and status = 'captured'"
+	addTopupQuery                = "INSERT INTO wallet_topups (farmer_id, provider, provider_order_id, amount, currency) VALUES ($1, $2, $3, $4, $5) RETURNING id, status, created_at"
+	getTopupByOrderQuery         = "SELECT " + topupColumns + " FROM wallet_topups WHERE provider = $1 and provider_order_id = $2"
+	creditTopupQuery             = "UPDATE wallet_topups SET status = 'credited', provider_payment_id = $2, updated_at = NOW() WHERE id = $1 and status = 'created' RETURNING " + topupColumns
+	failTopupQuery               = "UPDATE wallet_topups SET status = 'failed', provider_payment_id = NULLIF($2, ''), updated_at = NOW() WHERE id = $1 and status = 'created' RETURNING id"
+)
+
+// GetWallet returns the farmer's wallet, which is empty until their first
+// top-up.
+func (s *pgStore) GetWallet(ctx context.Context, farmerId uint) (wallet domain.Wallet, err error) {
+
+	err = s.db.GetContext(ctx, &wallet, getWalletQuery, farmerId)
+	if err == sql.ErrNoRows {
+		return domain.Wallet{FarmerId: farmerId, Balance: domain.NewMoney(0, domain.DefaultCurrency)}, nil
+	}
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting wallet")
+		return
+	}
+
+	return
+}
+
+// GetWalletTransactions returns the farmer's wallet history, latest first.
+func (s *pgStore) GetWalletTransactions(ctx context.Context, farmerId uint) (transactions []domain.WalletTransaction, err error) {
+
+	err = s.db.SelectContext(ctx, &transactions, getWalletTransactionsQuery, farmerId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting wallet transactions")
+		return
+	}
+
+	return
+}
+
+// changeWallet adds amount, negative to spend, to the farmer's wallet inside
+// the transaction. The wallet row is locked first, so concurrent changes are
+// applied one after the other and the balance can never go below zero.
+func changeWallet(ctx context.Context, tx *sqlx.Tx, farmerId uint, kind string, amount domain.Money, reference string) (entry domain.WalletTransaction, err error) {
+
+	_, err = tx.ExecContext(ctx, addWalletQuery, farmerId, amount.Currency)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error creating wallet")
+		return
+	}
+
+	var balance domain.Money
+	err = tx.QueryRowContext(ctx, lockWalletQuery, farmerId).Scan(&balance.Minor, &balance.Currency)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error locking wallet")
+		return
+	}
+	if !balance.SameCurrency(amount) {
+		err = domain.ErrCurrencyMismatch
+		return
+	}
+
+	balance = balance.Add(amount)
+	if balance.Minor < 0 {
+		err = ErrInsufficientFunds
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, updateWalletBalanceQuery, farmerId, balance.Minor)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error updating wallet balance")
+		return
+	}
+
+	entry = domain.WalletTransaction{Kind: kind, Amount: amount, Balance: balance, Reference: reference}
+	err = tx.QueryRowContext(ctx, addWalletTransactionQuery, farmerId, kind, amount.Minor, balance.Minor, balance.Currency, reference).Scan(&entry.Id, &entry.CreatedAt)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error inserting wallet transaction")
+		return
+	}
+
+	return
+}
+
+// payFromWallet pays the unpaid invoice from the renter's wallet inside the
+// transaction, recording it as a captured wallet payment.
+func payFromWallet(ctx context.Context, tx *sqlx.Tx, invoice *domain.Invoice, commissionBp uint) (payment domain.Payment, err error) {
+
+	entry, err := changeWallet(ctx, tx, invoice.FarmerId, domain.WalletEntrySpend, invoice.Amount.Neg(), "invoice:"+invoice.Number)
+	if err != nil {
+		return
+	}
+
+	payment = domain.Payment{
+		InvoiceId:         invoice.Id,
+		FarmerId:          invoice.FarmerId,
+		Provider:          domain.WalletProvider,
+		ProviderOrderId:   fmt.Sprintf("wallet_txn_%d", entry.Id),
+		ProviderPaymentId: fmt.Sprintf("wallet_txn_%d", entry.Id),
+		Amount:            invoice.Amount,
+	}
+	err = tx.QueryRowContext(ctx, addWalletPaymentQuery, payment.InvoiceId, payment.FarmerId, payment.Provider, payment.ProviderOrderId, payment.Amount.Minor, payment.Amount.Currency).Scan(&payment.Id, &payment.Status, &payment.CreatedAt)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error inserting wallet payment")
+		return
+	}
+
+	err = tx.QueryRowContext(ctx, updateInvoiceStatusQuery, invoice.Id, domain.InvoicePaid, domain.InvoiceUnpaid).Scan(&invoice.Id)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error marking invoice paid")
+		return
+	}
+	invoice.Status = domain.InvoicePaid
+
+	transactions := ledger.InvoicePaid(*invoice, payment, commissionBp)
+	for i := range transactions {
+		err = postTransaction(ctx, tx, &transactions[i])
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// refundCancelledBooking gives the cancelled booking's share of a wallet-paid
+// invoice back to the wallet inside the transaction that cancels it: its own
+// lines with the tax charged on them. Cancelling the last booking kept on the
+// invoice refunds what is left of the payment instead, so rounding never
+// strands a paisa. It returns nil when there is nothing to refund; invoices
+// paid through a provider are refunded by the owner.
+func refundCancelledBooking(ctx context.Context, tx *sqlx.Tx, bookingId uint) (refund *domain.Refund, err error) {
+
+	var paid domain.Payment
+	err = tx.GetContext(ctx, &paid, getBookingWalletPaymentQuery, bookingId)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting wallet payment of booking")
+		return
+	}
+
+	// bookings that ended in a no-show stay paid for, so they are kept too
+	var kept int
+	err = tx.GetContext(ctx, &kept, countKeptBookingsQuery, paid.InvoiceId, bookingId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error counting bookings kept on invoice")
+		return
+	}
+
+	rest := paid.Refundable()
+	if kept > 0 {
+		var lines []bookingLine
+		err = tx.SelectContext(ctx, &lines, getBookingLinesQuery, paid.InvoiceId, bookingId)
+		if err != nil {
+			logger.WithField("err", err.Error()).Error("Error getting invoice lines of booking")
+			return
+		}
+
+		share := bookingShare(lines)
+		if share.Minor <= 0 {
+			return nil, nil
+		}
+		if share.Minor < rest.Minor {
+			err = refundPaymentShare(ctx, tx, paid, share, bookingId)
+			if err != nil {
+				return
+			}
+			return &domain.Refund{PaymentId: paid.Id, FarmerId: paid.FarmerId, Amount: share}, nil
+		}
+	}
+
+	_, err = refundPayment(ctx, tx, paid.Id, "")
+	if err != nil {
+		return
+	}
+	return &domain.Refund{PaymentId: paid.Id, FarmerId: paid.FarmerId, Amount: rest}, nil
+}
+
+// bookingLine is an invoice line of a booking with what it takes to work out
+// the tax charged on it.
+type bookingLine struct {
+	Amount     domain.Money `db:"amount"`
+	TaxRate    uint         `db:"tax_rate_bp"`
+	SupplyType string       `db:"supply_type"`
+	OwnerGSTIN string       `db:"owner_gstin"`
+}
+
+// bookingShare adds up the booking's lines and the tax charged on them, rate
+// by rate the way applyTax does for the whole invoice.
+func bookingShare(lines []bookingLine) (share domain.Money) {
+
+	taxableByRate := map[uint]domain.Money{}
+	for _, line := range lines {
+		share = share.Add(line.Amount)
+		if line.TaxRate != 0 && line.OwnerGSTIN != "" {
+			taxableByRate[line.TaxRate] = taxableByRate[line.TaxRate].Add(line.Amount)
+		}
+	}
+	for rate, taxable := range taxableByRate {
+		share = share.Add(tax.Compute(taxable, rate, lines[0].SupplyType).Total())
+	}
+	return
+}
+
+// refundPaymentShare gives share of the captured wallet payment back to the
+// wallet inside the transaction, reversing that much of what was posted for
+// it, and leaves the rest of the payment captured.
+func refundPaymentShare(ctx context.Context, tx *sqlx.Tx, paid domain.Payment, share domain.Money, bookingId uint) (err error) {
+
+	_, err = tx.ExecContext(ctx, refundPaymentShareQuery, paid.Id, share.Minor)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error refunding share of payment")
+		return
+	}
+
+	reference := fmt.Sprintf("payment:%d", paid.Id)
+	posted, err := getTransactions(ctx, tx, reference)
+	if err != nil {
+		return
+	}
+	// payments captured before the ledger existed have nothing to reverse
+	if len(posted) > 0 {
+		reversal, err := ledger.ReverseShare(ledger.KindRefund, reference, fmt.Sprintf("Refund to wallet for booking %d", bookingId), posted, share, paid.Refundable())
+		if err != nil {
+			return err
+		}
+		if err = postTransaction(ctx, tx, &reversal); err != nil {
+			return err
+		}
+	}
+
+	_, err = changeWallet(ctx, tx, paid.FarmerId, domain.WalletEntryRefund, share, reference)
+	return
+}
+
+func (s *pgStore) AddWalletTopup(ctx context.Context, topup *domain.WalletTopup) (err error) {
+
+	err = s.db.QueryRowContext(ctx, addTopupQuery, topup.FarmerId, topup.Provider, topup.ProviderOrderId, topup.Amount.Minor, topup.Amount.Currency).Scan(&topup.Id, &topup.Status, &topup.CreatedAt)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error inserting wallet top-up")
+		return
+	}
+
+	return
+}
+
+func (s *pgStore) GetWalletTopupByOrder(ctx context.Context, provider string, orderId string) (topup domain.WalletTopup, err error) {
+
+	err = s.db.GetContext(ctx, &topup, getTopupByOrderQuery, provider, orderId)
+	return
+}
+
+// CreditWalletTopup marks the top-up credited and adds it to the wallet. It
+// returns sql.ErrNoRows, and changes nothing, unless the top-up was waiting to
+// be paid.
+func (s *pgStore) CreditWalletTopup(ctx context.Context, topupId uint, providerPaymentId string) (topup domain.WalletTopup, err error) {
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	err = tx.GetContext(ctx, &topup, creditTopupQuery, topupId, providerPaymentId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error crediting wallet top-up")
+		return
+	}
+
+	_, err = changeWallet(ctx, tx, topup.FarmerId, domain.WalletEntryTopup, topup.Amount, fmt.Sprintf("topup:%d", topup.Id))
+	if err != nil {
+		return
+	}
+
+	posting := ledger.WalletToppedUp(topup)
+	err = postTransaction(ctx, tx, &posting)
+	if err != nil {
+		return
+	}
+
+	err = tx.Commit()
+	return
+}
+
+func (s *pgStore) FailWalletTopup(ctx context.Context, topupId uint, providerPaymentId string) (err error) {
+
+	err = s.db.QueryRowContext(ctx, failTopupQuery, topupId, providerPaymentId).Scan(&topupId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error marking wallet top-up failed")
+		return
+	}
+
+	return
+}
@@ -0,0 +1,228 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"FarmEasy/ledger"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func (s *DbTestSuite) expectBookTractor() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("SELECT (.+) FROM machines m (.+) FOR UPDATE OF m").WithArgs(1).WillReturnRows(
+		sqlxmock.NewRows([]string{"name", "owner_id", "base_hourly_charge", "currency", "security_deposit", "hsn_sac", "rate_bp", "category"}).AddRow("Tractor", 20, 300000, "INR", 0, "997314", 1800, "tractor"))
+	s.mock.ExpectQuery("SELECT slots_booked.id FROM slots_booked").WithArgs(1, 7, "2026-11-07").WillReturnError(sql.ErrNoRows)
+	s.mock.ExpectQuery("INSERT INTO bookings").WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(11))
+	s.mock.ExpectExec("INSERT INTO slots_booked").WillReturnResult(sqlxmock.NewResult(1, 1))
+	s.expectOutboxEvent(domain.EventBookingRequested, 20)
+	s.expectOutboxEvent(domain.EventBookingAccepted, 9)
+	s.expectCreateInvoice(1, 1, 20, "", 300000, 1)
+}
+
+func (s *DbTestSuite) expectLockWallet(farmerId uint, balance int64) {
+	s.mock.ExpectExec("INSERT INTO wallets").WithArgs(farmerId, "INR").WillReturnResult(sqlxmock.NewResult(0, 0))
+	s.mock.ExpectQuery("SELECT balance, currency FROM wallets WHERE farmer_id = \\$1 FOR UPDATE").WithArgs(farmerId).WillReturnRows(sqlxmock.NewRows([]string{"balance", "currency"}).AddRow(balance, "INR"))
+}
+
+func (s *DbTestSuite) Test_pgStore_Book_payFromWallet() {
+	t := s.T()
+	booking := domain.NewBookingRequest{MachineId: 1, Date: "2026-11-07", Slots: []uint{7}, FarmerId: 9, PayFromWallet: true, CommissionBp: 1000}
+
+	s.Run("pays the invoice", func() {
+		s.expectBookTractor()
+		s.expectLockWallet(9, 500000)
+		s.mock.ExpectExec("UPDATE wallets SET balance = \\$2").WithArgs(9, 200000).WillReturnResult(sqlxmock.NewResult(0, 1))
+		s.mock.ExpectQuery("INSERT INTO wallet_transactions").WithArgs(9, domain.WalletEntrySpend, -300000, 200000, "INR", sqlxmock.AnyArg()).WillReturnRows(sqlxmock.NewRows([]string{"id", "created_at"}).AddRow(4, time.Now()))
+		s.mock.ExpectQuery("INSERT INTO payments").WithArgs(1, 9, domain.WalletProvider, "wallet_txn_4", 300000, "INR").WillReturnRows(sqlxmock.NewRows([]string{"id", "status", "created_at"}).AddRow(3, "captured", time.Now()))
+		s.mock.ExpectQuery("UPDATE invoices SET status = \\$2").WithArgs(1, domain.InvoicePaid, domain.InvoiceUnpaid).WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(1))
+		s.expectPostTransaction("charge", "payment:3", 9, 20, 0)
+		s.expectPostTransaction("payment", "payment:3", 9, 9)
+		s.mock.ExpectCommit()
+
+		got, err := s.repo.Book(context.TODO(), booking)
+		require.NoError(t, err)
+		require.NotNil(t, got.Payment)
+		assert.Equal(t, uint(3), got.Payment.Id)
+		assert.Equal(t, domain.WalletProvider, got.Payment.Provider)
+		assert.Equal(t, domain.Rupees(3000), got.Payment.Amount)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("when the wallet is short", func() {
+		s.expectBookTractor()
+		s.expectLockWallet(9, 100000)
+		s.mock.ExpectRollback()
+
+		_, err := s.repo.Book(context.TODO(), booking)
+		assert.Equal(t, ErrInsufficientFunds, err)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+}
+
+func (s *DbTestSuite) Test_pgStore_CreditWalletTopup() {
+	t := s.T()
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery("UPDATE wallet_topups SET status = 'credited'").WithArgs(2, "pay_fake_1").WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "farmer_id", "provider", "provider_order_id", "provider_payment_id", "amount.minor", "amount.currency", "status", "created_at"}).
+			AddRow(2, 9, "fake", "order_fake_1", "pay_fake_1", 100000, "INR", "credited", time.Now()))
+	s.expectLockWallet(9, 50000)
+	s.mock.ExpectExec("UPDATE wallets SET balance = \\$2").WithArgs(9, 150000).WillReturnResult(sqlxmock.NewResult(0, 1))
+	s.mock.ExpectQuery("INSERT INTO wallet_transactions").WithArgs(9, domain.WalletEntryTopup, 100000, 150000, "INR", "topup:2").WillReturnRows(sqlxmock.NewRows([]string{"id", "created_at"}).AddRow(5, time.Now()))
+	s.expectPostTransaction("wallet_topup", "topup:2", 0, 9)
+	s.mock.ExpectCommit()
+
+	got, err := s.repo.CreditWalletTopup(context.TODO(), 2, "pay_fake_1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.TopupCredited, got.Status)
+	assert.Equal(t, domain.Rupees(1000), got.Amount)
+	assert.NoError(t, s.mock.ExpectationsWereMet())
+}
+
+func (s *DbTestSuite) Test_pgStore_GetWallet() {
+	t := s.T()
+
+	s.mock.ExpectQuery("SELECT (.+) FROM wallets WHERE farmer_id = \\$1").WithArgs(9).WillReturnError(sql.ErrNoRows)
+
+	got, err := s.repo.GetWallet(context.TODO(), 9)
+	require.NoError(t, err)
+	assert.Equal(t, domain.Wallet{FarmerId: 9, Balance: domain.Rupees(0)}, got)
+	assert.NoError(t, s.mock.ExpectationsWereMet())
+}
+
+// expectNoWalletRefund expects a cancellation to find no wallet payment of
+// the booking to refund.
+func (s *DbTestSuite) expectNoWalletRefund(bookingId uint) {
+	s.mock.ExpectQuery("SELECT (.+) FROM payments WHERE provider = 'wallet'").WithArgs(bookingId).WillReturnError(sql.ErrNoRows)
+}
+
+var walletPaymentColumns = []string{"id", "invoice_id", "farmer_id", "provider", "amount.minor", "amount.currency", "refunded.minor", "refunded.currency", "status"}
+
+// expectWalletRefund expects a cancellation to refund the wallet payment of
+// the booking, which was the last one kept on the invoice and was posted
+// before the ledger existed.
+func (s *DbTestSuite) expectWalletRefund(bookingId uint, paymentId uint, farmerId uint, amount int64) {
+	s.mock.ExpectQuery("SELECT (.+) FROM payments WHERE provider = 'wallet'").WithArgs(bookingId).WillReturnRows(
+		sqlxmock.NewRows(walletPaymentColumns).AddRow(paymentId, 1, farmerId, domain.WalletProvider, amount, "INR", 0, "INR", domain.PaymentCaptured))
+	s.expectKeptBookings(bookingId, 0)
+	s.expectRefundWalletPayment(paymentId, farmerId, amount, 0)
+	s.mock.ExpectQuery("SELECT (.+) FROM ledger_transactions WHERE reference = \\$1").WithArgs(fmt.Sprintf("payment:%d", paymentId)).WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "kind", "reference", "description", "created_at"}))
+	s.expectChangeWallet(farmerId, domain.WalletEntryRefund, amount, 0, fmt.Sprintf("payment:%d", paymentId))
+}
+
+func (s *DbTestSuite) expectKeptBookings(bookingId uint, kept int) {
+	s.mock.ExpectQuery("SELECT COUNT\\(DISTINCT b.id\\) FROM invoice_line_items").WithArgs(1, bookingId).WillReturnRows(sqlxmock.NewRows([]string{"count"}).AddRow(kept))
+}
+
+// expectRefundWalletPayment expects the wallet payment of invoice 1, of which
+// refunded was already given back, to be locked and marked refunded with its
+// invoice.
+func (s *DbTestSuite) expectRefundWalletPayment(paymentId uint, farmerId uint, amount int64, refunded int64) {
+	s.mock.ExpectQuery("SELECT (.+) FROM payments WHERE id = \\$1 and status = 'captured' FOR UPDATE").WithArgs(paymentId).WillReturnRows(
+		sqlxmock.NewRows(walletPaymentColumns).AddRow(paymentId, 1, farmerId, domain.WalletProvider, amount, "INR", refunded, "INR", domain.PaymentCaptured))
+	s.mock.ExpectQuery("UPDATE payments SET status = 'refunded'").WithArgs(paymentId, "").WillReturnRows(
+		sqlxmock.NewRows(walletPaymentColumns).AddRow(paymentId, 1, farmerId, domain.WalletProvider, amount, "INR", amount, "INR", domain.PaymentRefunded))
+	s.mock.ExpectQuery("UPDATE invoices SET status = \\$2").WithArgs(1, domain.InvoiceRefunded, domain.InvoicePaid).WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(1))
+}
+
+func (s *DbTestSuite) Test_pgStore_CancelBooking_refundsWallet() {
+	t := s.T()
+
+	s.Run("refunds with the cancellation", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("UPDATE bookings SET status = 'cancelled'").WithArgs(11, 9).WillReturnRows(sqlxmock.NewRows([]string{"machine_id", "owner_id"}).AddRow(1, 20))
+		s.expectOutboxEvent(domain.EventBookingCancelled, 20)
+		s.expectWalletRefund(11, 3, 9, 300000)
+		s.expectNoHeldDeposit(11)
+		s.mock.ExpectCommit()
+		s.mock.ExpectQuery("SELECT (.+) FROM slots_booked WHERE booking_id = \\$1").WithArgs(11).WillReturnRows(sqlxmock.NewRows([]string{"date", "slot_id"}).AddRow("2026-11-07", 7))
+
+		got, err := s.repo.CancelBooking(context.TODO(), 11, 9)
+		require.NoError(t, err)
+		require.NotNil(t, got.Refund)
+		assert.Equal(t, uint(3), got.Refund.PaymentId)
+		assert.Equal(t, domain.Rupees(3000), got.Refund.Amount)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("refunds only the booking's share while others stay on the invoice", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("UPDATE bookings SET status = 'cancelled'").WithArgs(11, 9).WillReturnRows(sqlxmock.NewRows([]string{"machine_id", "owner_id"}).AddRow(1, 20))
+		s.expectOutboxEvent(domain.EventBookingCancelled, 20)
+		s.mock.ExpectQuery("SELECT (.+) FROM payments WHERE provider = 'wallet'").WithArgs(11).WillReturnRows(
+			sqlxmock.NewRows(walletPaymentColumns).AddRow(3, 1, 9, domain.WalletProvider, 708000, "INR", 0, "INR", domain.PaymentCaptured))
+		// the other booking ended in a no-show, which keeps it paid for
+		s.expectKeptBookings(11, 1)
+		s.mock.ExpectQuery("SELECT (.+) FROM invoice_line_items l JOIN invoices i ON i.id = l.invoice_id WHERE l.invoice_id = \\$1 and l.booking_id = \\$2").WithArgs(1, 11).WillReturnRows(
+			sqlxmock.NewRows([]string{"amount.minor", "amount.currency", "tax_rate_bp", "supply_type", "owner_gstin"}).
+				AddRow(320000, "INR", 1800, "intra_state", "27AAAPL1234C1Z5").
+				AddRow(-20000, "INR", 1800, "intra_state", "27AAAPL1234C1Z5"))
+		// 3000 rupees of rental after the discount and 540 of GST on it
+		s.mock.ExpectExec("UPDATE payments SET refunded = refunded \\+ \\$2").WithArgs(3, 354000).WillReturnResult(sqlxmock.NewResult(0, 1))
+		s.mock.ExpectQuery("SELECT (.+) FROM ledger_transactions WHERE reference = \\$1").WithArgs("payment:3").WillReturnRows(
+			sqlxmock.NewRows([]string{"id", "kind", "reference", "description", "created_at"}).
+				AddRow(1, "charge", "payment:3", "Invoice FE/2026-27/000042", time.Now()).
+				AddRow(2, "payment", "payment:3", "Payment for invoice FE/2026-27/000042", time.Now()))
+		s.mock.ExpectQuery("SELECT (.+) FROM ledger_entries WHERE transaction_id = ANY").WillReturnRows(
+			sqlxmock.NewRows([]string{"id", "transaction_id", "account", "farmer_id", "amount.minor", "amount.currency"}).
+				AddRow(1, 1, "renter", 9, 708000, "INR").
+				AddRow(2, 1, "owner_payable", 20, -648000, "INR").
+				AddRow(3, 1, "commission", 0, -60000, "INR").
+				AddRow(4, 2, "wallet", 9, 708000, "INR").
+				AddRow(5, 2, "renter", 9, -708000, "INR"))
+		// half of what the owner and the commission got is undone
+		s.expectPostTransaction(ledger.KindRefund, "payment:3", 20, 0, 9)
+		s.expectChangeWallet(9, domain.WalletEntryRefund, 354000, 0, "payment:3")
+		s.expectNoHeldDeposit(11)
+		s.mock.ExpectCommit()
+		s.mock.ExpectQuery("SELECT (.+) FROM slots_booked WHERE booking_id = \\$1").WithArgs(11).WillReturnRows(sqlxmock.NewRows([]string{"date", "slot_id"}).AddRow("2026-11-07", 7))
+
+		got, err := s.repo.CancelBooking(context.TODO(), 11, 9)
+		require.NoError(t, err)
+		require.NotNil(t, got.Refund)
+		assert.Equal(t, domain.Refund{PaymentId: 3, FarmerId: 9, Amount: domain.Rupees(3540)}, *got.Refund)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("refunds what is left with the last booking kept on the invoice", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("UPDATE bookings SET status = 'cancelled'").WithArgs(12, 9).WillReturnRows(sqlxmock.NewRows([]string{"machine_id", "owner_id"}).AddRow(1, 20))
+		s.expectOutboxEvent(domain.EventBookingCancelled, 20)
+		s.mock.ExpectQuery("SELECT (.+) FROM payments WHERE provider = 'wallet'").WithArgs(12).WillReturnRows(
+			sqlxmock.NewRows(walletPaymentColumns).AddRow(3, 1, 9, domain.WalletProvider, 708000, "INR", 354000, "INR", domain.PaymentCaptured))
+		s.expectKeptBookings(12, 0)
+		s.expectRefundWalletPayment(3, 9, 708000, 354000)
+		s.mock.ExpectQuery("SELECT (.+) FROM ledger_transactions WHERE reference = \\$1").WithArgs("payment:3").WillReturnRows(
+			sqlxmock.NewRows([]string{"id", "kind", "reference", "description", "created_at"}))
+		s.expectChangeWallet(9, domain.WalletEntryRefund, 354000, 354000, "payment:3")
+		s.expectNoHeldDeposit(12)
+		s.mock.ExpectCommit()
+		s.mock.ExpectQuery("SELECT (.+) FROM slots_booked WHERE booking_id = \\$1").WithArgs(12).WillReturnRows(sqlxmock.NewRows([]string{"date", "slot_id"}).AddRow("2026-11-07", 8))
+
+		got, err := s.repo.CancelBooking(context.TODO(), 12, 9)
+		require.NoError(t, err)
+		require.NotNil(t, got.Refund)
+		assert.Equal(t, domain.Rupees(3540), got.Refund.Amount)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("keeps the booking when the refund fails", func() {
+		s.mock.ExpectBegin()
+		s.mock.ExpectQuery("UPDATE bookings SET status = 'cancelled'").WithArgs(11, 9).WillReturnRows(sqlxmock.NewRows([]string{"machine_id", "owner_id"}).AddRow(1, 20))
+		s.expectOutboxEvent(domain.EventBookingCancelled, 20)
+		s.mock.ExpectQuery("SELECT (.+) FROM payments WHERE provider = 'wallet'").WithArgs(11).WillReturnError(errors.New("connection reset"))
+		s.mock.ExpectRollback()
+
+		_, err := s.repo.CancelBooking(context.TODO(), 11, 9)
+		assert.Error(t, err)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,208 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+
+	"github.com/lib/pq"
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	webhookColumns         = "w.id, w.client_id, c.farmer_id, w.url, w.events, w.active, w.created_at"
+	webhookDeliveryColumns = "d.id, d.subscription_id, d.event_id, d.kind, d.payload, d.replay_of, d.status, d.attempts, d.next_attempt_at, d.response_status, d.last_error, d.delivered_at, d.created_at"
+	addAPIClientQuery      = "INSERT INTO api_clients (farmer_id, name, secret) VALUES ($1, $2, $3) RETURNING id, created_at"
+	getAPIClientsQuery     = "SELECT id, farmer_id, name, created_at FROM api_clients WHERE farmer_id = $1 ORDER BY id"
+	getAPIClientQuery      = "SELECT id, farmer_id, name, created_at FROM api_clients WHERE id = $1"
+	addWebhookQuery        = "INSERT INTO webhook_subscriptions (client_id, url, events) VALUES ($1, $2, $3) RETURNING id, active, created_at"
+	getWebhooksQuery       = "SELECT " + webhookColumns + " FROM webhook_subscriptions w JOIN api_clients c ON c.id = w.client_id WHERE w.client_id = $1 ORDER BY w.id"
+	getWebhookQuery        = "SELECT " + webhookColumns + " FROM webhook_subscriptions w JOIN api_clients c ON c.id = w.client_id WHERE w.id = $1"
+	deactivateWebhookQuery = "UPDATE webhook_subscriptions w SET active = FALSE FROM api_clients c WHERE c.id = w.client_id and w.id = $1 and c.farmer_id = $2 RETURNING w.id"
+	getDeliveriesQuery     = "SELECT " + webhookDeliveryColumns + " FROM webhook_deliveries d WHERE d.subscription_id = $1 ORDER BY d.id DESC LIMIT $2"
+	// replayDeliveryQuery copies a delivery of a webhook of the farmer's as a
+	// new pending delivery of the same payload.
+	replayDeliveryQuery = "INSERT INTO webhook_deliveries (subscription_id, event_id, kind, payload, replay_of) SELECT d.subscription_id, d.event_id, d.kind, d.payload, d.id " +
+		"FROM webhook_deliveries d JOIN webhook_subscriptions w ON w.id = d.subscription_id JOIN api_clients c ON c.id = w.client_id WHERE d.id = $1 and c.farmer_id = $2 " +
+		"RETURNING id, subscription_id, event_id, kind, payload, replay_of, status, attempts, next_attempt_at, response_status, last_error, delivered_at, created_at"
+	getDueDeliveriesQuery = "SELECT " + webhookDeliveryColumns + ", w.url, c.secret FROM webhook_deliveries d JOIN webhook_subscriptions w ON w.id = d.subscription_id JOIN api_clients c ON c.id = w.client_id " +
+		"WHERE d.status = 'pending' and d.next_attempt_at <= NOW() and w.active ORDER BY d.next_attempt_at, d.id LIMIT $1"
+	updateDeliveryQuery = "UPDATE webhook_deliveries SET status = $2, attempts = $3, next_attempt_at = $4, response_status = $5, last_error = $6, delivered_at = CASE WHEN $2 = 'delivered' THEN NOW() END WHERE id = $1"
+)
+
+// webhookRow reads the events of a webhook, which the domain type keeps as a
+// plain slice.
+type webhookRow struct {
+	domain.WebhookSubscription
+	Events pq.StringArray `db:"events"`
+}
+
+func (row webhookRow) webhook() (webhook domain.WebhookSubscription) {
+	webhook = row.WebhookSubscription
+	webhook.Events = []string(row.Events)
+	return
+}
+
+// deliveryRow reads the payload of a delivery, which the driver returns as
+// bytes.
+type deliveryRow struct {
+	domain.WebhookDelivery
+	Payload []byte `db:"payload"`
+}
+
+func (row deliveryRow) delivery() (delivery domain.WebhookDelivery) {
+	delivery = row.WebhookDelivery
+	delivery.Payload = row.Payload
+	return
+}
+
+type dueDeliveryRow struct {
+	deliveryRow
+	URL    string `db:"url"`
+	Secret string `db:"secret"`
+}
+
+func (s *pgStore) AddAPIClient(ctx context.Context, client *domain.APIClient) (err error) {
+
+	err = s.db.QueryRowxContext(ctx, addAPIClientQuery, client.FarmerId, client.Name, client.Secret).Scan(&client.Id, &client.CreatedAt)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error inserting api client")
+		return
+	}
+
+	return
+}
+
+// GetAPIClients returns the farmer's api clients, without their secrets.
+func (s *pgStore) GetAPIClients(ctx context.Context, farmerId uint) (clients []domain.APIClient, err error) {
+
+	err = s.db.SelectContext(ctx, &clients, getAPIClientsQuery, farmerId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting api clients")
+		return
+	}
+
+	return
+}
+
+// GetAPIClient returns the api client, without its secret.
+func (s *pgStore) GetAPIClient(ctx context.Context, clientId uint) (client domain.APIClient, err error) {
+
+	err = s.db.GetContext(ctx, &client, getAPIClientQuery, clientId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting api client")
+		return
+	}
+
+	return
+}
+
+func (s *pgStore) AddWebhookSubscription(ctx context.Context, webhook *domain.WebhookSubscription) (err error) {
+
+	err = s.db.QueryRowxContext(ctx, addWebhookQuery, webhook.ClientId, webhook.URL, pq.Array(webhook.Events)).Scan(&webhook.Id, &webhook.Active, &webhook.CreatedAt)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error inserting webhook subscription")
+		return
+	}
+
+	return
+}
+
+func (s *pgStore) GetWebhookSubscriptions(ctx context.Context, clientId uint) (webhooks []domain.WebhookSubscription, err error) {
+
+	var rows []webhookRow
+	err = s.db.SelectContext(ctx, &rows, getWebhooksQuery, clientId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting webhook subscriptions")
+		return
+	}
+
+	for _, row := range rows {
+		webhooks = append(webhooks, row.webhook())
+	}
+	return
+}
+
+func (s *pgStore) GetWebhookSubscription(ctx context.Context, webhookId uint) (webhook domain.WebhookSubscription, err error) {
+
+	var row webhookRow
+	err = s.db.GetContext(ctx, &row, getWebhookQuery, webhookId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting webhook subscription")
+		return
+	}
+
+	return row.webhook(), nil
+}
+
+// DeactivateWebhookSubscription stops deliveries to a webhook of the farmer's
+// api clients. Its delivery log is kept. It returns sql.ErrNoRows if the
+// farmer has no such webhook.
+func (s *pgStore) DeactivateWebhookSubscription(ctx context.Context, webhookId uint, farmerId uint) (err error) {
+
+	err = s.db.QueryRowxContext(ctx, deactivateWebhookQuery, webhookId, farmerId).Scan(&webhookId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error deactivating webhook subscription")
+		return
+	}
+
+	return
+}
+
+// GetWebhookDeliveries returns up to limit deliveries to the webhook, newest
+// first.
+func (s *pgStore) GetWebhookDeliveries(ctx context.Context, webhookId uint, limit uint) (deliveries []domain.WebhookDelivery, err error) {
+
+	var rows []deliveryRow
+	err = s.db.SelectContext(ctx, &rows, getDeliveriesQuery, webhookId, limit)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting webhook deliveries")
+		return
+	}
+
+	for _, row := range rows {
+		deliveries = append(deliveries, row.delivery())
+	}
+	return
+}
+
+// ReplayWebhookDelivery queues the delivery's payload to be sent again. It
+// returns sql.ErrNoRows if the delivery is not to a webhook of the farmer's.
+func (s *pgStore) ReplayWebhookDelivery(ctx context.Context, deliveryId uint, farmerId uint) (replay domain.WebhookDelivery, err error) {
+
+	var row deliveryRow
+	err = s.db.GetContext(ctx, &row, replayDeliveryQuery, deliveryId, farmerId)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error replaying webhook delivery")
+		return
+	}
+
+	return row.delivery(), nil
+}
+
+// GetDueWebhookDeliveries returns up to limit pending deliveries to active
+// webhooks whose next attempt is due, the longest waiting first.
+func (s *pgStore) GetDueWebhookDeliveries(ctx context.Context, limit uint) (deliveries []domain.DueWebhookDelivery, err error) {
+
+	var rows []dueDeliveryRow
+	err = s.db.SelectContext(ctx, &rows, getDueDeliveriesQuery, limit)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error getting due webhook deliveries")
+		return
+	}
+
+	for _, row := range rows {
+		deliveries = append(deliveries, domain.DueWebhookDelivery{WebhookDelivery: row.delivery(), URL: row.URL, Secret: row.Secret})
+	}
+	return
+}
+
+func (s *pgStore) UpdateWebhookDelivery(ctx context.Context, delivery domain.WebhookDelivery) (err error) {
+
+	_, err = s.db.ExecContext(ctx, updateDeliveryQuery, delivery.Id, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.ResponseStatus, delivery.LastError)
+	if err != nil {
+		logger.WithField("err", err.Error()).Error("Error updating webhook delivery")
+		return
+	}
+
+	return
+}
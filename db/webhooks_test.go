@@ -0,0 +1,86 @@
+package db
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+var deliveryColumns = []string{"id", "subscription_id", "event_id", "kind", "payload", "replay_of", "status", "attempts", "next_attempt_at", "response_status", "last_error", "delivered_at", "created_at"}
+
+func (s *DbTestSuite) Test_pgStore_AddWebhookSubscription() {
+	t := s.T()
+
+	createdAt := time.Date(2026, 11, 6, 6, 0, 0, 0, time.UTC)
+	webhook := domain.WebhookSubscription{ClientId: 3, URL: "https://coop.example/hooks", Events: []string{domain.EventBookingRequested, domain.EventMachineCreated}}
+	s.mock.ExpectQuery("INSERT INTO webhook_subscriptions").WithArgs(3, "https://coop.example/hooks", pq.Array(webhook.Events)).WillReturnRows(sqlxmock.NewRows([]string{"id", "active", "created_at"}).AddRow(5, true, createdAt))
+
+	err := s.repo.AddWebhookSubscription(context.TODO(), &webhook)
+	require.NoError(t, err)
+	assert.Equal(t, uint(5), webhook.Id)
+	assert.True(t, webhook.Active)
+	assert.NoError(t, s.mock.ExpectationsWereMet())
+}
+
+func (s *DbTestSuite) Test_pgStore_GetWebhookSubscription() {
+	t := s.T()
+
+	s.mock.ExpectQuery("SELECT (.+) FROM webhook_subscriptions w JOIN api_clients c (.+) WHERE w.id = \\$1").WithArgs(5).WillReturnRows(
+		sqlxmock.NewRows([]string{"id", "client_id", "farmer_id", "url", "events", "active", "created_at"}).
+			AddRow(5, 3, 9, "https://coop.example/hooks", "{booking.requested,machine.created}", true, time.Now()))
+
+	got, err := s.repo.GetWebhookSubscription(context.TODO(), 5)
+	require.NoError(t, err)
+	assert.Equal(t, uint(9), got.FarmerId)
+	assert.Equal(t, []string{domain.EventBookingRequested, domain.EventMachineCreated}, got.Events)
+	assert.NoError(t, s.mock.ExpectationsWereMet())
+}
+
+func (s *DbTestSuite) Test_pgStore_GetDueWebhookDeliveries() {
+	t := s.T()
+
+	now := time.Date(2026, 11, 6, 6, 0, 0, 0, time.UTC)
+	payload := `{"id": 4, "type": "booking.requested", "data": {"booking_id": 11, "machine_id": 2}}`
+	s.mock.ExpectQuery("SELECT (.+), w.url, c.secret FROM webhook_deliveries d (.+) WHERE d.status = 'pending' (.+) and w.active").WithArgs(100).WillReturnRows(
+		sqlxmock.NewRows(append(deliveryColumns, "url", "secret")).
+			AddRow(7, 5, 4, domain.EventBookingRequested, []byte(payload), nil, "pending", 0, now, 0, "", nil, now, "https://coop.example/hooks", "client secret"))
+
+	got, err := s.repo.GetDueWebhookDeliveries(context.TODO(), 100)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, uint(7), got[0].Id)
+	assert.Equal(t, json.RawMessage(payload), got[0].Payload)
+	assert.Equal(t, "https://coop.example/hooks", got[0].URL)
+	assert.Equal(t, "client secret", got[0].Secret)
+	assert.NoError(t, s.mock.ExpectationsWereMet())
+}
+
+func (s *DbTestSuite) Test_pgStore_ReplayWebhookDelivery() {
+	t := s.T()
+
+	s.Run("when the delivery is to the farmer's webhook", func() {
+		now := time.Now()
+		s.mock.ExpectQuery("INSERT INTO webhook_deliveries (.+) SELECT (.+) WHERE d.id = \\$1 and c.farmer_id = \\$2").WithArgs(7, 9).WillReturnRows(
+			sqlxmock.NewRows(deliveryColumns).AddRow(8, 5, 4, domain.EventBookingRequested, []byte(`{"id": 4}`), 7, "pending", 0, now, 0, "", nil, now))
+
+		got, err := s.repo.ReplayWebhookDelivery(context.TODO(), 7, 9)
+		require.NoError(t, err)
+		assert.Equal(t, uint(8), got.Id)
+		assert.Equal(t, uint(7), *got.ReplayOf)
+		assert.NoError(t, s.mock.ExpectationsWereMet())
+	})
+
+	s.Run("when it is not", func() {
+		s.mock.ExpectQuery("INSERT INTO webhook_deliveries").WithArgs(7, 10).WillReturnError(sql.ErrNoRows)
+
+		_, err := s.repo.ReplayWebhookDelivery(context.TODO(), 7, 10)
+		assert.Equal(t, sql.ErrNoRows, err)
+	})
+}
@@ -0,0 +1,37 @@
+package document
+
+import (
+	"FarmEasy/domain"
+	_ "embed"
+	"encoding/base64"
+	"html/template"
+	"io"
+)
+
+//go:embed templates/invoice.html
+var invoiceTemplateText string
+
+var invoiceTemplate = template.Must(template.New("invoice").Funcs(template.FuncMap{
+	"title":     Title,
+	"slotTimes": SlotTimes,
+}).Parse(invoiceTemplateText))
+
+type htmlView struct {
+	domain.InvoiceDocument
+	QRCode template.URL
+}
+
+// InvoiceHTML writes the invoice as a standalone HTML page.
+func InvoiceHTML(w io.Writer, doc domain.InvoiceDocument, opts Options) (err error) {
+
+	view := htmlView{InvoiceDocument: doc}
+	if opts.QRCode {
+		image, err := qrCodePNG(QRContent(doc))
+		if err != nil {
+			return err
+		}
+		view.QRCode = template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(image))
+	}
+
+	return invoiceTemplate.Execute(w, view)
+}
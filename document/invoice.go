@@ -0,0 +1,72 @@
+// Package document renders invoices as HTML and PDF for printing.
+package document
+
+import (
+	"FarmEasy/domain"
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"strings"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/qr"
+)
+
+const qrCodeSize = 160
+
+// Options controls the optional parts of a rendered invoice.
+type Options struct {
+	QRCode bool
+}
+
+// Title is "Tax Invoice" when the owner is registered for GST, as the law
+// requires, and "Invoice" otherwise.
+func Title(doc domain.InvoiceDocument) string {
+	if doc.OwnerGSTIN != "" {
+		return "Tax Invoice"
+	}
+	return "Invoice"
+}
+
+// SlotTimes renders booked slots as time ranges, merging consecutive slots.
+// Slot n covers hour n-1 to n, so slots 7, 8 and 10 are
+// "06:00-08:00, 09:00-10:00".
+func SlotTimes(slots []uint) string {
+	var ranges []string
+	for i := 0; i < len(slots); {
+		j := i
+		for j+1 < len(slots) && slots[j+1] == slots[j]+1 {
+			j++
+		}
+		ranges = append(ranges, fmt.Sprintf("%02d:00-%02d:00", slots[i]-1, slots[j]))
+		i = j + 1
+	}
+	return strings.Join(ranges, ", ")
+}
+
+// QRContent is what the invoice QR code encodes.
+func QRContent(doc domain.InvoiceDocument) string {
+	return fmt.Sprintf("FARMEASY-INVOICE:%d:%s", doc.Id, doc.Number)
+}
+
+func qrCodePNG(content string) (encoded []byte, err error) {
+	code, err := qr.Encode(content, qr.M, qr.Auto)
+	if err != nil {
+		return
+	}
+	code, err = barcode.Scale(code, qrCodeSize, qrCodeSize)
+	if err != nil {
+		return
+	}
+
+	// fpdf only reads 8-bit PNGs and the barcode is 16-bit gray
+	bounds := code.Bounds()
+	gray := image.NewGray(bounds)
+	draw.Draw(gray, bounds, code, bounds.Min, draw.Src)
+
+	var buf bytes.Buffer
+	err = png.Encode(&buf, gray)
+	return buf.Bytes(), err
+}
@@ -0,0 +1,95 @@
+package document
+
+import (
+	"FarmEasy/domain"
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+func invoiceDocument() domain.InvoiceDocument {
+	orderId := uint(3)
+	return domain.InvoiceDocument{
+		Invoice: domain.Invoice{
+			Id:            7,
+			Number:        "FE/2026-27/000042",
+			FinancialYear: "2026-27",
+			BookingId:     11,
+			OrderId:       &orderId,
+			FarmerId:      9,
+			OwnerId:       20,
+			OwnerGSTIN:    "27AAPFU0939F1ZV",
+			PlaceOfSupply: "27",
+			SupplyType:    "intra_state",
+			DateGenrated:  "2026-10-19",
+			TaxableValue:  domain.Rupees(600),
+			CGST:          domain.Rupees(54),
+			SGST:          domain.Rupees(54),
+			Amount:        domain.Rupees(708),
+			LineItems: []domain.InvoiceLineItem{
+				{Id: 1, Kind: domain.LineItemRental, Description: "Tractor, 2 hourly slots", HsnSac: "997314", Quantity: 2, UnitAmount: domain.Rupees(300), Amount: domain.Rupees(600), TaxRate: 1800},
+				{Id: 2, Kind: domain.LineItemTax, Description: "CGST @ 9%", Quantity: 1, UnitAmount: domain.Rupees(54), Amount: domain.Rupees(54)},
+				{Id: 3, Kind: domain.LineItemTax, Description: "SGST @ 9%", Quantity: 1, UnitAmount: domain.Rupees(54), Amount: domain.Rupees(54)},
+			},
+		},
+		Renter: domain.InvoiceParty{Id: 9, Name: "Ramesh Patil", Address: "Baramati, Pune", State: "27"},
+		Owner:  domain.InvoiceParty{Id: 20, Name: "Suresh Jadhav", Address: "Indapur, Pune", State: "27", GSTIN: "27AAPFU0939F1ZV"},
+		Bookings: []domain.InvoiceBooking{
+			{BookingId: 11, MachineId: 4, MachineName: "Tractor", Date: "2026-10-21", Slots: []uint{7, 8}},
+		},
+	}
+}
+
+// golden compares the rendered output with testdata/name, or rewrites the
+// file when the tests run with -update.
+func golden(t *testing.T, name string, render func(io.Writer, domain.InvoiceDocument, Options) error, opts Options) {
+	var got bytes.Buffer
+	require.NoError(t, render(&got, invoiceDocument(), opts))
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, os.WriteFile(path, got.Bytes(), 0644))
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), got.String())
+}
+
+func TestInvoiceHTML(t *testing.T) {
+	golden(t, "invoice.html.golden", InvoiceHTML, Options{})
+}
+
+func TestInvoiceHTML_QRCode(t *testing.T) {
+	golden(t, "invoice_qr.html.golden", InvoiceHTML, Options{QRCode: true})
+}
+
+func TestInvoicePDF(t *testing.T) {
+	golden(t, "invoice.pdf.golden", InvoicePDF, Options{})
+}
+
+func TestInvoicePDF_QRCode(t *testing.T) {
+	golden(t, "invoice_qr.pdf.golden", InvoicePDF, Options{QRCode: true})
+}
+
+func TestTitle(t *testing.T) {
+	doc := invoiceDocument()
+	assert.Equal(t, "Tax Invoice", Title(doc))
+
+	doc.OwnerGSTIN = ""
+	assert.Equal(t, "Invoice", Title(doc))
+}
+
+func TestSlotTimes(t *testing.T) {
+	assert.Equal(t, "", SlotTimes(nil))
+	assert.Equal(t, "06:00-08:00", SlotTimes([]uint{7, 8}))
+	assert.Equal(t, "06:00-08:00, 09:00-10:00", SlotTimes([]uint{7, 8, 10}))
+}
@@ -0,0 +1,144 @@
+package document
+
+import (
+	"FarmEasy/domain"
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	pageMargin  = 15.0
+	lineHeight  = 6.0
+	pageWidth   = 210.0 - 2*pageMargin
+	qrImageName = "qr"
+)
+
+// InvoicePDF writes the invoice as an A4 PDF. The output only depends on the
+// invoice, so the same invoice always renders to the same bytes.
+func InvoicePDF(w io.Writer, doc domain.InvoiceDocument, opts Options) (err error) {
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pageMargin, pageMargin, pageMargin)
+	pdf.SetCompression(false)
+	pdf.SetCatalogSort(true)
+	if generated, err := time.Parse("2006-01-02", doc.DateGenrated); err == nil {
+		pdf.SetCreationDate(generated)
+		pdf.SetModificationDate(generated)
+	}
+	pdf.SetTitle(Title(doc)+" "+doc.Number, true)
+	pdf.SetAuthor(doc.Owner.Name, true)
+	pdf.AddPage()
+
+	// the core fonts are not UTF-8, names and addresses may be
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(pageWidth, 10, Title(doc), "", 1, "L", false, 0, "")
+
+	pdf.SetFont("Helvetica", "", 10)
+	details := []string{
+		"Invoice number: " + doc.Number,
+		"Date: " + doc.DateGenrated,
+		"Financial year: " + doc.FinancialYear,
+	}
+	if doc.PlaceOfSupply != "" {
+		details = append(details, "Place of supply: "+doc.PlaceOfSupply)
+	}
+	if doc.OrderId != nil {
+		details = append(details, fmt.Sprintf("Order: %d", *doc.OrderId))
+	}
+	for _, line := range details {
+		pdf.CellFormat(pageWidth, lineHeight, line, "", 1, "L", false, 0, "")
+	}
+
+	pdf.Ln(4)
+	top := pdf.GetY()
+	party(pdf, tr, "Billed by", doc.Owner, pageMargin, top)
+	ownerBottom := pdf.GetY()
+	party(pdf, tr, "Billed to", doc.Renter, pageMargin+pageWidth/2, top)
+	if ownerBottom > pdf.GetY() {
+		pdf.SetY(ownerBottom)
+	}
+
+	pdf.Ln(4)
+	bookingWidths := []float64{25, 70, 30, 55}
+	header(pdf, bookingWidths, []string{"Booking", "Machine", "Date", "Time"}, "LLLL")
+	for _, booking := range doc.Bookings {
+		row(pdf, bookingWidths, []string{fmt.Sprint(booking.BookingId), tr(booking.MachineName), booking.Date, SlotTimes(booking.Slots)}, "LLLL")
+	}
+
+	pdf.Ln(4)
+	itemWidths := []float64{75, 25, 15, 30, 35}
+	header(pdf, itemWidths, []string{"Description", "HSN/SAC", "Qty", "Rate", "Amount (" + doc.Amount.Currency + ")"}, "LLRRR")
+	for _, item := range doc.LineItems {
+		row(pdf, itemWidths, []string{tr(item.Description), item.HsnSac, fmt.Sprint(item.Quantity), item.UnitAmount.Decimal(), item.Amount.Decimal()}, "LLRRR")
+	}
+
+	totals := [][2]string{{"Taxable value", doc.TaxableValue.Decimal()}}
+	if !doc.CGST.IsZero() {
+		totals = append(totals, [2]string{"CGST", doc.CGST.Decimal()}, [2]string{"SGST", doc.SGST.Decimal()})
+	}
+	if !doc.IGST.IsZero() {
+		totals = append(totals, [2]string{"IGST", doc.IGST.Decimal()})
+	}
+	totals = append(totals, [2]string{"Total", doc.Amount.String()})
+	for i, total := range totals {
+		if i == len(totals)-1 {
+			pdf.SetFont("Helvetica", "B", 10)
+		}
+		pdf.CellFormat(pageWidth-35, lineHeight, total[0], "1", 0, "R", false, 0, "")
+		pdf.CellFormat(35, lineHeight, total[1], "1", 1, "R", false, 0, "")
+	}
+
+	if opts.QRCode {
+		image, err := qrCodePNG(QRContent(doc))
+		if err != nil {
+			return err
+		}
+		pdf.RegisterImageOptionsReader(qrImageName, fpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(image))
+		pdf.ImageOptions(qrImageName, pageMargin, pdf.GetY()+8, 35, 35, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	}
+
+	return pdf.Output(w)
+}
+
+func party(pdf *fpdf.Fpdf, tr func(string) string, title string, p domain.InvoiceParty, x float64, y float64) {
+	width := pageWidth / 2
+	lines := []string{tr(p.Name), tr(p.Address)}
+	if p.GSTIN != "" {
+		lines = append(lines, "GSTIN: "+p.GSTIN)
+	}
+	if p.State != "" {
+		lines = append(lines, "State code: "+p.State)
+	}
+
+	pdf.SetXY(x, y)
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(width, lineHeight, title, "", 2, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	for _, line := range lines {
+		pdf.MultiCell(width, lineHeight, line, "", "L", false)
+		pdf.SetX(x)
+	}
+}
+
+func header(pdf *fpdf.Fpdf, widths []float64, titles []string, aligns string) {
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetFillColor(230, 230, 230)
+	for i, title := range titles {
+		pdf.CellFormat(widths[i], lineHeight+1, title, "1", 0, string(aligns[i]), true, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("Helvetica", "", 10)
+}
+
+func row(pdf *fpdf.Fpdf, widths []float64, cells []string, aligns string) {
+	for i, cell := range cells {
+		pdf.CellFormat(widths[i], lineHeight, cell, "1", 0, string(aligns[i]), false, 0, "")
+	}
+	pdf.Ln(-1)
+}
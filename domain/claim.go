@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"FarmEasy/validate"
+	"time"
+)
+
+const (
+	// ClaimOpen waits for the renter to accept or dispute the claim.
+	ClaimOpen = "open"
+	// ClaimDisputed and ClaimEscalated wait for an admin to decide, after the
+	// renter disputed the claim or let the response deadline pass.
+	ClaimDisputed  = "disputed"
+	ClaimEscalated = "escalated"
+	ClaimApproved  = "approved"
+	ClaimRejected  = "rejected"
+)
+
+// Claim is an owner's claim for damage to a machine against a completed
+// booking. An approved amount is taken from the booking's deposit first, and
+// what the deposit does not cover is billed to the renter on Invoice.
+type Claim struct {
+	Id               uint         `db:"id" json:"id"`
+	BookingId        uint         `db:"booking_id" json:"booking_id"`
+	OwnerId          uint         `db:"owner_id" json:"owner_id"`
+	FarmerId         uint         `db:"farmer_id" json:"farmer_id"`
+	Amount           Money        `db:"amount" json:"amount"`
+	Description      string       `db:"description" json:"description"`
+	Evidence         []string     `db:"-" json:"evidence"`
+	Status           string       `db:"status" json:"status"`
+	Response         string       `db:"response" json:"response,omitempty"`
+	ResponseEvidence []string     `db:"-" json:"response_evidence"`
+	RespondBy        time.Time    `db:"respond_by" json:"respond_by"`
+	Approved         Money        `db:"approved" json:"approved"`
+	FromDeposit      Money        `db:"from_deposit" json:"from_deposit"`
+	InvoiceId        *uint        `db:"invoice_id" json:"invoice_id,omitempty"`
+	DecisionNote     string       `db:"decision_note" json:"decision_note,omitempty"`
+	DecidedBy        *uint        `db:"decided_by" json:"decided_by,omitempty"`
+	CreatedAt        time.Time    `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time    `db:"updated_at" json:"updated_at"`
+	Events           []ClaimEvent `db:"-" json:"events,omitempty"`
+}
+
+// ClaimEvent is one change of a claim's status. ActorId is nil when the
+// change was automatic.
+type ClaimEvent struct {
+	Id        uint      `db:"id" json:"id"`
+	ClaimId   uint      `db:"claim_id" json:"claim_id"`
+	Status    string    `db:"status" json:"status"`
+	ActorId   *uint     `db:"actor_id" json:"actor_id,omitempty"`
+	Note      string    `db:"note" json:"note,omitempty"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type NewClaimRequest struct {
+	BookingId   uint     `json:"booking_id" validate:"required"`
+	OwnerId     uint     `json:"-"`
+	Amount      Money    `json:"amount" validate:"required"`
+	Description string   `json:"description" validate:"required,max=2000"`
+	Evidence    []string `json:"evidence" validate:"required,url"`
+}
+
+// ClaimResponseRequest is the renter accepting the claim in full or disputing
+// it, with their side of the story.
+type ClaimResponseRequest struct {
+	Accept   bool     `json:"accept"`
+	Response string   `json:"response" validate:"max=2000"`
+	Evidence []string `json:"evidence" validate:"url"`
+}
+
+func (c ClaimResponseRequest) Check() (errs validate.Errors) {
+	if !c.Accept && c.Response == "" {
+		errs.Add("response", "is required to dispute a claim")
+	}
+	return
+}
+
+// ClaimDecisionRequest is an admin approving the claim, in part or in full,
+// or rejecting it.
+type ClaimDecisionRequest struct {
+	Approve bool   `json:"approve"`
+	Amount  Money  `json:"amount"`
+	Note    string `json:"note" validate:"required,max=1000"`
+}
+
+// ClaimDecision closes a claim. Status is ClaimApproved or ClaimRejected.
+type ClaimDecision struct {
+	Status  string
+	Amount  Money
+	Note    string
+	ActorId uint
+}
+
+// Pending reports whether the claim is still to be decided.
+func (c Claim) Pending() bool {
+	return c.Status == ClaimOpen || c.Status == ClaimDisputed || c.Status == ClaimEscalated
+}
@@ -1,8 +1,14 @@
 package domain
 
+import (
+	"FarmEasy/validate"
+	"fmt"
+	"time"
+)
+
 type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
 }
 
 type LoginResponse struct {
@@ -12,12 +18,14 @@ type LoginResponse struct {
 
 type NewFarmerRequest struct {
 	Id        uint   `db:"id" json:"id"`
-	FirstName string `db:"fname" json:"fname"`
-	LastName  string `db:"lname" json:"lname"`
-	Email     string `db:"email" json:"email"`
-	Phone     string `db:"phone" json:"phone"`
-	Address   string `db:"address" json:"address"`
-	Password  string `db:"password" json:"password"`
+	FirstName string `db:"fname" json:"fname" validate:"required,max=50"`
+	LastName  string `db:"lname" json:"lname" validate:"max=50"`
+	Email     string `db:"email" json:"email" validate:"required,email"`
+	Phone     string `db:"phone" json:"phone" validate:"required,phone"`
+	Address   string `db:"address" json:"address" validate:"max=200"`
+	Password  string `db:"password" json:"password" validate:"required,min=8"`
+	State     string `db:"state" json:"state,omitempty"`
+	GSTIN     string `db:"gstin" json:"gstin,omitempty"`
 }
 
 type FarmerResponse struct {
@@ -28,40 +36,129 @@ type FarmerResponse struct {
 	Phone     string `db:"phone" json:"phone"`
 	Address   string `db:"address" json:"address"`
 	Password  string `db:"password" json:"-"`
+	State     string `db:"state" json:"state,omitempty"`
+	GSTIN     string `db:"gstin" json:"gstin,omitempty"`
+	// OwnerRating rates the farmer by the reviews of their renters, and
+	// RenterRating by the reviews of the owners they rented from.
+	OwnerRating  Rating `db:"owner_rating" json:"owner_rating"`
+	RenterRating Rating `db:"renter_rating" json:"renter_rating"`
 }
 
 type NewMachineRequest struct {
-	Name             string `json:"name"`
-	Description      string `json:"description"`
-	BaseHourlyCharge uint   `json:"base_hourly_charge"`
-	OwnerId          uint   `json:"owner_id"`
+	Name             string   `json:"name" validate:"required,max=100"`
+	Description      string   `json:"description" validate:"max=1000"`
+	BaseHourlyCharge Money    `json:"base_hourly_charge" validate:"required"`
+	SecurityDeposit  Money    `json:"security_deposit"`
+	UsageBilling     bool     `json:"usage_billing"`
+	OwnerId          uint     `json:"-"`
+	Category         string   `json:"category,omitempty" validate:"max=50"`
+	Latitude         *float64 `json:"latitude,omitempty" validate:"min=-90,max=90"`
+	Longitude        *float64 `json:"longitude,omitempty" validate:"min=-180,max=180"`
+	NoShowPolicy     string   `json:"no_show_policy,omitempty"`
+}
+
+// Check holds the deposit to the currency of the charge and the no-show
+// policy to a known one.
+func (m NewMachineRequest) Check() (errs validate.Errors) {
+	if !m.SecurityDeposit.IsZero() && !m.BaseHourlyCharge.IsZero() && m.SecurityDeposit.Currency != m.BaseHourlyCharge.Currency {
+		errs.Add("security_deposit", ErrCurrencyMismatch.Error())
+	}
+	if m.NoShowPolicy != "" && !containsString(NoShowPolicies, m.NoShowPolicy) {
+		errs.Add("no_show_policy", fmt.Sprintf("must be one of %v", NoShowPolicies))
+	}
+	return
 }
 
 type MachineResponse struct {
-	Id               uint   `db:"id" json:"id"`
-	Name             string `db:"name" json:"name"`
-	Description      string `db:"description" json:"description"`
-	BaseHourlyCharge uint   `db:"base_hourly_charge" json:"base_hourly_charge"`
-	OwnerId          uint   `db:"owner_id" json:"owner_id"`
+	Id               uint     `db:"id" json:"id"`
+	Name             string   `db:"name" json:"name"`
+	Description      string   `db:"description" json:"description"`
+	BaseHourlyCharge Money    `db:"base_hourly_charge" json:"base_hourly_charge"`
+	SecurityDeposit  Money    `db:"security_deposit" json:"security_deposit"`
+	UsageBilling     bool     `db:"usage_billing" json:"usage_billing"`
+	OwnerId          uint     `db:"owner_id" json:"owner_id"`
+	Category         string   `db:"category" json:"category,omitempty"`
+	Latitude         *float64 `db:"latitude" json:"latitude,omitempty"`
+	Longitude        *float64 `db:"longitude" json:"longitude,omitempty"`
+	NoShowPolicy     string   `db:"no_show_policy" json:"no_show_policy"`
+	Rating           Rating   `db:"rating" json:"rating"`
+}
+
+type MachineFilter struct {
+	Category   string
+	MachineIds []uint
+}
+
+const (
+	BookingPending   = "pending"
+	BookingConfirmed = "confirmed"
+	BookingCancelled = "cancelled"
+	// BookingNoShow is a confirmed booking whose renter did not check in
+	// within the grace period and whose money is kept. Its slots are
+	// released.
+	BookingNoShow = "no_show"
+)
+
+// What happens to the money of a booking whose renter does not show up.
+const (
+	// NoShowKeepPayment keeps the rental paid and releases the deposit.
+	NoShowKeepPayment = "keep_payment"
+	// NoShowForfeitDeposit keeps the rental paid and the whole deposit.
+	NoShowForfeitDeposit = "forfeit_deposit"
+	// NoShowRefund cancels the booking and refunds the renter.
+	NoShowRefund = "refund"
+)
+
+var NoShowPolicies = []string{NoShowKeepPayment, NoShowForfeitDeposit, NoShowRefund}
+
+// NoShow is a booking that was marked a no-show, with the policy of its
+// machine. Date is the date of its slots.
+type NoShow struct {
+	BookingId uint   `db:"booking_id"`
+	FarmerId  uint   `db:"farmer_id"`
+	MachineId uint   `db:"machine_id"`
+	OwnerId   uint   `db:"owner_id"`
+	Policy    string `db:"no_show_policy"`
+	Date      string `db:"date"`
+	// Refund is what was given back of the wallet payment under the refund
+	// policy.
+	Refund *Refund `db:"-"`
+	// Deposit is the deposit released, or forfeited under that policy, with
+	// the no-show.
+	Deposit *Deposit `db:"-"`
 }
 
 type NewBookingRequest struct {
-	MachineId uint   `json:"machine_id"`
-	Date      string `json:"date"`
-	Slots     []uint `json:"slots"`
-	FarmerId  uint   `json:"farmer_id"`
+	MachineId uint   `json:"machine_id" validate:"required"`
+	Date      string `json:"date" validate:"required,date"`
+	Slots     []uint `json:"slots" validate:"required,slot"`
+	FarmerId  uint   `json:"-"`
+	SeriesId  *uint  `json:"-"`
+	OrderId   *uint  `json:"-"`
+	Status    string `json:"-"`
+	// PayFromWallet pays the booking's invoice from the renter's wallet as
+	// part of booking it, and fails the booking if the wallet is short.
+	PayFromWallet bool   `json:"pay_from_wallet"`
+	CommissionBp  uint   `json:"-"`
+	PromoCode     string `json:"promo_code,omitempty" validate:"max=32"`
 }
 
 type NewBookingResponse struct {
-	InvoiceId   uint   `json:"invoice_id"`
-	MachineId   uint   `json:"machine_id"`
-	SlotsBooked []uint `json:"slots_booked"`
-	TotalCost   uint   `json:"total_cost"`
+	BookingId     uint     `json:"booking_id"`
+	InvoiceId     uint     `json:"invoice_id"`
+	InvoiceNumber string   `json:"invoice_number"`
+	MachineId     uint     `json:"machine_id"`
+	Date          string   `json:"date"`
+	SlotsBooked   []uint   `json:"slots_booked"`
+	TotalCost     Money    `json:"total_cost"`
+	Discount      *Money   `json:"discount,omitempty"`
+	Deposit       *Deposit `json:"deposit,omitempty"`
+	Payment       *Payment `json:"payment,omitempty"`
 }
 
 type AvailabilityRequest struct {
-	MachineId uint   `json:"machine_id"`
-	Date      string `json:"date"`
+	MachineId uint   `json:"machine_id" validate:"required"`
+	Date      string `json:"date" validate:"required,date"`
 }
 
 type AvailabilityResponse struct {
@@ -70,10 +167,37 @@ type AvailabilityResponse struct {
 	SlotsAvailable []uint `json:"slots_available"`
 }
 
+// Why the slots of a machine changed.
+const (
+	AvailabilityBooked      = "booked"
+	AvailabilityCancelled   = "cancelled"
+	AvailabilityHoldExpired = "hold_expired"
+	AvailabilityNoShow      = "no_show"
+)
+
+// AvailabilityChange says that the slots of a machine on a date changed, and
+// why. It is published without SlotsAvailable, which is filled in for each
+// subscriber when it is delivered.
+type AvailabilityChange struct {
+	MachineId      uint   `json:"machine_id"`
+	Date           string `json:"date"`
+	Reason         string `json:"reason"`
+	SlotsAvailable []uint `json:"slots_available"`
+}
+
+// AvailabilityTopic is the topic changes to the machine's slots are published
+// on.
+func AvailabilityTopic(machineId uint) string {
+	return fmt.Sprintf("availability_%d", machineId)
+}
+
 type Booking struct {
-	Id        uint `db:"id" json:"id"`
-	MachineId uint `db:"machine_id" json:"machine_id"`
-	FarmerId  uint `db:"farmer_id" json:"farmer_id"`
+	Id        uint   `db:"id" json:"id"`
+	MachineId uint   `db:"machine_id" json:"machine_id"`
+	FarmerId  uint   `db:"farmer_id" json:"farmer_id"`
+	SeriesId  *uint  `db:"series_id" json:"series_id,omitempty"`
+	OrderId   *uint  `db:"order_id" json:"order_id,omitempty"`
+	Status    string `db:"status" json:"status"`
 }
 
 type Slot struct {
@@ -83,21 +207,482 @@ type Slot struct {
 	Date      string `db:"date" json:"date"`
 }
 
+// Invoice is in a single currency. Amount is the taxable value plus CGST,
+// SGST and IGST.
 type Invoice struct {
-	Id           uint   `db:"id" json:"id"`
-	BookingId    uint   `db:"booking_id" json:"booking_id"`
-	DateGenrated string `db:"date_generated" json:"date_generated"`
-	Amount       uint   `db:"amount" json:"amount"`
+	Id            uint              `db:"id" json:"id"`
+	Number        string            `db:"number" json:"number"`
+	FinancialYear string            `db:"financial_year" json:"financial_year"`
+	BookingId     uint              `db:"booking_id" json:"booking_id,omitempty"`
+	OrderId       *uint             `db:"order_id" json:"order_id,omitempty"`
+	FarmerId      uint              `db:"farmer_id" json:"farmer_id"`
+	OwnerId       uint              `db:"owner_id" json:"owner_id"`
+	OwnerGSTIN    string            `db:"owner_gstin" json:"owner_gstin,omitempty"`
+	PlaceOfSupply string            `db:"place_of_supply" json:"place_of_supply,omitempty"`
+	SupplyType    string            `db:"supply_type" json:"supply_type"`
+	DateGenrated  string            `db:"date_generated" json:"date_generated"`
+	TaxableValue  Money             `db:"taxable_value" json:"taxable_value"`
+	CGST          Money             `db:"cgst" json:"cgst"`
+	SGST          Money             `db:"sgst" json:"sgst"`
+	IGST          Money             `db:"igst" json:"igst"`
+	Amount        Money             `db:"amount" json:"amount"`
+	Status        string            `db:"status" json:"status"`
+	LineItems     []InvoiceLineItem `db:"-" json:"line_items"`
+}
+
+const (
+	InvoiceUnpaid   = "unpaid"
+	InvoicePaid     = "paid"
+	InvoiceRefunded = "refunded"
+	// InvoiceVoid is the invoice of a booking that lapsed before it was
+	// paid, such as a waitlist offer that was not confirmed in time.
+	InvoiceVoid = "void"
+)
+
+// InvoiceParty is the renter or the machine owner as printed on an invoice.
+type InvoiceParty struct {
+	Id      uint   `db:"id" json:"id"`
+	Name    string `db:"name" json:"name"`
+	Address string `db:"address" json:"address"`
+	State   string `db:"state" json:"state,omitempty"`
+	GSTIN   string `db:"gstin" json:"gstin,omitempty"`
+}
+
+type InvoiceBooking struct {
+	BookingId   uint   `json:"booking_id"`
+	MachineId   uint   `json:"machine_id"`
+	MachineName string `json:"machine_name"`
+	Date        string `json:"date"`
+	Slots       []uint `json:"slots"`
+}
+
+// InvoiceDocument is everything printed on an invoice.
+type InvoiceDocument struct {
+	Invoice
+	Renter   InvoiceParty     `json:"renter"`
+	Owner    InvoiceParty     `json:"owner"`
+	Bookings []InvoiceBooking `json:"bookings"`
+}
+
+const (
+	LineItemRental     = "rental"
+	LineItemAdjustment = "adjustment"
+	LineItemFee        = "fee"
+	LineItemTax        = "tax"
+	LineItemDiscount   = "discount"
+	LineItemDamage     = "damage"
+)
+
+// InvoiceLineItem is one line of an invoice. Amount is quantity times unit
+// amount, and is negative for discounts. TaxRate is in basis points and only
+// set on taxable lines.
+type InvoiceLineItem struct {
+	Id          uint   `db:"id" json:"id"`
+	InvoiceId   uint   `db:"invoice_id" json:"-"`
+	BookingId   *uint  `db:"booking_id" json:"booking_id,omitempty"`
+	Kind        string `db:"kind" json:"kind"`
+	Description string `db:"description" json:"description"`
+	HsnSac      string `db:"hsn_sac" json:"hsn_sac,omitempty"`
+	Quantity    uint   `db:"quantity" json:"quantity"`
+	UnitAmount  Money  `db:"unit_amount" json:"unit_amount"`
+	Amount      Money  `db:"amount" json:"amount"`
+	TaxRate     uint   `db:"tax_rate_bp" json:"tax_rate_bp,omitempty"`
 }
 
 type BookingResponse struct {
 	BookingId   uint   `json:"booking_id"`
 	MachineId   uint   `json:"machine_id"`
+	Status      string `json:"status"`
+	SeriesId    *uint  `json:"series_id,omitempty"`
 	SlotsBooked []uint `json:"slots_booked"`
 }
 
+type CancelledBooking struct {
+	BookingId uint   `json:"booking_id"`
+	MachineId uint   `json:"machine_id"`
+	OwnerId   uint   `json:"-"`
+	Date      string `json:"date"`
+	Slots     []uint `json:"slots"`
+	// Refund is what was given back to the wallet with the cancellation, if
+	// the booking was on a wallet-paid invoice.
+	Refund *Refund `json:"-"`
+	// Deposit is the deposit released with the cancellation, if one was held.
+	Deposit *Deposit `json:"-"`
+}
+
+const (
+	RecurrenceDaily  = "daily"
+	RecurrenceWeekly = "weekly"
+)
+
+// RecurrenceRule repeats a booking every Interval days or weeks, until the
+// Until date (inclusive) or for Count occurrences, whichever is given.
+type RecurrenceRule struct {
+	Frequency string `json:"frequency" validate:"required,oneof=daily weekly"`
+	Interval  uint   `json:"interval"`
+	Until     string `json:"until,omitempty" validate:"date"`
+	Count     uint   `json:"count,omitempty"`
+}
+
+type NewRecurringBookingRequest struct {
+	MachineId uint           `json:"machine_id" validate:"required"`
+	StartDate string         `json:"start_date" validate:"required,date"`
+	Slots     []uint         `json:"slots" validate:"required,slot"`
+	Rule      RecurrenceRule `json:"rule"`
+	FarmerId  uint           `json:"-"`
+}
+
+type BookingSeries struct {
+	Id        uint   `db:"id" json:"id"`
+	FarmerId  uint   `db:"farmer_id" json:"farmer_id"`
+	MachineId uint   `db:"machine_id" json:"machine_id"`
+	StartDate string `db:"start_date" json:"start_date"`
+	Frequency string `db:"frequency" json:"frequency"`
+	Interval  uint   `db:"interval" json:"interval"`
+	Until     string `db:"until" json:"until,omitempty"`
+	Count     uint   `db:"count" json:"count,omitempty"`
+	Slots     []uint `db:"slots" json:"slots"`
+}
+
+type OccurrenceConflict struct {
+	Date             string `json:"date"`
+	ConflictingSlots []uint `json:"conflicting_slots"`
+}
+
+type RecurringBookingResponse struct {
+	SeriesId  uint                 `json:"series_id"`
+	Booked    []NewBookingResponse `json:"booked"`
+	Conflicts []OccurrenceConflict `json:"conflicts"`
+}
+
 type SlotResponse struct {
 	SlotId    uint   `json:"slot_id"`
 	StartTime string `json:"start_time"`
 	EndTime   string `json:"end_time"`
 }
+
+type IdempotencyRecord struct {
+	FarmerId     uint   `db:"farmer_id" json:"farmer_id"`
+	Key          string `db:"key" json:"key"`
+	RequestHash  string `db:"request_hash" json:"request_hash"`
+	StatusCode   int    `db:"status_code" json:"status_code"`
+	ResponseBody []byte `db:"response_body" json:"-"`
+}
+
+const (
+	WaitlistWaiting   = "waiting"
+	WaitlistNotified  = "notified"
+	WaitlistOffered   = "offered"
+	WaitlistBooked    = "booked"
+	WaitlistExpired   = "expired"
+	WaitlistCancelled = "cancelled"
+)
+
+type NewWaitlistRequest struct {
+	MachineId uint   `json:"machine_id" validate:"required"`
+	Date      string `json:"date" validate:"required,date"`
+	StartSlot uint   `json:"start_slot" validate:"required,slot"`
+	EndSlot   uint   `json:"end_slot" validate:"required,slot"`
+	AutoBook  bool   `json:"auto_book"`
+	FarmerId  uint   `json:"-"`
+}
+
+func (w NewWaitlistRequest) Check() (errs validate.Errors) {
+	if w.StartSlot != 0 && w.EndSlot != 0 && w.EndSlot < w.StartSlot {
+		errs.Add("end_slot", "must not be before start_slot")
+	}
+	return
+}
+
+// WaitlistEntry is a farmer waiting for the slots StartSlot..EndSlot (inclusive)
+// of a machine to free up. Entries with AutoBook get a pending booking that has
+// to be confirmed before OfferExpiresAt.
+type WaitlistEntry struct {
+	Id             uint       `db:"id" json:"id"`
+	FarmerId       uint       `db:"farmer_id" json:"farmer_id"`
+	MachineId      uint       `db:"machine_id" json:"machine_id"`
+	Date           string     `db:"date" json:"date"`
+	StartSlot      uint       `db:"start_slot" json:"start_slot"`
+	EndSlot        uint       `db:"end_slot" json:"end_slot"`
+	AutoBook       bool       `db:"auto_book" json:"auto_book"`
+	Status         string     `db:"status" json:"status"`
+	BookingId      *uint      `db:"booking_id" json:"booking_id,omitempty"`
+	OfferExpiresAt *time.Time `db:"offer_expires_at" json:"offer_expires_at,omitempty"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
+
+func (e WaitlistEntry) Slots() (slots []uint) {
+	for slot := e.StartSlot; slot <= e.EndSlot; slot++ {
+		slots = append(slots, slot)
+	}
+	return
+}
+
+// NextAvailableRequest looks for DurationSlots contiguous free slots on any
+// machine of the category (or of the listed machines) between EarliestStart
+// and LatestEnd, both formatted as 2006-01-02T15:04.
+type NextAvailableRequest struct {
+	Category      string   `json:"category"`
+	MachineIds    []uint   `json:"machine_ids"`
+	DurationSlots uint     `json:"duration_slots" validate:"required,slot"`
+	EarliestStart string   `json:"earliest_start" validate:"required"`
+	LatestEnd     string   `json:"latest_end" validate:"required"`
+	Latitude      *float64 `json:"latitude,omitempty" validate:"min=-90,max=90"`
+	Longitude     *float64 `json:"longitude,omitempty" validate:"min=-180,max=180"`
+	Limit         uint     `json:"limit,omitempty"`
+	SortBy        string   `json:"sort_by,omitempty" validate:"oneof=rating"`
+}
+
+// SortByRating ranks search results by the machine's rating before start
+// time.
+const SortByRating = "rating"
+
+type AvailableWindow struct {
+	MachineId   uint     `json:"machine_id"`
+	MachineName string   `json:"machine_name"`
+	Category    string   `json:"category"`
+	Date        string   `json:"date"`
+	Slots       []uint   `json:"slots"`
+	StartTime   string   `json:"start_time"`
+	EndTime     string   `json:"end_time"`
+	TotalCost   Money    `json:"total_cost"`
+	DistanceKm  *float64 `json:"distance_km,omitempty"`
+	Rating      Rating   `json:"rating"`
+}
+
+type CartLine struct {
+	MachineId uint   `json:"machine_id" validate:"required"`
+	Date      string `json:"date" validate:"required,date"`
+	Slots     []uint `json:"slots" validate:"required,slot"`
+}
+
+type NewOrderRequest struct {
+	Lines         []CartLine `json:"lines" validate:"required"`
+	FarmerId      uint       `json:"-"`
+	PayFromWallet bool       `json:"pay_from_wallet"`
+	CommissionBp  uint       `json:"-"`
+	PromoCode     string     `json:"promo_code,omitempty" validate:"max=32"`
+}
+
+// OrderInvoice is the invoice of one machine owner for their part of an order.
+// Amount is the invoice total, tax included.
+type OrderInvoice struct {
+	InvoiceId  uint     `json:"invoice_id"`
+	Number     string   `json:"number"`
+	OwnerId    uint     `json:"owner_id"`
+	BookingIds []uint   `json:"booking_ids"`
+	Amount     Money    `json:"amount"`
+	Payment    *Payment `json:"payment,omitempty"`
+}
+
+type OrderResponse struct {
+	OrderId   uint                 `json:"order_id"`
+	Bookings  []NewBookingResponse `json:"bookings"`
+	Invoices  []OrderInvoice       `json:"invoices"`
+	TotalCost Money                `json:"total_cost"`
+	Discount  *Money               `json:"discount,omitempty"`
+}
+
+const (
+	PaymentCreated  = "created"
+	PaymentCaptured = "captured"
+	PaymentFailed   = "failed"
+	PaymentRefunded = "refunded"
+
+	// WalletProvider is the provider of payments made from a wallet.
+	WalletProvider = "wallet"
+)
+
+type NewPaymentRequest struct {
+	InvoiceId uint `json:"invoice_id" validate:"required"`
+	FarmerId  uint `json:"-"`
+}
+
+// Payment is the renter paying an invoice through a payment provider. The
+// renter pays ProviderOrderId at the provider, which then tells us the
+// ProviderPaymentId to capture. Refunded is how much of Amount was given
+// back so far, all of it once the payment is refunded.
+type Payment struct {
+	Id                uint      `db:"id" json:"id"`
+	InvoiceId         uint      `db:"invoice_id" json:"invoice_id"`
+	FarmerId          uint      `db:"farmer_id" json:"farmer_id"`
+	Provider          string    `db:"provider" json:"provider"`
+	ProviderOrderId   string    `db:"provider_order_id" json:"provider_order_id"`
+	ProviderPaymentId string    `db:"provider_payment_id" json:"provider_payment_id,omitempty"`
+	ProviderRefundId  string    `db:"provider_refund_id" json:"provider_refund_id,omitempty"`
+	Amount            Money     `db:"amount" json:"amount"`
+	Refunded          Money     `db:"refunded" json:"refunded"`
+	Status            string    `db:"status" json:"status"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+}
+
+// Refundable is what is left of the payment to give back.
+func (p Payment) Refundable() Money {
+	return p.Amount.Sub(p.Refunded)
+}
+
+// Refund is part or all of a wallet payment given back to the renter's wallet
+// when a booking on its invoice is cancelled.
+type Refund struct {
+	PaymentId uint
+	FarmerId  uint
+	Amount    Money
+}
+
+const (
+	DepositHeld     = "held"
+	DepositReleased = "released"
+	DepositCaptured = "captured"
+
+	DepositEntryHold    = "hold"
+	DepositEntryRelease = "release"
+	DepositEntryCapture = "capture"
+)
+
+// Deposit is the security deposit held from the renter for one booking of a
+// machine that asks for one. It is kept apart from the rental invoice and is
+// either released in full or captured up to Amount, with the rest released.
+type Deposit struct {
+	Id        uint                 `db:"id" json:"id"`
+	BookingId uint                 `db:"booking_id" json:"booking_id"`
+	FarmerId  uint                 `db:"farmer_id" json:"farmer_id"`
+	OwnerId   uint                 `db:"owner_id" json:"owner_id"`
+	Amount    Money                `db:"amount" json:"amount"`
+	Captured  Money                `db:"captured" json:"captured"`
+	Status    string               `db:"status" json:"status"`
+	CreatedAt time.Time            `db:"created_at" json:"created_at"`
+	Ledger    []DepositLedgerEntry `db:"-" json:"ledger,omitempty"`
+}
+
+// DepositLedgerEntry records one movement of a deposit: the hold, a capture
+// or a release.
+type DepositLedgerEntry struct {
+	Id        uint      `db:"id" json:"id"`
+	DepositId uint      `db:"deposit_id" json:"-"`
+	Kind      string    `db:"kind" json:"kind"`
+	Amount    Money     `db:"amount" json:"amount"`
+	Note      string    `db:"note" json:"note,omitempty"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+const (
+	WalletEntryTopup          = "topup"
+	WalletEntrySpend          = "spend"
+	WalletEntryRefund         = "refund"
+	WalletEntryDepositHold    = "deposit_hold"
+	WalletEntryDepositRelease = "deposit_release"
+
+	TopupCreated  = "created"
+	TopupCredited = "credited"
+	TopupFailed   = "failed"
+)
+
+// Wallet is a farmer's prepaid credit. A farmer without one has an empty
+// wallet in the default currency.
+type Wallet struct {
+	FarmerId  uint       `db:"farmer_id" json:"farmer_id"`
+	Balance   Money      `db:"balance" json:"balance"`
+	UpdatedAt *time.Time `db:"updated_at" json:"updated_at,omitempty"`
+}
+
+// WalletTransaction is one change of a wallet balance. Amount is positive for
+// top-ups and refunds and negative for spending; Balance is what the wallet
+// held afterwards.
+type WalletTransaction struct {
+	Id        uint      `db:"id" json:"id"`
+	Kind      string    `db:"kind" json:"kind"`
+	Amount    Money     `db:"amount" json:"amount"`
+	Balance   Money     `db:"balance" json:"balance"`
+	Reference string    `db:"reference" json:"reference"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type NewTopupRequest struct {
+	Amount   Money `json:"amount" validate:"required"`
+	FarmerId uint  `json:"-"`
+}
+
+// WalletTopup is the farmer adding money to their wallet through the payment
+// provider, e.g. in cash at one of its agents. The wallet is credited when the
+// provider reports the order paid.
+type WalletTopup struct {
+	Id                uint      `db:"id" json:"id"`
+	FarmerId          uint      `db:"farmer_id" json:"farmer_id"`
+	Provider          string    `db:"provider" json:"provider"`
+	ProviderOrderId   string    `db:"provider_order_id" json:"provider_order_id"`
+	ProviderPaymentId string    `db:"provider_payment_id" json:"provider_payment_id,omitempty"`
+	Amount            Money     `db:"amount" json:"amount"`
+	Status            string    `db:"status" json:"status"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+}
+
+type DepositCaptureRequest struct {
+	Amount Money  `json:"amount" validate:"required"`
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// LedgerTransaction is a set of ledger entries that move money between
+// accounts. Debits are positive and credits negative, so the entries of a
+// transaction sum to zero in every currency. Reference names what the money
+// moved for, e.g. "payment:3", and ties reversals to what they reverse.
+type LedgerTransaction struct {
+	Id          uint          `db:"id" json:"id"`
+	Kind        string        `db:"kind" json:"kind"`
+	Reference   string        `db:"reference" json:"reference"`
+	Description string        `db:"description" json:"description"`
+	CreatedAt   time.Time     `db:"created_at" json:"created_at"`
+	Entries     []LedgerEntry `db:"-" json:"entries"`
+}
+
+// LedgerEntry debits or credits one account. FarmerId is the renter or owner
+// the account belongs to, and zero for the platform's own accounts.
+type LedgerEntry struct {
+	Id            uint   `db:"id" json:"id"`
+	TransactionId uint   `db:"transaction_id" json:"-"`
+	Account       string `db:"account" json:"account"`
+	FarmerId      uint   `db:"farmer_id" json:"farmer_id,omitempty"`
+	Amount        Money  `db:"amount" json:"amount"`
+}
+
+// StatementLine is one movement on an owner's earnings. Amount is positive for
+// earnings and negative for payouts and refunds; Balance is the running
+// balance in the line's currency.
+type StatementLine struct {
+	TransactionId uint      `db:"transaction_id" json:"transaction_id"`
+	Kind          string    `db:"kind" json:"kind"`
+	Reference     string    `db:"reference" json:"reference"`
+	Description   string    `db:"description" json:"description"`
+	Amount        Money     `db:"amount" json:"amount"`
+	Balance       Money     `db:"-" json:"balance"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+}
+
+const (
+	PayoutPending = "pending"
+	PayoutPaid    = "paid"
+)
+
+// Payout is what an owner is paid in one settlement batch.
+type Payout struct {
+	Id        uint      `db:"id" json:"id"`
+	BatchId   uint      `db:"batch_id" json:"batch_id"`
+	OwnerId   uint      `db:"owner_id" json:"owner_id"`
+	Amount    Money     `db:"amount" json:"amount"`
+	Status    string    `db:"status" json:"status"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type PayoutBatch struct {
+	Id        uint      `json:"id"`
+	Payouts   []Payout  `json:"payouts"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OwnerEarnings is what the platform owes an owner, with one balance per
+// currency they earned in.
+type OwnerEarnings struct {
+	OwnerId   uint            `json:"owner_id"`
+	Balances  []Money         `json:"balances"`
+	Statement []StatementLine `json:"statement"`
+	Payouts   []Payout        `json:"payouts"`
+}
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	HandoverCheckIn  = "check_in"
+	HandoverCheckOut = "check_out"
+)
+
+// BookingParties is who is on either side of a booking, and how far it got.
+// EndDate is the date of its last slot.
+type BookingParties struct {
+	BookingId    uint   `db:"booking_id"`
+	MachineId    uint   `db:"machine_id"`
+	FarmerId     uint   `db:"farmer_id"`
+	OwnerId      uint   `db:"owner_id"`
+	Status       string `db:"status"`
+	UsageBilling bool   `db:"usage_billing"`
+	Currency     string `db:"currency"`
+	EndDate      string `db:"end_date"`
+	CheckedOut   bool   `db:"checked_out"`
+}
+
+// Role returns "renter" or "owner" for a party of the booking, and "" for
+// anyone else.
+func (p BookingParties) Role(farmerId uint) string {
+	switch farmerId {
+	case p.FarmerId:
+		return "renter"
+	case p.OwnerId:
+		return "owner"
+	}
+	return ""
+}
+
+// Completed reports whether the machine was given back or the booking is over
+// by the date today, in YYYY-MM-DD.
+func (p BookingParties) Completed(today string) bool {
+	return p.Status == BookingConfirmed && (p.CheckedOut || (p.EndDate != "" && p.EndDate < today))
+}
+
+type ChecklistItem struct {
+	Item string `json:"item" validate:"required,max=100"`
+	Ok   bool   `json:"ok"`
+	Note string `json:"note,omitempty"`
+}
+
+// Handover is the state of the machine when the renter takes it (check-in) or
+// gives it back (check-out), as recorded by one party and confirmed by the
+// other. MeterReading is in engine hours and FuelLevel in percent of a full
+// tank.
+type Handover struct {
+	Id                uint            `db:"id" json:"id"`
+	BookingId         uint            `db:"booking_id" json:"booking_id"`
+	Kind              string          `db:"kind" json:"kind"`
+	RecordedBy        uint            `db:"recorded_by" json:"recorded_by"`
+	MeterReading      float64         `db:"meter_reading" json:"meter_reading"`
+	FuelLevel         uint            `db:"fuel_level" json:"fuel_level"`
+	Checklist         []ChecklistItem `db:"-" json:"checklist"`
+	Photos            []string        `db:"-" json:"photos"`
+	Notes             string          `db:"notes" json:"notes,omitempty"`
+	RenterConfirmedAt *time.Time      `db:"renter_confirmed_at" json:"renter_confirmed_at,omitempty"`
+	OwnerConfirmedAt  *time.Time      `db:"owner_confirmed_at" json:"owner_confirmed_at,omitempty"`
+	CreatedAt         time.Time       `db:"created_at" json:"created_at"`
+}
+
+type HandoverRequest struct {
+	BookingId    uint            `json:"-"`
+	Kind         string          `json:"-"`
+	RecordedBy   uint            `json:"-"`
+	MeterReading float64         `json:"meter_reading" validate:"min=0"`
+	FuelLevel    uint            `json:"fuel_level" validate:"max=100"`
+	Checklist    []ChecklistItem `json:"checklist"`
+	Photos       []string        `json:"photos" validate:"url"`
+	Notes        string          `json:"notes" validate:"max=1000"`
+}
+
+// Confirmed reports whether both the renter and the owner agreed to the
+// handover.
+func (h Handover) Confirmed() bool {
+	return h.RenterConfirmedAt != nil && h.OwnerConfirmedAt != nil
+}
+
+// Clean reports whether every item on the checklist was found in order.
+func (h Handover) Clean() bool {
+	for _, item := range h.Checklist {
+		if !item.Ok {
+			return false
+		}
+	}
+	return true
+}
+
+// UsageTenths is the engine hours run between check-in and check-out, in
+// tenths of an hour.
+func UsageTenths(checkIn Handover, checkOut Handover) int64 {
+	return int64(math.Round((checkOut.MeterReading - checkIn.MeterReading) * 10))
+}
+
+// UsageAdjustment is what to add to a rental line, billed per hourly slot, so
+// that it bills the hours actually used instead. It is negative when the
+// machine ran for less than was booked, but never takes more off than the
+// line billed.
+func UsageAdjustment(rental InvoiceLineItem, usedTenths int64) Money {
+	extra := usedTenths - int64(rental.Quantity)*10
+	adjustment := rental.UnitAmount.Mul(extra).Percent(1000)
+	if adjustment.Minor < -rental.Amount.Minor {
+		adjustment = rental.Amount.Neg()
+	}
+	return adjustment
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandover_Confirmed(t *testing.T) {
+	now := time.Now()
+
+	assert.False(t, Handover{RenterConfirmedAt: &now}.Confirmed())
+	assert.False(t, Handover{OwnerConfirmedAt: &now}.Confirmed())
+	assert.True(t, Handover{RenterConfirmedAt: &now, OwnerConfirmedAt: &now}.Confirmed())
+}
+
+func TestHandover_Clean(t *testing.T) {
+	assert.True(t, Handover{}.Clean())
+	assert.True(t, Handover{Checklist: []ChecklistItem{{Item: "tyres", Ok: true}}}.Clean())
+	assert.False(t, Handover{Checklist: []ChecklistItem{{Item: "tyres", Ok: true}, {Item: "hitch", Note: "bent pin"}}}.Clean())
+}
+
+func TestUsageTenths(t *testing.T) {
+	assert.Equal(t, int64(35), UsageTenths(Handover{MeterReading: 1200.2}, Handover{MeterReading: 1203.7}))
+	assert.Equal(t, int64(0), UsageTenths(Handover{MeterReading: 1200.2}, Handover{MeterReading: 1200.2}))
+}
+
+func TestUsageAdjustment(t *testing.T) {
+	rental := InvoiceLineItem{Quantity: 3, UnitAmount: Rupees(200), Amount: Rupees(600)}
+
+	tests := []struct {
+		name string
+		used int64
+		want Money
+	}{
+		{name: "as booked", used: 30, want: Rupees(0)},
+		{name: "ran longer", used: 45, want: Rupees(300)},
+		{name: "ran shorter", used: 25, want: Rupees(-100)},
+		{name: "never ran", used: 0, want: Rupees(-600)},
+		{name: "meter ran backwards", used: -10, want: Rupees(-600)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, UsageAdjustment(rental, test.used))
+		})
+	}
+}
+
+func TestBookingParties_Role(t *testing.T) {
+	parties := BookingParties{FarmerId: 1, OwnerId: 2}
+
+	assert.Equal(t, "renter", parties.Role(1))
+	assert.Equal(t, "owner", parties.Role(2))
+	assert.Equal(t, "", parties.Role(3))
+}
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"FarmEasy/validate"
+	"regexp"
+	"time"
+)
+
+// Thread is a conversation between a renter and a machine's owner, either an
+// enquiry about the machine or about one of its bookings. Contact details in
+// its messages stay masked until Revealed, when the renter has a confirmed
+// booking of the machine.
+type Thread struct {
+	Id          uint      `db:"id" json:"id"`
+	MachineId   uint      `db:"machine_id" json:"machine_id"`
+	BookingId   *uint     `db:"booking_id" json:"booking_id,omitempty"`
+	RenterId    uint      `db:"renter_id" json:"renter_id"`
+	OwnerId     uint      `db:"owner_id" json:"owner_id"`
+	Unread      uint      `db:"unread" json:"unread"`
+	LastMessage string    `db:"last_message" json:"last_message"`
+	Revealed    bool      `db:"revealed" json:"-"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Other returns the party of the thread the farmer is talking to.
+func (t Thread) Other(farmerId uint) uint {
+	if farmerId == t.RenterId {
+		return t.OwnerId
+	}
+	return t.RenterId
+}
+
+// Includes reports whether the farmer is a party of the thread.
+func (t Thread) Includes(farmerId uint) bool {
+	return farmerId == t.RenterId || farmerId == t.OwnerId
+}
+
+type Message struct {
+	Id        uint      `db:"id" json:"id"`
+	ThreadId  uint      `db:"thread_id" json:"thread_id"`
+	SenderId  uint      `db:"sender_id" json:"sender_id"`
+	Body      string    `db:"body" json:"body"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// MessagePage is a page of a thread's messages, newest first. NextBefore is
+// the cursor of the next, older page.
+type MessagePage struct {
+	Messages   []Message `json:"messages"`
+	NextBefore *uint     `json:"next_before,omitempty"`
+}
+
+// NewThreadRequest opens the thread of a booking when BookingId is set, or
+// else the farmer's enquiry about MachineId, and posts Body to it.
+type NewThreadRequest struct {
+	MachineId uint   `json:"machine_id"`
+	BookingId uint   `json:"booking_id"`
+	FarmerId  uint   `json:"-"`
+	Body      string `json:"body" validate:"required,max=2000"`
+}
+
+func (t NewThreadRequest) Check() (errs validate.Errors) {
+	if t.MachineId == 0 && t.BookingId == 0 {
+		errs.Add("machine_id", "is required when booking_id is not given")
+	}
+	return
+}
+
+type NewMessageRequest struct {
+	ThreadId uint   `json:"-"`
+	SenderId uint   `json:"-"`
+	Body     string `json:"body" validate:"required,max=2000"`
+}
+
+const maskedContact = "[hidden]"
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// phonePattern matches ten or more digits, optionally after a + and
+	// separated by single spaces or dashes.
+	phonePattern = regexp.MustCompile(`\+?\d(?:[ -]?\d){9,}`)
+)
+
+// MaskContacts hides the email addresses and phone numbers in a message.
+func MaskContacts(body string) string {
+	body = emailPattern.ReplaceAllString(body, maskedContact)
+	return phonePattern.ReplaceAllString(body, maskedContact)
+}
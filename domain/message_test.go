@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaskContacts(t *testing.T) {
+	assert.Equal(t, "call me on [hidden] or [hidden]", MaskContacts("call me on +91 98765 43210 or 020-2553-1234"))
+	assert.Equal(t, "mail [hidden] today", MaskContacts("mail asha.patil@example.co.in today"))
+	assert.Equal(t, "Rs 15000 for 10 hours from 2026-11-07", MaskContacts("Rs 15000 for 10 hours from 2026-11-07"))
+}
+
+func TestThread_Other(t *testing.T) {
+	thread := Thread{RenterId: 9, OwnerId: 20}
+
+	assert.Equal(t, uint(20), thread.Other(9))
+	assert.Equal(t, uint(9), thread.Other(20))
+	assert.False(t, thread.Includes(30))
+}
@@ -0,0 +1,195 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const DefaultCurrency = "INR"
+
+var (
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrCurrencyMismatch = errors.New("amounts are in different currencies")
+
+	// currencyExponents is the number of minor unit digits of the ISO 4217
+	// currencies we accept.
+	currencyExponents = map[string]int{
+		"INR": 2,
+		"USD": 2,
+		"EUR": 2,
+		"GBP": 2,
+		"JPY": 0,
+	}
+)
+
+// Money is an amount in the minor units of its currency, e.g. paise for INR.
+// The zero value has no currency and takes the currency of whatever it is
+// added to, so it can be used as a running total.
+type Money struct {
+	Minor    int64  `db:"minor"`
+	Currency string `db:"currency"`
+}
+
+func NewMoney(minor int64, currency string) Money {
+	return Money{Minor: minor, Currency: currency}
+}
+
+// Rupees returns a whole number of rupees.
+func Rupees(rupees int64) Money {
+	return Money{Minor: rupees * 100, Currency: DefaultCurrency}
+}
+
+// ParseMoney parses a decimal amount such as "1250.50" in the currency. More
+// decimal places than the currency has minor units is an error rather than
+// being rounded away.
+func ParseMoney(amount string, currency string) (m Money, err error) {
+	exponent, ok := currencyExponents[currency]
+	if !ok {
+		return m, ErrUnknownCurrency
+	}
+
+	negative := strings.HasPrefix(amount, "-")
+	whole, fraction, hasPoint := strings.Cut(strings.TrimPrefix(amount, "-"), ".")
+	if whole == "" || (hasPoint && fraction == "") || len(fraction) > exponent || !digits(whole) || !digits(fraction) {
+		return m, ErrInvalidAmount
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	minor, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return m, ErrInvalidAmount
+	}
+	if negative {
+		minor = -minor
+	}
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+func digits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks that the currency is known and the amount is positive.
+func (m Money) Validate() (err error) {
+	if _, ok := currencyExponents[m.Currency]; !ok {
+		return ErrUnknownCurrency
+	}
+	if m.Minor <= 0 {
+		return ErrInvalidAmount
+	}
+	return
+}
+
+func (m Money) IsZero() bool {
+	return m.Minor == 0
+}
+
+func (m Money) SameCurrency(other Money) bool {
+	return m.Currency == "" || other.Currency == "" || m.Currency == other.Currency
+}
+
+// Add panics when the amounts are in different currencies; callers holding
+// amounts of unknown currencies check SameCurrency first.
+func (m Money) Add(other Money) Money {
+	if !m.SameCurrency(other) {
+		panic(fmt.Sprintf("adding %s to %s", other.Currency, m.Currency))
+	}
+	if m.Currency == "" {
+		m.Currency = other.Currency
+	}
+	m.Minor += other.Minor
+	return m
+}
+
+func (m Money) Sub(other Money) Money {
+	return m.Add(other.Neg())
+}
+
+func (m Money) Neg() Money {
+	m.Minor = -m.Minor
+	return m
+}
+
+func (m Money) Mul(quantity int64) Money {
+	m.Minor *= quantity
+	return m
+}
+
+// Percent returns the amount times basis points, rounded half away from zero
+// to the minor unit.
+func (m Money) Percent(basisPoints int64) Money {
+	product := m.Minor * basisPoints
+	if product < 0 {
+		m.Minor = -((-product + 5000) / 10000)
+	} else {
+		m.Minor = (product + 5000) / 10000
+	}
+	return m
+}
+
+// Decimal formats the amount with the currency's minor unit digits, e.g.
+// "1250.50".
+func (m Money) Decimal() string {
+	exponent, ok := currencyExponents[m.Currency]
+	if !ok {
+		exponent = 2
+	}
+	minor := m.Minor
+	sign := ""
+	if minor < 0 {
+		sign, minor = "-", -minor
+	}
+	if exponent == 0 {
+		return sign + strconv.FormatInt(minor, 10)
+	}
+	scale := int64(1)
+	for i := 0; i < exponent; i++ {
+		scale *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, minor/scale, exponent, minor%scale)
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	if m.Currency == "" {
+		m.Currency = DefaultCurrency
+	}
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{Amount: m.Decimal(), Currency: m.Currency})
+}
+
+// UnmarshalJSON accepts {"amount": "1250.50", "currency": "INR"}, with the
+// amount as a string or a number, or a bare number of rupees as the API took
+// before amounts had a currency.
+func (m *Money) UnmarshalJSON(data []byte) (err error) {
+	amount, currency := string(data), DefaultCurrency
+	if len(data) > 0 && data[0] == '{' {
+		var value struct {
+			Amount   json.RawMessage `json:"amount"`
+			Currency string          `json:"currency"`
+		}
+		if err = json.Unmarshal(data, &value); err != nil {
+			return
+		}
+		amount = string(value.Amount)
+		if value.Currency != "" {
+			currency = value.Currency
+		}
+	}
+	*m, err = ParseMoney(strings.Trim(amount, `"`), currency)
+	return
+}
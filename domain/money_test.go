@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		want     Money
+		wantErr  error
+	}{
+		{"1250.50", "INR", Money{125050, "INR"}, nil},
+		{"1250.5", "INR", Money{125050, "INR"}, nil},
+		{"1250", "INR", Money{125000, "INR"}, nil},
+		{"-3.07", "INR", Money{-307, "INR"}, nil},
+		{"500", "JPY", Money{500, "JPY"}, nil},
+		{"1250.505", "INR", Money{}, ErrInvalidAmount},
+		{"12.5", "JPY", Money{}, ErrInvalidAmount},
+		{"1e3", "INR", Money{}, ErrInvalidAmount},
+		{"12.", "INR", Money{}, ErrInvalidAmount},
+		{"", "INR", Money{}, ErrInvalidAmount},
+		{"10", "XYZ", Money{}, ErrUnknownCurrency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.amount+" "+tt.currency, func(t *testing.T) {
+			got, err := ParseMoney(tt.amount, tt.currency)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMoney_Percent(t *testing.T) {
+	assert.Equal(t, Money{5400, "INR"}, Money{60000, "INR"}.Percent(900))
+	assert.Equal(t, Money{3, "INR"}, Money{125, "INR"}.Percent(200))
+	assert.Equal(t, Money{-3, "INR"}, Money{-125, "INR"}.Percent(200))
+	assert.Equal(t, Money{741, "INR"}, Money{12345, "INR"}.Percent(600))
+}
+
+func TestMoney_Add(t *testing.T) {
+	var total Money
+	total = total.Add(Rupees(10)).Add(Money{50, "INR"})
+	assert.Equal(t, Money{1050, "INR"}, total)
+	assert.Equal(t, Money{950, "INR"}, Rupees(10).Sub(Money{50, "INR"}))
+	assert.Panics(t, func() { Rupees(1).Add(Money{100, "USD"}) })
+}
+
+func TestMoney_Decimal(t *testing.T) {
+	assert.Equal(t, "1250.50", Money{125050, "INR"}.Decimal())
+	assert.Equal(t, "0.05", Money{5, "INR"}.Decimal())
+	assert.Equal(t, "-0.05", Money{-5, "INR"}.Decimal())
+	assert.Equal(t, "500", Money{500, "JPY"}.Decimal())
+	assert.Equal(t, "1250.50 INR", Money{125050, "INR"}.String())
+}
+
+func TestMoney_JSON(t *testing.T) {
+	data, err := json.Marshal(Money{125050, "INR"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount": "1250.50", "currency": "INR"}`, string(data))
+
+	tests := []struct {
+		json    string
+		want    Money
+		wantErr bool
+	}{
+		{`{"amount": "1250.50", "currency": "INR"}`, Money{125050, "INR"}, false},
+		{`{"amount": 1250.5, "currency": "USD"}`, Money{125050, "USD"}, false},
+		{`{"amount": "1250.50"}`, Money{125050, "INR"}, false},
+		{`300`, Money{30000, "INR"}, false},
+		{`{"amount": "1.234", "currency": "INR"}`, Money{}, true},
+		{`true`, Money{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			var got Money
+			err := json.Unmarshal([]byte(tt.json), &got)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
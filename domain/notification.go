@@ -0,0 +1,162 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	EventBookingRequested = "booking.requested"
+	EventBookingAccepted  = "booking.accepted"
+	EventBookingCancelled = "booking.cancelled"
+	EventBookingReminder  = "booking.reminder"
+	EventBookingNoShow    = "booking.no_show"
+	EventInvoiceIssued    = "invoice.issued"
+	EventMachineCreated   = "machine.created"
+	// EventNotice is any other message for a farmer.
+	EventNotice = "notice"
+)
+
+const (
+	NotificationPending = "pending"
+	NotificationSent    = "sent"
+	NotificationFailed  = "failed"
+)
+
+// OutboxEvent is something that happened to a farmer's bookings, invoices or
+// machines. It is stored in the transaction of the change it describes, then
+// delivered on every channel the farmer has enabled and to the webhooks of the
+// farmer's api clients. An event without a Message only goes to webhooks.
+// Reference is the id of the booking, invoice or machine it is about, and Data
+// its details as partners receive them.
+type OutboxEvent struct {
+	Id        uint            `db:"id" json:"id"`
+	Kind      string          `db:"kind" json:"kind"`
+	FarmerId  uint            `db:"farmer_id" json:"farmer_id"`
+	Reference uint            `db:"reference" json:"reference"`
+	Message   string          `db:"message" json:"message"`
+	Data      json.RawMessage `db:"data" json:"data"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+}
+
+type BookingEventData struct {
+	BookingId uint `json:"booking_id"`
+	MachineId uint `json:"machine_id"`
+}
+
+type InvoiceEventData struct {
+	InvoiceId uint   `json:"invoice_id"`
+	Number    string `json:"number"`
+	BookingId uint   `json:"booking_id,omitempty"`
+	RenterId  uint   `json:"renter_id"`
+	OwnerId   uint   `json:"owner_id"`
+	Amount    Money  `json:"amount"`
+}
+
+type MachineEventData struct {
+	MachineId        uint   `json:"machine_id"`
+	Name             string `json:"name"`
+	Category         string `json:"category"`
+	BaseHourlyCharge Money  `json:"base_hourly_charge"`
+}
+
+// eventData marshals the details of an event. They are plain structs of
+// numbers and strings, so this cannot fail.
+func eventData(data interface{}) json.RawMessage {
+	raw, _ := json.Marshal(data)
+	return raw
+}
+
+// NewBookingEvent returns the event of the kind about a booking of the
+// machine, for the farmer it concerns.
+func NewBookingEvent(kind string, farmerId uint, bookingId uint, machineId uint) OutboxEvent {
+	var message string
+	switch kind {
+	case EventBookingRequested:
+		message = fmt.Sprintf("Your machine %d was booked, booking %d", machineId, bookingId)
+	case EventBookingAccepted:
+		message = fmt.Sprintf("Your booking %d of machine %d is confirmed", bookingId, machineId)
+	case EventBookingCancelled:
+		message = fmt.Sprintf("Booking %d of your machine %d was cancelled", bookingId, machineId)
+	}
+	return OutboxEvent{Kind: kind, FarmerId: farmerId, Reference: bookingId, Message: message, Data: eventData(BookingEventData{BookingId: bookingId, MachineId: machineId})}
+}
+
+// NewReminderEvent reminds the renter of a booking that starts soon.
+func NewReminderEvent(farmerId uint, bookingId uint, machineId uint, startsAt time.Time) OutboxEvent {
+	return OutboxEvent{
+		Kind:      EventBookingReminder,
+		FarmerId:  farmerId,
+		Reference: bookingId,
+		Message:   fmt.Sprintf("Your booking %d of machine %d starts at %s", bookingId, machineId, startsAt.Format("2006-01-02 15:04")),
+		Data:      eventData(BookingEventData{BookingId: bookingId, MachineId: machineId}),
+	}
+}
+
+// NewNoShowEvents tell the renter and the owner that a booking was marked a
+// no-show.
+func NewNoShowEvents(noShow NoShow) []OutboxEvent {
+	data := eventData(BookingEventData{BookingId: noShow.BookingId, MachineId: noShow.MachineId})
+	return []OutboxEvent{
+		{Kind: EventBookingNoShow, FarmerId: noShow.FarmerId, Reference: noShow.BookingId, Message: fmt.Sprintf("You did not check in for booking %d of machine %d, so it was marked a no-show", noShow.BookingId, noShow.MachineId), Data: data},
+		{Kind: EventBookingNoShow, FarmerId: noShow.OwnerId, Reference: noShow.BookingId, Message: fmt.Sprintf("Booking %d of your machine %d was marked a no-show and its slots released", noShow.BookingId, noShow.MachineId), Data: data},
+	}
+}
+
+// NewInvoiceEvents tell the renter about an invoice issued to them, and the
+// owner's partners that it was issued.
+func NewInvoiceEvents(invoice Invoice) []OutboxEvent {
+	data := eventData(InvoiceEventData{InvoiceId: invoice.Id, Number: invoice.Number, BookingId: invoice.BookingId, RenterId: invoice.FarmerId, OwnerId: invoice.OwnerId, Amount: invoice.Amount})
+	return []OutboxEvent{
+		{Kind: EventInvoiceIssued, FarmerId: invoice.FarmerId, Reference: invoice.Id, Message: fmt.Sprintf("Invoice %s for %s was issued to you", invoice.Number, invoice.Amount), Data: data},
+		{Kind: EventInvoiceIssued, FarmerId: invoice.OwnerId, Reference: invoice.Id, Data: data},
+	}
+}
+
+// NewMachineEvent tells the owner's partners about a machine they listed.
+func NewMachineEvent(machine MachineResponse) OutboxEvent {
+	return OutboxEvent{
+		Kind:      EventMachineCreated,
+		FarmerId:  machine.OwnerId,
+		Reference: machine.Id,
+		Data:      eventData(MachineEventData{MachineId: machine.Id, Name: machine.Name, Category: machine.Category, BaseHourlyCharge: machine.BaseHourlyCharge}),
+	}
+}
+
+// Notification is the delivery of an event on one channel. Pending deliveries
+// are retried until NextAttemptAt passes without a failure or they run out of
+// attempts. In-app notifications double as the farmer's inbox.
+type Notification struct {
+	Id            uint       `db:"id" json:"id"`
+	EventId       uint       `db:"event_id" json:"-"`
+	FarmerId      uint       `db:"farmer_id" json:"-"`
+	Channel       string     `db:"channel" json:"channel"`
+	Kind          string     `db:"kind" json:"kind"`
+	Message       string     `db:"message" json:"message"`
+	Status        string     `db:"status" json:"-"`
+	Attempts      uint       `db:"attempts" json:"-"`
+	NextAttemptAt time.Time  `db:"next_attempt_at" json:"-"`
+	LastError     string     `db:"last_error" json:"-"`
+	SentAt        *time.Time `db:"sent_at" json:"sent_at,omitempty"`
+	ReadAt        *time.Time `db:"read_at" json:"read_at,omitempty"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+}
+
+// NotificationDelivery is a due notification with the contact details of its
+// recipient.
+type NotificationDelivery struct {
+	Notification
+	Email string `db:"email"`
+	Phone string `db:"phone"`
+}
+
+type NotificationInbox struct {
+	Unread        uint           `json:"unread"`
+	Notifications []Notification `json:"notifications"`
+}
+
+type NotificationPreference struct {
+	Channel string `db:"channel" json:"channel" validate:"required"`
+	Enabled bool   `db:"enabled" json:"enabled"`
+}
@@ -0,0 +1,168 @@
+package domain
+
+import (
+	"FarmEasy/validate"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	PromoPercent = "percent"
+	PromoFlat    = "flat"
+)
+
+var (
+	ErrPromoInvalid       = errors.New("promo code is not valid")
+	ErrPromoExhausted     = errors.New("promo code has been used up")
+	ErrPromoNotApplicable = errors.New("promo code does not apply to these machines")
+)
+
+// PromoCode is a discount an admin offers, e.g. for a seasonal campaign or to
+// the members of a cooperative that owns the machines. A percent code takes
+// PercentBp basis points off every machine it applies to; a flat code takes
+// Amount off the booking or order as a whole. Zero caps mean no limit, and
+// empty Categories or OwnerIds mean any.
+type PromoCode struct {
+	Id             uint      `db:"id" json:"id"`
+	Code           string    `db:"code" json:"code"`
+	Description    string    `db:"description" json:"description"`
+	Kind           string    `db:"kind" json:"kind"`
+	PercentBp      uint      `db:"percent_bp" json:"percent_bp,omitempty"`
+	Amount         Money     `db:"amount" json:"amount"`
+	ValidFrom      time.Time `db:"valid_from" json:"valid_from"`
+	ValidUntil     time.Time `db:"valid_until" json:"valid_until"`
+	MaxRedemptions uint      `db:"max_redemptions" json:"max_redemptions"`
+	MaxPerFarmer   uint      `db:"max_per_farmer" json:"max_per_farmer"`
+	Categories     []string  `db:"-" json:"categories"`
+	OwnerIds       []uint    `db:"-" json:"owner_ids"`
+	Active         bool      `db:"active" json:"active"`
+	Redemptions    uint      `db:"redemptions" json:"redemptions"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
+
+type NewPromoCodeRequest struct {
+	Code           string    `json:"code" validate:"required,max=32"`
+	Description    string    `json:"description"`
+	Kind           string    `json:"kind" validate:"required,oneof=percent flat"`
+	PercentBp      uint      `json:"percent_bp" validate:"max=10000"`
+	Amount         Money     `json:"amount"`
+	ValidFrom      time.Time `json:"valid_from" validate:"required"`
+	ValidUntil     time.Time `json:"valid_until" validate:"required"`
+	MaxRedemptions uint      `json:"max_redemptions"`
+	MaxPerFarmer   uint      `json:"max_per_farmer"`
+	Categories     []string  `json:"categories"`
+	OwnerIds       []uint    `json:"owner_ids"`
+}
+
+// Check asks for the discount of the code's kind and a validity window that
+// ends after it starts.
+func (p NewPromoCodeRequest) Check() (errs validate.Errors) {
+	switch p.Kind {
+	case PromoPercent:
+		if p.PercentBp == 0 {
+			errs.Add("percent_bp", "is required")
+		}
+	case PromoFlat:
+		if p.Amount == (Money{}) {
+			errs.Add("amount", "is required")
+		}
+	}
+	if !p.ValidFrom.IsZero() && !p.ValidUntil.IsZero() && !p.ValidFrom.Before(p.ValidUntil) {
+		errs.Add("valid_until", "must be after valid_from")
+	}
+	return
+}
+
+// PromoRedemption is one booking or order a promo code was used on.
+type PromoRedemption struct {
+	Id          uint      `db:"id" json:"id"`
+	PromoCodeId uint      `db:"promo_code_id" json:"promo_code_id"`
+	FarmerId    uint      `db:"farmer_id" json:"farmer_id"`
+	BookingId   *uint     `db:"booking_id" json:"booking_id,omitempty"`
+	OrderId     *uint     `db:"order_id" json:"order_id,omitempty"`
+	Discount    Money     `db:"discount" json:"discount"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
+// PromoLine is a machine being booked, as far as a promo code cares.
+type PromoLine struct {
+	Category string
+	OwnerId  uint
+	Amount   Money
+}
+
+// NormalizePromoCode makes codes case-insensitive.
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Usable checks that the code is active and within its validity window.
+func (p PromoCode) Usable(now time.Time) error {
+	if !p.Active || now.Before(p.ValidFrom) || !now.Before(p.ValidUntil) {
+		return ErrPromoInvalid
+	}
+	return nil
+}
+
+// AppliesTo reports whether the code may discount the line.
+func (p PromoCode) AppliesTo(line PromoLine) bool {
+	if len(p.Categories) > 0 && !containsString(p.Categories, line.Category) {
+		return false
+	}
+	if len(p.OwnerIds) > 0 && !containsUint(p.OwnerIds, line.OwnerId) {
+		return false
+	}
+	if p.Kind == PromoFlat && !p.Amount.SameCurrency(line.Amount) {
+		return false
+	}
+	return true
+}
+
+// Discounts returns the discount on each line, zero where the code does not
+// apply. A flat discount is used up line by line and never exceeds a line's
+// amount. It fails with ErrPromoNotApplicable when nothing is discounted.
+func (p PromoCode) Discounts(lines []PromoLine) (discounts []Money, err error) {
+	remaining := p.Amount
+	var total int64
+	for _, line := range lines {
+		discount := NewMoney(0, line.Amount.Currency)
+		if p.AppliesTo(line) {
+			switch p.Kind {
+			case PromoPercent:
+				discount = line.Amount.Percent(int64(p.PercentBp))
+			case PromoFlat:
+				discount.Minor = remaining.Minor
+				if discount.Minor > line.Amount.Minor {
+					discount.Minor = line.Amount.Minor
+				}
+				remaining = remaining.Sub(discount)
+			}
+		}
+		total += discount.Minor
+		discounts = append(discounts, discount)
+	}
+
+	if total == 0 {
+		return nil, ErrPromoNotApplicable
+	}
+	return
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsUint(values []uint, value uint) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromoCode_Usable(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	promo := PromoCode{Active: true, ValidFrom: from, ValidUntil: from.AddDate(0, 1, 0)}
+
+	assert.NoError(t, promo.Usable(from))
+	assert.Equal(t, ErrPromoInvalid, promo.Usable(from.Add(-time.Second)))
+	assert.Equal(t, ErrPromoInvalid, promo.Usable(promo.ValidUntil))
+
+	promo.Active = false
+	assert.Equal(t, ErrPromoInvalid, promo.Usable(from))
+}
+
+func TestPromoCode_Discounts(t *testing.T) {
+	lines := []PromoLine{
+		{Category: "tractor", OwnerId: 20, Amount: Rupees(600)},
+		{Category: "harvester", OwnerId: 21, Amount: Rupees(400)},
+	}
+
+	tests := []struct {
+		name    string
+		promo   PromoCode
+		want    []Money
+		wantErr error
+	}{
+		{
+			name:  "percent off every line",
+			promo: PromoCode{Kind: PromoPercent, PercentBp: 1000},
+			want:  []Money{Rupees(60), Rupees(40)},
+		},
+		{
+			name:  "percent off a category",
+			promo: PromoCode{Kind: PromoPercent, PercentBp: 1000, Categories: []string{"harvester"}},
+			want:  []Money{Rupees(0), Rupees(40)},
+		},
+		{
+			name:  "flat across lines",
+			promo: PromoCode{Kind: PromoFlat, Amount: Rupees(700)},
+			want:  []Money{Rupees(600), Rupees(100)},
+		},
+		{
+			name:  "flat for an owner",
+			promo: PromoCode{Kind: PromoFlat, Amount: Rupees(100), OwnerIds: []uint{21}},
+			want:  []Money{Rupees(0), Rupees(100)},
+		},
+		{
+			name:    "flat in another currency",
+			promo:   PromoCode{Kind: PromoFlat, Amount: NewMoney(1000, "USD")},
+			wantErr: ErrPromoNotApplicable,
+		},
+		{
+			name:    "no machine matches",
+			promo:   PromoCode{Kind: PromoPercent, PercentBp: 1000, Categories: []string{"sprayer"}},
+			wantErr: ErrPromoNotApplicable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.promo.Discounts(lines)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package domain
+
+import "time"
+
+const (
+	ReviewReportOpen      = "open"
+	ReviewReportDismissed = "dismissed"
+	// ReviewReportUpheld hides the review from listings and ratings.
+	ReviewReportUpheld = "upheld"
+)
+
+// Rating aggregates the visible reviews of a machine or a farmer.
+type Rating struct {
+	Average float64 `db:"average" json:"average"`
+	Count   uint    `db:"count" json:"count"`
+}
+
+// Review is one party's rating of the other after a completed booking. Role is
+// the reviewer's role in the booking; a renter's review rates both the owner
+// and the machine.
+type Review struct {
+	Id         uint      `db:"id" json:"id"`
+	BookingId  uint      `db:"booking_id" json:"booking_id"`
+	MachineId  uint      `db:"machine_id" json:"machine_id"`
+	ReviewerId uint      `db:"reviewer_id" json:"reviewer_id"`
+	RevieweeId uint      `db:"reviewee_id" json:"reviewee_id"`
+	Role       string    `db:"role" json:"role"`
+	Rating     uint      `db:"rating" json:"rating"`
+	Comment    string    `db:"comment" json:"comment"`
+	Hidden     bool      `db:"hidden" json:"-"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+type NewReviewRequest struct {
+	BookingId  uint   `json:"-"`
+	ReviewerId uint   `json:"-"`
+	Rating     uint   `json:"rating" validate:"required,min=1,max=5"`
+	Comment    string `json:"comment" validate:"max=1000"`
+}
+
+// ReviewReport is a farmer's report of an abusive review, for an admin to
+// dismiss or uphold.
+type ReviewReport struct {
+	Id             uint       `db:"id" json:"id"`
+	ReviewId       uint       `db:"review_id" json:"review_id"`
+	ReporterId     uint       `db:"reporter_id" json:"reporter_id"`
+	Reason         string     `db:"reason" json:"reason"`
+	Status         string     `db:"status" json:"status"`
+	ResolutionNote string     `db:"resolution_note" json:"resolution_note,omitempty"`
+	ResolvedBy     *uint      `db:"resolved_by" json:"resolved_by,omitempty"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	ResolvedAt     *time.Time `db:"resolved_at" json:"resolved_at,omitempty"`
+	Review         Review     `db:"review" json:"review"`
+}
+
+type ReviewReportRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+type ReviewReportDecisionRequest struct {
+	Remove bool   `json:"remove"`
+	Note   string `json:"note" validate:"max=1000"`
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"FarmEasy/validate"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	WebhookPending   = "pending"
+	WebhookDelivered = "delivered"
+	WebhookFailed    = "failed"
+)
+
+// WebhookEvents are the kinds of event a webhook can subscribe to.
+var WebhookEvents = []string{EventBookingRequested, EventBookingAccepted, EventBookingCancelled, EventBookingNoShow, EventInvoiceIssued, EventMachineCreated}
+
+// APIClient is a partner application, such as a cooperative's own app, that
+// acts for a farmer and receives webhooks about the farmer's bookings,
+// invoices and machines. Secret signs those webhooks; it is only shown when
+// the client is created.
+type APIClient struct {
+	Id        uint      `db:"id" json:"id"`
+	FarmerId  uint      `db:"farmer_id" json:"farmer_id"`
+	Name      string    `db:"name" json:"name"`
+	Secret    string    `db:"secret" json:"secret,omitempty"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type NewAPIClientRequest struct {
+	FarmerId uint   `json:"-"`
+	Name     string `json:"name" validate:"required,max=100"`
+}
+
+type WebhookSubscription struct {
+	Id        uint      `db:"id" json:"id"`
+	ClientId  uint      `db:"client_id" json:"client_id"`
+	FarmerId  uint      `db:"farmer_id" json:"-"`
+	URL       string    `db:"url" json:"url"`
+	Events    []string  `db:"-" json:"events"`
+	Active    bool      `db:"active" json:"active"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type NewWebhookRequest struct {
+	ClientId uint     `json:"-"`
+	FarmerId uint     `json:"-"`
+	URL      string   `json:"url" validate:"required,url"`
+	Events   []string `json:"events" validate:"required"`
+}
+
+func (w NewWebhookRequest) Check() (errs validate.Errors) {
+	for i, event := range w.Events {
+		if !containsString(WebhookEvents, event) {
+			errs.Add(fmt.Sprintf("events[%d]", i), fmt.Sprintf("must be one of %v", WebhookEvents))
+		}
+	}
+	return
+}
+
+// WebhookDelivery is an attempt, and its retries, to post an event to a
+// webhook. Payload is the body posted; ReplayOf is set on the deliveries a
+// farmer asked to send again.
+type WebhookDelivery struct {
+	Id             uint            `db:"id" json:"id"`
+	SubscriptionId uint            `db:"subscription_id" json:"subscription_id"`
+	EventId        uint            `db:"event_id" json:"event_id"`
+	Kind           string          `db:"kind" json:"kind"`
+	Payload        json.RawMessage `db:"-" json:"payload"`
+	ReplayOf       *uint           `db:"replay_of" json:"replay_of,omitempty"`
+	Status         string          `db:"status" json:"status"`
+	Attempts       uint            `db:"attempts" json:"attempts"`
+	NextAttemptAt  time.Time       `db:"next_attempt_at" json:"next_attempt_at"`
+	ResponseStatus int             `db:"response_status" json:"response_status,omitempty"`
+	LastError      string          `db:"last_error" json:"last_error,omitempty"`
+	DeliveredAt    *time.Time      `db:"delivered_at" json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+}
+
+// DueWebhookDelivery is a delivery to attempt, with where to post it and the
+// secret to sign it with.
+type DueWebhookDelivery struct {
+	WebhookDelivery
+	URL    string
+	Secret string
+}
@@ -14,7 +14,12 @@ require (
 	github.com/urfave/negroni v1.0.0
 )
 
-require github.com/zhashkevych/go-sqlxmock v1.5.1 // indirect
+require (
+	github.com/boombuler/barcode v1.0.1
+	github.com/zhashkevych/go-sqlxmock v1.5.1
+)
+
+require github.com/go-pdf/fpdf v0.6.0
 
 require (
 	github.com/Microsoft/go-winio v0.6.0 // indirect
@@ -0,0 +1,280 @@
+// Package ledger builds the double-entry transactions that record money moving
+// between renters, owners and the platform. Debits are positive and credits
+// negative; a transaction is only valid when it sums to zero.
+package ledger
+
+import (
+	"FarmEasy/domain"
+	"errors"
+	"fmt"
+)
+
+// Accounts. Renter, owner and deposit accounts are per farmer.
+const (
+	// AccountCash is money the platform holds at the payment provider or bank.
+	AccountCash = "cash"
+	// AccountCommission is the platform's revenue.
+	AccountCommission = "commission"
+	// AccountRenter is what a renter owes for their rentals.
+	AccountRenter = "renter"
+	// AccountOwner is what the platform owes an owner.
+	AccountOwner = "owner_payable"
+	// AccountDeposits is the security deposits held for a renter.
+	AccountDeposits = "deposits_held"
+	// AccountWallet is the prepaid credit the platform holds for a farmer.
+	AccountWallet = "wallet"
+)
+
+// Transaction kinds.
+const (
+	KindCharge         = "charge"
+	KindPayment        = "payment"
+	KindRefund         = "refund"
+	KindDepositHold    = "deposit_hold"
+	KindDepositRelease = "deposit_release"
+	KindDepositCapture = "deposit_capture"
+	KindPayout         = "payout"
+	KindWalletTopup    = "wallet_topup"
+)
+
+var (
+	ErrUnbalanced      = errors.New("ledger transaction does not balance")
+	ErrTooFewEntries   = errors.New("ledger transaction needs at least two entries")
+	ErrZeroEntry       = errors.New("ledger entry has no amount")
+	ErrNothingToRevert = errors.New("no ledger transactions to reverse")
+)
+
+// Validate checks that the transaction has at least two non-zero entries and
+// that they sum to zero in every currency.
+func Validate(t domain.LedgerTransaction) (err error) {
+	if len(t.Entries) < 2 {
+		return ErrTooFewEntries
+	}
+
+	sums := map[string]int64{}
+	for _, entry := range t.Entries {
+		if entry.Amount.IsZero() {
+			return ErrZeroEntry
+		}
+		sums[entry.Amount.Currency] += entry.Amount.Minor
+	}
+	for _, sum := range sums {
+		if sum != 0 {
+			return ErrUnbalanced
+		}
+	}
+	return
+}
+
+func debit(account string, farmerId uint, amount domain.Money) domain.LedgerEntry {
+	return domain.LedgerEntry{Account: account, FarmerId: farmerId, Amount: amount}
+}
+
+func credit(account string, farmerId uint, amount domain.Money) domain.LedgerEntry {
+	return domain.LedgerEntry{Account: account, FarmerId: farmerId, Amount: amount.Neg()}
+}
+
+// Commission is the platform's cut of an invoice, commissionBp basis points of
+// its taxable value. Tax is passed on to the owner, who owes it.
+func Commission(invoice domain.Invoice, commissionBp uint) domain.Money {
+	return invoice.TaxableValue.Percent(int64(commissionBp))
+}
+
+// InvoicePaid records the renter being charged for an invoice, split between
+// the owner and the platform's commission, and paying it.
+func InvoicePaid(invoice domain.Invoice, payment domain.Payment, commissionBp uint) []domain.LedgerTransaction {
+	reference := fmt.Sprintf("payment:%d", payment.Id)
+	commission := Commission(invoice, commissionBp)
+
+	charge := domain.LedgerTransaction{
+		Kind:        KindCharge,
+		Reference:   reference,
+		Description: "Invoice " + invoice.Number,
+		Entries: []domain.LedgerEntry{
+			debit(AccountRenter, invoice.FarmerId, invoice.Amount),
+			credit(AccountOwner, invoice.OwnerId, invoice.Amount.Sub(commission)),
+		},
+	}
+	if !commission.IsZero() {
+		charge.Entries = append(charge.Entries, credit(AccountCommission, 0, commission))
+	}
+
+	// a wallet payment spends cash the platform took in at top-up
+	source := debit(AccountCash, 0, payment.Amount)
+	if payment.Provider == domain.WalletProvider {
+		source = debit(AccountWallet, invoice.FarmerId, payment.Amount)
+	}
+	paid := domain.LedgerTransaction{
+		Kind:        KindPayment,
+		Reference:   reference,
+		Description: "Payment for invoice " + invoice.Number,
+		Entries: []domain.LedgerEntry{
+			source,
+			credit(AccountRenter, invoice.FarmerId, payment.Amount),
+		},
+	}
+
+	return []domain.LedgerTransaction{charge, paid}
+}
+
+// Reverse returns one transaction that undoes all of the given ones, so a
+// refund gives back exactly what was charged, commission included.
+func Reverse(kind string, reference string, description string, transactions []domain.LedgerTransaction) (reversal domain.LedgerTransaction, err error) {
+	if len(transactions) == 0 {
+		err = ErrNothingToRevert
+		return
+	}
+
+	reversal = domain.LedgerTransaction{Kind: kind, Reference: reference, Description: description, Entries: undo(transactions)}
+	return
+}
+
+// ReverseShare returns one transaction that undoes share out of total of the
+// given ones, each account in proportion, for giving back part of a payment.
+// Amounts are rounded toward zero and the minor units lost to rounding go to
+// the entries that lost the most, so the transaction still balances.
+func ReverseShare(kind string, reference string, description string, transactions []domain.LedgerTransaction, share domain.Money, total domain.Money) (reversal domain.LedgerTransaction, err error) {
+	if len(transactions) == 0 || total.Minor == 0 {
+		err = ErrNothingToRevert
+		return
+	}
+
+	entries := undo(transactions)
+	lost := make([]int64, len(entries))
+	residual := map[string]int64{}
+	for i, entry := range entries {
+		scaled := entry.Amount.Minor * share.Minor
+		entries[i].Amount.Minor = scaled / total.Minor
+		lost[i] = scaled % total.Minor
+		residual[entry.Amount.Currency] -= entries[i].Amount.Minor
+	}
+	for currency := range residual {
+		for residual[currency] != 0 {
+			step := int64(1)
+			if residual[currency] < 0 {
+				step = -1
+			}
+			most := -1
+			for i, entry := range entries {
+				if entry.Amount.Currency == currency && lost[i]*step > 0 && (most < 0 || lost[i]*step > lost[most]*step) {
+					most = i
+				}
+			}
+			if most < 0 {
+				return reversal, ErrUnbalanced
+			}
+			entries[most].Amount.Minor += step
+			lost[most] = 0
+			residual[currency] -= step
+		}
+	}
+
+	reversal = domain.LedgerTransaction{Kind: kind, Reference: reference, Description: description}
+	for _, entry := range entries {
+		if entry.Amount.Minor != 0 {
+			reversal.Entries = append(reversal.Entries, entry)
+		}
+	}
+	return
+}
+
+// undo nets the entries of the transactions per account and returns the
+// entries that bring each account back to where it was.
+func undo(transactions []domain.LedgerTransaction) (entries []domain.LedgerEntry) {
+	type key struct {
+		account  string
+		farmerId uint
+		currency string
+	}
+	var order []key
+	net := map[key]int64{}
+	for _, t := range transactions {
+		for _, entry := range t.Entries {
+			k := key{entry.Account, entry.FarmerId, entry.Amount.Currency}
+			if _, ok := net[k]; !ok {
+				order = append(order, k)
+			}
+			net[k] -= entry.Amount.Minor
+		}
+	}
+
+	for _, k := range order {
+		if net[k] != 0 {
+			entries = append(entries, domain.LedgerEntry{Account: k.account, FarmerId: k.farmerId, Amount: domain.NewMoney(net[k], k.currency)})
+		}
+	}
+	return
+}
+
+func depositReference(deposit domain.Deposit) string {
+	return fmt.Sprintf("deposit:%d", deposit.Id)
+}
+
+// DepositHeld records the renter's deposit being taken from their wallet and
+// held for them. The cash behind it came in when the wallet was topped up.
+func DepositHeld(deposit domain.Deposit) domain.LedgerTransaction {
+	return domain.LedgerTransaction{
+		Kind:        KindDepositHold,
+		Reference:   depositReference(deposit),
+		Description: fmt.Sprintf("Deposit for booking %d", deposit.BookingId),
+		Entries: []domain.LedgerEntry{
+			debit(AccountWallet, deposit.FarmerId, deposit.Amount),
+			credit(AccountDeposits, deposit.FarmerId, deposit.Amount),
+		},
+	}
+}
+
+// DepositReleased records amount of the deposit going back to the renter's
+// wallet.
+func DepositReleased(deposit domain.Deposit, amount domain.Money) domain.LedgerTransaction {
+	return domain.LedgerTransaction{
+		Kind:        KindDepositRelease,
+		Reference:   depositReference(deposit),
+		Description: fmt.Sprintf("Deposit for booking %d released", deposit.BookingId),
+		Entries: []domain.LedgerEntry{
+			debit(AccountDeposits, deposit.FarmerId, amount),
+			credit(AccountWallet, deposit.FarmerId, amount),
+		},
+	}
+}
+
+// DepositCaptured records amount of the deposit being kept and owed to the
+// owner as compensation.
+func DepositCaptured(deposit domain.Deposit, amount domain.Money) domain.LedgerTransaction {
+	return domain.LedgerTransaction{
+		Kind:        KindDepositCapture,
+		Reference:   depositReference(deposit),
+		Description: fmt.Sprintf("Deposit for booking %d kept for damage", deposit.BookingId),
+		Entries: []domain.LedgerEntry{
+			debit(AccountDeposits, deposit.FarmerId, amount),
+			credit(AccountOwner, deposit.OwnerId, amount),
+		},
+	}
+}
+
+// Payout records the owner being paid what they earned.
+func Payout(payout domain.Payout) domain.LedgerTransaction {
+	return domain.LedgerTransaction{
+		Kind:        KindPayout,
+		Reference:   fmt.Sprintf("payout:%d", payout.Id),
+		Description: fmt.Sprintf("Payout in settlement batch %d", payout.BatchId),
+		Entries: []domain.LedgerEntry{
+			debit(AccountOwner, payout.OwnerId, payout.Amount),
+			credit(AccountCash, 0, payout.Amount),
+		},
+	}
+}
+
+// WalletToppedUp records the farmer's top-up coming in and being owed back to
+// them as wallet credit.
+func WalletToppedUp(topup domain.WalletTopup) domain.LedgerTransaction {
+	return domain.LedgerTransaction{
+		Kind:        KindWalletTopup,
+		Reference:   fmt.Sprintf("topup:%d", topup.Id),
+		Description: "Wallet top-up " + topup.ProviderPaymentId,
+		Entries: []domain.LedgerEntry{
+			debit(AccountCash, 0, topup.Amount),
+			credit(AccountWallet, topup.FarmerId, topup.Amount),
+		},
+	}
+}
@@ -0,0 +1,136 @@
+package ledger
+
+import (
+	"FarmEasy/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []domain.LedgerEntry
+		wantErr error
+	}{
+		{
+			name:    "balanced",
+			entries: []domain.LedgerEntry{debit(AccountCash, 0, domain.Rupees(10)), credit(AccountRenter, 9, domain.Rupees(10))},
+		},
+		{
+			name:    "unbalanced",
+			entries: []domain.LedgerEntry{debit(AccountCash, 0, domain.Rupees(10)), credit(AccountRenter, 9, domain.Rupees(9))},
+			wantErr: ErrUnbalanced,
+		},
+		{
+			name:    "balanced across currencies only",
+			entries: []domain.LedgerEntry{debit(AccountCash, 0, domain.Rupees(10)), credit(AccountRenter, 9, domain.NewMoney(1000, "USD"))},
+			wantErr: ErrUnbalanced,
+		},
+		{
+			name:    "single entry",
+			entries: []domain.LedgerEntry{debit(AccountCash, 0, domain.Rupees(10))},
+			wantErr: ErrTooFewEntries,
+		},
+		{
+			name:    "zero entry",
+			entries: []domain.LedgerEntry{debit(AccountCash, 0, domain.Rupees(0)), credit(AccountRenter, 9, domain.Rupees(0))},
+			wantErr: ErrZeroEntry,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantErr, Validate(domain.LedgerTransaction{Entries: tt.entries}))
+		})
+	}
+}
+
+func TestInvoicePaid(t *testing.T) {
+	invoice := domain.Invoice{Number: "FE/2026-27/000042", FarmerId: 9, OwnerId: 20, TaxableValue: domain.Rupees(600), Amount: domain.Rupees(708)}
+	payment := domain.Payment{Id: 3, Amount: domain.Rupees(708)}
+
+	txns := InvoicePaid(invoice, payment, 1000)
+	require.Len(t, txns, 2)
+	for _, txn := range txns {
+		assert.NoError(t, Validate(txn))
+		assert.Equal(t, "payment:3", txn.Reference)
+	}
+	assert.Equal(t, []domain.LedgerEntry{
+		{Account: AccountRenter, FarmerId: 9, Amount: domain.Rupees(708)},
+		{Account: AccountOwner, FarmerId: 20, Amount: domain.Rupees(-648)},
+		{Account: AccountCommission, Amount: domain.Rupees(-60)},
+	}, txns[0].Entries)
+
+	t.Run("from the wallet", func(t *testing.T) {
+		txns := InvoicePaid(invoice, domain.Payment{Id: 3, Provider: domain.WalletProvider, Amount: domain.Rupees(708)}, 1000)
+		assert.Equal(t, domain.LedgerEntry{Account: AccountWallet, FarmerId: 9, Amount: domain.Rupees(708)}, txns[1].Entries[0])
+		assert.NoError(t, Validate(txns[1]))
+	})
+
+	t.Run("without commission", func(t *testing.T) {
+		txns := InvoicePaid(invoice, payment, 0)
+		assert.Len(t, txns[0].Entries, 2)
+		assert.NoError(t, Validate(txns[0]))
+	})
+}
+
+func TestReverse(t *testing.T) {
+	invoice := domain.Invoice{Number: "FE/2026-27/000042", FarmerId: 9, OwnerId: 20, TaxableValue: domain.Rupees(600), Amount: domain.Rupees(708)}
+	txns := InvoicePaid(invoice, domain.Payment{Id: 3, Amount: domain.Rupees(708)}, 1000)
+
+	reversal, err := Reverse(KindRefund, "payment:3", "Refund", txns)
+	require.NoError(t, err)
+	assert.NoError(t, Validate(reversal))
+	// the renter's charge and payment net out, so only cash, the owner and
+	// the commission are undone
+	assert.Equal(t, []domain.LedgerEntry{
+		{Account: AccountOwner, FarmerId: 20, Amount: domain.Rupees(648)},
+		{Account: AccountCommission, Amount: domain.Rupees(60)},
+		{Account: AccountCash, Amount: domain.Rupees(-708)},
+	}, reversal.Entries)
+
+	_, err = Reverse(KindRefund, "payment:3", "Refund", nil)
+	assert.Equal(t, ErrNothingToRevert, err)
+}
+
+func TestReverseShare(t *testing.T) {
+	invoice := domain.Invoice{Number: "FE/2026-27/000042", FarmerId: 9, OwnerId: 20, TaxableValue: domain.Rupees(600), Amount: domain.Rupees(708)}
+	txns := InvoicePaid(invoice, domain.Payment{Id: 3, Amount: domain.Rupees(708)}, 1000)
+
+	reversal, err := ReverseShare(KindRefund, "payment:3", "Refund", txns, domain.Rupees(354), domain.Rupees(708))
+	require.NoError(t, err)
+	assert.NoError(t, Validate(reversal))
+	assert.Equal(t, []domain.LedgerEntry{
+		{Account: AccountOwner, FarmerId: 20, Amount: domain.Rupees(324)},
+		{Account: AccountCommission, Amount: domain.Rupees(30)},
+		{Account: AccountCash, Amount: domain.Rupees(-354)},
+	}, reversal.Entries)
+
+	// 9152.54 and 847.46 paise round down to 9152 and 847, and the paisa
+	// lost goes to the owner, who lost the most of it
+	rounded, err := ReverseShare(KindRefund, "payment:3", "Refund", txns, domain.Rupees(100), domain.Rupees(708))
+	require.NoError(t, err)
+	assert.NoError(t, Validate(rounded))
+	assert.Equal(t, []domain.LedgerEntry{
+		{Account: AccountOwner, FarmerId: 20, Amount: domain.NewMoney(9153, "INR")},
+		{Account: AccountCommission, Amount: domain.NewMoney(847, "INR")},
+		{Account: AccountCash, Amount: domain.NewMoney(-10000, "INR")},
+	}, rounded.Entries)
+
+	_, err = ReverseShare(KindRefund, "payment:3", "Refund", nil, domain.Rupees(354), domain.Rupees(708))
+	assert.Equal(t, ErrNothingToRevert, err)
+}
+
+func TestDepositCaptured(t *testing.T) {
+	deposit := domain.Deposit{Id: 5, BookingId: 11, FarmerId: 9, OwnerId: 20, Amount: domain.Rupees(25000)}
+
+	held := DepositHeld(deposit)
+	assert.NoError(t, Validate(held))
+	// the deposit comes out of the renter's wallet, not out of nowhere
+	assert.Equal(t, domain.LedgerEntry{Account: AccountWallet, FarmerId: 9, Amount: domain.Rupees(25000)}, held.Entries[0])
+	captured := DepositCaptured(deposit, domain.Rupees(4000))
+	assert.NoError(t, Validate(captured))
+	assert.Equal(t, "deposit:5", captured.Reference)
+	assert.Equal(t, domain.LedgerEntry{Account: AccountOwner, FarmerId: 20, Amount: domain.Rupees(-4000)}, captured.Entries[1])
+}
@@ -6,6 +6,7 @@ package main
 import (
 	"FarmEasy/config"
 	"FarmEasy/services"
+	"context"
 	"fmt"
 
 	"FarmEasy/db"
@@ -72,6 +73,8 @@ func startApp() (err error) {
 		return
 	}
 
+	services.NewScheduler(deps).Start(context.Background())
+
 	// mux router
 	router := services.InitRouter(deps)
 
@@ -0,0 +1,15 @@
+CREATE TABLE "idempotency_keys"(
+    "id" SERIAL NOT NULL,
+    "farmer_id" BIGINT NOT NULL,
+    "key" TEXT NOT NULL,
+    "request_hash" TEXT NOT NULL,
+    "status_code" INTEGER NOT NULL DEFAULT 0,
+    "response_body" BYTEA,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "idempotency_keys" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "idempotency_keys" ADD CONSTRAINT "idempotency_keys_farmer_id_key_unique" UNIQUE("farmer_id", "key");
+ALTER TABLE
+    "idempotency_keys" ADD CONSTRAINT "idempotency_keys_farmer_id_foreign" FOREIGN KEY("farmer_id") REFERENCES "farmers"("id");
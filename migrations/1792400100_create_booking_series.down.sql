@@ -0,0 +1,3 @@
+ALTER TABLE bookings DROP COLUMN status;
+ALTER TABLE bookings DROP COLUMN series_id;
+DROP TABLE booking_series;
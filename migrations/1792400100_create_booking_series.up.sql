@@ -0,0 +1,26 @@
+CREATE TABLE "booking_series"(
+    "id" SERIAL NOT NULL,
+    "farmer_id" BIGINT NOT NULL,
+    "machine_id" BIGINT NOT NULL,
+    "start_date" DATE NOT NULL,
+    "frequency" TEXT NOT NULL,
+    "interval" INTEGER NOT NULL,
+    "until" DATE,
+    "count" INTEGER,
+    "slots" BIGINT[] NOT NULL,
+    "status" TEXT NOT NULL DEFAULT 'active',
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "booking_series" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "booking_series" ADD CONSTRAINT "booking_series_farmer_id_foreign" FOREIGN KEY("farmer_id") REFERENCES "farmers"("id");
+ALTER TABLE
+    "booking_series" ADD CONSTRAINT "booking_series_machine_id_foreign" FOREIGN KEY("machine_id") REFERENCES "machines"("id");
+
+ALTER TABLE
+    "bookings" ADD COLUMN "series_id" BIGINT;
+ALTER TABLE
+    "bookings" ADD COLUMN "status" TEXT NOT NULL DEFAULT 'confirmed';
+ALTER TABLE
+    "bookings" ADD CONSTRAINT "bookings_series_id_foreign" FOREIGN KEY("series_id") REFERENCES "booking_series"("id");
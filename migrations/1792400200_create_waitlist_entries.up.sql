@@ -0,0 +1,22 @@
+CREATE TABLE "waitlist_entries"(
+    "id" SERIAL NOT NULL,
+    "farmer_id" BIGINT NOT NULL,
+    "machine_id" BIGINT NOT NULL,
+    "date" DATE NOT NULL,
+    "start_slot" BIGINT NOT NULL,
+    "end_slot" BIGINT NOT NULL,
+    "auto_book" BOOLEAN NOT NULL DEFAULT FALSE,
+    "status" TEXT NOT NULL DEFAULT 'waiting',
+    "booking_id" BIGINT,
+    "offer_expires_at" TIMESTAMP,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "waitlist_entries" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "waitlist_entries" ADD CONSTRAINT "waitlist_entries_farmer_id_foreign" FOREIGN KEY("farmer_id") REFERENCES "farmers"("id");
+ALTER TABLE
+    "waitlist_entries" ADD CONSTRAINT "waitlist_entries_machine_id_foreign" FOREIGN KEY("machine_id") REFERENCES "machines"("id");
+ALTER TABLE
+    "waitlist_entries" ADD CONSTRAINT "waitlist_entries_booking_id_foreign" FOREIGN KEY("booking_id") REFERENCES "bookings"("id");
+CREATE INDEX "waitlist_entries_machine_id_date_index" ON "waitlist_entries"("machine_id", "date");
@@ -0,0 +1,5 @@
+DROP INDEX slots_booked_date_index;
+DROP INDEX machines_category_index;
+ALTER TABLE machines DROP COLUMN longitude;
+ALTER TABLE machines DROP COLUMN latitude;
+ALTER TABLE machines DROP COLUMN category;
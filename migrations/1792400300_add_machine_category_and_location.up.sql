@@ -0,0 +1,8 @@
+ALTER TABLE
+    "machines" ADD COLUMN "category" TEXT NOT NULL DEFAULT 'other';
+ALTER TABLE
+    "machines" ADD COLUMN "latitude" DOUBLE PRECISION;
+ALTER TABLE
+    "machines" ADD COLUMN "longitude" DOUBLE PRECISION;
+CREATE INDEX "machines_category_index" ON "machines"("category");
+CREATE INDEX "slots_booked_date_index" ON "slots_booked"("date");
@@ -0,0 +1,6 @@
+ALTER TABLE invoices DROP COLUMN owner_id;
+ALTER TABLE invoices DROP COLUMN order_id;
+DELETE FROM invoices WHERE booking_id IS NULL;
+ALTER TABLE invoices ALTER COLUMN booking_id SET NOT NULL;
+ALTER TABLE bookings DROP COLUMN order_id;
+DROP TABLE orders;
@@ -0,0 +1,26 @@
+CREATE TABLE "orders"(
+    "id" SERIAL NOT NULL,
+    "farmer_id" BIGINT NOT NULL,
+    "total_amount" BIGINT NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "orders" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "orders" ADD CONSTRAINT "orders_farmer_id_foreign" FOREIGN KEY("farmer_id") REFERENCES "farmers"("id");
+
+ALTER TABLE
+    "bookings" ADD COLUMN "order_id" BIGINT;
+ALTER TABLE
+    "bookings" ADD CONSTRAINT "bookings_order_id_foreign" FOREIGN KEY("order_id") REFERENCES "orders"("id");
+
+ALTER TABLE
+    "invoices" ALTER COLUMN "booking_id" DROP NOT NULL;
+ALTER TABLE
+    "invoices" ADD COLUMN "order_id" BIGINT;
+ALTER TABLE
+    "invoices" ADD COLUMN "owner_id" BIGINT;
+ALTER TABLE
+    "invoices" ADD CONSTRAINT "invoices_order_id_foreign" FOREIGN KEY("order_id") REFERENCES "orders"("id");
+ALTER TABLE
+    "invoices" ADD CONSTRAINT "invoices_owner_id_foreign" FOREIGN KEY("owner_id") REFERENCES "farmers"("id");
@@ -0,0 +1,5 @@
+DROP TABLE invoice_line_items;
+DROP TABLE invoice_sequences;
+ALTER TABLE invoices DROP COLUMN number;
+ALTER TABLE invoices DROP COLUMN financial_year;
+ALTER TABLE invoices DROP COLUMN farmer_id;
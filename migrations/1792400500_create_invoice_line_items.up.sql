@@ -0,0 +1,77 @@
+ALTER TABLE
+    "invoices" ADD COLUMN "farmer_id" BIGINT;
+ALTER TABLE
+    "invoices" ADD COLUMN "financial_year" TEXT;
+ALTER TABLE
+    "invoices" ADD COLUMN "number" TEXT;
+ALTER TABLE
+    "invoices" ADD CONSTRAINT "invoices_farmer_id_foreign" FOREIGN KEY("farmer_id") REFERENCES "farmers"("id");
+
+CREATE TABLE "invoice_sequences"(
+    "financial_year" TEXT NOT NULL,
+    "last_number" BIGINT NOT NULL
+);
+ALTER TABLE
+    "invoice_sequences" ADD PRIMARY KEY("financial_year");
+
+CREATE TABLE "invoice_line_items"(
+    "id" SERIAL NOT NULL,
+    "invoice_id" BIGINT NOT NULL,
+    "booking_id" BIGINT,
+    "kind" TEXT NOT NULL,
+    "description" TEXT NOT NULL,
+    "quantity" BIGINT NOT NULL,
+    "unit_amount" BIGINT NOT NULL,
+    "amount" BIGINT NOT NULL
+);
+ALTER TABLE
+    "invoice_line_items" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "invoice_line_items" ADD CONSTRAINT "invoice_line_items_invoice_id_foreign" FOREIGN KEY("invoice_id") REFERENCES "invoices"("id");
+ALTER TABLE
+    "invoice_line_items" ADD CONSTRAINT "invoice_line_items_booking_id_foreign" FOREIGN KEY("booking_id") REFERENCES "bookings"("id");
+CREATE INDEX "invoice_line_items_invoice_id_index" ON "invoice_line_items"("invoice_id");
+
+-- parties of the existing invoices
+UPDATE invoices SET farmer_id = bookings.farmer_id, owner_id = machines.owner_id
+    FROM bookings, machines
+    WHERE invoices.booking_id = bookings.id and bookings.machine_id = machines.id;
+UPDATE invoices SET farmer_id = orders.farmer_id
+    FROM orders
+    WHERE invoices.order_id = orders.id;
+
+-- number the existing invoices in the order they were generated, per
+-- financial year (April to March)
+UPDATE invoices SET financial_year =
+    CASE WHEN EXTRACT(MONTH FROM date_generated) >= 4
+        THEN EXTRACT(YEAR FROM date_generated)::int || '-' || lpad(((EXTRACT(YEAR FROM date_generated)::int + 1) % 100)::text, 2, '0')
+        ELSE (EXTRACT(YEAR FROM date_generated)::int - 1) || '-' || lpad((EXTRACT(YEAR FROM date_generated)::int % 100)::text, 2, '0')
+    END;
+WITH numbered AS (
+    SELECT id, financial_year, ROW_NUMBER() OVER (PARTITION BY financial_year ORDER BY date_generated, id) AS n FROM invoices
+)
+UPDATE invoices SET number = 'FE/' || numbered.financial_year || '/' || lpad(numbered.n::text, 6, '0')
+    FROM numbered
+    WHERE invoices.id = numbered.id;
+INSERT INTO invoice_sequences (financial_year, last_number)
+    SELECT financial_year, COUNT(*) FROM invoices GROUP BY financial_year;
+
+-- one rental line per booking of the existing invoices
+INSERT INTO invoice_line_items (invoice_id, booking_id, kind, description, quantity, unit_amount, amount)
+    SELECT invoices.id, bookings.id, 'rental', machines.name, COUNT(slots_booked.id), machines.base_hourly_charge, COUNT(slots_booked.id) * machines.base_hourly_charge
+    FROM invoices
+    JOIN bookings ON bookings.id = invoices.booking_id or (bookings.order_id = invoices.order_id and invoices.booking_id IS NULL)
+    JOIN machines ON machines.id = bookings.machine_id and (invoices.owner_id IS NULL or machines.owner_id = invoices.owner_id)
+    JOIN slots_booked ON slots_booked.booking_id = bookings.id
+    GROUP BY invoices.id, bookings.id, machines.name, machines.base_hourly_charge;
+
+ALTER TABLE
+    "invoices" ALTER COLUMN "farmer_id" SET NOT NULL;
+ALTER TABLE
+    "invoices" ALTER COLUMN "financial_year" SET NOT NULL;
+ALTER TABLE
+    "invoices" ALTER COLUMN "number" SET NOT NULL;
+ALTER TABLE
+    "invoices" ADD CONSTRAINT "invoices_number_unique" UNIQUE("number");
+CREATE INDEX "invoices_farmer_id_index" ON "invoices"("farmer_id");
+CREATE INDEX "invoices_owner_id_index" ON "invoices"("owner_id");
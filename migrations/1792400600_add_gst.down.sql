@@ -0,0 +1,15 @@
+DELETE FROM invoice_line_items WHERE kind = 'tax';
+UPDATE invoices SET total_amount = taxable_value / 100;
+UPDATE invoice_line_items SET unit_amount = unit_amount / 100, amount = amount / 100;
+ALTER TABLE invoice_line_items DROP COLUMN tax_rate_bp;
+ALTER TABLE invoice_line_items DROP COLUMN hsn_sac;
+ALTER TABLE invoices DROP COLUMN igst;
+ALTER TABLE invoices DROP COLUMN sgst;
+ALTER TABLE invoices DROP COLUMN cgst;
+ALTER TABLE invoices DROP COLUMN taxable_value;
+ALTER TABLE invoices DROP COLUMN supply_type;
+ALTER TABLE invoices DROP COLUMN place_of_supply;
+ALTER TABLE invoices DROP COLUMN owner_gstin;
+DROP TABLE gst_rates;
+ALTER TABLE farmers DROP COLUMN gstin;
+ALTER TABLE farmers DROP COLUMN state;
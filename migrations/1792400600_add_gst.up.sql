@@ -0,0 +1,45 @@
+ALTER TABLE
+    "farmers" ADD COLUMN "state" TEXT;
+ALTER TABLE
+    "farmers" ADD COLUMN "gstin" TEXT;
+
+-- GST rate and HSN/SAC code per machine category; categories without a row
+-- are taxed as 'other'
+CREATE TABLE "gst_rates"(
+    "category" TEXT NOT NULL,
+    "hsn_sac" TEXT NOT NULL,
+    "rate_bp" BIGINT NOT NULL
+);
+ALTER TABLE
+    "gst_rates" ADD PRIMARY KEY("category");
+INSERT INTO gst_rates (category, hsn_sac, rate_bp) VALUES
+    ('other', '997314', 1800),
+    ('tractor', '997314', 1800),
+    ('harvester', '997314', 1800),
+    ('tiller', '997314', 1800),
+    ('sprayer', '997314', 1800);
+
+-- invoice amounts are now paise
+UPDATE invoices SET total_amount = total_amount * 100;
+UPDATE invoice_line_items SET unit_amount = unit_amount * 100, amount = amount * 100;
+
+ALTER TABLE
+    "invoices" ADD COLUMN "owner_gstin" TEXT;
+ALTER TABLE
+    "invoices" ADD COLUMN "place_of_supply" TEXT;
+ALTER TABLE
+    "invoices" ADD COLUMN "supply_type" TEXT NOT NULL DEFAULT 'intra_state';
+ALTER TABLE
+    "invoices" ADD COLUMN "taxable_value" BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE
+    "invoices" ADD COLUMN "cgst" BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE
+    "invoices" ADD COLUMN "sgst" BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE
+    "invoices" ADD COLUMN "igst" BIGINT NOT NULL DEFAULT 0;
+UPDATE invoices SET taxable_value = total_amount;
+
+ALTER TABLE
+    "invoice_line_items" ADD COLUMN "hsn_sac" TEXT;
+ALTER TABLE
+    "invoice_line_items" ADD COLUMN "tax_rate_bp" BIGINT NOT NULL DEFAULT 0;
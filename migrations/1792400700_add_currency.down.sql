@@ -0,0 +1,5 @@
+ALTER TABLE invoices DROP COLUMN currency;
+ALTER TABLE orders DROP COLUMN currency;
+UPDATE orders SET total_amount = total_amount / 100;
+ALTER TABLE machines DROP COLUMN currency;
+UPDATE machines SET base_hourly_charge = base_hourly_charge / 100;
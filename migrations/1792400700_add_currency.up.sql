@@ -0,0 +1,12 @@
+-- machine charges and order totals were whole rupees, they are now minor
+-- units of their currency like invoice amounts
+UPDATE machines SET base_hourly_charge = base_hourly_charge * 100;
+ALTER TABLE
+    "machines" ADD COLUMN "currency" TEXT NOT NULL DEFAULT 'INR';
+
+UPDATE orders SET total_amount = total_amount * 100;
+ALTER TABLE
+    "orders" ADD COLUMN "currency" TEXT NOT NULL DEFAULT 'INR';
+
+ALTER TABLE
+    "invoices" ADD COLUMN "currency" TEXT NOT NULL DEFAULT 'INR';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "payments";
+
+ALTER TABLE
+    "invoices" DROP COLUMN IF EXISTS "status";
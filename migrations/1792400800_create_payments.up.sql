@@ -0,0 +1,26 @@
+ALTER TABLE
+    "invoices" ADD COLUMN "status" TEXT NOT NULL DEFAULT 'unpaid';
+
+CREATE TABLE "payments"(
+    "id" SERIAL NOT NULL,
+    "invoice_id" BIGINT NOT NULL,
+    "farmer_id" BIGINT NOT NULL,
+    "provider" TEXT NOT NULL,
+    "provider_order_id" TEXT NOT NULL,
+    "provider_payment_id" TEXT,
+    "provider_refund_id" TEXT,
+    "amount" BIGINT NOT NULL,
+    "currency" TEXT NOT NULL,
+    "status" TEXT NOT NULL DEFAULT 'created',
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "updated_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "payments" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "payments" ADD CONSTRAINT "payments_invoice_id_foreign" FOREIGN KEY("invoice_id") REFERENCES "invoices"("id");
+ALTER TABLE
+    "payments" ADD CONSTRAINT "payments_farmer_id_foreign" FOREIGN KEY("farmer_id") REFERENCES "farmers"("id");
+ALTER TABLE
+    "payments" ADD CONSTRAINT "payments_provider_order_id_unique" UNIQUE("provider", "provider_order_id");
+CREATE INDEX "payments_invoice_id_index" ON "payments"("invoice_id");
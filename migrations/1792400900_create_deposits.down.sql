@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "deposit_ledger_entries";
+DROP TABLE IF EXISTS "deposits";
+
+ALTER TABLE
+    "machines" DROP COLUMN IF EXISTS "security_deposit";
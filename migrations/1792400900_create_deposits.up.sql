@@ -0,0 +1,40 @@
+-- in minor units of the machine's currency, 0 when the machine takes no deposit
+ALTER TABLE
+    "machines" ADD COLUMN "security_deposit" BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE "deposits"(
+    "id" SERIAL NOT NULL,
+    "booking_id" BIGINT NOT NULL,
+    "farmer_id" BIGINT NOT NULL,
+    "owner_id" BIGINT NOT NULL,
+    "amount" BIGINT NOT NULL,
+    "captured" BIGINT NOT NULL DEFAULT 0,
+    "currency" TEXT NOT NULL,
+    "status" TEXT NOT NULL DEFAULT 'held',
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "updated_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "deposits" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "deposits" ADD CONSTRAINT "deposits_booking_id_unique" UNIQUE("booking_id");
+ALTER TABLE
+    "deposits" ADD CONSTRAINT "deposits_booking_id_foreign" FOREIGN KEY("booking_id") REFERENCES "bookings"("id");
+ALTER TABLE
+    "deposits" ADD CONSTRAINT "deposits_farmer_id_foreign" FOREIGN KEY("farmer_id") REFERENCES "farmers"("id");
+ALTER TABLE
+    "deposits" ADD CONSTRAINT "deposits_owner_id_foreign" FOREIGN KEY("owner_id") REFERENCES "farmers"("id");
+
+CREATE TABLE "deposit_ledger_entries"(
+    "id" SERIAL NOT NULL,
+    "deposit_id" BIGINT NOT NULL,
+    "kind" TEXT NOT NULL,
+    "amount" BIGINT NOT NULL,
+    "note" TEXT NOT NULL DEFAULT '',
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "deposit_ledger_entries" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "deposit_ledger_entries" ADD CONSTRAINT "deposit_ledger_entries_deposit_id_foreign" FOREIGN KEY("deposit_id") REFERENCES "deposits"("id");
+CREATE INDEX "deposit_ledger_entries_deposit_id_index" ON "deposit_ledger_entries"("deposit_id");
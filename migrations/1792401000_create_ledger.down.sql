@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "payouts";
+DROP TABLE IF EXISTS "payout_batches";
+DROP TABLE IF EXISTS "ledger_entries";
+DROP FUNCTION IF EXISTS ledger_transaction_balanced();
+DROP TABLE IF EXISTS "ledger_transactions";
@@ -0,0 +1,75 @@
+CREATE TABLE "ledger_transactions"(
+    "id" SERIAL NOT NULL,
+    "kind" TEXT NOT NULL,
+    "reference" TEXT NOT NULL,
+    "description" TEXT NOT NULL DEFAULT '',
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "ledger_transactions" ADD PRIMARY KEY("id");
+CREATE INDEX "ledger_transactions_reference_index" ON "ledger_transactions"("reference");
+
+-- debits are positive and credits negative
+CREATE TABLE "ledger_entries"(
+    "id" SERIAL NOT NULL,
+    "transaction_id" BIGINT NOT NULL,
+    "account" TEXT NOT NULL,
+    "farmer_id" BIGINT,
+    "amount" BIGINT NOT NULL,
+    "currency" TEXT NOT NULL
+);
+ALTER TABLE
+    "ledger_entries" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "ledger_entries" ADD CONSTRAINT "ledger_entries_transaction_id_foreign" FOREIGN KEY("transaction_id") REFERENCES "ledger_transactions"("id");
+ALTER TABLE
+    "ledger_entries" ADD CONSTRAINT "ledger_entries_farmer_id_foreign" FOREIGN KEY("farmer_id") REFERENCES "farmers"("id");
+ALTER TABLE
+    "ledger_entries" ADD CONSTRAINT "ledger_entries_amount_check" CHECK("amount" <> 0);
+CREATE INDEX "ledger_entries_transaction_id_index" ON "ledger_entries"("transaction_id");
+CREATE INDEX "ledger_entries_account_farmer_id_index" ON "ledger_entries"("account", "farmer_id");
+
+-- every transaction has to balance in every currency by the time the
+-- database transaction that wrote it commits
+CREATE FUNCTION ledger_transaction_balanced() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM ledger_entries
+        WHERE transaction_id = NEW.transaction_id
+        GROUP BY currency
+        HAVING SUM(amount) <> 0
+    ) THEN
+        RAISE EXCEPTION 'ledger transaction % does not balance', NEW.transaction_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER "ledger_entries_balanced"
+    AFTER INSERT OR UPDATE ON "ledger_entries"
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE PROCEDURE ledger_transaction_balanced();
+
+CREATE TABLE "payout_batches"(
+    "id" SERIAL NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "payout_batches" ADD PRIMARY KEY("id");
+
+CREATE TABLE "payouts"(
+    "id" SERIAL NOT NULL,
+    "batch_id" BIGINT NOT NULL,
+    "owner_id" BIGINT NOT NULL,
+    "amount" BIGINT NOT NULL,
+    "currency" TEXT NOT NULL,
+    "status" TEXT NOT NULL DEFAULT 'pending',
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "payouts" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "payouts" ADD CONSTRAINT "payouts_batch_id_foreign" FOREIGN KEY("batch_id") REFERENCES "payout_batches"("id");
+ALTER TABLE
+    "payouts" ADD CONSTRAINT "payouts_owner_id_foreign" FOREIGN KEY("owner_id") REFERENCES "farmers"("id");
+CREATE INDEX "payouts_owner_id_index" ON "payouts"("owner_id");
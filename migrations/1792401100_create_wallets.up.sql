@@ -0,0 +1,50 @@
+CREATE TABLE "wallets"(
+    "farmer_id" BIGINT NOT NULL,
+    "balance" BIGINT NOT NULL DEFAULT 0,
+    "currency" TEXT NOT NULL,
+    "updated_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "wallets" ADD PRIMARY KEY("farmer_id");
+ALTER TABLE
+    "wallets" ADD CONSTRAINT "wallets_farmer_id_foreign" FOREIGN KEY("farmer_id") REFERENCES "farmers"("id");
+ALTER TABLE
+    "wallets" ADD CONSTRAINT "wallets_balance_check" CHECK("balance" >= 0);
+
+-- amount is signed, balance is the wallet balance after the transaction
+CREATE TABLE "wallet_transactions"(
+    "id" SERIAL NOT NULL,
+    "farmer_id" BIGINT NOT NULL,
+    "kind" TEXT NOT NULL,
+    "amount" BIGINT NOT NULL,
+    "balance" BIGINT NOT NULL,
+    "currency" TEXT NOT NULL,
+    "reference" TEXT NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "wallet_transactions" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "wallet_transactions" ADD CONSTRAINT "wallet_transactions_farmer_id_foreign" FOREIGN KEY("farmer_id") REFERENCES "wallets"("farmer_id");
+CREATE INDEX "wallet_transactions_farmer_id_index" ON "wallet_transactions"("farmer_id");
+
+CREATE TABLE "wallet_topups"(
+    "id" SERIAL NOT NULL,
+    "farmer_id" BIGINT NOT NULL,
+    "provider" TEXT NOT NULL,
+    "provider_order_id" TEXT NOT NULL,
+    "provider_payment_id" TEXT,
+    "amount" BIGINT NOT NULL,
+    "currency" TEXT NOT NULL,
+    "status" TEXT NOT NULL DEFAULT 'created',
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "updated_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "wallet_topups" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "wallet_topups" ADD CONSTRAINT "wallet_topups_farmer_id_foreign" FOREIGN KEY("farmer_id") REFERENCES "farmers"("id");
+ALTER TABLE
+    "wallet_topups" ADD CONSTRAINT "wallet_topups_provider_order_id_unique" UNIQUE("provider", "provider_order_id");
+ALTER TABLE
+    "wallet_topups" ADD CONSTRAINT "wallet_topups_amount_check" CHECK("amount" > 0);
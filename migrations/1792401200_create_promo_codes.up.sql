@@ -0,0 +1,51 @@
+-- empty categories or owner_ids mean the code applies to any machine, zero
+-- caps mean no limit
+CREATE TABLE "promo_codes"(
+    "id" SERIAL NOT NULL,
+    "code" TEXT NOT NULL,
+    "description" TEXT NOT NULL DEFAULT '',
+    "kind" TEXT NOT NULL,
+    "percent_bp" INTEGER NOT NULL DEFAULT 0,
+    "amount" BIGINT NOT NULL DEFAULT 0,
+    "currency" TEXT NOT NULL,
+    "valid_from" TIMESTAMP NOT NULL,
+    "valid_until" TIMESTAMP NOT NULL,
+    "max_redemptions" INTEGER NOT NULL DEFAULT 0,
+    "max_per_farmer" INTEGER NOT NULL DEFAULT 0,
+    "categories" TEXT[] NOT NULL DEFAULT '{}',
+    "owner_ids" BIGINT[] NOT NULL DEFAULT '{}',
+    "active" BOOLEAN NOT NULL DEFAULT TRUE,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "promo_codes" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "promo_codes" ADD CONSTRAINT "promo_codes_code_unique" UNIQUE("code");
+ALTER TABLE
+    "promo_codes" ADD CONSTRAINT "promo_codes_kind_check" CHECK(
+        ("kind" = 'percent' AND "percent_bp" BETWEEN 1 AND 10000) OR ("kind" = 'flat' AND "amount" > 0)
+    );
+ALTER TABLE
+    "promo_codes" ADD CONSTRAINT "promo_codes_validity_check" CHECK("valid_from" < "valid_until");
+
+CREATE TABLE "promo_redemptions"(
+    "id" SERIAL NOT NULL,
+    "promo_code_id" BIGINT NOT NULL,
+    "farmer_id" BIGINT NOT NULL,
+    "booking_id" BIGINT,
+    "order_id" BIGINT,
+    "discount" BIGINT NOT NULL,
+    "currency" TEXT NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "promo_redemptions" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "promo_redemptions" ADD CONSTRAINT "promo_redemptions_promo_code_id_foreign" FOREIGN KEY("promo_code_id") REFERENCES "promo_codes"("id");
+ALTER TABLE
+    "promo_redemptions" ADD CONSTRAINT "promo_redemptions_farmer_id_foreign" FOREIGN KEY("farmer_id") REFERENCES "farmers"("id");
+ALTER TABLE
+    "promo_redemptions" ADD CONSTRAINT "promo_redemptions_booking_id_foreign" FOREIGN KEY("booking_id") REFERENCES "bookings"("id");
+ALTER TABLE
+    "promo_redemptions" ADD CONSTRAINT "promo_redemptions_order_id_foreign" FOREIGN KEY("order_id") REFERENCES "orders"("id");
+CREATE INDEX "promo_redemptions_promo_code_id_farmer_id_index" ON "promo_redemptions"("promo_code_id", "farmer_id");
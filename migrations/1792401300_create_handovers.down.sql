@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "handovers";
+ALTER TABLE
+    "machines" DROP COLUMN IF EXISTS "usage_billing";
@@ -0,0 +1,33 @@
+-- bill the hours on the engine meter rather than the hours booked
+ALTER TABLE
+    "machines" ADD COLUMN "usage_billing" BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE "handovers"(
+    "id" SERIAL NOT NULL,
+    "booking_id" BIGINT NOT NULL,
+    "kind" TEXT NOT NULL,
+    "recorded_by" BIGINT NOT NULL,
+    -- engine hours, to a tenth of an hour
+    "meter_reading" NUMERIC(10, 1) NOT NULL,
+    -- percent of a full tank
+    "fuel_level" INTEGER NOT NULL,
+    "checklist" JSONB NOT NULL DEFAULT '[]',
+    "photos" TEXT[] NOT NULL DEFAULT '{}',
+    "notes" TEXT NOT NULL DEFAULT '',
+    "renter_confirmed_at" TIMESTAMP,
+    "owner_confirmed_at" TIMESTAMP,
+    "settled_at" TIMESTAMP,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "handovers" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "handovers" ADD CONSTRAINT "handovers_kind_check" CHECK("kind" IN ('check_in', 'check_out'));
+ALTER TABLE
+    "handovers" ADD CONSTRAINT "handovers_fuel_level_check" CHECK("fuel_level" BETWEEN 0 AND 100);
+ALTER TABLE
+    "handovers" ADD CONSTRAINT "handovers_booking_id_kind_unique" UNIQUE("booking_id", "kind");
+ALTER TABLE
+    "handovers" ADD CONSTRAINT "handovers_booking_id_foreign" FOREIGN KEY("booking_id") REFERENCES "bookings"("id");
+ALTER TABLE
+    "handovers" ADD CONSTRAINT "handovers_recorded_by_foreign" FOREIGN KEY("recorded_by") REFERENCES "farmers"("id");
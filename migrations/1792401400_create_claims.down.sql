@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "claim_events";
+DROP TABLE IF EXISTS "claims";
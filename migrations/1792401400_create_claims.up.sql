@@ -0,0 +1,54 @@
+CREATE TABLE "claims"(
+    "id" SERIAL NOT NULL,
+    "booking_id" BIGINT NOT NULL,
+    "owner_id" BIGINT NOT NULL,
+    "farmer_id" BIGINT NOT NULL,
+    "amount" BIGINT NOT NULL,
+    "currency" TEXT NOT NULL,
+    "description" TEXT NOT NULL,
+    "evidence" TEXT[] NOT NULL DEFAULT '{}',
+    "status" TEXT NOT NULL DEFAULT 'open',
+    "response" TEXT NOT NULL DEFAULT '',
+    "response_evidence" TEXT[] NOT NULL DEFAULT '{}',
+    "respond_by" TIMESTAMP NOT NULL,
+    -- what was approved, and how much of it the deposit covered; the rest is
+    -- billed on invoice_id
+    "approved" BIGINT NOT NULL DEFAULT 0,
+    "from_deposit" BIGINT NOT NULL DEFAULT 0,
+    "invoice_id" BIGINT,
+    "decision_note" TEXT NOT NULL DEFAULT '',
+    "decided_by" BIGINT,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "updated_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "claims" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "claims" ADD CONSTRAINT "claims_status_check" CHECK("status" IN ('open', 'disputed', 'escalated', 'approved', 'rejected'));
+ALTER TABLE
+    "claims" ADD CONSTRAINT "claims_booking_id_unique" UNIQUE("booking_id");
+ALTER TABLE
+    "claims" ADD CONSTRAINT "claims_booking_id_foreign" FOREIGN KEY("booking_id") REFERENCES "bookings"("id");
+ALTER TABLE
+    "claims" ADD CONSTRAINT "claims_owner_id_foreign" FOREIGN KEY("owner_id") REFERENCES "farmers"("id");
+ALTER TABLE
+    "claims" ADD CONSTRAINT "claims_farmer_id_foreign" FOREIGN KEY("farmer_id") REFERENCES "farmers"("id");
+ALTER TABLE
+    "claims" ADD CONSTRAINT "claims_invoice_id_foreign" FOREIGN KEY("invoice_id") REFERENCES "invoices"("id");
+CREATE INDEX "claims_status_respond_by_index" ON "claims"("status", "respond_by");
+
+-- every change of a claim's status, and who made it; actor_id is NULL for
+-- automatic escalation
+CREATE TABLE "claim_events"(
+    "id" SERIAL NOT NULL,
+    "claim_id" BIGINT NOT NULL,
+    "status" TEXT NOT NULL,
+    "actor_id" BIGINT,
+    "note" TEXT NOT NULL DEFAULT '',
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "claim_events" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "claim_events" ADD CONSTRAINT "claim_events_claim_id_foreign" FOREIGN KEY("claim_id") REFERENCES "claims"("id");
+CREATE INDEX "claim_events_claim_id_index" ON "claim_events"("claim_id");
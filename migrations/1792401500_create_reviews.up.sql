@@ -0,0 +1,55 @@
+-- a review is left by one party of a completed booking about the other; role
+-- is the reviewer's, and a renter's review also rates the machine
+CREATE TABLE "reviews"(
+    "id" SERIAL NOT NULL,
+    "booking_id" BIGINT NOT NULL,
+    "machine_id" BIGINT NOT NULL,
+    "reviewer_id" BIGINT NOT NULL,
+    "reviewee_id" BIGINT NOT NULL,
+    "role" TEXT NOT NULL,
+    "rating" SMALLINT NOT NULL,
+    "comment" TEXT NOT NULL DEFAULT '',
+    "hidden" BOOLEAN NOT NULL DEFAULT FALSE,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "reviews" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "reviews" ADD CONSTRAINT "reviews_role_check" CHECK("role" IN ('renter', 'owner'));
+ALTER TABLE
+    "reviews" ADD CONSTRAINT "reviews_rating_check" CHECK("rating" BETWEEN 1 AND 5);
+ALTER TABLE
+    "reviews" ADD CONSTRAINT "reviews_booking_id_reviewer_id_unique" UNIQUE("booking_id", "reviewer_id");
+ALTER TABLE
+    "reviews" ADD CONSTRAINT "reviews_booking_id_foreign" FOREIGN KEY("booking_id") REFERENCES "bookings"("id");
+ALTER TABLE
+    "reviews" ADD CONSTRAINT "reviews_machine_id_foreign" FOREIGN KEY("machine_id") REFERENCES "machines"("id");
+ALTER TABLE
+    "reviews" ADD CONSTRAINT "reviews_reviewer_id_foreign" FOREIGN KEY("reviewer_id") REFERENCES "farmers"("id");
+ALTER TABLE
+    "reviews" ADD CONSTRAINT "reviews_reviewee_id_foreign" FOREIGN KEY("reviewee_id") REFERENCES "farmers"("id");
+CREATE INDEX "reviews_machine_id_index" ON "reviews"("machine_id") WHERE "role" = 'renter' AND NOT "hidden";
+CREATE INDEX "reviews_reviewee_id_index" ON "reviews"("reviewee_id") WHERE NOT "hidden";
+
+CREATE TABLE "review_reports"(
+    "id" SERIAL NOT NULL,
+    "review_id" BIGINT NOT NULL,
+    "reporter_id" BIGINT NOT NULL,
+    "reason" TEXT NOT NULL,
+    "status" TEXT NOT NULL DEFAULT 'open',
+    "resolution_note" TEXT NOT NULL DEFAULT '',
+    "resolved_by" BIGINT,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "resolved_at" TIMESTAMP
+);
+ALTER TABLE
+    "review_reports" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "review_reports" ADD CONSTRAINT "review_reports_status_check" CHECK("status" IN ('open', 'dismissed', 'upheld'));
+ALTER TABLE
+    "review_reports" ADD CONSTRAINT "review_reports_review_id_reporter_id_unique" UNIQUE("review_id", "reporter_id");
+ALTER TABLE
+    "review_reports" ADD CONSTRAINT "review_reports_review_id_foreign" FOREIGN KEY("review_id") REFERENCES "reviews"("id");
+ALTER TABLE
+    "review_reports" ADD CONSTRAINT "review_reports_reporter_id_foreign" FOREIGN KEY("reporter_id") REFERENCES "farmers"("id");
+CREATE INDEX "review_reports_status_index" ON "review_reports"("status");
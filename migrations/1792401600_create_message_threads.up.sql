@@ -0,0 +1,43 @@
+-- a thread is a renter's enquiry about a machine, or the conversation of a
+-- booking; the read ids are the last message each party has seen
+CREATE TABLE "threads"(
+    "id" SERIAL NOT NULL,
+    "machine_id" BIGINT NOT NULL,
+    "booking_id" BIGINT,
+    "renter_id" BIGINT NOT NULL,
+    "owner_id" BIGINT NOT NULL,
+    "renter_read_id" BIGINT NOT NULL DEFAULT 0,
+    "owner_read_id" BIGINT NOT NULL DEFAULT 0,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "updated_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "threads" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "threads" ADD CONSTRAINT "threads_booking_id_unique" UNIQUE("booking_id");
+ALTER TABLE
+    "threads" ADD CONSTRAINT "threads_machine_id_foreign" FOREIGN KEY("machine_id") REFERENCES "machines"("id");
+ALTER TABLE
+    "threads" ADD CONSTRAINT "threads_booking_id_foreign" FOREIGN KEY("booking_id") REFERENCES "bookings"("id");
+ALTER TABLE
+    "threads" ADD CONSTRAINT "threads_renter_id_foreign" FOREIGN KEY("renter_id") REFERENCES "farmers"("id");
+ALTER TABLE
+    "threads" ADD CONSTRAINT "threads_owner_id_foreign" FOREIGN KEY("owner_id") REFERENCES "farmers"("id");
+CREATE UNIQUE INDEX "threads_enquiry_unique" ON "threads"("machine_id", "renter_id") WHERE "booking_id" IS NULL;
+CREATE INDEX "threads_renter_id_index" ON "threads"("renter_id");
+CREATE INDEX "threads_owner_id_index" ON "threads"("owner_id");
+
+CREATE TABLE "messages"(
+    "id" SERIAL NOT NULL,
+    "thread_id" BIGINT NOT NULL,
+    "sender_id" BIGINT NOT NULL,
+    "body" TEXT NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "messages" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "messages" ADD CONSTRAINT "messages_thread_id_foreign" FOREIGN KEY("thread_id") REFERENCES "threads"("id");
+ALTER TABLE
+    "messages" ADD CONSTRAINT "messages_sender_id_foreign" FOREIGN KEY("sender_id") REFERENCES "farmers"("id");
+CREATE INDEX "messages_thread_id_id_index" ON "messages"("thread_id", "id");
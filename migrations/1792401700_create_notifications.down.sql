@@ -0,0 +1,5 @@
+ALTER TABLE
+    "bookings" DROP COLUMN IF EXISTS "reminded_at";
+DROP TABLE IF EXISTS "notification_preferences";
+DROP TABLE IF EXISTS "notifications";
+DROP TABLE IF EXISTS "outbox_events";
@@ -0,0 +1,58 @@
+-- events are written in the transaction of the change they describe and fanned
+-- out to a delivery per channel afterwards, so none is lost on a crash
+CREATE TABLE "outbox_events"(
+    "id" SERIAL NOT NULL,
+    "kind" TEXT NOT NULL,
+    "farmer_id" BIGINT NOT NULL,
+    "reference" BIGINT NOT NULL DEFAULT 0,
+    "message" TEXT NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "dispatched_at" TIMESTAMP
+);
+ALTER TABLE
+    "outbox_events" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "outbox_events" ADD CONSTRAINT "outbox_events_farmer_id_foreign" FOREIGN KEY("farmer_id") REFERENCES "farmers"("id");
+CREATE INDEX "outbox_events_undispatched_index" ON "outbox_events"("id") WHERE "dispatched_at" IS NULL;
+
+CREATE TABLE "notifications"(
+    "id" SERIAL NOT NULL,
+    "event_id" BIGINT NOT NULL,
+    "farmer_id" BIGINT NOT NULL,
+    "channel" TEXT NOT NULL,
+    "kind" TEXT NOT NULL,
+    "message" TEXT NOT NULL,
+    "status" TEXT NOT NULL DEFAULT 'pending',
+    "attempts" INTEGER NOT NULL DEFAULT 0,
+    "next_attempt_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "last_error" TEXT NOT NULL DEFAULT '',
+    "sent_at" TIMESTAMP,
+    "read_at" TIMESTAMP,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "notifications" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "notifications" ADD CONSTRAINT "notifications_status_check" CHECK("status" IN ('pending', 'sent', 'failed'));
+ALTER TABLE
+    "notifications" ADD CONSTRAINT "notifications_event_id_channel_unique" UNIQUE("event_id", "channel");
+ALTER TABLE
+    "notifications" ADD CONSTRAINT "notifications_event_id_foreign" FOREIGN KEY("event_id") REFERENCES "outbox_events"("id");
+ALTER TABLE
+    "notifications" ADD CONSTRAINT "notifications_farmer_id_foreign" FOREIGN KEY("farmer_id") REFERENCES "farmers"("id");
+CREATE INDEX "notifications_pending_index" ON "notifications"("next_attempt_at") WHERE "status" = 'pending';
+CREATE INDEX "notifications_farmer_id_channel_index" ON "notifications"("farmer_id", "channel");
+
+-- a farmer without a row for a channel gets the configured default
+CREATE TABLE "notification_preferences"(
+    "farmer_id" BIGINT NOT NULL,
+    "channel" TEXT NOT NULL,
+    "enabled" BOOLEAN NOT NULL
+);
+ALTER TABLE
+    "notification_preferences" ADD PRIMARY KEY("farmer_id", "channel");
+ALTER TABLE
+    "notification_preferences" ADD CONSTRAINT "notification_preferences_farmer_id_foreign" FOREIGN KEY("farmer_id") REFERENCES "farmers"("id");
+
+ALTER TABLE
+    "bookings" ADD COLUMN "reminded_at" TIMESTAMP;
@@ -0,0 +1,62 @@
+-- events carry their details for partners; events without a message are only
+-- sent to webhooks, not to the farmer
+ALTER TABLE
+    "outbox_events" ADD COLUMN "data" JSONB NOT NULL DEFAULT '{}';
+
+-- an api client is a partner application acting for a farmer, such as a
+-- cooperative's own app; its secret signs the webhooks it receives
+CREATE TABLE "api_clients"(
+    "id" SERIAL NOT NULL,
+    "farmer_id" BIGINT NOT NULL,
+    "name" TEXT NOT NULL,
+    "secret" TEXT NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "api_clients" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "api_clients" ADD CONSTRAINT "api_clients_farmer_id_foreign" FOREIGN KEY("farmer_id") REFERENCES "farmers"("id");
+CREATE INDEX "api_clients_farmer_id_index" ON "api_clients"("farmer_id");
+
+CREATE TABLE "webhook_subscriptions"(
+    "id" SERIAL NOT NULL,
+    "client_id" BIGINT NOT NULL,
+    "url" TEXT NOT NULL,
+    "events" TEXT[] NOT NULL,
+    "active" BOOLEAN NOT NULL DEFAULT TRUE,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "webhook_subscriptions" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "webhook_subscriptions" ADD CONSTRAINT "webhook_subscriptions_client_id_foreign" FOREIGN KEY("client_id") REFERENCES "api_clients"("id");
+CREATE INDEX "webhook_subscriptions_client_id_index" ON "webhook_subscriptions"("client_id");
+
+-- the delivery log; a replay is a new delivery of the same payload
+CREATE TABLE "webhook_deliveries"(
+    "id" SERIAL NOT NULL,
+    "subscription_id" BIGINT NOT NULL,
+    "event_id" BIGINT NOT NULL,
+    "kind" TEXT NOT NULL,
+    "payload" JSONB NOT NULL,
+    "replay_of" BIGINT,
+    "status" TEXT NOT NULL DEFAULT 'pending',
+    "attempts" INTEGER NOT NULL DEFAULT 0,
+    "next_attempt_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "response_status" INTEGER NOT NULL DEFAULT 0,
+    "last_error" TEXT NOT NULL DEFAULT '',
+    "delivered_at" TIMESTAMP,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE
+    "webhook_deliveries" ADD PRIMARY KEY("id");
+ALTER TABLE
+    "webhook_deliveries" ADD CONSTRAINT "webhook_deliveries_status_check" CHECK("status" IN ('pending', 'delivered', 'failed'));
+ALTER TABLE
+    "webhook_deliveries" ADD CONSTRAINT "webhook_deliveries_subscription_id_foreign" FOREIGN KEY("subscription_id") REFERENCES "webhook_subscriptions"("id");
+ALTER TABLE
+    "webhook_deliveries" ADD CONSTRAINT "webhook_deliveries_event_id_foreign" FOREIGN KEY("event_id") REFERENCES "outbox_events"("id");
+ALTER TABLE
+    "webhook_deliveries" ADD CONSTRAINT "webhook_deliveries_replay_of_foreign" FOREIGN KEY("replay_of") REFERENCES "webhook_deliveries"("id");
+CREATE INDEX "webhook_deliveries_pending_index" ON "webhook_deliveries"("next_attempt_at") WHERE "status" = 'pending';
+CREATE INDEX "webhook_deliveries_subscription_id_index" ON "webhook_deliveries"("subscription_id", "id");
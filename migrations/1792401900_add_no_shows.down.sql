@@ -0,0 +1,6 @@
+ALTER TABLE
+    "bookings" DROP COLUMN IF EXISTS "no_show_at";
+ALTER TABLE
+    "machines" DROP CONSTRAINT IF EXISTS "machines_no_show_policy_check";
+ALTER TABLE
+    "machines" DROP COLUMN IF EXISTS "no_show_policy";
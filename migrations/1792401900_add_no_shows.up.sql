@@ -0,0 +1,13 @@
+-- what happens when a renter does not show up: keep_payment keeps the rental
+-- paid and releases the deposit, forfeit_deposit also keeps the deposit, and
+-- refund treats the booking as cancelled
+ALTER TABLE
+    "machines" ADD COLUMN "no_show_policy" TEXT NOT NULL DEFAULT 'keep_payment';
+ALTER TABLE
+    "machines" ADD CONSTRAINT "machines_no_show_policy_check" CHECK("no_show_policy" IN ('keep_payment', 'forfeit_deposit', 'refund'));
+
+-- when the renter of a booking did not check in in time; the booking is then
+-- cancelled under the refund policy and a no_show otherwise, which releases
+-- its slots all the same
+ALTER TABLE
+    "bookings" ADD COLUMN "no_show_at" TIMESTAMP;
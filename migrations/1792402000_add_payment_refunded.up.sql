@@ -0,0 +1,7 @@
+-- how much of a payment was given back so far: cancelling one booking of a
+-- wallet-paid invoice gives back its share while the payment stays captured,
+-- and refunding the payment gives back the rest
+ALTER TABLE
+    "payments" ADD COLUMN "refunded" BIGINT NOT NULL DEFAULT 0;
+UPDATE
+    "payments" SET "refunded" = "amount" WHERE "status" = 'refunded';
@@ -35,6 +35,71 @@ func (_m *Service) AddMachine(_a0 context.Context, _a1 domain.NewMachineRequest)
 	return r0, r1
 }
 
+// AddPromoCode provides a mock function with given fields: _a0, _a1
+func (_m *Service) AddPromoCode(_a0 context.Context, _a1 domain.NewPromoCodeRequest) (domain.PromoCode, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.PromoCode
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NewPromoCodeRequest) domain.PromoCode); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.PromoCode)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.NewPromoCodeRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AddWebhook provides a mock function with given fields: _a0, _a1
+func (_m *Service) AddWebhook(_a0 context.Context, _a1 domain.NewWebhookRequest) (domain.WebhookSubscription, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.WebhookSubscription
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NewWebhookRequest) domain.WebhookSubscription); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.WebhookSubscription)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.NewWebhookRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BeginIdempotentRequest provides a mock function with given fields: _a0, _a1
+func (_m *Service) BeginIdempotentRequest(_a0 context.Context, _a1 domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *domain.IdempotencyRecord
+	if rf, ok := ret.Get(0).(func(context.Context, domain.IdempotencyRecord) *domain.IdempotencyRecord); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.IdempotencyRecord)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.IdempotencyRecord) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // BookMachine provides a mock function with given fields: _a0, _a1
 func (_m *Service) BookMachine(_a0 context.Context, _a1 domain.NewBookingRequest) (domain.NewBookingResponse, error) {
 	ret := _m.Called(_a0, _a1)
@@ -56,19 +121,222 @@ func (_m *Service) BookMachine(_a0 context.Context, _a1 domain.NewBookingRequest
 	return r0, r1
 }
 
-// GetAllBookings provides a mock function with given fields: _a0, _a1
-func (_m *Service) GetAllBookings(_a0 context.Context, _a1 uint) ([]domain.BookingResponse, error) {
+// BookRecurring provides a mock function with given fields: _a0, _a1
+func (_m *Service) BookRecurring(_a0 context.Context, _a1 domain.NewRecurringBookingRequest) (domain.RecurringBookingResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 []domain.BookingResponse
-	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.BookingResponse); ok {
+	var r0 domain.RecurringBookingResponse
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NewRecurringBookingRequest) domain.RecurringBookingResponse); ok {
 		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.RecurringBookingResponse)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.NewRecurringBookingRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CancelBooking provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Service) CancelBooking(_a0 context.Context, _a1 uint, _a2 uint) (domain.CancelledBooking, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.CancelledBooking
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) domain.CancelledBooking); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.CancelledBooking)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CancelBookingSeries provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Service) CancelBookingSeries(_a0 context.Context, _a1 uint, _a2 uint) ([]domain.CancelledBooking, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []domain.CancelledBooking
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) []domain.CancelledBooking); ok {
+		r0 = rf(_a0, _a1, _a2)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]domain.BookingResponse)
+			r0 = ret.Get(0).([]domain.CancelledBooking)
 		}
 	}
 
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CaptureDeposit provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Service) CaptureDeposit(_a0 context.Context, _a1 uint, _a2 uint, _a3 domain.DepositCaptureRequest) (domain.Deposit, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 domain.Deposit
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint, domain.DepositCaptureRequest) domain.Deposit); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Get(0).(domain.Deposit)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint, domain.DepositCaptureRequest) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Checkout provides a mock function with given fields: _a0, _a1
+func (_m *Service) Checkout(_a0 context.Context, _a1 domain.NewOrderRequest) (domain.OrderResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.OrderResponse
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NewOrderRequest) domain.OrderResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.OrderResponse)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.NewOrderRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CompleteIdempotentRequest provides a mock function with given fields: _a0, _a1
+func (_m *Service) CompleteIdempotentRequest(_a0 context.Context, _a1 domain.IdempotencyRecord) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.IdempotencyRecord) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ConfirmHandover provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Service) ConfirmHandover(_a0 context.Context, _a1 uint, _a2 string, _a3 uint) (domain.Handover, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 domain.Handover
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string, uint) domain.Handover); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Get(0).(domain.Handover)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, string, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ConfirmWaitlistOffer provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Service) ConfirmWaitlistOffer(_a0 context.Context, _a1 uint, _a2 uint) (domain.WaitlistEntry, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.WaitlistEntry
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) domain.WaitlistEntry); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.WaitlistEntry)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateAPIClient provides a mock function with given fields: _a0, _a1
+func (_m *Service) CreateAPIClient(_a0 context.Context, _a1 domain.NewAPIClientRequest) (domain.APIClient, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.APIClient
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NewAPIClientRequest) domain.APIClient); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.APIClient)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.NewAPIClientRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreatePayment provides a mock function with given fields: _a0, _a1
+func (_m *Service) CreatePayment(_a0 context.Context, _a1 domain.NewPaymentRequest) (domain.Payment, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Payment
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NewPaymentRequest) domain.Payment); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Payment)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.NewPaymentRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeactivatePromoCode provides a mock function with given fields: _a0, _a1
+func (_m *Service) DeactivatePromoCode(_a0 context.Context, _a1 uint) (domain.PromoCode, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.PromoCode
+	if rf, ok := ret.Get(0).(func(context.Context, uint) domain.PromoCode); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.PromoCode)
+	}
+
 	var r1 error
 	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
 		r1 = rf(_a0, _a1)
@@ -79,17 +347,50 @@ func (_m *Service) GetAllBookings(_a0 context.Context, _a1 uint) ([]domain.Booki
 	return r0, r1
 }
 
-// GetAllSlots provides a mock function with given fields: _a0
-func (_m *Service) GetAllSlots(_a0 context.Context) ([]domain.SlotResponse, error) {
+// DecideClaim provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Service) DecideClaim(_a0 context.Context, _a1 uint, _a2 uint, _a3 domain.ClaimDecisionRequest) (domain.Claim, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 domain.Claim
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint, domain.ClaimDecisionRequest) domain.Claim); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Get(0).(domain.Claim)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint, domain.ClaimDecisionRequest) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteWebhook provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Service) DeleteWebhook(_a0 context.Context, _a1 uint, _a2 uint) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeliverWebhooks provides a mock function with given fields: _a0
+func (_m *Service) DeliverWebhooks(_a0 context.Context) (int, error) {
 	ret := _m.Called(_a0)
 
-	var r0 []domain.SlotResponse
-	if rf, ok := ret.Get(0).(func(context.Context) []domain.SlotResponse); ok {
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
 		r0 = rf(_a0)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]domain.SlotResponse)
-		}
+		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
@@ -102,22 +403,20 @@ func (_m *Service) GetAllSlots(_a0 context.Context) ([]domain.SlotResponse, erro
 	return r0, r1
 }
 
-// GetAvailability provides a mock function with given fields: _a0, _a1, _a2
-func (_m *Service) GetAvailability(_a0 context.Context, _a1 uint, _a2 string) ([]uint, error) {
-	ret := _m.Called(_a0, _a1, _a2)
+// DispatchNotifications provides a mock function with given fields: _a0
+func (_m *Service) DispatchNotifications(_a0 context.Context) (int, error) {
+	ret := _m.Called(_a0)
 
-	var r0 []uint
-	if rf, ok := ret.Get(0).(func(context.Context, uint, string) []uint); ok {
-		r0 = rf(_a0, _a1, _a2)
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
+		r0 = rf(_a0)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]uint)
-		}
+		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, uint, string) error); ok {
-		r1 = rf(_a0, _a1, _a2)
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -125,17 +424,15 @@ func (_m *Service) GetAvailability(_a0 context.Context, _a1 uint, _a2 string) ([
 	return r0, r1
 }
 
-// GetMachines provides a mock function with given fields: _a0
-func (_m *Service) GetMachines(_a0 context.Context) ([]domain.MachineResponse, error) {
+// EscalateOverdueClaims provides a mock function with given fields: _a0
+func (_m *Service) EscalateOverdueClaims(_a0 context.Context) (int, error) {
 	ret := _m.Called(_a0)
 
-	var r0 []domain.MachineResponse
-	if rf, ok := ret.Get(0).(func(context.Context) []domain.MachineResponse); ok {
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
 		r0 = rf(_a0)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]domain.MachineResponse)
-		}
+		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
@@ -148,20 +445,20 @@ func (_m *Service) GetMachines(_a0 context.Context) ([]domain.MachineResponse, e
 	return r0, r1
 }
 
-// Login provides a mock function with given fields: _a0, _a1
-func (_m *Service) Login(_a0 context.Context, _a1 domain.LoginRequest) (string, error) {
-	ret := _m.Called(_a0, _a1)
+// ExpireWaitlistOffers provides a mock function with given fields: _a0
+func (_m *Service) ExpireWaitlistOffers(_a0 context.Context) (int, error) {
+	ret := _m.Called(_a0)
 
-	var r0 string
-	if rf, ok := ret.Get(0).(func(context.Context, domain.LoginRequest) string); ok {
-		r0 = rf(_a0, _a1)
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
+		r0 = rf(_a0)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, domain.LoginRequest) error); ok {
-		r1 = rf(_a0, _a1)
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -169,15 +466,941 @@ func (_m *Service) Login(_a0 context.Context, _a1 domain.LoginRequest) (string,
 	return r0, r1
 }
 
-// Register provides a mock function with given fields: _a0, _a1
-func (_m *Service) Register(_a0 context.Context, _a1 domain.NewFarmerRequest) (domain.FarmerResponse, error) {
+// FileClaim provides a mock function with given fields: _a0, _a1
+func (_m *Service) FileClaim(_a0 context.Context, _a1 domain.NewClaimRequest) (domain.Claim, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 domain.FarmerResponse
-	if rf, ok := ret.Get(0).(func(context.Context, domain.NewFarmerRequest) domain.FarmerResponse); ok {
+	var r0 domain.Claim
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NewClaimRequest) domain.Claim); ok {
 		r0 = rf(_a0, _a1)
 	} else {
-		r0 = ret.Get(0).(domain.FarmerResponse)
+		r0 = ret.Get(0).(domain.Claim)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.NewClaimRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindNextAvailable provides a mock function with given fields: _a0, _a1
+func (_m *Service) FindNextAvailable(_a0 context.Context, _a1 domain.NextAvailableRequest) ([]domain.AvailableWindow, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.AvailableWindow
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NextAvailableRequest) []domain.AvailableWindow); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.AvailableWindow)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.NextAvailableRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAPIClients provides a mock function with given fields: _a0, _a1
+func (_m *Service) GetAPIClients(_a0 context.Context, _a1 uint) ([]domain.APIClient, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.APIClient
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.APIClient); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.APIClient)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAllBookings provides a mock function with given fields: _a0, _a1
+func (_m *Service) GetAllBookings(_a0 context.Context, _a1 uint) ([]domain.BookingResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.BookingResponse
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.BookingResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.BookingResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAllSlots provides a mock function with given fields: _a0
+func (_m *Service) GetAllSlots(_a0 context.Context) ([]domain.SlotResponse, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []domain.SlotResponse
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.SlotResponse); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.SlotResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAvailability provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Service) GetAvailability(_a0 context.Context, _a1 uint, _a2 string) ([]uint, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []uint
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) []uint); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uint)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetClaim provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Service) GetClaim(_a0 context.Context, _a1 uint, _a2 uint) (domain.Claim, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.Claim
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) domain.Claim); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.Claim)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetClaims provides a mock function with given fields: _a0, _a1
+func (_m *Service) GetClaims(_a0 context.Context, _a1 uint) ([]domain.Claim, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.Claim
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.Claim); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Claim)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetClaimsForReview provides a mock function with given fields: _a0
+func (_m *Service) GetClaimsForReview(_a0 context.Context) ([]domain.Claim, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []domain.Claim
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.Claim); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Claim)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeposit provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Service) GetDeposit(_a0 context.Context, _a1 uint, _a2 uint) (domain.Deposit, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.Deposit
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) domain.Deposit); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.Deposit)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeposits provides a mock function with given fields: _a0, _a1
+func (_m *Service) GetDeposits(_a0 context.Context, _a1 uint) ([]domain.Deposit, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.Deposit
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.Deposit); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Deposit)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetFarmer provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Service) GetFarmer(_a0 context.Context, _a1 uint, _a2 uint) (domain.FarmerResponse, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.FarmerResponse
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) domain.FarmerResponse); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.FarmerResponse)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetFarmerReviews provides a mock function with given fields: _a0, _a1
+func (_m *Service) GetFarmerReviews(_a0 context.Context, _a1 uint) ([]domain.Review, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.Review
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.Review); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Review)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetHandovers provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Service) GetHandovers(_a0 context.Context, _a1 uint, _a2 uint) ([]domain.Handover, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []domain.Handover
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) []domain.Handover); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Handover)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetInvoice provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Service) GetInvoice(_a0 context.Context, _a1 uint, _a2 uint) (domain.Invoice, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.Invoice
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) domain.Invoice); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.Invoice)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetInvoiceDocument provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Service) GetInvoiceDocument(_a0 context.Context, _a1 uint, _a2 uint) (domain.InvoiceDocument, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.InvoiceDocument
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) domain.InvoiceDocument); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.InvoiceDocument)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetInvoices provides a mock function with given fields: _a0, _a1
+func (_m *Service) GetInvoices(_a0 context.Context, _a1 uint) ([]domain.Invoice, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.Invoice
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.Invoice); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Invoice)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMachineReviews provides a mock function with given fields: _a0, _a1
+func (_m *Service) GetMachineReviews(_a0 context.Context, _a1 uint) ([]domain.Review, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.Review
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.Review); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Review)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMachines provides a mock function with given fields: _a0
+func (_m *Service) GetMachines(_a0 context.Context) ([]domain.MachineResponse, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []domain.MachineResponse
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.MachineResponse); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.MachineResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMessages provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4
+func (_m *Service) GetMessages(_a0 context.Context, _a1 uint, _a2 uint, _a3 uint, _a4 uint) (domain.MessagePage, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3, _a4)
+
+	var r0 domain.MessagePage
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint, uint, uint) domain.MessagePage); ok {
+		r0 = rf(_a0, _a1, _a2, _a3, _a4)
+	} else {
+		r0 = ret.Get(0).(domain.MessagePage)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint, uint, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3, _a4)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetNotificationPreferences provides a mock function with given fields: _a0, _a1
+func (_m *Service) GetNotificationPreferences(_a0 context.Context, _a1 uint) ([]domain.NotificationPreference, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.NotificationPreference
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.NotificationPreference); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.NotificationPreference)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetNotifications provides a mock function with given fields: _a0, _a1
+func (_m *Service) GetNotifications(_a0 context.Context, _a1 uint) (domain.NotificationInbox, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.NotificationInbox
+	if rf, ok := ret.Get(0).(func(context.Context, uint) domain.NotificationInbox); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.NotificationInbox)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrderPayer provides a mock function with given fields: _a0, _a1
+func (_m *Service) GetOrderPayer(_a0 context.Context, _a1 string) (uint, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, string) uint); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOwnerEarnings provides a mock function with given fields: _a0, _a1
+func (_m *Service) GetOwnerEarnings(_a0 context.Context, _a1 uint) (domain.OwnerEarnings, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.OwnerEarnings
+	if rf, ok := ret.Get(0).(func(context.Context, uint) domain.OwnerEarnings); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.OwnerEarnings)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPayment provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Service) GetPayment(_a0 context.Context, _a1 uint, _a2 uint) (domain.Payment, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.Payment
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) domain.Payment); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.Payment)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPromoCodes provides a mock function with given fields: _a0
+func (_m *Service) GetPromoCodes(_a0 context.Context) ([]domain.PromoCode, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []domain.PromoCode
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.PromoCode); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.PromoCode)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPromoRedemptions provides a mock function with given fields: _a0, _a1
+func (_m *Service) GetPromoRedemptions(_a0 context.Context, _a1 uint) ([]domain.PromoRedemption, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.PromoRedemption
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.PromoRedemption); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.PromoRedemption)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReviewReports provides a mock function with given fields: _a0
+func (_m *Service) GetReviewReports(_a0 context.Context) ([]domain.ReviewReport, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []domain.ReviewReport
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.ReviewReport); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.ReviewReport)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetThreads provides a mock function with given fields: _a0, _a1
+func (_m *Service) GetThreads(_a0 context.Context, _a1 uint) ([]domain.Thread, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.Thread
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.Thread); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Thread)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWaitlist provides a mock function with given fields: _a0, _a1
+func (_m *Service) GetWaitlist(_a0 context.Context, _a1 uint) ([]domain.WaitlistEntry, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.WaitlistEntry
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.WaitlistEntry); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.WaitlistEntry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWallet provides a mock function with given fields: _a0, _a1
+func (_m *Service) GetWallet(_a0 context.Context, _a1 uint) (domain.Wallet, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Wallet
+	if rf, ok := ret.Get(0).(func(context.Context, uint) domain.Wallet); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Wallet)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWalletTransactions provides a mock function with given fields: _a0, _a1
+func (_m *Service) GetWalletTransactions(_a0 context.Context, _a1 uint) ([]domain.WalletTransaction, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.WalletTransaction
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.WalletTransaction); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.WalletTransaction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWebhookDeliveries provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Service) GetWebhookDeliveries(_a0 context.Context, _a1 uint, _a2 uint) ([]domain.WebhookDelivery, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []domain.WebhookDelivery
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) []domain.WebhookDelivery); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.WebhookDelivery)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWebhooks provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Service) GetWebhooks(_a0 context.Context, _a1 uint, _a2 uint) ([]domain.WebhookSubscription, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []domain.WebhookSubscription
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) []domain.WebhookSubscription); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.WebhookSubscription)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HandlePaymentWebhook provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Service) HandlePaymentWebhook(_a0 context.Context, _a1 []byte, _a2 string) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, string) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// JoinWaitlist provides a mock function with given fields: _a0, _a1
+func (_m *Service) JoinWaitlist(_a0 context.Context, _a1 domain.NewWaitlistRequest) (domain.WaitlistEntry, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.WaitlistEntry
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NewWaitlistRequest) domain.WaitlistEntry); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.WaitlistEntry)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.NewWaitlistRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LeaveWaitlist provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Service) LeaveWaitlist(_a0 context.Context, _a1 uint, _a2 uint) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Login provides a mock function with given fields: _a0, _a1
+func (_m *Service) Login(_a0 context.Context, _a1 domain.LoginRequest) (string, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, domain.LoginRequest) string); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.LoginRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkNoShows provides a mock function with given fields: _a0
+func (_m *Service) MarkNoShows(_a0 context.Context) (int, error) {
+	ret := _m.Called(_a0)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkNotificationRead provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Service) MarkNotificationRead(_a0 context.Context, _a1 uint, _a2 uint) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PostMessage provides a mock function with given fields: _a0, _a1
+func (_m *Service) PostMessage(_a0 context.Context, _a1 domain.NewMessageRequest) (domain.Message, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Message
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NewMessageRequest) domain.Message); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Message)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.NewMessageRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordHandover provides a mock function with given fields: _a0, _a1
+func (_m *Service) RecordHandover(_a0 context.Context, _a1 domain.HandoverRequest) (domain.Handover, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Handover
+	if rf, ok := ret.Get(0).(func(context.Context, domain.HandoverRequest) domain.Handover); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Handover)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.HandoverRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RefundPayment provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Service) RefundPayment(_a0 context.Context, _a1 uint, _a2 uint) (domain.Payment, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.Payment
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) domain.Payment); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.Payment)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Register provides a mock function with given fields: _a0, _a1
+func (_m *Service) Register(_a0 context.Context, _a1 domain.NewFarmerRequest) (domain.FarmerResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.FarmerResponse
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NewFarmerRequest) domain.FarmerResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.FarmerResponse)
 	}
 
 	var r1 error
@@ -190,6 +1413,292 @@ func (_m *Service) Register(_a0 context.Context, _a1 domain.NewFarmerRequest) (d
 	return r0, r1
 }
 
+// ReleaseDeposit provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Service) ReleaseDeposit(_a0 context.Context, _a1 uint, _a2 uint) (domain.Deposit, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.Deposit
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) domain.Deposit); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.Deposit)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReleaseUnclaimedDeposits provides a mock function with given fields: _a0
+func (_m *Service) ReleaseUnclaimedDeposits(_a0 context.Context) (int, error) {
+	ret := _m.Called(_a0)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReplayWebhookDelivery provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Service) ReplayWebhookDelivery(_a0 context.Context, _a1 uint, _a2 uint) (domain.WebhookDelivery, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.WebhookDelivery
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) domain.WebhookDelivery); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.WebhookDelivery)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReportReview provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Service) ReportReview(_a0 context.Context, _a1 uint, _a2 uint, _a3 domain.ReviewReportRequest) (domain.ReviewReport, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 domain.ReviewReport
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint, domain.ReviewReportRequest) domain.ReviewReport); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Get(0).(domain.ReviewReport)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint, domain.ReviewReportRequest) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResolveReviewReport provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Service) ResolveReviewReport(_a0 context.Context, _a1 uint, _a2 uint, _a3 domain.ReviewReportDecisionRequest) (domain.ReviewReport, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 domain.ReviewReport
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint, domain.ReviewReportDecisionRequest) domain.ReviewReport); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Get(0).(domain.ReviewReport)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint, domain.ReviewReportDecisionRequest) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RespondToClaim provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Service) RespondToClaim(_a0 context.Context, _a1 uint, _a2 uint, _a3 domain.ClaimResponseRequest) (domain.Claim, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 domain.Claim
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint, domain.ClaimResponseRequest) domain.Claim); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Get(0).(domain.Claim)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint, domain.ClaimResponseRequest) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReviewBooking provides a mock function with given fields: _a0, _a1
+func (_m *Service) ReviewBooking(_a0 context.Context, _a1 domain.NewReviewRequest) (domain.Review, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Review
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NewReviewRequest) domain.Review); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Review)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.NewReviewRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SendBookingReminders provides a mock function with given fields: _a0
+func (_m *Service) SendBookingReminders(_a0 context.Context) (int, error) {
+	ret := _m.Called(_a0)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetNotificationPreferences provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Service) SetNotificationPreferences(_a0 context.Context, _a1 uint, _a2 []domain.NotificationPreference) ([]domain.NotificationPreference, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []domain.NotificationPreference
+	if rf, ok := ret.Get(0).(func(context.Context, uint, []domain.NotificationPreference) []domain.NotificationPreference); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.NotificationPreference)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, []domain.NotificationPreference) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SettleOwnerBalances provides a mock function with given fields: _a0
+func (_m *Service) SettleOwnerBalances(_a0 context.Context) (domain.PayoutBatch, error) {
+	ret := _m.Called(_a0)
+
+	var r0 domain.PayoutBatch
+	if rf, ok := ret.Get(0).(func(context.Context) domain.PayoutBatch); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(domain.PayoutBatch)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StartThread provides a mock function with given fields: _a0, _a1
+func (_m *Service) StartThread(_a0 context.Context, _a1 domain.NewThreadRequest) (domain.Thread, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Thread
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NewThreadRequest) domain.Thread); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Thread)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.NewThreadRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SubscribeAvailability provides a mock function with given fields: _a0, _a1
+func (_m *Service) SubscribeAvailability(_a0 context.Context, _a1 uint) (<-chan domain.AvailabilityChange, func(), error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 <-chan domain.AvailabilityChange
+	if rf, ok := ret.Get(0).(func(context.Context, uint) <-chan domain.AvailabilityChange); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan domain.AvailabilityChange)
+		}
+	}
+
+	var r1 func()
+	if rf, ok := ret.Get(1).(func(context.Context, uint) func()); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(func())
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, uint) error); ok {
+		r2 = rf(_a0, _a1)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// TopUpWallet provides a mock function with given fields: _a0, _a1
+func (_m *Service) TopUpWallet(_a0 context.Context, _a1 domain.NewTopupRequest) (domain.WalletTopup, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.WalletTopup
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NewTopupRequest) domain.WalletTopup); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.WalletTopup)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.NewTopupRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 type mockConstructorTestingTNewService interface {
 	mock.TestingT
 	Cleanup(func())
@@ -15,6 +15,20 @@ type Storer struct {
 	mock.Mock
 }
 
+// AddAPIClient provides a mock function with given fields: _a0, _a1
+func (_m *Storer) AddAPIClient(_a0 context.Context, _a1 *domain.APIClient) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.APIClient) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // AddBooking provides a mock function with given fields: _a0, _a1
 func (_m *Storer) AddBooking(_a0 context.Context, _a1 domain.Booking) (uint, error) {
 	ret := _m.Called(_a0, _a1)
@@ -36,6 +50,34 @@ func (_m *Storer) AddBooking(_a0 context.Context, _a1 domain.Booking) (uint, err
 	return r0, r1
 }
 
+// AddClaim provides a mock function with given fields: _a0, _a1
+func (_m *Storer) AddClaim(_a0 context.Context, _a1 *domain.Claim) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Claim) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AddHandover provides a mock function with given fields: _a0, _a1
+func (_m *Storer) AddHandover(_a0 context.Context, _a1 *domain.Handover) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Handover) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // AddMachine provides a mock function with given fields: _a0, _a1
 func (_m *Storer) AddMachine(_a0 context.Context, _a1 *domain.MachineResponse) error {
 	ret := _m.Called(_a0, _a1)
@@ -50,6 +92,132 @@ func (_m *Storer) AddMachine(_a0 context.Context, _a1 *domain.MachineResponse) e
 	return r0
 }
 
+// AddMessage provides a mock function with given fields: _a0, _a1
+func (_m *Storer) AddMessage(_a0 context.Context, _a1 *domain.Message) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Message) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AddOutboxEvent provides a mock function with given fields: _a0, _a1
+func (_m *Storer) AddOutboxEvent(_a0 context.Context, _a1 domain.OutboxEvent) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.OutboxEvent) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AddPayment provides a mock function with given fields: _a0, _a1
+func (_m *Storer) AddPayment(_a0 context.Context, _a1 *domain.Payment) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Payment) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AddPromoCode provides a mock function with given fields: _a0, _a1
+func (_m *Storer) AddPromoCode(_a0 context.Context, _a1 *domain.PromoCode) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.PromoCode) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AddReview provides a mock function with given fields: _a0, _a1
+func (_m *Storer) AddReview(_a0 context.Context, _a1 *domain.Review) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Review) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AddReviewReport provides a mock function with given fields: _a0, _a1
+func (_m *Storer) AddReviewReport(_a0 context.Context, _a1 *domain.ReviewReport) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.ReviewReport) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AddWaitlistEntry provides a mock function with given fields: _a0, _a1
+func (_m *Storer) AddWaitlistEntry(_a0 context.Context, _a1 *domain.WaitlistEntry) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.WaitlistEntry) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AddWalletTopup provides a mock function with given fields: _a0, _a1
+func (_m *Storer) AddWalletTopup(_a0 context.Context, _a1 *domain.WalletTopup) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.WalletTopup) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AddWebhookSubscription provides a mock function with given fields: _a0, _a1
+func (_m *Storer) AddWebhookSubscription(_a0 context.Context, _a1 *domain.WebhookSubscription) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.WebhookSubscription) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Book provides a mock function with given fields: _a0, _a1
 func (_m *Storer) Book(_a0 context.Context, _a1 domain.NewBookingRequest) (domain.NewBookingResponse, error) {
 	ret := _m.Called(_a0, _a1)
@@ -71,6 +239,27 @@ func (_m *Storer) Book(_a0 context.Context, _a1 domain.NewBookingRequest) (domai
 	return r0, r1
 }
 
+// BookSeries provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) BookSeries(_a0 context.Context, _a1 *domain.BookingSeries, _a2 []string) (domain.RecurringBookingResponse, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.RecurringBookingResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.BookingSeries, []string) domain.RecurringBookingResponse); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.RecurringBookingResponse)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.BookingSeries, []string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // BookSlot provides a mock function with given fields: _a0, _a1
 func (_m *Storer) BookSlot(_a0 context.Context, _a1 domain.Slot) error {
 	ret := _m.Called(_a0, _a1)
@@ -85,19 +274,19 @@ func (_m *Storer) BookSlot(_a0 context.Context, _a1 domain.Slot) error {
 	return r0
 }
 
-// GenrateInvoice provides a mock function with given fields: _a0, _a1
-func (_m *Storer) GenrateInvoice(_a0 context.Context, _a1 domain.Invoice) (uint, error) {
+// BookWaitlistOffer provides a mock function with given fields: _a0, _a1
+func (_m *Storer) BookWaitlistOffer(_a0 context.Context, _a1 *domain.WaitlistEntry) (domain.NewBookingResponse, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 uint
-	if rf, ok := ret.Get(0).(func(context.Context, domain.Invoice) uint); ok {
+	var r0 domain.NewBookingResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.WaitlistEntry) domain.NewBookingResponse); ok {
 		r0 = rf(_a0, _a1)
 	} else {
-		r0 = ret.Get(0).(uint)
+		r0 = ret.Get(0).(domain.NewBookingResponse)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, domain.Invoice) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.WaitlistEntry) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -106,22 +295,20 @@ func (_m *Storer) GenrateInvoice(_a0 context.Context, _a1 domain.Invoice) (uint,
 	return r0, r1
 }
 
-// GetAllBookings provides a mock function with given fields: _a0, _a1
-func (_m *Storer) GetAllBookings(_a0 context.Context, _a1 uint) ([]domain.BookingResponse, error) {
-	ret := _m.Called(_a0, _a1)
+// CancelBooking provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) CancelBooking(_a0 context.Context, _a1 uint, _a2 uint) (domain.CancelledBooking, error) {
+	ret := _m.Called(_a0, _a1, _a2)
 
-	var r0 []domain.BookingResponse
-	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.BookingResponse); ok {
-		r0 = rf(_a0, _a1)
+	var r0 domain.CancelledBooking
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) domain.CancelledBooking); ok {
+		r0 = rf(_a0, _a1, _a2)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]domain.BookingResponse)
-		}
+		r0 = ret.Get(0).(domain.CancelledBooking)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
-		r1 = rf(_a0, _a1)
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -129,20 +316,22 @@ func (_m *Storer) GetAllBookings(_a0 context.Context, _a1 uint) ([]domain.Bookin
 	return r0, r1
 }
 
-// GetBaseCharge provides a mock function with given fields: _a0, _a1
-func (_m *Storer) GetBaseCharge(_a0 context.Context, _a1 uint) (uint, error) {
-	ret := _m.Called(_a0, _a1)
+// CancelBookingSeries provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) CancelBookingSeries(_a0 context.Context, _a1 uint, _a2 uint) ([]domain.CancelledBooking, error) {
+	ret := _m.Called(_a0, _a1, _a2)
 
-	var r0 uint
-	if rf, ok := ret.Get(0).(func(context.Context, uint) uint); ok {
-		r0 = rf(_a0, _a1)
+	var r0 []domain.CancelledBooking
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) []domain.CancelledBooking); ok {
+		r0 = rf(_a0, _a1, _a2)
 	} else {
-		r0 = ret.Get(0).(uint)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.CancelledBooking)
+		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
-		r1 = rf(_a0, _a1)
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -150,45 +339,62 @@ func (_m *Storer) GetBaseCharge(_a0 context.Context, _a1 uint) (uint, error) {
 	return r0, r1
 }
 
-// GetBookedSlot provides a mock function with given fields: _a0, _a1, _a2
-func (_m *Storer) GetBookedSlot(_a0 context.Context, _a1 uint, _a2 string) (map[uint]struct{}, error) {
+// CancelWaitlistEntry provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) CancelWaitlistEntry(_a0 context.Context, _a1 uint, _a2 uint) error {
 	ret := _m.Called(_a0, _a1, _a2)
 
-	var r0 map[uint]struct{}
-	if rf, ok := ret.Get(0).(func(context.Context, uint, string) map[uint]struct{}); ok {
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) error); ok {
 		r0 = rf(_a0, _a1, _a2)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(map[uint]struct{})
-		}
+		r0 = ret.Error(0)
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, uint, string) error); ok {
-		r1 = rf(_a0, _a1, _a2)
+	return r0
+}
+
+// CaptureDeposit provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Storer) CaptureDeposit(_a0 context.Context, _a1 uint, _a2 domain.Money, _a3 string) error {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, domain.Money, string) error); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// GetMachines provides a mock function with given fields: _a0
-func (_m *Storer) GetMachines(_a0 context.Context) ([]domain.MachineResponse, error) {
-	ret := _m.Called(_a0)
+// CapturePayment provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Storer) CapturePayment(_a0 context.Context, _a1 uint, _a2 string, _a3 []domain.LedgerTransaction) error {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
 
-	var r0 []domain.MachineResponse
-	if rf, ok := ret.Get(0).(func(context.Context) []domain.MachineResponse); ok {
-		r0 = rf(_a0)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string, []domain.LedgerTransaction) error); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]domain.MachineResponse)
-		}
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Checkout provides a mock function with given fields: _a0, _a1
+func (_m *Storer) Checkout(_a0 context.Context, _a1 domain.NewOrderRequest) (domain.OrderResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.OrderResponse
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NewOrderRequest) domain.OrderResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.OrderResponse)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(_a0)
+	if rf, ok := ret.Get(1).(func(context.Context, domain.NewOrderRequest) error); ok {
+		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -196,34 +402,41 @@ func (_m *Storer) GetMachines(_a0 context.Context) ([]domain.MachineResponse, er
 	return r0, r1
 }
 
-// IsEmptySlot provides a mock function with given fields: _a0, _a1, _a2, _a3
-func (_m *Storer) IsEmptySlot(_a0 context.Context, _a1 uint, _a2 uint, _a3 string) bool {
+// ClaimIdempotencyKey provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Storer) ClaimIdempotencyKey(_a0 context.Context, _a1 uint, _a2 string, _a3 string) (bool, error) {
 	ret := _m.Called(_a0, _a1, _a2, _a3)
 
 	var r0 bool
-	if rf, ok := ret.Get(0).(func(context.Context, uint, uint, string) bool); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string, string) bool); ok {
 		r0 = rf(_a0, _a1, _a2, _a3)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, string, string) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// LoginFarmer provides a mock function with given fields: _a0, _a1, _a2
-func (_m *Storer) LoginFarmer(_a0 context.Context, _a1 string, _a2 string) (uint, error) {
-	ret := _m.Called(_a0, _a1, _a2)
+// ConfirmHandover provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Storer) ConfirmHandover(_a0 context.Context, _a1 uint, _a2 string, _a3 string) (domain.Handover, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
 
-	var r0 uint
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) uint); ok {
-		r0 = rf(_a0, _a1, _a2)
+	var r0 domain.Handover
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string, string) domain.Handover); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
 	} else {
-		r0 = ret.Get(0).(uint)
+		r0 = ret.Get(0).(domain.Handover)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(_a0, _a1, _a2)
+	if rf, ok := ret.Get(1).(func(context.Context, uint, string, string) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -231,12 +444,1739 @@ func (_m *Storer) LoginFarmer(_a0 context.Context, _a1 string, _a2 string) (uint
 	return r0, r1
 }
 
-// RegisterFarmer provides a mock function with given fields: _a0, _a1
-func (_m *Storer) RegisterFarmer(_a0 context.Context, _a1 *domain.FarmerResponse) error {
-	ret := _m.Called(_a0, _a1)
+// ConfirmWaitlistOffer provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) ConfirmWaitlistOffer(_a0 context.Context, _a1 uint, _a2 uint) (domain.WaitlistEntry, error) {
+	ret := _m.Called(_a0, _a1, _a2)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, *domain.FarmerResponse) error); ok {
+	var r0 domain.WaitlistEntry
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) domain.WaitlistEntry); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.WaitlistEntry)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreditWalletTopup provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) CreditWalletTopup(_a0 context.Context, _a1 uint, _a2 string) (domain.WalletTopup, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.WalletTopup
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) domain.WalletTopup); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.WalletTopup)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeactivatePromoCode provides a mock function with given fields: _a0, _a1
+func (_m *Storer) DeactivatePromoCode(_a0 context.Context, _a1 uint) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeactivateWebhookSubscription provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) DeactivateWebhookSubscription(_a0 context.Context, _a1 uint, _a2 uint) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DecideClaim provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Storer) DecideClaim(_a0 context.Context, _a1 uint, _a2 []string, _a3 domain.ClaimDecision) (domain.Claim, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 domain.Claim
+	if rf, ok := ret.Get(0).(func(context.Context, uint, []string, domain.ClaimDecision) domain.Claim); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Get(0).(domain.Claim)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, []string, domain.ClaimDecision) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteIdempotencyKey provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) DeleteIdempotencyKey(_a0 context.Context, _a1 uint, _a2 string) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DispatchOutboxEvents provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Storer) DispatchOutboxEvents(_a0 context.Context, _a1 []string, _a2 []string, _a3 uint) (int, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, []string, []string, uint) int); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []string, []string, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DisputeClaim provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4
+func (_m *Storer) DisputeClaim(_a0 context.Context, _a1 uint, _a2 uint, _a3 string, _a4 []string) error {
+	ret := _m.Called(_a0, _a1, _a2, _a3, _a4)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint, string, []string) error); ok {
+		r0 = rf(_a0, _a1, _a2, _a3, _a4)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EscalateOverdueClaims provides a mock function with given fields: _a0
+func (_m *Storer) EscalateOverdueClaims(_a0 context.Context) ([]domain.Claim, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []domain.Claim
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.Claim); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Claim)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExpireWaitlistOffer provides a mock function with given fields: _a0, _a1
+func (_m *Storer) ExpireWaitlistOffer(_a0 context.Context, _a1 domain.WaitlistEntry) (domain.CancelledBooking, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.CancelledBooking
+	if rf, ok := ret.Get(0).(func(context.Context, domain.WaitlistEntry) domain.CancelledBooking); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.CancelledBooking)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.WaitlistEntry) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FailPayment provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) FailPayment(_a0 context.Context, _a1 uint, _a2 string) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FailWalletTopup provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) FailWalletTopup(_a0 context.Context, _a1 uint, _a2 string) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindMachines provides a mock function with given fields: _a0, _a1
+func (_m *Storer) FindMachines(_a0 context.Context, _a1 domain.MachineFilter) ([]domain.MachineResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.MachineResponse
+	if rf, ok := ret.Get(0).(func(context.Context, domain.MachineFilter) []domain.MachineResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.MachineResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.MachineFilter) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GenrateInvoice provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GenrateInvoice(_a0 context.Context, _a1 domain.Invoice) (uint, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Invoice) uint); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Invoice) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAPIClient provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetAPIClient(_a0 context.Context, _a1 uint) (domain.APIClient, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.APIClient
+	if rf, ok := ret.Get(0).(func(context.Context, uint) domain.APIClient); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.APIClient)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAPIClients provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetAPIClients(_a0 context.Context, _a1 uint) ([]domain.APIClient, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.APIClient
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.APIClient); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.APIClient)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAllBookings provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetAllBookings(_a0 context.Context, _a1 uint) ([]domain.BookingResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.BookingResponse
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.BookingResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.BookingResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBaseCharge provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetBaseCharge(_a0 context.Context, _a1 uint) (domain.Money, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Money
+	if rf, ok := ret.Get(0).(func(context.Context, uint) domain.Money); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Money)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBookedSlot provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) GetBookedSlot(_a0 context.Context, _a1 uint, _a2 string) (map[uint]struct{}, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 map[uint]struct{}
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) map[uint]struct{}); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[uint]struct{})
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBookedSlots provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Storer) GetBookedSlots(_a0 context.Context, _a1 []uint, _a2 string, _a3 string) (map[uint]map[string]map[uint]struct{}, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 map[uint]map[string]map[uint]struct{}
+	if rf, ok := ret.Get(0).(func(context.Context, []uint, string, string) map[uint]map[string]map[uint]struct{}); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[uint]map[string]map[uint]struct{})
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []uint, string, string) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBookingClaim provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetBookingClaim(_a0 context.Context, _a1 uint) (domain.Claim, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Claim
+	if rf, ok := ret.Get(0).(func(context.Context, uint) domain.Claim); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Claim)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBookingDeposit provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetBookingDeposit(_a0 context.Context, _a1 uint) (domain.Deposit, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Deposit
+	if rf, ok := ret.Get(0).(func(context.Context, uint) domain.Deposit); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Deposit)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBookingParties provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetBookingParties(_a0 context.Context, _a1 uint) (domain.BookingParties, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.BookingParties
+	if rf, ok := ret.Get(0).(func(context.Context, uint) domain.BookingParties); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.BookingParties)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetClaim provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetClaim(_a0 context.Context, _a1 uint) (domain.Claim, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Claim
+	if rf, ok := ret.Get(0).(func(context.Context, uint) domain.Claim); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Claim)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetClaims provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetClaims(_a0 context.Context, _a1 uint) ([]domain.Claim, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.Claim
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.Claim); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Claim)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetClaimsByStatus provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetClaimsByStatus(_a0 context.Context, _a1 []string) ([]domain.Claim, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.Claim
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []domain.Claim); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Claim)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeposit provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetDeposit(_a0 context.Context, _a1 uint) (domain.Deposit, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Deposit
+	if rf, ok := ret.Get(0).(func(context.Context, uint) domain.Deposit); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Deposit)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeposits provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetDeposits(_a0 context.Context, _a1 uint) ([]domain.Deposit, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.Deposit
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.Deposit); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Deposit)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDueNotifications provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetDueNotifications(_a0 context.Context, _a1 uint) ([]domain.NotificationDelivery, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.NotificationDelivery
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.NotificationDelivery); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.NotificationDelivery)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDueWebhookDeliveries provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetDueWebhookDeliveries(_a0 context.Context, _a1 uint) ([]domain.DueWebhookDelivery, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.DueWebhookDelivery
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.DueWebhookDelivery); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.DueWebhookDelivery)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetExpiredWaitlistOffers provides a mock function with given fields: _a0
+func (_m *Storer) GetExpiredWaitlistOffers(_a0 context.Context) ([]domain.WaitlistEntry, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []domain.WaitlistEntry
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.WaitlistEntry); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.WaitlistEntry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetFarmer provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetFarmer(_a0 context.Context, _a1 uint) (domain.FarmerResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.FarmerResponse
+	if rf, ok := ret.Get(0).(func(context.Context, uint) domain.FarmerResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.FarmerResponse)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetFarmerReviews provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetFarmerReviews(_a0 context.Context, _a1 uint) ([]domain.Review, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.Review
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.Review); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Review)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetHandovers provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetHandovers(_a0 context.Context, _a1 uint) ([]domain.Handover, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.Handover
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.Handover); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Handover)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetIdempotencyRecord provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) GetIdempotencyRecord(_a0 context.Context, _a1 uint, _a2 string) (domain.IdempotencyRecord, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.IdempotencyRecord
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) domain.IdempotencyRecord); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.IdempotencyRecord)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetInvoice provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetInvoice(_a0 context.Context, _a1 uint) (domain.Invoice, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Invoice
+	if rf, ok := ret.Get(0).(func(context.Context, uint) domain.Invoice); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Invoice)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetInvoiceDocument provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetInvoiceDocument(_a0 context.Context, _a1 uint) (domain.InvoiceDocument, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.InvoiceDocument
+	if rf, ok := ret.Get(0).(func(context.Context, uint) domain.InvoiceDocument); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.InvoiceDocument)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetInvoices provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetInvoices(_a0 context.Context, _a1 uint) ([]domain.Invoice, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.Invoice
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.Invoice); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Invoice)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMachine provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetMachine(_a0 context.Context, _a1 uint) (domain.MachineResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.MachineResponse
+	if rf, ok := ret.Get(0).(func(context.Context, uint) domain.MachineResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.MachineResponse)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMachineReviews provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetMachineReviews(_a0 context.Context, _a1 uint) ([]domain.Review, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.Review
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.Review); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Review)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMachines provides a mock function with given fields: _a0
+func (_m *Storer) GetMachines(_a0 context.Context) ([]domain.MachineResponse, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []domain.MachineResponse
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.MachineResponse); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.MachineResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMessages provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Storer) GetMessages(_a0 context.Context, _a1 uint, _a2 uint, _a3 uint) ([]domain.Message, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 []domain.Message
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint, uint) []domain.Message); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetNotificationInbox provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) GetNotificationInbox(_a0 context.Context, _a1 uint, _a2 uint) (domain.NotificationInbox, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.NotificationInbox
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) domain.NotificationInbox); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.NotificationInbox)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetNotificationPreferences provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetNotificationPreferences(_a0 context.Context, _a1 uint) ([]domain.NotificationPreference, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.NotificationPreference
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.NotificationPreference); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.NotificationPreference)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOpenPayment provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetOpenPayment(_a0 context.Context, _a1 uint) (domain.Payment, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Payment
+	if rf, ok := ret.Get(0).(func(context.Context, uint) domain.Payment); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Payment)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOwnerStatement provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetOwnerStatement(_a0 context.Context, _a1 uint) ([]domain.StatementLine, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.StatementLine
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.StatementLine); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.StatementLine)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPayment provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetPayment(_a0 context.Context, _a1 uint) (domain.Payment, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Payment
+	if rf, ok := ret.Get(0).(func(context.Context, uint) domain.Payment); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Payment)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPaymentByOrder provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) GetPaymentByOrder(_a0 context.Context, _a1 string, _a2 string) (domain.Payment, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.Payment
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) domain.Payment); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.Payment)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPayouts provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetPayouts(_a0 context.Context, _a1 uint) ([]domain.Payout, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.Payout
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.Payout); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Payout)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPromoCode provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetPromoCode(_a0 context.Context, _a1 uint) (domain.PromoCode, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.PromoCode
+	if rf, ok := ret.Get(0).(func(context.Context, uint) domain.PromoCode); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.PromoCode)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPromoCodes provides a mock function with given fields: _a0
+func (_m *Storer) GetPromoCodes(_a0 context.Context) ([]domain.PromoCode, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []domain.PromoCode
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.PromoCode); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.PromoCode)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPromoRedemptions provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetPromoRedemptions(_a0 context.Context, _a1 uint) ([]domain.PromoRedemption, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.PromoRedemption
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.PromoRedemption); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.PromoRedemption)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReview provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetReview(_a0 context.Context, _a1 uint) (domain.Review, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Review
+	if rf, ok := ret.Get(0).(func(context.Context, uint) domain.Review); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Review)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReviewReports provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetReviewReports(_a0 context.Context, _a1 string) ([]domain.ReviewReport, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.ReviewReport
+	if rf, ok := ret.Get(0).(func(context.Context, string) []domain.ReviewReport); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.ReviewReport)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetThread provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) GetThread(_a0 context.Context, _a1 uint, _a2 uint) (domain.Thread, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.Thread
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) domain.Thread); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.Thread)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetThreads provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetThreads(_a0 context.Context, _a1 uint) ([]domain.Thread, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.Thread
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.Thread); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Thread)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWaitingEntries provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) GetWaitingEntries(_a0 context.Context, _a1 uint, _a2 string) ([]domain.WaitlistEntry, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []domain.WaitlistEntry
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) []domain.WaitlistEntry); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.WaitlistEntry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWaitlistEntries provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetWaitlistEntries(_a0 context.Context, _a1 uint) ([]domain.WaitlistEntry, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.WaitlistEntry
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.WaitlistEntry); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.WaitlistEntry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWallet provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetWallet(_a0 context.Context, _a1 uint) (domain.Wallet, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Wallet
+	if rf, ok := ret.Get(0).(func(context.Context, uint) domain.Wallet); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Wallet)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWalletTopupByOrder provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) GetWalletTopupByOrder(_a0 context.Context, _a1 string, _a2 string) (domain.WalletTopup, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.WalletTopup
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) domain.WalletTopup); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.WalletTopup)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWalletTransactions provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetWalletTransactions(_a0 context.Context, _a1 uint) ([]domain.WalletTransaction, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.WalletTransaction
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.WalletTransaction); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.WalletTransaction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWebhookDeliveries provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) GetWebhookDeliveries(_a0 context.Context, _a1 uint, _a2 uint) ([]domain.WebhookDelivery, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []domain.WebhookDelivery
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) []domain.WebhookDelivery); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.WebhookDelivery)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWebhookSubscription provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetWebhookSubscription(_a0 context.Context, _a1 uint) (domain.WebhookSubscription, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.WebhookSubscription
+	if rf, ok := ret.Get(0).(func(context.Context, uint) domain.WebhookSubscription); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.WebhookSubscription)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWebhookSubscriptions provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetWebhookSubscriptions(_a0 context.Context, _a1 uint) ([]domain.WebhookSubscription, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.WebhookSubscription
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.WebhookSubscription); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.WebhookSubscription)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsEmptySlot provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Storer) IsEmptySlot(_a0 context.Context, _a1 uint, _a2 uint, _a3 string) bool {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint, string) bool); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// LoginFarmer provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) LoginFarmer(_a0 context.Context, _a1 string, _a2 string) (uint, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) uint); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkNoShows provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) MarkNoShows(_a0 context.Context, _a1 int, _a2 int) ([]domain.NoShow, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []domain.NoShow
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) []domain.NoShow); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.NoShow)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkNotificationRead provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) MarkNotificationRead(_a0 context.Context, _a1 uint, _a2 uint) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MarkThreadRead provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Storer) MarkThreadRead(_a0 context.Context, _a1 uint, _a2 uint, _a3 uint) error {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint, uint) error); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// OpenThread provides a mock function with given fields: _a0, _a1
+func (_m *Storer) OpenThread(_a0 context.Context, _a1 domain.Thread) (uint, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Thread) uint); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Thread) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// QueueBookingReminders provides a mock function with given fields: _a0, _a1
+func (_m *Storer) QueueBookingReminders(_a0 context.Context, _a1 int) (int, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, int) int); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RefundPayment provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) RefundPayment(_a0 context.Context, _a1 uint, _a2 string) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RegisterFarmer provides a mock function with given fields: _a0, _a1
+func (_m *Storer) RegisterFarmer(_a0 context.Context, _a1 *domain.FarmerResponse) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.FarmerResponse) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReleaseDeposit provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) ReleaseDeposit(_a0 context.Context, _a1 uint, _a2 string) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReleaseUnclaimedDeposits provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) ReleaseUnclaimedDeposits(_a0 context.Context, _a1 int, _a2 uint) ([]domain.Deposit, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []domain.Deposit
+	if rf, ok := ret.Get(0).(func(context.Context, int, uint) []domain.Deposit); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Deposit)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReplayWebhookDelivery provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) ReplayWebhookDelivery(_a0 context.Context, _a1 uint, _a2 uint) (domain.WebhookDelivery, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.WebhookDelivery
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) domain.WebhookDelivery); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.WebhookDelivery)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResolveReviewReport provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4
+func (_m *Storer) ResolveReviewReport(_a0 context.Context, _a1 uint, _a2 string, _a3 string, _a4 uint) (domain.ReviewReport, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3, _a4)
+
+	var r0 domain.ReviewReport
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string, string, uint) domain.ReviewReport); ok {
+		r0 = rf(_a0, _a1, _a2, _a3, _a4)
+	} else {
+		r0 = ret.Get(0).(domain.ReviewReport)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, string, string, uint) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3, _a4)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveIdempotencyResponse provides a mock function with given fields: _a0, _a1
+func (_m *Storer) SaveIdempotencyResponse(_a0 context.Context, _a1 domain.IdempotencyRecord) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.IdempotencyRecord) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetNotificationPreferences provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) SetNotificationPreferences(_a0 context.Context, _a1 uint, _a2 []domain.NotificationPreference) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, []domain.NotificationPreference) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SettleHandover provides a mock function with given fields: _a0, _a1
+func (_m *Storer) SettleHandover(_a0 context.Context, _a1 uint) (*domain.Invoice, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *domain.Invoice
+	if rf, ok := ret.Get(0).(func(context.Context, uint) *domain.Invoice); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Invoice)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SettleOwnerBalances provides a mock function with given fields: _a0
+func (_m *Storer) SettleOwnerBalances(_a0 context.Context) (domain.PayoutBatch, error) {
+	ret := _m.Called(_a0)
+
+	var r0 domain.PayoutBatch
+	if rf, ok := ret.Get(0).(func(context.Context) domain.PayoutBatch); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(domain.PayoutBatch)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TryAdvisoryLock provides a mock function with given fields: _a0, _a1
+func (_m *Storer) TryAdvisoryLock(_a0 context.Context, _a1 int64) (func(), bool, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 func()
+	if rf, ok := ret.Get(0).(func(context.Context, int64) func()); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(func())
+		}
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(context.Context, int64) bool); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, int64) error); ok {
+		r2 = rf(_a0, _a1)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// UpdateNotification provides a mock function with given fields: _a0, _a1
+func (_m *Storer) UpdateNotification(_a0 context.Context, _a1 domain.Notification) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Notification) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateWaitlistEntry provides a mock function with given fields: _a0, _a1
+func (_m *Storer) UpdateWaitlistEntry(_a0 context.Context, _a1 domain.WaitlistEntry) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.WaitlistEntry) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateWebhookDelivery provides a mock function with given fields: _a0, _a1
+func (_m *Storer) UpdateWebhookDelivery(_a0 context.Context, _a1 domain.WebhookDelivery) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.WebhookDelivery) error); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		r0 = ret.Error(0)
@@ -0,0 +1,57 @@
+// Package notify delivers notifications to farmers over channels such as email
+// and SMS.
+package notify
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	InApp = "in_app"
+	Email = "email"
+	SMS   = "sms"
+)
+
+var ErrNoAddress = errors.New("recipient has no address on the channel")
+
+// Recipient is the farmer a notification is for, with their contact details.
+type Recipient struct {
+	FarmerId uint
+	Email    string
+	Phone    string
+}
+
+// Address returns where the channel reaches the recipient.
+func (r Recipient) Address(channel string) string {
+	switch channel {
+	case Email:
+		return r.Email
+	case SMS:
+		return r.Phone
+	}
+	return ""
+}
+
+// Channel sends messages to farmers. A failed send is retried later, so Send
+// should not retry by itself.
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, to Recipient, message string) (err error)
+}
+
+// inApp is the channel of the farmer's inbox in the app. The stored
+// notification is the message, so there is nothing to send.
+type inApp struct{}
+
+func NewInApp() Channel {
+	return inApp{}
+}
+
+func (inApp) Name() string {
+	return InApp
+}
+
+func (inApp) Send(ctx context.Context, to Recipient, message string) (err error) {
+	return
+}
@@ -0,0 +1,69 @@
+package notify
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Sent is a message a Fake channel delivered.
+type Sent struct {
+	To      Recipient
+	Address string
+	Message string
+}
+
+// Fake is a channel for development and tests that logs messages instead of
+// sending them and keeps them in memory. FailNext makes the following sends
+// fail, to exercise retries.
+type Fake struct {
+	name string
+
+	mu       sync.Mutex
+	sent     []Sent
+	failures []error
+}
+
+func NewFake(name string) *Fake {
+	return &Fake{name: name}
+}
+
+func (f *Fake) Name() string {
+	return f.name
+}
+
+func (f *Fake) Send(ctx context.Context, to Recipient, message string) (err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.failures) > 0 {
+		err, f.failures = f.failures[0], f.failures[1:]
+		return
+	}
+
+	address := to.Address(f.name)
+	if address == "" {
+		return ErrNoAddress
+	}
+
+	f.sent = append(f.sent, Sent{To: to, Address: address, Message: message})
+	logrus.WithFields(logrus.Fields{"channel": f.name, "farmer_id": to.FarmerId, "to": address}).Info(message)
+	return
+}
+
+// FailNext makes the next sends return the errors, one each.
+func (f *Fake) FailNext(errs ...error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failures = append(f.failures, errs...)
+}
+
+// Sent returns the messages delivered so far.
+func (f *Fake) Sent() []Sent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Sent(nil), f.sent...)
+}
@@ -0,0 +1,26 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFake(t *testing.T) {
+	ctx := context.TODO()
+	farmer := Recipient{FarmerId: 9, Email: "asha@example.com", Phone: "9876543210"}
+
+	sms := NewFake(SMS)
+	sms.FailNext(errors.New("gateway timeout"))
+
+	assert.EqualError(t, sms.Send(ctx, farmer, "Your booking 11 is confirmed"), "gateway timeout")
+	require.NoError(t, sms.Send(ctx, farmer, "Your booking 11 is confirmed"))
+	assert.Equal(t, []Sent{{To: farmer, Address: "9876543210", Message: "Your booking 11 is confirmed"}}, sms.Sent())
+
+	email := NewFake(Email)
+	assert.Equal(t, ErrNoAddress, email.Send(ctx, Recipient{FarmerId: 10}, "Invoice issued"))
+	assert.Empty(t, email.Sent())
+}
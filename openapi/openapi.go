@@ -0,0 +1,247 @@
+// Package openapi builds an OpenAPI 3 document from a list of routes, with
+// the schemas of their request and response bodies read from the go types
+// they are encoded from.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const Version = "3.0.3"
+
+// Auth is who may call a route.
+type Auth int
+
+const (
+	Public Auth = iota
+	// Bearer routes need the token returned at login in the Authorization
+	// header.
+	Bearer
+	// Admin routes need the token of one of the admins.
+	Admin
+)
+
+// Route describes an operation. Request and Response are values of the go
+// types of the bodies, nil when there is none; Response is sent with Status,
+// as ContentType if it is not JSON.
+type Route struct {
+	Method      string
+	Path        string
+	Summary     string
+	Tag         string
+	Auth        Auth
+	Request     interface{}
+	Response    interface{}
+	Status      int
+	ContentType string
+	Query       []Parameter
+	Headers     []Parameter
+}
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem holds the operations on a path, by lower case method.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationId string                `json:"operationId"`
+	Summary     string                `json:"summary"`
+	Tags        []string              `json:"tags,omitempty"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	In          string `json:"in"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+const (
+	tokenScheme = "token"
+	jsonType    = "application/json"
+)
+
+var pathParam = regexp.MustCompile(`\{(\w+)(:[^}]*)?\}`)
+
+// Builder adds routes to a document.
+type Builder struct {
+	doc     Document
+	schemas *schemas
+}
+
+// New starts a document for the API served at serverURL. errorResponse is
+// the body of every failed request.
+func New(info Info, serverURL string, errorResponse interface{}) (b *Builder) {
+	b = &Builder{
+		doc: Document{
+			OpenAPI: Version,
+			Info:    info,
+			Servers: []Server{{URL: serverURL}},
+			Paths:   map[string]*PathItem{},
+			Components: Components{
+				Schemas: map[string]*Schema{},
+				SecuritySchemes: map[string]SecurityScheme{
+					tokenScheme: {Type: "apiKey", In: "header", Name: "Authorization", Description: "the token returned by POST /login"},
+				},
+			},
+		},
+	}
+	b.schemas = newSchemas(b.doc.Components.Schemas)
+	b.schemas.errorRef = b.schemas.of(errorResponse)
+	return
+}
+
+// Define gives the schema of a type that does not encode as its fields, such
+// as one with its own MarshalJSON.
+func (b *Builder) Define(value interface{}, schema Schema) {
+	b.schemas.define(value, schema)
+}
+
+// Add documents the route. Its path may be a mux template, with patterns
+// on its variables.
+func (b *Builder) Add(route Route) {
+	path := Path(route.Path)
+
+	operation := &Operation{
+		OperationId: operationId(route.Method, path),
+		Summary:     route.Summary,
+		Responses:   map[string]Response{},
+	}
+	if route.Tag != "" {
+		operation.Tags = []string{route.Tag}
+	}
+	if route.Auth != Public {
+		operation.Security = []map[string][]string{{tokenScheme: {}}}
+	}
+
+	for _, match := range pathParam.FindAllStringSubmatch(route.Path, -1) {
+		schema := &Schema{Type: "string"}
+		if match[2] == ":[0-9]+" {
+			schema = &Schema{Type: "integer", Minimum: new(float64)}
+		}
+		operation.Parameters = append(operation.Parameters, Parameter{Name: match[1], In: "path", Required: true, Schema: schema})
+	}
+	for _, query := range route.Query {
+		query.In = "query"
+		operation.Parameters = append(operation.Parameters, query)
+	}
+	for _, header := range route.Headers {
+		header.In = "header"
+		operation.Parameters = append(operation.Parameters, header)
+	}
+
+	if route.Request != nil {
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{jsonType: {Schema: b.schemas.of(route.Request)}},
+		}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := Response{Description: http.StatusText(status)}
+	switch {
+	case route.ContentType != "":
+		success.Content = map[string]MediaType{route.ContentType: {Schema: &Schema{Type: "string"}}}
+	case route.Response != nil:
+		success.Content = map[string]MediaType{jsonType: {Schema: b.schemas.of(route.Response)}}
+	}
+	operation.Responses[strconv.Itoa(status)] = success
+	operation.Responses["default"] = Response{
+		Description: "the error, with the status that fits it",
+		Content:     map[string]MediaType{jsonType: {Schema: b.schemas.errorRef}},
+	}
+
+	item, ok := b.doc.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		b.doc.Paths[path] = item
+	}
+	(*item)[strings.ToLower(route.Method)] = operation
+}
+
+// Document returns the document with the routes added so far.
+func (b *Builder) Document() Document {
+	return b.doc
+}
+
+// Path is the OpenAPI form of a mux path template, without the patterns of
+// its variables.
+func Path(template string) string {
+	return pathParam.ReplaceAllString(template, "{$1}")
+}
+
+// Operations lists the documented operations as "METHOD path", sorted.
+func (d Document) Operations() (operations []string) {
+	for path, item := range d.Paths {
+		for method := range *item {
+			operations = append(operations, fmt.Sprintf("%s %s", strings.ToUpper(method), path))
+		}
+	}
+	sort.Strings(operations)
+	return
+}
+
+// operationId is camel case of the method and the path's words, such as
+// getBookingsIdHandovers.
+func operationId(method string, path string) string {
+	id := strings.ToLower(method)
+	for _, word := range regexp.MustCompile(`[A-Za-z0-9]+`).FindAllString(path, -1) {
+		id += strings.ToUpper(word[:1]) + word[1:]
+	}
+	return id
+}
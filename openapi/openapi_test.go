@@ -0,0 +1,70 @@
+package openapi
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type problem struct {
+	Message string `json:"message"`
+}
+
+type owner struct {
+	Id uint `json:"id"`
+}
+
+type audit struct {
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type machine struct {
+	audit
+	Name     string   `json:"name" validate:"required,max=50"`
+	Owner    *owner   `json:"owner,omitempty"`
+	Slots    []uint   `json:"slots"`
+	Note     *string  `json:"note"`
+	OwnerId  uint     `json:"-"`
+	Tags     []string `json:"tags,omitempty"`
+	internal bool
+}
+
+func TestBuilder(t *testing.T) {
+	b := New(Info{Title: "test", Version: "1"}, "/api/v1", problem{})
+	b.Add(Route{Method: http.MethodPost, Path: "/owners/{id:[0-9]+}/machines", Summary: "Add a machine", Auth: Bearer, Request: machine{}, Response: machine{}, Status: http.StatusCreated})
+	b.Add(Route{Method: http.MethodGet, Path: "/machines", Summary: "List machines", Response: []machine{}})
+
+	doc := b.Document()
+
+	assert.Equal(t, []string{"GET /machines", "POST /owners/{id}/machines"}, doc.Operations())
+
+	add := (*doc.Paths["/owners/{id}/machines"])["post"]
+	require.NotNil(t, add)
+	assert.Equal(t, "postOwnersIdMachines", add.OperationId)
+	assert.Equal(t, []map[string][]string{{"token": {}}}, add.Security)
+	assert.Equal(t, "integer", add.Parameters[0].Schema.Type)
+	assert.Equal(t, "#/components/schemas/machine", add.RequestBody.Content["application/json"].Schema.Ref)
+	assert.Contains(t, add.Responses, "201")
+	assert.Equal(t, "#/components/schemas/problem", add.Responses["default"].Content["application/json"].Schema.Ref)
+
+	list := (*doc.Paths["/machines"])["get"]
+	assert.Empty(t, list.Security)
+	assert.Equal(t, "array", list.Responses["200"].Content["application/json"].Schema.Type)
+
+	schema := doc.Components.Schemas["machine"]
+	require.NotNil(t, schema)
+	assert.Len(t, schema.Properties, 6)
+	assert.Equal(t, []string{"name"}, schema.Required)
+	assert.Equal(t, "date-time", schema.Properties["created_at"].Format)
+	assert.Equal(t, "#/components/schemas/owner", schema.Properties["owner"].Ref)
+	assert.True(t, schema.Properties["note"].Nullable)
+	assert.Equal(t, "integer", schema.Properties["slots"].Items.Type)
+}
+
+func TestPath(t *testing.T) {
+	assert.Equal(t, "/invoices/{id}.pdf", Path("/invoices/{id:[0-9]+}.pdf"))
+	assert.Equal(t, "/payments/fake/orders/{order_id}/pay", Path("/payments/fake/orders/{order_id}/pay"))
+}
@@ -0,0 +1,158 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemas keeps the named schemas of the document, one for each struct
+// type, and refers to them from the schemas of fields and bodies.
+type schemas struct {
+	named    map[string]*Schema
+	types    map[string]reflect.Type
+	defined  map[reflect.Type]Schema
+	errorRef *Schema
+}
+
+func newSchemas(named map[string]*Schema) *schemas {
+	return &schemas{named: named, types: map[string]reflect.Type{}, defined: map[reflect.Type]Schema{}}
+}
+
+func (s *schemas) define(value interface{}, schema Schema) {
+	s.defined[reflect.TypeOf(value)] = schema
+}
+
+func (s *schemas) of(value interface{}) *Schema {
+	return s.schema(reflect.TypeOf(value))
+}
+
+func (s *schemas) schema(t reflect.Type) *Schema {
+
+	if schema, ok := s.defined[t]; ok {
+		return s.ref(t, func() *Schema { return &schema })
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawJSONType:
+		return &Schema{Type: "object"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := *s.schema(t.Elem())
+		if schema.Ref != "" {
+			// $ref takes no siblings in OpenAPI 3.0
+			return &schema
+		}
+		schema.Nullable = true
+		return &schema
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Minimum: new(float64)}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.schema(t.Elem())}
+	case reflect.Struct:
+		return s.ref(t, func() *Schema { return s.object(t) })
+	default:
+		return &Schema{}
+	}
+}
+
+// ref names the schema of a type after it, building it the first time.
+func (s *schemas) ref(t reflect.Type, build func() *Schema) *Schema {
+	name := t.Name()
+	if name == "" {
+		return build()
+	}
+	if known, ok := s.types[name]; ok && known != t {
+		// another package has a type of the same name, as api.Message and
+		// domain.Message
+		pkg := path.Base(t.PkgPath())
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	if _, ok := s.named[name]; !ok {
+		s.types[name] = t
+		// named first, so that a type can refer to itself
+		s.named[name] = &Schema{}
+		*s.named[name] = *build()
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// object is the schema of a struct as encoding/json writes it: fields by
+// their json names, embedded structs flattened and "-" skipped. Fields the
+// validate package requires of a request are listed as required.
+func (s *schemas) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	s.addFields(schema, t)
+	return schema
+}
+
+func (s *schemas) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (field.PkgPath != "" && !field.Anonymous) {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			s.addFields(schema, field.Type)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = s.schema(field.Type)
+		if isRequired(field) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+func isRequired(field reflect.StructField) bool {
+	for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
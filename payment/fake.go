@@ -0,0 +1,144 @@
+package payment
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+type fakeOrder struct {
+	amount    domain.Money
+	paymentId string
+	captured  bool
+	refunded  domain.Money
+}
+
+// Fake is an in-memory provider for development and tests. Pay stands in for
+// the renter paying at the provider and returns the webhook the provider
+// would send, signed like a real one.
+type Fake struct {
+	secret []byte
+
+	mu       sync.Mutex
+	orders   map[string]*fakeOrder
+	payments map[string]string
+	refunds  map[string]string
+	lastId   int
+}
+
+func NewFake(secret string) *Fake {
+	return &Fake{
+		secret:   []byte(secret),
+		orders:   map[string]*fakeOrder{},
+		payments: map[string]string{},
+		refunds:  map[string]string{},
+	}
+}
+
+func (f *Fake) Name() string {
+	return "fake"
+}
+
+func (f *Fake) nextId(prefix string) string {
+	f.lastId++
+	return fmt.Sprintf("%s_fake_%d", prefix, f.lastId)
+}
+
+func (f *Fake) CreateOrder(ctx context.Context, amount domain.Money, reference string) (orderId string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	orderId = f.nextId("order")
+	f.orders[orderId] = &fakeOrder{amount: amount}
+	return
+}
+
+// Pay authorizes a payment for the whole order and returns the signed
+// payment.authorized webhook.
+func (f *Fake) Pay(orderId string) (payload []byte, signature string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	order, ok := f.orders[orderId]
+	if !ok {
+		return nil, "", ErrOrderNotFound
+	}
+	if order.paymentId == "" {
+		order.paymentId = f.nextId("pay")
+		f.payments[order.paymentId] = orderId
+	}
+
+	payload, err = json.Marshal(Event{
+		Type:      EventPaymentAuthorized,
+		OrderId:   orderId,
+		PaymentId: order.paymentId,
+		Amount:    order.amount,
+	})
+	if err != nil {
+		return
+	}
+	return payload, f.Sign(payload), nil
+}
+
+// Capture captures an authorized payment. Capturing it again is a no-op.
+func (f *Fake) Capture(ctx context.Context, paymentId string, amount domain.Money) (err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	order, ok := f.orders[f.payments[paymentId]]
+	if !ok {
+		return ErrNotAuthorized
+	}
+	if order.amount != amount {
+		return ErrAmountMismatch
+	}
+	order.captured = true
+	return
+}
+
+func (f *Fake) Refund(ctx context.Context, paymentId string, amount domain.Money, idempotencyKey string) (refundId string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if refundId, ok := f.refunds[idempotencyKey]; ok {
+		return refundId, nil
+	}
+	order, ok := f.orders[f.payments[paymentId]]
+	if !ok || !order.captured {
+		return "", ErrNotCaptured
+	}
+	if !amount.SameCurrency(order.amount) || order.refunded.Add(amount).Minor > order.amount.Minor {
+		return "", ErrAmountMismatch
+	}
+	order.refunded = order.refunded.Add(amount)
+	refundId = f.nextId("rfnd")
+	f.refunds[idempotencyKey] = refundId
+	return
+}
+
+// Sign returns the hex HMAC-SHA256 of the payload with the webhook secret.
+func (f *Fake) Sign(payload []byte) string {
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (f *Fake) VerifyWebhook(payload []byte, signature string) (event Event, err error) {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return event, ErrInvalidSignature
+	}
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write(payload)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return event, ErrInvalidSignature
+	}
+
+	err = json.Unmarshal(payload, &event)
+	return
+}
@@ -0,0 +1,66 @@
+package payment
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFake(t *testing.T) {
+	fake := NewFake("secret")
+	ctx := context.TODO()
+
+	orderId, err := fake.CreateOrder(ctx, domain.Rupees(708), "FE/2026-27/000042")
+	require.NoError(t, err)
+
+	payload, signature, err := fake.Pay(orderId)
+	require.NoError(t, err)
+
+	event, err := fake.VerifyWebhook(payload, signature)
+	require.NoError(t, err)
+	assert.Equal(t, EventPaymentAuthorized, event.Type)
+	assert.Equal(t, orderId, event.OrderId)
+	assert.Equal(t, domain.Rupees(708), event.Amount)
+
+	_, err = fake.Refund(ctx, event.PaymentId, domain.Rupees(708), "refund:1")
+	assert.Equal(t, ErrNotCaptured, err)
+
+	assert.Equal(t, ErrAmountMismatch, fake.Capture(ctx, event.PaymentId, domain.Rupees(700)))
+	require.NoError(t, fake.Capture(ctx, event.PaymentId, domain.Rupees(708)))
+	// a redelivered webhook captures again
+	require.NoError(t, fake.Capture(ctx, event.PaymentId, domain.Rupees(708)))
+
+	refundId, err := fake.Refund(ctx, event.PaymentId, domain.Rupees(708), "refund:1")
+	require.NoError(t, err)
+	assert.NotEmpty(t, refundId)
+
+	// a retried refund returns the first one instead of refunding again
+	again, err := fake.Refund(ctx, event.PaymentId, domain.Rupees(708), "refund:1")
+	require.NoError(t, err)
+	assert.Equal(t, refundId, again)
+
+	_, err = fake.Refund(ctx, event.PaymentId, domain.Rupees(1), "refund:2")
+	assert.Equal(t, ErrAmountMismatch, err)
+}
+
+func TestFake_VerifyWebhook(t *testing.T) {
+	fake := NewFake("secret")
+	orderId, _ := fake.CreateOrder(context.TODO(), domain.Rupees(100), "FE/2026-27/000001")
+	payload, signature, err := fake.Pay(orderId)
+	require.NoError(t, err)
+
+	_, err = NewFake("other secret").VerifyWebhook(payload, signature)
+	assert.Equal(t, ErrInvalidSignature, err)
+
+	_, err = fake.VerifyWebhook(append(payload, ' '), signature)
+	assert.Equal(t, ErrInvalidSignature, err)
+
+	_, err = fake.VerifyWebhook(payload, "not hex")
+	assert.Equal(t, ErrInvalidSignature, err)
+
+	_, _, err = fake.Pay("order_unknown")
+	assert.Equal(t, ErrOrderNotFound, err)
+}
@@ -0,0 +1,52 @@
+// Package payment collects invoice payments through a payment gateway.
+package payment
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"errors"
+)
+
+const (
+	// EventPaymentAuthorized is sent when the renter has paid an order and
+	// the payment is waiting to be captured.
+	EventPaymentAuthorized = "payment.authorized"
+	EventPaymentFailed     = "payment.failed"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrOrderNotFound    = errors.New("payment order not found")
+	ErrNotAuthorized    = errors.New("payment is not authorized")
+	ErrNotCaptured      = errors.New("payment is not captured")
+	ErrAmountMismatch   = errors.New("amount does not match the payment")
+)
+
+// Event is a verified webhook notification from a provider.
+type Event struct {
+	Type      string       `json:"type"`
+	OrderId   string       `json:"order_id"`
+	PaymentId string       `json:"payment_id"`
+	Amount    domain.Money `json:"amount"`
+}
+
+// Provider is a payment gateway. An order is created for the amount to
+// collect, the renter pays it with the provider, the provider tells us with a
+// signed webhook and we capture the payment.
+//
+// Capture must succeed when the payment was already captured for the same
+// amount. A payment is captured at the provider before it is recorded, so
+// when recording fails the webhook is refused and its redelivery captures
+// again.
+//
+// Refund is refunded once per idempotencyKey: a retry with the same key
+// returns the first refund's id instead of refunding again. A payment is
+// refunded at the provider before it is recorded, so a refund whose recording
+// failed is retried with the same key.
+type Provider interface {
+	Name() string
+	CreateOrder(ctx context.Context, amount domain.Money, reference string) (orderId string, err error)
+	Capture(ctx context.Context, paymentId string, amount domain.Money) (err error)
+	Refund(ctx context.Context, paymentId string, amount domain.Money, idempotencyKey string) (refundId string, err error)
+	VerifyWebhook(payload []byte, signature string) (event Event, err error)
+}
@@ -0,0 +1,72 @@
+// Package pubsub passes messages between parts of the server by topic. The
+// in-process broker serves a single server; a broker backed by Postgres
+// LISTEN/NOTIFY can take its place to reach the subscribers of every server,
+// so topics are kept to valid channel names and payloads to a few kilobytes.
+package pubsub
+
+import (
+	"context"
+	"sync"
+)
+
+// subscriptionBuffer is how many messages a subscriber may fall behind
+// before it misses some.
+const subscriptionBuffer = 16
+
+type Broker interface {
+	// Publish sends the payload to every current subscriber of the topic. It
+	// does not wait for them, and a subscriber that has fallen behind misses
+	// the message.
+	Publish(ctx context.Context, topic string, payload []byte) (err error)
+	// Subscribe returns the messages published to the topic from now on,
+	// until unsubscribe is called, which closes messages.
+	Subscribe(ctx context.Context, topic string) (messages <-chan []byte, unsubscribe func(), err error)
+}
+
+// Memory is a Broker within the process.
+type Memory struct {
+	mu     sync.Mutex
+	topics map[string]map[chan []byte]struct{}
+}
+
+func NewMemory() *Memory {
+	return &Memory{topics: map[string]map[chan []byte]struct{}{}}
+}
+
+func (m *Memory) Publish(ctx context.Context, topic string, payload []byte) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for subscriber := range m.topics[topic] {
+		select {
+		case subscriber <- payload:
+		default:
+		}
+	}
+	return
+}
+
+func (m *Memory) Subscribe(ctx context.Context, topic string) (messages <-chan []byte, unsubscribe func(), err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	subscriber := make(chan []byte, subscriptionBuffer)
+	if m.topics[topic] == nil {
+		m.topics[topic] = map[chan []byte]struct{}{}
+	}
+	m.topics[topic][subscriber] = struct{}{}
+
+	var once sync.Once
+	unsubscribe = func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			delete(m.topics[topic], subscriber)
+			if len(m.topics[topic]) == 0 {
+				delete(m.topics, topic)
+			}
+			close(subscriber)
+		})
+	}
+	return subscriber, unsubscribe, nil
+}
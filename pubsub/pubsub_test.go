@@ -0,0 +1,41 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory(t *testing.T) {
+	ctx := context.TODO()
+	broker := NewMemory()
+
+	first, unsubscribeFirst, err := broker.Subscribe(ctx, "availability_3")
+	require.NoError(t, err)
+	second, unsubscribeSecond, err := broker.Subscribe(ctx, "availability_3")
+	require.NoError(t, err)
+	other, unsubscribeOther, err := broker.Subscribe(ctx, "availability_4")
+	require.NoError(t, err)
+	defer unsubscribeOther()
+
+	require.NoError(t, broker.Publish(ctx, "availability_3", []byte("slot 7 booked")))
+	assert.Equal(t, []byte("slot 7 booked"), <-first)
+	assert.Equal(t, []byte("slot 7 booked"), <-second)
+	assert.Empty(t, other)
+
+	unsubscribeFirst()
+	unsubscribeFirst()
+	_, open := <-first
+	assert.False(t, open, "unsubscribing closes the messages")
+
+	// a subscriber that falls behind misses messages rather than holding up
+	// the publisher
+	for i := 0; i < subscriptionBuffer+4; i++ {
+		require.NoError(t, broker.Publish(ctx, "availability_3", []byte("slot freed")))
+	}
+	assert.Len(t, second, subscriptionBuffer)
+	unsubscribeSecond()
+	assert.Empty(t, broker.topics["availability_3"])
+}
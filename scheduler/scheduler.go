@@ -0,0 +1,87 @@
+// Package scheduler runs the background jobs of the server. Every run of a job
+// first takes a database advisory lock named after it, so that when several
+// servers share a database only one of them runs the job at a time.
+package scheduler
+
+import (
+	"context"
+	"hash/fnv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Locker takes locks shared by every server, such as Postgres advisory locks.
+type Locker interface {
+	// TryAdvisoryLock takes the lock on key if it is free. The lock is held
+	// until unlock is called.
+	TryAdvisoryLock(ctx context.Context, key int64) (unlock func(), locked bool, err error)
+}
+
+// Job is run every Interval.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(context.Context) error
+}
+
+type Scheduler struct {
+	locker Locker
+	jobs   []Job
+}
+
+func New(locker Locker) *Scheduler {
+	return &Scheduler{locker: locker}
+}
+
+// Add schedules run every interval under the name, which also names its lock.
+func (s *Scheduler) Add(name string, interval time.Duration, run func(context.Context) error) {
+	s.jobs = append(s.jobs, Job{Name: name, Interval: interval, Run: run})
+}
+
+func (s *Scheduler) Jobs() []Job {
+	return s.jobs
+}
+
+// Start runs every job on its interval until the context is cancelled. A run
+// that is still going when the next is due delays it rather than overlapping.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		go s.loop(ctx, job)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.RunJob(ctx, job); err != nil {
+				logrus.WithField("job", job.Name).WithField("err", err.Error()).Error("error running scheduled job")
+			}
+		}
+	}
+}
+
+// RunJob runs the job once if no other server is running it. It reports
+// whether it ran.
+func (s *Scheduler) RunJob(ctx context.Context, job Job) (ran bool, err error) {
+
+	unlock, locked, err := s.locker.TryAdvisoryLock(ctx, LockKey(job.Name))
+	if err != nil || !locked {
+		return
+	}
+	defer unlock()
+
+	return true, job.Run(ctx)
+}
+
+// LockKey is the advisory lock key of the job with the name.
+func LockKey(name string) int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte("farmeasy.scheduler." + name))
+	return int64(hash.Sum64())
+}
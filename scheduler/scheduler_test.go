@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLocker holds locks in memory, as Postgres would across servers.
+type fakeLocker struct {
+	mu   sync.Mutex
+	held map[int64]bool
+}
+
+func (l *fakeLocker) TryAdvisoryLock(ctx context.Context, key int64) (unlock func(), locked bool, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held[key] {
+		return nil, false, nil
+	}
+	l.held[key] = true
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.held, key)
+	}, true, nil
+}
+
+func TestScheduler_RunJob(t *testing.T) {
+	locker := &fakeLocker{held: map[int64]bool{}}
+	// two servers sharing a database
+	first, second := New(locker), New(locker)
+
+	started, finish := make(chan struct{}), make(chan struct{})
+	runs := 0
+	job := Job{Name: "no-shows", Interval: time.Minute, Run: func(context.Context) error {
+		runs++
+		close(started)
+		<-finish
+		return nil
+	}}
+
+	done := make(chan bool)
+	go func() {
+		ran, err := first.RunJob(context.TODO(), job)
+		assert.NoError(t, err)
+		done <- ran
+	}()
+	<-started
+
+	ran, err := second.RunJob(context.TODO(), job)
+	require.NoError(t, err)
+	assert.False(t, ran, "the job runs on one server at a time")
+
+	close(finish)
+	assert.True(t, <-done)
+	assert.Equal(t, 1, runs)
+
+	failing := Job{Name: "no-shows", Run: func(context.Context) error { return errors.New("database is down") }}
+	ran, err = second.RunJob(context.TODO(), failing)
+	assert.True(t, ran, "the lock was released")
+	assert.EqualError(t, err, "database is down")
+}
+
+func TestLockKey(t *testing.T) {
+	assert.Equal(t, LockKey("webhooks"), LockKey("webhooks"))
+	assert.NotEqual(t, LockKey("webhooks"), LockKey("notifications"))
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// streamKeepAlive is how often an idle stream sends a comment, so that
+// proxies do not close it.
+const streamKeepAlive = 25 * time.Second
+
+// SubscribeAvailability returns the changes to the machine's slots from now
+// on, with the slots available after each, until unsubscribe is called or the
+// context ends.
+func (s *FarmService) SubscribeAvailability(ctx context.Context, machineId uint) (changes <-chan domain.AvailabilityChange, unsubscribe func(), err error) {
+
+	_, err = s.store.GetMachine(ctx, machineId)
+	if err == sql.ErrNoRows {
+		err = ErrMachineNotFound
+	}
+	if err != nil {
+		return
+	}
+
+	messages, unsubscribe, err := s.events.Subscribe(ctx, domain.AvailabilityTopic(machineId))
+	if err != nil {
+		return
+	}
+
+	updates := make(chan domain.AvailabilityChange)
+	go func() {
+		defer close(updates)
+		for message := range messages {
+			var change domain.AvailabilityChange
+			if err := json.Unmarshal(message, &change); err != nil {
+				logrus.WithField("err", err.Error()).Error("error reading availability change")
+				continue
+			}
+
+			change.SlotsAvailable, err = s.GetAvailability(ctx, change.MachineId, change.Date)
+			if err != nil {
+				logrus.WithField("err", err.Error()).Error("error getting availability for stream")
+				continue
+			}
+
+			select {
+			case updates <- change:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return updates, unsubscribe, nil
+}
+
+// availabilityChanged tells the subscribers of the machine that its slots on
+// the date changed.
+func (s *FarmService) availabilityChanged(ctx context.Context, machineId uint, date string, reason string) {
+
+	message, err := json.Marshal(domain.AvailabilityChange{MachineId: machineId, Date: date, Reason: reason})
+	if err != nil {
+		return
+	}
+	if err = s.events.Publish(ctx, domain.AvailabilityTopic(machineId), message); err != nil {
+		logrus.WithField("err", err.Error()).Error("error publishing availability change")
+	}
+}
+
+// availabilityStreamHandler streams the changes to a machine's slots as
+// server-sent "availability" events, each with the free slots of the date
+// that changed.
+func availabilityStreamHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		machineId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid machine id"))
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			fail(w, r, api.NewError(http.StatusInternalServerError, api.CodeInternal, "streaming is not supported"))
+			return
+		}
+
+		// an unknown machine is refused before the stream opens
+		changes, unsubscribe, err := deps.FarmService.SubscribeAvailability(r.Context(), uint(machineId))
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+		defer unsubscribe()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, ": subscribed\n\n")
+		flusher.Flush()
+
+		keepAlive := time.NewTicker(streamKeepAlive)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+				flusher.Flush()
+			case change, open := <-changes:
+				if !open {
+					return
+				}
+				data, err := json.Marshal(change)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "event: availability\ndata: %s\n\n", data)
+				flusher.Flush()
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"FarmEasy/domain"
+	"bufio"
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *ServiceTestSuite) Test_availabilityStreamHandler() {
+	t := s.T()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/machines/{id:[0-9]+}/availability/stream", availabilityStreamHandler(dependencies{FarmService: s.service}))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// an unknown machine is refused before the stream opens
+	s.repo.On("GetMachine", mock.Anything, uint(5)).Return(domain.MachineResponse{}, sql.ErrNoRows).Once()
+	missing, err := http.Get(server.URL + "/machines/5/availability/stream")
+	require.NoError(t, err)
+	missing.Body.Close()
+	assert.Equal(t, http.StatusNotFound, missing.StatusCode)
+	assert.NotEqual(t, "text/event-stream", missing.Header.Get("Content-Type"))
+
+	s.repo.On("GetMachine", mock.Anything, uint(3)).Return(domain.MachineResponse{Id: 3}, nil).Once()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/machines/3/availability/stream", nil)
+	require.NoError(t, err)
+	rsp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer rsp.Body.Close()
+	assert.Equal(t, "text/event-stream", rsp.Header.Get("Content-Type"))
+
+	stream := bufio.NewReader(rsp.Body)
+	line, err := stream.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, ": subscribed\n", line)
+
+	booked := map[uint]struct{}{}
+	for slot := uint(1); slot <= 24; slot++ {
+		if slot != 7 && slot != 8 {
+			booked[slot] = struct{}{}
+		}
+	}
+	s.repo.On("GetBookedSlot", mock.Anything, uint(3), "2026-11-07").Return(booked, nil).Once()
+
+	service := s.service.(*FarmService)
+	// another machine's changes are not streamed
+	service.availabilityChanged(context.TODO(), 4, "2026-11-07", domain.AvailabilityBooked)
+	service.availabilityChanged(context.TODO(), 3, "2026-11-07", domain.AvailabilityCancelled)
+
+	var event []string
+	for len(event) < 2 {
+		line, err = stream.ReadString('\n')
+		require.NoError(t, err)
+		if line = strings.TrimSpace(line); line != "" {
+			event = append(event, line)
+		}
+	}
+	assert.Equal(t, []string{
+		"event: availability",
+		`data: {"machine_id":3,"date":"2026-11-07","reason":"cancelled","slots_available":[7,8]}`,
+	}, event)
+}
@@ -0,0 +1,340 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/config"
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// FileClaim lets an owner claim for damage against a completed booking of
+// their machine, within the filing window after it ended. The renter is given
+// until RespondBy to accept or dispute it, and the booking's deposit stays
+// held until the claim is decided.
+func (s *FarmService) FileClaim(ctx context.Context, request domain.NewClaimRequest) (claim domain.Claim, err error) {
+
+	parties, err := s.store.GetBookingParties(ctx, request.BookingId)
+	if err == sql.ErrNoRows || (err == nil && parties.OwnerId != request.OwnerId) {
+		err = ErrClaimBookingNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	if !parties.Completed(now.Format("2006-01-02")) {
+		err = ErrBookingNotCompleted
+		return
+	}
+	end, err := time.Parse("2006-01-02", parties.EndDate)
+	if err != nil {
+		return
+	}
+	if now.After(end.AddDate(0, 0, config.ClaimFilingWindow()+1)) {
+		err = ErrClaimWindowClosed
+		return
+	}
+	if request.Amount.Currency != parties.Currency {
+		err = domain.ErrCurrencyMismatch
+		return
+	}
+
+	claim = domain.Claim{
+		BookingId:        parties.BookingId,
+		OwnerId:          parties.OwnerId,
+		FarmerId:         parties.FarmerId,
+		Amount:           request.Amount,
+		Description:      request.Description,
+		Evidence:         request.Evidence,
+		ResponseEvidence: []string{},
+		RespondBy:        now.Add(config.ClaimResponseWindow()),
+	}
+	err = s.store.AddClaim(ctx, &claim)
+	if err == sql.ErrNoRows {
+		err = ErrClaimExists
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	s.notifier.Notify(ctx, claim.FarmerId, fmt.Sprintf("The owner claims %s for damage on booking %d, please respond by %s", claim.Amount, claim.BookingId, claim.RespondBy.Format("2006-01-02 15:04")))
+	return
+}
+
+func (s *FarmService) GetClaims(ctx context.Context, farmerId uint) (claims []domain.Claim, err error) {
+
+	claims, err = s.store.GetClaims(ctx, farmerId)
+	if err != nil {
+		return
+	}
+	if claims == nil {
+		claims = []domain.Claim{}
+	}
+	return
+}
+
+// GetClaim returns the claim and its history to the owner, the renter or an
+// admin.
+func (s *FarmService) GetClaim(ctx context.Context, claimId uint, farmerId uint) (claim domain.Claim, err error) {
+
+	claim, err = s.store.GetClaim(ctx, claimId)
+	if err == sql.ErrNoRows {
+		err = ErrClaimNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	if claim.OwnerId != farmerId && claim.FarmerId != farmerId && !isAdmin(farmerId) {
+		return domain.Claim{}, ErrClaimNotFound
+	}
+	return
+}
+
+// RespondToClaim lets the renter accept the claim, which approves it in full,
+// or dispute it, which hands it to the admins.
+func (s *FarmService) RespondToClaim(ctx context.Context, claimId uint, farmerId uint, request domain.ClaimResponseRequest) (claim domain.Claim, err error) {
+
+	claim, err = s.GetClaim(ctx, claimId, farmerId)
+	if err != nil {
+		return
+	}
+	if claim.FarmerId != farmerId {
+		return domain.Claim{}, ErrClaimNotFound
+	}
+
+	if request.Accept {
+		decision := domain.ClaimDecision{Status: domain.ClaimApproved, Amount: claim.Amount, Note: "accepted by the renter", ActorId: farmerId}
+		_, err = s.store.DecideClaim(ctx, claimId, []string{domain.ClaimOpen}, decision)
+	} else {
+		err = s.store.DisputeClaim(ctx, claimId, farmerId, request.Response, request.Evidence)
+	}
+	if err == sql.ErrNoRows {
+		err = ErrClaimNotOpen
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	if request.Accept {
+		s.notifier.Notify(ctx, claim.OwnerId, fmt.Sprintf("The renter accepted your claim %d for %s", claimId, claim.Amount))
+	} else {
+		s.notifier.Notify(ctx, claim.OwnerId, fmt.Sprintf("The renter disputed your claim %d, an admin will decide it", claimId))
+	}
+	return s.store.GetClaim(ctx, claimId)
+}
+
+// GetClaimsForReview returns the claims waiting for an admin to decide them.
+func (s *FarmService) GetClaimsForReview(ctx context.Context) (claims []domain.Claim, err error) {
+
+	claims, err = s.store.GetClaimsByStatus(ctx, []string{domain.ClaimDisputed, domain.ClaimEscalated})
+	if err != nil {
+		return
+	}
+	if claims == nil {
+		claims = []domain.Claim{}
+	}
+	return
+}
+
+// DecideClaim lets an admin approve a disputed or escalated claim, in part or
+// in full, or reject it.
+func (s *FarmService) DecideClaim(ctx context.Context, claimId uint, adminId uint, request domain.ClaimDecisionRequest) (claim domain.Claim, err error) {
+
+	claim, err = s.store.GetClaim(ctx, claimId)
+	if err == sql.ErrNoRows {
+		err = ErrClaimNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	decision := domain.ClaimDecision{Status: domain.ClaimRejected, Note: request.Note, ActorId: adminId}
+	if request.Approve {
+		if request.Amount.Currency != claim.Amount.Currency || request.Amount.Minor <= 0 || request.Amount.Minor > claim.Amount.Minor {
+			err = ErrInvalidClaimAmount
+			return
+		}
+		decision.Status = domain.ClaimApproved
+		decision.Amount = request.Amount
+	}
+
+	claim, err = s.store.DecideClaim(ctx, claimId, []string{domain.ClaimDisputed, domain.ClaimEscalated}, decision)
+	if err == sql.ErrNoRows {
+		err = ErrClaimNotDecidable
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	message := fmt.Sprintf("Claim %d on booking %d was rejected", claim.Id, claim.BookingId)
+	if claim.Status == domain.ClaimApproved {
+		message = fmt.Sprintf("Claim %d on booking %d was approved for %s", claim.Id, claim.BookingId, claim.Approved)
+	}
+	s.notifier.Notify(ctx, claim.OwnerId, message)
+	s.notifier.Notify(ctx, claim.FarmerId, message)
+	return s.store.GetClaim(ctx, claimId)
+}
+
+// EscalateOverdueClaims hands the claims whose renter did not respond in time
+// to the admins.
+func (s *FarmService) EscalateOverdueClaims(ctx context.Context) (escalated int, err error) {
+
+	claims, err := s.store.EscalateOverdueClaims(ctx)
+	if err != nil {
+		return
+	}
+
+	for _, claim := range claims {
+		message := fmt.Sprintf("Claim %d on booking %d got no response in time, an admin will decide it", claim.Id, claim.BookingId)
+		s.notifier.Notify(ctx, claim.OwnerId, message)
+		s.notifier.Notify(ctx, claim.FarmerId, message)
+	}
+	return len(claims), nil
+}
+
+// claimPending reports whether a claim against the booking is still to be
+// decided, in which case its deposit must stay held.
+func (s *FarmService) claimPending(ctx context.Context, bookingId uint) bool {
+
+	claim, err := s.store.GetBookingClaim(ctx, bookingId)
+	return err == nil && claim.Pending()
+}
+
+func fileClaimHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var request domain.NewClaimRequest
+
+		if err := decode(r, &request); err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		request.OwnerId = r.Context().Value("token").(uint)
+
+		claim, err := deps.FarmService.FileClaim(r.Context(), request)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusCreated, claim)
+	}
+}
+
+func getClaimsHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		claims, err := deps.FarmService.GetClaims(r.Context(), farmerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, claims)
+	}
+}
+
+func getClaimHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		claimId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid claim id"))
+			return
+		}
+
+		claim, err := deps.FarmService.GetClaim(r.Context(), uint(claimId), farmerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, claim)
+	}
+}
+
+func respondToClaimHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		claimId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid claim id"))
+			return
+		}
+
+		var request domain.ClaimResponseRequest
+		if err := decode(r, &request); err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		claim, err := deps.FarmService.RespondToClaim(r.Context(), uint(claimId), farmerId, request)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, claim)
+	}
+}
+
+func getClaimsForReviewHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		claims, err := deps.FarmService.GetClaimsForReview(r.Context())
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, claims)
+	}
+}
+
+func decideClaimHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		adminId := r.Context().Value("token").(uint)
+
+		claimId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid claim id"))
+			return
+		}
+
+		var request domain.ClaimDecisionRequest
+		if err := decode(r, &request); err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		claim, err := deps.FarmService.DecideClaim(r.Context(), uint(claimId), adminId, request)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, claim)
+	}
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"FarmEasy/domain"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *ServiceTestSuite) TestFarmService_FileClaim() {
+	t := s.T()
+	ctx := context.TODO()
+
+	notifier := &recordingNotifier{}
+	s.service.(*FarmService).notifier = notifier
+
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	parties := domain.BookingParties{BookingId: 5, FarmerId: 9, OwnerId: 20, Status: domain.BookingConfirmed, Currency: "INR", EndDate: yesterday}
+	request := domain.NewClaimRequest{BookingId: 5, OwnerId: 20, Amount: domain.Rupees(8000), Description: "cracked bonnet", Evidence: []string{"https://photos.example/1.jpg"}}
+
+	t.Run("when the farmer does not own the machine", func(t *testing.T) {
+		s.repo.On("GetBookingParties", ctx, uint(5)).Return(parties, nil).Once()
+		renter := request
+		renter.OwnerId = 9
+		_, err := s.service.FileClaim(ctx, renter)
+		assert.Equal(t, ErrClaimBookingNotFound, err)
+	})
+
+	t.Run("when the booking has not ended", func(t *testing.T) {
+		ongoing := parties
+		ongoing.EndDate = time.Now().Format("2006-01-02")
+		s.repo.On("GetBookingParties", ctx, uint(5)).Return(ongoing, nil).Once()
+		_, err := s.service.FileClaim(ctx, request)
+		assert.Equal(t, ErrBookingNotCompleted, err)
+	})
+
+	t.Run("when the filing window has closed", func(t *testing.T) {
+		old := parties
+		old.EndDate = time.Now().AddDate(0, 0, -30).Format("2006-01-02")
+		s.repo.On("GetBookingParties", ctx, uint(5)).Return(old, nil).Once()
+		_, err := s.service.FileClaim(ctx, request)
+		assert.Equal(t, ErrClaimWindowClosed, err)
+	})
+
+	t.Run("when the claim is filed", func(t *testing.T) {
+		notifier.notified = nil
+		s.repo.On("GetBookingParties", ctx, uint(5)).Return(parties, nil).Once()
+		s.repo.On("AddClaim", ctx, mock.MatchedBy(func(c *domain.Claim) bool {
+			return c.FarmerId == 9 && c.OwnerId == 20 && c.RespondBy.After(time.Now().Add(71*time.Hour))
+		})).Return(nil).Once()
+
+		got, err := s.service.FileClaim(ctx, request)
+		require.NoError(t, err)
+		assert.Equal(t, domain.Rupees(8000), got.Amount)
+		assert.Equal(t, []uint{9}, notifier.notified)
+	})
+
+	t.Run("when the booking already has a claim", func(t *testing.T) {
+		s.repo.On("GetBookingParties", ctx, uint(5)).Return(parties, nil).Once()
+		s.repo.On("AddClaim", ctx, mock.Anything).Return(sql.ErrNoRows).Once()
+		_, err := s.service.FileClaim(ctx, request)
+		assert.Equal(t, ErrClaimExists, err)
+	})
+}
+
+func (s *ServiceTestSuite) TestFarmService_RespondToClaim() {
+	t := s.T()
+	ctx := context.TODO()
+
+	notifier := &recordingNotifier{}
+	s.service.(*FarmService).notifier = notifier
+
+	claim := domain.Claim{Id: 3, BookingId: 5, OwnerId: 20, FarmerId: 9, Amount: domain.Rupees(8000), Status: domain.ClaimOpen}
+
+	t.Run("when the owner tries to respond", func(t *testing.T) {
+		s.repo.On("GetClaim", ctx, uint(3)).Return(claim, nil).Once()
+		_, err := s.service.RespondToClaim(ctx, 3, 20, domain.ClaimResponseRequest{Accept: true})
+		assert.Equal(t, ErrClaimNotFound, err)
+	})
+
+	t.Run("when the renter accepts", func(t *testing.T) {
+		notifier.notified = nil
+		approved := claim
+		approved.Status = domain.ClaimApproved
+		decision := domain.ClaimDecision{Status: domain.ClaimApproved, Amount: domain.Rupees(8000), Note: "accepted by the renter", ActorId: 9}
+		s.repo.On("GetClaim", ctx, uint(3)).Return(claim, nil).Once()
+		s.repo.On("DecideClaim", ctx, uint(3), []string{domain.ClaimOpen}, decision).Return(approved, nil).Once()
+		s.repo.On("GetClaim", ctx, uint(3)).Return(approved, nil).Once()
+
+		got, err := s.service.RespondToClaim(ctx, 3, 9, domain.ClaimResponseRequest{Accept: true})
+		require.NoError(t, err)
+		assert.Equal(t, domain.ClaimApproved, got.Status)
+		assert.Equal(t, []uint{20}, notifier.notified)
+	})
+
+	t.Run("when the response deadline has passed", func(t *testing.T) {
+		s.repo.On("GetClaim", ctx, uint(3)).Return(claim, nil).Once()
+		s.repo.On("DisputeClaim", ctx, uint(3), uint(9), "it was already cracked", []string(nil)).Return(sql.ErrNoRows).Once()
+		_, err := s.service.RespondToClaim(ctx, 3, 9, domain.ClaimResponseRequest{Response: "it was already cracked"})
+		assert.Equal(t, ErrClaimNotOpen, err)
+	})
+}
+
+func (s *ServiceTestSuite) TestFarmService_DecideClaim() {
+	t := s.T()
+	ctx := context.TODO()
+
+	notifier := &recordingNotifier{}
+	s.service.(*FarmService).notifier = notifier
+
+	claim := domain.Claim{Id: 3, BookingId: 5, OwnerId: 20, FarmerId: 9, Amount: domain.Rupees(8000), Status: domain.ClaimDisputed}
+
+	t.Run("when more is approved than was claimed", func(t *testing.T) {
+		s.repo.On("GetClaim", ctx, uint(3)).Return(claim, nil).Once()
+		_, err := s.service.DecideClaim(ctx, 3, 1, domain.ClaimDecisionRequest{Approve: true, Amount: domain.Rupees(9000), Note: "generous"})
+		assert.Equal(t, ErrInvalidClaimAmount, err)
+	})
+
+	t.Run("when the claim is approved in part", func(t *testing.T) {
+		notifier.notified = nil
+		approved := claim
+		approved.Status = domain.ClaimApproved
+		approved.Approved = domain.Rupees(6000)
+		decision := domain.ClaimDecision{Status: domain.ClaimApproved, Amount: domain.Rupees(6000), Note: "photos show the crack", ActorId: 1}
+		s.repo.On("GetClaim", ctx, uint(3)).Return(claim, nil).Once()
+		s.repo.On("DecideClaim", ctx, uint(3), []string{domain.ClaimDisputed, domain.ClaimEscalated}, decision).Return(approved, nil).Once()
+		s.repo.On("GetClaim", ctx, uint(3)).Return(approved, nil).Once()
+
+		got, err := s.service.DecideClaim(ctx, 3, 1, domain.ClaimDecisionRequest{Approve: true, Amount: domain.Rupees(6000), Note: "photos show the crack"})
+		require.NoError(t, err)
+		assert.Equal(t, domain.Rupees(6000), got.Approved)
+		assert.Equal(t, []uint{20, 9}, notifier.notified)
+	})
+
+	t.Run("when the claim is still open", func(t *testing.T) {
+		s.repo.On("GetClaim", ctx, uint(3)).Return(claim, nil).Once()
+		s.repo.On("DecideClaim", ctx, uint(3), mock.Anything, mock.Anything).Return(domain.Claim{}, sql.ErrNoRows).Once()
+		_, err := s.service.DecideClaim(ctx, 3, 1, domain.ClaimDecisionRequest{Note: "too early"})
+		assert.Equal(t, ErrClaimNotDecidable, err)
+	})
+}
+
+func (s *ServiceTestSuite) TestFarmService_EscalateOverdueClaims() {
+	t := s.T()
+	ctx := context.TODO()
+
+	notifier := &recordingNotifier{}
+	s.service.(*FarmService).notifier = notifier
+
+	s.repo.On("EscalateOverdueClaims", ctx).Return([]domain.Claim{{Id: 3, BookingId: 5, OwnerId: 20, FarmerId: 9}}, nil).Once()
+
+	got, err := s.service.EscalateOverdueClaims(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, got)
+	assert.Equal(t, []uint{20, 9}, notifier.notified)
+}
+
+func (s *HandlerTestSuite) Test_respondToClaimHandler() {
+	t := s.T()
+
+	deps := dependencies{
+		FarmService: s.service,
+	}
+
+	t.Run("when a dispute has no response", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/claims/3/respond", bytes.NewBufferString(`{"accept": false}`))
+		r = r.WithContext(context.WithValue(r.Context(), "token", uint(9)))
+		r = mux.SetURLVars(r, map[string]string{"id": "3"})
+		w := httptest.NewRecorder()
+
+		respondToClaimHandler(deps).ServeHTTP(w, r)
+		exp, _ := json.Marshal(invalidField("response", "is required to dispute a claim"))
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+		assert.Equal(t, string(exp), w.Body.String())
+	})
+
+	t.Run("when the claim is disputed", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/claims/3/respond", bytes.NewBufferString(`{"response": "it was already cracked", "evidence": ["https://photos.example/2.jpg"]}`))
+		r = r.WithContext(context.WithValue(r.Context(), "token", uint(9)))
+		r = mux.SetURLVars(r, map[string]string{"id": "3"})
+		w := httptest.NewRecorder()
+		request := domain.ClaimResponseRequest{Response: "it was already cracked", Evidence: []string{"https://photos.example/2.jpg"}}
+		respBody := domain.Claim{Id: 3, BookingId: 5, Status: domain.ClaimDisputed, Response: request.Response, ResponseEvidence: request.Evidence}
+		s.service.On("RespondToClaim", r.Context(), uint(3), uint(9), request).Return(respBody, nil).Once()
+
+		respondToClaimHandler(deps).ServeHTTP(w, r)
+		exp, _ := json.Marshal(respBody)
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+		assert.Equal(t, string(exp), w.Body.String())
+	})
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/validate"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxBodyBytes is the largest request body a handler reads.
+const maxBodyBytes = 1 << 20
+
+var ErrBodyTooLarge = api.TooLarge(fmt.Sprintf("request body is larger than %d bytes", maxBodyBytes))
+
+// readBody reads the body of r, up to maxBodyBytes.
+func readBody(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes+1))
+	if err != nil {
+		return nil, api.BadRequest("request body could not be read")
+	}
+	if len(body) > maxBodyBytes {
+		return nil, ErrBodyTooLarge
+	}
+	return body, nil
+}
+
+// tokenFields are the fields of a request body that the server fills in from
+// the token. Older clients still send them, so they are dropped before
+// decoding rather than refused; they never reach the request.
+var tokenFields = []string{"farmer_id", "owner_id"}
+
+// decode reads the JSON body of r into v, a pointer to a request struct, and
+// checks it against the validate tags of its fields and any further checks
+// of the handler. Fields v does not have are refused rather than dropped, so
+// a misspelt field is not silently ignored. Every field at fault is reported
+// in the one error.
+func decode(r *http.Request, v interface{}, checks ...func() validate.Errors) error {
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+	body = dropTokenFields(body)
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return invalidBody(err)
+	}
+
+	errs := validate.Struct(v)
+	for _, check := range checks {
+		errs = append(errs, check()...)
+	}
+	return invalidFields(errs)
+}
+
+// dropTokenFields removes the tokenFields from a body that is a JSON object.
+// Any other body is returned as it is, for decoding to judge.
+func dropTokenFields(body []byte) []byte {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return body
+	}
+
+	dropped := false
+	for _, name := range tokenFields {
+		if _, ok := fields[name]; ok {
+			delete(fields, name)
+			dropped = true
+		}
+	}
+	if !dropped {
+		return body
+	}
+
+	trimmed, err := json.Marshal(fields)
+	if err != nil {
+		return body
+	}
+	return trimmed
+}
+
+// invalidFields is the error for the fields at fault in a request, or nil
+// if there are none.
+func invalidFields(errs validate.Errors) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	fields := make([]api.FieldError, len(errs))
+	for i, fieldErr := range errs {
+		fields[i] = api.FieldError{Field: fieldErr.Field, Message: fieldErr.Message}
+	}
+	invalid := api.Unprocessable(errs.Error())
+	invalid.Fields = fields
+	return invalid
+}
+
+// unknownField is the field named by a decoding error of
+// DisallowUnknownFields, which encoding/json does not export a type for.
+func unknownField(err error) (field string, ok bool) {
+	const prefix = "json: unknown field "
+	if !strings.HasPrefix(err.Error(), prefix) {
+		return "", false
+	}
+	field, err = strconv.Unquote(strings.TrimPrefix(err.Error(), prefix))
+	return field, err == nil
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/domain"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// invalidField is the response to a request with one field at fault.
+func invalidField(field string, message string) api.ErrorResponse {
+	invalid := api.Unprocessable(field + ": " + message)
+	invalid.Fields = []api.FieldError{{Field: field, Message: message}}
+	return api.ErrorResponse{Error: *invalid}
+}
+
+func Test_decode(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		want   api.Error
+	}{
+		{
+			name:   "when a field is unknown",
+			body:   `{"name": "tractor", "base_hourly_charge": 500, "hourly_charge": 500}`,
+			status: http.StatusBadRequest,
+			want:   api.Error{Code: api.CodeBadRequest, Message: `unknown field "hourly_charge"`, Fields: []api.FieldError{{Field: "hourly_charge", Message: "is not a field of this request"}}},
+		},
+		{
+			name:   "when the body is too large",
+			body:   `{"name": "tractor", "description": "` + strings.Repeat("a", maxBodyBytes) + `"}`,
+			status: http.StatusRequestEntityTooLarge,
+			want:   *ErrBodyTooLarge,
+		},
+		{
+			name:   "when several fields are at fault",
+			body:   `{"name": "", "base_hourly_charge": -500, "latitude": 91, "no_show_policy": "charge_double"}`,
+			status: http.StatusUnprocessableEntity,
+			want: api.Error{Code: api.CodeInvalid, Message: "name: is required; base_hourly_charge: invalid amount; latitude: must be at most 90; no_show_policy: must be one of [keep_payment forfeit_deposit refund]", Fields: []api.FieldError{
+				{Field: "name", Message: "is required"},
+				{Field: "base_hourly_charge", Message: "invalid amount"},
+				{Field: "latitude", Message: "must be at most 90"},
+				{Field: "no_show_policy", Message: "must be one of [keep_payment forfeit_deposit refund]"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/machines", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			var machine domain.NewMachineRequest
+			fail(w, r, decode(r, &machine))
+
+			exp, _ := json.Marshal(api.ErrorResponse{Error: tt.want})
+			assert.Equal(t, tt.status, w.Result().StatusCode)
+			assert.Equal(t, string(exp), w.Body.String())
+		})
+	}
+}
+
+func (s *HandlerTestSuite) Test_registerHandler_fieldErrors() {
+	t := s.T()
+
+	bodyReader := strings.NewReader(`{"fname": "John", "email": "john@gmail", "phone": "12345", "password": "secret", "state": "29", "gstin": "27AAPFU0939F1ZV"}`)
+	r := httptest.NewRequest(http.MethodPost, "/register", bodyReader)
+	w := httptest.NewRecorder()
+
+	registerHandler(dependencies{FarmService: s.service}).ServeHTTP(w, r)
+
+	var got api.ErrorResponse
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+	assert.Equal(t, []api.FieldError{
+		{Field: "email", Message: "must be an email address"},
+		{Field: "phone", Message: "must be a 10 digit phone number"},
+		{Field: "password", Message: "must be at least 8 characters"},
+		{Field: "gstin", Message: "GSTIN does not belong to the given state"},
+	}, got.Error.Fields)
+}
+
+func (s *HandlerTestSuite) Test_availabilityHandler_invalidDate() {
+	t := s.T()
+
+	r := httptest.NewRequest(http.MethodPost, "/availability", strings.NewReader(`{"machine_id": 1, "date": "2026-02-30"}`))
+	r = r.WithContext(context.WithValue(r.Context(), "token", uint(1)))
+	w := httptest.NewRecorder()
+
+	availabilityHandler(dependencies{FarmService: s.service}).ServeHTTP(w, r)
+
+	exp, _ := json.Marshal(invalidField("date", "must be a date as YYYY-MM-DD"))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+	assert.Equal(t, string(exp), w.Body.String())
+}
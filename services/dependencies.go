@@ -1,11 +1,22 @@
 package services
 
 import (
+	"FarmEasy/config"
 	"FarmEasy/db"
+	"FarmEasy/notify"
+	"FarmEasy/payment"
+	"FarmEasy/scheduler"
+	"errors"
+	"fmt"
 )
 
 type dependencies struct {
 	FarmService Service
+	// Locker takes the locks that keep background jobs to one server.
+	Locker scheduler.Locker
+	// FakePayments is set when payments go through the built-in fake
+	// provider, which then also gets an endpoint to pay its orders.
+	FakePayments *payment.Fake
 }
 
 func InitDependencies() (deps dependencies, err error) {
@@ -13,10 +24,50 @@ func InitDependencies() (deps dependencies, err error) {
 	if err != nil {
 		return
 	}
-	farmService := NewFarmService(store)
 
-	deps = dependencies{
-		FarmService: farmService,
+	provider, err := paymentProvider(&deps)
+	if err != nil {
+		return
+	}
+
+	channels := []notify.Channel{notify.NewInApp()}
+	senders := []struct{ channel, provider string }{
+		{notify.Email, config.EmailProvider()},
+		{notify.SMS, config.SMSProvider()},
+	}
+	for _, sender := range senders {
+		switch sender.provider {
+		case "":
+			// the channel is off
+		case "fake":
+			channels = append(channels, notify.NewFake(sender.channel))
+		default:
+			err = fmt.Errorf("unknown %s provider %q", sender.channel, sender.provider)
+			return
+		}
 	}
+
+	deps.Locker = store
+	deps.FarmService = NewFarmService(store, provider, channels...)
 	return deps, nil
 }
+
+// paymentProvider sets up the configured payment gateway. The provider and
+// its webhook secret must both be set; the fake is only used when asked for.
+func paymentProvider(deps *dependencies) (provider payment.Provider, err error) {
+	name, secret := config.PaymentProvider(), config.PaymentWebhookSecret()
+	if name == "" {
+		return nil, errors.New("PAYMENT_PROVIDER is not set")
+	}
+	if secret == "" {
+		return nil, errors.New("PAYMENT_WEBHOOK_SECRET is not set")
+	}
+
+	switch name {
+	case "fake":
+		deps.FakePayments = payment.NewFake(secret)
+		return deps.FakePayments, nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", name)
+	}
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"FarmEasy/config"
+	"errors"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_paymentProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		secret   string
+		wantErr  error
+	}{
+		{"when the provider is not set", "", "secret", errors.New("PAYMENT_PROVIDER is not set")},
+		{"when the webhook secret is not set", "fake", "", errors.New("PAYMENT_WEBHOOK_SECRET is not set")},
+		{"when the provider is unknown", "paypal", "secret", errors.New(`unknown payment provider "paypal"`)},
+		{"when the fake is chosen", "fake", "secret", nil},
+	}
+	defer func() {
+		viper.Reset()
+		config.Load()
+	}()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("PAYMENT_PROVIDER", tt.provider)
+			viper.Set("PAYMENT_WEBHOOK_SECRET", tt.secret)
+
+			var deps dependencies
+			provider, err := paymentProvider(&deps)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, "fake", provider.Name())
+				assert.NotNil(t, deps.FakePayments)
+			}
+		})
+	}
+}
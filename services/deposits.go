@@ -0,0 +1,235 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/config"
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// unclaimedDepositBatch is how many unclaimed deposits are released in a run.
+const unclaimedDepositBatch = 100
+
+func (s *FarmService) GetDeposits(ctx context.Context, farmerId uint) (deposits []domain.Deposit, err error) {
+	deposits, err = s.store.GetDeposits(ctx, farmerId)
+	return
+}
+
+// GetDeposit returns the deposit and its ledger to the renter or the owner.
+func (s *FarmService) GetDeposit(ctx context.Context, depositId uint, farmerId uint) (deposit domain.Deposit, err error) {
+
+	deposit, err = s.store.GetDeposit(ctx, depositId)
+	if err == sql.ErrNoRows {
+		err = ErrDepositNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	if deposit.FarmerId != farmerId && deposit.OwnerId != farmerId {
+		return domain.Deposit{}, ErrDepositNotFound
+	}
+	return
+}
+
+// ReleaseDeposit lets the owner give a held deposit back before it would be
+// released on its own.
+func (s *FarmService) ReleaseDeposit(ctx context.Context, depositId uint, farmerId uint) (deposit domain.Deposit, err error) {
+
+	deposit, err = s.ownerDeposit(ctx, depositId, farmerId)
+	if err != nil {
+		return
+	}
+
+	err = s.store.ReleaseDeposit(ctx, depositId, "released by owner")
+	if err == sql.ErrNoRows {
+		err = ErrDepositNotHeld
+	}
+	if err != nil {
+		return
+	}
+
+	s.notifier.Notify(ctx, deposit.FarmerId, fmt.Sprintf("Your deposit of %s for booking %d was released", deposit.Amount, deposit.BookingId))
+	return s.store.GetDeposit(ctx, depositId)
+}
+
+// CaptureDeposit keeps part or all of a held deposit against damage and
+// releases the rest.
+func (s *FarmService) CaptureDeposit(ctx context.Context, depositId uint, farmerId uint, request domain.DepositCaptureRequest) (deposit domain.Deposit, err error) {
+
+	deposit, err = s.ownerDeposit(ctx, depositId, farmerId)
+	if err != nil {
+		return
+	}
+
+	if request.Amount.Currency != deposit.Amount.Currency || request.Amount.Minor <= 0 || request.Amount.Minor > deposit.Amount.Minor {
+		err = ErrInvalidCaptureAmount
+		return
+	}
+
+	err = s.store.CaptureDeposit(ctx, depositId, request.Amount, request.Reason)
+	if err == sql.ErrNoRows {
+		err = ErrDepositNotHeld
+	}
+	if err != nil {
+		return
+	}
+
+	s.notifier.Notify(ctx, deposit.FarmerId, fmt.Sprintf("%s of your deposit for booking %d was kept: %s", request.Amount, deposit.BookingId, request.Reason))
+	return s.store.GetDeposit(ctx, depositId)
+}
+
+func (s *FarmService) ownerDeposit(ctx context.Context, depositId uint, farmerId uint) (deposit domain.Deposit, err error) {
+
+	deposit, err = s.store.GetDeposit(ctx, depositId)
+	if err == sql.ErrNoRows {
+		err = ErrDepositNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	if deposit.OwnerId != farmerId {
+		return domain.Deposit{}, ErrDepositNotFound
+	}
+	return
+}
+
+// ReleaseUnclaimedDeposits gives back the deposits still held once the claim
+// filing window of their booking has closed without a claim, and tells each
+// renter. It returns how many were released.
+func (s *FarmService) ReleaseUnclaimedDeposits(ctx context.Context) (released int, err error) {
+
+	deposits, err := s.store.ReleaseUnclaimedDeposits(ctx, config.ClaimFilingWindow(), unclaimedDepositBatch)
+	if err != nil {
+		return
+	}
+
+	for i := range deposits {
+		s.notifyDepositSettled(ctx, &deposits[i])
+	}
+	return len(deposits), nil
+}
+
+// notifyDepositSettled tells the renter what became of a deposit that was
+// settled with their booking, if one was. A deposit is only kept with the
+// booking when its renter did not show up.
+func (s *FarmService) notifyDepositSettled(ctx context.Context, deposit *domain.Deposit) {
+
+	switch {
+	case deposit == nil:
+	case deposit.Status == domain.DepositCaptured:
+		s.notifier.Notify(ctx, deposit.FarmerId, fmt.Sprintf("Your deposit of %s for booking %d was kept because you did not show up", deposit.Amount, deposit.BookingId))
+	default:
+		s.notifier.Notify(ctx, deposit.FarmerId, fmt.Sprintf("Your deposit of %s for booking %d was released", deposit.Amount, deposit.BookingId))
+	}
+}
+
+// releaseBookingDeposit releases the deposit held for a booking, if any. It is
+// best effort, failing to release does not undo what released it.
+func (s *FarmService) releaseBookingDeposit(ctx context.Context, bookingId uint, note string) {
+
+	deposit, err := s.store.GetBookingDeposit(ctx, bookingId)
+	if err != nil || deposit.Status != domain.DepositHeld {
+		return
+	}
+
+	if err = s.store.ReleaseDeposit(ctx, deposit.Id, note); err != nil {
+		logrus.WithField("err", err.Error()).Error("error releasing deposit")
+		return
+	}
+	s.notifier.Notify(ctx, deposit.FarmerId, fmt.Sprintf("Your deposit of %s for booking %d was released", deposit.Amount, bookingId))
+}
+
+func getDepositsHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		deposits, err := deps.FarmService.GetDeposits(r.Context(), farmerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, deposits)
+	}
+}
+
+func getDepositHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		depositId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid deposit id"))
+			return
+		}
+
+		deposit, err := deps.FarmService.GetDeposit(r.Context(), uint(depositId), farmerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, deposit)
+	}
+}
+
+func releaseDepositHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		depositId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid deposit id"))
+			return
+		}
+
+		deposit, err := deps.FarmService.ReleaseDeposit(r.Context(), uint(depositId), farmerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, deposit)
+	}
+}
+
+func captureDepositHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		depositId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid deposit id"))
+			return
+		}
+
+		var request domain.DepositCaptureRequest
+		if err := decode(r, &request); err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		deposit, err := deps.FarmService.CaptureDeposit(r.Context(), uint(depositId), farmerId, request)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, deposit)
+	}
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"FarmEasy/domain"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *ServiceTestSuite) TestFarmService_CaptureDeposit() {
+	t := s.T()
+	ctx := context.TODO()
+
+	held := domain.Deposit{Id: 5, BookingId: 11, FarmerId: 9, OwnerId: 20, Amount: domain.Rupees(25000), Status: domain.DepositHeld}
+
+	t.Run("when the farmer is the renter", func(t *testing.T) {
+		s.repo.On("GetDeposit", ctx, uint(5)).Return(held, nil).Once()
+		_, err := s.service.CaptureDeposit(ctx, 5, 9, domain.DepositCaptureRequest{Amount: domain.Rupees(4000), Reason: "broken blade"})
+		assert.Equal(t, ErrDepositNotFound, err)
+	})
+
+	t.Run("when the amount is more than the deposit", func(t *testing.T) {
+		s.repo.On("GetDeposit", ctx, uint(5)).Return(held, nil).Once()
+		_, err := s.service.CaptureDeposit(ctx, 5, 20, domain.DepositCaptureRequest{Amount: domain.Rupees(25001), Reason: "broken blade"})
+		assert.Equal(t, ErrInvalidCaptureAmount, err)
+	})
+
+	t.Run("when the deposit was already released", func(t *testing.T) {
+		s.repo.On("GetDeposit", ctx, uint(5)).Return(held, nil).Once()
+		s.repo.On("CaptureDeposit", ctx, uint(5), domain.Rupees(4000), "broken blade").Return(sql.ErrNoRows).Once()
+		_, err := s.service.CaptureDeposit(ctx, 5, 20, domain.DepositCaptureRequest{Amount: domain.Rupees(4000), Reason: "broken blade"})
+		assert.Equal(t, ErrDepositNotHeld, err)
+	})
+
+	t.Run("when the owner captures part of it", func(t *testing.T) {
+		captured := held
+		captured.Status = domain.DepositCaptured
+		captured.Captured = domain.Rupees(4000)
+		s.repo.On("GetDeposit", ctx, uint(5)).Return(held, nil).Once()
+		s.repo.On("CaptureDeposit", ctx, uint(5), domain.Rupees(4000), "broken blade").Return(nil).Once()
+		s.repo.On("GetDeposit", ctx, uint(5)).Return(captured, nil).Once()
+
+		got, err := s.service.CaptureDeposit(ctx, 5, 20, domain.DepositCaptureRequest{Amount: domain.Rupees(4000), Reason: "broken blade"})
+		require.NoError(t, err)
+		assert.Equal(t, captured, got)
+	})
+}
+
+func (s *ServiceTestSuite) TestFarmService_ReleaseUnclaimedDeposits() {
+	t := s.T()
+	ctx := context.TODO()
+
+	notifier := &recordingNotifier{}
+	s.service.(*FarmService).notifier = notifier
+
+	released := domain.Deposit{Id: 5, BookingId: 11, FarmerId: 9, OwnerId: 20, Amount: domain.Rupees(25000), Status: domain.DepositReleased}
+	s.repo.On("ReleaseUnclaimedDeposits", ctx, 7, uint(unclaimedDepositBatch)).Return([]domain.Deposit{released}, nil).Once()
+
+	got, err := s.service.ReleaseUnclaimedDeposits(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, got)
+	assert.Equal(t, []string{"Your deposit of 25000.00 INR for booking 11 was released"}, notifier.messages)
+}
+
+func (s *HandlerTestSuite) Test_captureDepositHandler() {
+	t := s.T()
+
+	deps := dependencies{
+		FarmService: s.service,
+	}
+
+	t.Run("when the reason is missing", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/deposits/5/capture", bytes.NewBufferString(`{"amount": 4000}`))
+		r = r.WithContext(context.WithValue(r.Context(), "token", uint(20)))
+		r = mux.SetURLVars(r, map[string]string{"id": "5"})
+		w := httptest.NewRecorder()
+
+		captureDepositHandler(deps).ServeHTTP(w, r)
+		exp, _ := json.Marshal(invalidField("reason", "is required"))
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+		assert.Equal(t, string(exp), w.Body.String())
+	})
+
+	t.Run("when the deposit is captured", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/deposits/5/capture", bytes.NewBufferString(`{"amount": {"amount": "4000", "currency": "INR"}, "reason": "broken blade"}`))
+		r = r.WithContext(context.WithValue(r.Context(), "token", uint(20)))
+		r = mux.SetURLVars(r, map[string]string{"id": "5"})
+		w := httptest.NewRecorder()
+		respBody := domain.Deposit{Id: 5, BookingId: 11, FarmerId: 9, OwnerId: 20, Amount: domain.Rupees(25000), Captured: domain.Rupees(4000), Status: domain.DepositCaptured}
+		s.service.On("CaptureDeposit", r.Context(), uint(5), uint(20), domain.DepositCaptureRequest{Amount: domain.Rupees(4000), Reason: "broken blade"}).Return(respBody, nil).Once()
+
+		captureDepositHandler(deps).ServeHTTP(w, r)
+		exp, _ := json.Marshal(respBody)
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+		assert.Equal(t, string(exp), w.Body.String())
+	})
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/domain"
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// GetOwnerEarnings returns what the platform owes the owner, with the
+// statement of how it got there and the payouts made so far.
+func (s *FarmService) GetOwnerEarnings(ctx context.Context, ownerId uint) (earnings domain.OwnerEarnings, err error) {
+
+	statement, err := s.store.GetOwnerStatement(ctx, ownerId)
+	if err != nil {
+		return
+	}
+
+	payouts, err := s.store.GetPayouts(ctx, ownerId)
+	if err != nil {
+		return
+	}
+
+	earnings = domain.OwnerEarnings{
+		OwnerId:   ownerId,
+		Balances:  []domain.Money{},
+		Statement: []domain.StatementLine{},
+		Payouts:   []domain.Payout{},
+	}
+	if payouts != nil {
+		earnings.Payouts = payouts
+	}
+
+	balances := map[string]int{}
+	for _, line := range statement {
+		i, ok := balances[line.Amount.Currency]
+		if !ok {
+			i = len(earnings.Balances)
+			balances[line.Amount.Currency] = i
+			earnings.Balances = append(earnings.Balances, domain.NewMoney(0, line.Amount.Currency))
+		}
+		earnings.Balances[i] = earnings.Balances[i].Add(line.Amount)
+		line.Balance = earnings.Balances[i]
+		earnings.Statement = append(earnings.Statement, line)
+	}
+
+	return
+}
+
+// SettleOwnerBalances pays out every owner's balance in one batch.
+func (s *FarmService) SettleOwnerBalances(ctx context.Context) (batch domain.PayoutBatch, err error) {
+
+	batch, err = s.store.SettleOwnerBalances(ctx)
+	if err != nil {
+		return
+	}
+
+	for _, payout := range batch.Payouts {
+		s.notifier.Notify(ctx, payout.OwnerId, fmt.Sprintf("A payout of %s is on its way", payout.Amount))
+	}
+	return
+}
+
+func getOwnerEarningsHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		ownerId := r.Context().Value("token").(uint)
+
+		earnings, err := deps.FarmService.GetOwnerEarnings(r.Context(), ownerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, earnings)
+	}
+}
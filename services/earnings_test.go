@@ -0,0 +1,72 @@
+package services
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *ServiceTestSuite) TestFarmService_GetOwnerEarnings() {
+	t := s.T()
+	ctx := context.TODO()
+
+	statement := []domain.StatementLine{
+		{TransactionId: 1, Kind: "charge", Reference: "payment:3", Amount: domain.Rupees(648)},
+		{TransactionId: 4, Kind: "deposit_capture", Reference: "deposit:5", Amount: domain.NewMoney(1000, "USD")},
+		{TransactionId: 6, Kind: "payout", Reference: "payout:2", Amount: domain.Rupees(-600)},
+	}
+	s.repo.On("GetOwnerStatement", ctx, uint(20)).Return(statement, nil).Once()
+	s.repo.On("GetPayouts", ctx, uint(20)).Return(nil, nil).Once()
+
+	got, err := s.service.GetOwnerEarnings(ctx, 20)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Money{domain.Rupees(48), domain.NewMoney(1000, "USD")}, got.Balances)
+	require.Len(t, got.Statement, 3)
+	assert.Equal(t, domain.Rupees(648), got.Statement[0].Balance)
+	assert.Equal(t, domain.NewMoney(1000, "USD"), got.Statement[1].Balance)
+	assert.Equal(t, domain.Rupees(48), got.Statement[2].Balance)
+	assert.NotNil(t, got.Payouts)
+}
+
+func (s *ServiceTestSuite) TestFarmService_SettleOwnerBalances() {
+	t := s.T()
+	ctx := context.TODO()
+
+	notifier := &recordingNotifier{}
+	s.service.(*FarmService).notifier = notifier
+
+	batch := domain.PayoutBatch{Id: 2, Payouts: []domain.Payout{
+		{Id: 5, BatchId: 2, OwnerId: 20, Amount: domain.Rupees(648), Status: domain.PayoutPending},
+		{Id: 6, BatchId: 2, OwnerId: 21, Amount: domain.Rupees(100), Status: domain.PayoutPending},
+	}}
+	s.repo.On("SettleOwnerBalances", ctx).Return(batch, nil).Once()
+
+	got, err := s.service.SettleOwnerBalances(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, batch, got)
+	assert.Equal(t, []uint{20, 21}, notifier.notified)
+}
+
+func (s *HandlerTestSuite) Test_getOwnerEarningsHandler() {
+	t := s.T()
+
+	deps := dependencies{
+		FarmService: s.service,
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/owner/earnings", nil)
+	r = r.WithContext(context.WithValue(r.Context(), "token", uint(20)))
+	w := httptest.NewRecorder()
+	respBody := domain.OwnerEarnings{OwnerId: 20, Balances: []domain.Money{domain.Rupees(48)}, Statement: []domain.StatementLine{}, Payouts: []domain.Payout{}}
+	s.service.On("GetOwnerEarnings", r.Context(), uint(20)).Return(respBody, nil).Once()
+
+	getOwnerEarningsHandler(deps).ServeHTTP(w, r)
+	exp, _ := json.Marshal(respBody)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Equal(t, string(exp), w.Body.String())
+}
@@ -1,9 +1,149 @@
 package services
 
-import "errors"
+import (
+	"FarmEasy/api"
+	"FarmEasy/db"
+	"FarmEasy/domain"
+	"FarmEasy/payment"
+	"FarmEasy/tax"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
 
 var (
-	ErrUnauthorized   = errors.New("incorrect email or password")
-	ErrDuplicateEmail = errors.New("account exists for the given email")
-	ErrDuplicatePhone = errors.New("account exists for the given phone")
+	ErrUnauthorized   = api.Unauthorized("incorrect email or password")
+	ErrDuplicateEmail = api.Conflict("account exists for the given email")
+	ErrDuplicatePhone = api.Conflict("account exists for the given phone")
+
+	ErrIdempotencyKeyReused   = api.Unprocessable("idempotency key was already used with a different request")
+	ErrIdempotencyKeyInFlight = api.Conflict("a request with this idempotency key is still being processed")
+	ErrInvalidIdempotencyKey  = api.BadRequest("invalid idempotency key")
+
+	ErrBookingNotFound    = api.NotFound("booking not found or can no longer be cancelled")
+	ErrSeriesNotFound     = api.NotFound("booking series not found")
+	ErrInvalidRecurrence  = api.Unprocessable("invalid recurrence rule")
+	ErrTooManyOccurrences = api.Unprocessable("recurrence rule expands to too many occurrences")
+	ErrSeriesStartsInPast = api.Unprocessable("start_date must not be in the past")
+	ErrSeriesUnavailable  = api.Conflict("every occurrence clashes with an existing booking")
+
+	ErrWaitlistEntryNotFound = api.NotFound("waitlist entry not found")
+	ErrNoWaitlistOffer       = api.Conflict("no open booking offer for this waitlist entry")
+
+	ErrInvalidSearchWindow = api.Unprocessable("invalid search window")
+	ErrSearchWindowTooLong = api.Unprocessable("search window is longer than 14 days")
+	ErrNoSearchCriteria    = api.Unprocessable("category or machine ids required")
+
+	ErrEmptyCart    = api.Unprocessable("cart has no lines")
+	ErrCartTooLarge = api.Unprocessable("cart has too many lines")
+
+	ErrInvoiceNotFound = api.NotFound("invoice not found")
+
+	ErrPaymentNotFound       = api.NotFound("payment not found")
+	ErrInvoiceNotPayable     = api.Conflict("invoice is already paid")
+	ErrPaymentNotRefundable  = api.Conflict("only captured payments can be refunded")
+	ErrPaymentAmountMismatch = api.Unprocessable("paid amount does not match the invoice")
+
+	ErrDepositNotFound      = api.NotFound("deposit not found")
+	ErrDepositNotHeld       = api.Conflict("deposit is no longer held")
+	ErrInvalidCaptureAmount = api.Unprocessable("capture amount must be positive and at most the deposit, in its currency")
+
+	ErrPromoNotFound = api.NotFound("promo code not found")
+
+	ErrHandoverBookingNotFound = api.NotFound("booking not found")
+	ErrHandoverNotFound        = api.NotFound("handover not found")
+	ErrHandoverExists          = api.Conflict("handover was already recorded for this booking")
+	ErrCheckInRequired         = api.Conflict("check-out needs a check-in confirmed by both parties")
+	ErrMeterBehind             = api.Unprocessable("meter reading is below the check-in reading")
+
+	ErrClaimBookingNotFound = api.NotFound("booking not found")
+	ErrBookingNotCompleted  = api.Conflict("booking is not completed yet")
+	ErrClaimWindowClosed    = api.Conflict("claims for this booking can no longer be filed")
+	ErrClaimExists          = api.Conflict("a claim was already filed for this booking")
+	ErrClaimNotFound        = api.NotFound("claim not found")
+	ErrClaimNotOpen         = api.Conflict("claim is no longer open for a response")
+	ErrClaimNotDecidable    = api.Conflict("claim is not waiting for a decision")
+	ErrInvalidClaimAmount   = api.Unprocessable("approved amount must be positive and at most the claim, in its currency")
+
+	ErrFarmerNotFound        = api.NotFound("farmer not found")
+	ErrReviewBookingNotFound = api.NotFound("booking not found")
+	ErrReviewExists          = api.Conflict("you already reviewed this booking")
+	ErrReviewNotFound        = api.NotFound("review not found")
+	ErrOwnReview             = api.Forbidden("you cannot report your own review")
+	ErrReviewAlreadyReported = api.Conflict("you already reported this review")
+	ErrReviewReportNotOpen   = api.NotFound("review report not found or already resolved")
+	ErrInvalidSearchSort     = api.Unprocessable("sort_by must be empty or rating")
+
+	ErrMachineNotFound       = api.NotFound("machine not found")
+	ErrThreadBookingNotFound = api.NotFound("booking not found")
+	ErrThreadNotFound        = api.NotFound("thread not found")
+
+	ErrNotificationNotFound = api.NotFound("notification not found")
+	ErrUnknownChannel       = api.Unprocessable("unknown notification channel")
+
+	ErrAPIClientNotFound       = api.NotFound("api client not found")
+	ErrWebhookNotFound         = api.NotFound("webhook not found")
+	ErrWebhookDeliveryNotFound = api.NotFound("webhook delivery not found")
 )
+
+// clientErrors are the errors of other packages that a client may see, with
+// how each is answered. They may come wrapped, as in "line 2: slot not empty".
+var clientErrors = []struct {
+	err    error
+	answer func(message string) *api.Error
+}{
+	{db.ErrSlotNotEmpty, api.Conflict},
+	{db.ErrInsufficientFunds, api.Conflict},
+	{domain.ErrUnknownCurrency, api.Unprocessable},
+	{domain.ErrInvalidAmount, api.Unprocessable},
+	{domain.ErrCurrencyMismatch, api.Unprocessable},
+	{domain.ErrPromoInvalid, api.Unprocessable},
+	{domain.ErrPromoExhausted, api.Unprocessable},
+	{domain.ErrPromoNotApplicable, api.Unprocessable},
+	{tax.ErrInvalidGSTIN, api.Unprocessable},
+	{tax.ErrInvalidState, api.Unprocessable},
+	{tax.ErrStateMismatch, api.Unprocessable},
+	{payment.ErrInvalidSignature, api.Unauthorized},
+	{payment.ErrOrderNotFound, api.NotFound},
+	{payment.ErrNotAuthorized, api.Conflict},
+	{payment.ErrNotCaptured, api.Conflict},
+	{payment.ErrAmountMismatch, api.Unprocessable},
+}
+
+// fail answers the request with what went wrong, in the words of err if it
+// is an error the client may see, or as an internal error if it is not.
+func fail(w http.ResponseWriter, r *http.Request, err error) {
+	api.Fail(w, r, clientError(err))
+}
+
+func clientError(err error) error {
+	for _, known := range clientErrors {
+		if errors.Is(err, known.err) {
+			return known.answer(err.Error())
+		}
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return api.NotFound("not found")
+	}
+	return err
+}
+
+// invalidBody is the error for a request body that could not be decoded.
+// It names the field at fault rather than the go type it is read into.
+func invalidBody(err error) error {
+	if known := clientError(err); known != err {
+		return known
+	}
+	if field, ok := unknownField(err); ok {
+		unknown := api.BadRequest(fmt.Sprintf("unknown field %q", field))
+		unknown.Fields = []api.FieldError{{Field: field, Message: "is not a field of this request"}}
+		return unknown
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return api.BadRequest(fmt.Sprintf("%s must not be a %s", typeErr.Field, typeErr.Value))
+	}
+	return api.BadRequest("request body is not valid JSON")
+}
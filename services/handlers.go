@@ -3,8 +3,11 @@ package services
 import (
 	"FarmEasy/api"
 	"FarmEasy/domain"
-	"encoding/json"
+	"FarmEasy/validate"
 	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
 )
 
 type MsgResponse struct {
@@ -18,28 +21,15 @@ func registerHandler(deps dependencies) http.HandlerFunc {
 
 		var farmer domain.NewFarmerRequest
 
-		err := json.NewDecoder(req.Body).Decode(&farmer)
+		err := decode(req, &farmer, func() validate.Errors { return ValidateFarmerTaxDetails(farmer) })
 		if err != nil {
-			api.Response(rw, http.StatusBadRequest, api.Message{Msg: err.Error()})
-			return
-		}
-
-		if err = ValidateFarmerPhone(farmer.Phone); err != nil {
-			api.Response(rw, http.StatusBadRequest, api.Message{Msg: err.Error()})
-
-			return
-		}
-
-		if err = ValidateFarmerEmail(farmer.Email); err != nil {
-
-			api.Response(rw, http.StatusBadRequest, api.Message{Msg: err.Error()})
-
+			fail(rw, req, err)
 			return
 		}
 
 		addedFarmer, err := deps.FarmService.Register(req.Context(), farmer)
 		if err != nil {
-			api.Response(rw, http.StatusBadRequest, api.Message{Msg: "Err - " + err.Error()})
+			fail(rw, req, err)
 			return
 		}
 
@@ -53,20 +43,14 @@ func loginHandler(deps dependencies) http.HandlerFunc {
 
 		var fAuth domain.LoginRequest
 
-		if err := json.NewDecoder(r.Body).Decode(&fAuth); err != nil {
-			api.Response(w, http.StatusBadRequest, api.Message{Msg: err.Error()})
-			return
-		}
-
-		if err := ValidateFarmerEmail(fAuth.Email); err != nil {
-			api.Response(w, http.StatusBadRequest, api.Message{Msg: err.Error()})
-
+		if err := decode(r, &fAuth); err != nil {
+			fail(w, r, err)
 			return
 		}
 
 		tokenString, err := deps.FarmService.Login(r.Context(), fAuth)
 		if err != nil {
-			api.Response(w, http.StatusBadRequest, api.Message{Msg: err.Error()})
+			fail(w, r, err)
 			return
 		}
 
@@ -81,18 +65,16 @@ func addMachineHandler(deps dependencies) http.HandlerFunc {
 
 		var machine domain.NewMachineRequest
 
-		farmerId := r.Context().Value("token")
-
-		machine.OwnerId = farmerId.(uint)
-
-		if err := json.NewDecoder(r.Body).Decode(&machine); err != nil {
-			api.Response(w, http.StatusBadRequest, api.Message{Msg: err.Error()})
+		if err := decode(r, &machine); err != nil {
+			fail(w, r, err)
 			return
 		}
 
+		machine.OwnerId = r.Context().Value("token").(uint)
+
 		addedMachine, err := deps.FarmService.AddMachine(r.Context(), machine)
 		if err != nil {
-			api.Response(w, http.StatusBadRequest, api.Message{Msg: err.Error()})
+			fail(w, r, err)
 			return
 		}
 
@@ -105,7 +87,7 @@ func getMachineHandler(deps dependencies) http.HandlerFunc {
 
 		machines, err := deps.FarmService.GetMachines(r.Context())
 		if err != nil {
-			api.Response(w, http.StatusBadRequest, api.Message{Msg: err.Error()})
+			fail(w, r, err)
 			return
 		}
 
@@ -119,35 +101,41 @@ func bookingHandler(deps dependencies) http.HandlerFunc {
 
 		var booking domain.NewBookingRequest
 
-		id := r.Context().Value("token")
-
-		farmerId := id.(uint)
-
-		booking.FarmerId = farmerId
-
-		if err := json.NewDecoder(r.Body).Decode(&booking); err != nil {
-			api.Response(w, http.StatusBadRequest, api.Message{Msg: err.Error()})
+		if err := decode(r, &booking); err != nil {
+			fail(w, r, err)
 			return
 		}
 
-		if err := ValidateBookingslots(booking.Slots); err != nil {
-			api.Response(w, http.StatusBadRequest, api.Message{Msg: err.Error()})
+		booking.FarmerId = r.Context().Value("token").(uint)
 
+		addedBooking, err := deps.FarmService.BookMachine(r.Context(), booking)
+		if err != nil {
+			fail(w, r, err)
 			return
 		}
 
-		if err := ValidateBookingDate(booking.Date); err != nil {
-			api.Response(w, http.StatusBadRequest, api.Message{Msg: err.Error()})
+		api.Response(w, http.StatusCreated, addedBooking)
+	}
+}
+
+func cancelBookingHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		bookingId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid booking id"))
 			return
 		}
 
-		addedBooking, err := deps.FarmService.BookMachine(r.Context(), booking)
+		cancelled, err := deps.FarmService.CancelBooking(r.Context(), uint(bookingId), farmerId)
 		if err != nil {
-			api.Response(w, http.StatusBadRequest, api.Message{Msg: err.Error()})
+			fail(w, r, err)
 			return
 		}
 
-		api.Response(w, http.StatusCreated, addedBooking)
+		api.Response(w, http.StatusOK, cancelled)
 	}
 }
 
@@ -156,14 +144,14 @@ func availabilityHandler(deps dependencies) http.HandlerFunc {
 
 		var availability domain.AvailabilityRequest
 
-		if err := json.NewDecoder(r.Body).Decode(&availability); err != nil {
-			api.Response(w, http.StatusBadRequest, api.Message{Msg: err.Error()})
+		if err := decode(r, &availability); err != nil {
+			fail(w, r, err)
 			return
 		}
 
 		slotsAvailable, err := deps.FarmService.GetAvailability(r.Context(), availability.MachineId, availability.Date)
 		if err != nil {
-			api.Response(w, http.StatusBadRequest, api.Message{Msg: err.Error()})
+			fail(w, r, err)
 			return
 		}
 
@@ -182,7 +170,7 @@ func getAllBookingsHandler(deps dependencies) http.HandlerFunc {
 
 		bookings, err := deps.FarmService.GetAllBookings(r.Context(), uint(farmerId))
 		if err != nil {
-			api.Response(w, http.StatusBadRequest, api.Message{Msg: err.Error()})
+			fail(w, r, err)
 			return
 		}
 
@@ -195,7 +183,7 @@ func getAllSlotsHandler(deps dependencies) http.HandlerFunc {
 
 		slots, err := deps.FarmService.GetAllSlots(r.Context())
 		if err != nil {
-			api.Response(w, http.StatusBadRequest, api.Message{Msg: err.Error()})
+			fail(w, r, err)
 			return
 		}
 
@@ -4,6 +4,7 @@ import (
 	"FarmEasy/api"
 	"FarmEasy/domain"
 	"FarmEasy/mocks"
+	"FarmEasy/tax"
 	"context"
 	"encoding/json"
 	"errors"
@@ -67,14 +68,25 @@ func (s *HandlerTestSuite) Test_registerHandler() {
 		assert.Equal(t, w.Result().StatusCode, http.StatusCreated)
 		assert.Equal(t, string(exp), w.Body.String())
 	})
+	t.Run("when the GSTIN is from another state", func(t *testing.T) {
+		bodyReader := strings.NewReader(`{"fname": "John", "lname": "Doe", "email": "john@gmail.com" , "phone": "1234567890", "password": "password", "state": "29", "gstin": "27AAPFU0939F1ZV"}`)
+		r := httptest.NewRequest(http.MethodPost, "/register", (bodyReader))
+		w := httptest.NewRecorder()
+
+		deps := dependencies{
+			FarmService: s.service,
+		}
+		exp, _ := json.Marshal(invalidField("gstin", tax.ErrStateMismatch.Error()))
+		registerHandler(deps).ServeHTTP(w, r)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+		assert.Equal(t, string(exp), w.Body.String())
+	})
 	t.Run("when error in registering user", func(t *testing.T) {
 		bodyReader := strings.NewReader(`{"fname": "John", "lname": "Doe", "email": "john@gmail.com" , "phone": "1234567890", "password": "password"}`)
 		r := httptest.NewRequest(http.MethodPost, "/register", (bodyReader))
 		w := httptest.NewRecorder()
 		ctx := r.Context()
-		respBody := api.Message{
-			Msg: "Err - mocked error",
-		}
+		respBody := api.ErrorResponse{Error: *api.Internal()}
 		requestBody := domain.NewFarmerRequest{
 			FirstName: "John",
 			LastName:  "Doe",
@@ -90,7 +102,7 @@ func (s *HandlerTestSuite) Test_registerHandler() {
 		exp, _ := json.Marshal(respBody)
 		got := registerHandler(deps)
 		got.ServeHTTP(w, r)
-		assert.Equal(t, w.Result().StatusCode, http.StatusBadRequest)
+		assert.Equal(t, w.Result().StatusCode, http.StatusInternalServerError)
 		assert.Equal(t, string(exp), w.Body.String())
 	})
 
@@ -98,9 +110,7 @@ func (s *HandlerTestSuite) Test_registerHandler() {
 		bodyReader := strings.NewReader(`{"fname": "John", "lname": "Doe", "email": "john@gmail" , "phone": "1234567890", "password": "password"}`)
 		r := httptest.NewRequest(http.MethodPost, "/register", (bodyReader))
 		w := httptest.NewRecorder()
-		respBody := api.Message{
-			Msg: "invalid email",
-		}
+		respBody := invalidField("email", "must be an email address")
 
 		deps := dependencies{
 			FarmService: s.service,
@@ -108,16 +118,14 @@ func (s *HandlerTestSuite) Test_registerHandler() {
 		exp, _ := json.Marshal(respBody)
 		got := registerHandler(deps)
 		got.ServeHTTP(w, r)
-		assert.Equal(t, w.Result().StatusCode, http.StatusBadRequest)
+		assert.Equal(t, w.Result().StatusCode, http.StatusUnprocessableEntity)
 		assert.Equal(t, string(exp), w.Body.String())
 	})
 	t.Run("when invalid register request is made, invalid phone", func(t *testing.T) {
 		bodyReader := strings.NewReader(`{"fname": "John", "lname": "Doe", "email": "john@gmail.com" , "phone": "123456789", "password": "password"}`)
 		r := httptest.NewRequest(http.MethodPost, "/register", (bodyReader))
 		w := httptest.NewRecorder()
-		respBody := api.Message{
-			Msg: "invalid phone number",
-		}
+		respBody := invalidField("phone", "must be a 10 digit phone number")
 
 		deps := dependencies{
 			FarmService: s.service,
@@ -125,7 +133,7 @@ func (s *HandlerTestSuite) Test_registerHandler() {
 		exp, _ := json.Marshal(respBody)
 		got := registerHandler(deps)
 		got.ServeHTTP(w, r)
-		assert.Equal(t, w.Result().StatusCode, http.StatusBadRequest)
+		assert.Equal(t, w.Result().StatusCode, http.StatusUnprocessableEntity)
 		assert.Equal(t, string(exp), w.Body.String())
 	})
 
@@ -163,9 +171,7 @@ func (s *HandlerTestSuite) Test_loginHandler() {
 		r := httptest.NewRequest(http.MethodPost, "/login", (bodyReader))
 		w := httptest.NewRecorder()
 		ctx := r.Context()
-		respBody := api.Message{
-			Msg: "mocked error",
-		}
+		respBody := api.ErrorResponse{Error: *api.Internal()}
 		requestBody := domain.LoginRequest{
 			Email:    "john@gmail.com",
 			Password: "password",
@@ -178,7 +184,7 @@ func (s *HandlerTestSuite) Test_loginHandler() {
 		exp, _ := json.Marshal(respBody)
 		got := loginHandler(deps)
 		got.ServeHTTP(w, r)
-		assert.Equal(t, w.Result().StatusCode, http.StatusBadRequest)
+		assert.Equal(t, w.Result().StatusCode, http.StatusInternalServerError)
 		assert.Equal(t, string(exp), w.Body.String())
 	})
 
@@ -187,9 +193,7 @@ func (s *HandlerTestSuite) Test_loginHandler() {
 
 		r := httptest.NewRequest(http.MethodPost, "/login", (bodyReader))
 		w := httptest.NewRecorder()
-		respBody := api.Message{
-			Msg: "invalid email",
-		}
+		respBody := invalidField("email", "must be an email address")
 
 		deps := dependencies{
 			FarmService: s.service,
@@ -197,7 +201,7 @@ func (s *HandlerTestSuite) Test_loginHandler() {
 		exp, _ := json.Marshal(respBody)
 		got := loginHandler(deps)
 		got.ServeHTTP(w, r)
-		assert.Equal(t, w.Result().StatusCode, http.StatusBadRequest)
+		assert.Equal(t, w.Result().StatusCode, http.StatusUnprocessableEntity)
 		assert.Equal(t, string(exp), w.Body.String())
 	})
 
@@ -215,14 +219,14 @@ func (s *HandlerTestSuite) Test_addMachineHandler() {
 			Id:               1,
 			Name:             "machine1",
 			Description:      "machine1 description",
-			BaseHourlyCharge: 500,
+			BaseHourlyCharge: domain.Rupees(500),
 			OwnerId:          1,
 		}
 		requestBody := domain.NewMachineRequest{
 
 			Name:             "machine1",
 			Description:      "machine1 description",
-			BaseHourlyCharge: 500,
+			BaseHourlyCharge: domain.Rupees(500),
 			OwnerId:          1,
 		}
 		s.service.On("AddMachine", ctx, requestBody).Return(respBody, nil).Once()
@@ -243,14 +247,12 @@ func (s *HandlerTestSuite) Test_addMachineHandler() {
 		w := httptest.NewRecorder()
 		r = r.WithContext(context.WithValue(r.Context(), "token", uint(1)))
 		ctx := r.Context()
-		respBody := api.Message{
-			Msg: "mocked error",
-		}
+		respBody := api.ErrorResponse{Error: *api.Internal()}
 		requestBody := domain.NewMachineRequest{
 
 			Name:             "machine1",
 			Description:      "machine1 description",
-			BaseHourlyCharge: 500,
+			BaseHourlyCharge: domain.Rupees(500),
 			OwnerId:          1,
 		}
 		s.service.On("AddMachine", ctx, requestBody).Return(domain.MachineResponse{}, errors.New("mocked error")).Once()
@@ -261,7 +263,22 @@ func (s *HandlerTestSuite) Test_addMachineHandler() {
 		exp, _ := json.Marshal(respBody)
 		got := addMachineHandler(deps)
 		got.ServeHTTP(w, r)
-		assert.Equal(t, w.Result().StatusCode, http.StatusBadRequest)
+		assert.Equal(t, w.Result().StatusCode, http.StatusInternalServerError)
+		assert.Equal(t, string(exp), w.Body.String())
+	})
+
+	t.Run("when the no-show policy is unknown", func(t *testing.T) {
+		bodyReader := strings.NewReader(`{"name" : "machine1", "base_hourly_charge": 500, "no_show_policy": "charge_double"}`)
+		r := httptest.NewRequest(http.MethodPost, "/machines", (bodyReader))
+		w := httptest.NewRecorder()
+		r = r.WithContext(context.WithValue(r.Context(), "token", uint(1)))
+
+		deps := dependencies{
+			FarmService: s.service,
+		}
+		exp, _ := json.Marshal(invalidField("no_show_policy", "must be one of [keep_payment forfeit_deposit refund]"))
+		addMachineHandler(deps).ServeHTTP(w, r)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
 		assert.Equal(t, string(exp), w.Body.String())
 	})
 
@@ -279,7 +296,7 @@ func (s *HandlerTestSuite) Test_getMachineHandler() {
 				Id:               1,
 				Name:             "machine1",
 				Description:      "machine1 description",
-				BaseHourlyCharge: 500,
+				BaseHourlyCharge: domain.Rupees(500),
 				OwnerId:          1,
 			},
 		}
@@ -301,9 +318,7 @@ func (s *HandlerTestSuite) Test_getMachineHandler() {
 		w := httptest.NewRecorder()
 		r = r.WithContext(context.WithValue(r.Context(), "token", uint(1)))
 		ctx := r.Context()
-		respBody := api.Message{
-			Msg: "mocked error",
-		}
+		respBody := api.ErrorResponse{Error: *api.Internal()}
 
 		s.service.On("GetMachines", ctx).Return([]domain.MachineResponse{}, errors.New("mocked error")).Once()
 
@@ -313,7 +328,7 @@ func (s *HandlerTestSuite) Test_getMachineHandler() {
 		exp, _ := json.Marshal(respBody)
 		got := getMachineHandler(deps)
 		got.ServeHTTP(w, r)
-		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
 		assert.Equal(t, string(exp), w.Body.String())
 	})
 }
@@ -330,7 +345,7 @@ func (s *HandlerTestSuite) Test_bookingHandler() {
 			InvoiceId:   1,
 			MachineId:   1,
 			SlotsBooked: []uint{1, 2},
-			TotalCost:   1000,
+			TotalCost:   domain.Rupees(1000),
 		}
 		requestBody := domain.NewBookingRequest{
 			MachineId: 1,
@@ -350,15 +365,36 @@ func (s *HandlerTestSuite) Test_bookingHandler() {
 		assert.Equal(t, string(exp), w.Body.String())
 	})
 
+	t.Run("when the body names another farmer", func(t *testing.T) {
+		bodyReader := strings.NewReader(`{"machine_id" : 1, "date" : "2021-01-01", "slots": [1,2] , "farmer_id" : 2, "pay_from_wallet": true}`)
+		r := httptest.NewRequest(http.MethodPost, "/bookings", (bodyReader))
+		w := httptest.NewRecorder()
+		r = r.WithContext(context.WithValue(r.Context(), "token", uint(1)))
+		ctx := r.Context()
+		requestBody := domain.NewBookingRequest{
+			MachineId:     1,
+			Date:          "2021-01-01",
+			Slots:         []uint{1, 2},
+			FarmerId:      1,
+			PayFromWallet: true,
+		}
+		s.service.On("BookMachine", ctx, requestBody).Return(domain.NewBookingResponse{InvoiceId: 1}, nil).Once()
+
+		deps := dependencies{
+			FarmService: s.service,
+		}
+		got := bookingHandler(deps)
+		got.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusCreated, w.Result().StatusCode)
+	})
+
 	t.Run("when invalid booking request is made,no slots selected", func(t *testing.T) {
 		bodyReader := strings.NewReader(`{"machine_id" : 1, "date" : "2021-01-01", "slots": [] , "farmer_id" : 1}`)
 		r := httptest.NewRequest(http.MethodPost, "/bookings", (bodyReader))
 		w := httptest.NewRecorder()
 		r = r.WithContext(context.WithValue(r.Context(), "token", uint(1)))
 
-		respBody := api.Message{
-			Msg: "no slots selected",
-		}
+		respBody := invalidField("slots", "is required")
 
 		// s.service.On("BookMachine", ctx, requestBody).Return(respBody, nil).Once()
 
@@ -368,7 +404,7 @@ func (s *HandlerTestSuite) Test_bookingHandler() {
 		exp, _ := json.Marshal(respBody)
 		got := bookingHandler(deps)
 		got.ServeHTTP(w, r)
-		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
 		assert.Equal(t, string(exp), w.Body.String())
 	})
 
@@ -378,9 +414,7 @@ func (s *HandlerTestSuite) Test_bookingHandler() {
 		w := httptest.NewRecorder()
 		r = r.WithContext(context.WithValue(r.Context(), "token", uint(1)))
 
-		respBody := api.Message{
-			Msg: "invalid slot selected",
-		}
+		respBody := invalidField("slots", "must be a slot from 1 to 24")
 
 		// s.service.On("BookMachine", ctx, requestBody).Return(respBody, nil).Once()
 
@@ -390,7 +424,7 @@ func (s *HandlerTestSuite) Test_bookingHandler() {
 		exp, _ := json.Marshal(respBody)
 		got := bookingHandler(deps)
 		got.ServeHTTP(w, r)
-		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
 		assert.Equal(t, string(exp), w.Body.String())
 	})
 	t.Run("when invalid booking request is made,invalid date format", func(t *testing.T) {
@@ -399,9 +433,7 @@ func (s *HandlerTestSuite) Test_bookingHandler() {
 		w := httptest.NewRecorder()
 		r = r.WithContext(context.WithValue(r.Context(), "token", uint(1)))
 
-		respBody := api.Message{
-			Msg: "invalid date",
-		}
+		respBody := invalidField("date", "must be a date as YYYY-MM-DD")
 
 		// s.service.On("BookMachine", ctx, requestBody).Return(respBody, nil).Once()
 
@@ -411,7 +443,7 @@ func (s *HandlerTestSuite) Test_bookingHandler() {
 		exp, _ := json.Marshal(respBody)
 		got := bookingHandler(deps)
 		got.ServeHTTP(w, r)
-		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
 		assert.Equal(t, string(exp), w.Body.String())
 	})
 	t.Run("when error in booking ", func(t *testing.T) {
@@ -428,9 +460,7 @@ func (s *HandlerTestSuite) Test_bookingHandler() {
 			Slots:     []uint{1, 2},
 			FarmerId:  1,
 		}
-		respBody := api.Message{
-			Msg: "mocked error",
-		}
+		respBody := api.ErrorResponse{Error: *api.Internal()}
 
 		s.service.On("BookMachine", ctx, requestBody).Return(domain.NewBookingResponse{}, errors.New("mocked error")).Once()
 
@@ -440,7 +470,7 @@ func (s *HandlerTestSuite) Test_bookingHandler() {
 		exp, _ := json.Marshal(respBody)
 		got := bookingHandler(deps)
 		got.ServeHTTP(w, r)
-		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
 		assert.Equal(t, string(exp), w.Body.String())
 	})
 
@@ -481,9 +511,7 @@ func (s *HandlerTestSuite) Test_availabilityHandler() {
 		w := httptest.NewRecorder()
 		r = r.WithContext(context.WithValue(r.Context(), "token", uint(1)))
 		ctx := r.Context()
-		respBody := api.Message{
-			Msg: "mocked error",
-		}
+		respBody := api.ErrorResponse{Error: *api.Internal()}
 		requestBody := domain.AvailabilityRequest{
 			MachineId: 1,
 			Date:      "2021-01-01",
@@ -498,7 +526,7 @@ func (s *HandlerTestSuite) Test_availabilityHandler() {
 		exp, _ := json.Marshal(respBody)
 		got := availabilityHandler(deps)
 		got.ServeHTTP(w, r)
-		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
 		assert.Equal(t, string(exp), w.Body.String())
 	})
 
@@ -534,9 +562,7 @@ func (s *HandlerTestSuite) Test_getAllBookingsHandler() {
 		w := httptest.NewRecorder()
 		r = r.WithContext(context.WithValue(r.Context(), "token", uint(1)))
 		ctx := r.Context()
-		respBody := api.Message{
-			Msg: "mocked error",
-		}
+		respBody := api.ErrorResponse{Error: *api.Internal()}
 		s.service.On("GetAllBookings", ctx, uint(1)).Return([]domain.BookingResponse{}, errors.New("mocked error")).Once()
 
 		deps := dependencies{
@@ -545,7 +571,7 @@ func (s *HandlerTestSuite) Test_getAllBookingsHandler() {
 		exp, _ := json.Marshal(respBody)
 		got := getAllBookingsHandler(deps)
 		got.ServeHTTP(w, r)
-		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
 		assert.Equal(t, string(exp), w.Body.String())
 	})
 
@@ -587,9 +613,7 @@ func (s *HandlerTestSuite) Test_getAllSlotsHandler() {
 		w := httptest.NewRecorder()
 		r = r.WithContext(context.WithValue(r.Context(), "token", uint(1)))
 		ctx := r.Context()
-		respBody := api.Message{
-			Msg: "mocked error",
-		}
+		respBody := api.ErrorResponse{Error: *api.Internal()}
 
 		s.service.On("GetAllSlots", ctx).Return([]domain.SlotResponse{}, errors.New("mocked error")).Once()
 
@@ -599,7 +623,7 @@ func (s *HandlerTestSuite) Test_getAllSlotsHandler() {
 		exp, _ := json.Marshal(respBody)
 		got := getAllSlotsHandler(deps)
 		got.ServeHTTP(w, r)
-		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
 		assert.Equal(t, string(exp), w.Body.String())
 	})
 }
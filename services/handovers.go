@@ -0,0 +1,246 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// RecordHandover records the state of the machine at check-in or check-out.
+// Either the renter or the owner records it, which counts as their
+// confirmation, and the other party confirms it. A check-out can only follow
+// a check-in both parties confirmed.
+func (s *FarmService) RecordHandover(ctx context.Context, request domain.HandoverRequest) (handover domain.Handover, err error) {
+
+	parties, err := s.handoverParties(ctx, request.BookingId, request.RecordedBy)
+	if err != nil {
+		return
+	}
+
+	if request.Kind == domain.HandoverCheckOut {
+		handovers, err := s.store.GetHandovers(ctx, request.BookingId)
+		if err != nil {
+			return handover, err
+		}
+		checkIn := findHandover(handovers, domain.HandoverCheckIn)
+		if checkIn == nil || !checkIn.Confirmed() {
+			return handover, ErrCheckInRequired
+		}
+		if request.MeterReading < checkIn.MeterReading {
+			return handover, ErrMeterBehind
+		}
+	}
+
+	handover = domain.Handover{
+		BookingId:    request.BookingId,
+		Kind:         request.Kind,
+		RecordedBy:   request.RecordedBy,
+		MeterReading: request.MeterReading,
+		FuelLevel:    request.FuelLevel,
+		Checklist:    request.Checklist,
+		Photos:       request.Photos,
+		Notes:        request.Notes,
+	}
+	if handover.Checklist == nil {
+		handover.Checklist = []domain.ChecklistItem{}
+	}
+	if handover.Photos == nil {
+		handover.Photos = []string{}
+	}
+	now := time.Now()
+	other := parties.OwnerId
+	if parties.Role(request.RecordedBy) == "owner" {
+		handover.OwnerConfirmedAt = &now
+		other = parties.FarmerId
+	} else {
+		handover.RenterConfirmedAt = &now
+	}
+
+	err = s.store.AddHandover(ctx, &handover)
+	if err == sql.ErrNoRows {
+		err = ErrHandoverExists
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	s.notifier.Notify(ctx, other, fmt.Sprintf("The %s of booking %d was recorded, please confirm it", handoverName(handover.Kind), handover.BookingId))
+	return
+}
+
+// ConfirmHandover records the other party's agreement with a handover. Once
+// both confirm the check-out, the booking is settled: usage is billed when the
+// machine bills by usage, and the deposit is released if nothing on the
+// checklist was found wrong and no claim is pending.
+func (s *FarmService) ConfirmHandover(ctx context.Context, bookingId uint, kind string, farmerId uint) (handover domain.Handover, err error) {
+
+	parties, err := s.handoverParties(ctx, bookingId, farmerId)
+	if err != nil {
+		return
+	}
+
+	handover, err = s.store.ConfirmHandover(ctx, bookingId, kind, parties.Role(farmerId))
+	if err == sql.ErrNoRows {
+		err = ErrHandoverNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	if handover.Kind == domain.HandoverCheckOut && handover.Confirmed() {
+		s.settleCheckOut(ctx, parties, handover)
+	}
+	return
+}
+
+func (s *FarmService) GetHandovers(ctx context.Context, bookingId uint, farmerId uint) (handovers []domain.Handover, err error) {
+
+	if _, err = s.handoverParties(ctx, bookingId, farmerId); err != nil {
+		return
+	}
+
+	handovers, err = s.store.GetHandovers(ctx, bookingId)
+	if err != nil {
+		return
+	}
+	if handovers == nil {
+		handovers = []domain.Handover{}
+	}
+	return
+}
+
+// handoverParties returns the parties of a confirmed booking the farmer is
+// one of.
+func (s *FarmService) handoverParties(ctx context.Context, bookingId uint, farmerId uint) (parties domain.BookingParties, err error) {
+
+	parties, err = s.store.GetBookingParties(ctx, bookingId)
+	if err == sql.ErrNoRows {
+		err = ErrHandoverBookingNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	if parties.Role(farmerId) == "" || parties.Status != domain.BookingConfirmed {
+		return domain.BookingParties{}, ErrHandoverBookingNotFound
+	}
+	return
+}
+
+// settleCheckOut is best effort, like the other side effects of a booking
+// changing state; a check-out that failed to settle is settled by the next
+// confirmation.
+func (s *FarmService) settleCheckOut(ctx context.Context, parties domain.BookingParties, checkOut domain.Handover) {
+
+	invoice, err := s.store.SettleHandover(ctx, parties.BookingId)
+	if err == sql.ErrNoRows {
+		return
+	}
+	if err != nil {
+		logrus.WithField("err", err.Error()).Error("error settling check-out")
+		return
+	}
+
+	if invoice != nil {
+		s.notifier.Notify(ctx, parties.FarmerId, fmt.Sprintf("Invoice %s now bills the hours used on booking %d, %s in all", invoice.Number, parties.BookingId, invoice.Amount))
+	}
+	if checkOut.Clean() && !s.claimPending(ctx, parties.BookingId) {
+		s.releaseBookingDeposit(ctx, parties.BookingId, "returned in good condition")
+	}
+}
+
+func findHandover(handovers []domain.Handover, kind string) *domain.Handover {
+	for i := range handovers {
+		if handovers[i].Kind == kind {
+			return &handovers[i]
+		}
+	}
+	return nil
+}
+
+func handoverName(kind string) string {
+	return strings.Replace(kind, "_", "-", 1)
+}
+
+func recordHandoverHandler(deps dependencies, kind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		bookingId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid booking id"))
+			return
+		}
+
+		var request domain.HandoverRequest
+		if err := decode(r, &request); err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		request.BookingId = uint(bookingId)
+		request.Kind = kind
+		request.RecordedBy = r.Context().Value("token").(uint)
+
+		handover, err := deps.FarmService.RecordHandover(r.Context(), request)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusCreated, handover)
+	}
+}
+
+func confirmHandoverHandler(deps dependencies, kind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		bookingId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid booking id"))
+			return
+		}
+
+		handover, err := deps.FarmService.ConfirmHandover(r.Context(), uint(bookingId), kind, farmerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, handover)
+	}
+}
+
+func getHandoversHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		bookingId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid booking id"))
+			return
+		}
+
+		handovers, err := deps.FarmService.GetHandovers(r.Context(), uint(bookingId), farmerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, handovers)
+	}
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"FarmEasy/domain"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *ServiceTestSuite) TestFarmService_RecordHandover() {
+	t := s.T()
+	ctx := context.TODO()
+
+	notifier := &recordingNotifier{}
+	s.service.(*FarmService).notifier = notifier
+
+	parties := domain.BookingParties{BookingId: 5, FarmerId: 9, OwnerId: 20, Status: domain.BookingConfirmed}
+	now := time.Now()
+	checkIn := domain.Handover{Id: 3, BookingId: 5, Kind: domain.HandoverCheckIn, MeterReading: 1200, RenterConfirmedAt: &now}
+
+	t.Run("when the farmer is not a party to the booking", func(t *testing.T) {
+		s.repo.On("GetBookingParties", ctx, uint(5)).Return(parties, nil).Once()
+		_, err := s.service.RecordHandover(ctx, domain.HandoverRequest{BookingId: 5, Kind: domain.HandoverCheckIn, RecordedBy: 30})
+		assert.Equal(t, ErrHandoverBookingNotFound, err)
+	})
+
+	t.Run("when the booking was cancelled", func(t *testing.T) {
+		cancelled := parties
+		cancelled.Status = domain.BookingCancelled
+		s.repo.On("GetBookingParties", ctx, uint(5)).Return(cancelled, nil).Once()
+		_, err := s.service.RecordHandover(ctx, domain.HandoverRequest{BookingId: 5, Kind: domain.HandoverCheckIn, RecordedBy: 9})
+		assert.Equal(t, ErrHandoverBookingNotFound, err)
+	})
+
+	t.Run("when the check-in is not confirmed by the owner", func(t *testing.T) {
+		s.repo.On("GetBookingParties", ctx, uint(5)).Return(parties, nil).Once()
+		s.repo.On("GetHandovers", ctx, uint(5)).Return([]domain.Handover{checkIn}, nil).Once()
+		_, err := s.service.RecordHandover(ctx, domain.HandoverRequest{BookingId: 5, Kind: domain.HandoverCheckOut, RecordedBy: 9, MeterReading: 1204})
+		assert.Equal(t, ErrCheckInRequired, err)
+	})
+
+	t.Run("when the meter reads less than at check-in", func(t *testing.T) {
+		confirmed := checkIn
+		confirmed.OwnerConfirmedAt = &now
+		s.repo.On("GetBookingParties", ctx, uint(5)).Return(parties, nil).Once()
+		s.repo.On("GetHandovers", ctx, uint(5)).Return([]domain.Handover{confirmed}, nil).Once()
+		_, err := s.service.RecordHandover(ctx, domain.HandoverRequest{BookingId: 5, Kind: domain.HandoverCheckOut, RecordedBy: 9, MeterReading: 1199.9})
+		assert.Equal(t, ErrMeterBehind, err)
+	})
+
+	t.Run("when the owner records the check-in", func(t *testing.T) {
+		notifier.notified = nil
+		s.repo.On("GetBookingParties", ctx, uint(5)).Return(parties, nil).Once()
+		s.repo.On("AddHandover", ctx, mock.MatchedBy(func(h *domain.Handover) bool {
+			return h.RecordedBy == 20 && h.OwnerConfirmedAt != nil && h.RenterConfirmedAt == nil
+		})).Return(nil).Once()
+
+		got, err := s.service.RecordHandover(ctx, domain.HandoverRequest{BookingId: 5, Kind: domain.HandoverCheckIn, RecordedBy: 20, MeterReading: 1200, FuelLevel: 90})
+		require.NoError(t, err)
+		assert.Equal(t, []domain.ChecklistItem{}, got.Checklist)
+		assert.Equal(t, []uint{9}, notifier.notified)
+	})
+
+	t.Run("when the check-in was already recorded", func(t *testing.T) {
+		s.repo.On("GetBookingParties", ctx, uint(5)).Return(parties, nil).Once()
+		s.repo.On("AddHandover", ctx, mock.Anything).Return(sql.ErrNoRows).Once()
+		_, err := s.service.RecordHandover(ctx, domain.HandoverRequest{BookingId: 5, Kind: domain.HandoverCheckIn, RecordedBy: 9})
+		assert.Equal(t, ErrHandoverExists, err)
+	})
+}
+
+func (s *ServiceTestSuite) TestFarmService_ConfirmHandover() {
+	t := s.T()
+	ctx := context.TODO()
+
+	notifier := &recordingNotifier{}
+	s.service.(*FarmService).notifier = notifier
+
+	parties := domain.BookingParties{BookingId: 5, FarmerId: 9, OwnerId: 20, Status: domain.BookingConfirmed, UsageBilling: true}
+	now := time.Now()
+	checkOut := domain.Handover{Id: 4, BookingId: 5, Kind: domain.HandoverCheckOut, MeterReading: 1204.5, Checklist: []domain.ChecklistItem{{Item: "tyres", Ok: true}}, RenterConfirmedAt: &now, OwnerConfirmedAt: &now}
+
+	t.Run("when there is nothing to confirm", func(t *testing.T) {
+		s.repo.On("GetBookingParties", ctx, uint(5)).Return(parties, nil).Once()
+		s.repo.On("ConfirmHandover", ctx, uint(5), domain.HandoverCheckOut, "owner").Return(domain.Handover{}, sql.ErrNoRows).Once()
+		_, err := s.service.ConfirmHandover(ctx, 5, domain.HandoverCheckOut, 20)
+		assert.Equal(t, ErrHandoverNotFound, err)
+	})
+
+	t.Run("when the owner confirms a clean check-out", func(t *testing.T) {
+		notifier.notified = nil
+		invoice := &domain.Invoice{Id: 1, Number: "FE/2026-27/000001", FarmerId: 9, Amount: domain.Rupees(1593)}
+		deposit := domain.Deposit{Id: 6, BookingId: 5, FarmerId: 9, OwnerId: 20, Amount: domain.Rupees(5000), Status: domain.DepositHeld}
+		s.repo.On("GetBookingParties", ctx, uint(5)).Return(parties, nil).Once()
+		s.repo.On("ConfirmHandover", ctx, uint(5), domain.HandoverCheckOut, "owner").Return(checkOut, nil).Once()
+		s.repo.On("SettleHandover", ctx, uint(5)).Return(invoice, nil).Once()
+		s.repo.On("GetBookingClaim", ctx, uint(5)).Return(domain.Claim{}, sql.ErrNoRows).Once()
+		s.repo.On("GetBookingDeposit", ctx, uint(5)).Return(deposit, nil).Once()
+		s.repo.On("ReleaseDeposit", ctx, uint(6), "returned in good condition").Return(nil).Once()
+
+		got, err := s.service.ConfirmHandover(ctx, 5, domain.HandoverCheckOut, 20)
+		require.NoError(t, err)
+		assert.Equal(t, checkOut, got)
+		assert.Equal(t, []uint{9, 9}, notifier.notified)
+	})
+
+	t.Run("when the check-out was already settled", func(t *testing.T) {
+		notifier.notified = nil
+		damaged := checkOut
+		damaged.Checklist = []domain.ChecklistItem{{Item: "hitch", Note: "bent pin"}}
+		s.repo.On("GetBookingParties", ctx, uint(5)).Return(parties, nil).Once()
+		s.repo.On("ConfirmHandover", ctx, uint(5), domain.HandoverCheckOut, "renter").Return(damaged, nil).Once()
+		s.repo.On("SettleHandover", ctx, uint(5)).Return(nil, sql.ErrNoRows).Once()
+
+		_, err := s.service.ConfirmHandover(ctx, 5, domain.HandoverCheckOut, 9)
+		require.NoError(t, err)
+		assert.Empty(t, notifier.notified)
+	})
+}
+
+func (s *HandlerTestSuite) Test_recordHandoverHandler() {
+	t := s.T()
+
+	deps := dependencies{
+		FarmService: s.service,
+	}
+
+	t.Run("when a photo is not a URL", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/bookings/5/check-in", bytes.NewBufferString(`{"meter_reading": 1200, "fuel_level": 80, "photos": ["IMG_0042.jpg"]}`))
+		r = r.WithContext(context.WithValue(r.Context(), "token", uint(9)))
+		r = mux.SetURLVars(r, map[string]string{"id": "5"})
+		w := httptest.NewRecorder()
+
+		recordHandoverHandler(deps, domain.HandoverCheckIn).ServeHTTP(w, r)
+		exp, _ := json.Marshal(invalidField("photos", "must be an http or https URL"))
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+		assert.Equal(t, string(exp), w.Body.String())
+	})
+
+	t.Run("when the check-in is recorded", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/bookings/5/check-in", bytes.NewBufferString(`{"meter_reading": 1200, "fuel_level": 80, "checklist": [{"item": "tyres", "ok": true}]}`))
+		r = r.WithContext(context.WithValue(r.Context(), "token", uint(9)))
+		r = mux.SetURLVars(r, map[string]string{"id": "5"})
+		w := httptest.NewRecorder()
+		request := domain.HandoverRequest{BookingId: 5, Kind: domain.HandoverCheckIn, RecordedBy: 9, MeterReading: 1200, FuelLevel: 80, Checklist: []domain.ChecklistItem{{Item: "tyres", Ok: true}}}
+		respBody := domain.Handover{Id: 3, BookingId: 5, Kind: domain.HandoverCheckIn, RecordedBy: 9, MeterReading: 1200, FuelLevel: 80, Checklist: request.Checklist, Photos: []string{}}
+		s.service.On("RecordHandover", r.Context(), request).Return(respBody, nil).Once()
+
+		recordHandoverHandler(deps, domain.HandoverCheckIn).ServeHTTP(w, r)
+		exp, _ := json.Marshal(respBody)
+		assert.Equal(t, http.StatusCreated, w.Result().StatusCode)
+		assert.Equal(t, string(exp), w.Body.String())
+	})
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"FarmEasy/domain"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	idempotencyHeader      = "Idempotency-Key"
+	maxIdempotencyKeyLen   = 255
+	idempotentReplayHeader = "Idempotent-Replayed"
+)
+
+// BeginIdempotentRequest claims the key for the farmer. It returns the stored
+// response when the same request was already completed, and nil when the caller
+// should go ahead and process the request.
+func (s *FarmService) BeginIdempotentRequest(ctx context.Context, request domain.IdempotencyRecord) (replay *domain.IdempotencyRecord, err error) {
+
+	claimed, err := s.store.ClaimIdempotencyKey(ctx, request.FarmerId, request.Key, request.RequestHash)
+	if err != nil || claimed {
+		return
+	}
+
+	stored, err := s.store.GetIdempotencyRecord(ctx, request.FarmerId, request.Key)
+	if err != nil {
+		return
+	}
+
+	if stored.RequestHash != request.RequestHash {
+		err = ErrIdempotencyKeyReused
+		return
+	}
+
+	if stored.StatusCode == 0 {
+		err = ErrIdempotencyKeyInFlight
+		return
+	}
+
+	replay = &stored
+	return
+}
+
+// CompleteIdempotentRequest stores the response for later replays. Server errors
+// are not stored, the key is released so that the farmer can retry.
+func (s *FarmService) CompleteIdempotentRequest(ctx context.Context, response domain.IdempotencyRecord) (err error) {
+
+	if response.StatusCode >= http.StatusInternalServerError {
+		err = s.store.DeleteIdempotencyKey(ctx, response.FarmerId, response.Key)
+		return
+	}
+
+	err = s.store.SaveIdempotencyResponse(ctx, response)
+	return
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func hashRequest(req *http.Request, body []byte) string {
+	hsha := sha256.New()
+	hsha.Write([]byte(req.Method + " " + req.URL.Path + "\n"))
+	hsha.Write(body)
+	return hex.EncodeToString(hsha.Sum(nil))
+}
+
+// Idempotent makes a POST handler safe to retry. Requests carrying an
+// Idempotency-Key header are processed once per farmer and key; retries with the
+// same body get the stored response, retries with a different body get 422.
+func Idempotent(deps dependencies, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		key := r.Header.Get(idempotencyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLen {
+			fail(w, r, ErrInvalidIdempotencyKey)
+			return
+		}
+
+		body, err := readBody(r)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		request := domain.IdempotencyRecord{
+			FarmerId:    r.Context().Value("token").(uint),
+			Key:         key,
+			RequestHash: hashRequest(r, body),
+		}
+
+		replay, err := deps.FarmService.BeginIdempotentRequest(r.Context(), request)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		if replay != nil {
+			w.Header().Add("Content-Type", "application/json")
+			w.Header().Add(idempotentReplayHeader, "true")
+			w.WriteHeader(replay.StatusCode)
+			w.Write(replay.ResponseBody)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		request.StatusCode = rec.status
+		if request.StatusCode == 0 {
+			request.StatusCode = http.StatusOK
+		}
+		request.ResponseBody = rec.body.Bytes()
+		if err = deps.FarmService.CompleteIdempotentRequest(r.Context(), request); err != nil {
+			logrus.WithField("err", err.Error()).Error("error storing idempotent response")
+		}
+	}
+}
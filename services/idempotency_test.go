@@ -0,0 +1,148 @@
+package services
+
+import (
+	"FarmEasy/domain"
+	"FarmEasy/mocks"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *ServiceTestSuite) TestFarmService_BeginIdempotentRequest() {
+	t := s.T()
+
+	request := domain.IdempotencyRecord{FarmerId: 1, Key: "key-1", RequestHash: "hash"}
+
+	tests := []struct {
+		name       string
+		wantErr    error
+		wantReplay bool
+		prepare    func(*mocks.Storer)
+	}{
+		{
+			name: "when the key is used for the first time",
+			prepare: func(s *mocks.Storer) {
+				s.On("ClaimIdempotencyKey", context.TODO(), uint(1), "key-1", "hash").Return(true, nil).Once()
+			},
+		},
+		{
+			name:       "when the same request is retried",
+			wantReplay: true,
+			prepare: func(s *mocks.Storer) {
+				s.On("ClaimIdempotencyKey", context.TODO(), uint(1), "key-1", "hash").Return(false, nil).Once()
+				s.On("GetIdempotencyRecord", context.TODO(), uint(1), "key-1").Return(domain.IdempotencyRecord{FarmerId: 1, Key: "key-1", RequestHash: "hash", StatusCode: 201, ResponseBody: []byte(`{}`)}, nil).Once()
+			},
+		},
+		{
+			name:    "when the key is reused with a different body",
+			wantErr: ErrIdempotencyKeyReused,
+			prepare: func(s *mocks.Storer) {
+				s.On("ClaimIdempotencyKey", context.TODO(), uint(1), "key-1", "hash").Return(false, nil).Once()
+				s.On("GetIdempotencyRecord", context.TODO(), uint(1), "key-1").Return(domain.IdempotencyRecord{FarmerId: 1, Key: "key-1", RequestHash: "other"}, nil).Once()
+			},
+		},
+		{
+			name:    "when the first request is still running",
+			wantErr: ErrIdempotencyKeyInFlight,
+			prepare: func(s *mocks.Storer) {
+				s.On("ClaimIdempotencyKey", context.TODO(), uint(1), "key-1", "hash").Return(false, nil).Once()
+				s.On("GetIdempotencyRecord", context.TODO(), uint(1), "key-1").Return(domain.IdempotencyRecord{FarmerId: 1, Key: "key-1", RequestHash: "hash"}, nil).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.prepare(s.repo)
+			replay, err := s.service.BeginIdempotentRequest(context.TODO(), request)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantReplay, replay != nil)
+		})
+	}
+}
+
+func (s *ServiceTestSuite) TestFarmService_CompleteIdempotentRequest() {
+	t := s.T()
+
+	t.Run("when the response is stored", func(t *testing.T) {
+		response := domain.IdempotencyRecord{FarmerId: 1, Key: "key-1", StatusCode: http.StatusCreated}
+		s.repo.On("SaveIdempotencyResponse", context.TODO(), response).Return(nil).Once()
+		require.NoError(t, s.service.CompleteIdempotentRequest(context.TODO(), response))
+	})
+
+	t.Run("when the request failed with a server error", func(t *testing.T) {
+		response := domain.IdempotencyRecord{FarmerId: 1, Key: "key-1", StatusCode: http.StatusInternalServerError}
+		s.repo.On("DeleteIdempotencyKey", context.TODO(), uint(1), "key-1").Return(nil).Once()
+		require.NoError(t, s.service.CompleteIdempotentRequest(context.TODO(), response))
+	})
+}
+
+func (s *HandlerTestSuite) Test_Idempotent() {
+	t := s.T()
+
+	deps := dependencies{
+		FarmService: s.service,
+	}
+	created := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"invoice_id":1}`))
+	})
+
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/bookings", strings.NewReader(`{"machine_id": 1}`))
+		r.Header.Set(idempotencyHeader, "key-1")
+		return r.WithContext(context.WithValue(r.Context(), "token", uint(1)))
+	}
+
+	t.Run("when the request has no idempotency key", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/bookings", strings.NewReader(`{}`))
+		w := httptest.NewRecorder()
+		Idempotent(deps, created).ServeHTTP(w, r)
+		assert.Equal(t, http.StatusCreated, w.Result().StatusCode)
+	})
+
+	t.Run("when the request is processed for the first time", func(t *testing.T) {
+		r := newRequest()
+		w := httptest.NewRecorder()
+		s.service.On("BeginIdempotentRequest", r.Context(), mock.Anything).Return(nil, nil).Once()
+		s.service.On("CompleteIdempotentRequest", r.Context(), mock.MatchedBy(func(rec domain.IdempotencyRecord) bool {
+			return rec.StatusCode == http.StatusCreated && string(rec.ResponseBody) == `{"invoice_id":1}`
+		})).Return(nil).Once()
+		Idempotent(deps, created).ServeHTTP(w, r)
+		assert.Equal(t, http.StatusCreated, w.Result().StatusCode)
+	})
+
+	t.Run("when the request is a retry", func(t *testing.T) {
+		r := newRequest()
+		w := httptest.NewRecorder()
+		replay := &domain.IdempotencyRecord{StatusCode: http.StatusCreated, ResponseBody: []byte(`{"invoice_id":1}`)}
+		s.service.On("BeginIdempotentRequest", r.Context(), mock.Anything).Return(replay, nil).Once()
+		Idempotent(deps, created).ServeHTTP(w, r)
+		assert.Equal(t, http.StatusCreated, w.Result().StatusCode)
+		assert.Equal(t, "true", w.Header().Get(idempotentReplayHeader))
+		assert.Equal(t, `{"invoice_id":1}`, w.Body.String())
+	})
+
+	t.Run("when the key is reused with a different body", func(t *testing.T) {
+		r := newRequest()
+		w := httptest.NewRecorder()
+		s.service.On("BeginIdempotentRequest", r.Context(), mock.Anything).Return(nil, ErrIdempotencyKeyReused).Once()
+		Idempotent(deps, created).ServeHTTP(w, r)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+	})
+
+	t.Run("when the service fails", func(t *testing.T) {
+		r := newRequest()
+		w := httptest.NewRecorder()
+		s.service.On("BeginIdempotentRequest", r.Context(), mock.Anything).Return(nil, errors.New("mocked error")).Once()
+		Idempotent(deps, created).ServeHTTP(w, r)
+		assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
+	})
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/document"
+	"FarmEasy/domain"
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+func (s *FarmService) GetInvoices(ctx context.Context, farmerId uint) (invoices []domain.Invoice, err error) {
+	invoices, err = s.store.GetInvoices(ctx, farmerId)
+	return
+}
+
+// GetInvoice returns the invoice if the farmer is either the renter or the
+// machine owner on it. Anyone else gets the same error as for a missing
+// invoice.
+func (s *FarmService) GetInvoice(ctx context.Context, invoiceId uint, farmerId uint) (invoice domain.Invoice, err error) {
+
+	invoice, err = s.store.GetInvoice(ctx, invoiceId)
+	if err == sql.ErrNoRows {
+		err = ErrInvoiceNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	if invoice.FarmerId != farmerId && invoice.OwnerId != farmerId {
+		return domain.Invoice{}, ErrInvoiceNotFound
+	}
+	return
+}
+
+// GetInvoiceDocument returns everything printed on the invoice, with the same
+// access rules as GetInvoice.
+func (s *FarmService) GetInvoiceDocument(ctx context.Context, invoiceId uint, farmerId uint) (doc domain.InvoiceDocument, err error) {
+
+	doc, err = s.store.GetInvoiceDocument(ctx, invoiceId)
+	if err == sql.ErrNoRows {
+		err = ErrInvoiceNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	if doc.FarmerId != farmerId && doc.OwnerId != farmerId {
+		return domain.InvoiceDocument{}, ErrInvoiceNotFound
+	}
+	return
+}
+
+func getInvoicesHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		invoices, err := deps.FarmService.GetInvoices(r.Context(), farmerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, invoices)
+	}
+}
+
+func getInvoiceHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		invoiceId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid invoice id"))
+			return
+		}
+
+		invoice, err := deps.FarmService.GetInvoice(r.Context(), uint(invoiceId), farmerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, invoice)
+	}
+}
+
+func getInvoicePDFHandler(deps dependencies) http.HandlerFunc {
+	return invoiceDocumentHandler(deps, "application/pdf", document.InvoicePDF)
+}
+
+func getInvoiceHTMLHandler(deps dependencies) http.HandlerFunc {
+	return invoiceDocumentHandler(deps, "text/html; charset=utf-8", document.InvoiceHTML)
+}
+
+// invoiceDocumentHandler renders the invoice into a buffer first so that a
+// rendering error can still be reported as JSON.
+func invoiceDocumentHandler(deps dependencies, contentType string, render func(io.Writer, domain.InvoiceDocument, document.Options) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		invoiceId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid invoice id"))
+			return
+		}
+
+		doc, err := deps.FarmService.GetInvoiceDocument(r.Context(), uint(invoiceId), farmerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		opts := document.Options{QRCode: r.URL.Query().Get("qr") == "true"}
+
+		var body bytes.Buffer
+		if err = render(&body, doc, opts); err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		if contentType == "application/pdf" {
+			filename := strings.ReplaceAll(doc.Number, "/", "-") + ".pdf"
+			w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename))
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(body.Bytes())
+	}
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *ServiceTestSuite) TestFarmService_GetInvoice() {
+	t := s.T()
+
+	invoice := domain.Invoice{Id: 7, Number: "FE/2026-27/000042", FarmerId: 9, OwnerId: 20}
+
+	t.Run("when the farmer is the renter", func(t *testing.T) {
+		s.repo.On("GetInvoice", context.TODO(), uint(7)).Return(invoice, nil).Once()
+		got, err := s.service.GetInvoice(context.TODO(), 7, 9)
+		assert.NoError(t, err)
+		assert.Equal(t, invoice, got)
+	})
+
+	t.Run("when the farmer is the owner", func(t *testing.T) {
+		s.repo.On("GetInvoice", context.TODO(), uint(7)).Return(invoice, nil).Once()
+		_, err := s.service.GetInvoice(context.TODO(), 7, 20)
+		assert.NoError(t, err)
+	})
+
+	t.Run("when the farmer is neither", func(t *testing.T) {
+		s.repo.On("GetInvoice", context.TODO(), uint(7)).Return(invoice, nil).Once()
+		got, err := s.service.GetInvoice(context.TODO(), 7, 5)
+		assert.Equal(t, ErrInvoiceNotFound, err)
+		assert.Equal(t, domain.Invoice{}, got)
+	})
+
+	t.Run("when the invoice does not exist", func(t *testing.T) {
+		s.repo.On("GetInvoice", context.TODO(), uint(8)).Return(domain.Invoice{}, sql.ErrNoRows).Once()
+		_, err := s.service.GetInvoice(context.TODO(), 8, 9)
+		assert.Equal(t, ErrInvoiceNotFound, err)
+	})
+}
+
+func (s *HandlerTestSuite) Test_getInvoiceHandler() {
+	t := s.T()
+
+	deps := dependencies{
+		FarmService: s.service,
+	}
+
+	t.Run("when the invoice is found", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/invoices/7", nil)
+		r = r.WithContext(context.WithValue(r.Context(), "token", uint(9)))
+		r = mux.SetURLVars(r, map[string]string{"id": "7"})
+		w := httptest.NewRecorder()
+		respBody := domain.Invoice{Id: 7, Number: "FE/2026-27/000042", FarmerId: 9, OwnerId: 20, Amount: domain.Rupees(600), LineItems: []domain.InvoiceLineItem{
+			{Id: 1, Kind: domain.LineItemRental, Description: "Tractor, 2 hourly slots", Quantity: 2, UnitAmount: domain.Rupees(300), Amount: domain.Rupees(600)},
+		}}
+		s.service.On("GetInvoice", r.Context(), uint(7), uint(9)).Return(respBody, nil).Once()
+
+		getInvoiceHandler(deps).ServeHTTP(w, r)
+		exp, _ := json.Marshal(respBody)
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+		assert.Equal(t, string(exp), w.Body.String())
+	})
+
+	t.Run("when the invoice is not the farmer's", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/invoices/7", nil)
+		r = r.WithContext(context.WithValue(r.Context(), "token", uint(5)))
+		r = mux.SetURLVars(r, map[string]string{"id": "7"})
+		w := httptest.NewRecorder()
+		s.service.On("GetInvoice", r.Context(), uint(7), uint(5)).Return(domain.Invoice{}, ErrInvoiceNotFound).Once()
+
+		getInvoiceHandler(deps).ServeHTTP(w, r)
+		exp, _ := json.Marshal(api.ErrorResponse{Error: *ErrInvoiceNotFound})
+		assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+		assert.Equal(t, string(exp), w.Body.String())
+	})
+}
+
+func (s *ServiceTestSuite) TestFarmService_GetInvoiceDocument() {
+	t := s.T()
+
+	doc := domain.InvoiceDocument{Invoice: domain.Invoice{Id: 7, FarmerId: 9, OwnerId: 20}}
+
+	t.Run("when the farmer is the owner", func(t *testing.T) {
+		s.repo.On("GetInvoiceDocument", context.TODO(), uint(7)).Return(doc, nil).Once()
+		got, err := s.service.GetInvoiceDocument(context.TODO(), 7, 20)
+		assert.NoError(t, err)
+		assert.Equal(t, doc, got)
+	})
+
+	t.Run("when the farmer is neither", func(t *testing.T) {
+		s.repo.On("GetInvoiceDocument", context.TODO(), uint(7)).Return(doc, nil).Once()
+		_, err := s.service.GetInvoiceDocument(context.TODO(), 7, 5)
+		assert.Equal(t, ErrInvoiceNotFound, err)
+	})
+
+	t.Run("when the invoice does not exist", func(t *testing.T) {
+		s.repo.On("GetInvoiceDocument", context.TODO(), uint(8)).Return(domain.InvoiceDocument{}, sql.ErrNoRows).Once()
+		_, err := s.service.GetInvoiceDocument(context.TODO(), 8, 9)
+		assert.Equal(t, ErrInvoiceNotFound, err)
+	})
+}
+
+func (s *HandlerTestSuite) Test_getInvoicePDFHandler() {
+	t := s.T()
+
+	deps := dependencies{
+		FarmService: s.service,
+	}
+
+	doc := domain.InvoiceDocument{Invoice: domain.Invoice{Id: 7, Number: "FE/2026-27/000042", FarmerId: 9, OwnerId: 20, DateGenrated: "2026-10-19", Amount: domain.Rupees(600)}}
+
+	t.Run("when the invoice is found", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/invoices/7.pdf?qr=true", nil)
+		r = r.WithContext(context.WithValue(r.Context(), "token", uint(9)))
+		r = mux.SetURLVars(r, map[string]string{"id": "7"})
+		w := httptest.NewRecorder()
+		s.service.On("GetInvoiceDocument", r.Context(), uint(7), uint(9)).Return(doc, nil).Once()
+
+		getInvoicePDFHandler(deps).ServeHTTP(w, r)
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+		assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+		assert.Equal(t, `inline; filename="FE-2026-27-000042.pdf"`, w.Header().Get("Content-Disposition"))
+		assert.True(t, strings.HasPrefix(w.Body.String(), "%PDF-"))
+	})
+
+	t.Run("when the invoice is not the farmer's", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/invoices/7.pdf", nil)
+		r = r.WithContext(context.WithValue(r.Context(), "token", uint(5)))
+		r = mux.SetURLVars(r, map[string]string{"id": "7"})
+		w := httptest.NewRecorder()
+		s.service.On("GetInvoiceDocument", r.Context(), uint(7), uint(5)).Return(domain.InvoiceDocument{}, ErrInvoiceNotFound).Once()
+
+		getInvoicePDFHandler(deps).ServeHTTP(w, r)
+		exp, _ := json.Marshal(api.ErrorResponse{Error: *ErrInvoiceNotFound})
+		assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+		assert.Equal(t, string(exp), w.Body.String())
+	})
+}
+
+func (s *HandlerTestSuite) Test_getInvoiceHTMLHandler() {
+	t := s.T()
+
+	deps := dependencies{
+		FarmService: s.service,
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/invoices/7.html", nil)
+	r = r.WithContext(context.WithValue(r.Context(), "token", uint(9)))
+	r = mux.SetURLVars(r, map[string]string{"id": "7"})
+	w := httptest.NewRecorder()
+	doc := domain.InvoiceDocument{Invoice: domain.Invoice{Id: 7, Number: "FE/2026-27/000042", FarmerId: 9, OwnerId: 20, Amount: domain.Rupees(600)}}
+	s.service.On("GetInvoiceDocument", r.Context(), uint(7), uint(9)).Return(doc, nil).Once()
+
+	getInvoiceHTMLHandler(deps).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "FE/2026-27/000042")
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultMessagePage = 50
+	maxMessagePage     = 100
+)
+
+// StartThread opens the thread of a booking, or the farmer's enquiry about a
+// machine, and posts the first message to it. Asking again returns the same
+// thread with the new message.
+func (s *FarmService) StartThread(ctx context.Context, request domain.NewThreadRequest) (thread domain.Thread, err error) {
+
+	thread = domain.Thread{MachineId: request.MachineId, RenterId: request.FarmerId}
+	if request.BookingId != 0 {
+		parties, err := s.store.GetBookingParties(ctx, request.BookingId)
+		if err == sql.ErrNoRows || (err == nil && parties.Role(request.FarmerId) == "") {
+			return thread, ErrThreadBookingNotFound
+		}
+		if err != nil {
+			return thread, err
+		}
+		thread = domain.Thread{MachineId: parties.MachineId, BookingId: &parties.BookingId, RenterId: parties.FarmerId, OwnerId: parties.OwnerId}
+	}
+
+	threadId, err := s.store.OpenThread(ctx, thread)
+	if err == sql.ErrNoRows {
+		err = ErrMachineNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	_, err = s.PostMessage(ctx, domain.NewMessageRequest{ThreadId: threadId, SenderId: request.FarmerId, Body: request.Body})
+	if err != nil {
+		return
+	}
+
+	return s.getThread(ctx, threadId, request.FarmerId)
+}
+
+// GetThreads returns the farmer's threads with their unread counts.
+func (s *FarmService) GetThreads(ctx context.Context, farmerId uint) (threads []domain.Thread, err error) {
+
+	threads, err = s.store.GetThreads(ctx, farmerId)
+	if err != nil {
+		return
+	}
+	if threads == nil {
+		threads = []domain.Thread{}
+	}
+	for i := range threads {
+		threads[i] = maskThread(threads[i])
+	}
+	return
+}
+
+// GetMessages returns a page of the thread's messages, newest first, and marks
+// them read for the farmer.
+func (s *FarmService) GetMessages(ctx context.Context, threadId uint, farmerId uint, before uint, limit uint) (page domain.MessagePage, err error) {
+
+	thread, err := s.getThread(ctx, threadId, farmerId)
+	if err != nil {
+		return
+	}
+
+	if limit == 0 || limit > maxMessagePage {
+		limit = defaultMessagePage
+	}
+	messages, err := s.store.GetMessages(ctx, threadId, before, limit+1)
+	if err != nil {
+		return
+	}
+
+	page.Messages = []domain.Message{}
+	for i, message := range messages {
+		if uint(i) == limit {
+			page.NextBefore = &page.Messages[i-1].Id
+			break
+		}
+		if !thread.Revealed {
+			message.Body = domain.MaskContacts(message.Body)
+		}
+		page.Messages = append(page.Messages, message)
+	}
+
+	if len(page.Messages) > 0 {
+		err = s.store.MarkThreadRead(ctx, threadId, farmerId, page.Messages[0].Id)
+	}
+	return
+}
+
+// PostMessage posts to a thread the sender is a party of, and lets the other
+// party know.
+func (s *FarmService) PostMessage(ctx context.Context, request domain.NewMessageRequest) (message domain.Message, err error) {
+
+	thread, err := s.getThread(ctx, request.ThreadId, request.SenderId)
+	if err != nil {
+		return
+	}
+
+	message = domain.Message{ThreadId: request.ThreadId, SenderId: request.SenderId, Body: request.Body}
+	err = s.store.AddMessage(ctx, &message)
+	if err != nil {
+		return
+	}
+
+	if !thread.Revealed {
+		message.Body = domain.MaskContacts(message.Body)
+	}
+	s.notifier.Notify(ctx, thread.Other(request.SenderId), fmt.Sprintf("New message on thread %d about machine %d", thread.Id, thread.MachineId))
+	return
+}
+
+// getThread returns the thread, masked, if the farmer is a party of it.
+func (s *FarmService) getThread(ctx context.Context, threadId uint, farmerId uint) (thread domain.Thread, err error) {
+
+	thread, err = s.store.GetThread(ctx, threadId, farmerId)
+	if err == sql.ErrNoRows || (err == nil && !thread.Includes(farmerId)) {
+		return domain.Thread{}, ErrThreadNotFound
+	}
+	if err != nil {
+		return
+	}
+
+	return maskThread(thread), nil
+}
+
+// maskThread hides contact details in the thread's preview until they are
+// revealed.
+func maskThread(thread domain.Thread) domain.Thread {
+	if !thread.Revealed {
+		thread.LastMessage = domain.MaskContacts(thread.LastMessage)
+	}
+	return thread
+}
+
+func startThreadHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var request domain.NewThreadRequest
+
+		if err := decode(r, &request); err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		request.FarmerId = r.Context().Value("token").(uint)
+
+		thread, err := deps.FarmService.StartThread(r.Context(), request)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusCreated, thread)
+	}
+}
+
+func getThreadsHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		threads, err := deps.FarmService.GetThreads(r.Context(), farmerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, threads)
+	}
+}
+
+func getMessagesHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		threadId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid thread id"))
+			return
+		}
+
+		var before, limit uint64
+		if value := r.URL.Query().Get("before"); value != "" {
+			if before, err = strconv.ParseUint(value, 10, 64); err != nil {
+				fail(w, r, api.BadRequest("invalid before"))
+				return
+			}
+		}
+		if value := r.URL.Query().Get("limit"); value != "" {
+			if limit, err = strconv.ParseUint(value, 10, 64); err != nil {
+				fail(w, r, api.BadRequest("invalid limit"))
+				return
+			}
+		}
+
+		page, err := deps.FarmService.GetMessages(r.Context(), uint(threadId), farmerId, uint(before), uint(limit))
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, page)
+	}
+}
+
+func postMessageHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		threadId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid thread id"))
+			return
+		}
+
+		var request domain.NewMessageRequest
+		if err := decode(r, &request); err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		request.ThreadId = uint(threadId)
+		request.SenderId = r.Context().Value("token").(uint)
+
+		message, err := deps.FarmService.PostMessage(r.Context(), request)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusCreated, message)
+	}
+}
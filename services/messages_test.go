@@ -0,0 +1,130 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *ServiceTestSuite) TestFarmService_StartThread() {
+	t := s.T()
+	ctx := context.TODO()
+
+	notifier := &recordingNotifier{}
+	s.service.(*FarmService).notifier = notifier
+
+	t.Run("when the farmer is not a party to the booking", func(t *testing.T) {
+		s.repo.On("GetBookingParties", ctx, uint(5)).Return(domain.BookingParties{BookingId: 5, MachineId: 2, FarmerId: 9, OwnerId: 20}, nil).Once()
+		_, err := s.service.StartThread(ctx, domain.NewThreadRequest{BookingId: 5, FarmerId: 30, Body: "hello"})
+		assert.Equal(t, ErrThreadBookingNotFound, err)
+	})
+
+	t.Run("when the owner enquires about their own machine", func(t *testing.T) {
+		s.repo.On("OpenThread", ctx, domain.Thread{MachineId: 2, RenterId: 20}).Return(uint(0), sql.ErrNoRows).Once()
+		_, err := s.service.StartThread(ctx, domain.NewThreadRequest{MachineId: 2, FarmerId: 20, Body: "hello"})
+		assert.Equal(t, ErrMachineNotFound, err)
+	})
+
+	t.Run("when a renter enquires about a machine", func(t *testing.T) {
+		notifier.notified = nil
+		thread := domain.Thread{Id: 6, MachineId: 2, RenterId: 9, OwnerId: 20, LastMessage: "call 9876543210"}
+		s.repo.On("OpenThread", ctx, domain.Thread{MachineId: 2, RenterId: 9}).Return(uint(6), nil).Once()
+		s.repo.On("GetThread", ctx, uint(6), uint(9)).Return(thread, nil).Twice()
+		s.repo.On("AddMessage", ctx, mock.MatchedBy(func(m *domain.Message) bool {
+			return m.ThreadId == 6 && m.SenderId == 9 && m.Body == "call 9876543210"
+		})).Return(nil).Once()
+
+		got, err := s.service.StartThread(ctx, domain.NewThreadRequest{MachineId: 2, FarmerId: 9, Body: "call 9876543210"})
+		require.NoError(t, err)
+		assert.Equal(t, "call [hidden]", got.LastMessage)
+		assert.Equal(t, []uint{20}, notifier.notified)
+	})
+}
+
+func (s *ServiceTestSuite) TestFarmService_GetMessages() {
+	t := s.T()
+	ctx := context.TODO()
+
+	thread := domain.Thread{Id: 6, MachineId: 2, RenterId: 9, OwnerId: 20}
+	messages := []domain.Message{
+		{Id: 43, ThreadId: 6, SenderId: 20, Body: "mail me at owner@example.com"},
+		{Id: 42, ThreadId: 6, SenderId: 9, Body: "is it free on Friday?"},
+		{Id: 41, ThreadId: 6, SenderId: 9, Body: "hello"},
+	}
+
+	t.Run("when the farmer is not in the thread", func(t *testing.T) {
+		s.repo.On("GetThread", ctx, uint(6), uint(30)).Return(thread, nil).Once()
+		_, err := s.service.GetMessages(ctx, 6, 30, 0, 0)
+		assert.Equal(t, ErrThreadNotFound, err)
+	})
+
+	t.Run("when there are more messages than fit on the page", func(t *testing.T) {
+		s.repo.On("GetThread", ctx, uint(6), uint(9)).Return(thread, nil).Once()
+		s.repo.On("GetMessages", ctx, uint(6), uint(0), uint(3)).Return(messages, nil).Once()
+		s.repo.On("MarkThreadRead", ctx, uint(6), uint(9), uint(43)).Return(nil).Once()
+
+		got, err := s.service.GetMessages(ctx, 6, 9, 0, 2)
+		require.NoError(t, err)
+		require.Len(t, got.Messages, 2)
+		assert.Equal(t, "mail me at [hidden]", got.Messages[0].Body)
+		require.NotNil(t, got.NextBefore)
+		assert.Equal(t, uint(42), *got.NextBefore)
+	})
+
+	t.Run("when the renter has a confirmed booking", func(t *testing.T) {
+		revealed := thread
+		revealed.Revealed = true
+		s.repo.On("GetThread", ctx, uint(6), uint(9)).Return(revealed, nil).Once()
+		s.repo.On("GetMessages", ctx, uint(6), uint(42), uint(defaultMessagePage+1)).Return(messages[2:], nil).Once()
+		s.repo.On("MarkThreadRead", ctx, uint(6), uint(9), uint(41)).Return(nil).Once()
+
+		got, err := s.service.GetMessages(ctx, 6, 9, 42, 0)
+		require.NoError(t, err)
+		assert.Equal(t, messages[2:], got.Messages)
+		assert.Nil(t, got.NextBefore)
+	})
+}
+
+func (s *HandlerTestSuite) Test_getMessagesHandler() {
+	t := s.T()
+
+	deps := dependencies{
+		FarmService: s.service,
+	}
+
+	t.Run("when the cursor is not a number", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/threads/6/messages?before=latest", nil)
+		r = r.WithContext(context.WithValue(r.Context(), "token", uint(9)))
+		r = mux.SetURLVars(r, map[string]string{"id": "6"})
+		w := httptest.NewRecorder()
+
+		getMessagesHandler(deps).ServeHTTP(w, r)
+		exp, _ := json.Marshal(api.ErrorResponse{Error: *api.BadRequest("invalid before")})
+		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		assert.Equal(t, string(exp), w.Body.String())
+	})
+
+	t.Run("when a page is fetched", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/threads/6/messages?before=42&limit=20", nil)
+		r = r.WithContext(context.WithValue(r.Context(), "token", uint(9)))
+		r = mux.SetURLVars(r, map[string]string{"id": "6"})
+		w := httptest.NewRecorder()
+		respBody := domain.MessagePage{Messages: []domain.Message{{Id: 41, ThreadId: 6, SenderId: 9, Body: "hello"}}}
+		s.service.On("GetMessages", r.Context(), uint(6), uint(9), uint(42), uint(20)).Return(respBody, nil).Once()
+
+		getMessagesHandler(deps).ServeHTTP(w, r)
+		exp, _ := json.Marshal(respBody)
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+		assert.Equal(t, string(exp), w.Body.String())
+	})
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"FarmEasy/config"
+	"FarmEasy/domain"
+	"context"
+)
+
+// MarkNoShows marks the bookings whose renter did not check in within the
+// grace period, applies the no-show policy of each machine and offers the
+// released slots to the waitlist. It returns how many bookings were marked.
+func (s *FarmService) MarkNoShows(ctx context.Context) (marked int, err error) {
+
+	noShows, err := s.store.MarkNoShows(ctx, config.NoShowGraceMinutes(), config.NoShowLookbackHours())
+	if err != nil {
+		return
+	}
+
+	for _, noShow := range noShows {
+		s.applyNoShowPolicy(ctx, noShow)
+		s.processWaitlist(ctx, noShow.MachineId, noShow.Date)
+		s.availabilityChanged(ctx, noShow.MachineId, noShow.Date, domain.AvailabilityNoShow)
+	}
+	return len(noShows), nil
+}
+
+func (s *FarmService) applyNoShowPolicy(ctx context.Context, noShow domain.NoShow) {
+
+	s.notifyDepositSettled(ctx, noShow.Deposit)
+	if noShow.Policy == domain.NoShowRefund {
+		s.notifyWalletRefund(ctx, noShow.BookingId, noShow.Refund)
+	}
+}
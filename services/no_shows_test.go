@@ -0,0 +1,48 @@
+package services
+
+import (
+	"FarmEasy/domain"
+	"context"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *ServiceTestSuite) TestFarmService_MarkNoShows() {
+	t := s.T()
+	ctx := context.TODO()
+
+	settled := func(id, bookingId uint, status string) *domain.Deposit {
+		return &domain.Deposit{Id: id, BookingId: bookingId, FarmerId: 9, Amount: domain.Rupees(2000), Status: status}
+	}
+
+	// the store settled the deposits and refunded the payment with the
+	// no-shows: keep_payment gave the deposit back, forfeit_deposit kept it
+	s.repo.On("MarkNoShows", ctx, 60, 24).Return([]domain.NoShow{
+		{BookingId: 11, FarmerId: 9, MachineId: 1, OwnerId: 20, Policy: domain.NoShowKeepPayment, Date: "2026-11-07", Deposit: settled(1, 11, domain.DepositReleased)},
+		{BookingId: 12, FarmerId: 9, MachineId: 2, OwnerId: 20, Policy: domain.NoShowForfeitDeposit, Date: "2026-11-07", Deposit: settled(2, 12, domain.DepositCaptured)},
+		{BookingId: 13, FarmerId: 9, MachineId: 3, OwnerId: 21, Policy: domain.NoShowRefund, Date: "2026-11-08", Refund: &domain.Refund{PaymentId: 8, FarmerId: 9, Amount: domain.Rupees(900)}},
+	}, nil).Once()
+	s.repo.On("GetWaitingEntries", ctx, uint(1), "2026-11-07").Return(nil, nil).Once()
+	s.repo.On("GetWaitingEntries", ctx, uint(2), "2026-11-07").Return(nil, nil).Once()
+	s.repo.On("GetWaitingEntries", ctx, uint(3), "2026-11-08").Return(nil, nil).Once()
+
+	marked, err := s.service.MarkNoShows(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, marked)
+	assert.Equal(t, []string{
+		"Your deposit of 2000.00 INR for booking 11 was released",
+		"Your deposit of 2000.00 INR for booking 12 was kept because you did not show up",
+		"900.00 INR for booking 13 was refunded to your wallet",
+	}, s.service.(*FarmService).notifier.(*recordingNotifier).messages)
+}
+
+func (s *ServiceTestSuite) TestNewScheduler() {
+	deps := dependencies{FarmService: s.service}
+
+	var names []string
+	for _, job := range NewScheduler(deps).Jobs() {
+		names = append(names, job.Name)
+	}
+	assert.Equal(s.T(), []string{"waitlist-offers", "owner-settlement", "claim-escalation", "unclaimed-deposits", "notifications", "booking-reminders", "no-shows", "webhooks"}, names)
+}
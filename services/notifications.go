@@ -0,0 +1,223 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/config"
+	"FarmEasy/domain"
+	"FarmEasy/notify"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	notificationBatch = 100
+	inboxSize         = 50
+	// a failed delivery is retried after a minute, then after twice as long
+	// each time, until it has been tried maxNotificationAttempts times
+	maxNotificationAttempts = 5
+	notificationRetryDelay  = time.Minute
+)
+
+// DispatchNotifications fans new outbox events out to the channels of the
+// farmers they are for, then attempts every delivery that is due. It returns
+// how many were sent.
+func (s *FarmService) DispatchNotifications(ctx context.Context) (sent int, err error) {
+
+	_, err = s.store.DispatchOutboxEvents(ctx, s.channelNames(), config.NotificationDefaultChannels(), notificationBatch)
+	if err != nil {
+		return
+	}
+
+	deliveries, err := s.store.GetDueNotifications(ctx, notificationBatch)
+	if err != nil {
+		return
+	}
+
+	for _, delivery := range deliveries {
+		notification := delivery.Notification
+		notification.Attempts++
+
+		sendErr := fmt.Errorf("channel %s is not configured", notification.Channel)
+		if channel, ok := s.channels[notification.Channel]; ok {
+			sendErr = channel.Send(ctx, notify.Recipient{FarmerId: notification.FarmerId, Email: delivery.Email, Phone: delivery.Phone}, notification.Message)
+		}
+
+		switch {
+		case sendErr == nil:
+			notification.Status = domain.NotificationSent
+			notification.LastError = ""
+			sent++
+		case notification.Attempts >= maxNotificationAttempts:
+			notification.Status = domain.NotificationFailed
+			notification.LastError = sendErr.Error()
+		default:
+			notification.NextAttemptAt = time.Now().Add(notificationRetryDelay << (notification.Attempts - 1))
+			notification.LastError = sendErr.Error()
+		}
+
+		err = s.store.UpdateNotification(ctx, notification)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// SendBookingReminders queues a reminder for every confirmed booking that
+// starts within the configured hours.
+func (s *FarmService) SendBookingReminders(ctx context.Context) (queued int, err error) {
+	return s.store.QueueBookingReminders(ctx, config.BookingReminderHours())
+}
+
+// GetNotifications returns the farmer's in-app notifications, newest first.
+func (s *FarmService) GetNotifications(ctx context.Context, farmerId uint) (inbox domain.NotificationInbox, err error) {
+
+	inbox, err = s.store.GetNotificationInbox(ctx, farmerId, inboxSize)
+	if err != nil {
+		return
+	}
+	if inbox.Notifications == nil {
+		inbox.Notifications = []domain.Notification{}
+	}
+	return
+}
+
+func (s *FarmService) MarkNotificationRead(ctx context.Context, notificationId uint, farmerId uint) (err error) {
+
+	err = s.store.MarkNotificationRead(ctx, notificationId, farmerId)
+	if err == sql.ErrNoRows {
+		err = ErrNotificationNotFound
+	}
+	return
+}
+
+// GetNotificationPreferences returns whether the farmer is notified on each of
+// the configured channels.
+func (s *FarmService) GetNotificationPreferences(ctx context.Context, farmerId uint) (preferences []domain.NotificationPreference, err error) {
+
+	chosen, err := s.store.GetNotificationPreferences(ctx, farmerId)
+	if err != nil {
+		return
+	}
+
+	enabled := map[string]bool{}
+	for _, channel := range config.NotificationDefaultChannels() {
+		enabled[channel] = true
+	}
+	for _, preference := range chosen {
+		enabled[preference.Channel] = preference.Enabled
+	}
+
+	preferences = []domain.NotificationPreference{}
+	for _, channel := range s.channelNames() {
+		preferences = append(preferences, domain.NotificationPreference{Channel: channel, Enabled: enabled[channel]})
+	}
+	return
+}
+
+// SetNotificationPreferences turns the channels on or off for the farmer.
+// Channels that are not mentioned keep their setting.
+func (s *FarmService) SetNotificationPreferences(ctx context.Context, farmerId uint, preferences []domain.NotificationPreference) (updated []domain.NotificationPreference, err error) {
+
+	for _, preference := range preferences {
+		if _, ok := s.channels[preference.Channel]; !ok {
+			return nil, ErrUnknownChannel
+		}
+	}
+
+	err = s.store.SetNotificationPreferences(ctx, farmerId, preferences)
+	if err != nil {
+		return
+	}
+
+	return s.GetNotificationPreferences(ctx, farmerId)
+}
+
+// channelNames returns the names of the configured channels in order.
+func (s *FarmService) channelNames() (names []string) {
+	for name := range s.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+func getNotificationsHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		inbox, err := deps.FarmService.GetNotifications(r.Context(), farmerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, inbox)
+	}
+}
+
+func markNotificationReadHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		notificationId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid notification id"))
+			return
+		}
+
+		if err = deps.FarmService.MarkNotificationRead(r.Context(), uint(notificationId), farmerId); err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, api.Message{Msg: "notification read"})
+	}
+}
+
+func getNotificationPreferencesHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		preferences, err := deps.FarmService.GetNotificationPreferences(r.Context(), farmerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, preferences)
+	}
+}
+
+func setNotificationPreferencesHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var preferences []domain.NotificationPreference
+
+		if err := decode(r, &preferences); err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		farmerId := r.Context().Value("token").(uint)
+
+		preferences, err := deps.FarmService.SetNotificationPreferences(r.Context(), farmerId, preferences)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, preferences)
+	}
+}
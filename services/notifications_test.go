@@ -0,0 +1,167 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/domain"
+	"FarmEasy/notify"
+	"FarmEasy/payment"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *ServiceTestSuite) TestFarmService_DispatchNotifications() {
+	t := s.T()
+	ctx := context.TODO()
+
+	sms := notify.NewFake(notify.SMS)
+	service := NewFarmService(s.repo, payment.NewFake("secret"), notify.NewInApp(), sms)
+
+	delivery := func(id uint, channel string, attempts uint) domain.NotificationDelivery {
+		return domain.NotificationDelivery{
+			Notification: domain.Notification{Id: id, FarmerId: 9, Channel: channel, Kind: domain.EventBookingAccepted, Message: "Your booking 11 of machine 2 is confirmed", Status: domain.NotificationPending, Attempts: attempts},
+			Phone:        "9876543210",
+		}
+	}
+
+	s.repo.On("DispatchOutboxEvents", ctx, []string{notify.InApp, notify.SMS}, []string{notify.InApp, notify.Email}, uint(notificationBatch)).Return(1, nil).Once()
+	s.repo.On("GetDueNotifications", ctx, uint(notificationBatch)).Return([]domain.NotificationDelivery{
+		delivery(1, notify.InApp, 0),
+		delivery(2, notify.SMS, 0),
+		delivery(3, notify.SMS, 1),
+		delivery(4, notify.SMS, maxNotificationAttempts-1),
+		delivery(5, notify.Email, 0),
+	}, nil).Once()
+	sms.FailNext(errors.New("gateway timeout"), errors.New("gateway timeout"))
+
+	s.repo.On("UpdateNotification", ctx, mock.MatchedBy(func(n domain.Notification) bool {
+		return n.Id == 1 && n.Status == domain.NotificationSent && n.Attempts == 1
+	})).Return(nil).Once()
+	s.repo.On("UpdateNotification", ctx, mock.MatchedBy(func(n domain.Notification) bool {
+		// the first failure is retried after a minute
+		wait := time.Until(n.NextAttemptAt)
+		return n.Id == 2 && n.Status == domain.NotificationPending && n.Attempts == 1 && n.LastError == "gateway timeout" && wait > 50*time.Second && wait <= time.Minute
+	})).Return(nil).Once()
+	s.repo.On("UpdateNotification", ctx, mock.MatchedBy(func(n domain.Notification) bool {
+		// the second after two
+		wait := time.Until(n.NextAttemptAt)
+		return n.Id == 3 && n.Status == domain.NotificationPending && n.Attempts == 2 && wait > 110*time.Second && wait <= 2*time.Minute
+	})).Return(nil).Once()
+	s.repo.On("UpdateNotification", ctx, mock.MatchedBy(func(n domain.Notification) bool {
+		return n.Id == 4 && n.Status == domain.NotificationSent && n.Attempts == maxNotificationAttempts
+	})).Return(nil).Once()
+	s.repo.On("UpdateNotification", ctx, mock.MatchedBy(func(n domain.Notification) bool {
+		return n.Id == 5 && n.Status == domain.NotificationPending && n.LastError == "channel email is not configured"
+	})).Return(nil).Once()
+
+	sent, err := service.DispatchNotifications(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Equal(t, []notify.Sent{{To: notify.Recipient{FarmerId: 9, Phone: "9876543210"}, Address: "9876543210", Message: "Your booking 11 of machine 2 is confirmed"}}, sms.Sent())
+}
+
+func (s *ServiceTestSuite) TestFarmService_DispatchNotifications_givesUp() {
+	t := s.T()
+	ctx := context.TODO()
+
+	sms := notify.NewFake(notify.SMS)
+	service := NewFarmService(s.repo, payment.NewFake("secret"), sms)
+	sms.FailNext(errors.New("number unreachable"))
+
+	s.repo.On("DispatchOutboxEvents", ctx, []string{notify.SMS}, mock.Anything, uint(notificationBatch)).Return(0, nil).Once()
+	s.repo.On("GetDueNotifications", ctx, uint(notificationBatch)).Return([]domain.NotificationDelivery{{
+		Notification: domain.Notification{Id: 6, FarmerId: 9, Channel: notify.SMS, Status: domain.NotificationPending, Attempts: maxNotificationAttempts - 1},
+		Phone:        "9876543210",
+	}}, nil).Once()
+	s.repo.On("UpdateNotification", ctx, mock.MatchedBy(func(n domain.Notification) bool {
+		return n.Id == 6 && n.Status == domain.NotificationFailed && n.Attempts == maxNotificationAttempts && n.LastError == "number unreachable"
+	})).Return(nil).Once()
+
+	sent, err := service.DispatchNotifications(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+}
+
+func (s *ServiceTestSuite) TestFarmService_NotificationPreferences() {
+	t := s.T()
+	ctx := context.TODO()
+
+	service := NewFarmService(s.repo, payment.NewFake("secret"), notify.NewInApp(), notify.NewFake(notify.Email), notify.NewFake(notify.SMS))
+
+	t.Run("when the farmer has not chosen, the defaults apply", func(t *testing.T) {
+		s.repo.On("GetNotificationPreferences", ctx, uint(9)).Return(nil, nil).Once()
+
+		got, err := service.GetNotificationPreferences(ctx, 9)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.NotificationPreference{{Channel: notify.Email, Enabled: true}, {Channel: notify.InApp, Enabled: true}, {Channel: notify.SMS, Enabled: false}}, got)
+	})
+
+	t.Run("when the channel is not configured", func(t *testing.T) {
+		_, err := service.SetNotificationPreferences(ctx, 9, []domain.NotificationPreference{{Channel: "whatsapp", Enabled: true}})
+		assert.Equal(t, ErrUnknownChannel, err)
+	})
+
+	t.Run("when the farmer turns email off and SMS on", func(t *testing.T) {
+		chosen := []domain.NotificationPreference{{Channel: notify.Email, Enabled: false}, {Channel: notify.SMS, Enabled: true}}
+		s.repo.On("SetNotificationPreferences", ctx, uint(9), chosen).Return(nil).Once()
+		s.repo.On("GetNotificationPreferences", ctx, uint(9)).Return(chosen, nil).Once()
+
+		got, err := service.SetNotificationPreferences(ctx, 9, chosen)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.NotificationPreference{{Channel: notify.Email, Enabled: false}, {Channel: notify.InApp, Enabled: true}, {Channel: notify.SMS, Enabled: true}}, got)
+	})
+}
+
+func (s *ServiceTestSuite) TestFarmService_MarkNotificationRead() {
+	t := s.T()
+	ctx := context.TODO()
+
+	s.repo.On("MarkNotificationRead", ctx, uint(7), uint(10)).Return(sql.ErrNoRows).Once()
+	assert.Equal(t, ErrNotificationNotFound, s.service.MarkNotificationRead(ctx, 7, 10))
+}
+
+func (s *ServiceTestSuite) Test_outboxNotifier() {
+	ctx := context.TODO()
+
+	s.repo.On("AddOutboxEvent", ctx, domain.OutboxEvent{Kind: domain.EventNotice, FarmerId: 9, Message: "Invoice FE/2026-27/000042 was paid"}).Return(nil).Once()
+	outboxNotifier{store: s.repo}.Notify(ctx, 9, "Invoice FE/2026-27/000042 was paid")
+}
+
+func (s *HandlerTestSuite) Test_setNotificationPreferencesHandler() {
+	t := s.T()
+
+	deps := dependencies{
+		FarmService: s.service,
+	}
+
+	t.Run("when the body is not a list", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPut, "/notifications/preferences", strings.NewReader(`{"channel": "sms"}`))
+		r = r.WithContext(context.WithValue(r.Context(), "token", uint(9)))
+		w := httptest.NewRecorder()
+
+		setNotificationPreferencesHandler(deps).ServeHTTP(w, r)
+		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+	})
+
+	t.Run("when the channel is unknown", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPut, "/notifications/preferences", strings.NewReader(`[{"channel": "whatsapp", "enabled": true}]`))
+		r = r.WithContext(context.WithValue(r.Context(), "token", uint(9)))
+		w := httptest.NewRecorder()
+		s.service.On("SetNotificationPreferences", r.Context(), uint(9), []domain.NotificationPreference{{Channel: "whatsapp", Enabled: true}}).Return(nil, ErrUnknownChannel).Once()
+
+		setNotificationPreferencesHandler(deps).ServeHTTP(w, r)
+		exp, _ := json.Marshal(api.ErrorResponse{Error: *ErrUnknownChannel})
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+		assert.Equal(t, string(exp), w.Body.String())
+	})
+}
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"FarmEasy/db"
+	"FarmEasy/domain"
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Notifier tells a farmer about something that concerns them.
+type Notifier interface {
+	Notify(ctx context.Context, farmerId uint, message string)
+}
+
+// outboxNotifier queues the message in the outbox, from where it is delivered
+// on the farmer's channels.
+type outboxNotifier struct {
+	store db.Storer
+}
+
+func (n outboxNotifier) Notify(ctx context.Context, farmerId uint, message string) {
+	err := n.store.AddOutboxEvent(ctx, domain.OutboxEvent{Kind: domain.EventNotice, FarmerId: farmerId, Message: message})
+	if err != nil {
+		logrus.WithField("farmer_id", farmerId).WithField("err", err.Error()).Error("error queueing notification")
+	}
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/config"
+	"FarmEasy/domain"
+	"FarmEasy/openapi"
+	"fmt"
+	"html"
+	"net/http"
+	"sync"
+)
+
+const idParam = "{id:[0-9]+}"
+
+var (
+	idempotencyKey  = openapi.Parameter{Name: idempotencyHeader, Description: "makes retries of the request safe", Schema: &openapi.Schema{Type: "string"}}
+	qrParam         = openapi.Parameter{Name: "qr", Description: "true to print a QR code of the invoice id", Schema: &openapi.Schema{Type: "boolean"}}
+	messageResponse = api.Message{}
+)
+
+// routes documents every operation in InitRouter. TestOpenAPI fails when one
+// is missing. The fake provider's pay endpoint is left out, as it only
+// exists for local testing.
+var routes = []openapi.Route{
+	{Method: http.MethodGet, Path: "/ping", Summary: "Check that the server is up", Tag: "health", Response: PingResponse{}},
+
+	{Method: http.MethodPost, Path: "/register", Summary: "Register a farmer", Tag: "farmers", Request: domain.NewFarmerRequest{}, Response: domain.FarmerResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/login", Summary: "Log in and get a token", Tag: "farmers", Request: domain.LoginRequest{}, Response: domain.LoginResponse{}},
+	{Method: http.MethodGet, Path: "/farmers/" + idParam, Summary: "Get a farmer and their ratings", Tag: "farmers", Auth: openapi.Bearer, Response: domain.FarmerResponse{}},
+	{Method: http.MethodGet, Path: "/farmers/" + idParam + "/reviews", Summary: "List the reviews of a farmer", Tag: "reviews", Auth: openapi.Bearer, Response: []domain.Review{}},
+
+	{Method: http.MethodPost, Path: "/machines", Summary: "List a machine for rent", Tag: "machines", Auth: openapi.Bearer, Request: domain.NewMachineRequest{}, Response: domain.MachineResponse{}},
+	{Method: http.MethodGet, Path: "/machines", Summary: "List all machines", Tag: "machines", Auth: openapi.Bearer, Response: []domain.MachineResponse{}},
+	{Method: http.MethodGet, Path: "/machines/" + idParam + "/availability/stream", Summary: "Follow changes to a machine's availability as server-sent events", Tag: "availability", Auth: openapi.Bearer, ContentType: "text/event-stream"},
+	{Method: http.MethodGet, Path: "/machines/" + idParam + "/reviews", Summary: "List the reviews of a machine", Tag: "reviews", Auth: openapi.Bearer, Response: []domain.Review{}},
+	{Method: http.MethodGet, Path: "/slots", Summary: "List the bookable slots of a day", Tag: "machines", Auth: openapi.Bearer, Response: []domain.SlotResponse{}},
+
+	{Method: http.MethodPost, Path: "/availability", Summary: "Get the free slots of a machine on a date", Tag: "availability", Auth: openapi.Bearer, Request: domain.AvailabilityRequest{}, Response: domain.AvailabilityResponse{}},
+	{Method: http.MethodPost, Path: "/availability/search", Summary: "Find the next free windows of machines", Tag: "availability", Auth: openapi.Bearer, Request: domain.NextAvailableRequest{}, Response: []domain.AvailableWindow{}},
+
+	{Method: http.MethodPost, Path: "/bookings", Summary: "Book a machine", Tag: "bookings", Auth: openapi.Bearer, Request: domain.NewBookingRequest{}, Response: domain.NewBookingResponse{}, Status: http.StatusCreated, Headers: []openapi.Parameter{idempotencyKey}},
+	{Method: http.MethodGet, Path: "/bookings", Summary: "List the farmer's bookings", Tag: "bookings", Auth: openapi.Bearer, Response: []domain.BookingResponse{}},
+	{Method: http.MethodDelete, Path: "/bookings/" + idParam, Summary: "Cancel a booking", Tag: "bookings", Auth: openapi.Bearer, Response: domain.CancelledBooking{}},
+	{Method: http.MethodPost, Path: "/bookings/recurring", Summary: "Book a machine on a recurring schedule", Tag: "bookings", Auth: openapi.Bearer, Request: domain.NewRecurringBookingRequest{}, Response: domain.RecurringBookingResponse{}, Status: http.StatusCreated, Headers: []openapi.Parameter{idempotencyKey}},
+	{Method: http.MethodDelete, Path: "/bookings/series/" + idParam, Summary: "Cancel the remaining bookings of a series", Tag: "bookings", Auth: openapi.Bearer, Response: []domain.CancelledBooking{}},
+	{Method: http.MethodPost, Path: "/orders", Summary: "Book several machines in one order", Tag: "bookings", Auth: openapi.Bearer, Request: domain.NewOrderRequest{}, Response: domain.OrderResponse{}, Status: http.StatusCreated, Headers: []openapi.Parameter{idempotencyKey}},
+
+	{Method: http.MethodPost, Path: "/bookings/" + idParam + "/check-in", Summary: "Record the machine's state at check-in", Tag: "handovers", Auth: openapi.Bearer, Request: domain.HandoverRequest{}, Response: domain.Handover{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/bookings/" + idParam + "/check-in/confirm", Summary: "Confirm the other party's check-in record", Tag: "handovers", Auth: openapi.Bearer, Response: domain.Handover{}},
+	{Method: http.MethodPost, Path: "/bookings/" + idParam + "/check-out", Summary: "Record the machine's state at check-out", Tag: "handovers", Auth: openapi.Bearer, Request: domain.HandoverRequest{}, Response: domain.Handover{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/bookings/" + idParam + "/check-out/confirm", Summary: "Confirm the other party's check-out record", Tag: "handovers", Auth: openapi.Bearer, Response: domain.Handover{}},
+	{Method: http.MethodGet, Path: "/bookings/" + idParam + "/handovers", Summary: "List the handover records of a booking", Tag: "handovers", Auth: openapi.Bearer, Response: []domain.Handover{}},
+	{Method: http.MethodPost, Path: "/bookings/" + idParam + "/reviews", Summary: "Review the other party of a completed booking", Tag: "reviews", Auth: openapi.Bearer, Request: domain.NewReviewRequest{}, Response: domain.Review{}, Status: http.StatusCreated},
+
+	{Method: http.MethodPost, Path: "/reviews/" + idParam + "/report", Summary: "Report an abusive review", Tag: "reviews", Auth: openapi.Bearer, Request: domain.ReviewReportRequest{}, Response: domain.ReviewReport{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/admin/review-reports", Summary: "List open review reports", Tag: "admin", Auth: openapi.Admin, Response: []domain.ReviewReport{}},
+	{Method: http.MethodPost, Path: "/admin/review-reports/" + idParam + "/resolve", Summary: "Dismiss a report or remove the review", Tag: "admin", Auth: openapi.Admin, Request: domain.ReviewReportDecisionRequest{}, Response: domain.ReviewReport{}},
+
+	{Method: http.MethodPost, Path: "/threads", Summary: "Start a thread about a machine or a booking", Tag: "messages", Auth: openapi.Bearer, Request: domain.NewThreadRequest{}, Response: domain.Thread{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/threads", Summary: "List the farmer's threads with their unread counts", Tag: "messages", Auth: openapi.Bearer, Response: []domain.Thread{}},
+	{Method: http.MethodGet, Path: "/threads/" + idParam + "/messages", Summary: "Page through the messages of a thread", Tag: "messages", Auth: openapi.Bearer, Response: domain.MessagePage{}, Query: []openapi.Parameter{
+		{Name: "before", Description: "only messages older than this message id", Schema: &openapi.Schema{Type: "integer"}},
+		{Name: "limit", Description: "at most this many messages", Schema: &openapi.Schema{Type: "integer"}},
+	}},
+	{Method: http.MethodPost, Path: "/threads/" + idParam + "/messages", Summary: "Post a message to a thread", Tag: "messages", Auth: openapi.Bearer, Request: domain.NewMessageRequest{}, Response: domain.Message{}, Status: http.StatusCreated},
+
+	{Method: http.MethodGet, Path: "/notifications", Summary: "List the farmer's notifications", Tag: "notifications", Auth: openapi.Bearer, Response: domain.NotificationInbox{}},
+	{Method: http.MethodPost, Path: "/notifications/" + idParam + "/read", Summary: "Mark a notification read", Tag: "notifications", Auth: openapi.Bearer, Response: messageResponse},
+	{Method: http.MethodGet, Path: "/notifications/preferences", Summary: "Get the farmer's notification preferences", Tag: "notifications", Auth: openapi.Bearer, Response: []domain.NotificationPreference{}},
+	{Method: http.MethodPut, Path: "/notifications/preferences", Summary: "Set the farmer's notification preferences", Tag: "notifications", Auth: openapi.Bearer, Request: []domain.NotificationPreference{}, Response: []domain.NotificationPreference{}},
+
+	{Method: http.MethodPost, Path: "/api-clients", Summary: "Register a partner application", Tag: "webhooks", Auth: openapi.Bearer, Request: domain.NewAPIClientRequest{}, Response: domain.APIClient{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/api-clients", Summary: "List the farmer's partner applications", Tag: "webhooks", Auth: openapi.Bearer, Response: []domain.APIClient{}},
+	{Method: http.MethodPost, Path: "/api-clients/" + idParam + "/webhooks", Summary: "Subscribe a webhook to events", Tag: "webhooks", Auth: openapi.Bearer, Request: domain.NewWebhookRequest{}, Response: domain.WebhookSubscription{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/api-clients/" + idParam + "/webhooks", Summary: "List the webhooks of a partner application", Tag: "webhooks", Auth: openapi.Bearer, Response: []domain.WebhookSubscription{}},
+	{Method: http.MethodDelete, Path: "/webhooks/" + idParam, Summary: "Stop deliveries to a webhook", Tag: "webhooks", Auth: openapi.Bearer, Response: messageResponse},
+	{Method: http.MethodGet, Path: "/webhooks/" + idParam + "/deliveries", Summary: "List the latest deliveries to a webhook", Tag: "webhooks", Auth: openapi.Bearer, Response: []domain.WebhookDelivery{}},
+	{Method: http.MethodPost, Path: "/webhook-deliveries/" + idParam + "/replay", Summary: "Send a delivery again", Tag: "webhooks", Auth: openapi.Bearer, Response: domain.WebhookDelivery{}, Status: http.StatusCreated},
+
+	{Method: http.MethodGet, Path: "/invoices", Summary: "List the farmer's invoices", Tag: "invoices", Auth: openapi.Bearer, Response: []domain.Invoice{}},
+	{Method: http.MethodGet, Path: "/invoices/" + idParam, Summary: "Get an invoice", Tag: "invoices", Auth: openapi.Bearer, Response: domain.Invoice{}},
+	{Method: http.MethodGet, Path: "/invoices/" + idParam + ".pdf", Summary: "Download an invoice as a PDF", Tag: "invoices", Auth: openapi.Bearer, ContentType: "application/pdf", Query: []openapi.Parameter{qrParam}},
+	{Method: http.MethodGet, Path: "/invoices/" + idParam + ".html", Summary: "View an invoice as HTML", Tag: "invoices", Auth: openapi.Bearer, ContentType: "text/html", Query: []openapi.Parameter{qrParam}},
+
+	{Method: http.MethodPost, Path: "/payments", Summary: "Start paying an invoice", Tag: "payments", Auth: openapi.Bearer, Request: domain.NewPaymentRequest{}, Response: domain.Payment{}, Status: http.StatusCreated, Headers: []openapi.Parameter{idempotencyKey}},
+	{Method: http.MethodPost, Path: "/payments/webhook", Summary: "Receive a payment provider's signed notification", Tag: "payments", Request: map[string]interface{}{}, Response: messageResponse, Headers: []openapi.Parameter{
+		{Name: paymentSignatureHeader, Required: true, Description: "the provider's signature of the body", Schema: &openapi.Schema{Type: "string"}},
+	}},
+	{Method: http.MethodGet, Path: "/payments/" + idParam, Summary: "Get a payment", Tag: "payments", Auth: openapi.Bearer, Response: domain.Payment{}},
+	{Method: http.MethodPost, Path: "/payments/" + idParam + "/refund", Summary: "Refund a captured payment", Tag: "payments", Auth: openapi.Bearer, Response: domain.Payment{}, Headers: []openapi.Parameter{idempotencyKey}},
+
+	{Method: http.MethodGet, Path: "/deposits", Summary: "List the farmer's security deposits", Tag: "deposits", Auth: openapi.Bearer, Response: []domain.Deposit{}},
+	{Method: http.MethodGet, Path: "/deposits/" + idParam, Summary: "Get a security deposit", Tag: "deposits", Auth: openapi.Bearer, Response: domain.Deposit{}},
+	{Method: http.MethodPost, Path: "/deposits/" + idParam + "/release", Summary: "Release a held deposit", Tag: "deposits", Auth: openapi.Bearer, Response: domain.Deposit{}},
+	{Method: http.MethodPost, Path: "/deposits/" + idParam + "/capture", Summary: "Keep part of a held deposit", Tag: "deposits", Auth: openapi.Bearer, Request: domain.DepositCaptureRequest{}, Response: domain.Deposit{}},
+
+	{Method: http.MethodGet, Path: "/owner/earnings", Summary: "Get the owner's earnings and payouts", Tag: "wallet", Auth: openapi.Bearer, Response: domain.OwnerEarnings{}},
+	{Method: http.MethodGet, Path: "/wallet", Summary: "Get the farmer's wallet", Tag: "wallet", Auth: openapi.Bearer, Response: domain.Wallet{}},
+	{Method: http.MethodGet, Path: "/wallet/transactions", Summary: "List the wallet's transactions", Tag: "wallet", Auth: openapi.Bearer, Response: []domain.WalletTransaction{}},
+	{Method: http.MethodPost, Path: "/wallet/topups", Summary: "Top up the wallet through the payment provider", Tag: "wallet", Auth: openapi.Bearer, Request: domain.NewTopupRequest{}, Response: domain.WalletTopup{}, Status: http.StatusCreated, Headers: []openapi.Parameter{idempotencyKey}},
+
+	{Method: http.MethodPost, Path: "/admin/promos", Summary: "Add a promo code", Tag: "admin", Auth: openapi.Admin, Request: domain.NewPromoCodeRequest{}, Response: domain.PromoCode{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/admin/promos", Summary: "List promo codes", Tag: "admin", Auth: openapi.Admin, Response: []domain.PromoCode{}},
+	{Method: http.MethodDelete, Path: "/admin/promos/" + idParam, Summary: "Deactivate a promo code", Tag: "admin", Auth: openapi.Admin, Response: domain.PromoCode{}},
+	{Method: http.MethodGet, Path: "/admin/promos/" + idParam + "/redemptions", Summary: "List the redemptions of a promo code", Tag: "admin", Auth: openapi.Admin, Response: []domain.PromoRedemption{}},
+
+	{Method: http.MethodPost, Path: "/claims", Summary: "File a damage claim against a completed booking", Tag: "claims", Auth: openapi.Bearer, Request: domain.NewClaimRequest{}, Response: domain.Claim{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/claims", Summary: "List the farmer's claims", Tag: "claims", Auth: openapi.Bearer, Response: []domain.Claim{}},
+	{Method: http.MethodGet, Path: "/claims/" + idParam, Summary: "Get a claim", Tag: "claims", Auth: openapi.Bearer, Response: domain.Claim{}},
+	{Method: http.MethodPost, Path: "/claims/" + idParam + "/respond", Summary: "Accept or dispute a claim", Tag: "claims", Auth: openapi.Bearer, Request: domain.ClaimResponseRequest{}, Response: domain.Claim{}},
+	{Method: http.MethodGet, Path: "/admin/claims", Summary: "List claims waiting for a decision", Tag: "admin", Auth: openapi.Admin, Response: []domain.Claim{}},
+	{Method: http.MethodPost, Path: "/admin/claims/" + idParam + "/decide", Summary: "Decide a disputed claim", Tag: "admin", Auth: openapi.Admin, Request: domain.ClaimDecisionRequest{}, Response: domain.Claim{}},
+
+	{Method: http.MethodPost, Path: "/waitlist", Summary: "Join the waitlist for a taken slot range", Tag: "waitlist", Auth: openapi.Bearer, Request: domain.NewWaitlistRequest{}, Response: domain.WaitlistEntry{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/waitlist", Summary: "List the farmer's waitlist entries", Tag: "waitlist", Auth: openapi.Bearer, Response: []domain.WaitlistEntry{}},
+	{Method: http.MethodDelete, Path: "/waitlist/" + idParam, Summary: "Leave the waitlist", Tag: "waitlist", Auth: openapi.Bearer, Response: messageResponse},
+	{Method: http.MethodPost, Path: "/waitlist/" + idParam + "/confirm", Summary: "Confirm a booking offered from the waitlist", Tag: "waitlist", Auth: openapi.Bearer, Response: domain.WaitlistEntry{}},
+}
+
+var (
+	openAPIOnce sync.Once
+	openAPIDoc  openapi.Document
+)
+
+// OpenAPI is the OpenAPI document of the API.
+func OpenAPI() openapi.Document {
+	openAPIOnce.Do(func() {
+		b := openapi.New(openapi.Info{
+			Title:       "FarmEasy",
+			Description: "Lend and borrow farming machines. Amounts are strings of rupees and paise in the given currency.",
+			Version:     "1.0.0",
+		}, APIPrefix, api.ErrorResponse{})
+
+		b.Define(domain.Money{}, openapi.Schema{
+			Type: "object",
+			Properties: map[string]*openapi.Schema{
+				"amount":   {Type: "string", Description: "a decimal amount, such as 1250.50"},
+				"currency": {Type: "string", Description: "an ISO 4217 code, INR when left out"},
+			},
+			Required: []string{"amount"},
+		})
+
+		for _, route := range routes {
+			b.Add(route)
+		}
+		openAPIDoc = b.Document()
+	})
+	return openAPIDoc
+}
+
+func openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	api.Response(w, http.StatusOK, OpenAPI())
+}
+
+// redocBundle is pinned so the docs page only runs the script its integrity
+// digest was taken from.
+const redocBundle = "https://cdn.redoc.ly/redoc/v2.1.5/bundles/redoc.standalone.js"
+
+// docsPage renders /openapi.json with Redoc, given the script's attributes.
+const docsPage = `<!DOCTYPE html>
+<html>
+<head>
+<title>FarmEasy API</title>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+</head>
+<body>
+<redoc spec-url="/openapi.json"></redoc>
+<script src="%s"%s crossorigin="anonymous"></script>
+</body>
+</html>
+`
+
+func docsHandler(w http.ResponseWriter, r *http.Request) {
+	var integrity string
+	if digest := config.RedocIntegrity(); digest != "" {
+		integrity = fmt.Sprintf(` integrity="%s"`, html.EscapeString(digest))
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, docsPage, redocBundle, integrity)
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"FarmEasy/mocks"
+	"FarmEasy/openapi"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPI(t *testing.T) {
+	router := InitRouter(dependencies{FarmService: &mocks.Service{}})
+
+	var served []string
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil || !strings.HasPrefix(template, APIPrefix+"/") {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			served = append(served, method+" "+openapi.Path(strings.TrimPrefix(template, APIPrefix)))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	documented := OpenAPI().Operations()
+
+	for _, operation := range served {
+		assert.Contains(t, documented, operation, "route is missing from the OpenAPI document")
+	}
+	for _, operation := range documented {
+		assert.Contains(t, served, operation, "documented route is not served")
+	}
+}
+
+func TestOpenAPI_references(t *testing.T) {
+	body, err := json.Marshal(OpenAPI())
+	require.NoError(t, err)
+
+	var doc openapi.Document
+	require.NoError(t, json.Unmarshal(body, &doc))
+
+	for _, ref := range regexp.MustCompile(`"\$ref":"#/components/schemas/(\w+)"`).FindAllStringSubmatch(string(body), -1) {
+		assert.Contains(t, doc.Components.Schemas, ref[1])
+	}
+	assert.Contains(t, doc.Components.Schemas, "Message")
+	assert.Contains(t, doc.Components.Schemas, "ApiMessage")
+	assert.Equal(t, "object", doc.Components.Schemas["Money"].Type)
+}
+
+func Test_openAPIHandler(t *testing.T) {
+	router := InitRouter(dependencies{FarmService: &mocks.Service{}})
+
+	r := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Contains(t, w.Body.String(), `"openapi":"3.0.3"`)
+	assert.Contains(t, w.Body.String(), `"url":"/api/v1"`)
+
+	r = httptest.NewRequest(http.MethodGet, "/docs", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Contains(t, w.Body.String(), `spec-url="/openapi.json"`)
+	assert.Contains(t, w.Body.String(), `<script src="https://cdn.redoc.ly/redoc/v2.1.5/bundles/redoc.standalone.js" crossorigin="anonymous">`)
+
+	viper.Set("REDOC_INTEGRITY", "sha384-digest")
+	defer viper.Set("REDOC_INTEGRITY", "")
+	r = httptest.NewRequest(http.MethodGet, "/docs", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	assert.Contains(t, w.Body.String(), `redoc.standalone.js" integrity="sha384-digest" crossorigin="anonymous">`)
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/config"
+	"FarmEasy/domain"
+	"context"
+	"net/http"
+)
+
+const maxCartLines = 10
+
+func (s *FarmService) Checkout(ctx context.Context, order domain.NewOrderRequest) (rsp domain.OrderResponse, err error) {
+
+	if len(order.Lines) == 0 {
+		err = ErrEmptyCart
+		return
+	}
+	if len(order.Lines) > maxCartLines {
+		err = ErrCartTooLarge
+		return
+	}
+
+	if order.PayFromWallet {
+		order.CommissionBp = config.PlatformCommission()
+	}
+	rsp, err = s.store.Checkout(ctx, order)
+	if err != nil {
+		return
+	}
+
+	for _, line := range order.Lines {
+		s.availabilityChanged(ctx, line.MachineId, line.Date, domain.AvailabilityBooked)
+	}
+	return
+}
+
+func checkoutHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var order domain.NewOrderRequest
+
+		if err := decode(r, &order); err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		order.FarmerId = r.Context().Value("token").(uint)
+
+		placed, err := deps.FarmService.Checkout(r.Context(), order)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusCreated, placed)
+	}
+}
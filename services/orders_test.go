@@ -0,0 +1,71 @@
+package services
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *ServiceTestSuite) TestFarmService_Checkout() {
+	t := s.T()
+
+	t.Run("when the cart is empty", func(t *testing.T) {
+		_, err := s.service.Checkout(context.TODO(), domain.NewOrderRequest{FarmerId: 1})
+		assert.Equal(t, ErrEmptyCart, err)
+	})
+
+	t.Run("when the cart is valid", func(t *testing.T) {
+		order := domain.NewOrderRequest{FarmerId: 1, Lines: []domain.CartLine{{MachineId: 1, Date: "2026-11-07", Slots: []uint{7}}}}
+		s.repo.On("Checkout", context.TODO(), order).Return(domain.OrderResponse{OrderId: 4}, nil).Once()
+		got, err := s.service.Checkout(context.TODO(), order)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(4), got.OrderId)
+	})
+}
+
+func (s *HandlerTestSuite) Test_checkoutHandler() {
+	t := s.T()
+
+	deps := dependencies{
+		FarmService: s.service,
+	}
+
+	t.Run("when the order is placed", func(t *testing.T) {
+		bodyReader := strings.NewReader(`{"lines": [{"machine_id": 1, "date": "2026-11-07", "slots": [7, 8]}, {"machine_id": 2, "date": "2026-11-07", "slots": [7, 8]}]}`)
+		r := httptest.NewRequest(http.MethodPost, "/orders", bodyReader)
+		r = r.WithContext(context.WithValue(r.Context(), "token", uint(1)))
+		w := httptest.NewRecorder()
+		requestBody := domain.NewOrderRequest{
+			FarmerId: 1,
+			Lines: []domain.CartLine{
+				{MachineId: 1, Date: "2026-11-07", Slots: []uint{7, 8}},
+				{MachineId: 2, Date: "2026-11-07", Slots: []uint{7, 8}},
+			},
+		}
+		respBody := domain.OrderResponse{OrderId: 4, TotalCost: domain.Rupees(2000)}
+		s.service.On("Checkout", r.Context(), requestBody).Return(respBody, nil).Once()
+
+		checkoutHandler(deps).ServeHTTP(w, r)
+		exp, _ := json.Marshal(respBody)
+		assert.Equal(t, http.StatusCreated, w.Result().StatusCode)
+		assert.Equal(t, string(exp), w.Body.String())
+	})
+
+	t.Run("when a line has an invalid slot", func(t *testing.T) {
+		bodyReader := strings.NewReader(`{"lines": [{"machine_id": 1, "date": "2026-11-07", "slots": [7]}, {"machine_id": 2, "date": "2026-11-07", "slots": [25]}]}`)
+		r := httptest.NewRequest(http.MethodPost, "/orders", bodyReader)
+		r = r.WithContext(context.WithValue(r.Context(), "token", uint(1)))
+		w := httptest.NewRecorder()
+
+		checkoutHandler(deps).ServeHTTP(w, r)
+		exp, _ := json.Marshal(invalidField("lines[1].slots", "must be a slot from 1 to 24"))
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+		assert.Equal(t, string(exp), w.Body.String())
+	})
+}
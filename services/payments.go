@@ -0,0 +1,328 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/config"
+	"FarmEasy/domain"
+	"FarmEasy/ledger"
+	"FarmEasy/payment"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	paymentSignatureHeader = "X-Payment-Signature"
+	maxWebhookBytes        = 1 << 20
+)
+
+// CreatePayment starts paying an unpaid invoice of the renter. The renter
+// pays the returned provider order with the provider. An invoice has one
+// open payment at a time, so asking again returns the same one.
+func (s *FarmService) CreatePayment(ctx context.Context, request domain.NewPaymentRequest) (created domain.Payment, err error) {
+
+	invoice, err := s.GetInvoice(ctx, request.InvoiceId, request.FarmerId)
+	if err != nil {
+		return
+	}
+	if invoice.FarmerId != request.FarmerId {
+		err = ErrInvoiceNotFound
+		return
+	}
+	if invoice.Status != domain.InvoiceUnpaid {
+		err = ErrInvoiceNotPayable
+		return
+	}
+
+	created, err = s.store.GetOpenPayment(ctx, invoice.Id)
+	if err != sql.ErrNoRows {
+		return
+	}
+
+	orderId, err := s.payments.CreateOrder(ctx, invoice.Amount, invoice.Number)
+	if err != nil {
+		return
+	}
+
+	created = domain.Payment{
+		InvoiceId:       invoice.Id,
+		FarmerId:        request.FarmerId,
+		Provider:        s.payments.Name(),
+		ProviderOrderId: orderId,
+		Amount:          invoice.Amount,
+	}
+	err = s.store.AddPayment(ctx, &created)
+	return
+}
+
+// GetPayment returns the payment to either party of its invoice.
+func (s *FarmService) GetPayment(ctx context.Context, paymentId uint, farmerId uint) (found domain.Payment, err error) {
+
+	found, err = s.store.GetPayment(ctx, paymentId)
+	if err == sql.ErrNoRows {
+		err = ErrPaymentNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	_, err = s.GetInvoice(ctx, found.InvoiceId, farmerId)
+	if err == ErrInvoiceNotFound {
+		return domain.Payment{}, ErrPaymentNotFound
+	}
+	return
+}
+
+// HandlePaymentWebhook applies a signed provider notification. Authorized
+// payments are captured and their invoice marked paid. Notifications for
+// payments that were already settled are acknowledged and ignored, since
+// providers deliver them more than once. A payment captured at the provider
+// but not recorded stays created and the notification is refused, so the
+// provider delivers it again and the capture, which providers repeat
+// harmlessly, is retried with the recording.
+func (s *FarmService) HandlePaymentWebhook(ctx context.Context, payload []byte, signature string) (err error) {
+
+	event, err := s.payments.VerifyWebhook(payload, signature)
+	if err != nil {
+		return
+	}
+
+	found, err := s.store.GetPaymentByOrder(ctx, s.payments.Name(), event.OrderId)
+	if err == sql.ErrNoRows {
+		return s.handleTopupEvent(ctx, event)
+	}
+	if err != nil {
+		return
+	}
+	if found.Status != domain.PaymentCreated {
+		return nil
+	}
+
+	switch event.Type {
+	case payment.EventPaymentFailed:
+		err = s.store.FailPayment(ctx, found.Id, event.PaymentId)
+
+	case payment.EventPaymentAuthorized:
+		if event.Amount != found.Amount {
+			return ErrPaymentAmountMismatch
+		}
+
+		invoice, err := s.store.GetInvoice(ctx, found.InvoiceId)
+		if err != nil {
+			return err
+		}
+		// paid meanwhile through another payment, the authorization is
+		// left to lapse at the provider
+		if invoice.Status != domain.InvoiceUnpaid {
+			return s.store.FailPayment(ctx, found.Id, event.PaymentId)
+		}
+
+		if err = s.payments.Capture(ctx, event.PaymentId, found.Amount); err != nil {
+			return err
+		}
+		transactions := ledger.InvoicePaid(invoice, found, config.PlatformCommission())
+		if err = s.store.CapturePayment(ctx, found.Id, event.PaymentId, transactions); err != nil {
+			return err
+		}
+
+		s.notifier.Notify(ctx, invoice.FarmerId, fmt.Sprintf("Payment of %s for invoice %s received", found.Amount, invoice.Number))
+		s.notifier.Notify(ctx, invoice.OwnerId, fmt.Sprintf("Invoice %s was paid", invoice.Number))
+	}
+
+	return
+}
+
+// GetOrderPayer returns the farmer who is to pay a provider order, for either
+// an invoice payment or a wallet top-up.
+func (s *FarmService) GetOrderPayer(ctx context.Context, orderId string) (farmerId uint, err error) {
+
+	found, err := s.store.GetPaymentByOrder(ctx, s.payments.Name(), orderId)
+	if err == nil {
+		return found.FarmerId, nil
+	}
+	if err != sql.ErrNoRows {
+		return
+	}
+
+	topup, err := s.store.GetWalletTopupByOrder(ctx, s.payments.Name(), orderId)
+	if err == sql.ErrNoRows {
+		err = ErrPaymentNotFound
+	}
+	return topup.FarmerId, err
+}
+
+// RefundPayment refunds what is left of a captured payment. Only the machine
+// owner, who was paid, can refund. Wallet payments go back to the wallet.
+func (s *FarmService) RefundPayment(ctx context.Context, paymentId uint, farmerId uint) (refunded domain.Payment, err error) {
+
+	refunded, err = s.store.GetPayment(ctx, paymentId)
+	if err == sql.ErrNoRows {
+		err = ErrPaymentNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	invoice, err := s.store.GetInvoice(ctx, refunded.InvoiceId)
+	if err != nil {
+		return
+	}
+	if invoice.OwnerId != farmerId {
+		return domain.Payment{}, ErrPaymentNotFound
+	}
+	if refunded.Status != domain.PaymentCaptured {
+		err = ErrPaymentNotRefundable
+		return
+	}
+
+	rest := refunded.Refundable()
+	var refundId string
+	if refunded.Provider != domain.WalletProvider {
+		// keyed on the payment, so a retry after the store failed gets the
+		// same refund back instead of refunding twice
+		refundId, err = s.payments.Refund(ctx, refunded.ProviderPaymentId, rest, fmt.Sprintf("refund:payment:%d", refunded.Id))
+		if err != nil {
+			return
+		}
+	}
+	err = s.store.RefundPayment(ctx, refunded.Id, refundId)
+	if err == sql.ErrNoRows {
+		err = ErrPaymentNotRefundable
+	}
+	if err != nil {
+		return
+	}
+
+	refunded.Status = domain.PaymentRefunded
+	refunded.ProviderRefundId = refundId
+	refunded.Refunded = refunded.Amount
+	s.notifier.Notify(ctx, invoice.FarmerId, fmt.Sprintf("Payment of %s for invoice %s was refunded", rest, invoice.Number))
+	return
+}
+
+func createPaymentHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var request domain.NewPaymentRequest
+
+		if err := decode(r, &request); err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		request.FarmerId = r.Context().Value("token").(uint)
+
+		created, err := deps.FarmService.CreatePayment(r.Context(), request)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusCreated, created)
+	}
+}
+
+func getPaymentHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		paymentId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid payment id"))
+			return
+		}
+
+		found, err := deps.FarmService.GetPayment(r.Context(), uint(paymentId), farmerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, found)
+	}
+}
+
+func refundPaymentHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		paymentId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid payment id"))
+			return
+		}
+
+		refunded, err := deps.FarmService.RefundPayment(r.Context(), uint(paymentId), farmerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, refunded)
+	}
+}
+
+// paymentWebhookHandler receives the provider's notifications. It is not
+// behind ValidateUser, the signature authenticates the provider.
+func paymentWebhookHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBytes))
+		if err != nil {
+			fail(w, r, api.BadRequest("request body could not be read"))
+			return
+		}
+
+		err = deps.FarmService.HandlePaymentWebhook(r.Context(), payload, r.Header.Get(paymentSignatureHeader))
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, api.Message{Msg: "ok"})
+	}
+}
+
+// fakePayHandler stands in for the renter paying an order on the fake
+// provider's checkout page. Only the farmer the order is for can pay it. The
+// webhook the fake signs goes through the same verification as a real
+// provider's.
+func fakePayHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+		orderId := mux.Vars(r)["order_id"]
+
+		payer, err := deps.FarmService.GetOrderPayer(r.Context(), orderId)
+		if err == nil && payer != farmerId {
+			err = ErrPaymentNotFound
+		}
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		payload, signature, err := deps.FakePayments.Pay(orderId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		err = deps.FarmService.HandlePaymentWebhook(r.Context(), payload, signature)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, api.Message{Msg: "payment authorized"})
+	}
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/domain"
+	"FarmEasy/ledger"
+	"FarmEasy/payment"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *ServiceTestSuite) TestFarmService_PaymentFlow() {
+	t := s.T()
+	ctx := context.TODO()
+
+	fake := payment.NewFake("secret")
+	service := NewFarmService(s.repo, fake)
+	service.(*FarmService).notifier = &recordingNotifier{}
+	invoice := domain.Invoice{Id: 7, Number: "FE/2026-27/000042", FarmerId: 9, OwnerId: 20, TaxableValue: domain.Rupees(600), Amount: domain.Rupees(708), Status: domain.InvoiceUnpaid}
+
+	s.repo.On("GetInvoice", ctx, uint(7)).Return(invoice, nil).Once()
+	s.repo.On("GetOpenPayment", ctx, uint(7)).Return(domain.Payment{}, sql.ErrNoRows).Once()
+	s.repo.On("AddPayment", ctx, mock.AnythingOfType("*domain.Payment")).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.Payment).Id = 3
+		args.Get(1).(*domain.Payment).Status = domain.PaymentCreated
+	}).Return(nil).Once()
+
+	created, err := service.CreatePayment(ctx, domain.NewPaymentRequest{InvoiceId: 7, FarmerId: 9})
+	require.NoError(t, err)
+	assert.Equal(t, "fake", created.Provider)
+	assert.Equal(t, domain.Rupees(708), created.Amount)
+
+	payload, signature, err := fake.Pay(created.ProviderOrderId)
+	require.NoError(t, err)
+	var event payment.Event
+	require.NoError(t, json.Unmarshal(payload, &event))
+
+	t.Run("rejects a forged webhook", func(t *testing.T) {
+		err := service.HandlePaymentWebhook(ctx, payload, fake.Sign([]byte("{}")))
+		assert.Equal(t, payment.ErrInvalidSignature, err)
+	})
+
+	t.Run("refuses the webhook when the capture is not recorded", func(t *testing.T) {
+		s.repo.On("GetPaymentByOrder", ctx, "fake", created.ProviderOrderId).Return(created, nil).Once()
+		s.repo.On("GetInvoice", ctx, uint(7)).Return(invoice, nil).Once()
+		s.repo.On("CapturePayment", ctx, uint(3), event.PaymentId, mock.Anything).Return(errors.New("connection reset")).Once()
+
+		err := service.HandlePaymentWebhook(ctx, payload, signature)
+		assert.Error(t, err)
+	})
+
+	t.Run("captures an authorized payment", func(t *testing.T) {
+		s.repo.On("GetPaymentByOrder", ctx, "fake", created.ProviderOrderId).Return(created, nil).Once()
+		s.repo.On("GetInvoice", ctx, uint(7)).Return(invoice, nil).Once()
+		s.repo.On("CapturePayment", ctx, uint(3), event.PaymentId, mock.MatchedBy(func(txns []domain.LedgerTransaction) bool {
+			// the platform keeps its commission on the taxable value
+			return len(txns) == 2 && len(txns[0].Entries) == 3 && txns[0].Entries[2].Account == ledger.AccountCommission && txns[0].Entries[2].Amount == domain.Rupees(-60)
+		})).Return(nil).Once()
+
+		err := service.HandlePaymentWebhook(ctx, payload, signature)
+		assert.NoError(t, err)
+	})
+
+	captured := created
+	captured.Status = domain.PaymentCaptured
+	captured.ProviderPaymentId = event.PaymentId
+
+	t.Run("ignores a redelivered webhook", func(t *testing.T) {
+		s.repo.On("GetPaymentByOrder", ctx, "fake", created.ProviderOrderId).Return(captured, nil).Once()
+
+		err := service.HandlePaymentWebhook(ctx, payload, signature)
+		assert.NoError(t, err)
+	})
+
+	invoice.Status = domain.InvoicePaid
+
+	t.Run("the renter cannot refund", func(t *testing.T) {
+		s.repo.On("GetPayment", ctx, uint(3)).Return(captured, nil).Once()
+		s.repo.On("GetInvoice", ctx, uint(7)).Return(invoice, nil).Once()
+
+		_, err := service.RefundPayment(ctx, 3, 9)
+		assert.Equal(t, ErrPaymentNotFound, err)
+	})
+
+	var refundId string
+
+	t.Run("when the refund cannot be recorded", func(t *testing.T) {
+		s.repo.On("GetPayment", ctx, uint(3)).Return(captured, nil).Once()
+		s.repo.On("GetInvoice", ctx, uint(7)).Return(invoice, nil).Once()
+		s.repo.On("RefundPayment", ctx, uint(3), mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+			refundId = args.String(2)
+		}).Return(errors.New("connection reset")).Once()
+
+		_, err := service.RefundPayment(ctx, 3, 20)
+		assert.Error(t, err)
+		assert.NotEmpty(t, refundId)
+	})
+
+	t.Run("the owner refunds", func(t *testing.T) {
+		s.repo.On("GetPayment", ctx, uint(3)).Return(captured, nil).Once()
+		s.repo.On("GetInvoice", ctx, uint(7)).Return(invoice, nil).Once()
+		s.repo.On("RefundPayment", ctx, uint(3), mock.AnythingOfType("string")).Return(nil).Once()
+
+		// the retry gets the refund already made at the provider, which
+		// would refuse to refund the payment a second time
+		refunded, err := service.RefundPayment(ctx, 3, 20)
+		require.NoError(t, err)
+		assert.Equal(t, domain.PaymentRefunded, refunded.Status)
+		assert.Equal(t, refundId, refunded.ProviderRefundId)
+	})
+}
+
+func (s *ServiceTestSuite) TestFarmService_CreatePayment() {
+	t := s.T()
+	ctx := context.TODO()
+
+	invoice := domain.Invoice{Id: 7, FarmerId: 9, OwnerId: 20, Amount: domain.Rupees(708), Status: domain.InvoiceUnpaid}
+
+	t.Run("when the farmer is the owner", func(t *testing.T) {
+		s.repo.On("GetInvoice", ctx, uint(7)).Return(invoice, nil).Once()
+		_, err := s.service.CreatePayment(ctx, domain.NewPaymentRequest{InvoiceId: 7, FarmerId: 20})
+		assert.Equal(t, ErrInvoiceNotFound, err)
+	})
+
+	t.Run("when a payment is already open", func(t *testing.T) {
+		open := domain.Payment{Id: 3, InvoiceId: 7, Status: domain.PaymentCreated}
+		s.repo.On("GetInvoice", ctx, uint(7)).Return(invoice, nil).Once()
+		s.repo.On("GetOpenPayment", ctx, uint(7)).Return(open, nil).Once()
+		got, err := s.service.CreatePayment(ctx, domain.NewPaymentRequest{InvoiceId: 7, FarmerId: 9})
+		assert.NoError(t, err)
+		assert.Equal(t, open, got)
+	})
+
+	t.Run("when the invoice is paid", func(t *testing.T) {
+		paid := invoice
+		paid.Status = domain.InvoicePaid
+		s.repo.On("GetInvoice", ctx, uint(7)).Return(paid, nil).Once()
+		_, err := s.service.CreatePayment(ctx, domain.NewPaymentRequest{InvoiceId: 7, FarmerId: 9})
+		assert.Equal(t, ErrInvoiceNotPayable, err)
+	})
+}
+
+func (s *HandlerTestSuite) Test_paymentWebhookHandler() {
+	t := s.T()
+
+	deps := dependencies{
+		FarmService: s.service,
+	}
+
+	t.Run("when the signature is invalid", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/payments/webhook", bytes.NewBufferString(`{"type":"payment.authorized"}`))
+		r.Header.Set(paymentSignatureHeader, "bad")
+		w := httptest.NewRecorder()
+		s.service.On("HandlePaymentWebhook", r.Context(), []byte(`{"type":"payment.authorized"}`), "bad").Return(payment.ErrInvalidSignature).Once()
+
+		paymentWebhookHandler(deps).ServeHTTP(w, r)
+		exp, _ := json.Marshal(api.ErrorResponse{Error: *api.Unauthorized(payment.ErrInvalidSignature.Error())})
+		assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode)
+		assert.Equal(t, string(exp), w.Body.String())
+	})
+
+	t.Run("when the webhook is applied", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/payments/webhook", bytes.NewBufferString(`{}`))
+		r.Header.Set(paymentSignatureHeader, "good")
+		w := httptest.NewRecorder()
+		s.service.On("HandlePaymentWebhook", r.Context(), []byte(`{}`), "good").Return(nil).Once()
+
+		paymentWebhookHandler(deps).ServeHTTP(w, r)
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	})
+}
+
+func (s *HandlerTestSuite) Test_createPaymentHandler() {
+	t := s.T()
+
+	deps := dependencies{
+		FarmService: s.service,
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/payments", bytes.NewBufferString(`{"invoice_id": 7, "farmer_id": 1}`))
+	r = r.WithContext(context.WithValue(r.Context(), "token", uint(9)))
+	w := httptest.NewRecorder()
+	respBody := domain.Payment{Id: 3, InvoiceId: 7, FarmerId: 9, Provider: "fake", ProviderOrderId: "order_fake_1", Amount: domain.Rupees(708), Status: domain.PaymentCreated}
+	s.service.On("CreatePayment", r.Context(), domain.NewPaymentRequest{InvoiceId: 7, FarmerId: 9}).Return(respBody, nil).Once()
+
+	createPaymentHandler(deps).ServeHTTP(w, r)
+	exp, _ := json.Marshal(respBody)
+	assert.Equal(t, http.StatusCreated, w.Result().StatusCode)
+	assert.Equal(t, string(exp), w.Body.String())
+}
+
+func (s *ServiceTestSuite) TestFarmService_GetOrderPayer() {
+	t := s.T()
+	ctx := context.TODO()
+
+	t.Run("when the order pays an invoice", func(t *testing.T) {
+		s.repo.On("GetPaymentByOrder", ctx, "fake", "order_fake_1").Return(domain.Payment{Id: 3, FarmerId: 9}, nil).Once()
+		payer, err := s.service.GetOrderPayer(ctx, "order_fake_1")
+		assert.NoError(t, err)
+		assert.Equal(t, uint(9), payer)
+	})
+
+	t.Run("when the order tops up a wallet", func(t *testing.T) {
+		s.repo.On("GetPaymentByOrder", ctx, "fake", "order_fake_2").Return(domain.Payment{}, sql.ErrNoRows).Once()
+		s.repo.On("GetWalletTopupByOrder", ctx, "fake", "order_fake_2").Return(domain.WalletTopup{Id: 4, FarmerId: 9}, nil).Once()
+		payer, err := s.service.GetOrderPayer(ctx, "order_fake_2")
+		assert.NoError(t, err)
+		assert.Equal(t, uint(9), payer)
+	})
+
+	t.Run("when the order is unknown", func(t *testing.T) {
+		s.repo.On("GetPaymentByOrder", ctx, "fake", "order_fake_3").Return(domain.Payment{}, sql.ErrNoRows).Once()
+		s.repo.On("GetWalletTopupByOrder", ctx, "fake", "order_fake_3").Return(domain.WalletTopup{}, sql.ErrNoRows).Once()
+		_, err := s.service.GetOrderPayer(ctx, "order_fake_3")
+		assert.Equal(t, ErrPaymentNotFound, err)
+	})
+}
+
+func (s *HandlerTestSuite) Test_fakePayHandler() {
+	t := s.T()
+
+	fake := payment.NewFake("secret")
+	orderId, err := fake.CreateOrder(context.TODO(), domain.Rupees(708), "FE/2026-27/000042")
+	require.NoError(t, err)
+
+	deps := dependencies{
+		FarmService:  s.service,
+		FakePayments: fake,
+	}
+	newRequest := func(farmerId uint) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/payments/fake/orders/"+orderId+"/pay", nil)
+		r = mux.SetURLVars(r, map[string]string{"order_id": orderId})
+		return r.WithContext(context.WithValue(r.Context(), "token", farmerId))
+	}
+
+	t.Run("when the order is another farmer's", func(t *testing.T) {
+		r := newRequest(20)
+		w := httptest.NewRecorder()
+		s.service.On("GetOrderPayer", r.Context(), orderId).Return(uint(9), nil).Once()
+
+		fakePayHandler(deps).ServeHTTP(w, r)
+		assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+	})
+
+	t.Run("when the farmer pays their order", func(t *testing.T) {
+		r := newRequest(9)
+		w := httptest.NewRecorder()
+		s.service.On("GetOrderPayer", r.Context(), orderId).Return(uint(9), nil).Once()
+		s.service.On("HandlePaymentWebhook", r.Context(), mock.Anything, mock.AnythingOfType("string")).Return(nil).Once()
+
+		fakePayHandler(deps).ServeHTTP(w, r)
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	})
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+func (s *FarmService) AddPromoCode(ctx context.Context, request domain.NewPromoCodeRequest) (promo domain.PromoCode, err error) {
+
+	promo = domain.PromoCode{
+		Code:           domain.NormalizePromoCode(request.Code),
+		Description:    request.Description,
+		Kind:           request.Kind,
+		PercentBp:      request.PercentBp,
+		Amount:         request.Amount,
+		ValidFrom:      request.ValidFrom,
+		ValidUntil:     request.ValidUntil,
+		MaxRedemptions: request.MaxRedemptions,
+		MaxPerFarmer:   request.MaxPerFarmer,
+		Categories:     request.Categories,
+		OwnerIds:       request.OwnerIds,
+	}
+	if promo.Categories == nil {
+		promo.Categories = []string{}
+	}
+	if promo.OwnerIds == nil {
+		promo.OwnerIds = []uint{}
+	}
+	// a percent code has no amount, but is still priced in a currency
+	if promo.Kind == domain.PromoPercent {
+		promo.Amount = domain.NewMoney(0, domain.DefaultCurrency)
+	}
+
+	err = s.store.AddPromoCode(ctx, &promo)
+	return
+}
+
+func (s *FarmService) GetPromoCodes(ctx context.Context) (promos []domain.PromoCode, err error) {
+
+	promos, err = s.store.GetPromoCodes(ctx)
+	if err != nil {
+		return
+	}
+	if promos == nil {
+		promos = []domain.PromoCode{}
+	}
+	return
+}
+
+// DeactivatePromoCode ends a campaign early and returns the code as it now
+// stands.
+func (s *FarmService) DeactivatePromoCode(ctx context.Context, promoId uint) (promo domain.PromoCode, err error) {
+
+	err = s.store.DeactivatePromoCode(ctx, promoId)
+	if err == sql.ErrNoRows {
+		err = ErrPromoNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	promo, err = s.store.GetPromoCode(ctx, promoId)
+	return
+}
+
+func (s *FarmService) GetPromoRedemptions(ctx context.Context, promoId uint) (redemptions []domain.PromoRedemption, err error) {
+
+	_, err = s.store.GetPromoCode(ctx, promoId)
+	if err == sql.ErrNoRows {
+		err = ErrPromoNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	redemptions, err = s.store.GetPromoRedemptions(ctx, promoId)
+	if err != nil {
+		return
+	}
+	if redemptions == nil {
+		redemptions = []domain.PromoRedemption{}
+	}
+	return
+}
+
+func addPromoCodeHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var request domain.NewPromoCodeRequest
+
+		if err := decode(r, &request); err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		promo, err := deps.FarmService.AddPromoCode(r.Context(), request)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusCreated, promo)
+	}
+}
+
+func getPromoCodesHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		promos, err := deps.FarmService.GetPromoCodes(r.Context())
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, promos)
+	}
+}
+
+func deactivatePromoCodeHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		promoId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid promo code id"))
+			return
+		}
+
+		promo, err := deps.FarmService.DeactivatePromoCode(r.Context(), uint(promoId))
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, promo)
+	}
+}
+
+func getPromoRedemptionsHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		promoId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid promo code id"))
+			return
+		}
+
+		redemptions, err := deps.FarmService.GetPromoRedemptions(r.Context(), uint(promoId))
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, redemptions)
+	}
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"FarmEasy/config"
+	"FarmEasy/domain"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *ServiceTestSuite) TestFarmService_DeactivatePromoCode() {
+	t := s.T()
+	ctx := context.TODO()
+
+	t.Run("when the code does not exist", func(t *testing.T) {
+		s.repo.On("DeactivatePromoCode", ctx, uint(4)).Return(sql.ErrNoRows).Once()
+		_, err := s.service.DeactivatePromoCode(ctx, 4)
+		assert.Equal(t, ErrPromoNotFound, err)
+	})
+
+	t.Run("when the code is deactivated", func(t *testing.T) {
+		promo := domain.PromoCode{Id: 4, Code: "MONSOON10", Kind: domain.PromoPercent, PercentBp: 1000, Active: false, Redemptions: 12}
+		s.repo.On("DeactivatePromoCode", ctx, uint(4)).Return(nil).Once()
+		s.repo.On("GetPromoCode", ctx, uint(4)).Return(promo, nil).Once()
+
+		got, err := s.service.DeactivatePromoCode(ctx, 4)
+		require.NoError(t, err)
+		assert.Equal(t, promo, got)
+	})
+}
+
+func (s *ServiceTestSuite) TestFarmService_AddPromoCode() {
+	t := s.T()
+	ctx := context.TODO()
+
+	from := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	s.repo.On("AddPromoCode", ctx, &domain.PromoCode{
+		Code:       "MONSOON10",
+		Kind:       domain.PromoPercent,
+		PercentBp:  1000,
+		Amount:     domain.Rupees(0),
+		ValidFrom:  from,
+		ValidUntil: from.AddDate(0, 4, 0),
+		Categories: []string{"tractor"},
+		OwnerIds:   []uint{},
+	}).Return(nil).Once()
+
+	_, err := s.service.AddPromoCode(ctx, domain.NewPromoCodeRequest{Code: " monsoon10", Kind: domain.PromoPercent, PercentBp: 1000, ValidFrom: from, ValidUntil: from.AddDate(0, 4, 0), Categories: []string{"tractor"}})
+	assert.NoError(t, err)
+}
+
+func (s *HandlerTestSuite) Test_addPromoCodeHandler() {
+	t := s.T()
+
+	deps := dependencies{
+		FarmService: s.service,
+	}
+
+	window := `"valid_from": "2026-06-01T00:00:00Z", "valid_until": "2026-09-01T00:00:00Z"`
+	tests := []struct {
+		name    string
+		body    string
+		field   string
+		message string
+	}{
+		{"without a code", `{"kind": "percent", "percent_bp": 1000, ` + window + `}`, "code", "is required"},
+		{"with an unknown kind", `{"code": "X", "kind": "bogo", ` + window + `}`, "kind", "must be one of [percent flat]"},
+		{"with too large a percentage", `{"code": "X", "kind": "percent", "percent_bp": 10001, ` + window + `}`, "percent_bp", "must be at most 10000"},
+		{"without a percentage", `{"code": "X", "kind": "percent", ` + window + `}`, "percent_bp", "is required"},
+		{"with a flat amount of zero", `{"code": "X", "kind": "flat", "amount": 0, ` + window + `}`, "amount", domain.ErrInvalidAmount.Error()},
+		{"with an empty window", `{"code": "X", "kind": "flat", "amount": 100, "valid_from": "2026-06-01T00:00:00Z", "valid_until": "2026-06-01T00:00:00Z"}`, "valid_until", "must be after valid_from"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/admin/promos", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			addPromoCodeHandler(deps).ServeHTTP(w, r)
+			exp, _ := json.Marshal(invalidField(tt.field, tt.message))
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+			assert.Equal(t, string(exp), w.Body.String())
+		})
+	}
+}
+
+func TestValidateAdmin(t *testing.T) {
+	t.Setenv("ADMIN_FARMER_IDS", "1, 7")
+	config.Load()
+
+	handler := ValidateAdmin(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	for farmerId, want := range map[uint]int{7: http.StatusNoContent, 9: http.StatusForbidden} {
+		token, err := generateJWT(farmerId)
+		require.NoError(t, err)
+		r := httptest.NewRequest(http.MethodGet, "/admin/promos", nil)
+		r.Header.Set("Authorization", token)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, want, w.Result().StatusCode)
+	}
+}
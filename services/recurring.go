@@ -0,0 +1,179 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/db"
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	dateLayout     = "2006-01-02"
+	maxOccurrences = 52
+)
+
+// expandRecurrence returns the dates of every occurrence of the rule starting on
+// startDate, the start date being the first occurrence.
+func expandRecurrence(startDate string, rule domain.RecurrenceRule) (dates []string, err error) {
+	start, err := time.Parse(dateLayout, startDate)
+	if err != nil {
+		err = ErrInvalidRecurrence
+		return
+	}
+
+	var step int
+	switch rule.Frequency {
+	case domain.RecurrenceDaily:
+		step = 1
+	case domain.RecurrenceWeekly:
+		step = 7
+	default:
+		err = ErrInvalidRecurrence
+		return
+	}
+	if rule.Interval > 0 {
+		step *= int(rule.Interval)
+	}
+
+	if (rule.Until == "") == (rule.Count == 0) {
+		err = ErrInvalidRecurrence
+		return
+	}
+
+	if rule.Count > 0 {
+		if rule.Count > maxOccurrences {
+			err = ErrTooManyOccurrences
+			return
+		}
+		for i := 0; i < int(rule.Count); i++ {
+			dates = append(dates, start.AddDate(0, 0, i*step).Format(dateLayout))
+		}
+		return
+	}
+
+	until, err := time.Parse(dateLayout, rule.Until)
+	if err != nil || until.Before(start) {
+		err = ErrInvalidRecurrence
+		return
+	}
+	for day := start; !day.After(until); day = day.AddDate(0, 0, step) {
+		if len(dates) == maxOccurrences {
+			err = ErrTooManyOccurrences
+			return nil, err
+		}
+		dates = append(dates, day.Format(dateLayout))
+	}
+	return
+}
+
+// BookRecurring books every occurrence of the rule that is free and reports the
+// occurrences that clash with existing bookings. The series is only kept when
+// at least one occurrence is booked.
+func (s *FarmService) BookRecurring(ctx context.Context, request domain.NewRecurringBookingRequest) (rsp domain.RecurringBookingResponse, err error) {
+
+	if request.StartDate < time.Now().Format(dateLayout) {
+		err = ErrSeriesStartsInPast
+		return
+	}
+
+	dates, err := expandRecurrence(request.StartDate, request.Rule)
+	if err != nil {
+		return
+	}
+
+	series := domain.BookingSeries{
+		FarmerId:  request.FarmerId,
+		MachineId: request.MachineId,
+		StartDate: request.StartDate,
+		Frequency: request.Rule.Frequency,
+		Interval:  request.Rule.Interval,
+		Until:     request.Rule.Until,
+		Count:     request.Rule.Count,
+		Slots:     request.Slots,
+	}
+	if series.Interval == 0 {
+		series.Interval = 1
+	}
+	rsp, err = s.store.BookSeries(ctx, &series, dates)
+	if err == db.ErrSlotNotEmpty {
+		err = ErrSeriesUnavailable
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	for _, booking := range rsp.Booked {
+		s.availabilityChanged(ctx, request.MachineId, booking.Date, domain.AvailabilityBooked)
+	}
+	return
+}
+
+func (s *FarmService) CancelBookingSeries(ctx context.Context, seriesId uint, farmerId uint) (cancelled []domain.CancelledBooking, err error) {
+
+	cancelled, err = s.store.CancelBookingSeries(ctx, seriesId, farmerId)
+	if err == sql.ErrNoRows {
+		err = ErrSeriesNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	for _, booking := range cancelled {
+		s.notifyDepositSettled(ctx, booking.Deposit)
+		s.notifyWalletRefund(ctx, booking.BookingId, booking.Refund)
+		s.processWaitlist(ctx, booking.MachineId, booking.Date)
+		s.availabilityChanged(ctx, booking.MachineId, booking.Date, domain.AvailabilityCancelled)
+	}
+	return
+}
+
+func recurringBookingHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var booking domain.NewRecurringBookingRequest
+
+		if err := decode(r, &booking); err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		booking.FarmerId = r.Context().Value("token").(uint)
+
+		series, err := deps.FarmService.BookRecurring(r.Context(), booking)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusCreated, series)
+	}
+}
+
+func cancelBookingSeriesHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		seriesId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid series id"))
+			return
+		}
+
+		cancelled, err := deps.FarmService.CancelBookingSeries(r.Context(), uint(seriesId), farmerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, cancelled)
+	}
+}
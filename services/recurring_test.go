@@ -0,0 +1,177 @@
+package services
+
+import (
+	"FarmEasy/db"
+	"FarmEasy/domain"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_expandRecurrence(t *testing.T) {
+	tests := []struct {
+		name      string
+		startDate string
+		rule      domain.RecurrenceRule
+		want      []string
+		wantErr   error
+	}{
+		{
+			name:      "weekly with count",
+			startDate: "2026-11-07",
+			rule:      domain.RecurrenceRule{Frequency: domain.RecurrenceWeekly, Count: 3},
+			want:      []string{"2026-11-07", "2026-11-14", "2026-11-21"},
+		},
+		{
+			name:      "every 3 days until a date",
+			startDate: "2026-11-01",
+			rule:      domain.RecurrenceRule{Frequency: domain.RecurrenceDaily, Interval: 3, Until: "2026-11-10"},
+			want:      []string{"2026-11-01", "2026-11-04", "2026-11-07", "2026-11-10"},
+		},
+		{
+			name:      "both until and count",
+			startDate: "2026-11-01",
+			rule:      domain.RecurrenceRule{Frequency: domain.RecurrenceDaily, Until: "2026-11-10", Count: 2},
+			wantErr:   ErrInvalidRecurrence,
+		},
+		{
+			name:      "unknown frequency",
+			startDate: "2026-11-01",
+			rule:      domain.RecurrenceRule{Frequency: "monthly", Count: 2},
+			wantErr:   ErrInvalidRecurrence,
+		},
+		{
+			name:      "until before start",
+			startDate: "2026-11-01",
+			rule:      domain.RecurrenceRule{Frequency: domain.RecurrenceWeekly, Until: "2026-10-01"},
+			wantErr:   ErrInvalidRecurrence,
+		},
+		{
+			name:      "too many occurrences",
+			startDate: "2026-01-01",
+			rule:      domain.RecurrenceRule{Frequency: domain.RecurrenceDaily, Until: "2026-12-31"},
+			wantErr:   ErrTooManyOccurrences,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandRecurrence(tt.startDate, tt.rule)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func (s *ServiceTestSuite) TestFarmService_BookRecurring() {
+	t := s.T()
+
+	start := time.Now().AddDate(0, 0, 1).Format(dateLayout)
+	request := domain.NewRecurringBookingRequest{
+		MachineId: 1,
+		StartDate: start,
+		Slots:     []uint{7, 8},
+		Rule:      domain.RecurrenceRule{Frequency: domain.RecurrenceWeekly, Count: 2},
+		FarmerId:  2,
+	}
+	dates, err := expandRecurrence(start, request.Rule)
+	require.NoError(t, err)
+
+	t.Run("books the free occurrences", func(t *testing.T) {
+		booked := domain.RecurringBookingResponse{
+			SeriesId:  5,
+			Booked:    []domain.NewBookingResponse{{BookingId: 10, Date: dates[0]}},
+			Conflicts: []domain.OccurrenceConflict{{Date: dates[1], ConflictingSlots: []uint{8}}},
+		}
+		s.repo.On("BookSeries", context.TODO(), mock.MatchedBy(func(series *domain.BookingSeries) bool {
+			return series.MachineId == 1 && series.Interval == 1
+		}), dates).Return(booked, nil).Once()
+
+		got, err := s.service.BookRecurring(context.TODO(), request)
+		require.NoError(t, err)
+		assert.Equal(t, booked, got)
+	})
+
+	t.Run("when every occurrence clashes", func(t *testing.T) {
+		s.repo.On("BookSeries", context.TODO(), mock.Anything, dates).Return(domain.RecurringBookingResponse{}, db.ErrSlotNotEmpty).Once()
+
+		_, err := s.service.BookRecurring(context.TODO(), request)
+		assert.Equal(t, ErrSeriesUnavailable, err)
+	})
+
+	t.Run("when the series starts in the past", func(t *testing.T) {
+		past := request
+		past.StartDate = time.Now().AddDate(0, 0, -1).Format(dateLayout)
+
+		_, err := s.service.BookRecurring(context.TODO(), past)
+		assert.Equal(t, ErrSeriesStartsInPast, err)
+	})
+}
+
+func (s *ServiceTestSuite) TestFarmService_CancelBookingSeries() {
+	t := s.T()
+	ctx := context.TODO()
+
+	notifier := &recordingNotifier{}
+	s.service.(*FarmService).notifier = notifier
+
+	// the store refunded the wallet-paid occurrence and released the deposit
+	// of the other with the cancellation
+	cancelled := []domain.CancelledBooking{
+		{BookingId: 10, MachineId: 1, Date: "2026-11-07", Slots: []uint{7}, Refund: &domain.Refund{PaymentId: 3, FarmerId: 2, Amount: domain.Rupees(300)}},
+		{BookingId: 11, MachineId: 1, Date: "2026-11-14", Slots: []uint{7}, Deposit: &domain.Deposit{Id: 6, BookingId: 11, FarmerId: 2, Amount: domain.Rupees(2000), Status: domain.DepositReleased}},
+	}
+	s.repo.On("CancelBookingSeries", ctx, uint(5), uint(2)).Return(cancelled, nil).Once()
+	s.repo.On("GetWaitingEntries", ctx, uint(1), "2026-11-07").Return(nil, nil).Once()
+	s.repo.On("GetWaitingEntries", ctx, uint(1), "2026-11-14").Return(nil, nil).Once()
+
+	got, err := s.service.CancelBookingSeries(ctx, 5, 2)
+	require.NoError(t, err)
+	assert.Equal(t, cancelled, got)
+	assert.Equal(t, []string{
+		"300.00 INR for booking 10 was refunded to your wallet",
+		"Your deposit of 2000.00 INR for booking 11 was released",
+	}, notifier.messages)
+	s.repo.AssertExpectations(t)
+}
+
+func (s *HandlerTestSuite) Test_cancelBookingHandler() {
+	t := s.T()
+
+	deps := dependencies{
+		FarmService: s.service,
+	}
+
+	t.Run("when the booking is cancelled", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodDelete, "/bookings/3", nil)
+		r = r.WithContext(context.WithValue(r.Context(), "token", uint(1)))
+		r = mux.SetURLVars(r, map[string]string{"id": "3"})
+		w := httptest.NewRecorder()
+		respBody := domain.CancelledBooking{BookingId: 3, MachineId: 1, Date: "2026-11-07", Slots: []uint{7}}
+		s.service.On("CancelBooking", r.Context(), uint(3), uint(1)).Return(respBody, nil).Once()
+
+		cancelBookingHandler(deps).ServeHTTP(w, r)
+		exp, _ := json.Marshal(respBody)
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+		assert.Equal(t, string(exp), w.Body.String())
+	})
+
+	t.Run("when the booking cannot be cancelled", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodDelete, "/bookings/3", nil)
+		r = r.WithContext(context.WithValue(r.Context(), "token", uint(1)))
+		r = mux.SetURLVars(r, map[string]string{"id": "3"})
+		w := httptest.NewRecorder()
+		s.service.On("CancelBooking", r.Context(), uint(3), uint(1)).Return(domain.CancelledBooking{}, ErrBookingNotFound).Once()
+
+		cancelBookingHandler(deps).ServeHTTP(w, r)
+		assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+	})
+}
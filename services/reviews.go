@@ -0,0 +1,330 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// ReviewBooking lets either party of a completed booking rate the other once.
+// A renter's review also rates the machine.
+func (s *FarmService) ReviewBooking(ctx context.Context, request domain.NewReviewRequest) (review domain.Review, err error) {
+
+	parties, err := s.store.GetBookingParties(ctx, request.BookingId)
+	if err == sql.ErrNoRows {
+		err = ErrReviewBookingNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	role := parties.Role(request.ReviewerId)
+	if role == "" {
+		err = ErrReviewBookingNotFound
+		return
+	}
+	if !parties.Completed(time.Now().Format("2006-01-02")) {
+		err = ErrBookingNotCompleted
+		return
+	}
+
+	review = domain.Review{
+		BookingId:  parties.BookingId,
+		MachineId:  parties.MachineId,
+		ReviewerId: request.ReviewerId,
+		RevieweeId: parties.OwnerId,
+		Role:       role,
+		Rating:     request.Rating,
+		Comment:    request.Comment,
+	}
+	if role == "owner" {
+		review.RevieweeId = parties.FarmerId
+	}
+
+	err = s.store.AddReview(ctx, &review)
+	if err == sql.ErrNoRows {
+		err = ErrReviewExists
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	s.notifier.Notify(ctx, review.RevieweeId, fmt.Sprintf("You were rated %d out of 5 for booking %d", review.Rating, review.BookingId))
+	return
+}
+
+func (s *FarmService) GetMachineReviews(ctx context.Context, machineId uint) (reviews []domain.Review, err error) {
+
+	reviews, err = s.store.GetMachineReviews(ctx, machineId)
+	if err != nil {
+		return
+	}
+	if reviews == nil {
+		reviews = []domain.Review{}
+	}
+	return
+}
+
+// GetFarmer returns a farmer's profile with their ratings. Contact details are
+// only shown to the farmer themselves.
+func (s *FarmService) GetFarmer(ctx context.Context, farmerId uint, viewerId uint) (farmer domain.FarmerResponse, err error) {
+
+	farmer, err = s.store.GetFarmer(ctx, farmerId)
+	if err == sql.ErrNoRows {
+		err = ErrFarmerNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	if farmerId != viewerId {
+		farmer.Email = ""
+		farmer.Phone = ""
+		farmer.Address = ""
+	}
+	return
+}
+
+func (s *FarmService) GetFarmerReviews(ctx context.Context, farmerId uint) (reviews []domain.Review, err error) {
+
+	reviews, err = s.store.GetFarmerReviews(ctx, farmerId)
+	if err != nil {
+		return
+	}
+	if reviews == nil {
+		reviews = []domain.Review{}
+	}
+	return
+}
+
+// ReportReview flags a review as abusive for the admins. Anyone but its
+// author can report a review, once.
+func (s *FarmService) ReportReview(ctx context.Context, reviewId uint, reporterId uint, request domain.ReviewReportRequest) (report domain.ReviewReport, err error) {
+
+	review, err := s.store.GetReview(ctx, reviewId)
+	if err == sql.ErrNoRows || (err == nil && review.Hidden) {
+		err = ErrReviewNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+	if review.ReviewerId == reporterId {
+		err = ErrOwnReview
+		return
+	}
+
+	report = domain.ReviewReport{
+		ReviewId:   reviewId,
+		ReporterId: reporterId,
+		Reason:     request.Reason,
+		Review:     review,
+	}
+	err = s.store.AddReviewReport(ctx, &report)
+	if err == sql.ErrNoRows {
+		err = ErrReviewAlreadyReported
+		return
+	}
+	return
+}
+
+// GetReviewReports returns the reports waiting for an admin.
+func (s *FarmService) GetReviewReports(ctx context.Context) (reports []domain.ReviewReport, err error) {
+
+	reports, err = s.store.GetReviewReports(ctx, domain.ReviewReportOpen)
+	if err != nil {
+		return
+	}
+	if reports == nil {
+		reports = []domain.ReviewReport{}
+	}
+	return
+}
+
+// ResolveReviewReport lets an admin dismiss a report, or uphold it and remove
+// the review from listings and ratings.
+func (s *FarmService) ResolveReviewReport(ctx context.Context, reportId uint, adminId uint, request domain.ReviewReportDecisionRequest) (report domain.ReviewReport, err error) {
+
+	status := domain.ReviewReportDismissed
+	if request.Remove {
+		status = domain.ReviewReportUpheld
+	}
+
+	report, err = s.store.ResolveReviewReport(ctx, reportId, status, request.Note, adminId)
+	if err == sql.ErrNoRows {
+		err = ErrReviewReportNotOpen
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	if status == domain.ReviewReportUpheld {
+		s.notifier.Notify(ctx, report.Review.ReviewerId, fmt.Sprintf("Your review of booking %d was removed after it was reported", report.Review.BookingId))
+	}
+	return
+}
+
+func reviewBookingHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		bookingId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid booking id"))
+			return
+		}
+
+		var request domain.NewReviewRequest
+		if err := decode(r, &request); err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		request.BookingId = uint(bookingId)
+		request.ReviewerId = r.Context().Value("token").(uint)
+
+		review, err := deps.FarmService.ReviewBooking(r.Context(), request)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusCreated, review)
+	}
+}
+
+func getMachineReviewsHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		machineId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid machine id"))
+			return
+		}
+
+		reviews, err := deps.FarmService.GetMachineReviews(r.Context(), uint(machineId))
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, reviews)
+	}
+}
+
+func getFarmerHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		viewerId := r.Context().Value("token").(uint)
+
+		farmerId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid farmer id"))
+			return
+		}
+
+		farmer, err := deps.FarmService.GetFarmer(r.Context(), uint(farmerId), viewerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, farmer)
+	}
+}
+
+func getFarmerReviewsHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid farmer id"))
+			return
+		}
+
+		reviews, err := deps.FarmService.GetFarmerReviews(r.Context(), uint(farmerId))
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, reviews)
+	}
+}
+
+func reportReviewHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		reporterId := r.Context().Value("token").(uint)
+
+		reviewId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid review id"))
+			return
+		}
+
+		var request domain.ReviewReportRequest
+		if err := decode(r, &request); err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		report, err := deps.FarmService.ReportReview(r.Context(), uint(reviewId), reporterId, request)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusCreated, report)
+	}
+}
+
+func getReviewReportsHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		reports, err := deps.FarmService.GetReviewReports(r.Context())
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, reports)
+	}
+}
+
+func resolveReviewReportHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		adminId := r.Context().Value("token").(uint)
+
+		reportId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid report id"))
+			return
+		}
+
+		var request domain.ReviewReportDecisionRequest
+		if err := decode(r, &request); err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		report, err := deps.FarmService.ResolveReviewReport(r.Context(), uint(reportId), adminId, request)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, report)
+	}
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"FarmEasy/domain"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *ServiceTestSuite) TestFarmService_ReviewBooking() {
+	t := s.T()
+	ctx := context.TODO()
+
+	notifier := &recordingNotifier{}
+	s.service.(*FarmService).notifier = notifier
+
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	parties := domain.BookingParties{BookingId: 5, MachineId: 2, FarmerId: 9, OwnerId: 20, Status: domain.BookingConfirmed, EndDate: yesterday}
+
+	t.Run("when the farmer is not a party to the booking", func(t *testing.T) {
+		s.repo.On("GetBookingParties", ctx, uint(5)).Return(parties, nil).Once()
+		_, err := s.service.ReviewBooking(ctx, domain.NewReviewRequest{BookingId: 5, ReviewerId: 30, Rating: 5})
+		assert.Equal(t, ErrReviewBookingNotFound, err)
+	})
+
+	t.Run("when the booking has not ended", func(t *testing.T) {
+		ongoing := parties
+		ongoing.EndDate = time.Now().Format("2006-01-02")
+		s.repo.On("GetBookingParties", ctx, uint(5)).Return(ongoing, nil).Once()
+		_, err := s.service.ReviewBooking(ctx, domain.NewReviewRequest{BookingId: 5, ReviewerId: 9, Rating: 5})
+		assert.Equal(t, ErrBookingNotCompleted, err)
+	})
+
+	t.Run("when the renter reviews the owner and the machine", func(t *testing.T) {
+		notifier.notified = nil
+		s.repo.On("GetBookingParties", ctx, uint(5)).Return(parties, nil).Once()
+		s.repo.On("AddReview", ctx, mock.MatchedBy(func(r *domain.Review) bool {
+			return r.MachineId == 2 && r.RevieweeId == 20 && r.Role == "renter"
+		})).Return(nil).Once()
+
+		got, err := s.service.ReviewBooking(ctx, domain.NewReviewRequest{BookingId: 5, ReviewerId: 9, Rating: 4, Comment: "ran well"})
+		require.NoError(t, err)
+		assert.Equal(t, uint(4), got.Rating)
+		assert.Equal(t, []uint{20}, notifier.notified)
+	})
+
+	t.Run("when the owner reviews the renter twice", func(t *testing.T) {
+		s.repo.On("GetBookingParties", ctx, uint(5)).Return(parties, nil).Once()
+		s.repo.On("AddReview", ctx, mock.MatchedBy(func(r *domain.Review) bool {
+			return r.RevieweeId == 9 && r.Role == "owner"
+		})).Return(sql.ErrNoRows).Once()
+
+		_, err := s.service.ReviewBooking(ctx, domain.NewReviewRequest{BookingId: 5, ReviewerId: 20, Rating: 5})
+		assert.Equal(t, ErrReviewExists, err)
+	})
+}
+
+func (s *ServiceTestSuite) TestFarmService_GetFarmer() {
+	t := s.T()
+	ctx := context.TODO()
+
+	farmer := domain.FarmerResponse{Id: 20, FirstName: "Asha", Email: "asha@example.com", Phone: "9876543210", Address: "Baramati", OwnerRating: domain.Rating{Average: 4.33, Count: 3}}
+
+	t.Run("when another farmer looks", func(t *testing.T) {
+		s.repo.On("GetFarmer", ctx, uint(20)).Return(farmer, nil).Once()
+		got, err := s.service.GetFarmer(ctx, 20, 9)
+		require.NoError(t, err)
+		assert.Equal(t, domain.FarmerResponse{Id: 20, FirstName: "Asha", OwnerRating: domain.Rating{Average: 4.33, Count: 3}}, got)
+	})
+
+	t.Run("when the farmer looks at themselves", func(t *testing.T) {
+		s.repo.On("GetFarmer", ctx, uint(20)).Return(farmer, nil).Once()
+		got, err := s.service.GetFarmer(ctx, 20, 20)
+		require.NoError(t, err)
+		assert.Equal(t, farmer, got)
+	})
+}
+
+func (s *ServiceTestSuite) TestFarmService_ReportReview() {
+	t := s.T()
+	ctx := context.TODO()
+
+	review := domain.Review{Id: 7, BookingId: 5, ReviewerId: 9, RevieweeId: 20, Role: "renter", Rating: 1}
+
+	t.Run("when the author reports their own review", func(t *testing.T) {
+		s.repo.On("GetReview", ctx, uint(7)).Return(review, nil).Once()
+		_, err := s.service.ReportReview(ctx, 7, 9, domain.ReviewReportRequest{Reason: "oops"})
+		assert.Equal(t, ErrOwnReview, err)
+	})
+
+	t.Run("when the review was already removed", func(t *testing.T) {
+		hidden := review
+		hidden.Hidden = true
+		s.repo.On("GetReview", ctx, uint(7)).Return(hidden, nil).Once()
+		_, err := s.service.ReportReview(ctx, 7, 20, domain.ReviewReportRequest{Reason: "slurs"})
+		assert.Equal(t, ErrReviewNotFound, err)
+	})
+
+	t.Run("when the reviewee reports it", func(t *testing.T) {
+		s.repo.On("GetReview", ctx, uint(7)).Return(review, nil).Once()
+		s.repo.On("AddReviewReport", ctx, mock.MatchedBy(func(r *domain.ReviewReport) bool {
+			return r.ReviewId == 7 && r.ReporterId == 20 && r.Reason == "slurs"
+		})).Return(nil).Once()
+
+		got, err := s.service.ReportReview(ctx, 7, 20, domain.ReviewReportRequest{Reason: "slurs"})
+		require.NoError(t, err)
+		assert.Equal(t, review, got.Review)
+	})
+}
+
+func (s *ServiceTestSuite) TestFarmService_ResolveReviewReport() {
+	t := s.T()
+	ctx := context.TODO()
+
+	notifier := &recordingNotifier{}
+	s.service.(*FarmService).notifier = notifier
+
+	t.Run("when the review is removed", func(t *testing.T) {
+		notifier.notified = nil
+		report := domain.ReviewReport{Id: 4, ReviewId: 7, Status: domain.ReviewReportUpheld, Review: domain.Review{Id: 7, BookingId: 5, ReviewerId: 9}}
+		s.repo.On("ResolveReviewReport", ctx, uint(4), domain.ReviewReportUpheld, "abusive", uint(1)).Return(report, nil).Once()
+
+		got, err := s.service.ResolveReviewReport(ctx, 4, 1, domain.ReviewReportDecisionRequest{Remove: true, Note: "abusive"})
+		require.NoError(t, err)
+		assert.Equal(t, report, got)
+		assert.Equal(t, []uint{9}, notifier.notified)
+	})
+
+	t.Run("when the report was already resolved", func(t *testing.T) {
+		s.repo.On("ResolveReviewReport", ctx, uint(4), domain.ReviewReportDismissed, "", uint(1)).Return(domain.ReviewReport{}, sql.ErrNoRows).Once()
+		_, err := s.service.ResolveReviewReport(ctx, 4, 1, domain.ReviewReportDecisionRequest{})
+		assert.Equal(t, ErrReviewReportNotOpen, err)
+	})
+}
+
+func (s *HandlerTestSuite) Test_reviewBookingHandler() {
+	t := s.T()
+
+	deps := dependencies{
+		FarmService: s.service,
+	}
+
+	t.Run("when the rating is out of range", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/bookings/5/reviews", bytes.NewBufferString(`{"rating": 6}`))
+		r = r.WithContext(context.WithValue(r.Context(), "token", uint(9)))
+		r = mux.SetURLVars(r, map[string]string{"id": "5"})
+		w := httptest.NewRecorder()
+
+		reviewBookingHandler(deps).ServeHTTP(w, r)
+		exp, _ := json.Marshal(invalidField("rating", "must be at most 5"))
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+		assert.Equal(t, string(exp), w.Body.String())
+	})
+
+	t.Run("when the review is left", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/bookings/5/reviews", bytes.NewBufferString(`{"rating": 4, "comment": "ran well"}`))
+		r = r.WithContext(context.WithValue(r.Context(), "token", uint(9)))
+		r = mux.SetURLVars(r, map[string]string{"id": "5"})
+		w := httptest.NewRecorder()
+		request := domain.NewReviewRequest{BookingId: 5, ReviewerId: 9, Rating: 4, Comment: "ran well"}
+		respBody := domain.Review{Id: 7, BookingId: 5, MachineId: 2, ReviewerId: 9, RevieweeId: 20, Role: "renter", Rating: 4, Comment: "ran well"}
+		s.service.On("ReviewBooking", r.Context(), request).Return(respBody, nil).Once()
+
+		reviewBookingHandler(deps).ServeHTTP(w, r)
+		exp, _ := json.Marshal(respBody)
+		assert.Equal(t, http.StatusCreated, w.Result().StatusCode)
+		assert.Equal(t, string(exp), w.Body.String())
+	})
+}
@@ -1,32 +1,184 @@
 package services
 
 import (
+	"FarmEasy/api"
+	"FarmEasy/domain"
 	"net/http"
 
 	"github.com/gorilla/mux"
 )
 
+// APIPrefix is where the current version of the API is served.
+const APIPrefix = "/api/v1"
+
 /* The routing mechanism. Mux helps us define handler functions and the access methods */
 func InitRouter(deps dependencies) (router *mux.Router) {
 	router = mux.NewRouter()
+	router.Use(api.RequestID)
+	router.NotFoundHandler = api.RequestID(http.HandlerFunc(routeNotFoundHandler))
+	router.MethodNotAllowedHandler = api.RequestID(http.HandlerFunc(methodNotAllowedHandler))
+
+	router.HandleFunc("/openapi.json", openAPIHandler).Methods(http.MethodGet)
+
+	router.HandleFunc("/docs", docsHandler).Methods(http.MethodGet)
+
+	v1 := router.PathPrefix(APIPrefix).Subrouter()
+
+	v1.HandleFunc("/ping", pingHandler).Methods(http.MethodGet)
+
+	v1.HandleFunc("/register", registerHandler(deps)).Methods(http.MethodPost)
+
+	v1.HandleFunc("/login", loginHandler(deps)).Methods(http.MethodPost)
+
+	v1.HandleFunc("/machines", ValidateUser(addMachineHandler(deps))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/machines", ValidateUser(getMachineHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/bookings", ValidateUser(Idempotent(deps, bookingHandler(deps)))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/orders", ValidateUser(Idempotent(deps, checkoutHandler(deps)))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/availability", ValidateUser(availabilityHandler(deps))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/availability/search", ValidateUser(nextAvailableHandler(deps))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/bookings", ValidateUser(getAllBookingsHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/bookings/{id:[0-9]+}", ValidateUser(cancelBookingHandler(deps))).Methods(http.MethodDelete)
+
+	v1.HandleFunc("/bookings/recurring", ValidateUser(Idempotent(deps, recurringBookingHandler(deps)))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/bookings/series/{id:[0-9]+}", ValidateUser(cancelBookingSeriesHandler(deps))).Methods(http.MethodDelete)
+
+	v1.HandleFunc("/bookings/{id:[0-9]+}/check-in", ValidateUser(recordHandoverHandler(deps, domain.HandoverCheckIn))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/bookings/{id:[0-9]+}/check-in/confirm", ValidateUser(confirmHandoverHandler(deps, domain.HandoverCheckIn))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/bookings/{id:[0-9]+}/check-out", ValidateUser(recordHandoverHandler(deps, domain.HandoverCheckOut))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/bookings/{id:[0-9]+}/check-out/confirm", ValidateUser(confirmHandoverHandler(deps, domain.HandoverCheckOut))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/bookings/{id:[0-9]+}/handovers", ValidateUser(getHandoversHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/bookings/{id:[0-9]+}/reviews", ValidateUser(reviewBookingHandler(deps))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/machines/{id:[0-9]+}/availability/stream", ValidateUser(availabilityStreamHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/machines/{id:[0-9]+}/reviews", ValidateUser(getMachineReviewsHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/farmers/{id:[0-9]+}", ValidateUser(getFarmerHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/farmers/{id:[0-9]+}/reviews", ValidateUser(getFarmerReviewsHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/reviews/{id:[0-9]+}/report", ValidateUser(reportReviewHandler(deps))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/threads", ValidateUser(startThreadHandler(deps))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/threads", ValidateUser(getThreadsHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/threads/{id:[0-9]+}/messages", ValidateUser(getMessagesHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/threads/{id:[0-9]+}/messages", ValidateUser(postMessageHandler(deps))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/notifications", ValidateUser(getNotificationsHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/notifications/{id:[0-9]+}/read", ValidateUser(markNotificationReadHandler(deps))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/notifications/preferences", ValidateUser(getNotificationPreferencesHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/notifications/preferences", ValidateUser(setNotificationPreferencesHandler(deps))).Methods(http.MethodPut)
 
-	router.HandleFunc("/ping", pingHandler).Methods(http.MethodGet)
+	v1.HandleFunc("/api-clients", ValidateUser(createAPIClientHandler(deps))).Methods(http.MethodPost)
 
-	router.HandleFunc("/register", registerHandler(deps)).Methods(http.MethodPost)
+	v1.HandleFunc("/api-clients", ValidateUser(getAPIClientsHandler(deps))).Methods(http.MethodGet)
 
-	router.HandleFunc("/login", loginHandler(deps)).Methods(http.MethodPost)
+	v1.HandleFunc("/api-clients/{id:[0-9]+}/webhooks", ValidateUser(addWebhookHandler(deps))).Methods(http.MethodPost)
 
-	router.HandleFunc("/machines", ValidateUser(addMachineHandler(deps))).Methods(http.MethodPost)
+	v1.HandleFunc("/api-clients/{id:[0-9]+}/webhooks", ValidateUser(getWebhooksHandler(deps))).Methods(http.MethodGet)
 
-	router.HandleFunc("/machines", ValidateUser(getMachineHandler(deps))).Methods(http.MethodGet)
+	v1.HandleFunc("/webhooks/{id:[0-9]+}", ValidateUser(deleteWebhookHandler(deps))).Methods(http.MethodDelete)
 
-	router.HandleFunc("/bookings", ValidateUser(bookingHandler(deps))).Methods(http.MethodPost)
+	v1.HandleFunc("/webhooks/{id:[0-9]+}/deliveries", ValidateUser(getWebhookDeliveriesHandler(deps))).Methods(http.MethodGet)
 
-	router.HandleFunc("/availability", ValidateUser(availabilityHandler(deps))).Methods(http.MethodPost)
+	v1.HandleFunc("/webhook-deliveries/{id:[0-9]+}/replay", ValidateUser(replayWebhookDeliveryHandler(deps))).Methods(http.MethodPost)
 
-	router.HandleFunc("/bookings", ValidateUser(getAllBookingsHandler(deps))).Methods(http.MethodGet)
+	v1.HandleFunc("/invoices", ValidateUser(getInvoicesHandler(deps))).Methods(http.MethodGet)
 
-	router.HandleFunc("/slots", ValidateUser(getAllSlotsHandler(deps))).Methods(http.MethodGet)
+	v1.HandleFunc("/invoices/{id:[0-9]+}", ValidateUser(getInvoiceHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/invoices/{id:[0-9]+}.pdf", ValidateUser(getInvoicePDFHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/invoices/{id:[0-9]+}.html", ValidateUser(getInvoiceHTMLHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/payments", ValidateUser(Idempotent(deps, createPaymentHandler(deps)))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/payments/webhook", paymentWebhookHandler(deps)).Methods(http.MethodPost)
+
+	v1.HandleFunc("/payments/{id:[0-9]+}", ValidateUser(getPaymentHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/payments/{id:[0-9]+}/refund", ValidateUser(Idempotent(deps, refundPaymentHandler(deps)))).Methods(http.MethodPost)
+
+	if deps.FakePayments != nil {
+		v1.HandleFunc("/payments/fake/orders/{order_id}/pay", ValidateUser(fakePayHandler(deps))).Methods(http.MethodPost)
+	}
+
+	v1.HandleFunc("/deposits", ValidateUser(getDepositsHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/deposits/{id:[0-9]+}", ValidateUser(getDepositHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/deposits/{id:[0-9]+}/release", ValidateUser(releaseDepositHandler(deps))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/deposits/{id:[0-9]+}/capture", ValidateUser(captureDepositHandler(deps))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/owner/earnings", ValidateUser(getOwnerEarningsHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/wallet", ValidateUser(getWalletHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/wallet/transactions", ValidateUser(getWalletTransactionsHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/wallet/topups", ValidateUser(Idempotent(deps, topUpWalletHandler(deps)))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/admin/promos", ValidateAdmin(addPromoCodeHandler(deps))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/admin/promos", ValidateAdmin(getPromoCodesHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/admin/promos/{id:[0-9]+}", ValidateAdmin(deactivatePromoCodeHandler(deps))).Methods(http.MethodDelete)
+
+	v1.HandleFunc("/admin/promos/{id:[0-9]+}/redemptions", ValidateAdmin(getPromoRedemptionsHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/claims", ValidateUser(fileClaimHandler(deps))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/claims", ValidateUser(getClaimsHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/claims/{id:[0-9]+}", ValidateUser(getClaimHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/claims/{id:[0-9]+}/respond", ValidateUser(respondToClaimHandler(deps))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/admin/claims", ValidateAdmin(getClaimsForReviewHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/admin/claims/{id:[0-9]+}/decide", ValidateAdmin(decideClaimHandler(deps))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/admin/review-reports", ValidateAdmin(getReviewReportsHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/admin/review-reports/{id:[0-9]+}/resolve", ValidateAdmin(resolveReviewReportHandler(deps))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/waitlist", ValidateUser(joinWaitlistHandler(deps))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/waitlist", ValidateUser(getWaitlistHandler(deps))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/waitlist/{id:[0-9]+}", ValidateUser(leaveWaitlistHandler(deps))).Methods(http.MethodDelete)
+
+	v1.HandleFunc("/waitlist/{id:[0-9]+}/confirm", ValidateUser(confirmWaitlistOfferHandler(deps))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/slots", ValidateUser(getAllSlotsHandler(deps))).Methods(http.MethodGet)
 
 	return
 }
+
+func routeNotFoundHandler(w http.ResponseWriter, r *http.Request) {
+	fail(w, r, api.NotFound("no such route"))
+}
+
+func methodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	fail(w, r, api.NewError(http.StatusMethodNotAllowed, api.CodeNotAllowed, r.Method+" is not allowed here"))
+}
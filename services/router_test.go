@@ -0,0 +1,70 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/db"
+	"FarmEasy/mocks"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitRouter(t *testing.T) {
+	router := InitRouter(dependencies{FarmService: &mocks.Service{}})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		code   string
+	}{
+		{"when the route is versioned", http.MethodGet, "/api/v1/ping", http.StatusOK, ""},
+		{"when the route is not versioned", http.MethodGet, "/ping", http.StatusNotFound, api.CodeNotFound},
+		{"when the method is not allowed", http.MethodPut, "/api/v1/ping", http.StatusMethodNotAllowed, api.CodeNotAllowed},
+		{"when the token is missing", http.MethodGet, "/api/v1/bookings", http.StatusUnauthorized, api.CodeUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.status, w.Result().StatusCode)
+			assert.NotEmpty(t, w.Header().Get(api.RequestIDHeader))
+			if tt.code != "" {
+				assert.Contains(t, w.Body.String(), `"code":"`+tt.code+`"`)
+				assert.Contains(t, w.Body.String(), `"request_id":"`+w.Header().Get(api.RequestIDHeader)+`"`)
+			}
+		})
+	}
+}
+
+func Test_clientError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"when the error is the service's", ErrBookingNotFound, http.StatusNotFound},
+		{"when a slot is taken", fmt.Errorf("line 2: %w", db.ErrSlotNotEmpty), http.StatusConflict},
+		{"when the wallet is short", db.ErrInsufficientFunds, http.StatusConflict},
+		{"when the database fails", errors.New(`pq: duplicate key value violates unique constraint "bookings_pkey"`), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/orders", nil)
+			w := httptest.NewRecorder()
+
+			fail(w, r, tt.err)
+
+			assert.Equal(t, tt.status, w.Result().StatusCode)
+			assert.NotContains(t, w.Body.String(), "pq:")
+		})
+	}
+}
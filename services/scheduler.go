@@ -0,0 +1,57 @@
+package services
+
+import (
+	"FarmEasy/config"
+	"FarmEasy/scheduler"
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NewScheduler returns the scheduler of the background jobs of the server.
+// Each job runs on one server at a time however many share the database.
+func NewScheduler(deps dependencies) *scheduler.Scheduler {
+
+	jobs := scheduler.New(deps.Locker)
+	service := deps.FarmService
+
+	jobs.Add("waitlist-offers", time.Minute, func(ctx context.Context) error {
+		_, err := service.ExpireWaitlistOffers(ctx)
+		return err
+	})
+	jobs.Add("owner-settlement", config.SettlementInterval(), func(ctx context.Context) error {
+		batch, err := service.SettleOwnerBalances(ctx)
+		if err != nil {
+			return err
+		}
+		logrus.WithField("batch_id", batch.Id).WithField("payouts", len(batch.Payouts)).Info("settled owner balances")
+		return nil
+	})
+	jobs.Add("claim-escalation", time.Minute, func(ctx context.Context) error {
+		_, err := service.EscalateOverdueClaims(ctx)
+		return err
+	})
+	jobs.Add("unclaimed-deposits", time.Hour, func(ctx context.Context) error {
+		_, err := service.ReleaseUnclaimedDeposits(ctx)
+		return err
+	})
+	jobs.Add("notifications", 10*time.Second, func(ctx context.Context) error {
+		_, err := service.DispatchNotifications(ctx)
+		return err
+	})
+	jobs.Add("booking-reminders", 5*time.Minute, func(ctx context.Context) error {
+		_, err := service.SendBookingReminders(ctx)
+		return err
+	})
+	jobs.Add("no-shows", 5*time.Minute, func(ctx context.Context) error {
+		_, err := service.MarkNoShows(ctx)
+		return err
+	})
+	jobs.Add("webhooks", 10*time.Second, func(ctx context.Context) error {
+		_, err := service.DeliverWebhooks(ctx)
+		return err
+	})
+
+	return jobs
+}
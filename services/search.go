@@ -0,0 +1,182 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/domain"
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const (
+	dateTimeLayout     = "2006-01-02T15:04"
+	maxSearchDays      = 14
+	defaultSearchLimit = 10
+	earthRadiusKm      = 6371.0
+)
+
+// FindNextAvailable returns, for every matching machine, the earliest window of
+// the requested number of contiguous free slots, ranked by start time, then
+// price, then distance from the renter. Sorting by rating ranks the best rated
+// machines first, and the most reviewed among equally rated ones.
+func (s *FarmService) FindNextAvailable(ctx context.Context, request domain.NextAvailableRequest) (windows []domain.AvailableWindow, err error) {
+
+	start, err := time.Parse(dateTimeLayout, request.EarliestStart)
+	if err != nil {
+		return nil, ErrInvalidSearchWindow
+	}
+	end, err := time.Parse(dateTimeLayout, request.LatestEnd)
+	if err != nil {
+		return nil, ErrInvalidSearchWindow
+	}
+	if !end.After(start) || request.DurationSlots == 0 || request.DurationSlots > 24 {
+		return nil, ErrInvalidSearchWindow
+	}
+	if end.Sub(start) > maxSearchDays*24*time.Hour {
+		return nil, ErrSearchWindowTooLong
+	}
+	if request.Category == "" && len(request.MachineIds) == 0 {
+		return nil, ErrNoSearchCriteria
+	}
+	if request.SortBy != "" && request.SortBy != domain.SortByRating {
+		return nil, ErrInvalidSearchSort
+	}
+
+	machines, err := s.store.FindMachines(ctx, domain.MachineFilter{Category: request.Category, MachineIds: request.MachineIds})
+	if err != nil || len(machines) == 0 {
+		return
+	}
+
+	var machineIds []uint
+	for _, machine := range machines {
+		machineIds = append(machineIds, machine.Id)
+	}
+
+	bookedSlots, err := s.store.GetBookedSlots(ctx, machineIds, start.Format(dateLayout), end.Format(dateLayout))
+	if err != nil {
+		return
+	}
+
+	starts := map[uint]time.Time{}
+	for _, machine := range machines {
+		day, slots, ok := earliestWindow(bookedSlots[machine.Id], start, end, request.DurationSlots)
+		if !ok {
+			continue
+		}
+
+		windowStart := day.Add(time.Duration(slots[0]-1) * time.Hour)
+		window := domain.AvailableWindow{
+			MachineId:   machine.Id,
+			MachineName: machine.Name,
+			Category:    machine.Category,
+			Date:        day.Format(dateLayout),
+			Slots:       slots,
+			StartTime:   windowStart.Format(dateTimeLayout),
+			EndTime:     windowStart.Add(time.Duration(len(slots)) * time.Hour).Format(dateTimeLayout),
+			TotalCost:   machine.BaseHourlyCharge.Mul(int64(len(slots))),
+			Rating:      machine.Rating,
+		}
+		if request.Latitude != nil && request.Longitude != nil && machine.Latitude != nil && machine.Longitude != nil {
+			distance := distanceKm(*request.Latitude, *request.Longitude, *machine.Latitude, *machine.Longitude)
+			window.DistanceKm = &distance
+		}
+
+		starts[machine.Id] = windowStart
+		windows = append(windows, window)
+	}
+
+	sort.SliceStable(windows, func(i, j int) bool {
+		a, b := windows[i], windows[j]
+		if request.SortBy == domain.SortByRating && a.Rating != b.Rating {
+			if a.Rating.Average != b.Rating.Average {
+				return a.Rating.Average > b.Rating.Average
+			}
+			return a.Rating.Count > b.Rating.Count
+		}
+		if !starts[a.MachineId].Equal(starts[b.MachineId]) {
+			return starts[a.MachineId].Before(starts[b.MachineId])
+		}
+		if a.TotalCost != b.TotalCost {
+			return a.TotalCost.Minor < b.TotalCost.Minor
+		}
+		if a.DistanceKm == nil || b.DistanceKm == nil {
+			return a.DistanceKm != nil
+		}
+		return *a.DistanceKm < *b.DistanceKm
+	})
+
+	limit := int(request.Limit)
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+	if len(windows) > limit {
+		windows = windows[:limit]
+	}
+	return
+}
+
+// earliestWindow finds the first run of duration free slots that lies within a
+// single day and between start and end. Slot n covers hour n-1 to n.
+func earliestWindow(booked map[string]map[uint]struct{}, start time.Time, end time.Time, duration uint) (day time.Time, slots []uint, ok bool) {
+
+	for day = start.Truncate(24 * time.Hour); day.Before(end); day = day.AddDate(0, 0, 1) {
+		firstSlot, lastSlot := uint(1), uint(24)
+		if day.Equal(start.Truncate(24 * time.Hour)) {
+			firstSlot = uint(start.Hour()) + 1
+			if start.Minute() > 0 {
+				firstSlot++
+			}
+		}
+		if day.Equal(end.Truncate(24 * time.Hour)) {
+			lastSlot = uint(end.Hour())
+		}
+
+		bookedOnDay := booked[day.Format(dateLayout)]
+		run := uint(0)
+		for slot := firstSlot; slot <= lastSlot; slot++ {
+			if _, taken := bookedOnDay[slot]; taken {
+				run = 0
+				continue
+			}
+			run++
+			if run == duration {
+				for n := slot - duration + 1; n <= slot; n++ {
+					slots = append(slots, n)
+				}
+				return day, slots, true
+			}
+		}
+	}
+
+	return
+}
+
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+func nextAvailableHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var request domain.NextAvailableRequest
+
+		if err := decode(r, &request); err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		windows, err := deps.FarmService.FindNextAvailable(r.Context(), request)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, windows)
+	}
+}
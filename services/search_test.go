@@ -0,0 +1,149 @@
+package services
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_earliestWindow(t *testing.T) {
+	parse := func(value string) time.Time {
+		parsed, _ := time.Parse(dateTimeLayout, value)
+		return parsed
+	}
+
+	tests := []struct {
+		name      string
+		booked    map[string]map[uint]struct{}
+		start     string
+		end       string
+		duration  uint
+		wantDate  string
+		wantSlots []uint
+		wantOk    bool
+	}{
+		{
+			name:      "free machine starts at the next full hour",
+			start:     "2026-11-07T06:30",
+			end:       "2026-11-07T18:00",
+			duration:  3,
+			wantDate:  "2026-11-07",
+			wantSlots: []uint{8, 9, 10},
+			wantOk:    true,
+		},
+		{
+			name:      "skips booked slots",
+			booked:    map[string]map[uint]struct{}{"2026-11-07": {8: {}, 10: {}}},
+			start:     "2026-11-07T06:00",
+			end:       "2026-11-07T18:00",
+			duration:  2,
+			wantDate:  "2026-11-07",
+			wantSlots: []uint{11, 12},
+			wantOk:    true,
+		},
+		{
+			name:      "moves to the next day",
+			booked:    map[string]map[uint]struct{}{"2026-11-07": {20: {}, 22: {}}},
+			start:     "2026-11-07T18:00",
+			end:       "2026-11-08T12:00",
+			duration:  3,
+			wantDate:  "2026-11-08",
+			wantSlots: []uint{1, 2, 3},
+			wantOk:    true,
+		},
+		{
+			name:     "no window before the latest end",
+			start:    "2026-11-07T06:00",
+			end:      "2026-11-07T08:00",
+			duration: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day, slots, ok := earliestWindow(tt.booked, parse(tt.start), parse(tt.end), tt.duration)
+			assert.Equal(t, tt.wantOk, ok)
+			if tt.wantOk {
+				assert.Equal(t, tt.wantDate, day.Format(dateLayout))
+				assert.Equal(t, tt.wantSlots, slots)
+			}
+		})
+	}
+}
+
+func (s *ServiceTestSuite) TestFarmService_FindNextAvailable() {
+	t := s.T()
+
+	lat, lon := 18.52, 73.85
+	nearLat, nearLon := 18.53, 73.86
+	farLat, farLon := 19.07, 72.87
+
+	machines := []domain.MachineResponse{
+		{Id: 1, Name: "far", Category: "harvester", BaseHourlyCharge: domain.Rupees(1000), Latitude: &farLat, Longitude: &farLon},
+		{Id: 2, Name: "busy", Category: "harvester", BaseHourlyCharge: domain.Rupees(500)},
+		{Id: 3, Name: "near", Category: "harvester", BaseHourlyCharge: domain.Rupees(1000), Latitude: &nearLat, Longitude: &nearLon},
+		{Id: 4, Name: "cheap", Category: "harvester", BaseHourlyCharge: domain.Rupees(800)},
+	}
+	booked := map[uint]map[string]map[uint]struct{}{
+		2: {"2026-11-07": {8: {}}},
+	}
+
+	s.repo.On("FindMachines", context.TODO(), domain.MachineFilter{Category: "harvester"}).Return(machines, nil).Once()
+	s.repo.On("GetBookedSlots", context.TODO(), []uint{1, 2, 3, 4}, "2026-11-07", "2026-11-07").Return(booked, nil).Once()
+
+	windows, err := s.service.FindNextAvailable(context.TODO(), domain.NextAvailableRequest{
+		Category:      "harvester",
+		DurationSlots: 2,
+		EarliestStart: "2026-11-07T06:00",
+		LatestEnd:     "2026-11-07T18:00",
+		Latitude:      &lat,
+		Longitude:     &lon,
+	})
+	require.NoError(t, err)
+
+	var order []string
+	for _, window := range windows {
+		order = append(order, window.MachineName)
+	}
+	assert.Equal(t, []string{"cheap", "near", "far", "busy"}, order)
+	assert.Equal(t, "2026-11-07T06:00", windows[0].StartTime)
+	assert.Equal(t, "2026-11-07T08:00", windows[0].EndTime)
+	assert.Equal(t, domain.Rupees(1600), windows[0].TotalCost)
+	assert.Equal(t, []uint{9, 10}, windows[3].Slots)
+
+	_, err = s.service.FindNextAvailable(context.TODO(), domain.NextAvailableRequest{Category: "harvester", DurationSlots: 2, EarliestStart: "2026-11-07T06:00", LatestEnd: "2026-12-07T06:00"})
+	assert.Equal(t, ErrSearchWindowTooLong, err)
+}
+
+func (s *ServiceTestSuite) TestFarmService_FindNextAvailable_SortByRating() {
+	t := s.T()
+
+	machines := []domain.MachineResponse{
+		{Id: 1, Name: "unrated", Category: "harvester", BaseHourlyCharge: domain.Rupees(500)},
+		{Id: 2, Name: "good", Category: "harvester", BaseHourlyCharge: domain.Rupees(1000), Rating: domain.Rating{Average: 4.5, Count: 2}},
+		{Id: 3, Name: "best", Category: "harvester", BaseHourlyCharge: domain.Rupees(1000), Rating: domain.Rating{Average: 4.8, Count: 5}},
+		{Id: 4, Name: "proven", Category: "harvester", BaseHourlyCharge: domain.Rupees(1000), Rating: domain.Rating{Average: 4.5, Count: 12}},
+	}
+	request := domain.NextAvailableRequest{Category: "harvester", DurationSlots: 2, EarliestStart: "2026-11-07T06:00", LatestEnd: "2026-11-07T18:00", SortBy: domain.SortByRating}
+
+	s.repo.On("FindMachines", context.TODO(), domain.MachineFilter{Category: "harvester"}).Return(machines, nil).Once()
+	s.repo.On("GetBookedSlots", context.TODO(), []uint{1, 2, 3, 4}, "2026-11-07", "2026-11-07").Return(map[uint]map[string]map[uint]struct{}{}, nil).Once()
+
+	windows, err := s.service.FindNextAvailable(context.TODO(), request)
+	require.NoError(t, err)
+
+	var order []string
+	for _, window := range windows {
+		order = append(order, window.MachineName)
+	}
+	assert.Equal(t, []string{"best", "proven", "good", "unrated"}, order)
+	assert.Equal(t, domain.Rating{Average: 4.8, Count: 5}, windows[0].Rating)
+
+	request.SortBy = "price"
+	_, err = s.service.FindNextAvailable(context.TODO(), request)
+	assert.Equal(t, ErrInvalidSearchSort, err)
+}
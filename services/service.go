@@ -1,11 +1,18 @@
 package services
 
 import (
+	"FarmEasy/config"
 	"FarmEasy/constant"
 	"FarmEasy/db"
 	"FarmEasy/domain"
+	"FarmEasy/notify"
+	"FarmEasy/payment"
+	"FarmEasy/pubsub"
+	"FarmEasy/tax"
+	"FarmEasy/webhook"
 	"context"
 	"crypto/sha256"
+	"database/sql"
 	"encoding/base64"
 	"time"
 
@@ -15,6 +22,8 @@ import (
 
 var secretKey = []byte("I'mGoingToBeAGolangDeveloper")
 
+const defaultMachineCategory = "other"
+
 type Service interface {
 	Register(context.Context, domain.NewFarmerRequest) (addedFarmer domain.FarmerResponse, err error)
 	Login(context.Context, domain.LoginRequest) (token string, err error)
@@ -24,15 +33,105 @@ type Service interface {
 	GetAvailability(context.Context, uint, string) (slotsAvailable []uint, err error)
 	GetAllBookings(context.Context, uint) (bookings []domain.BookingResponse, err error)
 	GetAllSlots(context.Context) (slots []domain.SlotResponse, err error)
+	BeginIdempotentRequest(context.Context, domain.IdempotencyRecord) (replay *domain.IdempotencyRecord, err error)
+	CompleteIdempotentRequest(context.Context, domain.IdempotencyRecord) (err error)
+	Checkout(context.Context, domain.NewOrderRequest) (order domain.OrderResponse, err error)
+	CancelBooking(context.Context, uint, uint) (cancelled domain.CancelledBooking, err error)
+	BookRecurring(context.Context, domain.NewRecurringBookingRequest) (domain.RecurringBookingResponse, error)
+	CancelBookingSeries(context.Context, uint, uint) (cancelled []domain.CancelledBooking, err error)
+	FindNextAvailable(context.Context, domain.NextAvailableRequest) (windows []domain.AvailableWindow, err error)
+	JoinWaitlist(context.Context, domain.NewWaitlistRequest) (entry domain.WaitlistEntry, err error)
+	GetWaitlist(context.Context, uint) (entries []domain.WaitlistEntry, err error)
+	LeaveWaitlist(context.Context, uint, uint) (err error)
+	ConfirmWaitlistOffer(context.Context, uint, uint) (entry domain.WaitlistEntry, err error)
+	ExpireWaitlistOffers(context.Context) (expired int, err error)
+	GetInvoices(context.Context, uint) (invoices []domain.Invoice, err error)
+	GetInvoice(context.Context, uint, uint) (invoice domain.Invoice, err error)
+	GetInvoiceDocument(context.Context, uint, uint) (doc domain.InvoiceDocument, err error)
+	CreatePayment(context.Context, domain.NewPaymentRequest) (created domain.Payment, err error)
+	GetPayment(context.Context, uint, uint) (found domain.Payment, err error)
+	HandlePaymentWebhook(context.Context, []byte, string) (err error)
+	GetOrderPayer(context.Context, string) (farmerId uint, err error)
+	RefundPayment(context.Context, uint, uint) (refunded domain.Payment, err error)
+	GetDeposits(context.Context, uint) (deposits []domain.Deposit, err error)
+	GetDeposit(context.Context, uint, uint) (deposit domain.Deposit, err error)
+	ReleaseDeposit(context.Context, uint, uint) (deposit domain.Deposit, err error)
+	CaptureDeposit(context.Context, uint, uint, domain.DepositCaptureRequest) (deposit domain.Deposit, err error)
+	ReleaseUnclaimedDeposits(context.Context) (released int, err error)
+	GetOwnerEarnings(context.Context, uint) (earnings domain.OwnerEarnings, err error)
+	SettleOwnerBalances(context.Context) (batch domain.PayoutBatch, err error)
+	GetWallet(context.Context, uint) (wallet domain.Wallet, err error)
+	GetWalletTransactions(context.Context, uint) (transactions []domain.WalletTransaction, err error)
+	TopUpWallet(context.Context, domain.NewTopupRequest) (topup domain.WalletTopup, err error)
+	AddPromoCode(context.Context, domain.NewPromoCodeRequest) (promo domain.PromoCode, err error)
+	GetPromoCodes(context.Context) (promos []domain.PromoCode, err error)
+	DeactivatePromoCode(context.Context, uint) (promo domain.PromoCode, err error)
+	GetPromoRedemptions(context.Context, uint) (redemptions []domain.PromoRedemption, err error)
+	RecordHandover(context.Context, domain.HandoverRequest) (handover domain.Handover, err error)
+	ConfirmHandover(context.Context, uint, string, uint) (handover domain.Handover, err error)
+	GetHandovers(context.Context, uint, uint) (handovers []domain.Handover, err error)
+	FileClaim(context.Context, domain.NewClaimRequest) (claim domain.Claim, err error)
+	GetClaims(context.Context, uint) (claims []domain.Claim, err error)
+	GetClaim(context.Context, uint, uint) (claim domain.Claim, err error)
+	RespondToClaim(context.Context, uint, uint, domain.ClaimResponseRequest) (claim domain.Claim, err error)
+	GetClaimsForReview(context.Context) (claims []domain.Claim, err error)
+	DecideClaim(context.Context, uint, uint, domain.ClaimDecisionRequest) (claim domain.Claim, err error)
+	EscalateOverdueClaims(context.Context) (escalated int, err error)
+	ReviewBooking(context.Context, domain.NewReviewRequest) (review domain.Review, err error)
+	GetMachineReviews(context.Context, uint) (reviews []domain.Review, err error)
+	GetFarmer(context.Context, uint, uint) (farmer domain.FarmerResponse, err error)
+	GetFarmerReviews(context.Context, uint) (reviews []domain.Review, err error)
+	ReportReview(context.Context, uint, uint, domain.ReviewReportRequest) (report domain.ReviewReport, err error)
+	GetReviewReports(context.Context) (reports []domain.ReviewReport, err error)
+	ResolveReviewReport(context.Context, uint, uint, domain.ReviewReportDecisionRequest) (report domain.ReviewReport, err error)
+	StartThread(context.Context, domain.NewThreadRequest) (thread domain.Thread, err error)
+	GetThreads(context.Context, uint) (threads []domain.Thread, err error)
+	GetMessages(context.Context, uint, uint, uint, uint) (page domain.MessagePage, err error)
+	PostMessage(context.Context, domain.NewMessageRequest) (message domain.Message, err error)
+	DispatchNotifications(context.Context) (sent int, err error)
+	SendBookingReminders(context.Context) (queued int, err error)
+	GetNotifications(context.Context, uint) (inbox domain.NotificationInbox, err error)
+	MarkNotificationRead(context.Context, uint, uint) (err error)
+	GetNotificationPreferences(context.Context, uint) (preferences []domain.NotificationPreference, err error)
+	SetNotificationPreferences(context.Context, uint, []domain.NotificationPreference) (preferences []domain.NotificationPreference, err error)
+	CreateAPIClient(context.Context, domain.NewAPIClientRequest) (client domain.APIClient, err error)
+	GetAPIClients(context.Context, uint) (clients []domain.APIClient, err error)
+	AddWebhook(context.Context, domain.NewWebhookRequest) (webhook domain.WebhookSubscription, err error)
+	GetWebhooks(context.Context, uint, uint) (webhooks []domain.WebhookSubscription, err error)
+	DeleteWebhook(context.Context, uint, uint) (err error)
+	GetWebhookDeliveries(context.Context, uint, uint) (deliveries []domain.WebhookDelivery, err error)
+	ReplayWebhookDelivery(context.Context, uint, uint) (replay domain.WebhookDelivery, err error)
+	DeliverWebhooks(context.Context) (delivered int, err error)
+	MarkNoShows(context.Context) (marked int, err error)
+	SubscribeAvailability(context.Context, uint) (changes <-chan domain.AvailabilityChange, unsubscribe func(), err error)
 }
 
 type FarmService struct {
-	store db.Storer
+	store    db.Storer
+	notifier Notifier
+	payments payment.Provider
+	channels map[string]notify.Channel
+	webhooks *webhook.Sender
+	// events carries changes, such as to availability, to the streams that
+	// follow them.
+	events pubsub.Broker
 }
 
-func NewFarmService(s db.Storer) Service {
+// NewFarmService returns the service. Notifications are delivered on the
+// channels given; without any they stay in the outbox.
+func NewFarmService(s db.Storer, p payment.Provider, channels ...notify.Channel) Service {
+	byName := map[string]notify.Channel{}
+	for _, channel := range channels {
+		byName[channel.Name()] = channel
+	}
+
 	return &FarmService{
-		store: s,
+		store:    s,
+		notifier: outboxNotifier{store: s},
+		payments: p,
+		channels: byName,
+		webhooks: webhook.NewSender(webhookTimeout, !config.Development()),
+		events:   pubsub.NewMemory(),
 	}
 }
 
@@ -64,6 +163,11 @@ func (s *FarmService) Register(ctx context.Context, farmer domain.NewFarmerReque
 		Phone:     farmer.Phone,
 		Address:   farmer.Address,
 		Password:  farmer.Password,
+		State:     farmer.State,
+		GSTIN:     farmer.GSTIN,
+	}
+	if newFarmer.State == "" {
+		newFarmer.State = tax.StateOf(newFarmer.GSTIN)
 	}
 
 	newFarmer.Password = Hash_password(newFarmer.Password)
@@ -110,8 +214,22 @@ func (s *FarmService) AddMachine(ctx context.Context, machine domain.NewMachineR
 		Name:             machine.Name,
 		Description:      machine.Description,
 		BaseHourlyCharge: machine.BaseHourlyCharge,
+		SecurityDeposit:  machine.SecurityDeposit,
+		UsageBilling:     machine.UsageBilling,
 		OwnerId:          machine.OwnerId,
+		Category:         machine.Category,
+		Latitude:         machine.Latitude,
+		Longitude:        machine.Longitude,
+	}
+	if newMachine.Category == "" {
+		newMachine.Category = defaultMachineCategory
+	}
+	if machine.NoShowPolicy != "" {
+		newMachine.NoShowPolicy = machine.NoShowPolicy
+	} else {
+		newMachine.NoShowPolicy = domain.NoShowKeepPayment
 	}
+	newMachine.SecurityDeposit.Currency = newMachine.BaseHourlyCharge.Currency
 	err = s.store.AddMachine(ctx, &newMachine)
 	return
 }
@@ -123,7 +241,33 @@ func (s *FarmService) GetMachines(ctx context.Context) (machines []domain.Machin
 
 func (s *FarmService) BookMachine(ctx context.Context, booking domain.NewBookingRequest) (invoice domain.NewBookingResponse, err error) {
 
+	if booking.PayFromWallet {
+		booking.CommissionBp = config.PlatformCommission()
+	}
 	invoice, err = s.store.Book(ctx, booking)
+	if err != nil {
+		return
+	}
+
+	s.availabilityChanged(ctx, booking.MachineId, booking.Date, domain.AvailabilityBooked)
+	return
+}
+
+func (s *FarmService) CancelBooking(ctx context.Context, bookingId uint, farmerId uint) (cancelled domain.CancelledBooking, err error) {
+
+	cancelled, err = s.store.CancelBooking(ctx, bookingId, farmerId)
+	if err == sql.ErrNoRows {
+		err = ErrBookingNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	s.notifyDepositSettled(ctx, cancelled.Deposit)
+	s.notifyWalletRefund(ctx, bookingId, cancelled.Refund)
+	s.processWaitlist(ctx, cancelled.MachineId, cancelled.Date)
+	s.availabilityChanged(ctx, cancelled.MachineId, cancelled.Date, domain.AvailabilityCancelled)
 	return
 }
 
@@ -1,8 +1,10 @@
 package services
 
 import (
+	"FarmEasy/config"
 	"FarmEasy/domain"
 	"FarmEasy/mocks"
+	"FarmEasy/payment"
 	"context"
 	"errors"
 	"testing"
@@ -23,9 +25,15 @@ func TestServiceTestSuite(t *testing.T) {
 	suite.Run(t, new(ServiceTestSuite))
 }
 
+func (suite *ServiceTestSuite) SetupSuite() {
+	config.Load()
+}
+
 func (suite *ServiceTestSuite) SetupTest() {
 	suite.repo = &mocks.Storer{}
-	suite.service = NewFarmService(suite.repo)
+	suite.service = NewFarmService(suite.repo, payment.NewFake("secret"))
+	// notifications are only checked by the tests that record them
+	suite.service.(*FarmService).notifier = &recordingNotifier{}
 }
 
 func (suite *ServiceTestSuite) TearDownSuite() {
@@ -228,12 +236,15 @@ func (s *ServiceTestSuite) TestFarmService_AddMachine() {
 				machine: domain.NewMachineRequest{
 					Name:             "Sugar Cane Harvester",
 					Description:      "This is a sugar cane harvester",
-					BaseHourlyCharge: 1000,
+					BaseHourlyCharge: domain.Rupees(1000),
 				},
 			},
 			wantErr: false,
 			prepare: func(a args, s *mocks.Storer) {
-				s.On("AddMachine", context.TODO(), mock.Anything).Return(nil).Once()
+				// without a policy the rental stays paid when the renter does not show up
+				s.On("AddMachine", context.TODO(), mock.MatchedBy(func(m *domain.MachineResponse) bool {
+					return m.NoShowPolicy == domain.NoShowKeepPayment
+				})).Return(nil).Once()
 			},
 		},
 		{
@@ -243,7 +254,7 @@ func (s *ServiceTestSuite) TestFarmService_AddMachine() {
 				machine: domain.NewMachineRequest{
 					Name:             "Sugar Cane Harvester",
 					Description:      "This is a sugar cane harvester",
-					BaseHourlyCharge: 1000,
+					BaseHourlyCharge: domain.Rupees(1000),
 				},
 			},
 			wantErr: true,
@@ -355,7 +366,7 @@ func (s *ServiceTestSuite) TestFarmService_BookMachine() {
 				s.repo.On("BookSlot", context.TODO(), mock.AnythingOfType("domain.Slot")).Return(nil).Once()
 			}
 
-			s.repo.On("GetBaseCharge", context.TODO(), tt.args.booking.MachineId).Return(uint(1000), nil).Once()
+			s.repo.On("GetBaseCharge", context.TODO(), tt.args.booking.MachineId).Return(domain.Rupees(1000), nil).Once()
 
 			s.repo.On("GenrateInvoice", context.TODO(), mock.AnythingOfType("domain.Invoice")).Return(uint(1), nil).Once()
 
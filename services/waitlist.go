@@ -0,0 +1,215 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/config"
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+func (s *FarmService) JoinWaitlist(ctx context.Context, request domain.NewWaitlistRequest) (entry domain.WaitlistEntry, err error) {
+
+	entry = domain.WaitlistEntry{
+		FarmerId:  request.FarmerId,
+		MachineId: request.MachineId,
+		Date:      request.Date,
+		StartSlot: request.StartSlot,
+		EndSlot:   request.EndSlot,
+		AutoBook:  request.AutoBook,
+	}
+	err = s.store.AddWaitlistEntry(ctx, &entry)
+	return
+}
+
+func (s *FarmService) GetWaitlist(ctx context.Context, farmerId uint) (entries []domain.WaitlistEntry, err error) {
+	entries, err = s.store.GetWaitlistEntries(ctx, farmerId)
+	return
+}
+
+func (s *FarmService) LeaveWaitlist(ctx context.Context, entryId uint, farmerId uint) (err error) {
+
+	err = s.store.CancelWaitlistEntry(ctx, entryId, farmerId)
+	if err == sql.ErrNoRows {
+		err = ErrWaitlistEntryNotFound
+	}
+	return
+}
+
+func (s *FarmService) ConfirmWaitlistOffer(ctx context.Context, entryId uint, farmerId uint) (entry domain.WaitlistEntry, err error) {
+
+	entry, err = s.store.ConfirmWaitlistOffer(ctx, entryId, farmerId)
+	if err == sql.ErrNoRows {
+		err = ErrNoWaitlistOffer
+	}
+	return
+}
+
+// ExpireWaitlistOffers cancels the pending bookings whose confirmation window
+// has passed, voiding their invoices, and offers the freed slots to the next
+// farmers in line.
+func (s *FarmService) ExpireWaitlistOffers(ctx context.Context) (expired int, err error) {
+
+	entries, err := s.store.GetExpiredWaitlistOffers(ctx)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		cancelled, err := s.store.ExpireWaitlistOffer(ctx, entry)
+		if err != nil {
+			return expired, err
+		}
+		s.notifyDepositSettled(ctx, cancelled.Deposit)
+		expired++
+
+		s.notifier.Notify(ctx, entry.FarmerId, fmt.Sprintf("Your held booking for machine %d on %s expired without confirmation", entry.MachineId, entry.Date))
+		s.processWaitlist(ctx, entry.MachineId, entry.Date)
+		s.availabilityChanged(ctx, entry.MachineId, entry.Date, domain.AvailabilityHoldExpired)
+	}
+
+	return
+}
+
+// processWaitlist goes through the farmers waiting for the machine on the date
+// in the order they joined, and tells those whose slots are now free. The
+// first of them who asked for it gets the slots booked, pending confirmation.
+func (s *FarmService) processWaitlist(ctx context.Context, machineId uint, date string) {
+
+	entries, err := s.store.GetWaitingEntries(ctx, machineId, date)
+	if err != nil || len(entries) == 0 {
+		return
+	}
+
+	available, err := s.GetAvailability(ctx, machineId, date)
+	if err != nil {
+		return
+	}
+	free := map[uint]struct{}{}
+	for _, slot := range available {
+		free[slot] = struct{}{}
+	}
+
+	for _, entry := range entries {
+		slots := entry.Slots()
+		if !allFree(free, slots) {
+			continue
+		}
+
+		if !entry.AutoBook {
+			entry.Status = domain.WaitlistNotified
+			if err = s.store.UpdateWaitlistEntry(ctx, entry); err != nil {
+				logrus.WithField("err", err.Error()).Error("error updating waitlist entry")
+				continue
+			}
+			s.notifier.Notify(ctx, entry.FarmerId, fmt.Sprintf("Slots %d-%d of machine %d on %s are now available", entry.StartSlot, entry.EndSlot, machineId, date))
+			continue
+		}
+
+		expiresAt := time.Now().Add(config.WaitlistOfferWindow())
+		entry.OfferExpiresAt = &expiresAt
+		if _, err = s.store.BookWaitlistOffer(ctx, &entry); err != nil {
+			logrus.WithField("err", err.Error()).Error("error auto booking for waitlist")
+			continue
+		}
+		for _, slot := range slots {
+			delete(free, slot)
+		}
+
+		s.notifier.Notify(ctx, entry.FarmerId, fmt.Sprintf("Slots %d-%d of machine %d on %s are held for you, confirm before %s", entry.StartSlot, entry.EndSlot, machineId, date, expiresAt.Format(time.Kitchen)))
+	}
+}
+
+func allFree(free map[uint]struct{}, slots []uint) bool {
+	for _, slot := range slots {
+		if _, ok := free[slot]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func joinWaitlistHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var request domain.NewWaitlistRequest
+
+		if err := decode(r, &request); err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		request.FarmerId = r.Context().Value("token").(uint)
+
+		entry, err := deps.FarmService.JoinWaitlist(r.Context(), request)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusCreated, entry)
+	}
+}
+
+func getWaitlistHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		entries, err := deps.FarmService.GetWaitlist(r.Context(), farmerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, entries)
+	}
+}
+
+func leaveWaitlistHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		entryId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid waitlist entry id"))
+			return
+		}
+
+		if err = deps.FarmService.LeaveWaitlist(r.Context(), uint(entryId), farmerId); err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, api.Message{Msg: "left the waitlist"})
+	}
+}
+
+func confirmWaitlistOfferHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		entryId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid waitlist entry id"))
+			return
+		}
+
+		entry, err := deps.FarmService.ConfirmWaitlistOffer(r.Context(), uint(entryId), farmerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, entry)
+	}
+}
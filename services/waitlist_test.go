@@ -0,0 +1,80 @@
+package services
+
+import (
+	"FarmEasy/domain"
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingNotifier struct {
+	notified []uint
+	messages []string
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, farmerId uint, message string) {
+	n.notified = append(n.notified, farmerId)
+	n.messages = append(n.messages, message)
+}
+
+func (s *ServiceTestSuite) TestFarmService_CancelBooking_processesWaitlist() {
+	t := s.T()
+
+	notifier := &recordingNotifier{}
+	s.service.(*FarmService).notifier = notifier
+
+	// the store released the deposit with the cancellation
+	released := domain.Deposit{Id: 5, BookingId: 3, FarmerId: 2, Amount: domain.Rupees(5000), Status: domain.DepositReleased}
+	cancelled := domain.CancelledBooking{BookingId: 3, MachineId: 1, Date: "2026-11-07", Slots: []uint{7, 8}, Deposit: &released}
+	entries := []domain.WaitlistEntry{
+		{Id: 1, FarmerId: 10, MachineId: 1, Date: "2026-11-07", StartSlot: 7, EndSlot: 8, AutoBook: true, Status: domain.WaitlistWaiting},
+		{Id: 2, FarmerId: 11, MachineId: 1, Date: "2026-11-07", StartSlot: 8, EndSlot: 8, Status: domain.WaitlistWaiting},
+		{Id: 3, FarmerId: 12, MachineId: 1, Date: "2026-11-07", StartSlot: 12, EndSlot: 13, Status: domain.WaitlistWaiting},
+	}
+
+	s.repo.On("CancelBooking", context.TODO(), uint(3), uint(2)).Return(cancelled, nil).Once()
+	s.repo.On("GetWaitingEntries", context.TODO(), uint(1), "2026-11-07").Return(entries, nil).Once()
+	s.repo.On("GetBookedSlot", context.TODO(), uint(1), "2026-11-07").Return(map[uint]struct{}{1: {}}, nil).Once()
+	s.repo.On("BookWaitlistOffer", context.TODO(), mock.MatchedBy(func(e *domain.WaitlistEntry) bool {
+		return e.Id == 1 && e.OfferExpiresAt != nil
+	})).Return(domain.NewBookingResponse{BookingId: 20}, nil).Once()
+	s.repo.On("UpdateWaitlistEntry", context.TODO(), mock.MatchedBy(func(e domain.WaitlistEntry) bool {
+		return e.Id == 3 && e.Status == domain.WaitlistNotified
+	})).Return(nil).Once()
+
+	got, err := s.service.CancelBooking(context.TODO(), 3, 2)
+	require.NoError(t, err)
+	assert.Equal(t, cancelled, got)
+	assert.Equal(t, []uint{2, 10, 12}, notifier.notified)
+	s.repo.AssertExpectations(t)
+}
+
+func (s *ServiceTestSuite) TestFarmService_ExpireWaitlistOffers() {
+	t := s.T()
+
+	bookingId := uint(20)
+	entry := domain.WaitlistEntry{Id: 1, FarmerId: 10, MachineId: 1, Date: "2026-11-07", StartSlot: 7, EndSlot: 8, AutoBook: true, Status: domain.WaitlistOffered, BookingId: &bookingId}
+
+	s.repo.On("GetExpiredWaitlistOffers", context.TODO()).Return([]domain.WaitlistEntry{entry}, nil).Once()
+	s.repo.On("ExpireWaitlistOffer", context.TODO(), entry).Return(domain.CancelledBooking{BookingId: 20, MachineId: 1, Date: "2026-11-07", Slots: []uint{7, 8}}, nil).Once()
+	s.repo.On("GetWaitingEntries", context.TODO(), uint(1), "2026-11-07").Return([]domain.WaitlistEntry{}, nil).Once()
+
+	expired, err := s.service.ExpireWaitlistOffers(context.TODO())
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+	s.repo.AssertExpectations(t)
+}
+
+func (s *ServiceTestSuite) TestFarmService_ConfirmWaitlistOffer() {
+	t := s.T()
+
+	t.Run("when the offer has expired", func(t *testing.T) {
+		s.repo.On("ConfirmWaitlistOffer", context.TODO(), uint(1), uint(10)).Return(domain.WaitlistEntry{}, sql.ErrNoRows).Once()
+		_, err := s.service.ConfirmWaitlistOffer(context.TODO(), 1, 10)
+		assert.Equal(t, ErrNoWaitlistOffer, err)
+	})
+}
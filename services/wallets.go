@@ -0,0 +1,157 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/domain"
+	"FarmEasy/payment"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+)
+
+func (s *FarmService) GetWallet(ctx context.Context, farmerId uint) (wallet domain.Wallet, err error) {
+
+	wallet, err = s.store.GetWallet(ctx, farmerId)
+	return
+}
+
+func (s *FarmService) GetWalletTransactions(ctx context.Context, farmerId uint) (transactions []domain.WalletTransaction, err error) {
+
+	transactions, err = s.store.GetWalletTransactions(ctx, farmerId)
+	if err != nil {
+		return
+	}
+	if transactions == nil {
+		transactions = []domain.WalletTransaction{}
+	}
+	return
+}
+
+// TopUpWallet starts adding money to the farmer's wallet. The farmer pays the
+// returned provider order, in cash at an agent or online, and the wallet is
+// credited when the provider's webhook reports it paid.
+func (s *FarmService) TopUpWallet(ctx context.Context, request domain.NewTopupRequest) (topup domain.WalletTopup, err error) {
+
+	wallet, err := s.store.GetWallet(ctx, request.FarmerId)
+	if err != nil {
+		return
+	}
+	// a wallet holds a single currency, set by its first top-up
+	if wallet.UpdatedAt != nil && !wallet.Balance.SameCurrency(request.Amount) {
+		err = domain.ErrCurrencyMismatch
+		return
+	}
+
+	orderId, err := s.payments.CreateOrder(ctx, request.Amount, fmt.Sprintf("wallet:%d", request.FarmerId))
+	if err != nil {
+		return
+	}
+
+	topup = domain.WalletTopup{
+		FarmerId:        request.FarmerId,
+		Provider:        s.payments.Name(),
+		ProviderOrderId: orderId,
+		Amount:          request.Amount,
+	}
+	err = s.store.AddWalletTopup(ctx, &topup)
+	return
+}
+
+// handleTopupEvent applies a provider notification for a wallet top-up, in
+// the same way as for an invoice payment.
+func (s *FarmService) handleTopupEvent(ctx context.Context, event payment.Event) (err error) {
+
+	topup, err := s.store.GetWalletTopupByOrder(ctx, s.payments.Name(), event.OrderId)
+	if err == sql.ErrNoRows {
+		return ErrPaymentNotFound
+	}
+	if err != nil {
+		return
+	}
+	if topup.Status != domain.TopupCreated {
+		return nil
+	}
+
+	switch event.Type {
+	case payment.EventPaymentFailed:
+		err = s.store.FailWalletTopup(ctx, topup.Id, event.PaymentId)
+
+	case payment.EventPaymentAuthorized:
+		if event.Amount != topup.Amount {
+			return ErrPaymentAmountMismatch
+		}
+		if err = s.payments.Capture(ctx, event.PaymentId, topup.Amount); err != nil {
+			return
+		}
+		if topup, err = s.store.CreditWalletTopup(ctx, topup.Id, event.PaymentId); err != nil {
+			return
+		}
+
+		s.notifier.Notify(ctx, topup.FarmerId, fmt.Sprintf("%s was added to your wallet", topup.Amount))
+	}
+
+	return
+}
+
+// notifyWalletRefund tells the renter a cancelled booking was refunded to
+// their wallet, which the store does with the cancellation.
+func (s *FarmService) notifyWalletRefund(ctx context.Context, bookingId uint, refund *domain.Refund) {
+
+	if refund == nil {
+		return
+	}
+	s.notifier.Notify(ctx, refund.FarmerId, fmt.Sprintf("%s for booking %d was refunded to your wallet", refund.Amount, bookingId))
+}
+
+func getWalletHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		wallet, err := deps.FarmService.GetWallet(r.Context(), farmerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, wallet)
+	}
+}
+
+func getWalletTransactionsHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		transactions, err := deps.FarmService.GetWalletTransactions(r.Context(), farmerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, transactions)
+	}
+}
+
+func topUpWalletHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var request domain.NewTopupRequest
+
+		if err := decode(r, &request); err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		request.FarmerId = r.Context().Value("token").(uint)
+
+		topup, err := deps.FarmService.TopUpWallet(r.Context(), request)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusCreated, topup)
+	}
+}
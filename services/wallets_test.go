@@ -0,0 +1,127 @@
+package services
+
+import (
+	"FarmEasy/domain"
+	"FarmEasy/payment"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *ServiceTestSuite) TestFarmService_TopUpWallet() {
+	t := s.T()
+	ctx := context.TODO()
+
+	fake := payment.NewFake("secret")
+	service := NewFarmService(s.repo, fake)
+	service.(*FarmService).notifier = &recordingNotifier{}
+	updatedAt := time.Now()
+
+	t.Run("in another currency than the wallet", func(t *testing.T) {
+		s.repo.On("GetWallet", ctx, uint(9)).Return(domain.Wallet{FarmerId: 9, Balance: domain.Rupees(500), UpdatedAt: &updatedAt}, nil).Once()
+		_, err := service.TopUpWallet(ctx, domain.NewTopupRequest{FarmerId: 9, Amount: domain.NewMoney(1000, "USD")})
+		assert.Equal(t, domain.ErrCurrencyMismatch, err)
+	})
+
+	s.repo.On("GetWallet", ctx, uint(9)).Return(domain.Wallet{FarmerId: 9, Balance: domain.Rupees(0)}, nil).Once()
+	s.repo.On("AddWalletTopup", ctx, mock.AnythingOfType("*domain.WalletTopup")).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.WalletTopup).Id = 2
+		args.Get(1).(*domain.WalletTopup).Status = domain.TopupCreated
+	}).Return(nil).Once()
+
+	topup, err := service.TopUpWallet(ctx, domain.NewTopupRequest{FarmerId: 9, Amount: domain.Rupees(1000)})
+	require.NoError(t, err)
+	assert.Equal(t, "fake", topup.Provider)
+
+	payload, signature, err := fake.Pay(topup.ProviderOrderId)
+	require.NoError(t, err)
+	var event payment.Event
+	require.NoError(t, json.Unmarshal(payload, &event))
+
+	credited := topup
+	credited.Status = domain.TopupCredited
+	s.repo.On("GetPaymentByOrder", ctx, "fake", topup.ProviderOrderId).Return(domain.Payment{}, sql.ErrNoRows).Once()
+	s.repo.On("GetWalletTopupByOrder", ctx, "fake", topup.ProviderOrderId).Return(topup, nil).Once()
+	s.repo.On("CreditWalletTopup", ctx, uint(2), event.PaymentId).Return(credited, nil).Once()
+
+	err = service.HandlePaymentWebhook(ctx, payload, signature)
+	assert.NoError(t, err)
+	s.repo.AssertExpectations(t)
+}
+
+func (s *ServiceTestSuite) TestFarmService_CancelBooking_refundsWallet() {
+	t := s.T()
+	ctx := context.TODO()
+
+	notifier := &recordingNotifier{}
+	s.service.(*FarmService).notifier = notifier
+
+	// the store refunds the wallet with the cancellation
+	refunded := domain.Refund{PaymentId: 3, FarmerId: 9, Amount: domain.Rupees(3000)}
+	cancelled := domain.CancelledBooking{BookingId: 11, MachineId: 1, Date: "2026-11-07", Slots: []uint{7}, Refund: &refunded}
+
+	s.repo.On("CancelBooking", ctx, uint(11), uint(9)).Return(cancelled, nil).Once()
+	s.repo.On("GetWaitingEntries", ctx, uint(1), "2026-11-07").Return([]domain.WaitlistEntry{}, nil).Once()
+
+	_, err := s.service.CancelBooking(ctx, 11, 9)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{9}, notifier.notified)
+	s.repo.AssertExpectations(t)
+}
+
+func (s *ServiceTestSuite) TestFarmService_RefundPayment_toWallet() {
+	t := s.T()
+	ctx := context.TODO()
+
+	paid := domain.Payment{Id: 3, InvoiceId: 1, FarmerId: 9, Provider: domain.WalletProvider, ProviderPaymentId: "wallet_txn_4", Amount: domain.Rupees(3000), Status: domain.PaymentCaptured}
+	s.repo.On("GetPayment", ctx, uint(3)).Return(paid, nil).Once()
+	s.repo.On("GetInvoice", ctx, uint(1)).Return(domain.Invoice{Id: 1, FarmerId: 9, OwnerId: 20, Status: domain.InvoicePaid}, nil).Once()
+	// the provider knows nothing of wallet payments, so it is not asked
+	s.repo.On("RefundPayment", ctx, uint(3), "").Return(nil).Once()
+
+	got, err := s.service.RefundPayment(ctx, 3, 20)
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentRefunded, got.Status)
+	s.repo.AssertExpectations(t)
+}
+
+func (s *HandlerTestSuite) Test_topUpWalletHandler() {
+	t := s.T()
+
+	deps := dependencies{
+		FarmService: s.service,
+	}
+
+	t.Run("when the amount is not positive", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/wallet/topups", bytes.NewBufferString(`{"amount": 0}`))
+		r = r.WithContext(context.WithValue(r.Context(), "token", uint(9)))
+		w := httptest.NewRecorder()
+
+		topUpWalletHandler(deps).ServeHTTP(w, r)
+		exp, _ := json.Marshal(invalidField("amount", domain.ErrInvalidAmount.Error()))
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+		assert.Equal(t, string(exp), w.Body.String())
+	})
+
+	t.Run("when the top-up is created", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/wallet/topups", bytes.NewBufferString(`{"amount": {"amount": "1000", "currency": "INR"}}`))
+		r = r.WithContext(context.WithValue(r.Context(), "token", uint(9)))
+		w := httptest.NewRecorder()
+		respBody := domain.WalletTopup{Id: 2, FarmerId: 9, Provider: "fake", ProviderOrderId: "order_fake_1", Amount: domain.Rupees(1000), Status: domain.TopupCreated}
+		s.service.On("TopUpWallet", r.Context(), domain.NewTopupRequest{FarmerId: 9, Amount: domain.Rupees(1000)}).Return(respBody, nil).Once()
+
+		topUpWalletHandler(deps).ServeHTTP(w, r)
+		exp, _ := json.Marshal(respBody)
+		assert.Equal(t, http.StatusCreated, w.Result().StatusCode)
+		assert.Equal(t, string(exp), w.Body.String())
+	})
+}
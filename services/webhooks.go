@@ -0,0 +1,340 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/domain"
+	"FarmEasy/validate"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	webhookBatch      = 100
+	deliveryLogSize   = 100
+	webhookTimeout    = 10 * time.Second
+	webhookSecretSize = 32
+	// a failed delivery is retried after a minute, then after twice as long
+	// each time, until it has been tried maxWebhookAttempts times, about four
+	// hours in all
+	maxWebhookAttempts = 8
+	webhookRetryDelay  = time.Minute
+)
+
+// CreateAPIClient registers a partner application for the farmer, with a new
+// secret to verify its webhooks with.
+func (s *FarmService) CreateAPIClient(ctx context.Context, request domain.NewAPIClientRequest) (client domain.APIClient, err error) {
+
+	secret := make([]byte, webhookSecretSize)
+	if _, err = rand.Read(secret); err != nil {
+		return
+	}
+
+	client = domain.APIClient{
+		FarmerId: request.FarmerId,
+		Name:     request.Name,
+		Secret:   hex.EncodeToString(secret),
+	}
+	err = s.store.AddAPIClient(ctx, &client)
+	return
+}
+
+func (s *FarmService) GetAPIClients(ctx context.Context, farmerId uint) (clients []domain.APIClient, err error) {
+
+	clients, err = s.store.GetAPIClients(ctx, farmerId)
+	if err != nil {
+		return
+	}
+	if clients == nil {
+		clients = []domain.APIClient{}
+	}
+	return
+}
+
+// AddWebhook subscribes a webhook of one of the farmer's api clients to the
+// events. Its url must use https outside development and must not point
+// inside the platform's network.
+func (s *FarmService) AddWebhook(ctx context.Context, request domain.NewWebhookRequest) (webhook domain.WebhookSubscription, err error) {
+
+	if _, err = s.apiClient(ctx, request.ClientId, request.FarmerId); err != nil {
+		return
+	}
+
+	if err = s.webhooks.CheckURL(ctx, request.URL); err != nil {
+		var errs validate.Errors
+		errs.Add("url", err.Error())
+		return webhook, invalidFields(errs)
+	}
+
+	webhook = domain.WebhookSubscription{
+		ClientId: request.ClientId,
+		FarmerId: request.FarmerId,
+		URL:      request.URL,
+		Events:   request.Events,
+	}
+	err = s.store.AddWebhookSubscription(ctx, &webhook)
+	return
+}
+
+func (s *FarmService) GetWebhooks(ctx context.Context, clientId uint, farmerId uint) (webhooks []domain.WebhookSubscription, err error) {
+
+	if _, err = s.apiClient(ctx, clientId, farmerId); err != nil {
+		return
+	}
+
+	webhooks, err = s.store.GetWebhookSubscriptions(ctx, clientId)
+	if err != nil {
+		return
+	}
+	if webhooks == nil {
+		webhooks = []domain.WebhookSubscription{}
+	}
+	return
+}
+
+// DeleteWebhook stops deliveries to the webhook. Its delivery log stays
+// available.
+func (s *FarmService) DeleteWebhook(ctx context.Context, webhookId uint, farmerId uint) (err error) {
+
+	err = s.store.DeactivateWebhookSubscription(ctx, webhookId, farmerId)
+	if err == sql.ErrNoRows {
+		err = ErrWebhookNotFound
+	}
+	return
+}
+
+// GetWebhookDeliveries returns the latest deliveries to a webhook of the
+// farmer's, newest first.
+func (s *FarmService) GetWebhookDeliveries(ctx context.Context, webhookId uint, farmerId uint) (deliveries []domain.WebhookDelivery, err error) {
+
+	webhook, err := s.store.GetWebhookSubscription(ctx, webhookId)
+	if err == sql.ErrNoRows || (err == nil && webhook.FarmerId != farmerId) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return
+	}
+
+	deliveries, err = s.store.GetWebhookDeliveries(ctx, webhookId, deliveryLogSize)
+	if err != nil {
+		return
+	}
+	if deliveries == nil {
+		deliveries = []domain.WebhookDelivery{}
+	}
+	return
+}
+
+// ReplayWebhookDelivery sends the payload of a delivery again, as a new
+// delivery, whatever became of the first.
+func (s *FarmService) ReplayWebhookDelivery(ctx context.Context, deliveryId uint, farmerId uint) (replay domain.WebhookDelivery, err error) {
+
+	replay, err = s.store.ReplayWebhookDelivery(ctx, deliveryId, farmerId)
+	if err == sql.ErrNoRows {
+		err = ErrWebhookDeliveryNotFound
+	}
+	return
+}
+
+// DeliverWebhooks posts every webhook delivery that is due. It returns how
+// many were delivered.
+func (s *FarmService) DeliverWebhooks(ctx context.Context) (delivered int, err error) {
+
+	due, err := s.store.GetDueWebhookDeliveries(ctx, webhookBatch)
+	if err != nil {
+		return
+	}
+
+	for _, next := range due {
+		delivery := next.WebhookDelivery
+		delivery.Attempts++
+
+		status, postErr := s.webhooks.Post(ctx, next.URL, next.Secret, delivery.Id, delivery.Kind, delivery.Payload)
+		delivery.ResponseStatus = status
+
+		switch {
+		case postErr == nil:
+			delivery.Status = domain.WebhookDelivered
+			delivery.LastError = ""
+			delivered++
+		case delivery.Attempts >= maxWebhookAttempts:
+			delivery.Status = domain.WebhookFailed
+			delivery.LastError = postErr.Error()
+		default:
+			delivery.NextAttemptAt = time.Now().Add(webhookRetryDelay << (delivery.Attempts - 1))
+			delivery.LastError = postErr.Error()
+		}
+
+		err = s.store.UpdateWebhookDelivery(ctx, delivery)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// apiClient returns the api client if it is the farmer's.
+func (s *FarmService) apiClient(ctx context.Context, clientId uint, farmerId uint) (client domain.APIClient, err error) {
+
+	client, err = s.store.GetAPIClient(ctx, clientId)
+	if err == sql.ErrNoRows || (err == nil && client.FarmerId != farmerId) {
+		return domain.APIClient{}, ErrAPIClientNotFound
+	}
+	return
+}
+
+func createAPIClientHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var request domain.NewAPIClientRequest
+
+		if err := decode(r, &request); err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		request.FarmerId = r.Context().Value("token").(uint)
+
+		client, err := deps.FarmService.CreateAPIClient(r.Context(), request)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusCreated, client)
+	}
+}
+
+func getAPIClientsHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		farmerId := r.Context().Value("token").(uint)
+
+		clients, err := deps.FarmService.GetAPIClients(r.Context(), farmerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, clients)
+	}
+}
+
+func addWebhookHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		clientId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid api client id"))
+			return
+		}
+
+		var request domain.NewWebhookRequest
+
+		if err = decode(r, &request); err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		request.ClientId = uint(clientId)
+		request.FarmerId = r.Context().Value("token").(uint)
+
+		webhook, err := deps.FarmService.AddWebhook(r.Context(), request)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusCreated, webhook)
+	}
+}
+
+func getWebhooksHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		clientId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid api client id"))
+			return
+		}
+
+		farmerId := r.Context().Value("token").(uint)
+
+		webhooks, err := deps.FarmService.GetWebhooks(r.Context(), uint(clientId), farmerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, webhooks)
+	}
+}
+
+func deleteWebhookHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		webhookId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid webhook id"))
+			return
+		}
+
+		farmerId := r.Context().Value("token").(uint)
+
+		if err = deps.FarmService.DeleteWebhook(r.Context(), uint(webhookId), farmerId); err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, api.Message{Msg: "webhook deleted"})
+	}
+}
+
+func getWebhookDeliveriesHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		webhookId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid webhook id"))
+			return
+		}
+
+		farmerId := r.Context().Value("token").(uint)
+
+		deliveries, err := deps.FarmService.GetWebhookDeliveries(r.Context(), uint(webhookId), farmerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusOK, deliveries)
+	}
+}
+
+func replayWebhookDeliveryHandler(deps dependencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		deliveryId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			fail(w, r, api.BadRequest("invalid delivery id"))
+			return
+		}
+
+		farmerId := r.Context().Value("token").(uint)
+
+		replay, err := deps.FarmService.ReplayWebhookDelivery(r.Context(), uint(deliveryId), farmerId)
+		if err != nil {
+			fail(w, r, err)
+			return
+		}
+
+		api.Response(w, http.StatusCreated, replay)
+	}
+}
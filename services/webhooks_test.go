@@ -0,0 +1,183 @@
+package services
+
+import (
+	"FarmEasy/api"
+	"FarmEasy/domain"
+	"FarmEasy/webhook"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *ServiceTestSuite) TestFarmService_DeliverWebhooks() {
+	t := s.T()
+	ctx := context.TODO()
+
+	var signatures []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !webhook.Verify("client secret", body, r.Header.Get(webhook.SignatureHeader)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		signatures = append(signatures, r.Header.Get(webhook.DeliveryHeader))
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	due := func(id uint, path string, attempts uint) domain.DueWebhookDelivery {
+		return domain.DueWebhookDelivery{
+			WebhookDelivery: domain.WebhookDelivery{Id: id, SubscriptionId: 5, Kind: domain.EventBookingRequested, Payload: json.RawMessage(`{"id":4,"type":"booking.requested"}`), Status: domain.WebhookPending, Attempts: attempts},
+			URL:             server.URL + path,
+			Secret:          "client secret",
+		}
+	}
+
+	s.repo.On("GetDueWebhookDeliveries", ctx, uint(webhookBatch)).Return([]domain.DueWebhookDelivery{
+		due(1, "/hooks", 0),
+		due(2, "/down", 0),
+		due(3, "/down", maxWebhookAttempts-1),
+	}, nil).Once()
+	s.repo.On("UpdateWebhookDelivery", ctx, mock.MatchedBy(func(d domain.WebhookDelivery) bool {
+		return d.Id == 1 && d.Status == domain.WebhookDelivered && d.Attempts == 1 && d.ResponseStatus == http.StatusOK
+	})).Return(nil).Once()
+	s.repo.On("UpdateWebhookDelivery", ctx, mock.MatchedBy(func(d domain.WebhookDelivery) bool {
+		// the first failure is retried after a minute
+		wait := time.Until(d.NextAttemptAt)
+		return d.Id == 2 && d.Status == domain.WebhookPending && d.ResponseStatus == http.StatusServiceUnavailable && d.LastError == "endpoint answered 503" && wait > 50*time.Second && wait <= time.Minute
+	})).Return(nil).Once()
+	s.repo.On("UpdateWebhookDelivery", ctx, mock.MatchedBy(func(d domain.WebhookDelivery) bool {
+		return d.Id == 3 && d.Status == domain.WebhookFailed && d.Attempts == maxWebhookAttempts
+	})).Return(nil).Once()
+
+	// the test endpoint runs on loopback, which partners' webhooks may not
+	s.service.(*FarmService).webhooks = webhook.NewUnrestrictedSender(time.Second)
+
+	delivered, err := s.service.DeliverWebhooks(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, []string{"1", "2", "3"}, signatures)
+}
+
+func (s *ServiceTestSuite) TestFarmService_AddWebhook() {
+	t := s.T()
+	ctx := context.TODO()
+
+	request := domain.NewWebhookRequest{ClientId: 3, FarmerId: 9, URL: "https://203.0.113.10/hooks", Events: []string{domain.EventBookingRequested}}
+
+	t.Run("when the api client is another farmer's", func(t *testing.T) {
+		s.repo.On("GetAPIClient", ctx, uint(3)).Return(domain.APIClient{Id: 3, FarmerId: 10}, nil).Once()
+
+		_, err := s.service.AddWebhook(ctx, request)
+		assert.Equal(t, ErrAPIClientNotFound, err)
+	})
+
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"when the url points at the metadata service", "https://169.254.169.254/latest/meta-data", "url: must not point at a loopback, private or link-local address"},
+		{"when the url points at this machine", "https://127.0.0.1:8002/hooks", "url: must not point at a loopback, private or link-local address"},
+		{"when the url is plain http", "http://203.0.113.10/hooks", "url: must be an https URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.repo.On("GetAPIClient", ctx, uint(3)).Return(domain.APIClient{Id: 3, FarmerId: 9}, nil).Once()
+
+			internal := request
+			internal.URL = tt.url
+			_, err := s.service.AddWebhook(ctx, internal)
+			var apiErr *api.Error
+			require.True(t, errors.As(err, &apiErr))
+			assert.Equal(t, http.StatusUnprocessableEntity, apiErr.Status)
+			assert.Equal(t, tt.want, apiErr.Message)
+			assert.Equal(t, []api.FieldError{{Field: "url", Message: strings.TrimPrefix(tt.want, "url: ")}}, apiErr.Fields)
+		})
+	}
+
+	t.Run("when it is the farmer's", func(t *testing.T) {
+		s.repo.On("GetAPIClient", ctx, uint(3)).Return(domain.APIClient{Id: 3, FarmerId: 9}, nil).Once()
+		s.repo.On("AddWebhookSubscription", ctx, mock.MatchedBy(func(w *domain.WebhookSubscription) bool {
+			return w.ClientId == 3 && w.URL == request.URL
+		})).Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.WebhookSubscription).Id = 5
+		}).Return(nil).Once()
+
+		got, err := s.service.AddWebhook(ctx, request)
+		require.NoError(t, err)
+		assert.Equal(t, uint(5), got.Id)
+		assert.Equal(t, request.Events, got.Events)
+	})
+}
+
+func (s *ServiceTestSuite) TestFarmService_GetWebhookDeliveries() {
+	ctx := context.TODO()
+
+	s.repo.On("GetWebhookSubscription", ctx, uint(5)).Return(domain.WebhookSubscription{Id: 5, FarmerId: 10}, nil).Once()
+	_, err := s.service.GetWebhookDeliveries(ctx, 5, 9)
+	assert.Equal(s.T(), ErrWebhookNotFound, err)
+
+	s.repo.On("ReplayWebhookDelivery", ctx, uint(7), uint(9)).Return(domain.WebhookDelivery{}, sql.ErrNoRows).Once()
+	_, err = s.service.ReplayWebhookDelivery(ctx, 7, 9)
+	assert.Equal(s.T(), ErrWebhookDeliveryNotFound, err)
+}
+
+func (s *ServiceTestSuite) TestFarmService_CreateAPIClient() {
+	t := s.T()
+	ctx := context.TODO()
+
+	s.repo.On("AddAPIClient", ctx, mock.MatchedBy(func(c *domain.APIClient) bool {
+		return c.FarmerId == 9 && c.Name == "Coop app" && len(c.Secret) == 2*webhookSecretSize
+	})).Return(nil).Once()
+
+	got, err := s.service.CreateAPIClient(ctx, domain.NewAPIClientRequest{FarmerId: 9, Name: "Coop app"})
+	require.NoError(t, err)
+	assert.Len(t, got.Secret, 2*webhookSecretSize)
+}
+
+func (s *HandlerTestSuite) Test_addWebhookHandler() {
+	t := s.T()
+
+	deps := dependencies{
+		FarmService: s.service,
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"when the url is not http", `{"url": "ftp://coop.example", "events": ["booking.requested"]}`, `"field":"url","message":"must be an http or https URL"`},
+		{"when there are no events", `{"url": "https://coop.example/hooks"}`, `"field":"events","message":"is required"`},
+		{"when an event is unknown", `{"url": "https://coop.example/hooks", "events": ["booking.reminder"]}`, `"field":"events[0]","message":"must be one of [booking.requested booking.accepted booking.cancelled booking.no_show invoice.issued machine.created]"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api-clients/3/webhooks", strings.NewReader(tt.body))
+			r = mux.SetURLVars(r, map[string]string{"id": "3"})
+			r = r.WithContext(context.WithValue(r.Context(), "token", uint(9)))
+			w := httptest.NewRecorder()
+
+			addWebhookHandler(deps).ServeHTTP(w, r)
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+			assert.Contains(t, w.Body.String(), tt.want)
+		})
+	}
+}
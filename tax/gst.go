@@ -0,0 +1,104 @@
+// Package tax computes Goods and Services Tax on invoices. Rates are basis
+// points, so 18% is 1800.
+package tax
+
+import (
+	"FarmEasy/domain"
+	"errors"
+	"regexp"
+	"strconv"
+)
+
+const (
+	IntraState = "intra_state"
+	InterState = "inter_state"
+)
+
+var (
+	ErrInvalidGSTIN  = errors.New("invalid GSTIN")
+	ErrInvalidState  = errors.New("invalid state code")
+	ErrStateMismatch = errors.New("GSTIN does not belong to the given state")
+
+	gstinPattern = regexp.MustCompile(`^[0-9]{2}[A-Z]{5}[0-9]{4}[A-Z][1-9A-Z]Z[0-9A-Z]$`)
+	statePattern = regexp.MustCompile(`^[0-9]{2}$`)
+)
+
+// Breakdown is the tax charged on a taxable value. Intra-state supplies pay
+// CGST and SGST, splitting the tax at the full rate between them; inter-state
+// supplies pay IGST.
+type Breakdown struct {
+	SupplyType string
+	CGST       domain.Money
+	SGST       domain.Money
+	IGST       domain.Money
+}
+
+func (b Breakdown) Total() domain.Money {
+	return b.CGST.Add(b.SGST).Add(b.IGST)
+}
+
+func (b *Breakdown) Add(other Breakdown) {
+	b.CGST = b.CGST.Add(other.CGST)
+	b.SGST = b.SGST.Add(other.SGST)
+	b.IGST = b.IGST.Add(other.IGST)
+}
+
+// SupplyType tells whether a rental from an owner in ownerState to a renter in
+// renterState is intra-state or inter-state. When the renter's state is not on
+// record the place of supply is the owner's location, which makes it
+// intra-state.
+func SupplyType(ownerState string, renterState string) string {
+	if renterState == "" || ownerState == "" || ownerState == renterState {
+		return IntraState
+	}
+	return InterState
+}
+
+// Compute returns the tax on the taxable value at rate basis points. The tax is
+// rounded once at the full rate, so an odd rate or an odd paisa is not lost
+// when it is split: CGST takes half of it and SGST the rest.
+func Compute(taxable domain.Money, rate uint, supplyType string) (b Breakdown) {
+	zero := domain.NewMoney(0, taxable.Currency)
+	b = Breakdown{SupplyType: supplyType, CGST: zero, SGST: zero, IGST: zero}
+	if supplyType == InterState {
+		b.IGST = taxable.Percent(int64(rate))
+		return
+	}
+	total := taxable.Percent(int64(rate))
+	b.CGST = domain.NewMoney(total.Minor/2, total.Currency)
+	b.SGST = total.Sub(b.CGST)
+	return
+}
+
+// ValidateGSTIN checks the format of a GSTIN and that it was issued in the
+// state, when one is given.
+func ValidateGSTIN(gstin string, state string) (err error) {
+	if !gstinPattern.MatchString(gstin) {
+		return ErrInvalidGSTIN
+	}
+	if state != "" && StateOf(gstin) != state {
+		return ErrStateMismatch
+	}
+	return
+}
+
+func ValidateState(state string) (err error) {
+	if !statePattern.MatchString(state) {
+		err = ErrInvalidState
+	}
+	return
+}
+
+// StateOf returns the two digit state code a GSTIN was issued in.
+func StateOf(gstin string) string {
+	if len(gstin) < 2 {
+		return ""
+	}
+	return gstin[:2]
+}
+
+// FormatRate renders basis points as a percentage, e.g. 250 as "2.5%".
+func FormatRate(rate uint) string {
+	percent := strconv.FormatFloat(float64(rate)/100, 'f', -1, 64)
+	return percent + "%"
+}
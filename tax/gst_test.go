@@ -0,0 +1,55 @@
+package tax
+
+import (
+	"FarmEasy/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupplyType(t *testing.T) {
+	assert.Equal(t, IntraState, SupplyType("27", "27"))
+	assert.Equal(t, InterState, SupplyType("27", "29"))
+	assert.Equal(t, IntraState, SupplyType("27", ""))
+}
+
+func TestCompute(t *testing.T) {
+	inr := func(paise int64) domain.Money { return domain.NewMoney(paise, "INR") }
+	tests := []struct {
+		name       string
+		taxable    domain.Money
+		rate       uint
+		supplyType string
+		want       Breakdown
+	}{
+		{"intra state at 18%", inr(60000), 1800, IntraState, Breakdown{SupplyType: IntraState, CGST: inr(5400), SGST: inr(5400), IGST: inr(0)}},
+		{"inter state at 18%", inr(60000), 1800, InterState, Breakdown{SupplyType: InterState, CGST: inr(0), SGST: inr(0), IGST: inr(10800)}},
+		{"splits an odd paisa", inr(12345), 1200, IntraState, Breakdown{SupplyType: IntraState, CGST: inr(740), SGST: inr(741), IGST: inr(0)}},
+		{"intra state at an odd rate", inr(100000), 25, IntraState, Breakdown{SupplyType: IntraState, CGST: inr(125), SGST: inr(125), IGST: inr(0)}},
+		{"rounds half up", inr(125), 200, InterState, Breakdown{SupplyType: InterState, CGST: inr(0), SGST: inr(0), IGST: inr(3)}},
+		{"exempt", inr(60000), 0, IntraState, Breakdown{SupplyType: IntraState, CGST: inr(0), SGST: inr(0), IGST: inr(0)}},
+		{"negative adjustments", inr(-10050), 1800, InterState, Breakdown{SupplyType: InterState, CGST: inr(0), SGST: inr(0), IGST: inr(-1809)}},
+		{"negative adjustments within the state", inr(-10050), 1800, IntraState, Breakdown{SupplyType: IntraState, CGST: inr(-904), SGST: inr(-905), IGST: inr(0)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Compute(tt.taxable, tt.rate, tt.supplyType)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want.CGST.Add(tt.want.SGST).Add(tt.want.IGST), got.Total())
+		})
+	}
+}
+
+func TestValidateGSTIN(t *testing.T) {
+	assert.NoError(t, ValidateGSTIN("27AAPFU0939F1ZV", ""))
+	assert.NoError(t, ValidateGSTIN("27AAPFU0939F1ZV", "27"))
+	assert.Equal(t, ErrStateMismatch, ValidateGSTIN("27AAPFU0939F1ZV", "29"))
+	assert.Equal(t, ErrInvalidGSTIN, ValidateGSTIN("27AAPFU0939F1Z", ""))
+	assert.Equal(t, ErrInvalidGSTIN, ValidateGSTIN("27aapfu0939f1zv", ""))
+}
+
+func TestFormatRate(t *testing.T) {
+	assert.Equal(t, "9%", FormatRate(900))
+	assert.Equal(t, "2.5%", FormatRate(250))
+	assert.Equal(t, "0%", FormatRate(0))
+}
@@ -0,0 +1,285 @@
+// Package validate checks request structs against the rules in the validate
+// tags of their fields, and collects every field at fault.
+//
+// A tag is a comma separated list of rules:
+//
+//	required   the field is not its zero value, or an empty slice
+//	min=N      a number is at least N; a string or slice has at least N
+//	           characters or elements
+//	max=N      as min, at most N
+//	oneof=a b  a string is one of the words
+//	email      a string is an email address
+//	phone      a string is a 10 digit phone number
+//	date       a string is a date as 2006-01-02
+//	url        a string is an http or https URL
+//	slot       a number is a slot of the day, 1 to 24
+//
+// Rules other than required pass zero values, and apply to each element of
+// a slice. Nested structs and slices of structs are checked too, and a field
+// whose value has a Validate() error method is checked by it when it is not
+// zero. A struct can add rules between its fields by implementing Checker.
+package validate
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	tagName    = "validate"
+	dateLayout = "2006-01-02"
+	firstSlot  = 1
+	lastSlot   = 24
+)
+
+var (
+	emailPattern = regexp.MustCompile(`^\w+([\.-]?\w+)*@\w+([\.-]?\w+)*(\.\w{2,3})+$`)
+	phonePattern = regexp.MustCompile(`^[0-9]{10}$`)
+	datePattern  = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}$`)
+)
+
+// FieldError is what is wrong with one field, named by its json path, such
+// as lines[1].date.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// Errors are the fields at fault in a request.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Field + ": " + fieldErr.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Add records that field is at fault.
+func (e *Errors) Add(field string, message string) {
+	*e = append(*e, FieldError{Field: field, Message: message})
+}
+
+// Checker is a struct with rules that involve more than one of its fields.
+// Check reports the fields at fault by their json names.
+type Checker interface {
+	Check() Errors
+}
+
+type validator interface {
+	Validate() error
+}
+
+// Struct checks v, a struct, a pointer to one or a slice of them, and
+// returns the fields at fault, or nil.
+func Struct(v interface{}) Errors {
+	var errs Errors
+	check(&errs, "", reflect.ValueOf(v))
+	return errs
+}
+
+func check(errs *Errors, path string, value reflect.Value) {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !value.IsNil() {
+			check(errs, path, value.Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			check(errs, fmt.Sprintf("%s[%d]", path, i), value.Index(i))
+		}
+	case reflect.Struct:
+		checkStruct(errs, path, value)
+	}
+}
+
+func checkStruct(errs *Errors, path string, value reflect.Value) {
+	t := value.Type()
+	if t == reflect.TypeOf(time.Time{}) {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			check(errs, path, value.Field(i))
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fieldPath := join(path, name)
+		fieldValue := value.Field(i)
+
+		if rules := field.Tag.Get(tagName); rules != "" {
+			if message := checkRules(rules, fieldValue); message != "" {
+				errs.Add(fieldPath, message)
+				continue
+			}
+		}
+
+		if v, ok := interfaceOf(fieldValue).(validator); ok && !fieldValue.IsZero() {
+			if err := v.Validate(); err != nil {
+				errs.Add(fieldPath, err.Error())
+			}
+			continue
+		}
+		check(errs, fieldPath, fieldValue)
+	}
+
+	if checker, ok := interfaceOf(value).(Checker); ok {
+		for _, fieldErr := range checker.Check() {
+			errs.Add(join(path, fieldErr.Field), fieldErr.Message)
+		}
+	}
+}
+
+// checkRules returns what is wrong with the value, or "" if nothing is.
+func checkRules(rules string, value reflect.Value) string {
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+
+		if name == "required" {
+			if isEmpty(value) {
+				return "is required"
+			}
+			continue
+		}
+		if isEmpty(value) {
+			return ""
+		}
+
+		var message string
+		switch name {
+		case "min", "max":
+			message = checkBound(name, arg, value)
+		default:
+			message = checkEach(name, arg, value)
+		}
+		if message != "" {
+			return message
+		}
+	}
+	return ""
+}
+
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	case reflect.String:
+		return strings.TrimSpace(value.String()) == ""
+	default:
+		return value.IsZero()
+	}
+}
+
+func checkBound(name string, arg string, value reflect.Value) string {
+	bound, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validate: bad %s=%s", name, arg))
+	}
+
+	var size float64
+	var unit string
+	switch value.Kind() {
+	case reflect.Ptr:
+		return checkBound(name, arg, value.Elem())
+	case reflect.String:
+		size, unit = float64(utf8.RuneCountInString(value.String())), " characters"
+	case reflect.Slice:
+		size, unit = float64(value.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		size = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		size = value.Float()
+	}
+
+	if name == "min" && size < bound {
+		return "must be at least " + arg + unit
+	}
+	if name == "max" && size > bound {
+		return "must be at most " + arg + unit
+	}
+	return ""
+}
+
+// checkEach applies a rule to the value, or to each element of a slice.
+func checkEach(name string, arg string, value reflect.Value) string {
+	if value.Kind() == reflect.Slice {
+		for i := 0; i < value.Len(); i++ {
+			if message := checkEach(name, arg, value.Index(i)); message != "" {
+				return message
+			}
+		}
+		return ""
+	}
+	if value.Kind() == reflect.Ptr {
+		return checkEach(name, arg, value.Elem())
+	}
+
+	switch name {
+	case "oneof":
+		for _, word := range strings.Fields(arg) {
+			if value.String() == word {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of %v", strings.Fields(arg))
+	case "email":
+		if !emailPattern.MatchString(value.String()) {
+			return "must be an email address"
+		}
+	case "phone":
+		if !phonePattern.MatchString(value.String()) {
+			return "must be a 10 digit phone number"
+		}
+	case "date":
+		if _, err := time.Parse(dateLayout, value.String()); err != nil || !datePattern.MatchString(value.String()) {
+			return "must be a date as YYYY-MM-DD"
+		}
+	case "url":
+		if u, err := url.Parse(value.String()); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "must be an http or https URL"
+		}
+	case "slot":
+		if slot := value.Uint(); slot < firstSlot || slot > lastSlot {
+			return fmt.Sprintf("must be a slot from %d to %d", firstSlot, lastSlot)
+		}
+	default:
+		panic("validate: unknown rule " + name)
+	}
+	return ""
+}
+
+// interfaceOf is the value as an interface, or nil for the fields of an
+// unexported embedded struct, which reflect does not hand out.
+func interfaceOf(value reflect.Value) interface{} {
+	if !value.CanInterface() {
+		return nil
+	}
+	return value.Interface()
+}
+
+func join(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
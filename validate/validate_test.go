@@ -0,0 +1,107 @@
+package validate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type amount int
+
+func (a amount) Validate() error {
+	if a < 0 {
+		return errors.New("invalid amount")
+	}
+	return nil
+}
+
+type line struct {
+	Date  string `json:"date" validate:"required,date"`
+	Slots []uint `json:"slots" validate:"required,slot"`
+}
+
+type base struct {
+	Email string `json:"email" validate:"email"`
+}
+
+type request struct {
+	base
+	Name     string   `json:"name" validate:"required,max=5"`
+	Kind     string   `json:"kind,omitempty" validate:"oneof=a b"`
+	Phone    string   `json:"phone" validate:"phone"`
+	Rating   uint     `json:"rating" validate:"min=1,max=5"`
+	Latitude *float64 `json:"latitude" validate:"min=-90,max=90"`
+	Links    []string `json:"links" validate:"url"`
+	Price    amount   `json:"price"`
+	Lines    []line   `json:"lines" validate:"required"`
+	Start    uint     `json:"start"`
+	End      uint     `json:"end"`
+	Secret   string   `json:"-" validate:"required"`
+}
+
+func (r request) Check() (errs Errors) {
+	if r.End < r.Start {
+		errs.Add("end", "must not be before start")
+	}
+	return
+}
+
+func TestStruct(t *testing.T) {
+	latitude := 91.0
+
+	tests := []struct {
+		name    string
+		request request
+		want    Errors
+	}{
+		{
+			name:    "when the request is valid",
+			request: request{Name: "plow", Kind: "a", Phone: "9876543210", Rating: 5, Links: []string{"https://photos.example/1.jpg"}, Lines: []line{{Date: "2026-11-07", Slots: []uint{7, 8}}}},
+		},
+		{
+			name:    "when required fields are missing",
+			request: request{Name: "  "},
+			want:    Errors{{"name", "is required"}, {"lines", "is required"}},
+		},
+		{
+			name: "when every rule is broken",
+			request: request{
+				base:     base{Email: "john@"},
+				Name:     "harvester",
+				Kind:     "c",
+				Phone:    "12345",
+				Rating:   6,
+				Latitude: &latitude,
+				Links:    []string{"https://photos.example/1.jpg", "ftp://photos.example/2.jpg"},
+				Price:    -1,
+				Lines:    []line{{Date: "2026-11-07", Slots: []uint{7}}, {Date: "2026-02-30", Slots: []uint{0, 25}}},
+				Start:    9,
+				End:      8,
+			},
+			want: Errors{
+				{"email", "must be an email address"},
+				{"name", "must be at most 5 characters"},
+				{"kind", "must be one of [a b]"},
+				{"phone", "must be a 10 digit phone number"},
+				{"rating", "must be at most 5"},
+				{"latitude", "must be at most 90"},
+				{"links", "must be an http or https URL"},
+				{"price", "invalid amount"},
+				{"lines[1].date", "must be a date as YYYY-MM-DD"},
+				{"lines[1].slots", "must be a slot from 1 to 24"},
+				{"end", "must not be before start"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Struct(&tt.request))
+		})
+	}
+}
+
+func TestErrors_Error(t *testing.T) {
+	errs := Errors{{"name", "is required"}, {"lines[1].date", "must be a date as YYYY-MM-DD"}}
+	assert.Equal(t, "name: is required; lines[1].date: must be a date as YYYY-MM-DD", errs.Error())
+}
@@ -0,0 +1,214 @@
+// Package webhook posts signed events to the endpoints partners subscribe.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	// SignatureHeader carries the hex HMAC-SHA256 of the body with the api
+	// client's secret.
+	SignatureHeader = "X-FarmEasy-Signature"
+	EventHeader     = "X-FarmEasy-Event"
+	// DeliveryHeader identifies the delivery; retries of it send the same id.
+	DeliveryHeader = "X-FarmEasy-Delivery"
+)
+
+// Sign returns the hex HMAC-SHA256 of the payload with the secret.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether the signature is the payload's signature with the
+// secret, for partners checking what they receive.
+func Verify(secret string, payload []byte, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+var (
+	ErrInvalidURL    = errors.New("must be an http or https URL")
+	ErrInsecureURL   = errors.New("must be an https URL")
+	ErrPrivateTarget = errors.New("must not point at a loopback, private or link-local address")
+	ErrUnresolvable  = errors.New("host could not be resolved")
+)
+
+// nonPublic are the ranges, besides those net.IP classifies, that no partner
+// endpoint lives in: "this network" and carrier-grade NAT.
+var nonPublic = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("100.64.0.0/10"),
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+// publicIP reports whether ip is an address on the internet, rather than one
+// of this machine, its network or its cloud provider's metadata service
+// (169.254.169.254 is link-local).
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range nonPublic {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// Sender posts webhooks over HTTP. Partners choose where it posts, so it
+// only connects to public addresses: the address is checked when a webhook is
+// subscribed and again on every connection, after the host is resolved, so
+// a name that later resolves inside the network is refused too.
+type Sender struct {
+	client       *http.Client
+	requireHTTPS bool
+	// allowed decides which addresses may be connected to, and lookup
+	// resolves hosts; tests replace lookup to resolve names of their own.
+	allowed func(net.IP) bool
+	lookup  func(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// NewSender returns a sender whose requests time out after timeout. Outside
+// development requireHTTPS is set, and plain http endpoints are refused.
+func NewSender(timeout time.Duration, requireHTTPS bool) *Sender {
+	return newSender(timeout, requireHTTPS, publicIP)
+}
+
+// NewUnrestrictedSender returns a sender that posts to any address, over
+// http or https, for tests whose endpoints run on this machine. It must not
+// post to urls a partner chose.
+func NewUnrestrictedSender(timeout time.Duration) *Sender {
+	return newSender(timeout, false, func(net.IP) bool { return true })
+}
+
+func newSender(timeout time.Duration, requireHTTPS bool, allowed func(net.IP) bool) *Sender {
+	s := &Sender{
+		requireHTTPS: requireHTTPS,
+		allowed:      allowed,
+		lookup:       net.DefaultResolver.LookupIPAddr,
+	}
+
+	dialer := &net.Dialer{Timeout: timeout, Control: s.checkDial}
+	s.client = &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+		},
+		// a redirect is answered like any other non-2xx response
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return s
+}
+
+// checkDial refuses connections to addresses that are not allowed. It runs
+// once the host is resolved, on the address actually connected to.
+func (s *Sender) checkDial(network string, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !s.allowed(ip) {
+		return ErrPrivateTarget
+	}
+	return nil
+}
+
+// parseURL parses a webhook url and checks its scheme.
+func (s *Sender) parseURL(rawURL string) (u *url.URL, err error) {
+	u, err = url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, ErrInvalidURL
+	}
+	if s.requireHTTPS && u.Scheme != "https" {
+		return nil, ErrInsecureURL
+	}
+	return
+}
+
+// CheckURL checks that a webhook may be subscribed to the url: it uses https
+// where that is required and every address its host resolves to is public.
+func (s *Sender) CheckURL(ctx context.Context, rawURL string) (err error) {
+
+	u, err := s.parseURL(rawURL)
+	if err != nil {
+		return
+	}
+
+	if ip := net.ParseIP(u.Hostname()); ip != nil {
+		if !s.allowed(ip) {
+			return ErrPrivateTarget
+		}
+		return nil
+	}
+
+	addrs, err := s.lookup(ctx, u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return ErrUnresolvable
+	}
+	for _, addr := range addrs {
+		if !s.allowed(addr.IP) {
+			return ErrPrivateTarget
+		}
+	}
+	return nil
+}
+
+// Post signs the payload and posts it to the url. Any response other than a
+// 2xx is an error, returned with the status code the endpoint answered.
+func (s *Sender) Post(ctx context.Context, url string, secret string, deliveryId uint, kind string, payload []byte) (status int, err error) {
+
+	if _, err = s.parseURL(url); err != nil {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(secret, payload))
+	req.Header.Set(EventHeader, kind)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(deliveryId), 10))
+
+	rsp, err := s.client.Do(req)
+	if err != nil {
+		return
+	}
+	rsp.Body.Close()
+
+	status = rsp.StatusCode
+	if status < 200 || status > 299 {
+		err = fmt.Errorf("endpoint answered %d", status)
+	}
+	return
+}
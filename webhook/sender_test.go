@@ -0,0 +1,118 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSender_Post(t *testing.T) {
+	payload := []byte(`{"id":4,"type":"booking.requested","data":{"booking_id":11,"machine_id":2}}`)
+
+	var received *http.Request
+	var body []byte
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sender := NewUnrestrictedSender(time.Second)
+
+	got, err := sender.Post(context.TODO(), server.URL, "client secret", 7, "booking.requested", payload)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, got)
+	assert.Equal(t, payload, body)
+	assert.Equal(t, "booking.requested", received.Header.Get(EventHeader))
+	assert.Equal(t, "7", received.Header.Get(DeliveryHeader))
+	assert.True(t, Verify("client secret", body, received.Header.Get(SignatureHeader)))
+	assert.False(t, Verify("other secret", body, received.Header.Get(SignatureHeader)))
+
+	status = http.StatusServiceUnavailable
+	got, err = sender.Post(context.TODO(), server.URL, "client secret", 7, "booking.requested", payload)
+	assert.EqualError(t, err, "endpoint answered 503")
+	assert.Equal(t, http.StatusServiceUnavailable, got)
+}
+
+func TestSender_Post_refusesPrivateTargets(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	// the server listens on loopback, which is only found once connecting
+	_, err := NewSender(time.Second, false).Post(context.TODO(), server.URL, "client secret", 7, "booking.requested", []byte(`{}`))
+	assert.True(t, errors.Is(err, ErrPrivateTarget), err)
+	assert.Equal(t, 0, requests)
+
+	_, err = NewSender(time.Second, true).Post(context.TODO(), server.URL, "client secret", 7, "booking.requested", []byte(`{}`))
+	assert.Equal(t, ErrInsecureURL, err)
+}
+
+func TestSender_Post_doesNotFollowRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data", http.StatusFound)
+	}))
+	defer server.Close()
+
+	got, err := NewUnrestrictedSender(time.Second).Post(context.TODO(), server.URL, "client secret", 7, "booking.requested", []byte(`{}`))
+	assert.EqualError(t, err, "endpoint answered 302")
+	assert.Equal(t, http.StatusFound, got)
+}
+
+func TestSender_CheckURL(t *testing.T) {
+	lookup := func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		switch host {
+		case "coop.example":
+			return []net.IPAddr{{IP: net.ParseIP("203.0.113.10")}}, nil
+		case "internal.coop.example":
+			return []net.IPAddr{{IP: net.ParseIP("203.0.113.10")}, {IP: net.ParseIP("10.0.0.5")}}, nil
+		case "localhost":
+			return []net.IPAddr{{IP: net.ParseIP("127.0.0.1")}}, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	tests := []struct {
+		name         string
+		url          string
+		requireHTTPS bool
+		wantErr      error
+	}{
+		{"when the host is public", "https://coop.example/hooks", true, nil},
+		{"when the address is public", "https://203.0.113.10/hooks", true, nil},
+		{"when http is allowed", "http://coop.example/hooks", false, nil},
+		{"when https is required", "http://coop.example/hooks", true, ErrInsecureURL},
+		{"when the scheme is not http", "ftp://coop.example/hooks", false, ErrInvalidURL},
+		{"when there is no host", "https:///hooks", false, ErrInvalidURL},
+		{"when the host does not resolve", "https://unknown.example/hooks", true, ErrUnresolvable},
+		{"when the host resolves to loopback", "https://localhost:8002/hooks", true, ErrPrivateTarget},
+		{"when any address of the host is private", "https://internal.coop.example/hooks", true, ErrPrivateTarget},
+		{"when the address is loopback", "https://127.0.0.1/hooks", true, ErrPrivateTarget},
+		{"when the address is IPv6 loopback", "https://[::1]/hooks", true, ErrPrivateTarget},
+		{"when the address is private", "https://192.168.1.20/hooks", true, ErrPrivateTarget},
+		{"when the address is the metadata service", "http://169.254.169.254/latest/meta-data", false, ErrPrivateTarget},
+		{"when the address is unspecified", "https://0.0.0.0/hooks", true, ErrPrivateTarget},
+		{"when the address is carrier-grade NAT", "https://100.64.0.1/hooks", true, ErrPrivateTarget},
+		{"when the address is IPv4 mapped loopback", "https://[::ffff:127.0.0.1]/hooks", true, ErrPrivateTarget},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := NewSender(time.Second, tt.requireHTTPS)
+			sender.lookup = lookup
+
+			assert.Equal(t, tt.wantErr, sender.CheckURL(context.TODO(), tt.url))
+		})
+	}
+}